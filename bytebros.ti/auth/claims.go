@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var ErrTokenInvalido = errors.New("token inválido")
+
+// Claims são as claims tipadas do access token.
+type Claims struct {
+	Tipo  string `json:"tipo"`
+	UID   int    `json:"uid"`
+	Email string `json:"email"`
+	Papel Papel  `json:"papel"`
+	jwt.RegisteredClaims
+}
+
+// NovasClaims monta as claims de um access token para o principal.
+func NovasClaims(p Principal, jti string, ttl time.Duration) *Claims {
+	agora := time.Now()
+	return &Claims{
+		Tipo:  p.Tipo,
+		UID:   p.ID,
+		Email: p.Email,
+		Papel: p.Papel,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   p.Sujeito(),
+			IssuedAt:  jwt.NewNumericDate(agora),
+			ExpiresAt: jwt.NewNumericDate(agora.Add(ttl)),
+		},
+	}
+}
+
+// Principal devolve o titular descrito pelas claims.
+func (c *Claims) Principal() Principal {
+	return Principal{Tipo: c.Tipo, ID: c.UID, Email: c.Email, Papel: c.Papel}
+}
+
+// Assinar gera o JWT HS256 com as claims.
+func Assinar(claims *Claims, segredo []byte) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(segredo)
+}
+
+// Validar confere assinatura, expiração e campos obrigatórios do token.
+func Validar(tokenString string, segredo []byte) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return segredo, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrTokenInvalido
+	}
+	if claims.ID == "" || claims.Tipo == "" || claims.UID == 0 || claims.Papel == "" || claims.ExpiresAt == nil {
+		return nil, ErrTokenInvalido
+	}
+	return claims, nil
+}
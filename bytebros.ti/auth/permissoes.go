@@ -0,0 +1,72 @@
+package auth
+
+// Catálogo de permissões da área administrativa.
+const (
+	PermProdutosWrite      = "produtos:write"
+	PermEstoqueRead        = "estoque:read"
+	PermEstoqueWrite       = "estoque:write"
+	PermAvaliacoesModerate = "avaliacoes:moderate"
+	PermServicosWrite      = "servicos:write"
+	PermNoticiasPublish    = "noticias:publish"
+	PermPedidosRead        = "pedidos:read"
+	PermPedidosWrite       = "pedidos:write"
+	PermOrcamentosRead     = "orcamentos:read"
+	PermOrcamentosWrite    = "orcamentos:write"
+	PermSuporteRead        = "suporte:read"
+	PermSuporteWrite       = "suporte:write"
+	PermUsuariosRead       = "usuarios:read"
+	PermFuncionariosRead   = "funcionarios:read"
+	PermDashboardRead      = "dashboard:read"
+	PermAdminManage        = "admin:manage"
+	PermPermissoesManage   = "permissoes:manage"
+)
+
+// Permissao descreve uma entrada do catálogo.
+type Permissao struct {
+	Codigo    string `json:"codigo"`
+	Descricao string `json:"descricao"`
+}
+
+// Catalogo lista todas as permissões conhecidas pela aplicação.
+var Catalogo = []Permissao{
+	{PermProdutosWrite, "Criar, editar e excluir produtos"},
+	{PermEstoqueRead, "Consultar movimentos e relatórios de estoque"},
+	{PermEstoqueWrite, "Lançar entradas, ajustes e perdas de estoque"},
+	{PermAvaliacoesModerate, "Aprovar e ocultar avaliações de produtos"},
+	{PermServicosWrite, "Criar, editar e excluir serviços"},
+	{PermNoticiasPublish, "Publicar, editar e excluir notícias"},
+	{PermPedidosRead, "Consultar pedidos de loja"},
+	{PermPedidosWrite, "Alterar status, cancelar e estornar pedidos de loja"},
+	{PermOrcamentosRead, "Consultar orçamentos"},
+	{PermOrcamentosWrite, "Alterar status e excluir orçamentos"},
+	{PermSuporteRead, "Consultar mensagens de suporte"},
+	{PermSuporteWrite, "Alterar status e excluir mensagens de suporte"},
+	{PermUsuariosRead, "Consultar clientes"},
+	{PermFuncionariosRead, "Consultar funcionários"},
+	{PermDashboardRead, "Acessar o painel administrativo"},
+	{PermAdminManage, "Criar e excluir administradores"},
+	{PermPermissoesManage, "Editar as permissões de cada papel"},
+}
+
+// Papeis lista os papéis existentes, do menor para o maior acesso.
+var Papeis = []Papel{PapelCliente, PapelFuncionario, PapelTecnico, PapelAdmin, PapelSuperAdmin}
+
+// PermissaoExiste informa se o código faz parte do catálogo.
+func PermissaoExiste(codigo string) bool {
+	for _, p := range Catalogo {
+		if p.Codigo == codigo {
+			return true
+		}
+	}
+	return false
+}
+
+// PapelExiste informa se o papel é conhecido.
+func PapelExiste(papel Papel) bool {
+	for _, p := range Papeis {
+		if p == papel {
+			return true
+		}
+	}
+	return false
+}
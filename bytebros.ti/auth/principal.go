@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Papel é o nível de acesso de quem está autenticado.
+type Papel string
+
+const (
+	PapelCliente     Papel = "cliente"
+	PapelFuncionario Papel = "funcionario"
+	PapelTecnico     Papel = "tecnico"
+	PapelAdmin       Papel = "admin"
+	PapelSuperAdmin  Papel = "superadmin"
+)
+
+// Tipos de conta, conforme a tabela de onde vem o titular.
+const (
+	TipoUsuario     = "usuario"
+	TipoFuncionario = "funcionario"
+	TipoAdmin       = "admin"
+)
+
+// Principal identifica quem fez a requisição, independentemente de a conta
+// estar em usuarios, funcionarios ou admin.
+type Principal struct {
+	Tipo  string `json:"tipo"`
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+	Papel Papel  `json:"papel"`
+}
+
+// Sujeito devolve o identificador usado na claim "sub", ex.: "usuario:12".
+func (p Principal) Sujeito() string {
+	return fmt.Sprintf("%s:%d", p.Tipo, p.ID)
+}
+
+// EhAdmin informa se o principal tem acesso à área administrativa.
+func (p Principal) EhAdmin() bool {
+	return p.Papel == PapelAdmin || p.Papel == PapelSuperAdmin
+}
+
+// EhEquipe informa se o principal é funcionário da loja (qualquer cargo).
+func (p Principal) EhEquipe() bool {
+	return p.Papel != PapelCliente && p.Papel != ""
+}
+
+// TemPapel informa se o principal tem algum dos papéis informados.
+func (p Principal) TemPapel(papeis ...Papel) bool {
+	for _, papel := range papeis {
+		if p.Papel == papel {
+			return true
+		}
+	}
+	return false
+}
+
+// PapelDoCargo converte o cargo livre de um funcionário em papel. Cargos de
+// administração não dão acesso de admin: esses papéis vêm só da tabela admin.
+func PapelDoCargo(cargo string) Papel {
+	c := strings.ToLower(strings.TrimSpace(cargo))
+	if strings.HasPrefix(c, "tecnico") || strings.HasPrefix(c, "técnico") {
+		return PapelTecnico
+	}
+	return PapelFuncionario
+}
+
+// PapelDoAdmin converte a flag is_admin da tabela admin em papel.
+func PapelDoAdmin(isAdmin bool) Papel {
+	if isAdmin {
+		return PapelSuperAdmin
+	}
+	return PapelAdmin
+}
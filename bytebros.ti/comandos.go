@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"bytebros.ti/database"
+)
+
+const usoComandos = `Uso: bytebros [comando]
+
+Sem comando, aplica as migrações pendentes e inicia o servidor.
+
+Comandos:
+  migrate           aplica as migrações pendentes
+  rollback [passos] reverte as últimas migrações aplicadas (padrão: 1)
+  status            lista as migrações e se já foram aplicadas
+`
+
+// executarComando roda um subcomando de linha de comando e devolve o código
+// de saída do processo.
+func executarComando(args []string) int {
+	switch args[0] {
+	case "migrate":
+		if err := database.Migrar(); err != nil {
+			log.Printf("Erro ao aplicar migrações: %v", err)
+			return 1
+		}
+	case "rollback":
+		passos := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				log.Printf("Número de passos inválido: %s", args[1])
+				return 2
+			}
+			passos = n
+		}
+		if err := database.Reverter(passos); err != nil {
+			log.Printf("Erro ao reverter migrações: %v", err)
+			return 1
+		}
+	case "status":
+		estados, err := database.StatusMigracoes()
+		if err != nil {
+			log.Printf("Erro ao consultar migrações: %v", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSÃO\tNOME\tESTADO\tAPLICADA EM")
+		for _, e := range estados {
+			estado, aplicadaEm := "pendente", "-"
+			if e.Aplicada {
+				estado = "aplicada"
+				aplicadaEm = e.AplicadaEm.Format("2006-01-02 15:04:05")
+			}
+			if e.Alterada {
+				estado = "alterada"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", e.Versao, e.Nome, estado, aplicadaEm)
+		}
+		w.Flush()
+	case "help", "-h", "--help":
+		fmt.Print(usoComandos)
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n%s", args[0], usoComandos)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,296 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var arquivosMigracoes embed.FS
+
+// Migracao é um par de scripts up/down identificado pela versão numérica do
+// nome do arquivo (ex.: 0003_pedido_status_historico.up.sql).
+type Migracao struct {
+	Versao   int
+	Nome     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// EstadoMigracao descreve uma migração conhecida e se ela já foi aplicada.
+type EstadoMigracao struct {
+	Versao     int
+	Nome       string
+	Aplicada   bool
+	AplicadaEm *time.Time
+	// Alterada indica que o script up mudou depois de aplicado.
+	Alterada bool
+}
+
+const criarSchemaMigrations = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		versao INTEGER PRIMARY KEY,
+		nome VARCHAR(200) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		aplicada_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+// carregarMigracoes lê os scripts embutidos e os devolve em ordem de versão.
+func carregarMigracoes() ([]Migracao, error) {
+	entradas, err := fs.ReadDir(arquivosMigracoes, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	porVersao := make(map[int]*Migracao)
+	for _, e := range entradas {
+		arquivo := e.Name()
+
+		var direcao string
+		switch {
+		case strings.HasSuffix(arquivo, ".up.sql"):
+			direcao = "up"
+		case strings.HasSuffix(arquivo, ".down.sql"):
+			direcao = "down"
+		default:
+			return nil, fmt.Errorf("arquivo de migração sem .up.sql ou .down.sql: %s", arquivo)
+		}
+
+		base := strings.TrimSuffix(arquivo, "."+direcao+".sql")
+		prefixo, nome, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("nome de migração inválido: %s", arquivo)
+		}
+		versao, err := strconv.Atoi(prefixo)
+		if err != nil || versao <= 0 {
+			return nil, fmt.Errorf("versão de migração inválida: %s", arquivo)
+		}
+
+		conteudo, err := arquivosMigracoes.ReadFile(path.Join("migrations", arquivo))
+		if err != nil {
+			return nil, err
+		}
+		// O checksum ignora \r para não mudar conforme o final de linha do checkout.
+		script := strings.ReplaceAll(string(conteudo), "\r", "")
+
+		m := porVersao[versao]
+		if m == nil {
+			m = &Migracao{Versao: versao, Nome: nome}
+			porVersao[versao] = m
+		}
+		if m.Nome != nome {
+			return nil, fmt.Errorf("migração %d com nomes diferentes: %s e %s", versao, m.Nome, nome)
+		}
+		if direcao == "up" {
+			m.Up = script
+			soma := sha256.Sum256([]byte(script))
+			m.Checksum = hex.EncodeToString(soma[:])
+		} else {
+			m.Down = script
+		}
+	}
+
+	migracoes := make([]Migracao, 0, len(porVersao))
+	for _, m := range porVersao {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migração %04d_%s precisa de scripts up e down", m.Versao, m.Nome)
+		}
+		migracoes = append(migracoes, *m)
+	}
+	sort.Slice(migracoes, func(i, j int) bool { return migracoes[i].Versao < migracoes[j].Versao })
+	return migracoes, nil
+}
+
+type migracaoAplicada struct {
+	checksum   string
+	aplicadaEm time.Time
+}
+
+func migracoesAplicadas(db *sql.DB) (map[int]migracaoAplicada, error) {
+	rows, err := db.Query(`SELECT versao, checksum, aplicada_em FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aplicadas := make(map[int]migracaoAplicada)
+	for rows.Next() {
+		var versao int
+		var a migracaoAplicada
+		if err := rows.Scan(&versao, &a.checksum, &a.aplicadaEm); err != nil {
+			return nil, err
+		}
+		aplicadas[versao] = a
+	}
+	return aplicadas, rows.Err()
+}
+
+// Migrar aplica, em ordem, todas as migrações pendentes. Cada migração roda
+// em sua própria transação junto com o registro em schema_migrations. Se o
+// script de uma migração já aplicada tiver sido alterado, nada é executado.
+func Migrar() error {
+	migracoes, err := carregarMigracoes()
+	if err != nil {
+		return err
+	}
+	if _, err := DB.Exec(criarSchemaMigrations); err != nil {
+		return fmt.Errorf("erro ao criar schema_migrations: %w", err)
+	}
+
+	aplicadas, err := migracoesAplicadas(DB)
+	if err != nil {
+		return err
+	}
+	for _, m := range migracoes {
+		if a, ok := aplicadas[m.Versao]; ok && a.checksum != m.Checksum {
+			return fmt.Errorf("migração %04d_%s foi alterada depois de aplicada (checksum diferente)", m.Versao, m.Nome)
+		}
+	}
+
+	pendentes := 0
+	for _, m := range migracoes {
+		if _, ok := aplicadas[m.Versao]; ok {
+			continue
+		}
+		if err := aplicarMigracao(m); err != nil {
+			return err
+		}
+		pendentes++
+	}
+
+	if pendentes == 0 {
+		log.Println("Banco de dados já está atualizado")
+	} else {
+		log.Printf("%d migração(ões) aplicada(s) com sucesso", pendentes)
+	}
+	return nil
+}
+
+func aplicarMigracao(m Migracao) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Impede que duas instâncias subindo juntas apliquem a mesma migração.
+	if _, err := tx.Exec(`LOCK TABLE schema_migrations IN EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	var jaAplicada bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE versao = $1)`, m.Versao).Scan(&jaAplicada); err != nil {
+		return err
+	}
+	if jaAplicada {
+		return nil
+	}
+
+	log.Printf("Aplicando migração %04d_%s...", m.Versao, m.Nome)
+	if _, err := tx.Exec(m.Up); err != nil {
+		return fmt.Errorf("erro ao aplicar migração %04d_%s: %w", m.Versao, m.Nome, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (versao, nome, checksum) VALUES ($1, $2, $3)`, m.Versao, m.Nome, m.Checksum); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reverter desfaz as últimas `passos` migrações aplicadas, da mais recente
+// para a mais antiga.
+func Reverter(passos int) error {
+	if passos <= 0 {
+		return fmt.Errorf("número de passos deve ser positivo")
+	}
+
+	migracoes, err := carregarMigracoes()
+	if err != nil {
+		return err
+	}
+	if _, err := DB.Exec(criarSchemaMigrations); err != nil {
+		return fmt.Errorf("erro ao criar schema_migrations: %w", err)
+	}
+	aplicadas, err := migracoesAplicadas(DB)
+	if err != nil {
+		return err
+	}
+
+	conhecidas := make(map[int]Migracao, len(migracoes))
+	for _, m := range migracoes {
+		conhecidas[m.Versao] = m
+	}
+
+	versoes := make([]int, 0, len(aplicadas))
+	for v := range aplicadas {
+		versoes = append(versoes, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versoes)))
+	if passos > len(versoes) {
+		passos = len(versoes)
+	}
+
+	for _, v := range versoes[:passos] {
+		m, ok := conhecidas[v]
+		if !ok {
+			return fmt.Errorf("migração %d está aplicada mas não existe nesta versão do código", v)
+		}
+
+		tx, err := DB.Begin()
+		if err != nil {
+			return err
+		}
+		log.Printf("Revertendo migração %04d_%s...", m.Versao, m.Nome)
+		if _, err := tx.Exec(m.Down); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("erro ao reverter migração %04d_%s: %w", m.Versao, m.Nome, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE versao = $1`, m.Versao); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("%d migração(ões) revertida(s) com sucesso", passos)
+	return nil
+}
+
+// StatusMigracoes lista todas as migrações conhecidas com o seu estado.
+func StatusMigracoes() ([]EstadoMigracao, error) {
+	migracoes, err := carregarMigracoes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DB.Exec(criarSchemaMigrations); err != nil {
+		return nil, fmt.Errorf("erro ao criar schema_migrations: %w", err)
+	}
+	aplicadas, err := migracoesAplicadas(DB)
+	if err != nil {
+		return nil, err
+	}
+
+	estados := make([]EstadoMigracao, 0, len(migracoes))
+	for _, m := range migracoes {
+		e := EstadoMigracao{Versao: m.Versao, Nome: m.Nome}
+		if a, ok := aplicadas[m.Versao]; ok {
+			aplicadaEm := a.aplicadaEm
+			e.Aplicada = true
+			e.AplicadaEm = &aplicadaEm
+			e.Alterada = a.checksum != m.Checksum
+		}
+		estados = append(estados, e)
+	}
+	return estados, nil
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS orcamentos;
+DROP TABLE IF EXISTS pedido_itens;
+DROP TABLE IF EXISTS pedidos;
+DROP TABLE IF EXISTS admin;
+DROP TABLE IF EXISTS suporte;
+DROP TABLE IF EXISTS servicos;
+DROP TABLE IF EXISTS noticias;
+DROP TABLE IF EXISTS produtos;
+DROP TABLE IF EXISTS funcionarios;
+DROP TABLE IF EXISTS usuarios;
@@ -0,0 +1,116 @@
+-- Esquema original do CreateTables. Usa IF NOT EXISTS para que bancos criados
+-- antes das migrações passem por aqui sem erro.
+CREATE TABLE IF NOT EXISTS usuarios (
+	id SERIAL PRIMARY KEY,
+	nome_completo VARCHAR(100) NOT NULL,
+	email VARCHAR(100) NOT NULL UNIQUE,
+	senha_hash VARCHAR(100) NOT NULL,
+	telefone VARCHAR(20) NOT NULL,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_usuarios_email ON usuarios(email);
+
+CREATE TABLE IF NOT EXISTS funcionarios (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	cargo VARCHAR(50) NOT NULL,
+	email VARCHAR(100) NOT NULL UNIQUE,
+	senha_hash VARCHAR(100) NOT NULL,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_funcionarios_email ON funcionarios(email);
+CREATE INDEX IF NOT EXISTS idx_funcionarios_cargo ON funcionarios(cargo);
+
+CREATE TABLE IF NOT EXISTS produtos (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	quantidade INTEGER NOT NULL DEFAULT 0,
+	preco DECIMAL(10,2) NOT NULL,
+	oferta BOOLEAN NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS idx_produtos_oferta ON produtos(oferta);
+CREATE INDEX IF NOT EXISTS idx_produtos_nome ON produtos(nome);
+
+CREATE TABLE IF NOT EXISTS noticias (
+	id SERIAL PRIMARY KEY,
+	titulo VARCHAR(150) NOT NULL,
+	subtitulo VARCHAR(300) NOT NULL,
+	conteudo TEXT NOT NULL,
+	autor VARCHAR(100) NOT NULL,
+	data TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_noticias_data ON noticias(data);
+
+CREATE TABLE IF NOT EXISTS servicos (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	preco DECIMAL(10,2) NOT NULL,
+	oferta BOOLEAN NOT NULL DEFAULT false,
+	detalhes TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_servicos_oferta ON servicos(oferta);
+CREATE INDEX IF NOT EXISTS idx_servicos_nome ON servicos(nome);
+
+CREATE TABLE IF NOT EXISTS suporte (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	email VARCHAR(100) NOT NULL,
+	mensagem TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'aberto',
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_suporte_status ON suporte(status);
+CREATE INDEX IF NOT EXISTS idx_suporte_email ON suporte(email);
+
+CREATE TABLE IF NOT EXISTS admin (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	email VARCHAR(100) NOT NULL UNIQUE,
+	senha_hash VARCHAR(100) NOT NULL,
+	is_admin BOOLEAN NOT NULL DEFAULT false,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_administradores_email ON admin(email);
+
+CREATE TABLE IF NOT EXISTS pedidos (
+	id SERIAL PRIMARY KEY,
+	cliente_email VARCHAR(100) NOT NULL,
+	data_pedido TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	status VARCHAR(50) NOT NULL,
+	endereco_entrega TEXT NOT NULL,
+	tipo_frete VARCHAR(50) NOT NULL,
+	valor_frete DECIMAL(10,2) NOT NULL,
+	valor_total DECIMAL(10,2) NOT NULL,
+	forma_pagamento VARCHAR(50) NOT NULL,
+	prazo_entrega VARCHAR(100)
+);
+CREATE INDEX IF NOT EXISTS idx_pedidos_cliente_email ON pedidos(cliente_email);
+CREATE INDEX IF NOT EXISTS idx_pedidos_status ON pedidos(status);
+
+CREATE TABLE IF NOT EXISTS pedido_itens (
+	id SERIAL PRIMARY KEY,
+	pedido_id INTEGER NOT NULL,
+	produto_id INTEGER NOT NULL,
+	nome_produto VARCHAR(100) NOT NULL,
+	quantidade INTEGER NOT NULL,
+	valor_unitario DECIMAL(10,2) NOT NULL,
+	FOREIGN KEY (pedido_id) REFERENCES pedidos(id) ON DELETE CASCADE,
+	FOREIGN KEY (produto_id) REFERENCES produtos(id) ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_pedido_itens_pedido_id ON pedido_itens(pedido_id);
+
+CREATE TABLE IF NOT EXISTS orcamentos (
+	id SERIAL PRIMARY KEY,
+	nome_cliente VARCHAR(100) NOT NULL,
+	email_cliente VARCHAR(100) NOT NULL,
+	descricao TEXT NOT NULL,
+	servico_nome VARCHAR(100),
+	status VARCHAR(50) NOT NULL DEFAULT 'pendente',
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_orcamentos_email_cliente ON orcamentos(email_cliente);
+CREATE INDEX IF NOT EXISTS idx_orcamentos_status ON orcamentos(status);
+CREATE INDEX IF NOT EXISTS idx_orcamentos_criado_em ON orcamentos(criado_em);
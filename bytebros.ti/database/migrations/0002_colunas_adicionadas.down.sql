@@ -0,0 +1,8 @@
+ALTER TABLE orcamentos DROP COLUMN IF EXISTS telefone;
+ALTER TABLE pedidos DROP COLUMN IF EXISTS criado_em;
+DROP INDEX IF EXISTS idx_suporte_tipo_interacao;
+DROP INDEX IF EXISTS idx_suporte_cliente_email;
+ALTER TABLE suporte DROP COLUMN IF EXISTS cliente_email;
+ALTER TABLE suporte DROP COLUMN IF EXISTS tipo_interacao;
+ALTER TABLE produtos DROP COLUMN IF EXISTS imagem;
+ALTER TABLE produtos DROP COLUMN IF EXISTS detalhes;
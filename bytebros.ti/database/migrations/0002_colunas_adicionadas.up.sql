@@ -0,0 +1,25 @@
+-- Colunas que foram acrescentadas ao CreateTables depois que as tabelas já
+-- existiam em produção e por isso nunca chegaram aos bancos antigos.
+ALTER TABLE produtos ADD COLUMN IF NOT EXISTS detalhes TEXT;
+ALTER TABLE produtos ADD COLUMN IF NOT EXISTS imagem VARCHAR(255);
+
+ALTER TABLE suporte ADD COLUMN IF NOT EXISTS tipo_interacao VARCHAR(50) NOT NULL DEFAULT 'suporte';
+ALTER TABLE suporte ADD COLUMN IF NOT EXISTS cliente_email VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_suporte_cliente_email ON suporte(cliente_email);
+CREATE INDEX IF NOT EXISTS idx_suporte_tipo_interacao ON suporte(tipo_interacao);
+
+ALTER TABLE pedidos ADD COLUMN IF NOT EXISTS criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+-- Orçamentos antigos ficam com telefone vazio.
+ALTER TABLE orcamentos ADD COLUMN IF NOT EXISTS telefone VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE orcamentos ALTER COLUMN telefone DROP DEFAULT;
+
+-- Bancos antigos criaram a tabela admin com a coluna "senha".
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'admin' AND column_name = 'senha')
+		AND NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'admin' AND column_name = 'senha_hash') THEN
+		ALTER TABLE admin RENAME COLUMN senha TO senha_hash;
+	END IF;
+END
+$$;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS pedido_status_historico;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS pedido_status_historico (
+	id SERIAL PRIMARY KEY,
+	pedido_id INTEGER NOT NULL,
+	status_anterior VARCHAR(50),
+	status_novo VARCHAR(50) NOT NULL,
+	alterado_por VARCHAR(100) NOT NULL,
+	observacao TEXT,
+	alterado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (pedido_id) REFERENCES pedidos(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_pedido_status_historico_pedido_id ON pedido_status_historico(pedido_id);
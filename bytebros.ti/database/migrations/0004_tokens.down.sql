@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tokens_revogados;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id SERIAL PRIMARY KEY,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	tipo VARCHAR(20) NOT NULL,
+	sujeito_id INTEGER NOT NULL,
+	expira_em TIMESTAMP NOT NULL,
+	revogado_em TIMESTAMP,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_sujeito ON refresh_tokens(tipo, sujeito_id);
+
+CREATE TABLE IF NOT EXISTS tokens_revogados (
+	jti VARCHAR(64) PRIMARY KEY,
+	expira_em TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tokens_revogados_expira_em ON tokens_revogados(expira_em);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS redefinicoes_senha;
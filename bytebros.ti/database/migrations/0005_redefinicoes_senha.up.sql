@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS redefinicoes_senha (
+	id SERIAL PRIMARY KEY,
+	usuario_id INTEGER NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	expira_em TIMESTAMP NOT NULL,
+	usado_em TIMESTAMP,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_redefinicoes_senha_usuario_id ON redefinicoes_senha(usuario_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS papel_permissoes;
+DROP TABLE IF EXISTS permissoes;
@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS permissoes (
+	codigo VARCHAR(50) PRIMARY KEY,
+	descricao VARCHAR(200) NOT NULL
+);
+INSERT INTO permissoes (codigo, descricao) VALUES
+	('produtos:write', 'Criar, editar e excluir produtos'),
+	('servicos:write', 'Criar, editar e excluir serviços'),
+	('noticias:publish', 'Publicar, editar e excluir notícias'),
+	('pedidos:read', 'Consultar pedidos de loja'),
+	('pedidos:write', 'Alterar status e excluir pedidos de loja'),
+	('orcamentos:read', 'Consultar orçamentos'),
+	('orcamentos:write', 'Alterar status e excluir orçamentos'),
+	('suporte:read', 'Consultar mensagens de suporte'),
+	('suporte:write', 'Alterar status e excluir mensagens de suporte'),
+	('usuarios:read', 'Consultar clientes'),
+	('funcionarios:read', 'Consultar funcionários'),
+	('dashboard:read', 'Acessar o painel administrativo'),
+	('admin:manage', 'Criar e excluir administradores'),
+	('permissoes:manage', 'Editar as permissões de cada papel')
+ON CONFLICT (codigo) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS papel_permissoes (
+	papel VARCHAR(20) NOT NULL,
+	permissao VARCHAR(50) NOT NULL REFERENCES permissoes(codigo) ON DELETE CASCADE,
+	PRIMARY KEY (papel, permissao)
+);
+-- Permissões padrão, gravadas apenas na primeira execução para não
+-- desfazer ajustes feitos pelos superadmins.
+INSERT INTO papel_permissoes (papel, permissao)
+SELECT v.papel, v.permissao FROM (VALUES
+	('funcionario', 'pedidos:read'),
+	('funcionario', 'orcamentos:read'),
+	('funcionario', 'suporte:read'),
+	('tecnico', 'orcamentos:read'),
+	('tecnico', 'orcamentos:write'),
+	('tecnico', 'suporte:read'),
+	('tecnico', 'suporte:write'),
+	('admin', 'produtos:write'),
+	('admin', 'servicos:write'),
+	('admin', 'noticias:publish'),
+	('admin', 'pedidos:read'),
+	('admin', 'pedidos:write'),
+	('admin', 'orcamentos:read'),
+	('admin', 'orcamentos:write'),
+	('admin', 'suporte:read'),
+	('admin', 'suporte:write'),
+	('admin', 'usuarios:read'),
+	('admin', 'funcionarios:read'),
+	('admin', 'dashboard:read')
+) AS v(papel, permissao)
+WHERE NOT EXISTS (SELECT 1 FROM papel_permissoes);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_usuarios_nome_completo;
+DROP INDEX IF EXISTS idx_suporte_criado_em;
+DROP INDEX IF EXISTS idx_pedidos_data_pedido;
@@ -0,0 +1,4 @@
+-- Ordenação padrão das listagens paginadas.
+CREATE INDEX IF NOT EXISTS idx_pedidos_data_pedido ON pedidos(data_pedido);
+CREATE INDEX IF NOT EXISTS idx_suporte_criado_em ON suporte(criado_em);
+CREATE INDEX IF NOT EXISTS idx_usuarios_nome_completo ON usuarios(nome_completo);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS produto_categorias;
+DROP TABLE IF EXISTS categorias;
@@ -0,0 +1,16 @@
+-- Categorias aninhadas (pai_id) e a ligação N:N com produtos.
+CREATE TABLE IF NOT EXISTS categorias (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	slug VARCHAR(120) NOT NULL UNIQUE,
+	pai_id INTEGER REFERENCES categorias(id) ON DELETE RESTRICT,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_categorias_pai_id ON categorias(pai_id);
+
+CREATE TABLE IF NOT EXISTS produto_categorias (
+	produto_id INTEGER NOT NULL REFERENCES produtos(id) ON DELETE CASCADE,
+	categoria_id INTEGER NOT NULL REFERENCES categorias(id) ON DELETE CASCADE,
+	PRIMARY KEY (produto_id, categoria_id)
+);
+CREATE INDEX IF NOT EXISTS idx_produto_categorias_categoria_id ON produto_categorias(categoria_id);
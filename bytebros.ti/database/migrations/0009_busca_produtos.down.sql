@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_produtos_nome_trgm;
+DROP INDEX IF EXISTS idx_produtos_busca;
+ALTER TABLE produtos DROP COLUMN IF EXISTS busca;
+DROP FUNCTION IF EXISTS sem_acento(TEXT);
@@ -0,0 +1,18 @@
+-- Busca textual de produtos: tsvector em português sem acentos e trigramas
+-- no nome para tolerar erros de digitação.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() não é IMMUTABLE e por isso não pode ir em colunas geradas nem
+-- em índices; fixar o dicionário torna o resultado estável.
+CREATE OR REPLACE FUNCTION sem_acento(texto TEXT) RETURNS TEXT
+	LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+	AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, texto) $$;
+
+ALTER TABLE produtos ADD COLUMN IF NOT EXISTS busca TSVECTOR
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('portuguese', sem_acento(coalesce(nome, ''))), 'A') ||
+		setweight(to_tsvector('portuguese', sem_acento(coalesce(detalhes, ''))), 'B')
+	) STORED;
+CREATE INDEX IF NOT EXISTS idx_produtos_busca ON produtos USING GIN (busca);
+CREATE INDEX IF NOT EXISTS idx_produtos_nome_trgm ON produtos USING GIN (sem_acento(lower(nome)) gin_trgm_ops);
@@ -0,0 +1,14 @@
+-- Várias imagens por produto, cada uma com as URLs e as chaves no storage
+-- de todas as versões (original, miniatura, media, grande).
+CREATE TABLE IF NOT EXISTS produto_imagens (
+	id SERIAL PRIMARY KEY,
+	produto_id INTEGER NOT NULL REFERENCES produtos(id) ON DELETE CASCADE,
+	ordem INTEGER NOT NULL DEFAULT 0,
+	urls JSONB NOT NULL,
+	chaves JSONB NOT NULL,
+	content_type VARCHAR(50) NOT NULL,
+	largura INTEGER NOT NULL,
+	altura INTEGER NOT NULL,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_produto_imagens_produto_ordem ON produto_imagens(produto_id, ordem);
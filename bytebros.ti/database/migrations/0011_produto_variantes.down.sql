@@ -0,0 +1,4 @@
+ALTER TABLE pedido_itens ALTER COLUMN nome_produto TYPE VARCHAR(100) USING left(nome_produto, 100);
+ALTER TABLE pedido_itens DROP COLUMN IF EXISTS sku;
+ALTER TABLE pedido_itens DROP COLUMN IF EXISTS variante_id;
+DROP TABLE IF EXISTS produto_variantes;
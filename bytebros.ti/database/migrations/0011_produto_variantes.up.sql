@@ -0,0 +1,17 @@
+-- Variantes vendáveis de um produto, com estoque e preço próprios, e o
+-- vínculo dos itens de pedido com a variante comprada.
+CREATE TABLE IF NOT EXISTS produto_variantes (
+	id SERIAL PRIMARY KEY,
+	produto_id INTEGER NOT NULL REFERENCES produtos(id) ON DELETE CASCADE,
+	sku VARCHAR(64) NOT NULL UNIQUE,
+	atributos JSONB NOT NULL DEFAULT '{}',
+	preco DECIMAL(10,2),
+	quantidade INTEGER NOT NULL DEFAULT 0,
+	criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_produto_variantes_produto_id ON produto_variantes(produto_id);
+
+ALTER TABLE pedido_itens ADD COLUMN IF NOT EXISTS variante_id INTEGER REFERENCES produto_variantes(id) ON DELETE RESTRICT;
+ALTER TABLE pedido_itens ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+-- O nome do item passa a incluir os atributos da variante.
+ALTER TABLE pedido_itens ALTER COLUMN nome_produto TYPE VARCHAR(200);
@@ -0,0 +1,3 @@
+DELETE FROM permissoes WHERE codigo IN ('estoque:read', 'estoque:write');
+DROP TABLE IF EXISTS movimentos_estoque;
+DROP FUNCTION IF EXISTS movimentos_estoque_somente_insercao();
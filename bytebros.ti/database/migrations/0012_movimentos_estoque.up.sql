@@ -0,0 +1,61 @@
+-- Livro-razão do estoque: cada mudança de quantidade vira uma linha, com
+-- tipo, motivo e responsável. A soma dos movimentos é o estoque.
+CREATE TABLE IF NOT EXISTS movimentos_estoque (
+	id SERIAL PRIMARY KEY,
+	produto_id INTEGER NOT NULL REFERENCES produtos(id) ON DELETE CASCADE,
+	variante_id INTEGER REFERENCES produto_variantes(id) ON DELETE SET NULL,
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('entrada', 'saida_pedido', 'ajuste', 'devolucao', 'perda')),
+	quantidade INTEGER NOT NULL CHECK (quantidade <> 0),
+	saldo INTEGER NOT NULL,
+	saldo_variante INTEGER,
+	motivo VARCHAR(255) NOT NULL,
+	pedido_id INTEGER REFERENCES pedidos(id) ON DELETE SET NULL,
+	usuario VARCHAR(100) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_movimentos_estoque_produto_data ON movimentos_estoque(produto_id, criado_em);
+CREATE INDEX IF NOT EXISTS idx_movimentos_estoque_criado_em ON movimentos_estoque(criado_em);
+
+-- Só inserções: UPDATE e DELETE diretos são recusados. As ações das chaves
+-- estrangeiras (CASCADE e SET NULL) rodam dentro de outro gatilho, com
+-- pg_trigger_depth() maior que 1, e continuam permitidas.
+CREATE OR REPLACE FUNCTION movimentos_estoque_somente_insercao() RETURNS trigger AS $$
+BEGIN
+	IF pg_trigger_depth() < 2 THEN
+		RAISE EXCEPTION 'movimentos_estoque não pode ser alterada nem apagada';
+	END IF;
+	IF TG_OP = 'DELETE' THEN
+		RETURN OLD;
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movimentos_estoque_somente_insercao ON movimentos_estoque;
+CREATE TRIGGER movimentos_estoque_somente_insercao
+	BEFORE UPDATE OR DELETE ON movimentos_estoque
+	FOR EACH ROW EXECUTE FUNCTION movimentos_estoque_somente_insercao();
+
+-- Saldo de abertura: o estoque atual de cada variante e, no produto, o que
+-- não está em variantes.
+INSERT INTO movimentos_estoque (produto_id, variante_id, tipo, quantidade, saldo, saldo_variante, motivo, usuario)
+SELECT v.produto_id, v.id, 'ajuste', v.quantidade, p.quantidade, v.quantidade, 'Saldo inicial', 'sistema'
+FROM produto_variantes v
+JOIN produtos p ON p.id = v.produto_id
+WHERE v.quantidade <> 0;
+
+INSERT INTO movimentos_estoque (produto_id, tipo, quantidade, saldo, motivo, usuario)
+SELECT p.id, 'ajuste', p.quantidade - COALESCE(v.total, 0), p.quantidade, 'Saldo inicial', 'sistema'
+FROM produtos p
+LEFT JOIN (SELECT produto_id, SUM(quantidade) AS total FROM produto_variantes GROUP BY produto_id) v ON v.produto_id = p.id
+WHERE p.quantidade - COALESCE(v.total, 0) <> 0;
+
+INSERT INTO permissoes (codigo, descricao) VALUES
+	('estoque:read', 'Consultar movimentos e relatórios de estoque'),
+	('estoque:write', 'Lançar entradas, ajustes e perdas de estoque')
+ON CONFLICT (codigo) DO NOTHING;
+INSERT INTO papel_permissoes (papel, permissao) VALUES
+	('funcionario', 'estoque:read'),
+	('admin', 'estoque:read'),
+	('admin', 'estoque:write')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS avisos_estoque;
+DROP TABLE IF EXISTS notificacoes_admin;
+ALTER TABLE produtos DROP COLUMN IF EXISTS estoque_minimo;
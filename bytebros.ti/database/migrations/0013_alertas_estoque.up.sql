@@ -0,0 +1,28 @@
+-- Ponto de reposição por produto: com quantidade igual ou abaixo dele, o
+-- monitor de estoque abre um alerta.
+ALTER TABLE produtos ADD COLUMN IF NOT EXISTS estoque_minimo INTEGER NOT NULL DEFAULT 0 CHECK (estoque_minimo >= 0);
+
+CREATE TABLE IF NOT EXISTS notificacoes_admin (
+	id SERIAL PRIMARY KEY,
+	tipo VARCHAR(30) NOT NULL,
+	produto_id INTEGER REFERENCES produtos(id) ON DELETE CASCADE,
+	titulo VARCHAR(200) NOT NULL,
+	mensagem TEXT NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	lida_em TIMESTAMP,
+	lida_por VARCHAR(100),
+	resolvida_em TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_notificacoes_admin_criado_em ON notificacoes_admin(criado_em);
+-- No máximo um alerta aberto por tipo e produto.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notificacoes_admin_abertas ON notificacoes_admin(tipo, produto_id) WHERE resolvida_em IS NULL;
+
+-- Inscrições "avise-me quando chegar". O email é gravado em minúsculas.
+CREATE TABLE IF NOT EXISTS avisos_estoque (
+	id SERIAL PRIMARY KEY,
+	produto_id INTEGER NOT NULL REFERENCES produtos(id) ON DELETE CASCADE,
+	email VARCHAR(100) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	notificado_em TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_avisos_estoque_pendentes ON avisos_estoque(produto_id, email) WHERE notificado_em IS NULL;
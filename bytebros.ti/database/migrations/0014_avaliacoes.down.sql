@@ -0,0 +1,2 @@
+DELETE FROM permissoes WHERE codigo = 'avaliacoes:moderate';
+DROP TABLE IF EXISTS avaliacoes;
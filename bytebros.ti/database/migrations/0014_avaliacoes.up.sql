@@ -0,0 +1,25 @@
+-- Avaliações de produtos por clientes que receberam o produto. Entram na
+-- loja só depois de aprovadas.
+CREATE TABLE IF NOT EXISTS avaliacoes (
+	id SERIAL PRIMARY KEY,
+	produto_id INTEGER NOT NULL REFERENCES produtos(id) ON DELETE CASCADE,
+	cliente_email VARCHAR(100) NOT NULL,
+	autor VARCHAR(100) NOT NULL,
+	nota SMALLINT NOT NULL CHECK (nota BETWEEN 1 AND 5),
+	comentario TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pendente' CHECK (status IN ('pendente', 'aprovada', 'oculta')),
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	moderado_em TIMESTAMP,
+	moderado_por VARCHAR(100),
+	UNIQUE (produto_id, cliente_email)
+);
+CREATE INDEX IF NOT EXISTS idx_avaliacoes_produto_status ON avaliacoes(produto_id, status);
+CREATE INDEX IF NOT EXISTS idx_avaliacoes_status_criado_em ON avaliacoes(status, criado_em);
+
+INSERT INTO permissoes (codigo, descricao) VALUES
+	('avaliacoes:moderate', 'Aprovar e ocultar avaliações de produtos')
+ON CONFLICT (codigo) DO NOTHING;
+INSERT INTO papel_permissoes (papel, permissao) VALUES
+	('funcionario', 'avaliacoes:moderate'),
+	('admin', 'avaliacoes:moderate')
+ON CONFLICT DO NOTHING;
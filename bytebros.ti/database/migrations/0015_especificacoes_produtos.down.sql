@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_produtos_componente;
+ALTER TABLE produtos DROP COLUMN IF EXISTS especificacoes;
@@ -0,0 +1,4 @@
+-- Atributos técnicos dos componentes de PC (socket, chipset, memória,
+-- TDP, formato, potência), usados pela verificação de montagem.
+ALTER TABLE produtos ADD COLUMN IF NOT EXISTS especificacoes JSONB;
+CREATE INDEX IF NOT EXISTS idx_produtos_componente ON produtos((especificacoes->>'componente'));
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS historico_precos;
+ALTER TABLE servicos
+	DROP COLUMN IF EXISTS promocao_fim,
+	DROP COLUMN IF EXISTS promocao_inicio,
+	DROP COLUMN IF EXISTS preco_promocional;
+ALTER TABLE produtos
+	DROP COLUMN IF EXISTS promocao_fim,
+	DROP COLUMN IF EXISTS promocao_inicio,
+	DROP COLUMN IF EXISTS preco_promocional;
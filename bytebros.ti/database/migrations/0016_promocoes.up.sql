@@ -0,0 +1,38 @@
+-- Preço promocional com vigência em produtos e serviços. As datas usam
+-- TIMESTAMPTZ porque vêm do painel com fuso; o agendador de promoções liga
+-- e desliga oferta conforme a vigência.
+ALTER TABLE produtos
+	ADD COLUMN IF NOT EXISTS preco_promocional DECIMAL(10,2) CHECK (preco_promocional > 0),
+	ADD COLUMN IF NOT EXISTS promocao_inicio TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS promocao_fim TIMESTAMPTZ;
+ALTER TABLE servicos
+	ADD COLUMN IF NOT EXISTS preco_promocional DECIMAL(10,2) CHECK (preco_promocional > 0),
+	ADD COLUMN IF NOT EXISTS promocao_inicio TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS promocao_fim TIMESTAMPTZ;
+
+-- Uma linha por alteração de preço ou promoção, de um produto ou de um
+-- serviço.
+CREATE TABLE IF NOT EXISTS historico_precos (
+	id SERIAL PRIMARY KEY,
+	produto_id INTEGER REFERENCES produtos(id) ON DELETE CASCADE,
+	servico_id INTEGER REFERENCES servicos(id) ON DELETE CASCADE,
+	preco DECIMAL(10,2) NOT NULL,
+	preco_promocional DECIMAL(10,2),
+	promocao_inicio TIMESTAMPTZ,
+	promocao_fim TIMESTAMPTZ,
+	alterado_por VARCHAR(100) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK ((produto_id IS NULL) <> (servico_id IS NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_historico_precos_produto ON historico_precos(produto_id, criado_em) WHERE produto_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_historico_precos_servico ON historico_precos(servico_id, criado_em) WHERE servico_id IS NOT NULL;
+
+-- Preço de abertura de cada produto e serviço.
+INSERT INTO historico_precos (produto_id, preco, alterado_por)
+SELECT p.id, p.preco, 'migracao'
+FROM produtos p
+WHERE NOT EXISTS (SELECT 1 FROM historico_precos h WHERE h.produto_id = p.id);
+INSERT INTO historico_precos (servico_id, preco, alterado_por)
+SELECT s.id, s.preco, 'migracao'
+FROM servicos s
+WHERE NOT EXISTS (SELECT 1 FROM historico_precos h WHERE h.servico_id = s.id);
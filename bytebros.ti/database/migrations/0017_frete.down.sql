@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS cotacoes_frete;
+
+ALTER TABLE produtos
+	DROP COLUMN IF EXISTS comprimento_cm,
+	DROP COLUMN IF EXISTS largura_cm,
+	DROP COLUMN IF EXISTS altura_cm,
+	DROP COLUMN IF EXISTS peso_g;
@@ -0,0 +1,26 @@
+-- Peso e medidas do produto embalado, usados na cotação de frete. Zero
+-- significa "não informado" e a cotação usa a embalagem padrão.
+ALTER TABLE produtos
+	ADD COLUMN IF NOT EXISTS peso_g INTEGER NOT NULL DEFAULT 0 CHECK (peso_g >= 0),
+	ADD COLUMN IF NOT EXISTS altura_cm INTEGER NOT NULL DEFAULT 0 CHECK (altura_cm >= 0),
+	ADD COLUMN IF NOT EXISTS largura_cm INTEGER NOT NULL DEFAULT 0 CHECK (largura_cm >= 0),
+	ADD COLUMN IF NOT EXISTS comprimento_cm INTEGER NOT NULL DEFAULT 0 CHECK (comprimento_cm >= 0);
+
+-- Cada opção devolvida por POST /api/frete/cotacao. O pedido informa o id
+-- e o servidor confere valor, prazo, validade e itens; pedido_id marca a
+-- cotação como usada.
+CREATE TABLE IF NOT EXISTS cotacoes_frete (
+	id CHAR(32) PRIMARY KEY,
+	cep_destino CHAR(8) NOT NULL,
+	transportadora VARCHAR(30) NOT NULL,
+	servico VARCHAR(30) NOT NULL,
+	nome VARCHAR(100) NOT NULL,
+	valor DECIMAL(10,2) NOT NULL CHECK (valor >= 0),
+	prazo_dias INTEGER NOT NULL CHECK (prazo_dias >= 0),
+	prazo_entrega VARCHAR(10) NOT NULL,
+	itens TEXT NOT NULL,
+	pedido_id INTEGER REFERENCES pedidos(id) ON DELETE CASCADE,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expira_em TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cotacoes_frete_expira_em ON cotacoes_frete(expira_em);
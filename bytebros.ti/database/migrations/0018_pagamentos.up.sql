@@ -0,0 +1,19 @@
+-- Cobranças dos pedidos. Um pedido pode ter várias (um Pix expirado e
+-- depois outro, por exemplo); txid e end_to_end_id identificam o Pix no
+-- PSP e no Banco Central.
+CREATE TABLE IF NOT EXISTS pagamentos (
+	id SERIAL PRIMARY KEY,
+	pedido_id INTEGER NOT NULL REFERENCES pedidos(id) ON DELETE CASCADE,
+	metodo VARCHAR(20) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	valor DECIMAL(10,2) NOT NULL CHECK (valor > 0),
+	txid VARCHAR(35) UNIQUE,
+	copia_e_cola TEXT,
+	end_to_end_id VARCHAR(32) UNIQUE,
+	expira_em TIMESTAMP,
+	pago_em TIMESTAMP,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	atualizado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pagamentos_pedido ON pagamentos(pedido_id);
+CREATE INDEX IF NOT EXISTS idx_pagamentos_pendentes ON pagamentos(expira_em) WHERE status = 'pendente';
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS transacoes_pagamento;
+
+ALTER TABLE pagamentos
+	DROP COLUMN IF EXISTS valor_estornado,
+	DROP COLUMN IF EXISTS final_cartao,
+	DROP COLUMN IF EXISTS bandeira,
+	DROP COLUMN IF EXISTS valor_parcela,
+	DROP COLUMN IF EXISTS parcelas,
+	DROP COLUMN IF EXISTS gateway_id,
+	DROP COLUMN IF EXISTS gateway;
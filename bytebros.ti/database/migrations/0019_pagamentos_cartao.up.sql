@@ -0,0 +1,24 @@
+-- Cobranças no cartão: a autorização no gateway, o parcelamento e o total
+-- já estornado. Valor passa a incluir os juros do parcelamento.
+ALTER TABLE pagamentos
+	ADD COLUMN IF NOT EXISTS gateway VARCHAR(30),
+	ADD COLUMN IF NOT EXISTS gateway_id VARCHAR(100),
+	ADD COLUMN IF NOT EXISTS parcelas INTEGER,
+	ADD COLUMN IF NOT EXISTS valor_parcela DECIMAL(10,2),
+	ADD COLUMN IF NOT EXISTS bandeira VARCHAR(30),
+	ADD COLUMN IF NOT EXISTS final_cartao VARCHAR(4),
+	ADD COLUMN IF NOT EXISTS valor_estornado DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Cada chamada ao gateway, aprovada ou não, para auditoria e conciliação.
+CREATE TABLE IF NOT EXISTS transacoes_pagamento (
+	id SERIAL PRIMARY KEY,
+	pagamento_id INTEGER NOT NULL REFERENCES pagamentos(id) ON DELETE CASCADE,
+	tipo VARCHAR(20) NOT NULL,
+	valor DECIMAL(10,2) NOT NULL,
+	sucesso BOOLEAN NOT NULL,
+	gateway_id VARCHAR(100),
+	mensagem TEXT,
+	usuario VARCHAR(255) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_transacoes_pagamento_pagamento ON transacoes_pagamento(pagamento_id);
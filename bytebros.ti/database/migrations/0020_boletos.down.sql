@@ -0,0 +1,8 @@
+ALTER TABLE pagamentos
+	DROP COLUMN IF EXISTS documento_pagador,
+	DROP COLUMN IF EXISTS vencimento,
+	DROP COLUMN IF EXISTS codigo_barras,
+	DROP COLUMN IF EXISTS linha_digitavel,
+	DROP COLUMN IF EXISTS nosso_numero;
+
+DROP SEQUENCE IF EXISTS nosso_numero_seq;
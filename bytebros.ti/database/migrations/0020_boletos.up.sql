@@ -0,0 +1,10 @@
+-- Cobranças por boleto. O nosso número vem de uma sequência própria, e
+-- o arquivo de retorno do banco o usa para achar a cobrança paga.
+CREATE SEQUENCE IF NOT EXISTS nosso_numero_seq;
+
+ALTER TABLE pagamentos
+	ADD COLUMN IF NOT EXISTS nosso_numero CHAR(11) UNIQUE,
+	ADD COLUMN IF NOT EXISTS linha_digitavel VARCHAR(60),
+	ADD COLUMN IF NOT EXISTS codigo_barras CHAR(44),
+	ADD COLUMN IF NOT EXISTS vencimento DATE,
+	ADD COLUMN IF NOT EXISTS documento_pagador VARCHAR(14);
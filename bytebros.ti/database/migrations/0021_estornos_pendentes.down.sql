@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_pagamentos_estornos_pendentes;
+
+ALTER TABLE pagamentos
+	DROP COLUMN IF EXISTS estorno_usuario,
+	DROP COLUMN IF EXISTS estorno_motivo,
+	DROP COLUMN IF EXISTS estorno_referencia,
+	DROP COLUMN IF EXISTS estorno_pendente;
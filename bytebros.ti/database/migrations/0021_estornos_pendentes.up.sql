@@ -0,0 +1,9 @@
+-- Estorno de cartão em andamento. O estorno é gravado aqui antes da
+-- chamada ao gateway, com a chave de idempotência, e retomado pela
+-- expiração de pagamentos se a resposta não chegar a ser gravada.
+ALTER TABLE pagamentos
+	ADD COLUMN IF NOT EXISTS estorno_pendente DECIMAL(10,2) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS estorno_referencia VARCHAR(64),
+	ADD COLUMN IF NOT EXISTS estorno_motivo VARCHAR(255),
+	ADD COLUMN IF NOT EXISTS estorno_usuario VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_pagamentos_estornos_pendentes ON pagamentos(atualizado_em) WHERE status = 'estorno_pendente';
@@ -0,0 +1,12 @@
+-- Volta ao status legado os pedidos que não mudaram desde a conversão.
+UPDATE pedidos p SET status = h.status_anterior
+FROM pedido_status_historico h
+WHERE h.pedido_id = p.id
+	AND h.alterado_por = 'migracao'
+	AND h.observacao = 'Status legado convertido para o ciclo de vida do pedido'
+	AND p.status = h.status_novo
+	AND NOT EXISTS (
+		SELECT 1 FROM pedido_status_historico depois
+		WHERE depois.pedido_id = p.id AND depois.id > h.id
+	);
+DELETE FROM pedido_status_historico WHERE alterado_por = 'migracao' AND observacao = 'Status legado convertido para o ciclo de vida do pedido';
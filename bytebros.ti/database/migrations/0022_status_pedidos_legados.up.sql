@@ -0,0 +1,38 @@
+-- Pedidos gravados antes do ciclo de vida têm o status em texto livre
+-- ("pendente", "enviado"...), sem transição permitida. Cada um vai para o
+-- status equivalente, com o valor antigo no histórico; o que não tem
+-- equivalente fica "Processando", para a equipe revisar.
+WITH legados AS (
+	SELECT id, status AS anterior,
+		CASE lower(trim(status))
+			WHEN 'processando' THEN 'Processando'
+			WHEN 'pendente' THEN 'Processando'
+			WHEN 'novo' THEN 'Processando'
+			WHEN 'aguardando pagamento' THEN 'Processando'
+			WHEN 'pago' THEN 'Pago'
+			WHEN 'aprovado' THEN 'Pago'
+			WHEN 'separando' THEN 'Separando'
+			WHEN 'em separação' THEN 'Separando'
+			WHEN 'em separacao' THEN 'Separando'
+			WHEN 'enviado' THEN 'Enviado'
+			WHEN 'despachado' THEN 'Enviado'
+			WHEN 'em transporte' THEN 'Enviado'
+			WHEN 'entregue' THEN 'Entregue'
+			WHEN 'concluído' THEN 'Entregue'
+			WHEN 'concluido' THEN 'Entregue'
+			WHEN 'cancelado' THEN 'Cancelado'
+			WHEN 'devolvido' THEN 'Devolvido'
+			ELSE 'Processando'
+		END AS novo
+	FROM pedidos
+	WHERE status NOT IN ('Processando', 'Pago', 'Separando', 'Enviado', 'Entregue', 'Cancelado', 'Devolvido')
+), convertidos AS (
+	UPDATE pedidos p SET status = l.novo
+	FROM legados l
+	WHERE p.id = l.id
+	RETURNING p.id
+)
+INSERT INTO pedido_status_historico (pedido_id, status_anterior, status_novo, alterado_por, observacao)
+SELECT l.id, l.anterior, l.novo, 'migracao', 'Status legado convertido para o ciclo de vida do pedido'
+FROM legados l
+JOIN convertidos c ON c.id = l.id;
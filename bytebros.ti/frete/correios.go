@@ -0,0 +1,134 @@
+package frete
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServicoCorreios é um serviço contratado, como {"03220", "SEDEX"}.
+type ServicoCorreios struct {
+	Codigo string
+	Nome   string
+}
+
+// Correios cota pelas APIs de preço e prazo dos Correios (CWS), uma
+// chamada de cada por serviço.
+type Correios struct {
+	// URL é a base da API, como "https://api.correios.com.br", ou a de um
+	// stub local.
+	URL      string
+	Token    string
+	Servicos []ServicoCorreios
+
+	// Cliente permite trocar o http.Client; nil usa um com timeout de 10s.
+	Cliente *http.Client
+}
+
+func (c *Correios) Nome() string { return "correios" }
+
+// Cotar devolve os serviços que responderam; só é erro se todos falharem.
+func (c *Correios) Cotar(ctx context.Context, q Consulta) ([]Opcao, error) {
+	var opcoes []Opcao
+	var ultimoErro error
+	for _, s := range c.Servicos {
+		valor, err := c.preco(ctx, s.Codigo, q)
+		if err != nil {
+			ultimoErro = fmt.Errorf("preço do serviço %s: %w", s.Codigo, err)
+			continue
+		}
+		prazo, err := c.prazo(ctx, s.Codigo, q)
+		if err != nil {
+			ultimoErro = fmt.Errorf("prazo do serviço %s: %w", s.Codigo, err)
+			continue
+		}
+		opcoes = append(opcoes, Opcao{
+			Transportadora: c.Nome(),
+			Servico:        strings.ToLower(s.Nome),
+			Nome:           s.Nome,
+			Valor:          valor,
+			PrazoDias:      prazo,
+		})
+	}
+	if len(opcoes) == 0 && ultimoErro != nil {
+		return nil, ultimoErro
+	}
+	return opcoes, nil
+}
+
+func (c *Correios) preco(ctx context.Context, codigo string, q Consulta) (float64, error) {
+	p := q.Pacote
+	params := url.Values{
+		"cepOrigem":   {q.CEPOrigem},
+		"cepDestino":  {q.CEPDestino},
+		"psObjeto":    {strconv.Itoa(p.PesoG)},
+		"tpObjeto":    {"2"}, // pacote
+		"altura":      {strconv.Itoa(p.AlturaCm)},
+		"largura":     {strconv.Itoa(p.LarguraCm)},
+		"comprimento": {strconv.Itoa(p.ComprimentoCm)},
+	}
+	if p.ValorDeclarado > 0 {
+		params.Set("vlDeclarado", strconv.FormatFloat(p.ValorDeclarado, 'f', 2, 64))
+	}
+	var resp struct {
+		PcFinal string `json:"pcFinal"`
+	}
+	if err := c.consultar(ctx, "/preco/v1/nacional/"+url.PathEscape(codigo), params, &resp); err != nil {
+		return 0, err
+	}
+	// A API devolve o valor no formato brasileiro, como "1.038,50".
+	texto := strings.ReplaceAll(strings.ReplaceAll(resp.PcFinal, ".", ""), ",", ".")
+	valor, err := strconv.ParseFloat(texto, 64)
+	if err != nil || valor < 0 {
+		return 0, fmt.Errorf("pcFinal inválido: %q", resp.PcFinal)
+	}
+	return valor, nil
+}
+
+func (c *Correios) prazo(ctx context.Context, codigo string, q Consulta) (int, error) {
+	params := url.Values{"cepOrigem": {q.CEPOrigem}, "cepDestino": {q.CEPDestino}}
+	var resp struct {
+		PrazoEntrega *int `json:"prazoEntrega"`
+	}
+	if err := c.consultar(ctx, "/prazo/v1/nacional/"+url.PathEscape(codigo), params, &resp); err != nil {
+		return 0, err
+	}
+	if resp.PrazoEntrega == nil || *resp.PrazoEntrega < 0 {
+		return 0, fmt.Errorf("resposta sem prazoEntrega")
+	}
+	return *resp.PrazoEntrega, nil
+}
+
+func (c *Correios) consultar(ctx context.Context, caminho string, params url.Values, destino any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+caminho+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+
+	cliente := c.Cliente
+	if cliente == nil {
+		cliente = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detalhe, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(detalhe)))
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(destino); err != nil {
+		return fmt.Errorf("resposta inválida: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package frete
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubCorreios responde como a API CWS: preço em
+// /preco/v1/nacional/{codigo} e prazo em /prazo/v1/nacional/{codigo}.
+// Serviços fora de precos respondem 400, como um serviço não contratado.
+func stubCorreios(t *testing.T, precos map[string]string, prazos map[string]int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("método %s, esperado GET", r.Method)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token-teste" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		q := r.URL.Query()
+		if q.Get("cepOrigem") != "01310100" || q.Get("cepDestino") != "20040020" {
+			t.Errorf("CEPs = %s para %s", q.Get("cepOrigem"), q.Get("cepDestino"))
+		}
+
+		var codigo string
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/preco/v1/nacional/"):
+			codigo = strings.TrimPrefix(r.URL.Path, "/preco/v1/nacional/")
+			esperados := map[string]string{
+				"psObjeto": "3500", "tpObjeto": "2", "altura": "30", "largura": "20",
+				"comprimento": "40", "vlDeclarado": "2599.90",
+			}
+			for param, valor := range esperados {
+				if q.Get(param) != valor {
+					t.Errorf("%s = %q, esperado %q", param, q.Get(param), valor)
+				}
+			}
+			preco, ok := precos[codigo]
+			if !ok {
+				http.Error(w, `{"msgs":["Serviço não contratado"]}`, http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"coProduto": codigo, "pcFinal": preco})
+		case strings.HasPrefix(r.URL.Path, "/prazo/v1/nacional/"):
+			codigo = strings.TrimPrefix(r.URL.Path, "/prazo/v1/nacional/")
+			json.NewEncoder(w).Encode(map[string]int{"prazoEntrega": prazos[codigo]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func consultaTeste() Consulta {
+	return Consulta{
+		CEPOrigem:  "01310100",
+		CEPDestino: "20040020",
+		Pacote:     Pacote{PesoG: 3500, AlturaCm: 30, LarguraCm: 20, ComprimentoCm: 40, ValorDeclarado: 2599.9},
+	}
+}
+
+func TestCorreiosCotar(t *testing.T) {
+	srv := stubCorreios(t, map[string]string{"03220": "1.038,50", "03298": "42,10"}, map[string]int{"03220": 2, "03298": 7})
+	c := &Correios{
+		URL:      srv.URL,
+		Token:    "token-teste",
+		Servicos: []ServicoCorreios{{"03220", "SEDEX"}, {"03298", "PAC"}, {"04227", "Mini Envios"}},
+		Cliente:  srv.Client(),
+	}
+
+	opcoes, err := c.Cotar(context.Background(), consultaTeste())
+	if err != nil {
+		t.Fatalf("cotar: %v", err)
+	}
+	esperadas := []Opcao{
+		{Transportadora: "correios", Servico: "sedex", Nome: "SEDEX", Valor: 1038.5, PrazoDias: 2},
+		{Transportadora: "correios", Servico: "pac", Nome: "PAC", Valor: 42.1, PrazoDias: 7},
+	}
+	if len(opcoes) != len(esperadas) {
+		t.Fatalf("opções = %+v, esperado %+v (o serviço não contratado fica de fora)", opcoes, esperadas)
+	}
+	for i := range esperadas {
+		if opcoes[i] != esperadas[i] {
+			t.Errorf("opção %d = %+v, esperado %+v", i, opcoes[i], esperadas[i])
+		}
+	}
+}
+
+func TestCorreiosCotarTodosFalham(t *testing.T) {
+	srv := stubCorreios(t, nil, nil)
+	c := &Correios{URL: srv.URL, Token: "token-teste", Servicos: []ServicoCorreios{{"03220", "SEDEX"}}, Cliente: srv.Client()}
+
+	_, err := c.Cotar(context.Background(), consultaTeste())
+	if err == nil || !strings.Contains(err.Error(), "status 400") || !strings.Contains(err.Error(), "03220") {
+		t.Errorf("cotar sem serviços: %v, esperado o status 400 do serviço 03220", err)
+	}
+}
+
+func TestCorreiosPrecoInvalido(t *testing.T) {
+	srv := stubCorreios(t, map[string]string{"03220": "grátis"}, map[string]int{"03220": 2})
+	c := &Correios{URL: srv.URL, Token: "token-teste", Servicos: []ServicoCorreios{{"03220", "SEDEX"}}, Cliente: srv.Client()}
+
+	_, err := c.Cotar(context.Background(), consultaTeste())
+	if err == nil || !strings.Contains(err.Error(), "pcFinal inválido") {
+		t.Errorf("cotar com preço inválido: %v, esperado pcFinal inválido", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Package frete cota a entrega de um carrinho a partir do CEP de destino e
+// do peso e das medidas dos produtos, consultando uma ou mais
+// transportadoras.
+package frete
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Embalagem padrão dos produtos sem peso ou medidas cadastrados.
+const (
+	PesoPadraoG         = 1000
+	AlturaPadraoCm      = 10
+	LarguraPadraoCm     = 20
+	ComprimentoPadraoCm = 30
+)
+
+// divisorCubagem converte cm³ em kg de peso cubado (fator 6000 usado por
+// Correios e transportadoras).
+const divisorCubagem = 6000
+
+var (
+	ErrCEPInvalido = errors.New("CEP inválido")
+	// ErrSemOpcoes indica que nenhuma transportadora atende o destino ou
+	// respondeu à cotação.
+	ErrSemOpcoes = errors.New("nenhuma opção de frete disponível")
+)
+
+// Volume é um produto do carrinho. Medidas zeradas usam a embalagem padrão.
+type Volume struct {
+	PesoG         int
+	AlturaCm      int
+	LarguraCm     int
+	ComprimentoCm int
+	Quantidade    int
+}
+
+// Pacote é a remessa inteira, como a transportadora a mede.
+type Pacote struct {
+	PesoG         int
+	AlturaCm      int
+	LarguraCm     int
+	ComprimentoCm int
+	// ValorDeclarado é o valor dos produtos, para o seguro.
+	ValorDeclarado float64
+}
+
+// MontarPacote empilha os volumes numa caixa: as alturas se somam e a base
+// é a maior largura e o maior comprimento.
+func MontarPacote(volumes []Volume, valorDeclarado float64) Pacote {
+	p := Pacote{ValorDeclarado: valorDeclarado}
+	for _, v := range volumes {
+		p.PesoG += padrao(v.PesoG, PesoPadraoG) * v.Quantidade
+		p.AlturaCm += padrao(v.AlturaCm, AlturaPadraoCm) * v.Quantidade
+		p.LarguraCm = max(p.LarguraCm, padrao(v.LarguraCm, LarguraPadraoCm))
+		p.ComprimentoCm = max(p.ComprimentoCm, padrao(v.ComprimentoCm, ComprimentoPadraoCm))
+	}
+	return p
+}
+
+func padrao(valor, seZero int) int {
+	if valor > 0 {
+		return valor
+	}
+	return seZero
+}
+
+// PesoCobradoG é o maior entre o peso real e o peso cubado, em gramas.
+func (p Pacote) PesoCobradoG() int {
+	cubado := p.AlturaCm * p.LarguraCm * p.ComprimentoCm * 1000 / divisorCubagem
+	return max(p.PesoG, cubado)
+}
+
+// Consulta é o que se pede a uma transportadora.
+type Consulta struct {
+	CEPOrigem  string
+	CEPDestino string
+	Pacote     Pacote
+}
+
+// Opcao é um serviço de entrega cotado.
+type Opcao struct {
+	Transportadora string
+	Servico        string
+	Nome           string
+	Valor          float64
+	// PrazoDias é contado em dias úteis a partir da postagem.
+	PrazoDias int
+}
+
+// Transportadora cota a entrega de um pacote. Destinos não atendidos
+// devolvem lista vazia, não erro.
+type Transportadora interface {
+	Nome() string
+	Cotar(ctx context.Context, consulta Consulta) ([]Opcao, error)
+}
+
+// Cotador reúne as opções de várias transportadoras.
+type Cotador struct {
+	CEPOrigem       string
+	Transportadoras []Transportadora
+}
+
+// Cotar consulta as transportadoras em paralelo e devolve as opções da mais
+// barata para a mais cara. Uma transportadora que falha fica de fora da
+// cotação (o erro vai para o log); ErrSemOpcoes só sai quando nenhuma
+// opção sobra.
+func (c *Cotador) Cotar(ctx context.Context, cepDestino string, pacote Pacote) ([]Opcao, error) {
+	consulta := Consulta{CEPOrigem: c.CEPOrigem, CEPDestino: cepDestino, Pacote: pacote}
+
+	resultados := make([][]Opcao, len(c.Transportadoras))
+	var wg sync.WaitGroup
+	for i, t := range c.Transportadoras {
+		wg.Add(1)
+		go func(i int, t Transportadora) {
+			defer wg.Done()
+			opcoes, err := t.Cotar(ctx, consulta)
+			if err != nil {
+				log.Printf("AVISO: Falha ao cotar frete com %s: %v", t.Nome(), err)
+				return
+			}
+			resultados[i] = opcoes
+		}(i, t)
+	}
+	wg.Wait()
+
+	var todas []Opcao
+	for _, opcoes := range resultados {
+		todas = append(todas, opcoes...)
+	}
+	if len(todas) == 0 {
+		return nil, ErrSemOpcoes
+	}
+	sort.SliceStable(todas, func(i, j int) bool {
+		if todas[i].Valor != todas[j].Valor {
+			return todas[i].Valor < todas[j].Valor
+		}
+		return todas[i].PrazoDias < todas[j].PrazoDias
+	})
+	return todas, nil
+}
+
+// NormalizarCEP aceita "01310-100", "01310100" ou "01.310-100" e devolve
+// os oito dígitos.
+func NormalizarCEP(cep string) (string, error) {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(cep) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '.' || r == ' ':
+		default:
+			return "", ErrCEPInvalido
+		}
+	}
+	if b.Len() != 8 || b.String() == "00000000" {
+		return "", ErrCEPInvalido
+	}
+	return b.String(), nil
+}
+
+// DataEntrega soma dias úteis (segunda a sexta) à data.
+func DataEntrega(inicio time.Time, diasUteis int) time.Time {
+	d := inicio
+	for diasUteis > 0 {
+		d = d.AddDate(0, 0, 1)
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			diasUteis--
+		}
+	}
+	return d
+}
+
+// NovoDoAmbiente monta o Cotador a partir das variáveis de ambiente.
+//
+// A tabela local sempre participa: FRETE_TABELA aponta um JSON com as regras
+// e, sem ele, vale TabelaPadrao. CORREIOS_URL e CORREIOS_TOKEN ativam o
+// adaptador dos Correios, que exige FRETE_CEP_ORIGEM; CORREIOS_SERVICOS
+// escolhe os serviços ("03220:SEDEX,03298:PAC" por padrão).
+func NovoDoAmbiente() (*Cotador, error) {
+	c := &Cotador{}
+	if v := os.Getenv("FRETE_CEP_ORIGEM"); v != "" {
+		cep, err := NormalizarCEP(v)
+		if err != nil {
+			return nil, fmt.Errorf("FRETE_CEP_ORIGEM inválido: %q", v)
+		}
+		c.CEPOrigem = cep
+	}
+
+	tabela := &TabelaLocal{Regras: TabelaPadrao}
+	if caminho := os.Getenv("FRETE_TABELA"); caminho != "" {
+		t, err := CarregarTabela(caminho)
+		if err != nil {
+			return nil, err
+		}
+		tabela = t
+	}
+	c.Transportadoras = append(c.Transportadoras, tabela)
+
+	if url := os.Getenv("CORREIOS_URL"); url != "" {
+		correios := &Correios{
+			URL:   strings.TrimSuffix(url, "/"),
+			Token: os.Getenv("CORREIOS_TOKEN"),
+		}
+		if correios.Token == "" || c.CEPOrigem == "" {
+			return nil, fmt.Errorf("CORREIOS_TOKEN e FRETE_CEP_ORIGEM são obrigatórios com CORREIOS_URL")
+		}
+		servicos := os.Getenv("CORREIOS_SERVICOS")
+		if servicos == "" {
+			servicos = "03220:SEDEX,03298:PAC"
+		}
+		for _, s := range strings.Split(servicos, ",") {
+			codigo, nome, ok := strings.Cut(strings.TrimSpace(s), ":")
+			if !ok || codigo == "" || nome == "" {
+				return nil, fmt.Errorf("CORREIOS_SERVICOS inválido: %q", servicos)
+			}
+			correios.Servicos = append(correios.Servicos, ServicoCorreios{Codigo: codigo, Nome: nome})
+		}
+		c.Transportadoras = append(c.Transportadoras, correios)
+	}
+	return c, nil
+}
@@ -0,0 +1,98 @@
+package frete
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Regra é uma linha da tabela de frete da loja. Vale para destinos entre
+// CEPInicio e CEPFim (inclusive; vazios valem para todo o país) e pacotes
+// de até PesoMaxG gramas cobrados (0 = sem limite).
+type Regra struct {
+	Servico   string `json:"servico"`
+	Nome      string `json:"nome"`
+	CEPInicio string `json:"cep_inicio"`
+	CEPFim    string `json:"cep_fim"`
+	PesoMaxG  int    `json:"peso_max_g"`
+	// Valor cobre o primeiro quilo; cada quilo seguinte, ou fração, soma
+	// ValorKgAdicional.
+	Valor            float64 `json:"valor"`
+	ValorKgAdicional float64 `json:"valor_kg_adicional"`
+	PrazoDias        int     `json:"prazo_dias"`
+}
+
+// TabelaPadrao reproduz as modalidades que a loja sempre ofereceu, agora
+// com adicional por peso.
+var TabelaPadrao = []Regra{
+	{Servico: "padrao", Nome: "Entrega padrão", Valor: 25, ValorKgAdicional: 5, PrazoDias: 7},
+	{Servico: "expresso", Nome: "Entrega expressa", Valor: 45, ValorKgAdicional: 8, PrazoDias: 3},
+	{Servico: "retirada", Nome: "Retirada na loja", PrazoDias: 1},
+}
+
+// TabelaLocal cota pelas regras da própria loja, sem chamadas externas.
+type TabelaLocal struct {
+	Regras []Regra
+}
+
+// CarregarTabela lê as regras de um arquivo JSON (uma lista de Regra).
+func CarregarTabela(caminho string) (*TabelaLocal, error) {
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler a tabela de frete: %w", err)
+	}
+	var regras []Regra
+	if err := json.Unmarshal(conteudo, &regras); err != nil {
+		return nil, fmt.Errorf("tabela de frete inválida em %s: %w", caminho, err)
+	}
+	for i, r := range regras {
+		if r.Servico == "" || r.Nome == "" || r.Valor < 0 || r.ValorKgAdicional < 0 || r.PrazoDias < 0 {
+			return nil, fmt.Errorf("tabela de frete inválida em %s: regra %d incompleta", caminho, i+1)
+		}
+	}
+	return &TabelaLocal{Regras: regras}, nil
+}
+
+func (t *TabelaLocal) Nome() string { return "loja" }
+
+// Cotar usa, para cada serviço, a primeira regra que atende o destino e o
+// peso; por isso as faixas mais específicas vêm antes na tabela.
+func (t *TabelaLocal) Cotar(_ context.Context, q Consulta) ([]Opcao, error) {
+	peso := q.Pacote.PesoCobradoG()
+	vistos := make(map[string]bool)
+	var opcoes []Opcao
+	for _, r := range t.Regras {
+		if vistos[r.Servico] || !r.atende(q.CEPDestino, peso) {
+			continue
+		}
+		vistos[r.Servico] = true
+		opcoes = append(opcoes, Opcao{
+			Transportadora: t.Nome(),
+			Servico:        r.Servico,
+			Nome:           r.Nome,
+			Valor:          r.valor(peso),
+			PrazoDias:      r.PrazoDias,
+		})
+	}
+	return opcoes, nil
+}
+
+func (r Regra) atende(cep string, pesoG int) bool {
+	if r.CEPInicio != "" && cep < r.CEPInicio {
+		return false
+	}
+	if r.CEPFim != "" && cep > r.CEPFim {
+		return false
+	}
+	return r.PesoMaxG == 0 || pesoG <= r.PesoMaxG
+}
+
+func (r Regra) valor(pesoG int) float64 {
+	adicionais := math.Ceil(float64(pesoG-1000) / 1000)
+	if adicionais < 0 {
+		adicionais = 0
+	}
+	return math.Round((r.Valor+adicionais*r.ValorKgAdicional)*100) / 100
+}
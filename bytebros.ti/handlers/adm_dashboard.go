@@ -3,17 +3,18 @@ package handlers
 import (
 	"net/http"
 
+	"bytebros.ti/auth"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 func AdminDashboard(c *gin.Context) {
-	claims, _ := c.Get("jwt_claims")
-	jwtClaims := claims.(jwt.MapClaims)
+	principal, _ := principalAtual(c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"mensagem": "Bem-vindo ao painel administrativo",
-		"usuario":  jwtClaims["email"],
-		"is_admin": jwtClaims["is_admin"],
+		"usuario":  principal.Email,
+		"papel":    principal.Papel,
+		"is_admin": principal.Papel == auth.PapelSuperAdmin,
 	})
 }
@@ -1,18 +1,16 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"strings"
-	"time"
 
+	"bytebros.ti/auth"
 	"bytebros.ti/models"
+	"bytebros.ti/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -39,16 +37,17 @@ func RegistrarUsuario(c *gin.Context) {
 	}
 	log.Printf("DEBUG: Senha criptografada com sucesso.")
 
-	db := c.MustGet("db").(*sql.DB)
-
-	var newUser models.Usuario
-	err = db.QueryRow(`
-		INSERT INTO usuarios (nome_completo, email, senha_hash, telefone)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, nome_completo, email, telefone`,
-		user.Nome, user.Email, string(hashedPassword), user.Telefone).
-		Scan(&newUser.ID, &newUser.Nome, &newUser.Email, &newUser.Telefone)
-
+	newUser := models.Usuario{
+		Nome:      user.Nome,
+		Email:     user.Email,
+		Telefone:  user.Telefone,
+		SenhaHash: string(hashedPassword),
+	}
+	err = repos.Usuarios.Criar(&newUser)
+	if errors.Is(err, repository.ErrDuplicado) {
+		c.JSON(http.StatusConflict, gin.H{"erro": "Email já registrado"})
+		return
+	}
 	if err != nil {
 		log.Printf("ERRO BD: Falha ao inserir novo usuário: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar usuário", "detalhes": err.Error()})
@@ -56,7 +55,7 @@ func RegistrarUsuario(c *gin.Context) {
 	}
 	log.Printf("DEBUG: Usuário registrado com ID: %d. Nome após DB: '%s', Telefone após DB: '%s'", newUser.ID, newUser.Nome, newUser.Telefone)
 
-	token, err := generateJWTToken(newUser.ID, newUser.Email, "")
+	token, refreshToken, err := emitirTokens(auth.Principal{Tipo: auth.TipoUsuario, ID: newUser.ID, Email: newUser.Email, Papel: auth.PapelCliente})
 	if err != nil {
 		log.Printf("ERRO: Falha ao gerar token JWT para usuário %s: %v", newUser.Email, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar token"})
@@ -69,153 +68,144 @@ func RegistrarUsuario(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, models.LoginResponse{
-		ID:       newUser.ID,
-		Nome:     nomeParaResposta,
-		Email:    newUser.Email,
-		Token:    token,
-		Telefone: newUser.Telefone,
+		ID:           newUser.ID,
+		Nome:         nomeParaResposta,
+		Email:        newUser.Email,
+		Tipo:         auth.TipoUsuario,
+		Papel:        string(auth.PapelCliente),
+		Token:        token,
+		RefreshToken: refreshToken,
+		Telefone:     newUser.Telefone,
 	})
 	log.Printf("DEBUG: Resposta de registro de usuário enviada com sucesso.")
 }
 
-func LoginUsuario(c *gin.Context) {
-	log.Printf("DEBUG: Iniciando handler LoginUsuario.")
-	var login models.LoginRequest
-	if err := c.ShouldBindJSON(&login); err != nil {
-		log.Printf("ERRO: Falha ao fazer bind JSON para LoginUsuario: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
-		return
-	}
-	log.Printf("DEBUG: Tentativa de login para email: %s", login.Email)
-
-	db := c.MustGet("db").(*sql.DB)
-	var user models.Usuario
-	var senhaHashDB string
-	var telefoneDB sql.NullString // Temporário para ler o telefone do banco
+// contaLogin é uma conta candidata encontrada durante o login.
+type contaLogin struct {
+	principal auth.Principal
+	nome      string
+	telefone  string
+	cargo     string
+	senhaHash string
+}
 
-	log.Printf("DEBUG: Executando query SELECT para usuario com email %s.", login.Email)
-	err := db.QueryRow(`
-		SELECT id, nome_completo, email, senha_hash, telefone
-		FROM usuarios
-		WHERE email = $1`, login.Email).
-		Scan(&user.ID, &user.Nome, &user.Email, &senhaHashDB, &telefoneDB)
+// buscarContasLogin procura o email nas três tabelas de contas. Um mesmo
+// email pode existir em mais de uma delas; a senha decide qual conta entra.
+func buscarContasLogin(email string) ([]contaLogin, error) {
+	contas := make([]contaLogin, 0, 1)
 
-	if err != nil {
-		log.Printf("ERRO BD: Falha ao buscar usuário: %v", err)
-		handleAuthError(c, err)
-		return
+	admin, err := repos.Admins.ObterPorEmail(email)
+	if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+		return nil, fmt.Errorf("erro ao buscar administrador: %w", err)
+	}
+	if err == nil {
+		contas = append(contas, contaLogin{
+			principal: auth.Principal{Tipo: auth.TipoAdmin, ID: admin.ID, Email: admin.Email, Papel: auth.PapelDoAdmin(admin.IsAdmin)},
+			nome:      admin.Nome,
+			senhaHash: admin.SenhaHash,
+		})
 	}
 
-	user.Telefone = telefoneDB.String
+	funcionario, err := repos.Funcionarios.ObterPorEmail(email)
+	if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+		return nil, fmt.Errorf("erro ao buscar funcionário: %w", err)
+	}
+	if err == nil {
+		contas = append(contas, contaLogin{
+			principal: auth.Principal{Tipo: auth.TipoFuncionario, ID: funcionario.ID, Email: funcionario.Email, Papel: auth.PapelDoCargo(funcionario.Cargo)},
+			nome:      funcionario.Nome,
+			cargo:     funcionario.Cargo,
+			senhaHash: funcionario.SenhaHash,
+		})
+	}
 
-	nomeParaResposta := user.Nome
-	if nomeParaResposta == "" {
-		nomeParaResposta = "Usuário Padrão"
+	usuario, err := repos.Usuarios.ObterPorEmail(email)
+	if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if err == nil {
+		contas = append(contas, contaLogin{
+			principal: auth.Principal{Tipo: auth.TipoUsuario, ID: usuario.ID, Email: usuario.Email, Papel: auth.PapelCliente},
+			nome:      usuario.Nome,
+			telefone:  usuario.Telefone,
+			senhaHash: usuario.SenhaHash,
+		})
 	}
 
-	log.Printf("DEBUG: Usuario lido do DB: ID=%d, Nome='%s', Email='%s', Telefone='%s'", user.ID, nomeParaResposta, user.Email, user.Telefone)
+	return contas, nil
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(senhaHashDB), []byte(login.Senha)); err != nil {
-		log.Printf("ERRO: Senha incorreta para usuário %s", login.Email)
-		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Credenciais inválidas"})
+// Login autentica clientes, funcionários e administradores pelo mesmo
+// endpoint. O papel da conta vai no token e na resposta.
+func Login(c *gin.Context) {
+	var login models.LoginRequest
+	if err := c.ShouldBindJSON(&login); err != nil {
+		log.Printf("ERRO: Falha ao fazer bind JSON para Login: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
 		return
 	}
+	log.Printf("DEBUG: Tentativa de login para email: %s", login.Email)
 
-	token, err := generateJWTToken(user.ID, user.Email, "")
+	contas, err := buscarContasLogin(login.Email)
 	if err != nil {
-		log.Printf("ERRO: Falha ao gerar token JWT para usuário %s: %v", user.Email, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar token"})
+		log.Printf("ERRO BD: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao autenticar"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		ID:       user.ID,
-		Nome:     nomeParaResposta,
-		Email:    user.Email,
-		Token:    token,
-		Telefone: user.Telefone,
-	})
-	log.Printf("DEBUG: Resposta de login de usuário enviada com sucesso.")
-}
+	for _, conta := range contas {
+		if err := bcrypt.CompareHashAndPassword([]byte(conta.senhaHash), []byte(login.Senha)); err != nil {
+			continue
+		}
 
-func checkEmailExists(c *gin.Context, email, table string) error {
-	db := c.MustGet("db").(*sql.DB)
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE email = $1", email).Scan(&count)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar email"})
-		return err
-	}
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"erro": "Email já registrado"})
-		return err
-	}
-	return nil
-}
+		token, refreshToken, err := emitirTokens(conta.principal)
+		if err != nil {
+			log.Printf("ERRO: Falha ao gerar token JWT para %s: %v", conta.principal.Sujeito(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar token"})
+			return
+		}
 
-func generateJWTToken(id int, email, cargo string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": id,
-		"email":   email,
-		"exp":     time.Now().Add(time.Hour * 8).Unix(),
-	}
+		nomeParaResposta := conta.nome
+		if nomeParaResposta == "" {
+			nomeParaResposta = "Usuário Padrão"
+		}
 
-	if cargo != "" {
-		claims["cargo"] = cargo
+		c.JSON(http.StatusOK, models.LoginResponse{
+			ID:           conta.principal.ID,
+			Nome:         nomeParaResposta,
+			Email:        conta.principal.Email,
+			Tipo:         conta.principal.Tipo,
+			Papel:        string(conta.principal.Papel),
+			Cargo:        conta.cargo,
+			Token:        token,
+			RefreshToken: refreshToken,
+			Telefone:     conta.telefone,
+		})
+		log.Printf("DEBUG: Login de %s concluído com papel %s.", conta.principal.Sujeito(), conta.principal.Papel)
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-}
-
-func handleAuthError(c *gin.Context, err error) {
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Credenciais inválidas"})
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao autenticar"})
-	}
+	log.Printf("AVISO: Credenciais inválidas para %s", login.Email)
+	c.JSON(http.StatusUnauthorized, gin.H{"erro": "Credenciais inválidas"})
 }
 
 func ObterPerfil(c *gin.Context) {
-	claims, exists := c.Get("jwt_claims")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Usuário não autenticado"})
-		return
-	}
-
-	jwtClaims, ok := claims.(jwt.MapClaims)
+	principal, ok := principalAtual(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao obter informações do token"})
-		return
-	}
-
-	if cargo, exists := jwtClaims["cargo"]; exists {
-		c.JSON(http.StatusOK, gin.H{
-			"id":    jwtClaims["user_id"],
-			"email": jwtClaims["email"],
-			"cargo": cargo,
-			"tipo":  "funcionario",
-		})
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Usuário não autenticado"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":    jwtClaims["user_id"],
-		"email": jwtClaims["email"],
-		"tipo":  "usuario",
-	})
+	c.JSON(http.StatusOK, principal)
 }
 
 func AtualizarEmailUsuario(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	claims, exists := c.Get("jwt_claims")
-	if !exists {
+	claims, ok := claimsAtuais(c)
+	if !ok || claims.Tipo != auth.TipoUsuario {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Token JWT ausente ou inválido."})
 		return
 	}
-	jwtClaims := claims.(jwt.MapClaims)
-	emailLogado := jwtClaims["email"].(string)
+	emailLogado := claims.Email
 
 	var req models.AtualizarEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -233,42 +223,46 @@ func AtualizarEmailUsuario(c *gin.Context) {
 		return
 	}
 
-	var senhaHashDB string
-	var telefoneDBTemp sql.NullString // Temporário para ler telefone
-	var userID int
-	err := db.QueryRow(`SELECT id, senha_hash, telefone FROM usuarios WHERE email = $1`, emailLogado).Scan(&userID, &senhaHashDB, &telefoneDBTemp)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"erro": "Usuário não encontrado."})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar usuário."})
-		}
+	usuario, ok := usuarioLogado(c, emailLogado)
+	if !ok {
 		return
 	}
+	userID := usuario.ID
 
-	if err := bcrypt.CompareHashAndPassword([]byte(senhaHashDB), []byte(req.Senha)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(usuario.SenhaHash), []byte(req.Senha)); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Senha incorreta."})
 		return
 	}
 
-	var count int
-	err = db.QueryRow(`SELECT COUNT(*) FROM usuarios WHERE email = $1 AND id != $2`, req.NovoEmail, userID).Scan(&count)
+	emUso, err := repos.Usuarios.EmailEmUso(req.NovoEmail, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar novo email."})
 		return
 	}
-	if count > 0 {
+	if emUso {
 		c.JSON(http.StatusConflict, gin.H{"erro": "Este novo email já está em uso por outra conta."})
 		return
 	}
 
-	_, err = db.Exec(`UPDATE usuarios SET email = $1, atualizado_em = $2 WHERE id = $3`, req.NovoEmail, time.Now(), userID)
+	err = repos.Usuarios.AtualizarEmail(userID, req.NovoEmail)
+	if errors.Is(err, repository.ErrDuplicado) {
+		c.JSON(http.StatusConflict, gin.H{"erro": "Este novo email já está em uso por outra conta."})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar o email."})
 		return
 	}
 
-	newToken, err := generateJWTToken(userID, req.NovoEmail, "")
+	// Tokens emitidos para o email antigo deixam de valer.
+	if err := revogarAccessToken(claims); err != nil {
+		log.Printf("ERRO: Falha ao revogar token do usuário %d: %v", userID, err)
+	}
+	if err := revogarSessoes(auth.TipoUsuario, userID); err != nil {
+		log.Printf("ERRO: Falha ao revogar sessões do usuário %d: %v", userID, err)
+	}
+
+	newToken, newRefreshToken, err := emitirTokens(auth.Principal{Tipo: auth.TipoUsuario, ID: userID, Email: req.NovoEmail, Papel: auth.PapelCliente})
 	if err != nil {
 		log.Printf("ERRO: Falha ao gerar novo token JWT para usuário %d: %v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Email alterado, mas falha ao gerar novo token."})
@@ -276,22 +270,20 @@ func AtualizarEmailUsuario(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"mensagem":   "Email atualizado com sucesso! Por favor, use o novo email para futuros logins.",
-		"novo_email": req.NovoEmail,
-		"token":      newToken,
+		"mensagem":      "Email atualizado com sucesso! Por favor, use o novo email para futuros logins.",
+		"novo_email":    req.NovoEmail,
+		"token":         newToken,
+		"refresh_token": newRefreshToken,
 	})
 }
 
 func AtualizarTelefoneUsuario(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	claims, exists := c.Get("jwt_claims")
-	if !exists {
+	claims, ok := claimsAtuais(c)
+	if !ok || claims.Tipo != auth.TipoUsuario {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Token JWT ausente ou inválido."})
 		return
 	}
-	jwtClaims := claims.(jwt.MapClaims)
-	emailLogado := jwtClaims["email"].(string)
+	emailLogado := claims.Email
 
 	var req models.AtualizarTelefoneRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -304,26 +296,17 @@ func AtualizarTelefoneUsuario(c *gin.Context) {
 		return
 	}
 
-	var senhaHashDB string
-	var telefoneAtualDBTemp sql.NullString // Temporário para ler telefone do banco
-	var userID int
-	err := db.QueryRow(`SELECT id, senha_hash, telefone FROM usuarios WHERE email = $1`, emailLogado).Scan(&userID, &senhaHashDB, &telefoneAtualDBTemp)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"erro": "Usuário não encontrado."})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar usuário."})
-		}
+	usuario, ok := usuarioLogado(c, emailLogado)
+	if !ok {
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(senhaHashDB), []byte(req.Senha)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(usuario.SenhaHash), []byte(req.Senha)); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Senha incorreta."})
 		return
 	}
 
-	// Converte telefoneAtualDBTemp para string para a validação.
-	telefoneAtual := telefoneAtualDBTemp.String
+	telefoneAtual := usuario.Telefone
 
 	// Validação de correspondência
 	if telefoneAtual != req.TelefoneAtual && req.TelefoneAtual != "" {
@@ -332,12 +315,9 @@ func AtualizarTelefoneUsuario(c *gin.Context) {
 	}
 	// Se o telefone no DB é vazio e o usuário preencheu o campo "atual",
 	// isso pode ser uma tentativa de adicionar o primeiro telefone.
-	// Se a coluna telefone no DB é NOT NULL, o req.TelefoneAtual NÃO PODE SER VAZIO se telefoneAtual é vazio.
-	// O seu database.go atual para usuarios.telefone é VARCHAR(20) e NÃO NOT NULL.
-	// Então, telefoneAtual pode ser "".
+	// A coluna usuarios.telefone aceita NULL, então telefoneAtual pode ser "".
 
-	_, err = db.Exec(`UPDATE usuarios SET telefone = $1, atualizado_em = $2 WHERE id = $3`, req.NovoTelefone, time.Now(), userID)
-	if err != nil {
+	if err := repos.Usuarios.AtualizarTelefone(usuario.ID, req.NovoTelefone); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar o telefone."})
 		return
 	}
@@ -349,51 +329,31 @@ func AtualizarTelefoneUsuario(c *gin.Context) {
 }
 
 func ListarUsuarios(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	busca := c.Query("busca")
-
-	query := `SELECT id, nome_completo, email, telefone FROM usuarios`
-	args := []interface{}{}
-	whereClauses := []string{}
-	argCounter := 1
-
-	if busca != "" {
-		buscaPattern := "%" + strings.ToLower(busca) + "%"
-		whereClauses = append(whereClauses, fmt.Sprintf("(LOWER(email) LIKE $%d OR LOWER(telefone) LIKE $%d)", argCounter, argCounter+1))
-		args = append(args, buscaPattern, buscaPattern)
-		argCounter += 2
-	}
-
-	if len(whereClauses) > 0 {
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoUsuarios)
+	if !ok {
+		return
 	}
-	query += " ORDER BY nome_completo ASC"
 
-	rows, err := db.Query(query, args...)
+	usuarios, total, err := repos.Usuarios.Listar(c.Query("busca"), pagina)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar usuários", "detalhes": err.Error()})
 		return
 	}
-	defer rows.Close()
-
-	var usuarios []models.Usuario
-	// Inicializa como slice vazio (não nil) para garantir que sempre serializa para []
-	usuarios = make([]models.Usuario, 0)
 
-	for rows.Next() {
-		var u models.Usuario
-		var telefoneSQL sql.NullString // Variável temporária para ler telefone
-		// AQUI: Leia nome_completo para u.Nome e telefone para telefoneSQL
-		if err := rows.Scan(&u.ID, &u.Nome, &u.Email, &telefoneSQL); err != nil {
-			log.Printf("ERRO: Erro ao ler dados do usuário durante Scan: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler dados do usuário", "detalhes": err.Error()})
-			return
-		}
-		u.Telefone = telefoneSQL.String // Converte para string do modelo
+	responderPagina(c, pagina, usuarios, total)
+}
 
-		usuarios = append(usuarios, u)
+// usuarioLogado carrega o cliente dono do token, respondendo 404 ou 500
+// quando não é possível.
+func usuarioLogado(c *gin.Context, email string) (models.Usuario, bool) {
+	usuario, err := repos.Usuarios.ObterPorEmail(email)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Usuário não encontrado."})
+		return usuario, false
 	}
-
-	c.JSON(http.StatusOK, usuarios)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar usuário."})
+		return usuario, false
+	}
+	return usuario, true
 }
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// novoTeste troca os repositórios por repository.NovoMemoria() e desliga
+// os meios de pagamento, restaurando a configuração no fim do teste.
+func novoTeste(t *testing.T) *repository.Repositorios {
+	t.Helper()
+	pix, cartao, boleto := configPix, configCartao, configBoleto
+	t.Cleanup(func() {
+		// Goroutines como avisarReposicao podem terminar depois do teste:
+		// elas encontram repositórios vazios em vez de nil.
+		repos = repository.NovoMemoria()
+		configPix, configCartao, configBoleto = pix, cartao, boleto
+	})
+	repos = repository.NovoMemoria()
+	configPix, configCartao, configBoleto = nil, nil, nil
+	return repos
+}
+
+// ligarCartaoMemoria liga o cartão com o gateway em memória.
+func ligarCartaoMemoria() *pagamento.GatewayMemoria {
+	gateway := pagamento.NovoGatewayMemoria()
+	configCartao = &pagamento.ConfigCartao{Gateway: gateway, Parcelamento: pagamento.ParcelamentoPadrao}
+	return gateway
+}
+
+// requisitar monta um router só com h em rota e envia a requisição. Com
+// email, a requisição chega autenticada como faria o AuthMiddleware.
+func requisitar(t *testing.T, h gin.HandlerFunc, metodo, rota, caminho string, corpo any, email string) *httptest.ResponseRecorder {
+	t.Helper()
+	var leitor *bytes.Reader
+	if corpo == nil {
+		leitor = bytes.NewReader(nil)
+	} else {
+		dados, err := json.Marshal(corpo)
+		if err != nil {
+			t.Fatalf("corpo da requisição: %v", err)
+		}
+		leitor = bytes.NewReader(dados)
+	}
+
+	router := gin.New()
+	router.Handle(metodo, rota, func(c *gin.Context) {
+		if email != "" {
+			principal := auth.Principal{Tipo: auth.TipoUsuario, ID: 1, Email: email, Papel: auth.PapelCliente}
+			c.Set(ctxPrincipal, principal)
+			c.Set("user_id", principal.ID)
+			c.Set("email", principal.Email)
+		}
+		c.Next()
+	}, h)
+
+	req := httptest.NewRequest(metodo, caminho, leitor)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// lerJSON decodifica a resposta em destino.
+func lerJSON(t *testing.T, w *httptest.ResponseRecorder, destino any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), destino); err != nil {
+		t.Fatalf("resposta não é JSON (%v): %s", err, w.Body.String())
+	}
+}
+
+func criarProdutoTeste(t *testing.T, r *repository.Repositorios, nome string, preco float64, quantidade int) models.Produto {
+	t.Helper()
+	p := models.Produto{Nome: nome, Preco: preco, Quantidade: quantidade}
+	if err := r.Produtos.Criar(&p); err != nil {
+		t.Fatalf("criar produto: %v", err)
+	}
+	return p
+}
+
+// criarCotacaoTeste grava uma opção de frete para o carrinho itens, no
+// formato de assinaturaItens.
+func criarCotacaoTeste(t *testing.T, r *repository.Repositorios, id, itens string, valor float64, expiraEm time.Time) models.OpcaoFrete {
+	t.Helper()
+	o := models.OpcaoFrete{
+		ID:             id,
+		CEPDestino:     "01310100",
+		Transportadora: "loja",
+		Servico:        "padrao",
+		Nome:           "Entrega padrão",
+		Valor:          valor,
+		PrazoDias:      5,
+		PrazoEntrega:   "20/10/2026",
+		Itens:          itens,
+		ExpiraEm:       expiraEm,
+	}
+	if err := r.Fretes.Criar(&o); err != nil {
+		t.Fatalf("criar cotação: %v", err)
+	}
+	return o
+}
+
+func esperarStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, esperado %d: %s", w.Code, status, w.Body.String())
+	}
+}
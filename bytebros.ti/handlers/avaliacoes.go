@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// preencherAvaliacoes coloca em cada produto a média e o total das
+// avaliações aprovadas.
+func preencherAvaliacoes(produtos []*models.Produto) error {
+	ids := make([]int, len(produtos))
+	for i, p := range produtos {
+		ids[i] = p.ID
+	}
+	resumos, err := repos.Avaliacoes.Resumos(ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range produtos {
+		p.Avaliacoes = resumos[p.ID]
+	}
+	return nil
+}
+
+// CriarAvaliacao registra a avaliação do cliente autenticado. Só quem tem
+// um pedido entregue com o produto pode avaliar, uma vez por produto; a
+// avaliação aparece na loja depois de aprovada.
+func CriarAvaliacao(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	principal, ok := principalAtual(c)
+	if !ok || principal.Tipo != auth.TipoUsuario {
+		c.JSON(http.StatusForbidden, gin.H{"erro": "Apenas clientes podem avaliar produtos"})
+		return
+	}
+	var req models.AvaliacaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+	comentario := strings.TrimSpace(req.Comentario)
+	if comentario == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Comentário não pode ser vazio"})
+		return
+	}
+
+	if !produtoExiste(c, produtoID) {
+		return
+	}
+	recebeu, err := repos.Pedidos.ClienteRecebeu(principal.Email, produtoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar pedidos", "detalhes": err.Error()})
+		return
+	}
+	if !recebeu {
+		c.JSON(http.StatusForbidden, gin.H{"erro": "Só é possível avaliar produtos de pedidos entregues"})
+		return
+	}
+	usuario, err := repos.Usuarios.ObterPorEmail(principal.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar usuário", "detalhes": err.Error()})
+		return
+	}
+
+	avaliacao := models.Avaliacao{
+		ProdutoID:    produtoID,
+		ClienteEmail: principal.Email,
+		Autor:        primeiroNome(usuario.Nome),
+		Nota:         req.Nota,
+		Comentario:   comentario,
+	}
+	err = repos.Avaliacoes.Criar(&avaliacao)
+	switch {
+	case errors.Is(err, repository.ErrDuplicado):
+		c.JSON(http.StatusConflict, gin.H{"erro": "Você já avaliou este produto"})
+	case errors.Is(err, repository.ErrNaoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar avaliação", "detalhes": err.Error()})
+	default:
+		c.JSON(http.StatusCreated, avaliacao)
+	}
+}
+
+func primeiroNome(nome string) string {
+	if campos := strings.Fields(nome); len(campos) > 0 {
+		return campos[0]
+	}
+	return "Cliente"
+}
+
+// ListarAvaliacoesProduto devolve as avaliações aprovadas do produto
+// (paginadas), sem o email de quem avaliou.
+func ListarAvaliacoesProduto(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoAvaliacoes)
+	if !ok {
+		return
+	}
+	if !produtoExiste(c, produtoID) {
+		return
+	}
+
+	filtro := repository.FiltroAvaliacoes{ProdutoID: produtoID, Status: models.AvaliacaoAprovada}
+	avaliacoes, total, err := repos.Avaliacoes.Listar(filtro, pagina)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar avaliações", "detalhes": err.Error()})
+		return
+	}
+	for i := range avaliacoes {
+		avaliacoes[i].ClienteEmail = ""
+		avaliacoes[i].ModeradoEm = nil
+		avaliacoes[i].ModeradoPor = ""
+	}
+	responderPagina(c, pagina, avaliacoes, total)
+}
+
+var statusAvaliacao = []string{models.AvaliacaoPendente, models.AvaliacaoAprovada, models.AvaliacaoOculta}
+
+// ListarAvaliacoesAdmin é a fila de moderação: todas as avaliações
+// (paginadas), filtráveis por status e produto_id.
+func ListarAvaliacoesAdmin(c *gin.Context) {
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoAvaliacoes)
+	if !ok {
+		return
+	}
+	var filtro repository.FiltroAvaliacoes
+	if filtro.ProdutoID, ok = idDaQuery(c, "produto_id"); !ok {
+		return
+	}
+	filtro.Status = c.Query("status")
+	if filtro.Status != "" && !slices.Contains(statusAvaliacao, filtro.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Status de avaliação inválido", "status_aceitos": statusAvaliacao})
+		return
+	}
+
+	avaliacoes, total, err := repos.Avaliacoes.Listar(filtro, pagina)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar avaliações", "detalhes": err.Error()})
+		return
+	}
+	responderPagina(c, pagina, avaliacoes, total)
+}
+
+// ModerarAvaliacao aprova ou oculta uma avaliação. Uma avaliação oculta
+// pode ser aprovada depois, e vice-versa.
+func ModerarAvaliacao(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	var req models.ModerarAvaliacaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	avaliacao, err := repos.Avaliacoes.Moderar(id, req.Status, emailDaRequisicao(c))
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Avaliação não encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao moderar avaliação", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, avaliacao)
+}
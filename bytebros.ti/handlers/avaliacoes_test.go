@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pedidoEntregue cria um produto e um pedido dele para clienteTeste,
+// deixando o pedido no status indicado.
+func pedidoEntregue(t *testing.T, r *repository.Repositorios, status string) (models.Produto, int) {
+	t.Helper()
+	p := criarProdutoTeste(t, r, "Monitor 27", 1500, 3)
+	cotacao := criarCotacaoTeste(t, r, fmt.Sprintf("cot-avaliacao-%d", p.ID), fmt.Sprintf("%d:1", p.ID), 40, time.Now().Add(time.Hour))
+	w := criarPedido(t, pedidoTeste(p.ID, 1, 1500, cotacao))
+	esperarStatus(t, w, http.StatusCreated)
+	var criado struct {
+		PedidoID int `json:"pedido_id"`
+	}
+	lerJSON(t, w, &criado)
+	if err := r.Pedidos.AtualizarStatus(criado.PedidoID, status); err != nil {
+		t.Fatalf("atualizar pedido: %v", err)
+	}
+	return p, criado.PedidoID
+}
+
+func avaliar(t *testing.T, produtoID int, req models.AvaliacaoRequest, email string) *httptest.ResponseRecorder {
+	t.Helper()
+	return requisitar(t, CriarAvaliacao, http.MethodPost, "/api/produtos/:id/avaliacoes",
+		fmt.Sprintf("/api/produtos/%d/avaliacoes", produtoID), req, email)
+}
+
+func moderar(t *testing.T, id int, status string) *httptest.ResponseRecorder {
+	t.Helper()
+	return requisitar(t, ModerarAvaliacao, http.MethodPut, "/api/admin/avaliacoes/:id/status",
+		fmt.Sprintf("/api/admin/avaliacoes/%d/status", id), models.ModerarAvaliacaoRequest{Status: status}, adminTeste)
+}
+
+// avaliacoesPublicas devolve as avaliações que a loja mostra do produto e
+// o resumo exibido junto com ele.
+func avaliacoesPublicas(t *testing.T, produtoID int) ([]models.Avaliacao, models.ResumoAvaliacoes) {
+	t.Helper()
+	w := requisitar(t, ListarAvaliacoesProduto, http.MethodGet, "/api/produtos/:id/avaliacoes",
+		fmt.Sprintf("/api/produtos/%d/avaliacoes", produtoID), nil, "")
+	esperarStatus(t, w, http.StatusOK)
+	var avaliacoes []models.Avaliacao
+	lerJSON(t, w, &avaliacoes)
+	if total := w.Header().Get(headerTotal); total != fmt.Sprint(len(avaliacoes)) {
+		t.Errorf("%s = %s, esperado %d", headerTotal, total, len(avaliacoes))
+	}
+
+	w = requisitar(t, ObterProduto, http.MethodGet, "/api/produtos/:id", fmt.Sprintf("/api/produtos/%d", produtoID), nil, "")
+	esperarStatus(t, w, http.StatusOK)
+	var produto models.Produto
+	lerJSON(t, w, &produto)
+	return avaliacoes, produto.Avaliacoes
+}
+
+func TestCriarAvaliacaoCompradorVerificado(t *testing.T) {
+	r := novoTeste(t)
+	if err := r.Usuarios.Criar(&models.Usuario{Nome: "Ana Souza", Email: clienteTeste, SenhaHash: "x"}); err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+	p, pedidoID := pedidoEntregue(t, r, models.StatusPedidoEnviado)
+	req := models.AvaliacaoRequest{Nota: 4, Comentario: "  Ótima imagem.  "}
+
+	// Pedido ainda a caminho e cliente sem pedido não avaliam.
+	esperarStatus(t, avaliar(t, p.ID, req, clienteTeste), http.StatusForbidden)
+	esperarStatus(t, avaliar(t, p.ID, req, "outro@example.com"), http.StatusForbidden)
+
+	if err := r.Pedidos.AtualizarStatus(pedidoID, models.StatusPedidoEntregue); err != nil {
+		t.Fatalf("atualizar pedido: %v", err)
+	}
+	w := avaliar(t, p.ID, req, clienteTeste)
+	esperarStatus(t, w, http.StatusCreated)
+	var criada models.Avaliacao
+	lerJSON(t, w, &criada)
+	if criada.Status != models.AvaliacaoPendente || criada.Autor != "Ana" || criada.Comentario != "Ótima imagem." || criada.Nota != 4 {
+		t.Errorf("avaliação = %+v, esperada pendente de Ana com o comentário aparado", criada)
+	}
+
+	// Uma avaliação por produto, mesmo com outro texto.
+	w = avaliar(t, p.ID, models.AvaliacaoRequest{Nota: 1, Comentario: "Mudei de ideia"}, clienteTeste)
+	esperarStatus(t, w, http.StatusConflict)
+	avaliacoes, _, err := r.Avaliacoes.Listar(repository.FiltroAvaliacoes{ProdutoID: p.ID}, repository.Pagina{})
+	if err != nil || len(avaliacoes) != 1 {
+		t.Errorf("%d avaliações gravadas (%v), esperado 1", len(avaliacoes), err)
+	}
+}
+
+func TestCriarAvaliacaoInvalida(t *testing.T) {
+	r := novoTeste(t)
+	if err := r.Usuarios.Criar(&models.Usuario{Nome: "Ana Souza", Email: clienteTeste, SenhaHash: "x"}); err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+	p, _ := pedidoEntregue(t, r, models.StatusPedidoEntregue)
+
+	casos := []struct {
+		nome      string
+		produtoID int
+		req       models.AvaliacaoRequest
+		status    int
+	}{
+		{"nota zero", p.ID, models.AvaliacaoRequest{Nota: 0, Comentario: "Bom"}, http.StatusBadRequest},
+		{"nota seis", p.ID, models.AvaliacaoRequest{Nota: 6, Comentario: "Bom"}, http.StatusBadRequest},
+		{"comentário em branco", p.ID, models.AvaliacaoRequest{Nota: 5, Comentario: "   "}, http.StatusBadRequest},
+		{"produto inexistente", p.ID + 100, models.AvaliacaoRequest{Nota: 5, Comentario: "Bom"}, http.StatusNotFound},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			esperarStatus(t, avaliar(t, caso.produtoID, caso.req, clienteTeste), caso.status)
+		})
+	}
+
+	// Funcionários não avaliam produtos.
+	router := gin.New()
+	router.POST("/api/produtos/:id/avaliacoes", func(c *gin.Context) {
+		c.Set(ctxPrincipal, auth.Principal{Tipo: auth.TipoFuncionario, ID: 1, Email: clienteTeste})
+		c.Next()
+	}, CriarAvaliacao)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/produtos/%d/avaliacoes", p.ID), nil))
+	esperarStatus(t, w, http.StatusForbidden)
+}
+
+func TestModerarAvaliacao(t *testing.T) {
+	r := novoTeste(t)
+	if err := r.Usuarios.Criar(&models.Usuario{Nome: "Ana Souza", Email: clienteTeste, SenhaHash: "x"}); err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+	p, _ := pedidoEntregue(t, r, models.StatusPedidoEntregue)
+	w := avaliar(t, p.ID, models.AvaliacaoRequest{Nota: 4, Comentario: "Ótima imagem."}, clienteTeste)
+	esperarStatus(t, w, http.StatusCreated)
+	var criada models.Avaliacao
+	lerJSON(t, w, &criada)
+
+	// Pendente: só a moderação vê.
+	if avaliacoes, resumo := avaliacoesPublicas(t, p.ID); len(avaliacoes) != 0 || resumo.Total != 0 {
+		t.Errorf("loja mostra %+v (%+v) antes da moderação", avaliacoes, resumo)
+	}
+	w = requisitar(t, ListarAvaliacoesAdmin, http.MethodGet, "/api/admin/avaliacoes", "/api/admin/avaliacoes?status=pendente", nil, adminTeste)
+	esperarStatus(t, w, http.StatusOK)
+	var fila []models.Avaliacao
+	lerJSON(t, w, &fila)
+	if len(fila) != 1 || fila[0].ClienteEmail != clienteTeste {
+		t.Errorf("fila de moderação = %+v, esperada a avaliação de %s", fila, clienteTeste)
+	}
+	w = requisitar(t, ListarAvaliacoesAdmin, http.MethodGet, "/api/admin/avaliacoes", "/api/admin/avaliacoes?status=removida", nil, adminTeste)
+	esperarStatus(t, w, http.StatusBadRequest)
+
+	w = moderar(t, criada.ID, models.AvaliacaoAprovada)
+	esperarStatus(t, w, http.StatusOK)
+	var moderada models.Avaliacao
+	lerJSON(t, w, &moderada)
+	if moderada.Status != models.AvaliacaoAprovada || moderada.ModeradoPor != adminTeste || moderada.ModeradoEm == nil {
+		t.Errorf("avaliação = %+v, esperada aprovada por %s", moderada, adminTeste)
+	}
+	avaliacoes, resumo := avaliacoesPublicas(t, p.ID)
+	if len(avaliacoes) != 1 || resumo != (models.ResumoAvaliacoes{Media: 4, Total: 1}) {
+		t.Fatalf("loja mostra %+v (%+v), esperada a avaliação aprovada com média 4", avaliacoes, resumo)
+	}
+	if a := avaliacoes[0]; a.ClienteEmail != "" || a.ModeradoPor != "" || a.ModeradoEm != nil || a.Autor != "Ana" {
+		t.Errorf("avaliação pública = %+v, esperada sem email e sem dados da moderação", a)
+	}
+
+	// Oculta sai da loja e da média; pode voltar a ser aprovada.
+	esperarStatus(t, moderar(t, criada.ID, models.AvaliacaoOculta), http.StatusOK)
+	if avaliacoes, resumo := avaliacoesPublicas(t, p.ID); len(avaliacoes) != 0 || resumo.Total != 0 {
+		t.Errorf("loja mostra %+v (%+v) depois de ocultar", avaliacoes, resumo)
+	}
+	esperarStatus(t, moderar(t, criada.ID, models.AvaliacaoAprovada), http.StatusOK)
+	if avaliacoes, _ := avaliacoesPublicas(t, p.ID); len(avaliacoes) != 1 {
+		t.Errorf("%d avaliações na loja depois de reaprovar, esperado 1", len(avaliacoes))
+	}
+
+	esperarStatus(t, moderar(t, criada.ID, models.AvaliacaoPendente), http.StatusBadRequest)
+	esperarStatus(t, moderar(t, criada.ID+1, models.AvaliacaoAprovada), http.StatusNotFound)
+}
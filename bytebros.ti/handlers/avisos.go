@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"bytebros.ti/mailer"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AviseMe inscreve o usuário logado para ser avisado quando o produto, hoje
+// sem estoque, voltar a ter unidades. O aviso vai para o email da conta,
+// para que ninguém inscreva endereços de terceiros.
+func AviseMe(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+
+	produto, err := repos.Produtos.Obter(id)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produto", "detalhes": err.Error()})
+		return
+	}
+	if produto.Quantidade > 0 {
+		c.JSON(http.StatusConflict, gin.H{"erro": "Produto já está disponível em estoque"})
+		return
+	}
+
+	aviso := models.AvisoEstoque{ProdutoID: id, Email: strings.ToLower(strings.TrimSpace(emailDaRequisicao(c)))}
+	err = repos.Avisos.Criar(&aviso)
+	switch {
+	case errors.Is(err, repository.ErrDuplicado):
+		c.JSON(http.StatusOK, gin.H{"mensagem": "Você já será avisado quando o produto voltar ao estoque"})
+	case errors.Is(err, repository.ErrNaoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar aviso", "detalhes": err.Error()})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"mensagem": "Você será avisado quando o produto voltar ao estoque"})
+	}
+}
+
+// avisarReposicao envia o email de "voltou ao estoque" aos inscritos do
+// produto, se ele tiver estoque. Os avisos são reivindicados antes do envio
+// para que duas chamadas concorrentes não mandem o mesmo email; os que
+// falham são liberados e a próxima passada do monitor tenta de novo.
+func avisarReposicao(produtoID int) {
+	avisos, err := repos.Avisos.Reivindicar(produtoID)
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao buscar avisos de reposição do produto %d: %v", produtoID, err)
+		return
+	}
+	if len(avisos) == 0 {
+		return
+	}
+	produto, err := repos.Produtos.Obter(produtoID)
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao buscar produto %d para avisos de reposição: %v", produtoID, err)
+		liberarAvisos(avisos)
+		return
+	}
+
+	for _, aviso := range avisos {
+		err := mailSender.Enviar(mailer.Mensagem{
+			Para:    aviso.Email,
+			Assunto: "Byte Bros.TI - " + produto.Nome + " voltou ao estoque",
+			Corpo: fmt.Sprintf(`Olá!
+
+O produto %s, que você pediu para acompanhar, voltou ao estoque.
+Confira em:
+
+%s/produto.html?id=%d
+
+Este é um aviso único; para ser avisado de novo, faça uma nova inscrição.
+
+Equipe Byte Bros.TI`, produto.Nome, urlFrontend(), produto.ID),
+		})
+		if err != nil {
+			log.Printf("ERRO: Falha ao enviar aviso de reposição para %s: %v", aviso.Email, err)
+			liberarAvisos([]models.AvisoEstoque{aviso})
+		}
+	}
+}
+
+func liberarAvisos(avisos []models.AvisoEstoque) {
+	for _, aviso := range avisos {
+		if err := repos.Avisos.Liberar(aviso.ID); err != nil {
+			log.Printf("ERRO BD: Falha ao liberar aviso de reposição %d: %v", aviso.ID, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configBoleto é nil com o boleto desligado (sem BOLETO_AGENCIA).
+var configBoleto *pagamento.ConfigBoleto
+
+// maxBytesRetorno limita o arquivo de retorno enviado pela equipe.
+const maxBytesRetorno = 5 << 20
+
+// resultadoLiquidado marca, na resposta do retorno, os boletos confirmados.
+const resultadoLiquidado = "liquidado"
+
+// criarCobrancaBoleto emite o boleto do pedido recém-criado. A cobrança só
+// expira depois do prazo de baixa, para dar tempo de um pagamento feito no
+// vencimento chegar no retorno do banco.
+func criarCobrancaBoleto(tx *repository.Repositorios, pedido models.Pedido, documento string) (*models.Pagamento, error) {
+	sequencia, err := tx.Pagamentos.ProximoNossoNumero()
+	if err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao reservar nosso número do boleto", "detalhes": err.Error()})
+	}
+	vencimento := configBoleto.Vencimento(time.Now())
+	b, err := configBoleto.Gerar(sequencia, vencimento, paraCentavos(pedido.ValorTotal))
+	if errors.Is(err, pagamento.ErrValorBoleto) {
+		return nil, abortar(http.StatusUnprocessableEntity, gin.H{"erro": "Valor do pedido fora da faixa aceita no boleto"})
+	}
+	if err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar boleto", "detalhes": err.Error()})
+	}
+	expiraEm := configBoleto.Expiracao(vencimento)
+	p := models.Pagamento{
+		PedidoID:         pedido.ID,
+		Metodo:           models.FormaPagamentoBoleto,
+		Status:           models.StatusPagamentoPendente,
+		Valor:            pedido.ValorTotal,
+		ExpiraEm:         &expiraEm,
+		NossoNumero:      b.NossoNumero,
+		LinhaDigitavel:   b.LinhaDigitavel,
+		CodigoBarras:     b.CodigoBarras,
+		Vencimento:       &vencimento,
+		DocumentoPagador: documento,
+	}
+	if err := tx.Pagamentos.Criar(&p); err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao gravar boleto", "detalhes": err.Error()})
+	}
+	comLinks(&p)
+	return &p, nil
+}
+
+// BoletoPDFCliente devolve o PDF do boleto de um pedido do usuário logado.
+func BoletoPDFCliente(c *gin.Context) {
+	boletoPDF(c, emailDaRequisicao(c))
+}
+
+// BoletoPDFAdmin é o equivalente para a equipe, para reenviar o boleto ao
+// cliente.
+func BoletoPDFAdmin(c *gin.Context) {
+	boletoPDF(c, "")
+}
+
+// boletoPDF desenha o boleto pendente. Com clienteEmail, pedidos de outros
+// clientes são tratados como inexistentes.
+func boletoPDF(c *gin.Context, clienteEmail string) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	pagamentoID, ok := idDoParametro(c, "pagamentoId")
+	if !ok {
+		return
+	}
+	pedido, err := repos.Pedidos.Obter(pedidoID)
+	if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedido", "detalhes": err.Error()})
+		return
+	}
+	if err != nil || clienteEmail != "" && pedido.ClienteEmail != clienteEmail {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Pedido não encontrado"})
+		return
+	}
+
+	pagamentos, err := repos.Pagamentos.DoPedido(pedido.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pagamentos do pedido", "detalhes": err.Error()})
+		return
+	}
+	var p *models.Pagamento
+	for i := range pagamentos {
+		if pagamentos[i].ID == pagamentoID && pagamentos[i].Metodo == models.FormaPagamentoBoleto {
+			p = &pagamentos[i]
+		}
+	}
+	if p == nil {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Boleto não encontrado"})
+		return
+	}
+	if p.Status != models.StatusPagamentoPendente {
+		c.JSON(http.StatusGone, gin.H{"erro": "O boleto não está mais pendente", "status": p.Status})
+		return
+	}
+	if configBoleto == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"erro": "Pagamento com boleto desligado"})
+		return
+	}
+
+	pagador := pagamento.Pagador{Nome: pedido.ClienteEmail, Documento: p.DocumentoPagador, Endereco: pedido.EnderecoEntrega}
+	if usuario, err := repos.Usuarios.ObterPorEmail(pedido.ClienteEmail); err == nil {
+		pagador.Nome = usuario.Nome
+	} else if !errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar cliente do pedido", "detalhes": err.Error()})
+		return
+	}
+
+	b := pagamento.Boleto{
+		NossoNumero:    p.NossoNumero,
+		Vencimento:     *p.Vencimento,
+		Centavos:       paraCentavos(p.Valor),
+		CodigoBarras:   p.CodigoBarras,
+		LinhaDigitavel: p.LinhaDigitavel,
+	}
+	documento, err := configBoleto.PDF(b, strconv.Itoa(pedido.ID), p.CriadoEm, pagador)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar PDF do boleto", "detalhes": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="boleto-pedido-%d.pdf"`, pedido.ID))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/pdf", documento)
+}
+
+// ProcessarRetornoBoleto lê o arquivo de retorno do banco (campo arquivo,
+// CNAB 240 ou CNAB 400) e confirma os boletos liquidados. Cada título roda
+// na sua própria transação, então reenviar o mesmo arquivo é seguro: os já
+// confirmados voltam como "ja_pago".
+func ProcessarRetornoBoleto(c *gin.Context) {
+	if configBoleto == nil {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Pagamento com boleto desligado"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytesRetorno+1<<20)
+	cabecalho, err := c.FormFile("arquivo")
+	if err != nil {
+		var muitoGrande *http.MaxBytesError
+		if errors.As(err, &muitoGrande) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": fmt.Sprintf("Arquivo de retorno maior que %d MB", maxBytesRetorno>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Envie o retorno como multipart/form-data no campo arquivo", "detalhes": err.Error()})
+		return
+	}
+	if cabecalho.Size > maxBytesRetorno {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": fmt.Sprintf("Arquivo de retorno maior que %d MB", maxBytesRetorno>>20)})
+		return
+	}
+	arquivo, err := cabecalho.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler arquivo de retorno", "detalhes": err.Error()})
+		return
+	}
+	defer arquivo.Close()
+	retorno, err := pagamento.LerRetornoCNAB(arquivo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Arquivo de retorno inválido", "detalhes": err.Error()})
+		return
+	}
+	if retorno.Banco != configBoleto.Banco {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": "Arquivo de retorno de outro banco", "banco": retorno.Banco, "esperado": configBoleto.Banco})
+		return
+	}
+
+	usuario := emailDaRequisicao(c)
+	resultados := make([]models.ResultadoRetornoBoleto, 0)
+	liquidados := 0
+	for _, registro := range retorno.Registros {
+		if !registro.Liquidado {
+			continue
+		}
+		resultado, err := liquidarBoleto(registro, usuario)
+		if err != nil {
+			responderErro(c, err, fmt.Sprintf("Erro ao processar a linha %d do retorno", registro.Linha))
+			return
+		}
+		if resultado.Resultado == resultadoLiquidado {
+			liquidados++
+		}
+		resultados = append(resultados, resultado)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"layout":     retorno.Layout,
+		"banco":      retorno.Banco,
+		"registros":  len(retorno.Registros),
+		"liquidados": liquidados,
+		"resultados": resultados,
+	})
+}
+
+// liquidarBoleto confirma o pagamento de um título do retorno. Boletos
+// desconhecidos ou pagos a menor não são confirmados e ficam para a equipe
+// conferir na resposta.
+func liquidarBoleto(registro pagamento.RegistroRetorno, usuario string) (models.ResultadoRetornoBoleto, error) {
+	resultado := models.ResultadoRetornoBoleto{
+		Linha:       registro.Linha,
+		NossoNumero: registro.NossoNumero,
+		ValorPago:   deCentavos(registro.ValorPago),
+	}
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		p, err := tx.Pagamentos.BloquearPorNossoNumero(registro.NossoNumero)
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			resultado.Resultado = "nao_encontrado"
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resultado.PedidoID = &p.PedidoID
+		switch {
+		case p.Status == models.StatusPagamentoPago:
+			resultado.Resultado = "ja_pago"
+			return nil
+		case registro.ValorPago < paraCentavos(p.Valor):
+			resultado.Resultado = "valor_divergente"
+			return nil
+		}
+
+		pagoEm := registro.PagoEm
+		if pagoEm.IsZero() {
+			pagoEm = time.Now()
+		}
+		resultado.Resultado = resultadoLiquidado
+		return confirmarPagamento(tx, p, pagoEm, usuario, "Boleto "+configBoleto.NossoNumeroImpresso(p.NossoNumero))
+	})
+	return resultado, err
+}
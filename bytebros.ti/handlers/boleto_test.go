@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ligarBoleto() {
+	configBoleto = &pagamento.ConfigBoleto{
+		Banco:          "237",
+		Agencia:        "3381",
+		Conta:          "0000633",
+		Carteira:       "09",
+		Beneficiario:   "Byte Bros TI",
+		Documento:      "11222333000181",
+		DiasVencimento: 3,
+		DiasBaixa:      5,
+	}
+}
+
+// pedidoBoleto cria um pedido de R$ 150,00 a pagar com boleto e devolve a
+// cobrança. Os nossos números saem em sequência a partir de 1, como nos
+// arquivos de pagamento/testdata.
+func pedidoBoleto(t *testing.T, r *repository.Repositorios) models.Pagamento {
+	t.Helper()
+	p := criarProdutoTeste(t, r, "Fonte 650W", 110, 5)
+	cotacao := criarCotacaoTeste(t, r, fmt.Sprintf("cot-boleto-%d", p.ID), fmt.Sprintf("%d:1", p.ID), 40, time.Now().Add(time.Hour))
+	req := pedidoTeste(p.ID, 1, 110, cotacao)
+	req.FormaPagamento = models.FormaPagamentoBoleto
+	req.DocumentoPagador = "529.982.247-25"
+
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusCreated)
+	var resposta struct {
+		Pagamento models.Pagamento `json:"pagamento"`
+	}
+	lerJSON(t, w, &resposta)
+	if len(resposta.Pagamento.CodigoBarras) != 44 || resposta.Pagamento.Status != models.StatusPagamentoPendente {
+		t.Fatalf("cobrança = %+v, esperado boleto pendente", resposta.Pagamento)
+	}
+	return resposta.Pagamento
+}
+
+// enviarRetorno posta o arquivo de pagamento/testdata como a equipe faria.
+func enviarRetorno(t *testing.T, arquivo string) *httptest.ResponseRecorder {
+	t.Helper()
+	conteudo, err := os.ReadFile("../pagamento/testdata/" + arquivo)
+	if err != nil {
+		t.Fatalf("ler %s: %v", arquivo, err)
+	}
+	var corpo bytes.Buffer
+	form := multipart.NewWriter(&corpo)
+	parte, err := form.CreateFormFile("arquivo", arquivo)
+	if err != nil {
+		t.Fatalf("multipart: %v", err)
+	}
+	if _, err := io.Copy(parte, bytes.NewReader(conteudo)); err != nil {
+		t.Fatalf("multipart: %v", err)
+	}
+	form.Close()
+
+	router := gin.New()
+	router.POST("/api/admin/pagamentos/boletos/retorno", func(c *gin.Context) {
+		c.Set("email", adminTeste)
+		c.Next()
+	}, ProcessarRetornoBoleto)
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/pagamentos/boletos/retorno", &corpo)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+type respostaRetorno struct {
+	Layout     string                          `json:"layout"`
+	Banco      string                          `json:"banco"`
+	Registros  int                             `json:"registros"`
+	Liquidados int                             `json:"liquidados"`
+	Resultados []models.ResultadoRetornoBoleto `json:"resultados"`
+}
+
+func TestProcessarRetornoBoleto(t *testing.T) {
+	arquivos := map[string]string{pagamento.CNAB240: "retorno_240.ret", pagamento.CNAB400: "retorno_400.ret"}
+	for layout, arquivo := range arquivos {
+		t.Run(layout, func(t *testing.T) {
+			r := novoTeste(t)
+			ligarBoleto()
+			pago, aMenor, emAberto := pedidoBoleto(t, r), pedidoBoleto(t, r), pedidoBoleto(t, r)
+
+			w := enviarRetorno(t, arquivo)
+			esperarStatus(t, w, http.StatusOK)
+			var resposta respostaRetorno
+			lerJSON(t, w, &resposta)
+			if resposta.Layout != layout || resposta.Banco != "237" || resposta.Registros != 3 || resposta.Liquidados != 1 {
+				t.Errorf("resposta = %+v, esperado %s do 237 com 3 registros e 1 liquidado", resposta, layout)
+			}
+			if len(resposta.Resultados) != 2 {
+				t.Fatalf("resultados = %+v, esperado 2", resposta.Resultados)
+			}
+			esperados := []struct {
+				nossoNumero string
+				valor       float64
+				resultado   string
+			}{
+				{pago.NossoNumero, 150, resultadoLiquidado},
+				{aMenor.NossoNumero, 120, "valor_divergente"},
+			}
+			for i, e := range esperados {
+				res := resposta.Resultados[i]
+				if res.NossoNumero != e.nossoNumero || res.ValorPago != e.valor || res.Resultado != e.resultado {
+					t.Errorf("resultado %d = %+v, esperado %s de %.2f %s", i, res, e.nossoNumero, e.valor, e.resultado)
+				}
+			}
+
+			status := map[int]string{
+				pago.PedidoID:     models.StatusPedidoPago,
+				aMenor.PedidoID:   models.StatusPedidoProcessando,
+				emAberto.PedidoID: models.StatusPedidoProcessando,
+			}
+			for pedidoID, esperado := range status {
+				if s := statusDoPedido(t, r, pedidoID); s != esperado {
+					t.Errorf("pedido %d em %q, esperado %q", pedidoID, s, esperado)
+				}
+			}
+
+			// Reenviar o mesmo arquivo não paga de novo.
+			w = enviarRetorno(t, arquivo)
+			esperarStatus(t, w, http.StatusOK)
+			lerJSON(t, w, &resposta)
+			if resposta.Liquidados != 0 || resposta.Resultados[0].Resultado != "ja_pago" {
+				t.Errorf("reenvio = %+v, esperado o primeiro título como ja_pago", resposta)
+			}
+		})
+	}
+}
+
+func TestProcessarRetornoBoletoDeOutroBanco(t *testing.T) {
+	r := novoTeste(t)
+	ligarBoleto()
+	cobranca := pedidoBoleto(t, r)
+
+	w := enviarRetorno(t, "retorno_400_itau.ret")
+	esperarStatus(t, w, http.StatusUnprocessableEntity)
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoProcessando {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoProcessando)
+	}
+}
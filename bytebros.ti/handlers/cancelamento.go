@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resultados da devolução de uma cobrança paga no cancelamento.
+const (
+	devolucaoEstornada = "estornado"
+	devolucaoRecusada  = "estorno_recusado"
+	devolucaoPendente  = "estorno_pendente"
+	devolucaoManual    = "manual"
+)
+
+// CancelarPedidoCliente cancela um pedido do usuário logado que ainda não
+// foi enviado, devolvendo o pagamento e o estoque.
+func CancelarPedidoCliente(c *gin.Context) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	var req models.CancelarPedidoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+	clienteEmail := emailDaRequisicao(c)
+
+	var devolucoes []models.DevolucaoCancelamento
+	var estornos []models.Pagamento
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		pedido, err := bloquearPedido(tx, pedidoID)
+		if err != nil {
+			return err
+		}
+		// Pedidos de outros clientes são tratados como inexistentes.
+		if pedido.ClienteEmail != clienteEmail {
+			return abortar(http.StatusNotFound, gin.H{"erro": "Pedido não encontrado"})
+		}
+		if !models.TransicaoPedidoPermitida(pedido.Status, models.StatusPedidoCancelado) {
+			return abortar(http.StatusConflict, gin.H{
+				"erro":         "O pedido não pode mais ser cancelado",
+				"status_atual": pedido.Status,
+			})
+		}
+		devolucoes, estornos, err = cancelarPedido(tx, pedido, clienteEmail, req.Motivo)
+		return err
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao cancelar pedido")
+		return
+	}
+	estornarDevolucoes(context.WithoutCancel(c.Request.Context()), pedidoID, devolucoes, estornos)
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Pedido cancelado com sucesso", "status": models.StatusPedidoCancelado, "devolucoes": devolucoes})
+}
+
+// cancelarPedido leva a "Cancelado" o pedido, já travado e com a transição
+// conferida, devolvendo o estoque. As cobranças pendentes são canceladas e
+// o saldo do que foi pago no cartão fica reservado para estorno
+// (reservarEstorno), devolvido em estornos para estornarDevolucoes chamar o
+// gateway depois do commit. Pix e boletos pagos voltam como "manual", para
+// a equipe devolver fora da loja.
+func cancelarPedido(tx *repository.Repositorios, pedido models.Pedido, usuario, motivo string) (devolucoes []models.DevolucaoCancelamento, estornos []models.Pagamento, err error) {
+	pagamentos, err := tx.Pagamentos.DoPedido(pedido.ID)
+	if err != nil {
+		return nil, nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pagamentos do pedido", "detalhes": err.Error()})
+	}
+
+	for i := range pagamentos {
+		if pagamentos[i], err = tx.Pagamentos.Bloquear(pagamentos[i].ID); err != nil {
+			return nil, nil, err
+		}
+		p := pagamentos[i]
+		if p.Metodo == models.FormaPagamentoCredito && (p.Status == models.StatusPagamentoPendente || p.Status == models.StatusPagamentoAutorizado || p.Status == models.StatusPagamentoEstornoPendente) {
+			return nil, nil, abortar(http.StatusConflict, gin.H{"erro": "Pagamento com cartão em processamento; tente de novo em instantes"})
+		}
+	}
+	if err := mudarStatusPedido(tx, pedido, models.StatusPedidoCancelado, usuario, motivo); err != nil {
+		return nil, nil, err
+	}
+
+	devolucoes = make([]models.DevolucaoCancelamento, 0)
+	for _, p := range pagamentos {
+		switch {
+		case p.Status == models.StatusPagamentoPendente:
+			p.Status = models.StatusPagamentoCancelado
+			if err := tx.Pagamentos.Atualizar(&p); err != nil {
+				return nil, nil, err
+			}
+
+		case p.Status == models.StatusPagamentoPago && p.Metodo == models.FormaPagamentoCredito:
+			saldo := paraCentavos(p.Valor) - paraCentavos(p.ValorEstornado)
+			if saldo <= 0 {
+				continue
+			}
+			mensagem := "Pedido cancelado"
+			if motivo != "" {
+				mensagem += ": " + motivo
+			}
+			if err := reservarEstorno(tx, &p, saldo, usuario, mensagem); err != nil {
+				return nil, nil, err
+			}
+			estornos = append(estornos, p)
+			devolucoes = append(devolucoes, models.DevolucaoCancelamento{PagamentoID: p.ID, Metodo: p.Metodo, Valor: deCentavos(saldo), Resultado: devolucaoPendente})
+
+		case p.Status == models.StatusPagamentoPago:
+			log.Printf("AVISO: Pedido #%d cancelado com %s pago (cobrança %d, R$ %.2f); devolver ao cliente", pedido.ID, p.Metodo, p.ID, p.Valor)
+			devolucoes = append(devolucoes, models.DevolucaoCancelamento{PagamentoID: p.ID, Metodo: p.Metodo, Valor: p.Valor, Resultado: devolucaoManual})
+		}
+	}
+
+	return devolucoes, estornos, nil
+}
+
+// estornarDevolucoes pede ao gateway, já com o cancelamento gravado, os
+// estornos reservados por cancelarPedido e põe o resultado em devolucoes.
+// Um estorno recusado não desfaz o cancelamento: a cobrança continua paga
+// para a equipe estornar depois. Sem resposta do gateway, a devolução fica
+// "estorno_pendente" e a expiração de pagamentos a retoma.
+func estornarDevolucoes(ctx context.Context, pedidoID int, devolucoes []models.DevolucaoCancelamento, estornos []models.Pagamento) {
+	for _, p := range estornos {
+		atual, recusa, err := estornarCartao(ctx, p)
+		resultado, motivo := devolucaoEstornada, ""
+		switch {
+		case err != nil || atual.Status == models.StatusPagamentoEstornoPendente:
+			resultado = devolucaoPendente
+		case recusa != "":
+			log.Printf("AVISO: Estorno da cobrança %d do pedido #%d cancelado recusado (%s); estornar pela equipe", p.ID, pedidoID, recusa)
+			resultado, motivo = devolucaoRecusada, recusa
+		}
+		for i := range devolucoes {
+			if devolucoes[i].PagamentoID == p.ID {
+				devolucoes[i].Resultado, devolucoes[i].Motivo = resultado, motivo
+			}
+		}
+	}
+}
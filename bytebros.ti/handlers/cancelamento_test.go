@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
+)
+
+// gatewaySemEstorno é o gateway em memória sem resposta nos estornos,
+// como numa queda da rede depois do cancelamento gravado.
+type gatewaySemEstorno struct {
+	*pagamento.GatewayMemoria
+}
+
+func (g gatewaySemEstorno) Estornar(ctx context.Context, id, referencia string, centavos int64) (pagamento.Resposta, error) {
+	return pagamento.Resposta{}, errors.New("tempo esgotado")
+}
+
+// pedidoCartao cria um pedido de R$ 230,00 (produto de 200 mais frete de
+// 30) pago no cartão e devolve o produto, com 4 unidades depois da baixa,
+// e a cobrança.
+func pedidoCartao(t *testing.T, r *repository.Repositorios) (models.Produto, models.Pagamento) {
+	t.Helper()
+	p := criarProdutoTeste(t, r, "Headset", 200, 5)
+	cotacao := criarCotacaoTeste(t, r, fmt.Sprintf("cot-cartao-%d", p.ID), fmt.Sprintf("%d:1", p.ID), 30, time.Now().Add(time.Hour))
+	req := pedidoTeste(p.ID, 1, 200, cotacao)
+	req.FormaPagamento = models.FormaPagamentoCredito
+	req.CartaoToken = "tok_visa_4242"
+
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusCreated)
+	var resposta struct {
+		Pagamento models.Pagamento `json:"pagamento"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Pagamento.Status != models.StatusPagamentoPago {
+		t.Fatalf("cobrança = %+v, esperado paga", resposta.Pagamento)
+	}
+	return p, resposta.Pagamento
+}
+
+func cancelarComoCliente(t *testing.T, pedidoID int, email string) *httptest.ResponseRecorder {
+	t.Helper()
+	return requisitar(t, CancelarPedidoCliente, http.MethodPost, "/api/meus-pedidos/:id/cancelar",
+		fmt.Sprintf("/api/meus-pedidos/%d/cancelar", pedidoID), models.CancelarPedidoRequest{Motivo: "Comprei errado"}, email)
+}
+
+func devolucoesDaResposta(t *testing.T, w *httptest.ResponseRecorder) []models.DevolucaoCancelamento {
+	t.Helper()
+	var resposta struct {
+		Status     string                         `json:"status"`
+		Devolucoes []models.DevolucaoCancelamento `json:"devolucoes"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Status != models.StatusPedidoCancelado {
+		t.Errorf("status = %q, esperado %q", resposta.Status, models.StatusPedidoCancelado)
+	}
+	return resposta.Devolucoes
+}
+
+func bloquearPagamento(t *testing.T, r *repository.Repositorios, id int) models.Pagamento {
+	t.Helper()
+	p, err := r.Pagamentos.Bloquear(id)
+	if err != nil {
+		t.Fatalf("obter cobrança %d: %v", id, err)
+	}
+	return p
+}
+
+func TestCancelarPedidoCartaoEstorna(t *testing.T) {
+	r := novoTeste(t)
+	gateway := ligarCartaoMemoria()
+	p, cobranca := pedidoCartao(t, r)
+
+	w := cancelarComoCliente(t, cobranca.PedidoID, clienteTeste)
+	esperarStatus(t, w, http.StatusOK)
+	devolucoes := devolucoesDaResposta(t, w)
+	esperada := models.DevolucaoCancelamento{PagamentoID: cobranca.ID, Metodo: models.FormaPagamentoCredito, Valor: 230, Resultado: devolucaoEstornada}
+	if len(devolucoes) != 1 || devolucoes[0] != esperada {
+		t.Errorf("devoluções = %+v, esperado %+v", devolucoes, esperada)
+	}
+
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoCancelado {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoCancelado)
+	}
+	atual := bloquearPagamento(t, r, cobranca.ID)
+	if atual.Status != models.StatusPagamentoEstornado || atual.ValorEstornado != 230 || atual.EstornoPendente != 0 || atual.EstornoReferencia != "" {
+		t.Errorf("cobrança = %+v, esperado estornada em 230 sem reserva", atual)
+	}
+	transacoes, err := r.Pagamentos.Transacoes(cobranca.ID)
+	if err != nil || len(transacoes) == 0 {
+		t.Fatalf("transações = %+v (%v)", transacoes, err)
+	}
+	if ultima := transacoes[len(transacoes)-1]; ultima.Tipo != models.TransacaoEstorno || !ultima.Sucesso || ultima.Valor != 230 || ultima.Usuario != clienteTeste {
+		t.Errorf("última transação = %+v, esperado o estorno de 230 pelo cliente", ultima)
+	}
+	// O gateway estornou tudo: não sobra nem um centavo para estornar.
+	if e, _ := gateway.Estornar(context.Background(), cobranca.GatewayID, "teste", 1); e.Aprovada {
+		t.Error("o gateway ainda aceita estorno, esperado tudo estornado")
+	}
+
+	// O estoque volta com um movimento de devolução do pedido.
+	if q := quantidadeAtual(t, r, p.ID); q != 5 {
+		t.Errorf("estoque = %d, esperado 5", q)
+	}
+	movimentos := movimentosDoProduto(t, r, p.ID)
+	if len(movimentos) != 2 {
+		t.Fatalf("%d movimentos, esperado 2: %+v", len(movimentos), movimentos)
+	}
+	m := movimentos[0]
+	if m.Tipo == models.MovimentoSaidaPedido {
+		m = movimentos[1]
+	}
+	if m.Tipo != models.MovimentoDevolucao || m.Quantidade != 1 || m.Saldo != 5 || m.PedidoID == nil || *m.PedidoID != cobranca.PedidoID {
+		t.Errorf("movimento = %+v, esperada devolução de 1 do pedido %d com saldo 5", m, cobranca.PedidoID)
+	}
+
+	// Um pedido cancelado não é cancelado de novo.
+	esperarStatus(t, cancelarComoCliente(t, cobranca.PedidoID, clienteTeste), http.StatusConflict)
+}
+
+func TestCancelarPedidoEstornoPendente(t *testing.T) {
+	r := novoTeste(t)
+	gateway := ligarCartaoMemoria()
+	_, cobranca := pedidoCartao(t, r)
+	configCartao.Gateway = gatewaySemEstorno{gateway}
+
+	w := cancelarComoCliente(t, cobranca.PedidoID, clienteTeste)
+	esperarStatus(t, w, http.StatusOK)
+	devolucoes := devolucoesDaResposta(t, w)
+	if len(devolucoes) != 1 || devolucoes[0].Resultado != devolucaoPendente || devolucoes[0].Valor != 230 {
+		t.Errorf("devoluções = %+v, esperado estorno pendente de 230", devolucoes)
+	}
+	// O cancelamento fica gravado com o estorno reservado na cobrança.
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoCancelado {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoCancelado)
+	}
+	pendente := bloquearPagamento(t, r, cobranca.ID)
+	if pendente.Status != models.StatusPagamentoEstornoPendente || pendente.EstornoPendente != 230 || pendente.EstornoReferencia == "" {
+		t.Fatalf("cobrança = %+v, esperado estorno pendente de 230", pendente)
+	}
+
+	// Com o gateway de volta, a retomada conclui o mesmo estorno.
+	configCartao.Gateway = gateway
+	atual, recusa, err := estornarCartao(context.Background(), pendente)
+	if err != nil || recusa != "" || atual.Status != models.StatusPagamentoEstornado || atual.ValorEstornado != 230 {
+		t.Errorf("retomada = %+v, %q, %v; esperado estornada em 230", atual, recusa, err)
+	}
+	// Repetir a retomada com a mesma referência não estorna de novo.
+	if atual, _, err := estornarCartao(context.Background(), pendente); err != nil || atual.ValorEstornado != 230 {
+		t.Errorf("segunda retomada = %+v (%v), esperado 230 estornados", atual, err)
+	}
+}
+
+func TestCancelarPedidoEstornoRecusado(t *testing.T) {
+	r := novoTeste(t)
+	gateway := ligarCartaoMemoria()
+	p, cobranca := pedidoCartao(t, r)
+	// Um estorno feito direto no gateway deixa menos que o pedido para
+	// estornar, e o gateway recusa o saldo inteiro.
+	if e, _ := gateway.Estornar(context.Background(), cobranca.GatewayID, "fora-da-loja", 100); !e.Aprovada {
+		t.Fatalf("estorno fora da loja = %+v", e)
+	}
+
+	w := cancelarComoCliente(t, cobranca.PedidoID, clienteTeste)
+	esperarStatus(t, w, http.StatusOK)
+	devolucoes := devolucoesDaResposta(t, w)
+	if len(devolucoes) != 1 || devolucoes[0].Resultado != devolucaoRecusada || devolucoes[0].Motivo != "Valor acima do capturado" {
+		t.Errorf("devoluções = %+v, esperado estorno recusado", devolucoes)
+	}
+	// A recusa não desfaz o cancelamento; a cobrança volta a paga para a
+	// equipe estornar.
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoCancelado {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoCancelado)
+	}
+	if q := quantidadeAtual(t, r, p.ID); q != 5 {
+		t.Errorf("estoque = %d, esperado 5", q)
+	}
+	atual := bloquearPagamento(t, r, cobranca.ID)
+	if atual.Status != models.StatusPagamentoPago || atual.ValorEstornado != 0 || atual.EstornoPendente != 0 {
+		t.Errorf("cobrança = %+v, esperado paga sem estorno", atual)
+	}
+}
+
+func TestCancelarPedidoDepoisDoEnvio(t *testing.T) {
+	r := novoTeste(t)
+	ligarCartaoMemoria()
+	p, cobranca := pedidoCartao(t, r)
+	for _, status := range []string{models.StatusPedidoSeparando, models.StatusPedidoEnviado} {
+		if err := r.Pedidos.AtualizarStatus(cobranca.PedidoID, status); err != nil {
+			t.Fatalf("mudar para %s: %v", status, err)
+		}
+	}
+
+	w := cancelarComoCliente(t, cobranca.PedidoID, clienteTeste)
+	esperarStatus(t, w, http.StatusConflict)
+	var resposta struct {
+		Erro        string `json:"erro"`
+		StatusAtual string `json:"status_atual"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.StatusAtual != models.StatusPedidoEnviado {
+		t.Errorf("status_atual = %q, esperado %q", resposta.StatusAtual, models.StatusPedidoEnviado)
+	}
+	if q := quantidadeAtual(t, r, p.ID); q != 4 {
+		t.Errorf("estoque = %d, esperado 4", q)
+	}
+	if atual := bloquearPagamento(t, r, cobranca.ID); atual.Status != models.StatusPagamentoPago || atual.ValorEstornado != 0 {
+		t.Errorf("cobrança = %+v, esperado paga sem estorno", atual)
+	}
+}
+
+func TestCancelarPedidoDeOutroCliente(t *testing.T) {
+	r := novoTeste(t)
+	ligarCartaoMemoria()
+	_, cobranca := pedidoCartao(t, r)
+
+	esperarStatus(t, cancelarComoCliente(t, cobranca.PedidoID, "outro@example.com"), http.StatusNotFound)
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoPago {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoPago)
+	}
+}
+
+func TestCancelarPedidoPixPendente(t *testing.T) {
+	r := novoTeste(t)
+	ligarPixFalso(t)
+	p, cobranca := pedidoPix(t, r)
+
+	w := cancelarComoCliente(t, cobranca.PedidoID, clienteTeste)
+	esperarStatus(t, w, http.StatusOK)
+	// Nada foi pago, então não há devolução; a cobrança é cancelada.
+	if devolucoes := devolucoesDaResposta(t, w); len(devolucoes) != 0 {
+		t.Errorf("devoluções = %+v, esperado nenhuma", devolucoes)
+	}
+	if atual := obterPagamento(t, r, cobranca.TxID); atual.Status != models.StatusPagamentoCancelado {
+		t.Errorf("cobrança em %q, esperado %q", atual.Status, models.StatusPagamentoCancelado)
+	}
+	if q := quantidadeAtual(t, r, p.ID); q != 3 {
+		t.Errorf("estoque = %d, esperado 3", q)
+	}
+}
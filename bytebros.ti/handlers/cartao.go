@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configCartao é nil com o cartão desligado (sem CARTAO_GATEWAY).
+var configCartao *pagamento.ConfigCartao
+
+// validadeCobrancaCartao só vale se o servidor cair entre gravar o pedido e
+// ouvir o gateway: a expiração de pagamentos cancela o pedido esquecido.
+const validadeCobrancaCartao = 15 * time.Minute
+
+// esperaEstornoPendente é quanto um estorno fica pendente antes de a
+// expiração de pagamentos retomá-lo, para não disputar com a requisição
+// que o pediu.
+const esperaEstornoPendente = 2 * time.Minute
+
+// ListarParcelas devolve a tabela de parcelas do cartão para ?valor=.
+func ListarParcelas(c *gin.Context) {
+	if configCartao == nil {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Pagamento com cartão desligado"})
+		return
+	}
+	valor, err := strconv.ParseFloat(c.Query("valor"), 64)
+	if err != nil || valor <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Informe um valor positivo em ?valor="})
+		return
+	}
+	c.JSON(http.StatusOK, opcoesParcelamento(paraCentavos(valor)))
+}
+
+func opcoesParcelamento(centavos int64) []models.OpcaoParcelamento {
+	parcelas := configCartao.Parcelamento.Opcoes(centavos)
+	opcoes := make([]models.OpcaoParcelamento, 0, len(parcelas))
+	for _, p := range parcelas {
+		opcoes = append(opcoes, models.OpcaoParcelamento{
+			Parcelas:     p.Parcelas,
+			ValorParcela: deCentavos(p.ValorParcela),
+			Total:        deCentavos(p.Total),
+			TaxaMensal:   p.TaxaMensal,
+			SemJuros:     p.TaxaMensal == 0,
+		})
+	}
+	return opcoes
+}
+
+// criarCobrancaCartao grava a cobrança do pedido, ainda pendente, com o
+// parcelamento calculado aqui; o cliente só escolhe o número de parcelas.
+func criarCobrancaCartao(tx *repository.Repositorios, pedido models.Pedido, parcelas int) (*models.Pagamento, error) {
+	centavos := paraCentavos(pedido.ValorTotal)
+	parcela, err := configCartao.Parcelamento.Calcular(centavos, parcelas)
+	if err != nil {
+		return nil, abortar(http.StatusUnprocessableEntity, gin.H{
+			"erro":     "Parcelamento indisponível para o valor do pedido",
+			"parcelas": parcelas,
+			"opcoes":   opcoesParcelamento(centavos),
+		})
+	}
+	expiraEm := time.Now().Add(validadeCobrancaCartao)
+	p := models.Pagamento{
+		PedidoID:     pedido.ID,
+		Metodo:       models.FormaPagamentoCredito,
+		Status:       models.StatusPagamentoPendente,
+		Valor:        deCentavos(parcela.Total),
+		ExpiraEm:     &expiraEm,
+		Gateway:      configCartao.Gateway.Nome(),
+		Parcelas:     parcela.Parcelas,
+		ValorParcela: deCentavos(parcela.ValorParcela),
+	}
+	if err := tx.Pagamentos.Criar(&p); err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao gravar cobrança no cartão", "detalhes": err.Error()})
+	}
+	return &p, nil
+}
+
+// cobrarCartao autoriza e captura a cobrança no gateway, fora de
+// transação, já que a chamada pode demorar. Com o cartão recusado a
+// cobrança fica "recusada" e o pedido é cancelado, devolvendo o estoque.
+func cobrarCartao(ctx context.Context, pedidoID int, p models.Pagamento, token, usuario string) (models.Pagamento, error) {
+	gateway := configCartao.Gateway
+	centavos := paraCentavos(p.Valor)
+
+	autorizacao, err := gateway.Autorizar(ctx, pagamento.Autorizacao{
+		Referencia: fmt.Sprintf("pagamento-%d", p.ID),
+		Token:      token,
+		Centavos:   centavos,
+		Parcelas:   p.Parcelas,
+	})
+	registrarTransacaoCartao(repos, p.ID, models.TransacaoAutorizacao, p.Valor, autorizacao, err, usuario, "")
+	if err != nil {
+		log.Printf("ERRO: Autorização da cobrança %d no gateway %s: %v", p.ID, gateway.Nome(), err)
+		return p, recusarCartao(pedidoID, p.ID, "Falha na comunicação com o gateway", true)
+	}
+	if !autorizacao.Aprovada {
+		return p, recusarCartao(pedidoID, p.ID, autorizacao.Mensagem, false)
+	}
+
+	captura, err := gateway.Capturar(ctx, autorizacao.ID, centavos)
+	registrarTransacaoCartao(repos, p.ID, models.TransacaoCaptura, p.Valor, captura, err, usuario, "")
+	if err != nil || !captura.Aprovada {
+		motivo := captura.Mensagem
+		if err != nil {
+			log.Printf("ERRO: Captura da cobrança %d no gateway %s: %v", p.ID, gateway.Nome(), err)
+			motivo = "Falha na comunicação com o gateway"
+		}
+		cancelamento, errCancelar := gateway.Cancelar(ctx, autorizacao.ID)
+		registrarTransacaoCartao(repos, p.ID, models.TransacaoCancelamento, p.Valor, cancelamento, errCancelar, usuarioSistema, "")
+		if errCancelar != nil || !cancelamento.Aprovada {
+			log.Printf("AVISO: Autorização %s da cobrança %d não foi cancelada; cancelar no gateway", autorizacao.ID, p.ID)
+		}
+		return p, recusarCartao(pedidoID, p.ID, motivo, err != nil)
+	}
+
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		atual, err := tx.Pagamentos.Bloquear(p.ID)
+		if err != nil {
+			return err
+		}
+		agora := time.Now()
+		atual.Status = models.StatusPagamentoPago
+		atual.PagoEm = &agora
+		atual.GatewayID = autorizacao.ID
+		atual.Bandeira = autorizacao.Bandeira
+		atual.FinalCartao = autorizacao.FinalCartao
+		if err := tx.Pagamentos.Atualizar(&atual); err != nil {
+			return err
+		}
+		p = atual
+
+		pedido, err := tx.Pedidos.Bloquear(pedidoID)
+		if err != nil {
+			return err
+		}
+		if !models.TransicaoPedidoPermitida(pedido.Status, models.StatusPedidoPago) {
+			log.Printf("AVISO: Cobrança %d capturada com o pedido #%d em %s; verificar estorno", p.ID, pedido.ID, pedido.Status)
+			return nil
+		}
+		observacao := fmt.Sprintf("Cartão %s final %s em %dx", atual.Bandeira, atual.FinalCartao, atual.Parcelas)
+		return mudarStatusPedido(tx, pedido, models.StatusPedidoPago, usuario, observacao)
+	})
+	if err != nil {
+		log.Printf("ERRO: Cobrança %d capturada no gateway (autorização %s) mas não gravada: %v", p.ID, autorizacao.ID, err)
+	}
+	return p, err
+}
+
+// recusarCartao encerra a cobrança recusada e cancela o pedido. Devolve o
+// erro que a criação do pedido responde: 402, ou 502 se o gateway falhou.
+func recusarCartao(pedidoID, pagamentoID int, motivo string, falhaGateway bool) error {
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		p, err := tx.Pagamentos.Bloquear(pagamentoID)
+		if err != nil {
+			return err
+		}
+		if p.Status != models.StatusPagamentoPendente {
+			return nil
+		}
+		p.Status = models.StatusPagamentoRecusado
+		if err := tx.Pagamentos.Atualizar(&p); err != nil {
+			return err
+		}
+
+		pedido, err := tx.Pedidos.Bloquear(pedidoID)
+		if err != nil {
+			return err
+		}
+		if pedido.Status != models.StatusPedidoProcessando {
+			return nil
+		}
+		return mudarStatusPedido(tx, pedido, models.StatusPedidoCancelado, usuarioSistema, "Pagamento com cartão recusado: "+motivo)
+	})
+	if err != nil {
+		return err
+	}
+	if falhaGateway {
+		return abortar(http.StatusBadGateway, gin.H{"erro": "Erro ao processar pagamento com cartão", "pedido_id": pedidoID})
+	}
+	return abortar(http.StatusPaymentRequired, gin.H{"erro": "Pagamento com cartão recusado", "motivo": motivo, "pedido_id": pedidoID})
+}
+
+// registrarTransacaoCartao guarda a chamada ao gateway. Uma falha aqui só
+// vai para o log: a operação no gateway já aconteceu.
+func registrarTransacaoCartao(r *repository.Repositorios, pagamentoID int, tipo string, valor float64, resposta pagamento.Resposta, erro error, usuario, mensagem string) {
+	t := transacaoCartao(pagamentoID, tipo, valor, resposta, erro, usuario, mensagem)
+	if err := r.Pagamentos.RegistrarTransacao(&t); err != nil {
+		log.Printf("ERRO BD: Falha ao registrar %s da cobrança %d: %v", tipo, pagamentoID, err)
+	}
+}
+
+func transacaoCartao(pagamentoID int, tipo string, valor float64, resposta pagamento.Resposta, erro error, usuario, mensagem string) models.TransacaoPagamento {
+	t := models.TransacaoPagamento{
+		PagamentoID: pagamentoID,
+		Tipo:        tipo,
+		Valor:       valor,
+		Sucesso:     erro == nil && resposta.Aprovada,
+		GatewayID:   resposta.ID,
+		Mensagem:    mensagem,
+		Usuario:     usuario,
+	}
+	switch {
+	case erro != nil:
+		t.Mensagem = erro.Error()
+	case !resposta.Aprovada || mensagem == "":
+		t.Mensagem = resposta.Mensagem
+	}
+	return t
+}
+
+// reservarEstorno grava na cobrança, já travada em tx, o estorno a pedir ao
+// gateway. Em "estorno_pendente" ela não aceita outro estorno nem o
+// cancelamento do pedido até estornarCartao gravar o resultado.
+func reservarEstorno(tx *repository.Repositorios, p *models.Pagamento, centavos int64, usuario, motivo string) error {
+	p.Status = models.StatusPagamentoEstornoPendente
+	p.EstornoPendente = deCentavos(centavos)
+	p.EstornoReferencia = fmt.Sprintf("estorno-%d-%d", p.ID, time.Now().UnixNano())
+	p.EstornoMotivo = motivo
+	p.EstornoUsuario = usuario
+	return tx.Pagamentos.Atualizar(p)
+}
+
+// estornarCartao pede ao gateway o estorno reservado em p, fora de
+// transação, e grava o resultado numa transação curta. A chamada leva a
+// referência da reserva como chave de idempotência, então repeti-la não
+// estorna duas vezes. Se o gateway não responde, ou a resposta não é
+// gravada, a cobrança continua pendente e a expiração de pagamentos tenta
+// de novo. A recusa do gateway não é erro: volta em recusa, com a cobrança
+// de volta a "pago".
+func estornarCartao(ctx context.Context, p models.Pagamento) (atual models.Pagamento, recusa string, err error) {
+	centavos := paraCentavos(p.EstornoPendente)
+	var resposta pagamento.Resposta
+	if configCartao == nil {
+		resposta.Mensagem = "Pagamento com cartão desligado"
+	} else {
+		var errGateway error
+		resposta, errGateway = configCartao.Gateway.Estornar(ctx, p.GatewayID, p.EstornoReferencia, centavos)
+		if errGateway != nil {
+			log.Printf("ERRO: Estorno da cobrança %d no gateway %s: %v", p.ID, configCartao.Gateway.Nome(), errGateway)
+			registrarTransacaoCartao(repos, p.ID, models.TransacaoEstorno, p.EstornoPendente, resposta, errGateway, p.EstornoUsuario, p.EstornoMotivo)
+			return p, "", nil
+		}
+	}
+
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		atual, err = tx.Pagamentos.Bloquear(p.ID)
+		if err != nil {
+			return err
+		}
+		// Outra chamada já gravou o resultado deste estorno.
+		if atual.Status != models.StatusPagamentoEstornoPendente || atual.EstornoReferencia != p.EstornoReferencia {
+			return nil
+		}
+		t := transacaoCartao(p.ID, models.TransacaoEstorno, p.EstornoPendente, resposta, nil, p.EstornoUsuario, p.EstornoMotivo)
+		if err := tx.Pagamentos.RegistrarTransacao(&t); err != nil {
+			return err
+		}
+
+		atual.Status = models.StatusPagamentoPago
+		if resposta.Aprovada {
+			estornado := paraCentavos(atual.ValorEstornado) + centavos
+			atual.ValorEstornado = deCentavos(estornado)
+			if estornado >= paraCentavos(atual.Valor) {
+				atual.Status = models.StatusPagamentoEstornado
+			}
+		}
+		atual.EstornoPendente, atual.EstornoReferencia, atual.EstornoMotivo, atual.EstornoUsuario = 0, "", "", ""
+		return tx.Pagamentos.Atualizar(&atual)
+	})
+	if err != nil {
+		log.Printf("ERRO: Estorno %s da cobrança %d respondido pelo gateway mas não gravado; será retomado: %v", p.EstornoReferencia, p.ID, err)
+		return p, "", err
+	}
+	if !resposta.Aprovada {
+		return atual, resposta.Mensagem, nil
+	}
+	return atual, "", nil
+}
+
+// retomarEstornos repete no gateway os estornos que ficaram pendentes há
+// mais de esperaEstornoPendente, com a mesma chave de idempotência.
+func retomarEstornos(ctx context.Context) {
+	pendentes, err := repos.Pagamentos.EstornosPendentes(time.Now().Add(-esperaEstornoPendente))
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao buscar estornos pendentes: %v", err)
+		return
+	}
+	for _, p := range pendentes {
+		if ctx.Err() != nil {
+			return
+		}
+		atual, recusa, err := estornarCartao(ctx, p)
+		switch {
+		case err != nil || atual.Status == models.StatusPagamentoEstornoPendente:
+			continue
+		case recusa != "":
+			log.Printf("AVISO: Estorno pendente da cobrança %d recusado (%s); estornar pela equipe", p.ID, recusa)
+		default:
+			log.Printf("Estorno pendente da cobrança %d concluído", p.ID)
+		}
+	}
+}
+
+// EstornarPagamento devolve ao cliente parte ou todo o valor de uma
+// cobrança no cartão. O status do pedido não muda. O estorno é reservado
+// antes da chamada ao gateway; se ela não tiver resposta, a requisição
+// devolve 202 e o estorno é retomado depois.
+func EstornarPagamento(c *gin.Context) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	pagamentoID, ok := idDoParametro(c, "pagamentoId")
+	if !ok {
+		return
+	}
+	var req models.EstornoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	var p models.Pagamento
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		var err error
+		p, err = tx.Pagamentos.Bloquear(pagamentoID)
+		if errors.Is(err, repository.ErrNaoEncontrado) || err == nil && p.PedidoID != pedidoID {
+			return abortar(http.StatusNotFound, gin.H{"erro": "Pagamento não encontrado"})
+		}
+		if err != nil {
+			return err
+		}
+		if p.Metodo != models.FormaPagamentoCredito {
+			return abortar(http.StatusUnprocessableEntity, gin.H{"erro": "Só pagamentos com cartão são estornados pela loja"})
+		}
+		if p.Status != models.StatusPagamentoPago {
+			return abortar(http.StatusConflict, gin.H{"erro": "Só pagamentos capturados podem ser estornados", "status": p.Status})
+		}
+
+		saldo := paraCentavos(p.Valor) - paraCentavos(p.ValorEstornado)
+		centavos := saldo
+		if req.Valor != nil {
+			centavos = paraCentavos(*req.Valor)
+		}
+		if centavos > saldo {
+			return abortar(http.StatusUnprocessableEntity, gin.H{"erro": "Valor acima do saldo a estornar", "saldo": deCentavos(saldo)})
+		}
+		return reservarEstorno(tx, &p, centavos, emailDaRequisicao(c), req.Motivo)
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao estornar pagamento")
+		return
+	}
+
+	p, recusa, err := estornarCartao(context.WithoutCancel(c.Request.Context()), p)
+	switch {
+	case err != nil || p.Status == models.StatusPagamentoEstornoPendente:
+		c.JSON(http.StatusAccepted, gin.H{"mensagem": "Estorno em processamento; o resultado será gravado automaticamente", "pagamento": p})
+	case recusa != "":
+		c.JSON(http.StatusBadGateway, gin.H{"erro": "O gateway recusou o estorno", "motivo": recusa})
+	default:
+		c.JSON(http.StatusOK, p)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+// TestCriarPedidoCartaoParceladoComJuros confere que o total com juros sai
+// do servidor, não do cliente, e é o que o gateway captura.
+func TestCriarPedidoCartaoParceladoComJuros(t *testing.T) {
+	r := novoTeste(t)
+	gateway := ligarCartaoMemoria()
+	p := criarProdutoTeste(t, r, "Monitor 34", 970, 2)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 30, time.Now().Add(time.Hour))
+
+	req := pedidoTeste(p.ID, 1, 970, cotacao)
+	req.FormaPagamento = models.FormaPagamentoCredito
+	req.CartaoToken = "tok_visa_4242"
+	req.Parcelas = 10
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusCreated)
+
+	var resposta struct {
+		Pagamento models.Pagamento `json:"pagamento"`
+	}
+	lerJSON(t, w, &resposta)
+	pg := resposta.Pagamento
+	// R$ 1.000,00 em 10x a 1,99% ao mês: 10 de R$ 111,27.
+	if pg.Status != models.StatusPagamentoPago || pg.Parcelas != 10 || pg.ValorParcela != 111.27 || pg.Valor != 1112.7 {
+		t.Fatalf("pagamento = %+v, esperado pago em 10x de 111,27 (1112,70)", pg)
+	}
+
+	// O gateway capturou o total com juros: estorna até ele e nada além.
+	ctx := context.Background()
+	if e, _ := gateway.Estornar(ctx, pg.GatewayID, "teste-1", 111271); e.Aprovada {
+		t.Errorf("estorno de 1112,71 aprovado, esperado acima do capturado")
+	}
+	if e, _ := gateway.Estornar(ctx, pg.GatewayID, "teste-2", 111270); !e.Aprovada {
+		t.Errorf("estorno de 1112,70 = %+v, esperado aprovado", e)
+	}
+}
+
+func TestCriarPedidoCartaoParcelasIndisponiveis(t *testing.T) {
+	r := novoTeste(t)
+	ligarCartaoMemoria()
+	p := criarProdutoTeste(t, r, "Mouse", 20, 3)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 30, time.Now().Add(time.Hour))
+
+	// R$ 50,00 vai só até 5x.
+	req := pedidoTeste(p.ID, 1, 20, cotacao)
+	req.FormaPagamento = models.FormaPagamentoCredito
+	req.CartaoToken = "tok_visa_4242"
+	req.Parcelas = 6
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusUnprocessableEntity)
+
+	var resposta struct {
+		Erro   string                     `json:"erro"`
+		Opcoes []models.OpcaoParcelamento `json:"opcoes"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Erro != "Parcelamento indisponível para o valor do pedido" || len(resposta.Opcoes) != 5 {
+		t.Errorf("resposta = %+v, esperado as opções de 1x a 5x", resposta)
+	}
+	if n := pedidosGravados(t, r); n != 0 {
+		t.Errorf("%d pedidos gravados, esperado nenhum", n)
+	}
+	if q := quantidadeAtual(t, r, p.ID); q != 3 {
+		t.Errorf("estoque = %d, esperado 3", q)
+	}
+}
@@ -0,0 +1,664 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/planilha"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// colunasCatalogo são as colunas da planilha do catálogo, na ordem da
+// exportação. Linhas com sku descrevem uma variante do produto nome; as
+// demais, o próprio produto.
+var colunasCatalogo = []string{"nome", "sku", "atributos", "preco", "quantidade", "estoque_minimo", "oferta", "detalhes", "imagem", "categorias"}
+
+// colunasSoDeProduto não valem em linhas de variante.
+var colunasSoDeProduto = []string{"estoque_minimo", "oferta", "detalhes", "imagem", "categorias"}
+
+const (
+	maxBytesPlanilha  = 10 << 20
+	maxLinhasPlanilha = 5000
+	maxPreco          = 99999999.99
+	// separadorLista separa as categorias e os atributos dentro da célula.
+	separadorLista = "|"
+)
+
+// errSimulacao desfaz a transação de uma importação simulada; errImportacao,
+// a de uma importação com erros.
+var (
+	errSimulacao  = errors.New("simulação de importação")
+	errImportacao = errors.New("importação com erros")
+)
+
+// linhaCatalogo é uma linha já validada. Campos nil (ou célula vazia) não
+// foram informados: na atualização, mantêm o valor atual.
+type linhaCatalogo struct {
+	numero        int
+	nome          string
+	sku           string
+	atributos     map[string]string
+	preco         *float64
+	quantidade    *int
+	estoqueMinimo *int
+	oferta        *bool
+	detalhes      *string
+	imagem        *string
+	categorias    []int
+}
+
+// ImportarProdutos cria e atualiza produtos e variantes a partir de uma
+// planilha CSV ou XLSX (campo arquivo). Produtos são casados pelo nome e
+// variantes pelo SKU. Tudo roda numa transação: com qualquer erro nada é
+// gravado e a resposta lista os problemas por linha. Com simular=true a
+// importação é feita e desfeita, só para validar.
+func ImportarProdutos(c *gin.Context) {
+	simular := c.Query("simular") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytesPlanilha+1<<20)
+	cabecalho, err := c.FormFile("arquivo")
+	if err != nil {
+		var muitoGrande *http.MaxBytesError
+		if errors.As(err, &muitoGrande) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": fmt.Sprintf("Planilha maior que %d MB", maxBytesPlanilha>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Envie a planilha como multipart/form-data no campo arquivo", "detalhes": err.Error()})
+		return
+	}
+	formato, err := planilha.FormatoDoArquivo(cabecalho.Filename)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"erro": "Formato não suportado: envie .csv ou .xlsx", "formatos_aceitos": planilha.Formatos})
+		return
+	}
+	if cabecalho.Size > maxBytesPlanilha {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": fmt.Sprintf("Planilha maior que %d MB", maxBytesPlanilha>>20)})
+		return
+	}
+	arquivo, err := cabecalho.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler planilha", "detalhes": err.Error()})
+		return
+	}
+	defer arquivo.Close()
+	celulas, err := planilha.Ler(formato, arquivo, cabecalho.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Planilha inválida", "detalhes": err.Error()})
+		return
+	}
+
+	categorias, err := repos.Categorias.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categorias", "detalhes": err.Error()})
+		return
+	}
+
+	resultado := models.ResultadoImportacao{Simulacao: simular, Erros: make([]models.ErroImportacao, 0)}
+	linhas := lerLinhasCatalogo(celulas, categorias, &resultado)
+	if len(resultado.Erros) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, resultado)
+		return
+	}
+
+	usuario := emailDaRequisicao(c)
+	var repostos []int
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		var err error
+		repostos, err = aplicarLinhasCatalogo(tx, linhas, usuario, &resultado)
+		if err != nil {
+			return err
+		}
+		if len(resultado.Erros) > 0 {
+			return errImportacao
+		}
+		if simular {
+			return errSimulacao
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errImportacao):
+		c.JSON(http.StatusUnprocessableEntity, resultado)
+	case errors.Is(err, errSimulacao):
+		c.JSON(http.StatusOK, resultado)
+	case err != nil:
+		responderErro(c, err, "Erro ao importar produtos")
+	default:
+		for _, id := range repostos {
+			go avisarReposicao(id)
+		}
+		c.JSON(http.StatusOK, resultado)
+	}
+}
+
+// lerLinhasCatalogo confere o cabeçalho e valida cada linha isoladamente,
+// acumulando os erros no resultado. Linhas em branco são ignoradas.
+func lerLinhasCatalogo(celulas [][]string, categorias []models.Categoria, resultado *models.ResultadoImportacao) []linhaCatalogo {
+	erro := func(linha int, coluna, mensagem string) {
+		resultado.Erros = append(resultado.Erros, models.ErroImportacao{Linha: linha, Coluna: coluna, Erro: mensagem})
+	}
+	if len(celulas) == 0 {
+		erro(1, "", "Planilha vazia")
+		return nil
+	}
+
+	indices := make(map[string]int)
+	for i, nome := range celulas[0] {
+		nome = strings.ToLower(strings.TrimSpace(nome))
+		switch {
+		case nome == "":
+		case !slices.Contains(colunasCatalogo, nome):
+			erro(1, nome, "Coluna desconhecida; use "+strings.Join(colunasCatalogo, ", "))
+		default:
+			if _, repetida := indices[nome]; repetida {
+				erro(1, nome, "Coluna repetida")
+			}
+			indices[nome] = i
+		}
+	}
+	if _, ok := indices["nome"]; !ok {
+		erro(1, "nome", "A coluna nome é obrigatória")
+	}
+	if len(resultado.Erros) > 0 {
+		return nil
+	}
+	if len(celulas)-1 > maxLinhasPlanilha {
+		erro(1, "", fmt.Sprintf("A planilha pode ter no máximo %d linhas", maxLinhasPlanilha))
+		return nil
+	}
+
+	categoriaPorChave := make(map[string]int, 2*len(categorias))
+	for _, cat := range categorias {
+		categoriaPorChave[cat.Slug] = cat.ID
+		categoriaPorChave[strconv.Itoa(cat.ID)] = cat.ID
+	}
+
+	linhas := make([]linhaCatalogo, 0, len(celulas)-1)
+	for n, cels := range celulas[1:] {
+		numero := n + 2
+		celula := func(coluna string) string {
+			if i, ok := indices[coluna]; ok && i < len(cels) {
+				return strings.TrimSpace(cels[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(cels, "")) == "" {
+			continue
+		}
+		resultado.Linhas++
+		errosAntes := len(resultado.Erros)
+
+		l := linhaCatalogo{numero: numero, nome: celula("nome"), sku: strings.ToUpper(celula("sku"))}
+		switch {
+		case l.nome == "":
+			erro(numero, "nome", "Nome é obrigatório")
+		case len([]rune(l.nome)) > 100:
+			erro(numero, "nome", "Nome com mais de 100 caracteres")
+		}
+		if len(l.sku) > 64 {
+			erro(numero, "sku", "SKU com mais de 64 caracteres")
+		}
+
+		if v := celula("atributos"); v != "" {
+			if l.sku == "" {
+				erro(numero, "atributos", "Atributos só valem em linhas de variante (com sku)")
+			}
+			l.atributos = make(map[string]string)
+			for _, par := range strings.Split(v, separadorLista) {
+				nome, valor, ok := strings.Cut(par, "=")
+				nome, valor = strings.ToLower(strings.TrimSpace(nome)), strings.TrimSpace(valor)
+				if !ok || nome == "" || valor == "" {
+					erro(numero, "atributos", "Use nome=valor separados por "+separadorLista+", como capacidade=16GB|cor=Preto")
+					break
+				}
+				l.atributos[nome] = valor
+			}
+		}
+		if v := celula("preco"); v != "" {
+			preco, err := precoDaCelula(v)
+			if err != nil {
+				erro(numero, "preco", err.Error())
+			}
+			l.preco = &preco
+		}
+		if v := celula("quantidade"); v != "" {
+			q, err := strconv.Atoi(v)
+			if err != nil || q < 0 {
+				erro(numero, "quantidade", "Quantidade deve ser um inteiro não negativo")
+			}
+			l.quantidade = &q
+		}
+
+		if l.sku != "" {
+			for _, coluna := range colunasSoDeProduto {
+				if celula(coluna) != "" {
+					erro(numero, coluna, "Coluna não vale em linhas de variante; informe-a na linha do produto")
+				}
+			}
+		}
+		if v := celula("estoque_minimo"); v != "" {
+			m, err := strconv.Atoi(v)
+			if err != nil || m < 0 {
+				erro(numero, "estoque_minimo", "Estoque mínimo deve ser um inteiro não negativo")
+			}
+			l.estoqueMinimo = &m
+		}
+		if v := celula("oferta"); v != "" {
+			oferta, ok := simNao(v)
+			if !ok {
+				erro(numero, "oferta", "Use sim ou não")
+			}
+			l.oferta = &oferta
+		}
+		if v := celula("detalhes"); v != "" {
+			l.detalhes = &v
+		}
+		if v := celula("imagem"); v != "" {
+			l.imagem = &v
+		}
+		if v := celula("categorias"); v != "" {
+			l.categorias = make([]int, 0)
+			for _, chave := range strings.Split(v, separadorLista) {
+				chave = strings.TrimSpace(chave)
+				id, ok := categoriaPorChave[chave]
+				if !ok {
+					erro(numero, "categorias", "Categoria não encontrada: "+chave)
+					continue
+				}
+				l.categorias = append(l.categorias, id)
+			}
+		}
+
+		if len(resultado.Erros) == errosAntes {
+			linhas = append(linhas, l)
+		}
+	}
+	return linhas
+}
+
+// aplicarLinhasCatalogo grava as linhas na transação, na ordem da planilha,
+// acumulando no resultado os erros que dependem do banco. Devolve os
+// produtos que ficaram com estoque, para os avisos de reposição.
+func aplicarLinhasCatalogo(tx *repository.Repositorios, linhas []linhaCatalogo, usuario string, resultado *models.ResultadoImportacao) ([]int, error) {
+	erro := func(linha int, coluna, mensagem string) {
+		resultado.Erros = append(resultado.Erros, models.ErroImportacao{Linha: linha, Coluna: coluna, Erro: mensagem})
+	}
+
+	produtos, _, err := tx.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{})
+	if err != nil {
+		return nil, err
+	}
+	porNome := make(map[string][]int)
+	promocoes := make(map[int]*models.Promocao)
+	for _, p := range produtos {
+		chave := strings.ToLower(p.Nome)
+		porNome[chave] = append(porNome[chave], p.ID)
+		promocoes[p.ID] = p.Promocao
+	}
+	variantes, err := tx.Variantes.Todas()
+	if err != nil {
+		return nil, err
+	}
+	porSKU := make(map[string]models.ProdutoVariante, len(variantes))
+	comVariantes := make(map[int]bool)
+	for _, v := range variantes {
+		porSKU[v.SKU] = v
+		comVariantes[v.ProdutoID] = true
+	}
+
+	// Cada produto e cada SKU só pode aparecer uma vez.
+	linhaDoProduto := make(map[string]int)
+	linhaDoSKU := make(map[string]int)
+	tocados := make(map[int]bool)
+	sincronizar := make(map[int]bool)
+
+	for _, l := range linhas {
+		chave := strings.ToLower(l.nome)
+		ids := porNome[chave]
+		if len(ids) > 1 {
+			erro(l.numero, "nome", "Há mais de um produto com este nome; renomeie-os antes de importar")
+			continue
+		}
+
+		if l.sku == "" {
+			if anterior, ok := linhaDoProduto[chave]; ok {
+				erro(l.numero, "nome", fmt.Sprintf("Produto repetido (já aparece na linha %d)", anterior))
+				continue
+			}
+			linhaDoProduto[chave] = l.numero
+
+			if len(ids) == 0 {
+				if l.preco == nil {
+					erro(l.numero, "preco", "Preço é obrigatório para criar o produto")
+					continue
+				}
+				id, err := criarProdutoImportado(tx, l, usuario)
+				if err != nil {
+					return nil, err
+				}
+				porNome[chave] = []int{id}
+				tocados[id] = true
+				resultado.ProdutosCriados++
+				continue
+			}
+			if l.preco != nil && validarPromocao(*l.preco, promocoes[ids[0]]) != "" {
+				erro(l.numero, "preco", "O preço deve ser maior que o da promoção do produto")
+				continue
+			}
+			if err := atualizarProdutoImportado(tx, ids[0], l, comVariantes[ids[0]], usuario); err != nil {
+				return nil, err
+			}
+			tocados[ids[0]] = true
+			resultado.ProdutosAtualizados++
+			continue
+		}
+
+		if anterior, ok := linhaDoSKU[l.sku]; ok {
+			erro(l.numero, "sku", fmt.Sprintf("SKU repetido (já aparece na linha %d)", anterior))
+			continue
+		}
+		linhaDoSKU[l.sku] = l.numero
+		if len(ids) == 0 {
+			erro(l.numero, "nome", "Produto não encontrado; inclua uma linha do produto (sem sku) antes das variantes")
+			continue
+		}
+		produtoID := ids[0]
+
+		atual, existe := porSKU[l.sku]
+		switch {
+		case existe && atual.ProdutoID != produtoID:
+			erro(l.numero, "sku", "SKU pertence a outra variante de outro produto")
+			continue
+		case !existe && l.atributos == nil:
+			erro(l.numero, "atributos", "Atributos são obrigatórios para criar a variante")
+			continue
+		}
+		v, err := gravarVarianteImportada(tx, produtoID, atual, existe, l, usuario)
+		if err != nil {
+			return nil, err
+		}
+		porSKU[v.SKU] = v
+		comVariantes[produtoID] = true
+		tocados[produtoID] = true
+		sincronizar[produtoID] = true
+		if existe {
+			resultado.VariantesAtualizadas++
+		} else {
+			resultado.VariantesCriadas++
+		}
+	}
+
+	ids := make([]int, 0, len(sincronizar))
+	for id := range sincronizar {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if _, err := sincronizarEstoqueProduto(tx, id, usuario); err != nil {
+			return nil, err
+		}
+	}
+
+	repostos := make([]int, 0, len(tocados))
+	for id := range tocados {
+		repostos = append(repostos, id)
+	}
+	sort.Ints(repostos)
+	return repostos, nil
+}
+
+func criarProdutoImportado(tx *repository.Repositorios, l linhaCatalogo, usuario string) (int, error) {
+	produto := models.Produto{Nome: l.nome, Preco: *l.preco}
+	aplicarCamposImportados(&produto, l)
+	if err := tx.Produtos.Criar(&produto); err != nil {
+		return 0, err
+	}
+	if err := registrarPrecoProduto(tx, produto, usuario); err != nil {
+		return 0, err
+	}
+	if l.quantidade != nil && *l.quantidade > 0 {
+		err := movimentarEstoque(tx, &models.MovimentoEstoque{
+			ProdutoID:  produto.ID,
+			Tipo:       models.MovimentoEntrada,
+			Quantidade: *l.quantidade,
+			Motivo:     "Estoque inicial da importação de catálogo",
+			Usuario:    usuario,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if l.categorias != nil {
+		if err := definirCategoriasDoProduto(tx, produto.ID, l.categorias); err != nil {
+			return 0, err
+		}
+	}
+	return produto.ID, nil
+}
+
+// atualizarProdutoImportado aplica só os campos informados. Como em
+// AtualizarProduto, a quantidade de produtos com variantes é ignorada.
+func atualizarProdutoImportado(tx *repository.Repositorios, id int, l linhaCatalogo, temVariantes bool, usuario string) error {
+	atuais, err := tx.Produtos.Bloquear([]int{id})
+	if err != nil {
+		return err
+	}
+	produto := atuais[id]
+	precoAnterior := produto.Preco
+	produto.Nome = l.nome
+	if l.preco != nil {
+		produto.Preco = *l.preco
+	}
+	aplicarCamposImportados(&produto, l)
+	if err := tx.Produtos.Atualizar(produto); err != nil {
+		return err
+	}
+	if paraCentavos(precoAnterior) != paraCentavos(produto.Preco) {
+		if err := registrarPrecoProduto(tx, produto, usuario); err != nil {
+			return err
+		}
+	}
+	if l.quantidade != nil && !temVariantes {
+		if delta := *l.quantidade - produto.Quantidade; delta != 0 {
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  id,
+				Tipo:       models.MovimentoAjuste,
+				Quantidade: delta,
+				Motivo:     "Importação de catálogo",
+				Usuario:    usuario,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if l.categorias != nil {
+		return definirCategoriasDoProduto(tx, id, l.categorias)
+	}
+	return nil
+}
+
+// aplicarCamposImportados copia os campos informados. Em produtos com
+// promoção a oferta segue a vigência dela e a coluna oferta é ignorada.
+func aplicarCamposImportados(produto *models.Produto, l linhaCatalogo) {
+	if l.oferta != nil && produto.Promocao == nil {
+		produto.Oferta = *l.oferta
+	}
+	if l.detalhes != nil {
+		produto.Detalhes = sql.NullString{String: *l.detalhes, Valid: true}
+	}
+	if l.imagem != nil {
+		produto.Imagem = sql.NullString{String: *l.imagem, Valid: true}
+	}
+	if l.estoqueMinimo != nil {
+		produto.EstoqueMinimo = *l.estoqueMinimo
+	}
+}
+
+// gravarVarianteImportada cria ou atualiza a variante; a diferença de
+// estoque entra pelo livro-razão.
+func gravarVarianteImportada(tx *repository.Repositorios, produtoID int, atual models.ProdutoVariante, existe bool, l linhaCatalogo, usuario string) (models.ProdutoVariante, error) {
+	v := atual
+	if !existe {
+		v = models.ProdutoVariante{ProdutoID: produtoID, SKU: l.sku}
+	}
+	if l.atributos != nil {
+		v.Atributos = l.atributos
+	}
+	if l.preco != nil {
+		v.Preco = l.preco
+	}
+
+	var err error
+	if existe {
+		err = tx.Variantes.Atualizar(v)
+	} else {
+		err = tx.Variantes.Criar(&v)
+	}
+	if err != nil {
+		return v, err
+	}
+
+	if l.quantidade != nil {
+		if delta := *l.quantidade - v.Quantidade; delta != 0 {
+			tipo, motivo := models.MovimentoAjuste, "Importação de catálogo"
+			if !existe {
+				tipo, motivo = models.MovimentoEntrada, "Estoque inicial da variante "+v.SKU
+			}
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  produtoID,
+				VarianteID: &v.ID,
+				Tipo:       tipo,
+				Quantidade: delta,
+				Motivo:     motivo,
+				Usuario:    usuario,
+			})
+			if err != nil {
+				return v, err
+			}
+			v.Quantidade = *l.quantidade
+		}
+	}
+	return v, nil
+}
+
+// precoDaCelula aceita "1234.56", "1234,56" e "1.234,56", com ou sem "R$".
+func precoDaCelula(v string) (float64, error) {
+	v = strings.TrimSpace(strings.TrimPrefix(v, "R$"))
+	if strings.Contains(v, ",") {
+		v = strings.ReplaceAll(strings.ReplaceAll(v, ".", ""), ",", ".")
+	}
+	preco, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(preco) || preco < 0.01 || preco > maxPreco {
+		return 0, fmt.Errorf("Preço deve ser um número entre 0,01 e %.2f", maxPreco)
+	}
+	return math.Round(preco*100) / 100, nil
+}
+
+func simNao(v string) (bool, bool) {
+	switch strings.ToLower(v) {
+	case "sim", "s", "true", "1":
+		return true, true
+	case "não", "nao", "n", "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// ExportarProdutos envia o catálogo na planilha aceita pela importação
+// (formato=csv, o padrão, ou xlsx). Cada produto é escrito na resposta
+// assim que é lido do banco, sem carregar o catálogo inteiro.
+func ExportarProdutos(c *gin.Context) {
+	formato := c.DefaultQuery("formato", planilha.CSV)
+	if !slices.Contains(planilha.Formatos, formato) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Formato inválido", "formatos_aceitos": planilha.Formatos})
+		return
+	}
+
+	categorias, err := repos.Categorias.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categorias", "detalhes": err.Error()})
+		return
+	}
+	slugs := make(map[int]string, len(categorias))
+	for _, cat := range categorias {
+		slugs[cat.ID] = cat.Slug
+	}
+
+	nome := fmt.Sprintf("produtos-%s.%s", time.Now().Format("20060102"), formato)
+	c.Header("Content-Type", planilha.ContentType(formato))
+	c.Header("Content-Disposition", `attachment; filename="`+nome+`"`)
+	c.Status(http.StatusOK)
+
+	// Com a resposta já iniciada, uma falha só pode ir para o log.
+	escritor, err := planilha.NovoEscritor(formato, c.Writer)
+	if err == nil {
+		err = escreverCatalogo(escritor, slugs)
+	}
+	if err != nil {
+		log.Printf("ERRO: Falha ao exportar catálogo: %v", err)
+	}
+}
+
+func escreverCatalogo(e planilha.Escritor, slugs map[int]string) error {
+	if err := e.Escrever(colunasCatalogo); err != nil {
+		return err
+	}
+	err := repos.Produtos.Percorrer(func(p models.Produto, variantes []models.ProdutoVariante) error {
+		cats := make([]string, 0, len(p.CategoriaIDs))
+		for _, id := range p.CategoriaIDs {
+			cats = append(cats, slugs[id])
+		}
+		oferta := "não"
+		if p.Oferta {
+			oferta = "sim"
+		}
+		err := e.Escrever([]string{
+			p.Nome, "", "", strconv.FormatFloat(p.Preco, 'f', 2, 64), strconv.Itoa(p.Quantidade),
+			strconv.Itoa(p.EstoqueMinimo), oferta, p.Detalhes.String, p.Imagem.String, strings.Join(cats, separadorLista),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, v := range variantes {
+			nomes := make([]string, 0, len(v.Atributos))
+			for nome := range v.Atributos {
+				nomes = append(nomes, nome)
+			}
+			sort.Strings(nomes)
+			atributos := make([]string, len(nomes))
+			for i, nome := range nomes {
+				atributos[i] = nome + "=" + v.Atributos[nome]
+			}
+			preco := ""
+			if v.Preco != nil {
+				preco = strconv.FormatFloat(*v.Preco, 'f', 2, 64)
+			}
+			err := e.Escrever([]string{
+				p.Nome, v.SKU, strings.Join(atributos, separadorLista), preco, strconv.Itoa(v.Quantidade),
+				"", "", "", "", "",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return e.Fechar()
+}
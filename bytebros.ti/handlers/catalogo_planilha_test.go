@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bytebros.ti/models"
+	"bytebros.ti/planilha"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// planilhaCatalogo monta a planilha com o cabeçalho de colunasCatalogo;
+// cada linha pode omitir as colunas vazias do fim.
+func planilhaCatalogo(t *testing.T, formato string, linhas ...[]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	e, err := planilha.NovoEscritor(formato, &buf)
+	if err != nil {
+		t.Fatalf("escritor: %v", err)
+	}
+	for _, l := range append([][]string{colunasCatalogo}, linhas...) {
+		if err := e.Escrever(l); err != nil {
+			t.Fatalf("escrever: %v", err)
+		}
+	}
+	if err := e.Fechar(); err != nil {
+		t.Fatalf("fechar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func importarPlanilha(t *testing.T, nome string, conteudo []byte, simular bool) (*httptest.ResponseRecorder, models.ResultadoImportacao) {
+	t.Helper()
+	var corpo bytes.Buffer
+	form := multipart.NewWriter(&corpo)
+	parte, err := form.CreateFormFile("arquivo", nome)
+	if err != nil {
+		t.Fatalf("multipart: %v", err)
+	}
+	parte.Write(conteudo)
+	form.Close()
+
+	caminho := "/api/admin/produtos/importar"
+	if simular {
+		caminho += "?simular=true"
+	}
+	router := gin.New()
+	router.POST("/api/admin/produtos/importar", func(c *gin.Context) {
+		c.Set("email", adminTeste)
+		c.Next()
+	}, ImportarProdutos)
+	req := httptest.NewRequest(http.MethodPost, caminho, &corpo)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resultado models.ResultadoImportacao
+	if w.Code == http.StatusOK || w.Code == http.StatusUnprocessableEntity {
+		lerJSON(t, w, &resultado)
+	}
+	return w, resultado
+}
+
+func produtoPorNome(t *testing.T, r *repository.Repositorios, nome string) (models.Produto, bool) {
+	t.Helper()
+	produtos, _, err := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{})
+	if err != nil {
+		t.Fatalf("listar produtos: %v", err)
+	}
+	for _, p := range produtos {
+		if p.Nome == nome {
+			return p, true
+		}
+	}
+	return models.Produto{}, false
+}
+
+func variantesPorSKU(t *testing.T, r *repository.Repositorios) map[string]models.ProdutoVariante {
+	t.Helper()
+	variantes, err := r.Variantes.Todas()
+	if err != nil {
+		t.Fatalf("listar variantes: %v", err)
+	}
+	porSKU := make(map[string]models.ProdutoVariante, len(variantes))
+	for _, v := range variantes {
+		porSKU[v.SKU] = v
+	}
+	return porSKU
+}
+
+func conferirContagens(t *testing.T, resultado models.ResultadoImportacao, esperado models.ResultadoImportacao) {
+	t.Helper()
+	if len(resultado.Erros) > 0 {
+		t.Fatalf("erros = %+v", resultado.Erros)
+	}
+	if resultado.Simulacao != esperado.Simulacao || resultado.Linhas != esperado.Linhas ||
+		resultado.ProdutosCriados != esperado.ProdutosCriados || resultado.ProdutosAtualizados != esperado.ProdutosAtualizados ||
+		resultado.VariantesCriadas != esperado.VariantesCriadas || resultado.VariantesAtualizadas != esperado.VariantesAtualizadas {
+		t.Errorf("resultado = %+v, esperado %+v", resultado, esperado)
+	}
+}
+
+// catalogoInicial cria "Memória Fury" com a variante MEM-16 (5 unidades) e
+// "Mouse" com 12 unidades na categoria perifericos.
+var catalogoInicial = [][]string{
+	{"Memória Fury", "", "", "300", "", "2", "não", "DDR5 6000 MHz"},
+	{"Memória Fury", "mem-16", "capacidade=16GB", "", "5"},
+	{"Mouse", "", "", "R$ 89,90", "12", "", "sim", "", "", "perifericos"},
+}
+
+func TestImportarProdutosSimular(t *testing.T) {
+	r := novoTeste(t)
+	if err := r.Categorias.Criar(&models.Categoria{Nome: "Periféricos", Slug: "perifericos"}); err != nil {
+		t.Fatalf("criar categoria: %v", err)
+	}
+	for _, formato := range planilha.Formatos {
+		conteudo := planilhaCatalogo(t, formato, catalogoInicial...)
+		w, resultado := importarPlanilha(t, "catalogo."+formato, conteudo, true)
+		esperarStatus(t, w, http.StatusOK)
+		conferirContagens(t, resultado, models.ResultadoImportacao{Simulacao: true, Linhas: 3, ProdutosCriados: 2, VariantesCriadas: 1})
+
+		// A simulação não grava produto, variante nem movimento de estoque.
+		if _, total, _ := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{}); total != 0 {
+			t.Errorf("%s: %d produtos gravados na simulação", formato, total)
+		}
+		if v := variantesPorSKU(t, r); len(v) != 0 {
+			t.Errorf("%s: variantes gravadas na simulação: %+v", formato, v)
+		}
+		if _, total, _ := r.Movimentos.Listar(repository.FiltroMovimentos{}, repository.Pagina{}); total != 0 {
+			t.Errorf("%s: %d movimentos gravados na simulação", formato, total)
+		}
+	}
+
+	w, resultado := importarPlanilha(t, "catalogo.xlsx", planilhaCatalogo(t, planilha.XLSX, catalogoInicial...), false)
+	esperarStatus(t, w, http.StatusOK)
+	conferirContagens(t, resultado, models.ResultadoImportacao{Linhas: 3, ProdutosCriados: 2, VariantesCriadas: 1})
+	memoria, ok := produtoPorNome(t, r, "Memória Fury")
+	if !ok || memoria.Preco != 300 || memoria.Quantidade != 5 || memoria.EstoqueMinimo != 2 || memoria.Detalhes.String != "DDR5 6000 MHz" {
+		t.Errorf("memória = %+v, esperado R$ 300 com 5 unidades das variantes", memoria)
+	}
+	mouse, ok := produtoPorNome(t, r, "Mouse")
+	if !ok || mouse.Preco != 89.9 || mouse.Quantidade != 12 || !mouse.Oferta || len(mouse.CategoriaIDs) != 1 {
+		t.Errorf("mouse = %+v, esperado R$ 89,90 com 12 unidades, em oferta e com categoria", mouse)
+	}
+	if v := variantesPorSKU(t, r)["MEM-16"]; v.Quantidade != 5 || v.Atributos["capacidade"] != "16GB" || v.Preco != nil {
+		t.Errorf("variante MEM-16 = %+v, esperado 5 unidades de 16GB sem preço próprio", v)
+	}
+}
+
+func TestImportarProdutosAtualizaVariantes(t *testing.T) {
+	r := novoTeste(t)
+	if err := r.Categorias.Criar(&models.Categoria{Nome: "Periféricos", Slug: "perifericos"}); err != nil {
+		t.Fatalf("criar categoria: %v", err)
+	}
+	w, _ := importarPlanilha(t, "catalogo.csv", planilhaCatalogo(t, planilha.CSV, catalogoInicial...), false)
+	esperarStatus(t, w, http.StatusOK)
+
+	// Produto e MEM-16 existentes são atualizados pelo nome e pelo SKU;
+	// MEM-32 é criada. Células vazias mantêm o valor atual.
+	w, resultado := importarPlanilha(t, "catalogo.csv", planilhaCatalogo(t, planilha.CSV,
+		[]string{"memória fury", "", "", "320"},
+		[]string{"Memória Fury", "MEM-16", "", "", "8"},
+		[]string{"Memória Fury", "MEM-32", "capacidade=32GB", "599,90", "3"},
+	), false)
+	esperarStatus(t, w, http.StatusOK)
+	conferirContagens(t, resultado, models.ResultadoImportacao{Linhas: 3, ProdutosAtualizados: 1, VariantesCriadas: 1, VariantesAtualizadas: 1})
+
+	memoria, ok := produtoPorNome(t, r, "memória fury")
+	if !ok || memoria.Preco != 320 || memoria.Quantidade != 11 || memoria.Detalhes.String != "DDR5 6000 MHz" {
+		t.Errorf("memória = %+v, esperado R$ 320, 11 unidades e os detalhes mantidos", memoria)
+	}
+	variantes := variantesPorSKU(t, r)
+	if v := variantes["MEM-16"]; v.Quantidade != 8 || v.Atributos["capacidade"] != "16GB" {
+		t.Errorf("MEM-16 = %+v, esperado 8 unidades e os atributos mantidos", v)
+	}
+	if v := variantes["MEM-32"]; v.Quantidade != 3 || v.Preco == nil || *v.Preco != 599.9 || v.ProdutoID != memoria.ID {
+		t.Errorf("MEM-32 = %+v, esperado 3 unidades a R$ 599,90 no produto %d", v, memoria.ID)
+	}
+
+	// A diferença de estoque da MEM-16 entra como ajuste no livro-razão.
+	mem16 := variantes["MEM-16"].ID
+	var ajustes []int
+	for _, m := range movimentosDoProduto(t, r, memoria.ID) {
+		if m.VarianteID != nil && *m.VarianteID == mem16 && m.Tipo == models.MovimentoAjuste {
+			ajustes = append(ajustes, m.Quantidade)
+		}
+	}
+	if len(ajustes) != 1 || ajustes[0] != 3 {
+		t.Errorf("ajustes da MEM-16 = %v, esperado um de +3", ajustes)
+	}
+}
+
+func TestImportarProdutosErrosPorLinha(t *testing.T) {
+	casos := []struct {
+		nome   string
+		linhas [][]string
+		erros  []models.ErroImportacao
+	}{
+		{"validação das células", [][]string{
+			{"Teclado", "", "", "abc"},
+			{"Teclado 2", "", "", "100", "-1"},
+			{"", "", "", "100"},
+			{"Fone", "", "cor=preto", "100"},
+			{"Fone", "FONE-1", "cor=preto", "", "1", "", "sim"},
+			{"Cabo", "", "", "10", "", "", "talvez"},
+			{"Cabo 2", "", "", "10", "", "", "", "", "", "inexistente"},
+			{"Válido", "", "", "10", "1"},
+		}, []models.ErroImportacao{
+			{Linha: 2, Coluna: "preco", Erro: "Preço deve ser um número entre 0,01 e 99999999.99"},
+			{Linha: 3, Coluna: "quantidade", Erro: "Quantidade deve ser um inteiro não negativo"},
+			{Linha: 4, Coluna: "nome", Erro: "Nome é obrigatório"},
+			{Linha: 5, Coluna: "atributos", Erro: "Atributos só valem em linhas de variante (com sku)"},
+			{Linha: 6, Coluna: "oferta", Erro: "Coluna não vale em linhas de variante; informe-a na linha do produto"},
+			{Linha: 7, Coluna: "oferta", Erro: "Use sim ou não"},
+			{Linha: 8, Coluna: "categorias", Erro: "Categoria não encontrada: inexistente"},
+		}},
+		{"conferência com o catálogo", [][]string{
+			{"Novo", "", "", "", "1"},
+			{"Válido", "", "", "10", "1"},
+			{"válido", "", "", "11"},
+			{"Fantasma", "F-1", "cor=azul", "", "1"},
+			{"Válido", "V-1", "", "", "1"},
+			{"Válido", "V-2", "cor=azul", "", "1"},
+			{"Válido", "v-2", "cor=verde"},
+		}, []models.ErroImportacao{
+			{Linha: 2, Coluna: "preco", Erro: "Preço é obrigatório para criar o produto"},
+			{Linha: 4, Coluna: "nome", Erro: "Produto repetido (já aparece na linha 3)"},
+			{Linha: 5, Coluna: "nome", Erro: "Produto não encontrado; inclua uma linha do produto (sem sku) antes das variantes"},
+			{Linha: 6, Coluna: "atributos", Erro: "Atributos são obrigatórios para criar a variante"},
+			{Linha: 8, Coluna: "sku", Erro: "SKU repetido (já aparece na linha 7)"},
+		}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			r := novoTeste(t)
+			w, resultado := importarPlanilha(t, "catalogo.csv", planilhaCatalogo(t, planilha.CSV, caso.linhas...), false)
+			esperarStatus(t, w, http.StatusUnprocessableEntity)
+			if len(resultado.Erros) != len(caso.erros) {
+				t.Fatalf("erros = %+v, esperado %+v", resultado.Erros, caso.erros)
+			}
+			for i, e := range caso.erros {
+				if resultado.Erros[i] != e {
+					t.Errorf("erro %d = %+v, esperado %+v", i, resultado.Erros[i], e)
+				}
+			}
+			// Com qualquer erro, nem as linhas válidas são gravadas.
+			if _, total, _ := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{}); total != 0 {
+				t.Errorf("%d produtos gravados, esperado nenhum", total)
+			}
+			if v := variantesPorSKU(t, r); len(v) != 0 {
+				t.Errorf("variantes gravadas: %+v", v)
+			}
+		})
+	}
+}
+
+func TestImportarProdutosCabecalho(t *testing.T) {
+	novoTeste(t)
+	var buf bytes.Buffer
+	buf.WriteString("nome;preço;sku;sku\r\nTeclado;100;;\r\n")
+	w, resultado := importarPlanilha(t, "catalogo.csv", buf.Bytes(), false)
+	esperarStatus(t, w, http.StatusUnprocessableEntity)
+	esperados := []models.ErroImportacao{
+		{Linha: 1, Coluna: "preço", Erro: "Coluna desconhecida; use nome, sku, atributos, preco, quantidade, estoque_minimo, oferta, detalhes, imagem, categorias"},
+		{Linha: 1, Coluna: "sku", Erro: "Coluna repetida"},
+	}
+	if len(resultado.Erros) != len(esperados) || resultado.Erros[0] != esperados[0] || resultado.Erros[1] != esperados[1] {
+		t.Errorf("erros = %+v, esperado %+v", resultado.Erros, esperados)
+	}
+
+	w, _ = importarPlanilha(t, "catalogo.ods", buf.Bytes(), false)
+	esperarStatus(t, w, http.StatusUnsupportedMediaType)
+}
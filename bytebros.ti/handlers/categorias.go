@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListarCategorias devolve a árvore completa de categorias, cada nó com o
+// total de produtos dele e das subcategorias.
+func ListarCategorias(c *gin.Context) {
+	categorias, err := repos.Categorias.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categorias", "detalhes": err.Error()})
+		return
+	}
+	produtos, err := repos.Categorias.Produtos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao contar produtos das categorias", "detalhes": err.Error()})
+		return
+	}
+
+	filhos := filhosPorCategoria(categorias)
+	var montar func(cat models.Categoria) (models.CategoriaArvore, map[int]bool)
+	montar = func(cat models.Categoria) (models.CategoriaArvore, map[int]bool) {
+		no := models.CategoriaArvore{Categoria: cat, Subcategorias: make([]models.CategoriaArvore, 0)}
+		// Um produto pode estar em várias categorias do mesmo ramo; o
+		// conjunto evita contá-lo duas vezes.
+		vistos := make(map[int]bool)
+		for _, id := range produtos[cat.ID] {
+			vistos[id] = true
+		}
+		for _, filho := range filhos[cat.ID] {
+			sub, produtosSub := montar(filho)
+			for id := range produtosSub {
+				vistos[id] = true
+			}
+			no.Subcategorias = append(no.Subcategorias, sub)
+		}
+		no.TotalProdutos = len(vistos)
+		return no, vistos
+	}
+
+	arvore := make([]models.CategoriaArvore, 0)
+	for _, raiz := range filhos[0] {
+		no, _ := montar(raiz)
+		arvore = append(arvore, no)
+	}
+	c.JSON(http.StatusOK, arvore)
+}
+
+// filhosPorCategoria agrupa as categorias pelo pai, mantendo a ordem de
+// Listar; as raízes ficam na chave 0.
+func filhosPorCategoria(categorias []models.Categoria) map[int][]models.Categoria {
+	filhos := make(map[int][]models.Categoria)
+	for _, cat := range categorias {
+		pai := 0
+		if cat.PaiID != nil {
+			pai = *cat.PaiID
+		}
+		filhos[pai] = append(filhos[pai], cat)
+	}
+	return filhos
+}
+
+// descendentes devolve id e os ids de todas as categorias abaixo dele.
+func descendentes(categorias []models.Categoria, id int) []int {
+	filhos := filhosPorCategoria(categorias)
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, filho := range filhos[ids[i]] {
+			ids = append(ids, filho.ID)
+		}
+	}
+	return ids
+}
+
+// categoriaDoFiltro resolve o parâmetro ?categoria= (slug ou id) nos ids
+// da categoria e de suas descendentes.
+func categoriaDoFiltro(valor string) ([]int, error) {
+	var categoria models.Categoria
+	var err error
+	if id, errConv := strconv.Atoi(valor); errConv == nil {
+		categoria, err = repos.Categorias.Obter(id)
+	} else {
+		categoria, err = repos.Categorias.ObterPorSlug(valor)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	categorias, err := repos.Categorias.Listar()
+	if err != nil {
+		return nil, err
+	}
+	return descendentes(categorias, categoria.ID), nil
+}
+
+var semAcento = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// gerarSlug transforma "Placas de Vídeo" em "placas-de-video".
+func gerarSlug(texto string) string {
+	texto = semAcento.Replace(strings.ToLower(texto))
+	var b strings.Builder
+	hifen := false
+	for _, r := range texto {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if hifen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hifen = false
+			b.WriteRune(r)
+			continue
+		}
+		hifen = true
+	}
+	return b.String()
+}
+
+// categoriaDaRequisicao valida o corpo de criação/edição. id é 0 na criação;
+// na edição impede que a categoria vire filha dela mesma ou de uma
+// descendente. Em caso de erro já responde e devolve false.
+func categoriaDaRequisicao(c *gin.Context, id int) (models.Categoria, bool) {
+	var req models.CategoriaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return models.Categoria{}, false
+	}
+
+	slug := req.Slug
+	if slug == "" {
+		slug = req.Nome
+	}
+	categoria := models.Categoria{ID: id, Nome: strings.TrimSpace(req.Nome), Slug: gerarSlug(slug), PaiID: req.PaiID}
+	if categoria.Slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Slug inválido: use letras ou números"})
+		return categoria, false
+	}
+	if categoria.PaiID == nil {
+		return categoria, true
+	}
+
+	categorias, err := repos.Categorias.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categorias", "detalhes": err.Error()})
+		return categoria, false
+	}
+	existe := false
+	for _, cat := range categorias {
+		if cat.ID == *categoria.PaiID {
+			existe = true
+			break
+		}
+	}
+	if !existe {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Categoria pai não encontrada"})
+		return categoria, false
+	}
+	if id != 0 {
+		for _, descendente := range descendentes(categorias, id) {
+			if descendente == *categoria.PaiID {
+				c.JSON(http.StatusBadRequest, gin.H{"erro": "Uma categoria não pode ficar abaixo dela mesma ou de uma subcategoria sua"})
+				return categoria, false
+			}
+		}
+	}
+	return categoria, true
+}
+
+func CriarCategoria(c *gin.Context) {
+	categoria, ok := categoriaDaRequisicao(c, 0)
+	if !ok {
+		return
+	}
+
+	if err := repos.Categorias.Criar(&categoria); err != nil {
+		if errors.Is(err, repository.ErrDuplicado) {
+			c.JSON(http.StatusConflict, gin.H{"erro": "Já existe uma categoria com este slug", "slug": categoria.Slug})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao criar categoria", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, categoria)
+}
+
+func AtualizarCategoria(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := repos.Categorias.Obter(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Categoria não encontrada"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categoria", "detalhes": err.Error()})
+		}
+		return
+	}
+
+	categoria, ok := categoriaDaRequisicao(c, id)
+	if !ok {
+		return
+	}
+
+	if err := repos.Categorias.Atualizar(categoria); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNaoEncontrado):
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Categoria não encontrada"})
+		case errors.Is(err, repository.ErrDuplicado):
+			c.JSON(http.StatusConflict, gin.H{"erro": "Já existe uma categoria com este slug", "slug": categoria.Slug})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar categoria", "detalhes": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, categoria)
+}
+
+func DeletarCategoria(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := repos.Categorias.Deletar(id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNaoEncontrado):
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Categoria não encontrada"})
+		case errors.Is(err, repository.ErrEmUso):
+			c.JSON(http.StatusConflict, gin.H{"erro": "A categoria tem subcategorias; mova ou remova-as antes"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao deletar categoria", "detalhes": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Categoria deletada com sucesso"})
+}
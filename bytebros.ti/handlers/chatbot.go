@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"database/sql"
 	"log"
 	"net/http"
 	"os"
@@ -92,8 +91,6 @@ func ChatbotHandler(c *gin.Context) {
 }
 
 func ChatbotSupportRequest(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
 	clienteEmail, exists := c.Get("email")
 	clienteEmailStr := ""
 	if exists && clienteEmail != nil {
@@ -112,14 +109,15 @@ func ChatbotSupportRequest(c *gin.Context) {
 		return
 	}
 
-	var suporte models.Suporte
-	err := db.QueryRow(`
-        INSERT INTO suporte (nome, email, mensagem, status, tipo_interacao, cliente_email)
-        VALUES ($1, $2, $3, 'aberto', $4, $5)
-        RETURNING id, criado_em`,
-		supportReq.Nome, supportReq.Email, supportReq.Mensagem, "chatbot_suporte", sql.NullString{String: clienteEmailStr, Valid: clienteEmailStr != ""}).
-		Scan(&suporte.ID, &suporte.CriadoEm)
-
+	suporte := models.Suporte{
+		Nome:          supportReq.Nome,
+		Email:         supportReq.Email,
+		Mensagem:      supportReq.Mensagem,
+		Status:        "aberto",
+		TipoInteracao: "chatbot_suporte",
+		ClienteEmail:  clienteEmailStr,
+	}
+	err := repos.Suporte.Criar(&suporte)
 	if err != nil {
 		log.Printf("ERRO BD: Erro ao registrar pedido de suporte via chatbot: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar pedido de suporte via chatbot", "detalhes": err.Error()})
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+)
+
+// verificarEstoque soma as quantidades pedidas por produto (ou por variante,
+// nos itens que têm uma) e devolve uma entrada para cada um cujo estoque
+// não cobre o pedido.
+func verificarEstoque(produtos map[int]models.Produto, variantes map[int]models.ProdutoVariante, itens []models.PedidoItem) []models.EstoqueInsuficiente {
+	type chave struct{ produto, variante int }
+	solicitado := make(map[chave]int)
+	ordem := make([]chave, 0)
+	for _, item := range itens {
+		k := chave{produto: item.ProdutoID}
+		if item.VarianteID != nil {
+			k.variante = *item.VarianteID
+		}
+		if _, ok := solicitado[k]; !ok {
+			ordem = append(ordem, k)
+		}
+		solicitado[k] += item.Quantidade
+	}
+
+	faltas := make([]models.EstoqueInsuficiente, 0)
+	for _, k := range ordem {
+		p := produtos[k.produto]
+		falta := models.EstoqueInsuficiente{
+			ProdutoID:   k.produto,
+			NomeProduto: p.Nome,
+			Solicitado:  solicitado[k],
+			Disponivel:  p.Quantidade,
+		}
+		if k.variante != 0 {
+			v := variantes[k.variante]
+			falta.VarianteID = &v.ID
+			falta.SKU = v.SKU
+			falta.Disponivel = v.Quantidade
+		}
+		if falta.Solicitado > falta.Disponivel {
+			faltas = append(faltas, falta)
+		}
+	}
+	return faltas
+}
+
+// movimentarEstoque aplica m.Quantidade à variante (se houver) e ao
+// produto e grava o movimento no livro-razão, preenchendo id e saldos. Toda
+// mudança de estoque passa por aqui, na transação de quem a provocou.
+func movimentarEstoque(tx *repository.Repositorios, m *models.MovimentoEstoque) error {
+	if m.VarianteID != nil {
+		if err := tx.Variantes.AjustarEstoque(*m.VarianteID, m.Quantidade); err != nil {
+			return err
+		}
+	}
+	if err := tx.Produtos.AjustarEstoque(m.ProdutoID, m.Quantidade); err != nil {
+		return err
+	}
+	return tx.Movimentos.Registrar(m)
+}
+
+// baixarEstoque decrementa o estoque dos itens do pedido e, nos que têm
+// variante, também o da variante. Deve ser chamada depois de
+// Produtos.Bloquear, Variantes.Bloquear e verificarEstoque, dentro da mesma
+// transação.
+func baixarEstoque(tx *repository.Repositorios, pedidoID int, itens []models.PedidoItem, usuario string) error {
+	for _, item := range itens {
+		err := movimentarEstoque(tx, &models.MovimentoEstoque{
+			ProdutoID:  item.ProdutoID,
+			VarianteID: item.VarianteID,
+			Tipo:       models.MovimentoSaidaPedido,
+			Quantidade: -item.Quantidade,
+			Motivo:     fmt.Sprintf("Pedido #%d", pedidoID),
+			PedidoID:   &pedidoID,
+			Usuario:    usuario,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// devolverEstoque repõe no catálogo as quantidades dos itens do pedido.
+func devolverEstoque(tx *repository.Repositorios, pedidoID int, itens []models.PedidoItem, usuario, motivo string) error {
+	ids := make([]int, 0, len(itens))
+	varianteIDs := make([]int, 0)
+	for _, item := range itens {
+		ids = append(ids, item.ProdutoID)
+		if item.VarianteID != nil {
+			varianteIDs = append(varianteIDs, *item.VarianteID)
+		}
+	}
+	if _, err := tx.Produtos.Bloquear(ids); err != nil {
+		return err
+	}
+	if _, err := tx.Variantes.Bloquear(varianteIDs); err != nil {
+		return err
+	}
+	for _, item := range itens {
+		err := movimentarEstoque(tx, &models.MovimentoEstoque{
+			ProdutoID:  item.ProdutoID,
+			VarianteID: item.VarianteID,
+			Tipo:       models.MovimentoDevolucao,
+			Quantidade: item.Quantidade,
+			Motivo:     motivo,
+			PedidoID:   &pedidoID,
+			Usuario:    usuario,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
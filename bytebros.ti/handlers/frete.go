@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"bytebros.ti/frete"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validadeCotacaoFrete é o tempo que o cliente tem para fechar o pedido
+// com a cotação.
+const validadeCotacaoFrete = time.Hour
+
+// formatoPrazoEntrega é o formato de pedidos.prazo_entrega.
+const formatoPrazoEntrega = "02/01/2006"
+
+var cotadorFrete *frete.Cotador
+
+func InitializeFrete() {
+	c, err := frete.NovoDoAmbiente()
+	if err != nil {
+		log.Fatalf("Erro ao configurar cotação de frete: %v", err)
+	}
+	cotadorFrete = c
+}
+
+// assinaturaItens identifica um carrinho pelas quantidades de cada
+// produto, como "3:1,12:2".
+func assinaturaItens(quantidades map[int]int) string {
+	ids := make([]int, 0, len(quantidades))
+	for id := range quantidades {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	partes := make([]string, len(ids))
+	for i, id := range ids {
+		partes[i] = fmt.Sprintf("%d:%d", id, quantidades[id])
+	}
+	return strings.Join(partes, ",")
+}
+
+// CotarFrete calcula as opções de entrega do carrinho para o CEP e as
+// guarda para o pedido. Não exige login.
+func CotarFrete(c *gin.Context) {
+	var req models.CotacaoFreteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+	cep, err := frete.NormalizarCEP(req.CEP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "CEP inválido", "cep": req.CEP})
+		return
+	}
+
+	quantidades := make(map[int]int)
+	for _, item := range req.Itens {
+		quantidades[item.ProdutoID] += item.Quantidade
+	}
+	agora := time.Now()
+	volumes := make([]frete.Volume, 0, len(quantidades))
+	var valor int64
+	var faltando []int
+	for id, quantidade := range quantidades {
+		p, err := repos.Produtos.Obter(id)
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			faltando = append(faltando, id)
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos", "detalhes": err.Error()})
+			return
+		}
+		e := p.Embalagem
+		volumes = append(volumes, frete.Volume{
+			PesoG:         e.PesoG,
+			AlturaCm:      e.AlturaCm,
+			LarguraCm:     e.LarguraCm,
+			ComprimentoCm: e.ComprimentoCm,
+			Quantidade:    quantidade,
+		})
+		valor += paraCentavos(p.PrecoVigente(agora)) * int64(quantidade)
+	}
+	if len(faltando) > 0 {
+		sort.Ints(faltando)
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado", "produto_ids": faltando})
+		return
+	}
+
+	pacote := frete.MontarPacote(volumes, deCentavos(valor))
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	opcoes, err := cotadorFrete.Cotar(ctx, cep, pacote)
+	if errors.Is(err, frete.ErrSemOpcoes) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": "Nenhuma opção de frete disponível para o CEP", "cep": cep})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"erro": "Erro ao cotar frete", "detalhes": err.Error()})
+		return
+	}
+
+	cotacao := models.CotacaoFrete{CEP: cep, PesoCobradoG: pacote.PesoCobradoG(), Opcoes: make([]models.OpcaoFrete, 0, len(opcoes))}
+	itens := assinaturaItens(quantidades)
+	expiraEm := agora.Add(validadeCotacaoFrete)
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		for _, o := range opcoes {
+			id, err := tokenAleatorio(24)
+			if err != nil {
+				return err
+			}
+			opcao := models.OpcaoFrete{
+				ID:             id,
+				CEPDestino:     cep,
+				Transportadora: o.Transportadora,
+				Servico:        o.Servico,
+				Nome:           o.Nome,
+				Valor:          deCentavos(paraCentavos(o.Valor)),
+				PrazoDias:      o.PrazoDias,
+				PrazoEntrega:   frete.DataEntrega(agora, o.PrazoDias).Format(formatoPrazoEntrega),
+				Itens:          itens,
+				ExpiraEm:       expiraEm,
+			}
+			if err := tx.Fretes.Criar(&opcao); err != nil {
+				return err
+			}
+			cotacao.Opcoes = append(cotacao.Opcoes, opcao)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao salvar cotação de frete", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cotacao)
+}
+
+// cotacaoDoPedido trava o frete do pedido: a cotação precisa existir, estar
+// no prazo, não ter sido usada e ter sido feita para o mesmo CEP e os
+// mesmos itens.
+func cotacaoDoPedido(tx *repository.Repositorios, req models.CriarPedidoRequest) (models.OpcaoFrete, error) {
+	opcao, err := tx.Fretes.Obter(req.CotacaoFreteID)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		return opcao, abortar(http.StatusBadRequest, gin.H{"erro": "Cotação de frete não encontrada", "cotacao_frete_id": req.CotacaoFreteID})
+	}
+	if err != nil {
+		return opcao, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar cotação de frete", "detalhes": err.Error()})
+	}
+	if opcao.PedidoID != nil {
+		return opcao, abortar(http.StatusConflict, gin.H{"erro": "Cotação de frete já usada em outro pedido"})
+	}
+	if time.Now().After(opcao.ExpiraEm) {
+		return opcao, abortar(http.StatusUnprocessableEntity, gin.H{"erro": "Cotação de frete expirada; faça uma nova cotação", "expira_em": opcao.ExpiraEm})
+	}
+	cep, err := frete.NormalizarCEP(req.CEPEntrega)
+	if err != nil {
+		return opcao, abortar(http.StatusBadRequest, gin.H{"erro": "CEP de entrega inválido", "cep_entrega": req.CEPEntrega})
+	}
+	if cep != opcao.CEPDestino {
+		return opcao, abortar(http.StatusUnprocessableEntity, gin.H{"erro": "A cotação de frete foi feita para outro CEP; faça uma nova cotação", "cep_cotado": opcao.CEPDestino})
+	}
+
+	quantidades := make(map[int]int)
+	for _, item := range req.Itens {
+		quantidades[item.ProdutoID] += item.Quantidade
+	}
+	if assinaturaItens(quantidades) != opcao.Itens {
+		return opcao, abortar(http.StatusUnprocessableEntity, gin.H{"erro": "A cotação de frete foi feita para outros itens; faça uma nova cotação"})
+	}
+	return opcao, nil
+}
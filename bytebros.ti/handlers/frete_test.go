@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+// TestCriarPedidoConfereCotacao cobre as recusas de cotacaoDoPedido. Em
+// todas, nem o pedido nem a baixa de estoque podem ficar gravados.
+func TestCriarPedidoConfereCotacao(t *testing.T) {
+	casos := []struct {
+		nome   string
+		ajuste func(req *models.CriarPedidoRequest, cotacao *models.OpcaoFrete)
+		status int
+		erro   string
+	}{
+		{"expirada", func(req *models.CriarPedidoRequest, cotacao *models.OpcaoFrete) {
+			cotacao.ExpiraEm = time.Now().Add(-time.Minute)
+		}, http.StatusUnprocessableEntity, "Cotação de frete expirada; faça uma nova cotação"},
+		{"id adulterado", func(req *models.CriarPedidoRequest, cotacao *models.OpcaoFrete) {
+			req.CotacaoFreteID = cotacao.ID + "x"
+		}, http.StatusBadRequest, "Cotação de frete não encontrada"},
+		{"itens adulterados", func(req *models.CriarPedidoRequest, cotacao *models.OpcaoFrete) {
+			// Cotado para 1 unidade, pedido com 3.
+			req.Itens[0].Quantidade = 3
+			req.ValorTotal = 3*req.Itens[0].ValorUnitario + cotacao.Valor
+		}, http.StatusUnprocessableEntity, "A cotação de frete foi feita para outros itens; faça uma nova cotação"},
+		{"outro CEP", func(req *models.CriarPedidoRequest, cotacao *models.OpcaoFrete) {
+			req.CEPEntrega = "69900-000"
+		}, http.StatusUnprocessableEntity, "A cotação de frete foi feita para outro CEP; faça uma nova cotação"},
+		{"CEP inválido", func(req *models.CriarPedidoRequest, cotacao *models.OpcaoFrete) {
+			req.CEPEntrega = "0131"
+		}, http.StatusBadRequest, "CEP de entrega inválido"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			r := novoTeste(t)
+			p := criarProdutoTeste(t, r, "Notebook", 3500, 5)
+			cotacao := models.OpcaoFrete{ID: "cot-1", Valor: 60, ExpiraEm: time.Now().Add(time.Hour)}
+			req := pedidoTeste(p.ID, 1, 3500, cotacao)
+			caso.ajuste(&req, &cotacao)
+			criarCotacaoTeste(t, r, cotacao.ID, "1:1", cotacao.Valor, cotacao.ExpiraEm)
+
+			w := criarPedido(t, req)
+			esperarStatus(t, w, caso.status)
+			var resposta struct {
+				Erro string `json:"erro"`
+			}
+			lerJSON(t, w, &resposta)
+			if resposta.Erro != caso.erro {
+				t.Errorf("erro = %q, esperado %q", resposta.Erro, caso.erro)
+			}
+			if n := pedidosGravados(t, r); n != 0 {
+				t.Errorf("%d pedidos gravados, esperado nenhum", n)
+			}
+			if q := quantidadeAtual(t, r, p.ID); q != 5 {
+				t.Errorf("estoque = %d, esperado 5", q)
+			}
+		})
+	}
+}
+
+func TestCriarPedidoCotacaoJaUsada(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "Monitor 27", 1500, 5)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 30, time.Now().Add(time.Hour))
+	req := pedidoTeste(p.ID, 1, 1500, cotacao)
+	// O CEP é conferido sem pontuação.
+	req.CEPEntrega = "01310100"
+
+	esperarStatus(t, criarPedido(t, req), http.StatusCreated)
+	esperarStatus(t, criarPedido(t, req), http.StatusConflict)
+	if n := pedidosGravados(t, r); n != 1 {
+		t.Errorf("%d pedidos gravados, esperado 1", n)
+	}
+	if q := quantidadeAtual(t, r, p.ID); q != 4 {
+		t.Errorf("estoque = %d, esperado 4", q)
+	}
+}
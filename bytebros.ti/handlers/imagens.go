@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+
+	"bytebros.ti/imagens"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+	"bytebros.ti/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImagensPorEnvio limita quantos arquivos um único POST pode trazer.
+	maxImagensPorEnvio = 10
+	// versaoPrincipal é a versão gravada em produtos.imagem, que o site já
+	// usa como foto do produto.
+	versaoPrincipal = "grande"
+)
+
+var (
+	armazenamento storage.Storage
+	// maxBytesImagem vem de UPLOAD_MAX_MB (padrão 5).
+	maxBytesImagem int64 = 5 << 20
+)
+
+func InitializeStorage() {
+	s, err := storage.NovoDoAmbiente()
+	if err != nil {
+		log.Fatalf("Erro ao configurar armazenamento de arquivos: %v", err)
+	}
+	armazenamento = s
+
+	if v := os.Getenv("UPLOAD_MAX_MB"); v != "" {
+		mb, err := strconv.Atoi(v)
+		if err != nil || mb <= 0 {
+			log.Fatalf("UPLOAD_MAX_MB inválido: %q", v)
+		}
+		maxBytesImagem = int64(mb) << 20
+	}
+}
+
+// StorageLocal devolve o armazenamento em disco, quando é o configurado,
+// para que main sirva os arquivos enviados.
+func StorageLocal() (*storage.LocalStorage, bool) {
+	local, ok := armazenamento.(*storage.LocalStorage)
+	return local, ok
+}
+
+func ListarImagensProduto(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	if !produtoExiste(c, produtoID) {
+		return
+	}
+
+	lista, err := repos.Imagens.Listar(produtoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar imagens", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, lista)
+}
+
+// EnviarImagensProduto recebe um multipart com um ou mais arquivos no
+// campo "imagens", gera as versões reduzidas e grava tudo no storage. As
+// novas imagens entram depois das que o produto já tem.
+func EnviarImagensProduto(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	if !produtoExiste(c, produtoID) {
+		return
+	}
+
+	// Folga de 1 MiB para os cabeçalhos do multipart.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImagensPorEnvio*maxBytesImagem+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var muitoGrande *http.MaxBytesError
+		if errors.As(err, &muitoGrande) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": "Envio maior que o permitido"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Envie as imagens como multipart/form-data no campo imagens", "detalhes": err.Error()})
+		return
+	}
+	arquivos := form.File["imagens"]
+	if len(arquivos) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Nenhuma imagem enviada no campo imagens"})
+		return
+	}
+	if len(arquivos) > maxImagensPorEnvio {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": fmt.Sprintf("Envie no máximo %d imagens por vez", maxImagensPorEnvio)})
+		return
+	}
+
+	// Valida e processa tudo antes de gravar qualquer arquivo.
+	processadas := make([][]imagens.Versao, 0, len(arquivos))
+	for _, arquivo := range arquivos {
+		versoes, status, erro := processarArquivo(arquivo)
+		if erro != "" {
+			c.JSON(status, gin.H{"erro": erro, "arquivo": arquivo.Filename})
+			return
+		}
+		processadas = append(processadas, versoes)
+	}
+
+	ctx := c.Request.Context()
+	var gravadas []string
+	novas := make([]models.ProdutoImagem, 0, len(processadas))
+	for _, versoes := range processadas {
+		img, chaves, err := gravarVersoes(ctx, produtoID, versoes)
+		gravadas = append(gravadas, chaves...)
+		if err != nil {
+			removerArquivos(gravadas)
+			c.JSON(http.StatusBadGateway, gin.H{"erro": "Erro ao gravar imagem no armazenamento", "detalhes": err.Error()})
+			return
+		}
+		novas = append(novas, img)
+	}
+
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		for i := range novas {
+			if err := tx.Imagens.Criar(&novas[i]); err != nil {
+				if errors.Is(err, repository.ErrNaoEncontrado) {
+					return abortar(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+				}
+				return err
+			}
+		}
+		return sincronizarImagemPrincipal(tx, produtoID, nil)
+	})
+	if err != nil {
+		removerArquivos(gravadas)
+		responderErro(c, err, "Erro ao salvar imagens")
+		return
+	}
+	c.JSON(http.StatusCreated, novas)
+}
+
+// processarArquivo lê e valida um arquivo do multipart. Em caso de erro
+// devolve o status e a mensagem para o cliente.
+func processarArquivo(arquivo *multipart.FileHeader) ([]imagens.Versao, int, string) {
+	if arquivo.Size > maxBytesImagem {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("Imagem maior que %d MB", maxBytesImagem>>20)
+	}
+	f, err := arquivo.Open()
+	if err != nil {
+		return nil, http.StatusBadRequest, "Erro ao ler arquivo enviado"
+	}
+	defer f.Close()
+	conteudo, err := io.ReadAll(io.LimitReader(f, maxBytesImagem+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, "Erro ao ler arquivo enviado"
+	}
+	if int64(len(conteudo)) > maxBytesImagem {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("Imagem maior que %d MB", maxBytesImagem>>20)
+	}
+
+	versoes, err := imagens.Processar(conteudo)
+	switch {
+	case errors.Is(err, imagens.ErrFormato):
+		return nil, http.StatusUnsupportedMediaType, "Formato não suportado: envie JPEG, PNG ou GIF"
+	case errors.Is(err, imagens.ErrDimensoes):
+		return nil, http.StatusRequestEntityTooLarge, "Imagem com resolução grande demais"
+	case err != nil:
+		return nil, http.StatusBadRequest, "Arquivo de imagem inválido ou corrompido"
+	}
+	return versoes, 0, ""
+}
+
+// gravarVersoes envia todas as versões de uma imagem para o storage sob
+// produtos/{id}/{aleatório}/. Devolve as chaves gravadas mesmo em caso de
+// erro, para que possam ser removidas.
+func gravarVersoes(ctx context.Context, produtoID int, versoes []imagens.Versao) (models.ProdutoImagem, []string, error) {
+	pasta, err := tokenAleatorio(12)
+	if err != nil {
+		return models.ProdutoImagem{}, nil, err
+	}
+	original := versoes[0]
+	img := models.ProdutoImagem{
+		ProdutoID:   produtoID,
+		URLs:        make(map[string]string, len(versoes)),
+		Chaves:      make(map[string]string, len(versoes)),
+		ContentType: original.ContentType,
+		Largura:     original.Largura,
+		Altura:      original.Altura,
+	}
+	var gravadas []string
+	for _, v := range versoes {
+		chave := fmt.Sprintf("produtos/%d/%s/%s.%s", produtoID, pasta, v.Nome, v.Extensao)
+		url, err := armazenamento.Salvar(ctx, chave, v.Conteudo, v.ContentType)
+		if err != nil {
+			return img, gravadas, err
+		}
+		gravadas = append(gravadas, chave)
+		img.URLs[v.Nome] = url
+		img.Chaves[v.Nome] = chave
+	}
+	return img, gravadas, nil
+}
+
+// removerArquivos apaga arquivos do storage sem interromper a requisição;
+// falhas só deixam arquivos órfãos e vão para o log.
+func removerArquivos(chaves []string) {
+	for _, chave := range chaves {
+		if err := armazenamento.Remover(context.Background(), chave); err != nil {
+			log.Printf("AVISO: não foi possível remover %s do armazenamento: %v", chave, err)
+		}
+	}
+}
+
+func chavesDaImagem(img models.ProdutoImagem) []string {
+	chaves := make([]string, 0, len(img.Chaves))
+	for _, chave := range img.Chaves {
+		chaves = append(chaves, chave)
+	}
+	return chaves
+}
+
+// sincronizarImagemPrincipal grava em produtos.imagem a primeira imagem da
+// galeria. Se a galeria ficou vazia, limpa o campo apenas quando ele
+// apontava para a imagem removida, preservando URLs externas antigas.
+func sincronizarImagemPrincipal(tx *repository.Repositorios, produtoID int, removida *models.ProdutoImagem) error {
+	lista, err := tx.Imagens.Listar(produtoID)
+	if err != nil {
+		return err
+	}
+	produto, err := tx.Produtos.Obter(produtoID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(lista) > 0:
+		produto.Imagem.String, produto.Imagem.Valid = lista[0].URLs[versaoPrincipal], true
+	case removida != nil && produto.Imagem.String == removida.URLs[versaoPrincipal]:
+		produto.Imagem.String, produto.Imagem.Valid = "", false
+	default:
+		return nil
+	}
+	return tx.Produtos.Atualizar(produto)
+}
+
+func ReordenarImagensProduto(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	var req models.ReordenarImagensRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	var lista []models.ProdutoImagem
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		atuais, err := tx.Imagens.Listar(produtoID)
+		if err != nil {
+			return err
+		}
+		// A nova ordem precisa citar cada imagem do produto exatamente uma vez.
+		ids := make([]int, len(atuais))
+		for i, img := range atuais {
+			ids[i] = img.ID
+		}
+		recebidos := slices.Clone(req.IDs)
+		slices.Sort(ids)
+		slices.Sort(recebidos)
+		if !slices.Equal(ids, recebidos) {
+			return abortar(http.StatusBadRequest, gin.H{"erro": "Informe todos os ids de imagens do produto, cada um uma vez", "ids_atuais": ids})
+		}
+
+		if err := tx.Imagens.Reordenar(produtoID, req.IDs); err != nil {
+			return err
+		}
+		if err := sincronizarImagemPrincipal(tx, produtoID, nil); err != nil {
+			return err
+		}
+		lista, err = tx.Imagens.Listar(produtoID)
+		return err
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao reordenar imagens")
+		return
+	}
+	c.JSON(http.StatusOK, lista)
+}
+
+func DeletarImagemProduto(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	imagemID, ok := idDoParametro(c, "imagemId")
+	if !ok {
+		return
+	}
+
+	var removida models.ProdutoImagem
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		var err error
+		removida, err = tx.Imagens.Obter(produtoID, imagemID)
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			return abortar(http.StatusNotFound, gin.H{"erro": "Imagem não encontrada"})
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Imagens.Deletar(produtoID, imagemID); err != nil {
+			return err
+		}
+		return sincronizarImagemPrincipal(tx, produtoID, &removida)
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao deletar imagem")
+		return
+	}
+
+	// Os arquivos só saem depois do commit: se a transação falhasse, a
+	// imagem continuaria no banco apontando para eles.
+	removerArquivos(chavesDaImagem(removida))
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Imagem deletada com sucesso"})
+}
+
+// produtoExiste responde 404 (ou 500) e devolve false quando o produto não
+// pode ser usado.
+func produtoExiste(c *gin.Context, id int) bool {
+	_, err := repos.Produtos.Obter(id)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produto", "detalhes": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"path/filepath"
+	"testing"
+
+	"bytebros.ti/models"
+	"bytebros.ti/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ligarStorageLocal grava as imagens num diretório temporário e devolve o
+// diretório.
+func ligarStorageLocal(t *testing.T) string {
+	t.Helper()
+	anterior, maxAnterior := armazenamento, maxBytesImagem
+	t.Cleanup(func() { armazenamento, maxBytesImagem = anterior, maxAnterior })
+	dir := t.TempDir()
+	armazenamento = &storage.LocalStorage{Dir: dir, Prefixo: "/uploads"}
+	return dir
+}
+
+func arquivosGravados(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("listar %s: %v", dir, err)
+	}
+	return n
+}
+
+func pngTeste(t *testing.T, largura, altura int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, largura, altura))); err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// arquivoEnviado é uma parte do multipart, com o Content-Type que o
+// cliente declarou.
+type arquivoEnviado struct {
+	nome        string
+	contentType string
+	conteudo    []byte
+}
+
+func enviarImagens(t *testing.T, produtoID int, arquivos ...arquivoEnviado) *httptest.ResponseRecorder {
+	t.Helper()
+	var corpo bytes.Buffer
+	form := multipart.NewWriter(&corpo)
+	for _, a := range arquivos {
+		cabecalho := textproto.MIMEHeader{}
+		cabecalho.Set("Content-Disposition", fmt.Sprintf(`form-data; name="imagens"; filename=%q`, a.nome))
+		cabecalho.Set("Content-Type", a.contentType)
+		parte, err := form.CreatePart(cabecalho)
+		if err != nil {
+			t.Fatalf("multipart: %v", err)
+		}
+		parte.Write(a.conteudo)
+	}
+	form.Close()
+
+	router := gin.New()
+	router.POST("/api/admin/produtos/:id/imagens", EnviarImagensProduto)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/produtos/%d/imagens", produtoID), &corpo)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestEnviarImagensProduto(t *testing.T) {
+	r := novoTeste(t)
+	dir := ligarStorageLocal(t)
+	p := criarProdutoTeste(t, r, "Gabinete", 400, 1)
+
+	w := enviarImagens(t, p.ID, arquivoEnviado{"gabinete.png", "image/png", pngTeste(t, 1600, 900)})
+	esperarStatus(t, w, http.StatusCreated)
+	var novas []models.ProdutoImagem
+	lerJSON(t, w, &novas)
+	if len(novas) != 1 || len(novas[0].URLs) != 4 || novas[0].Largura != 1600 || novas[0].Altura != 900 {
+		t.Fatalf("imagens = %+v, esperado uma de 1600x900 com 4 versões", novas)
+	}
+	if n := arquivosGravados(t, dir); n != 4 {
+		t.Errorf("%d arquivos gravados, esperado 4", n)
+	}
+	produto, err := r.Produtos.Obter(p.ID)
+	if err != nil || produto.Imagem.String != novas[0].URLs[versaoPrincipal] {
+		t.Errorf("imagem do produto = %q (%v), esperado a versão %s", produto.Imagem.String, err, versaoPrincipal)
+	}
+}
+
+func TestEnviarImagensProdutoRecusa(t *testing.T) {
+	valida := arquivoEnviado{"valida.png", "image/png", pngTeste(t, 20, 20)}
+	casos := []struct {
+		nome     string
+		arquivos []arquivoEnviado
+		status   int
+		erro     string
+	}{
+		// O tipo vem do conteúdo: nome e Content-Type do upload não contam.
+		{"MIME falsificado", []arquivoEnviado{{"foto.jpg", "image/jpeg", []byte("<?php echo 'oi'; ?>")}},
+			http.StatusUnsupportedMediaType, "Formato não suportado: envie JPEG, PNG ou GIF"},
+		{"SVG declarado como PNG", []arquivoEnviado{{"foto.png", "image/png", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)}},
+			http.StatusUnsupportedMediaType, "Formato não suportado: envie JPEG, PNG ou GIF"},
+		{"PNG corrompido", []arquivoEnviado{{"foto.png", "image/png", pngTeste(t, 20, 20)[:40]}},
+			http.StatusBadRequest, "Arquivo de imagem inválido ou corrompido"},
+		{"maior que o limite", []arquivoEnviado{{"grande.png", "image/png", append(pngTeste(t, 20, 20), make([]byte, 1<<20)...)}},
+			http.StatusRequestEntityTooLarge, "Imagem maior que 1 MB"},
+		// Nada é gravado se um dos arquivos do envio é recusado.
+		{"segundo arquivo inválido", []arquivoEnviado{valida, {"doc.gif", "image/gif", []byte("%PDF-1.7")}},
+			http.StatusUnsupportedMediaType, "Formato não suportado: envie JPEG, PNG ou GIF"},
+		{"sem arquivos", nil, http.StatusBadRequest, "Nenhuma imagem enviada no campo imagens"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			r := novoTeste(t)
+			dir := ligarStorageLocal(t)
+			maxBytesImagem = 1 << 20
+			p := criarProdutoTeste(t, r, "Gabinete", 400, 1)
+
+			w := enviarImagens(t, p.ID, caso.arquivos...)
+			esperarStatus(t, w, caso.status)
+			var resposta struct {
+				Erro string `json:"erro"`
+			}
+			lerJSON(t, w, &resposta)
+			if resposta.Erro != caso.erro {
+				t.Errorf("erro = %q, esperado %q", resposta.Erro, caso.erro)
+			}
+			if n := arquivosGravados(t, dir); n != 0 {
+				t.Errorf("%d arquivos gravados, esperado nenhum", n)
+			}
+			if lista, _ := r.Imagens.Listar(p.ID); len(lista) != 0 {
+				t.Errorf("%d imagens no produto, esperado nenhuma", len(lista))
+			}
+		})
+	}
+}
+
+func TestEnviarImagensProdutoEnvioGrandeDemais(t *testing.T) {
+	r := novoTeste(t)
+	dir := ligarStorageLocal(t)
+	maxBytesImagem = 1 << 10
+	p := criarProdutoTeste(t, r, "Gabinete", 400, 1)
+
+	// O corpo passa do limite do envio inteiro (10 imagens mais 1 MiB).
+	w := enviarImagens(t, p.ID, arquivoEnviado{"enorme.png", "image/png", make([]byte, 2<<20)})
+	esperarStatus(t, w, http.StatusRequestEntityTooLarge)
+	var resposta struct {
+		Erro string `json:"erro"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Erro != "Envio maior que o permitido" {
+		t.Errorf("erro = %q", resposta.Erro)
+	}
+	if n := arquivosGravados(t, dir); n != 0 {
+		t.Errorf("%d arquivos gravados, esperado nenhum", n)
+	}
+}
+
+func TestEnviarImagensProdutoResolucaoGrandeDemais(t *testing.T) {
+	r := novoTeste(t)
+	ligarStorageLocal(t)
+	p := criarProdutoTeste(t, r, "Gabinete", 400, 1)
+
+	// 7000x7000 em cinza uniforme comprime para poucos KB.
+	w := enviarImagens(t, p.ID, arquivoEnviado{"enorme.png", "image/png", pngTeste(t, 7000, 7000)})
+	esperarStatus(t, w, http.StatusRequestEntityTooLarge)
+	var resposta struct {
+		Erro string `json:"erro"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Erro != "Imagem com resolução grande demais" {
+		t.Errorf("erro = %q", resposta.Erro)
+	}
+}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"net/http"
-	"os"
 	"strings"
 
+	"bytebros.ti/auth"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
+)
+
+// Chaves usadas no contexto do Gin pelo AuthMiddleware.
+const (
+	ctxClaims    = "claims"
+	ctxPrincipal = "principal"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -18,42 +24,53 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := auth.Validar(tokenString, jwtSecret())
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"erro": "Token inválido"})
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("jwt_claims", claims)
-			c.Set("user_id", claims["user_id"])
-			c.Set("email", claims["email"])
-			if cargo, exists := claims["cargo"]; exists {
-				c.Set("cargo", cargo)
-			}
+		revogado, err := tokenRevogado(claims.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao validar token"})
+			c.Abort()
+			return
+		}
+		if revogado {
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": "Token revogado"})
+			c.Abort()
+			return
 		}
 
+		principal := claims.Principal()
+		c.Set(ctxClaims, claims)
+		c.Set(ctxPrincipal, principal)
+		c.Set("user_id", principal.ID)
+		c.Set("email", principal.Email)
+
 		c.Next()
 	}
 }
 
-func FuncMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		cargo, exists := c.Get("cargo")
-		if !exists || cargo != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"erro": "Acesso restrito a administradores"})
-			c.Abort()
-			return
-		}
-		c.Next()
+// principalAtual devolve quem está autenticado na requisição.
+func principalAtual(c *gin.Context) (auth.Principal, bool) {
+	v, exists := c.Get(ctxPrincipal)
+	if !exists {
+		return auth.Principal{}, false
+	}
+	principal, ok := v.(auth.Principal)
+	return principal, ok
+}
+
+// claimsAtuais devolve as claims do access token da requisição.
+func claimsAtuais(c *gin.Context) (*auth.Claims, bool) {
+	v, exists := c.Get(ctxClaims)
+	if !exists {
+		return nil, false
 	}
+	claims, ok := v.(*auth.Claims)
+	return claims, ok
 }
 
 func extractToken(c *gin.Context) string {
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"bytebros.ti/models"
+	"bytebros.ti/montagem"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPecasMontagem acompanha o limite de produto_ids de MontagemRequest.
+const maxPecasMontagem = 30
+
+// produtosDaMontagem carrega os produtos, repetindo os ids que aparecem mais
+// de uma vez. Ids inexistentes voltam em faltando.
+func produtosDaMontagem(ids []int) (produtos []models.Produto, faltando []int, err error) {
+	carregados := make(map[int]models.Produto)
+	for _, id := range ids {
+		p, ok := carregados[id]
+		if !ok {
+			p, err = repos.Produtos.Obter(id)
+			if errors.Is(err, repository.ErrNaoEncontrado) {
+				faltando = append(faltando, id)
+				carregados[id] = models.Produto{}
+				err = nil
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			carregados[id] = p
+		}
+		if p.ID != 0 {
+			produtos = append(produtos, p)
+		}
+	}
+	return produtos, faltando, nil
+}
+
+// ValidarMontagem verifica se os produtos informados formam um PC
+// compatível e estima o consumo. Não exige login.
+func ValidarMontagem(c *gin.Context) {
+	var req models.MontagemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	produtos, faltando, err := produtosDaMontagem(req.ProdutoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos", "detalhes": err.Error()})
+		return
+	}
+	if len(faltando) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado", "produto_ids": faltando})
+		return
+	}
+	c.JSON(http.StatusOK, montagem.Validar(produtos))
+}
+
+// CriarPedidoMontagem cria o pedido de uma montagem, recusando-o se os
+// componentes forem incompatíveis. O corpo é o mesmo de POST /api/pedidos.
+func CriarPedidoMontagem(c *gin.Context) {
+	clienteEmail, exists := c.Get("email")
+	if !exists || clienteEmail == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Email do usuário não encontrado no token"})
+		return
+	}
+
+	var req models.CriarPedidoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	var ids []int
+	for _, item := range req.Itens {
+		if len(ids)+item.Quantidade > maxPecasMontagem {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "A montagem pode ter no máximo 30 peças"})
+			return
+		}
+		for i := 0; i < item.Quantidade; i++ {
+			ids = append(ids, item.ProdutoID)
+		}
+	}
+	produtos, faltando, err := produtosDaMontagem(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos", "detalhes": err.Error()})
+		return
+	}
+	if len(faltando) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Produto não encontrado", "produto_ids": faltando})
+		return
+	}
+	if resultado := montagem.Validar(produtos); !resultado.Compativel {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": "Os componentes da montagem são incompatíveis", "montagem": resultado})
+		return
+	}
+
+	registrarPedido(c, clienteEmail.(string), req)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LancarMovimentoEstoque registra uma entrada, ajuste ou perda manual. As
+// saídas e devoluções de pedidos são lançadas pelos próprios pedidos.
+func LancarMovimentoEstoque(c *gin.Context) {
+	var req models.MovimentoEstoqueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	movimento := models.MovimentoEstoque{
+		ProdutoID:  req.ProdutoID,
+		VarianteID: req.VarianteID,
+		Tipo:       req.Tipo,
+		Quantidade: req.Quantidade,
+		Motivo:     req.Motivo,
+		Usuario:    emailDaRequisicao(c),
+	}
+	if req.Tipo != models.MovimentoAjuste {
+		if req.Quantidade < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Em " + req.Tipo + " a quantidade deve ser positiva; use ajuste para valores com sinal"})
+			return
+		}
+		if req.Tipo == models.MovimentoPerda {
+			movimento.Quantidade = -req.Quantidade
+		}
+	}
+
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		produtos, err := tx.Produtos.Bloquear([]int{req.ProdutoID})
+		if err != nil {
+			return err
+		}
+		produto, ok := produtos[req.ProdutoID]
+		if !ok {
+			return abortar(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+		}
+		variantes, err := tx.Variantes.Listar(produto.ID)
+		if err != nil {
+			return err
+		}
+
+		disponivel := produto.Quantidade
+		switch {
+		case len(variantes) > 0 && req.VarianteID == nil:
+			return abortar(http.StatusBadRequest, gin.H{"erro": "O produto tem variantes; informe variante_id"})
+		case len(variantes) == 0 && req.VarianteID != nil:
+			return abortar(http.StatusBadRequest, gin.H{"erro": "Produto não possui variantes"})
+		case req.VarianteID != nil:
+			bloqueadas, err := tx.Variantes.Bloquear([]int{*req.VarianteID})
+			if err != nil {
+				return err
+			}
+			v, ok := bloqueadas[*req.VarianteID]
+			if !ok || v.ProdutoID != produto.ID {
+				return abortar(http.StatusBadRequest, gin.H{"erro": "Variante não encontrada para o produto", "variante_id": *req.VarianteID})
+			}
+			disponivel = v.Quantidade
+		}
+		if disponivel+movimento.Quantidade < 0 {
+			return abortar(http.StatusConflict, gin.H{"erro": "O estoque ficaria negativo", "disponivel": disponivel})
+		}
+
+		return movimentarEstoque(tx, &movimento)
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao lançar movimento de estoque")
+		return
+	}
+	go avisarReposicao(movimento.ProdutoID)
+	c.JSON(http.StatusCreated, movimento)
+}
+
+var tiposMovimento = []string{
+	models.MovimentoEntrada, models.MovimentoSaidaPedido, models.MovimentoAjuste, models.MovimentoDevolucao, models.MovimentoPerda,
+}
+
+// idDaQuery lê um id opcional da query; 0 quando ausente. Em caso de erro
+// já responde 400 e devolve false.
+func idDaQuery(c *gin.Context, parametro string) (int, bool) {
+	v := c.Query(parametro)
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": parametro + " inválido"})
+		return 0, false
+	}
+	return id, true
+}
+
+// ListarMovimentosEstoque devolve o livro-razão (paginado), filtrável por
+// produto, variante, tipo e período.
+func ListarMovimentosEstoque(c *gin.Context) {
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoMovimentos)
+	if !ok {
+		return
+	}
+
+	var filtro repository.FiltroMovimentos
+	if filtro.ProdutoID, ok = idDaQuery(c, "produto_id"); !ok {
+		return
+	}
+	if filtro.VarianteID, ok = idDaQuery(c, "variante_id"); !ok {
+		return
+	}
+	filtro.Tipo = c.Query("tipo")
+	if filtro.Tipo != "" && !slices.Contains(tiposMovimento, filtro.Tipo) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Tipo de movimento inválido", "tipos_aceitos": tiposMovimento})
+		return
+	}
+
+	movimentos, total, err := repos.Movimentos.Listar(filtro, pagina)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar movimentos de estoque", "detalhes": err.Error()})
+		return
+	}
+	responderPagina(c, pagina, movimentos, total)
+}
+
+// RelatorioEstoque consolida os movimentos por produto no período: saldo
+// inicial, total de cada tipo e saldo final.
+func RelatorioEstoque(c *gin.Context) {
+	produtoID, ok := idDaQuery(c, "produto_id")
+	if !ok {
+		return
+	}
+	desde, ate, ok := periodoDaRequisicao(c)
+	if !ok {
+		return
+	}
+	if desde != nil && ate != nil && desde.After(*ate) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "desde não pode ser depois de ate"})
+		return
+	}
+
+	resumos, err := repos.Movimentos.Resumo(produtoID, desde, ate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar relatório de estoque", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"desde": desde, "ate": ate, "produtos": resumos})
+}
+
+// ConferirEstoque compara a quantidade gravada em produtos e variantes com
+// a soma dos movimentos. Divergências indicam alterações feitas por fora da
+// API.
+func ConferirEstoque(c *gin.Context) {
+	divergencias, err := repos.Movimentos.Divergencias()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao conferir estoque", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"consistente": len(divergencias) == 0, "divergencias": divergencias})
+}
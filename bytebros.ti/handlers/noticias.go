@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"bytebros.ti/models"
+	"bytebros.ti/repository"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,9 +22,6 @@ func CriarNoticia(c *gin.Context) {
 	}
 	log.Printf("DEBUG: Request bindada. Noticia: %+v", noticiaReq)
 
-	db := c.MustGet("db").(*sql.DB)
-	log.Println("DEBUG: Obtive a conexão com o DB.")
-
 	noticia := models.Noticia{
 		Titulo:    noticiaReq.Titulo,
 		Subtitulo: noticiaReq.Subtitulo,
@@ -32,14 +30,7 @@ func CriarNoticia(c *gin.Context) {
 		Data:      time.Now(),
 	}
 
-	err := db.QueryRow(`
-        INSERT INTO noticias (titulo, subtitulo, conteudo, autor, data)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id`,
-		noticia.Titulo, noticia.Subtitulo, noticia.Conteudo, noticia.Autor, noticia.Data).
-		Scan(&noticia.ID)
-
-	if err != nil {
+	if err := repos.Noticias.Criar(&noticia); err != nil {
 		log.Printf("DEBUG: Erro ao inserir notícia no DB: %v", err) // Log detalhado do erro real
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao criar notícia"})
 		return
@@ -50,44 +41,29 @@ func CriarNoticia(c *gin.Context) {
 }
 
 func ListarNoticias(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoNoticias)
+	if !ok {
+		return
+	}
 
-	rows, err := db.Query(`
-        SELECT id, titulo, subtitulo, conteudo, autor, data
-        FROM noticias
-        ORDER BY data DESC`)
+	noticias, total, err := repos.Noticias.Listar(pagina)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar notícias"})
 		return
 	}
-	defer rows.Close()
 
-	var noticias []models.Noticia
-	for rows.Next() {
-		var n models.Noticia
-		if err := rows.Scan(&n.ID, &n.Titulo, &n.Subtitulo, &n.Conteudo, &n.Autor, &n.Data); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler notícias"})
-			return
-		}
-		noticias = append(noticias, n)
-	}
-
-	c.JSON(http.StatusOK, noticias)
+	responderPagina(c, pagina, noticias, total)
 }
 
 func ObterNoticia(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	id := c.Param("id")
-
-	var noticia models.Noticia
-	err := db.QueryRow(`
-        SELECT id, titulo, subtitulo, conteudo, autor, data
-        FROM noticias
-        WHERE id = $1`, id).
-		Scan(&noticia.ID, &noticia.Titulo, &noticia.Subtitulo, &noticia.Conteudo, &noticia.Autor, &noticia.Data)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
+	noticia, err := repos.Noticias.Obter(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
 			c.JSON(http.StatusNotFound, gin.H{"erro": "Notícia não encontrada"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar notícia"})
@@ -99,7 +75,10 @@ func ObterNoticia(c *gin.Context) {
 }
 
 func AtualizarNoticia(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 	var noticiaReq models.NoticiaRequest
 
 	if err := c.ShouldBindJSON(&noticiaReq); err != nil {
@@ -107,15 +86,18 @@ func AtualizarNoticia(c *gin.Context) {
 		return
 	}
 
-	db := c.MustGet("db").(*sql.DB)
-
-	_, err := db.Exec(`
-        UPDATE noticias
-        SET titulo = $1, subtitulo = $2, conteudo = $3, autor = $4
-        WHERE id = $5`,
-		noticiaReq.Titulo, noticiaReq.Subtitulo, noticiaReq.Conteudo, noticiaReq.Autor, id)
-
+	err := repos.Noticias.Atualizar(models.Noticia{
+		ID:        uint(id),
+		Titulo:    noticiaReq.Titulo,
+		Subtitulo: noticiaReq.Subtitulo,
+		Conteudo:  noticiaReq.Conteudo,
+		Autor:     noticiaReq.Autor,
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Notícia não encontrada"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar notícia"})
 		return
 	}
@@ -124,11 +106,16 @@ func AtualizarNoticia(c *gin.Context) {
 }
 
 func DeletarNoticia(c *gin.Context) {
-	id := c.Param("id")
-	db := c.MustGet("db").(*sql.DB)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
-	_, err := db.Exec("DELETE FROM noticias WHERE id = $1", id)
-	if err != nil {
+	if err := repos.Noticias.Deletar(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Notícia não encontrada"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao deletar notícia"})
 		return
 	}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"bytebros.ti/mailer"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const intervaloMonitorPadrao = 5 * time.Minute
+
+// IniciarMonitorEstoque roda, a cada ESTOQUE_MONITOR_INTERVALO (padrão 5m),
+// a verificação de estoque até ctx ser cancelado: abre alertas para produtos
+// no estoque mínimo, resolve os que foram repostos e envia os avisos de
+// reposição que ainda estiverem pendentes.
+func IniciarMonitorEstoque(ctx context.Context) {
+	iniciarTarefaPeriodica(ctx, "ESTOQUE_MONITOR_INTERVALO", intervaloMonitorPadrao, passadaMonitorEstoque)
+}
+
+// passadaMonitorEstoque é uma rodada do monitor.
+func passadaMonitorEstoque(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	abertos, err := repos.Notificacoes.AbrirAlertasEstoque()
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao abrir alertas de estoque baixo: %v", err)
+	} else if len(abertos) > 0 {
+		log.Printf("Monitor de estoque: %d produto(s) no estoque mínimo", len(abertos))
+		enviarAlertasEstoque(abertos)
+	}
+
+	if _, err := repos.Notificacoes.ResolverAlertasEstoque(); err != nil {
+		log.Printf("ERRO BD: Falha ao resolver alertas de estoque baixo: %v", err)
+	}
+
+	repostos, err := repos.Avisos.ProdutosRepostos()
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao buscar produtos repostos: %v", err)
+		return
+	}
+	for _, id := range repostos {
+		if ctx.Err() != nil {
+			return
+		}
+		avisarReposicao(id)
+	}
+}
+
+// enviarAlertasEstoque manda os alertas recém-abertos para ALERTA_ESTOQUE_EMAIL,
+// quando configurado. O painel continua sendo a fonte principal.
+func enviarAlertasEstoque(alertas []models.NotificacaoAdmin) {
+	destino := os.Getenv("ALERTA_ESTOQUE_EMAIL")
+	if destino == "" {
+		return
+	}
+	linhas := make([]string, len(alertas))
+	for i, a := range alertas {
+		linhas[i] = "- " + a.Mensagem
+	}
+	err := mailSender.Enviar(mailer.Mensagem{
+		Para:    destino,
+		Assunto: "Byte Bros.TI - Produtos com estoque baixo",
+		Corpo: "Os produtos abaixo chegaram ao estoque mínimo:\n\n" + strings.Join(linhas, "\n") +
+			"\n\nAcompanhe as notificações no painel administrativo.",
+	})
+	if err != nil {
+		log.Printf("ERRO: Falha ao enviar alerta de estoque baixo para %s: %v", destino, err)
+	}
+}
+
+var tiposNotificacao = []string{models.NotificacaoEstoqueBaixo}
+
+// ListarNotificacoes devolve as notificações do painel (paginadas),
+// filtráveis por tipo e por nao_lidas=true.
+func ListarNotificacoes(c *gin.Context) {
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoNotificacoes)
+	if !ok {
+		return
+	}
+
+	filtro := repository.FiltroNotificacoes{Tipo: c.Query("tipo")}
+	if filtro.Tipo != "" && filtro.Tipo != models.NotificacaoEstoqueBaixo {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Tipo de notificação inválido", "tipos_aceitos": tiposNotificacao})
+		return
+	}
+	switch c.Query("nao_lidas") {
+	case "", "false":
+	case "true":
+		filtro.SomenteNaoLidas = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "nao_lidas deve ser true ou false"})
+		return
+	}
+
+	notificacoes, total, err := repos.Notificacoes.Listar(filtro, pagina)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar notificações", "detalhes": err.Error()})
+		return
+	}
+	responderPagina(c, pagina, notificacoes, total)
+}
+
+func MarcarNotificacaoLida(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	notificacao, err := repos.Notificacoes.MarcarLida(id, emailDaRequisicao(c))
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Notificação não encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao marcar notificação como lida", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, notificacao)
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/qrcode"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configPix é nil com o Pix desligado (sem PIX_CHAVE).
+var configPix *pagamento.ConfigPix
+
+const intervaloPagamentosPadrao = time.Minute
+
+// Quem aparece no histórico do pedido nas mudanças automáticas.
+const (
+	usuarioPix     = "pix"
+	usuarioSistema = "sistema"
+)
+
+// maxBytesWebhook limita o corpo aceito no webhook do PSP.
+const maxBytesWebhook = 1 << 20
+
+func InitializePagamentos() {
+	c, err := pagamento.PixDoAmbiente()
+	if err != nil {
+		log.Fatalf("Erro ao configurar pagamento via Pix: %v", err)
+	}
+	configPix = c
+	if c == nil {
+		log.Println("PIX_CHAVE não definida - pagamento via Pix desligado")
+	} else if c.Falso != nil {
+		log.Printf("Pix com PSP simulado: webhooks enviados para %s", c.Falso.URLWebhook)
+	}
+
+	cartao, err := pagamento.CartaoDoAmbiente()
+	if err != nil {
+		log.Fatalf("Erro ao configurar pagamento com cartão: %v", err)
+	}
+	configCartao = cartao
+	if cartao == nil {
+		log.Println("CARTAO_GATEWAY não definido - pagamento com cartão desligado")
+	}
+
+	boleto, err := pagamento.BoletoDoAmbiente()
+	if err != nil {
+		log.Fatalf("Erro ao configurar pagamento com boleto: %v", err)
+	}
+	configBoleto = boleto
+	if boleto == nil {
+		log.Println("BOLETO_AGENCIA não definida - pagamento com boleto desligado")
+	}
+}
+
+// IniciarExpiracaoPagamentos expira, a cada PAGAMENTOS_INTERVALO (padrão
+// 1m), as cobranças não pagas no prazo e retoma os estornos de cartão
+// pendentes, até ctx ser cancelado.
+func IniciarExpiracaoPagamentos(ctx context.Context) {
+	iniciarTarefaPeriodica(ctx, "PAGAMENTOS_INTERVALO", intervaloPagamentosPadrao, passadaPagamentos)
+}
+
+// passadaPagamentos é uma rodada da expiração de cobranças.
+func passadaPagamentos(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	retomarEstornos(ctx)
+	ids, err := repos.Pagamentos.Vencidas(time.Now())
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao buscar cobranças vencidas: %v", err)
+		return
+	}
+	expiradas := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := expirarCobranca(id); err != nil {
+			log.Printf("ERRO BD: Falha ao expirar a cobrança %d: %v", id, err)
+			continue
+		}
+		expiradas++
+	}
+	if expiradas > 0 {
+		log.Printf("Expiração de pagamentos: %d cobrança(s) expirada(s)", expiradas)
+	}
+}
+
+// expirarCobranca marca a cobrança como expirada e cancela o pedido, que
+// devolve o estoque, se ele ainda aguardava só por ela. No cartão isso só
+// acontece se o servidor cair no meio da cobrança.
+func expirarCobranca(id int) error {
+	return repos.Transacao(func(tx *repository.Repositorios) error {
+		p, err := tx.Pagamentos.Bloquear(id)
+		if err != nil {
+			return err
+		}
+		if p.Status != models.StatusPagamentoPendente || p.ExpiraEm == nil || p.ExpiraEm.After(time.Now()) {
+			return nil
+		}
+		p.Status = models.StatusPagamentoExpirado
+		if err := tx.Pagamentos.Atualizar(&p); err != nil {
+			return err
+		}
+
+		pedido, err := tx.Pedidos.Bloquear(p.PedidoID)
+		if err != nil {
+			return err
+		}
+		if pedido.Status != models.StatusPedidoProcessando {
+			return nil
+		}
+		outras, err := tx.Pagamentos.DoPedido(pedido.ID)
+		if err != nil {
+			return err
+		}
+		for _, o := range outras {
+			if o.ID != p.ID && (o.Status == models.StatusPagamentoPendente || o.Status == models.StatusPagamentoPago) {
+				return nil
+			}
+		}
+		return mudarStatusPedido(tx, pedido, models.StatusPedidoCancelado, usuarioSistema, "Pagamento não confirmado no prazo")
+	})
+}
+
+// criarCobrancaPix gera a cobrança Pix do pedido recém-criado.
+func criarCobrancaPix(tx *repository.Repositorios, pedido models.Pedido) (*models.Pagamento, error) {
+	txid, err := pagamento.NovoTxID(pedido.ID)
+	if err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar cobrança Pix", "detalhes": err.Error()})
+	}
+	expiraEm := time.Now().Add(configPix.Validade)
+	p := models.Pagamento{
+		PedidoID:   pedido.ID,
+		Metodo:     models.FormaPagamentoPix,
+		Status:     models.StatusPagamentoPendente,
+		Valor:      pedido.ValorTotal,
+		TxID:       txid,
+		CopiaECola: configPix.Cobranca(txid, pedido.ValorTotal).Payload(),
+		ExpiraEm:   &expiraEm,
+	}
+	if err := tx.Pagamentos.Criar(&p); err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao gravar cobrança Pix", "detalhes": err.Error()})
+	}
+	comLinks(&p)
+	return &p, nil
+}
+
+// comLinks preenche, nas cobranças pendentes, o endereço do QR Code do Pix
+// ou do PDF do boleto.
+func comLinks(p *models.Pagamento) {
+	if p.Status != models.StatusPagamentoPendente {
+		return
+	}
+	switch {
+	case p.Metodo == models.FormaPagamentoPix && p.TxID != "":
+		p.QRCodeURL = "/api/pagamentos/pix/" + p.TxID + "/qrcode.png"
+	case p.Metodo == models.FormaPagamentoBoleto:
+		p.BoletoURL = fmt.Sprintf("/api/meus-pedidos/%d/pagamentos/%d/boleto.pdf", p.PedidoID, p.ID)
+	}
+}
+
+// QRCodePix devolve o PNG do BR Code da cobrança. O txid, aleatório, faz
+// as vezes de senha, para que a imagem possa ir direto num <img>.
+func QRCodePix(c *gin.Context) {
+	p, err := repos.Pagamentos.ObterPorTxID(c.Param("txid"))
+	if errors.Is(err, repository.ErrNaoEncontrado) || err == nil && p.Metodo != models.FormaPagamentoPix {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Cobrança Pix não encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar cobrança", "detalhes": err.Error()})
+		return
+	}
+	if p.Status != models.StatusPagamentoPendente {
+		c.JSON(http.StatusGone, gin.H{"erro": "A cobrança Pix não está mais pendente", "status": p.Status})
+		return
+	}
+
+	codigo, err := qrcode.Gerar([]byte(p.CopiaECola), qrcode.NivelM)
+	var png []byte
+	if err == nil {
+		png, err = codigo.PNG(8)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar QR Code", "detalhes": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// WebhookPix recebe do PSP os Pix recebidos. Cada Pix é processado na sua
+// própria transação; repetições do mesmo Pix são ignoradas.
+func WebhookPix(c *gin.Context) {
+	if configPix == nil {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Pagamento via Pix desligado"})
+		return
+	}
+	corpo, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBytesWebhook))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Erro ao ler o corpo do webhook"})
+		return
+	}
+	err = pagamento.VerificarAssinatura(configPix.Segredo,
+		c.GetHeader(pagamento.CabecalhoTimestamp), c.GetHeader(pagamento.CabecalhoAssinatura), corpo, time.Now())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Assinatura do webhook inválida"})
+		return
+	}
+	var notificacao pagamento.NotificacaoPix
+	if err := json.Unmarshal(corpo, &notificacao); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Corpo do webhook inválido", "detalhes": err.Error()})
+		return
+	}
+
+	confirmados := 0
+	for _, pix := range notificacao.Pix {
+		confirmado, err := confirmarPix(pix)
+		if err != nil {
+			// O PSP reenvia o webhook quando não recebe 200.
+			responderErro(c, err, "Erro ao confirmar Pix")
+			return
+		}
+		if confirmado {
+			confirmados++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"recebidos": len(notificacao.Pix), "confirmados": confirmados})
+}
+
+// confirmarPix dá a cobrança por paga e leva o pedido a "Pago". Pix
+// desconhecidos ou com valor diferente do cobrado só vão para o log.
+func confirmarPix(pix pagamento.PixRecebido) (bool, error) {
+	valor, err := strconv.ParseFloat(pix.Valor, 64)
+	if err != nil || pix.TxID == "" || pix.EndToEndID == "" {
+		log.Printf("AVISO: Pix ignorado no webhook, dados incompletos: %+v", pix)
+		return false, nil
+	}
+
+	confirmado := false
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		p, err := tx.Pagamentos.BloquearPorTxID(pix.TxID)
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			log.Printf("AVISO: Pix %s recebido para txid desconhecido %s", pix.EndToEndID, pix.TxID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if p.Status == models.StatusPagamentoPago {
+			return nil
+		}
+		if paraCentavos(valor) != paraCentavos(p.Valor) {
+			log.Printf("AVISO: Pix %s de R$ %.2f para a cobrança %d, de R$ %.2f; não confirmado", pix.EndToEndID, valor, p.ID, p.Valor)
+			return nil
+		}
+
+		pagoEm := pix.Horario
+		if pagoEm.IsZero() {
+			pagoEm = time.Now()
+		}
+		p.EndToEndID = pix.EndToEndID
+		confirmado = true
+		return confirmarPagamento(tx, p, pagoEm, usuarioPix, "Pix "+pix.EndToEndID)
+	})
+	return confirmado, err
+}
+
+// confirmarPagamento dá a cobrança, já travada, por paga e leva o pedido a
+// "Pago". Se o pedido não aceita mais a transição, por ter sido cancelado
+// quando a cobrança expirou, o valor precisa ser devolvido à parte e o
+// aviso vai para o log.
+func confirmarPagamento(tx *repository.Repositorios, p models.Pagamento, pagoEm time.Time, usuario, descricao string) error {
+	estavaExpirada := p.Status == models.StatusPagamentoExpirado
+	p.Status = models.StatusPagamentoPago
+	p.PagoEm = &pagoEm
+	if err := tx.Pagamentos.Atualizar(&p); err != nil {
+		return err
+	}
+
+	pedido, err := tx.Pedidos.Bloquear(p.PedidoID)
+	if err != nil {
+		return err
+	}
+	if !models.TransicaoPedidoPermitida(pedido.Status, models.StatusPedidoPago) {
+		log.Printf("AVISO: %s recebido para o pedido #%d em %s (cobrança expirada: %t); verificar devolução",
+			descricao, pedido.ID, pedido.Status, estavaExpirada)
+		return nil
+	}
+	return mudarStatusPedido(tx, pedido, models.StatusPedidoPago, usuario, descricao)
+}
+
+// SimularPagamentoPix paga a cobrança pelo PSP simulado, que chama o
+// webhook como o PSP de verdade faria. Só existe com PIX_PSP=falso.
+func SimularPagamentoPix(c *gin.Context) {
+	if configPix == nil || configPix.Falso == nil {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "PSP simulado desligado"})
+		return
+	}
+	p, err := repos.Pagamentos.ObterPorTxID(c.Param("txid"))
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Cobrança Pix não encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar cobrança", "detalhes": err.Error()})
+		return
+	}
+	if p.Status == models.StatusPagamentoPago {
+		c.JSON(http.StatusConflict, gin.H{"erro": "Cobrança Pix já paga"})
+		return
+	}
+
+	e2e, err := configPix.Falso.Pagar(c.Request.Context(), p.TxID, p.Valor)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"erro": "O webhook recusou o pagamento simulado", "detalhes": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Pagamento simulado", "end_to_end_id": e2e})
+}
+
+// ListarPagamentosPedidoCliente lista as cobranças de um pedido do usuário
+// logado.
+func ListarPagamentosPedidoCliente(c *gin.Context) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	pedido, err := repos.Pedidos.Obter(pedidoID)
+	if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedido", "detalhes": err.Error()})
+		return
+	}
+	// Pedidos de outros clientes são tratados como inexistentes.
+	if err != nil || pedido.ClienteEmail != emailDaRequisicao(c) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Pedido não encontrado"})
+		return
+	}
+	listarPagamentosPedido(c, pedido.ID, false)
+}
+
+// ListarPagamentosPedidoAdmin é o equivalente para a equipe, com as
+// chamadas ao gateway de cartão.
+func ListarPagamentosPedidoAdmin(c *gin.Context) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := repos.Pedidos.Obter(pedidoID); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Pedido não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedido", "detalhes": err.Error()})
+		}
+		return
+	}
+	listarPagamentosPedido(c, pedidoID, true)
+}
+
+func listarPagamentosPedido(c *gin.Context, pedidoID int, comTransacoes bool) {
+	pagamentos, err := repos.Pagamentos.DoPedido(pedidoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pagamentos do pedido", "detalhes": err.Error()})
+		return
+	}
+	for i := range pagamentos {
+		comLinks(&pagamentos[i])
+		if comTransacoes && pagamentos[i].Metodo == models.FormaPagamentoCredito {
+			pagamentos[i].Transacoes, err = repos.Pagamentos.Transacoes(pagamentos[i].ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar transações do pagamento", "detalhes": err.Error()})
+				return
+			}
+		}
+	}
+	c.JSON(http.StatusOK, pagamentos)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const segredoPixTeste = "segredo-teste"
+
+// ligarPixFalso liga o Pix com o PSP simulado, que envia os webhooks a um
+// servidor de teste com WebhookPix.
+func ligarPixFalso(t *testing.T) *httptest.Server {
+	t.Helper()
+	router := gin.New()
+	router.POST("/api/webhooks/pix", WebhookPix)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	configPix = &pagamento.ConfigPix{
+		Chave:    "pix@bytebros.com.br",
+		Nome:     "Byte Bros TI",
+		Cidade:   "São Paulo",
+		Segredo:  segredoPixTeste,
+		Validade: 30 * time.Minute,
+		Falso:    &pagamento.PSPFalso{URLWebhook: srv.URL + "/api/webhooks/pix", Segredo: segredoPixTeste},
+	}
+	return srv
+}
+
+// pedidoPix cria um pedido a pagar com Pix e devolve a cobrança gerada.
+func pedidoPix(t *testing.T, r *repository.Repositorios) (models.Produto, models.Pagamento) {
+	t.Helper()
+	p := criarProdutoTeste(t, r, "Placa de vídeo", 2000, 3)
+	cotacao := criarCotacaoTeste(t, r, fmt.Sprintf("cot-pix-%d", p.ID), fmt.Sprintf("%d:1", p.ID), 40, time.Now().Add(time.Hour))
+	req := pedidoTeste(p.ID, 1, 2000, cotacao)
+	req.FormaPagamento = models.FormaPagamentoPix
+
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusCreated)
+	var resposta struct {
+		Pagamento models.Pagamento `json:"pagamento"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Pagamento.TxID == "" || resposta.Pagamento.Status != models.StatusPagamentoPendente {
+		t.Fatalf("cobrança = %+v, esperado Pix pendente", resposta.Pagamento)
+	}
+	return p, resposta.Pagamento
+}
+
+func statusDoPedido(t *testing.T, r *repository.Repositorios, id int) string {
+	t.Helper()
+	pedido, err := r.Pedidos.Obter(id)
+	if err != nil {
+		t.Fatalf("obter pedido %d: %v", id, err)
+	}
+	return pedido.Status
+}
+
+func obterPagamento(t *testing.T, r *repository.Repositorios, txid string) models.Pagamento {
+	t.Helper()
+	p, err := r.Pagamentos.ObterPorTxID(txid)
+	if err != nil {
+		t.Fatalf("obter cobrança %s: %v", txid, err)
+	}
+	return p
+}
+
+// enviarWebhook posta a notificação assinada com o timestamp informado.
+func enviarWebhook(t *testing.T, srv *httptest.Server, corpo []byte, timestamp int64, assinatura string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/webhooks/pix", bytes.NewReader(corpo))
+	if err != nil {
+		t.Fatalf("requisição: %v", err)
+	}
+	req.Header.Set(pagamento.CabecalhoTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(pagamento.CabecalhoAssinatura, assinatura)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhookPixConfirmaPagamento(t *testing.T) {
+	r := novoTeste(t)
+	ligarPixFalso(t)
+	_, cobranca := pedidoPix(t, r)
+	if !strings.HasPrefix(cobranca.CopiaECola, "000201") || cobranca.QRCodeURL == "" {
+		t.Errorf("cobrança sem copia e cola ou QR Code: %+v", cobranca)
+	}
+
+	e2e, err := configPix.Falso.Pagar(context.Background(), cobranca.TxID, cobranca.Valor)
+	if err != nil {
+		t.Fatalf("pagar pelo PSP simulado: %v", err)
+	}
+	atual := obterPagamento(t, r, cobranca.TxID)
+	if atual.Status != models.StatusPagamentoPago || atual.EndToEndID != e2e || atual.PagoEm == nil {
+		t.Errorf("cobrança = %+v, esperado paga pelo Pix %s", atual, e2e)
+	}
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoPago {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoPago)
+	}
+}
+
+func TestWebhookPixRecusaAssinaturaInvalida(t *testing.T) {
+	r := novoTeste(t)
+	srv := ligarPixFalso(t)
+	_, cobranca := pedidoPix(t, r)
+
+	psp := &pagamento.PSPFalso{URLWebhook: srv.URL + "/api/webhooks/pix", Segredo: "segredo-errado"}
+	_, err := psp.Pagar(context.Background(), cobranca.TxID, cobranca.Valor)
+	if err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("pagar com segredo errado: %v, esperado status 401", err)
+	}
+	if s := obterPagamento(t, r, cobranca.TxID).Status; s != models.StatusPagamentoPendente {
+		t.Errorf("cobrança em %q, esperado %q", s, models.StatusPagamentoPendente)
+	}
+	if s := statusDoPedido(t, r, cobranca.PedidoID); s != models.StatusPedidoProcessando {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoProcessando)
+	}
+}
+
+func TestWebhookPixReenvio(t *testing.T) {
+	r := novoTeste(t)
+	srv := ligarPixFalso(t)
+	_, cobranca := pedidoPix(t, r)
+
+	corpo, err := json.Marshal(pagamento.NotificacaoPix{Pix: []pagamento.PixRecebido{{
+		EndToEndID: "E00000000202610181200000000001",
+		TxID:       cobranca.TxID,
+		Valor:      strconv.FormatFloat(cobranca.Valor, 'f', 2, 64),
+		Horario:    time.Now().UTC().Truncate(time.Second),
+	}}})
+	if err != nil {
+		t.Fatalf("corpo: %v", err)
+	}
+
+	// Uma notificação capturada e reenviada depois da tolerância é recusada,
+	// mesmo com a assinatura correta.
+	antigo := time.Now().Add(-10 * time.Minute).Unix()
+	if s := enviarWebhook(t, srv, corpo, antigo, pagamento.Assinar(segredoPixTeste, antigo, corpo)); s != http.StatusUnauthorized {
+		t.Fatalf("webhook antigo: status %d, esperado 401", s)
+	}
+	if s := obterPagamento(t, r, cobranca.TxID).Status; s != models.StatusPagamentoPendente {
+		t.Fatalf("cobrança em %q depois do webhook antigo", s)
+	}
+
+	// Dentro da tolerância, o mesmo Pix entregue duas vezes é confirmado uma
+	// só vez.
+	agora := time.Now().Unix()
+	assinatura := pagamento.Assinar(segredoPixTeste, agora, corpo)
+	for i := 0; i < 2; i++ {
+		if s := enviarWebhook(t, srv, corpo, agora, assinatura); s != http.StatusOK {
+			t.Fatalf("entrega %d: status %d, esperado 200", i+1, s)
+		}
+	}
+	if s := obterPagamento(t, r, cobranca.TxID).Status; s != models.StatusPagamentoPago {
+		t.Errorf("cobrança em %q, esperado %q", s, models.StatusPagamentoPago)
+	}
+	historico, err := r.Pedidos.Historico(cobranca.PedidoID)
+	if err != nil {
+		t.Fatalf("histórico: %v", err)
+	}
+	pagos := 0
+	for _, h := range historico {
+		if h.StatusNovo == models.StatusPedidoPago {
+			pagos++
+		}
+	}
+	if pagos != 1 {
+		t.Errorf("%d mudanças para %q no histórico, esperado 1", pagos, models.StatusPedidoPago)
+	}
+}
+
+func TestExpirarCobrancaCancelaPedido(t *testing.T) {
+	r := novoTeste(t)
+	ligarPixFalso(t)
+	_, noPrazo := pedidoPix(t, r)
+	configPix.Validade = -time.Minute
+	produto, vencida := pedidoPix(t, r)
+	if q := quantidadeAtual(t, r, produto.ID); q != 2 {
+		t.Fatalf("estoque = %d depois do pedido, esperado 2", q)
+	}
+
+	passadaPagamentos(context.Background())
+
+	if s := obterPagamento(t, r, vencida.TxID).Status; s != models.StatusPagamentoExpirado {
+		t.Errorf("cobrança vencida em %q, esperado %q", s, models.StatusPagamentoExpirado)
+	}
+	if s := statusDoPedido(t, r, vencida.PedidoID); s != models.StatusPedidoCancelado {
+		t.Errorf("pedido em %q, esperado %q", s, models.StatusPedidoCancelado)
+	}
+	if q := quantidadeAtual(t, r, produto.ID); q != 3 {
+		t.Errorf("estoque = %d depois da expiração, esperado 3", q)
+	}
+
+	// A cobrança ainda no prazo não muda, nem se for expirada diretamente.
+	if err := expirarCobranca(noPrazo.ID); err != nil {
+		t.Fatalf("expirar no prazo: %v", err)
+	}
+	if s := obterPagamento(t, r, noPrazo.TxID).Status; s != models.StatusPagamentoPendente {
+		t.Errorf("cobrança no prazo em %q, esperado %q", s, models.StatusPagamentoPendente)
+	}
+	if s := statusDoPedido(t, r, noPrazo.PedidoID); s != models.StatusPedidoProcessando {
+		t.Errorf("pedido no prazo em %q, esperado %q", s, models.StatusPedidoProcessando)
+	}
+
+	// Um Pix que chega depois da expiração é registrado, mas o pedido
+	// continua cancelado.
+	if _, err := configPix.Falso.Pagar(context.Background(), vencida.TxID, vencida.Valor); err != nil {
+		t.Fatalf("pagar depois de expirar: %v", err)
+	}
+	if s := statusDoPedido(t, r, vencida.PedidoID); s != models.StatusPedidoCancelado {
+		t.Errorf("pedido em %q depois do Pix atrasado, esperado %q", s, models.StatusPedidoCancelado)
+	}
+}
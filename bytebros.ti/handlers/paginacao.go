@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	limitePadrao = 50
+	limiteMaximo = 200
+
+	headerTotal         = "X-Total-Count"
+	headerProximoCursor = "X-Next-Cursor"
+)
+
+// paginaDaRequisicao lê limit, cursor, sort, order, desde e ate. O cursor é
+// opaco para o cliente: basta repassar o X-Next-Cursor da resposta anterior
+// com os mesmos filtros. Em caso de erro já responde 400 e devolve false.
+func paginaDaRequisicao(c *gin.Context, ordenacao repository.Ordenacao) (repository.Pagina, bool) {
+	pagina := repository.Pagina{Limite: limitePadrao}
+
+	if v := c.Query("limit"); v != "" {
+		limite, err := strconv.Atoi(v)
+		if err != nil || limite < 1 || limite > limiteMaximo {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "limit deve ser um número entre 1 e " + strconv.Itoa(limiteMaximo)})
+			return pagina, false
+		}
+		pagina.Limite = limite
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		deslocamento, ok := lerCursor(v)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "cursor inválido"})
+			return pagina, false
+		}
+		pagina.Deslocamento = deslocamento
+	}
+
+	pagina.Ordenar = ordenacao.Padrao
+	pagina.Desc = ordenacao.PadraoDesc
+	if v := c.Query("sort"); v != "" {
+		if !ordenacao.Aceita(v) {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Campo de ordenação inválido", "campos_aceitos": ordenacao.Campos})
+			return pagina, false
+		}
+		pagina.Ordenar = v
+	}
+
+	switch strings.ToLower(c.Query("order")) {
+	case "":
+	case "asc":
+		pagina.Desc = false
+	case "desc":
+		pagina.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "order deve ser asc ou desc"})
+		return pagina, false
+	}
+
+	if (c.Query("desde") != "" || c.Query("ate") != "") && !ordenacao.PorData {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Este recurso não aceita filtro por data"})
+		return pagina, false
+	}
+	desde, ate, ok := periodoDaRequisicao(c)
+	if !ok {
+		return pagina, false
+	}
+	pagina.Desde, pagina.Ate = desde, ate
+
+	return pagina, true
+}
+
+// periodoDaRequisicao lê desde e ate (nil quando ausentes). Em caso de erro
+// já responde 400 e devolve false.
+func periodoDaRequisicao(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var desde, ate *time.Time
+	if v := c.Query("desde"); v != "" {
+		t, _, err := lerData(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "desde deve estar no formato AAAA-MM-DD ou RFC 3339"})
+			return nil, nil, false
+		}
+		desde = &t
+	}
+	if v := c.Query("ate"); v != "" {
+		t, soData, err := lerData(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "ate deve estar no formato AAAA-MM-DD ou RFC 3339"})
+			return nil, nil, false
+		}
+		if soData {
+			// "ate=2024-05-31" inclui o dia 31 inteiro.
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		ate = &t
+	}
+	return desde, ate, true
+}
+
+// lerData aceita uma data (AAAA-MM-DD) ou um instante RFC 3339 e informa
+// qual dos dois formatos foi usado.
+func lerData(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, false, err
+}
+
+func gerarCursor(deslocamento int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(deslocamento)))
+}
+
+func lerCursor(cursor string) (int, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	deslocamento, err := strconv.Atoi(string(b))
+	if err != nil || deslocamento < 0 {
+		return 0, false
+	}
+	return deslocamento, true
+}
+
+// responderPagina envia a página com o total em X-Total-Count e, se houver
+// mais itens, o cursor da próxima página em X-Next-Cursor. O corpo continua
+// sendo a lista pura, como antes da paginação.
+func responderPagina[T any](c *gin.Context, pagina repository.Pagina, itens []T, total int) {
+	cabecalhosPagina(c, pagina, len(itens), total)
+	c.JSON(http.StatusOK, itens)
+}
+
+// cabecalhosPagina define X-Total-Count e X-Next-Cursor para respostas que
+// não são uma lista pura, como a busca com facetas.
+func cabecalhosPagina(c *gin.Context, pagina repository.Pagina, quantidade, total int) {
+	c.Header(headerTotal, strconv.Itoa(total))
+	if proximo := pagina.Deslocamento + quantidade; quantidade > 0 && proximo < total {
+		c.Header(headerProximoCursor, gerarCursor(proximo))
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lerPagina passa a query por paginaDaRequisicao com a ordenação o.
+func lerPagina(t *testing.T, o repository.Ordenacao, query string) (repository.Pagina, *httptest.ResponseRecorder) {
+	t.Helper()
+	var pagina repository.Pagina
+	router := gin.New()
+	router.GET("/lista", func(c *gin.Context) {
+		var ok bool
+		if pagina, ok = paginaDaRequisicao(c, o); ok {
+			c.Status(http.StatusNoContent)
+		}
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lista?"+query, nil))
+	return pagina, w
+}
+
+func TestPaginaDaRequisicao(t *testing.T) {
+	desde := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	fimDoDia := time.Date(2026, 5, 31, 23, 59, 59, 999999999, time.UTC)
+	instante := time.Date(2026, 5, 31, 12, 30, 0, 0, time.UTC)
+
+	casos := []struct {
+		nome   string
+		o      repository.Ordenacao
+		query  string
+		pagina repository.Pagina
+	}{
+		{"padrão", repository.OrdenacaoProdutos, "", repository.Pagina{Limite: 50, Ordenar: "name"}},
+		{"padrão descendente", repository.OrdenacaoPedidos, "", repository.Pagina{Limite: 50, Ordenar: "data_pedido", Desc: true}},
+		{"limite mínimo", repository.OrdenacaoProdutos, "limit=1", repository.Pagina{Limite: 1, Ordenar: "name"}},
+		{"limite máximo", repository.OrdenacaoProdutos, "limit=200", repository.Pagina{Limite: 200, Ordenar: "name"}},
+		{"cursor", repository.OrdenacaoProdutos, "limit=10&cursor=" + gerarCursor(30), repository.Pagina{Limite: 10, Deslocamento: 30, Ordenar: "name"}},
+		{"ordenação", repository.OrdenacaoProdutos, "sort=value&order=desc", repository.Pagina{Limite: 50, Ordenar: "value", Desc: true}},
+		{"order maiúsculo", repository.OrdenacaoPedidos, "order=ASC", repository.Pagina{Limite: 50, Ordenar: "data_pedido"}},
+		{"período por dia", repository.OrdenacaoPedidos, "desde=2026-05-01&ate=2026-05-31", repository.Pagina{Limite: 50, Ordenar: "data_pedido", Desc: true, Desde: &desde, Ate: &fimDoDia}},
+		{"período RFC 3339", repository.OrdenacaoPedidos, "ate=2026-05-31T12:30:00Z", repository.Pagina{Limite: 50, Ordenar: "data_pedido", Desc: true, Ate: &instante}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			pagina, w := lerPagina(t, caso.o, caso.query)
+			esperarStatus(t, w, http.StatusNoContent)
+			if pagina.Limite != caso.pagina.Limite || pagina.Deslocamento != caso.pagina.Deslocamento ||
+				pagina.Ordenar != caso.pagina.Ordenar || pagina.Desc != caso.pagina.Desc ||
+				!mesmaData(pagina.Desde, caso.pagina.Desde) || !mesmaData(pagina.Ate, caso.pagina.Ate) {
+				t.Errorf("página = %+v, esperado %+v", pagina, caso.pagina)
+			}
+		})
+	}
+}
+
+func TestPaginaDaRequisicaoInvalida(t *testing.T) {
+	casos := []struct {
+		nome  string
+		o     repository.Ordenacao
+		query string
+		erro  string
+	}{
+		{"limite zero", repository.OrdenacaoProdutos, "limit=0", "limit deve ser um número entre 1 e 200"},
+		{"limite negativo", repository.OrdenacaoProdutos, "limit=-5", "limit deve ser um número entre 1 e 200"},
+		{"limite acima do máximo", repository.OrdenacaoProdutos, "limit=201", "limit deve ser um número entre 1 e 200"},
+		{"limite não numérico", repository.OrdenacaoProdutos, "limit=dez", "limit deve ser um número entre 1 e 200"},
+		{"cursor fora de base64", repository.OrdenacaoProdutos, "cursor=%25%25%25", "cursor inválido"},
+		{"cursor sem número", repository.OrdenacaoProdutos, "cursor=" + base64.RawURLEncoding.EncodeToString([]byte("abc")), "cursor inválido"},
+		{"cursor negativo", repository.OrdenacaoProdutos, "cursor=" + base64.RawURLEncoding.EncodeToString([]byte("-1")), "cursor inválido"},
+		{"cursor com padding", repository.OrdenacaoProdutos, "cursor=" + base64.URLEncoding.EncodeToString([]byte("5")), "cursor inválido"},
+		{"campo fora da lista", repository.OrdenacaoProdutos, "sort=senha", "Campo de ordenação inválido"},
+		{"campo de outro recurso", repository.OrdenacaoProdutos, "sort=data_pedido", "Campo de ordenação inválido"},
+		{"order inválido", repository.OrdenacaoProdutos, "order=random", "order deve ser asc ou desc"},
+		{"período sem data", repository.OrdenacaoProdutos, "desde=2026-05-01", "Este recurso não aceita filtro por data"},
+		{"desde inválido", repository.OrdenacaoPedidos, "desde=01/05/2026", "desde deve estar no formato AAAA-MM-DD ou RFC 3339"},
+		{"ate inválido", repository.OrdenacaoPedidos, "ate=ontem", "ate deve estar no formato AAAA-MM-DD ou RFC 3339"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			_, w := lerPagina(t, caso.o, caso.query)
+			esperarStatus(t, w, http.StatusBadRequest)
+			var resposta struct {
+				Erro          string   `json:"erro"`
+				CamposAceitos []string `json:"campos_aceitos"`
+			}
+			lerJSON(t, w, &resposta)
+			if resposta.Erro != caso.erro {
+				t.Errorf("erro = %q, esperado %q", resposta.Erro, caso.erro)
+			}
+			if caso.erro == "Campo de ordenação inválido" && len(resposta.CamposAceitos) != len(caso.o.Campos) {
+				t.Errorf("campos_aceitos = %v, esperado %v", resposta.CamposAceitos, caso.o.Campos)
+			}
+		})
+	}
+}
+
+// Percorre a listagem de produtos seguindo o X-Next-Cursor até o fim.
+func TestListarProdutosCabecalhosPaginacao(t *testing.T) {
+	r := novoTeste(t)
+	for i := 1; i <= 5; i++ {
+		criarProdutoTeste(t, r, fmt.Sprintf("Produto %d", i), float64(10*i), 1)
+	}
+
+	var nomes []string
+	var cursores []string
+	caminho := "/api/produtos?limit=2&sort=value&order=desc"
+	for caminho != "" {
+		w := requisitar(t, ListarProdutos, http.MethodGet, "/api/produtos", caminho, nil, "")
+		esperarStatus(t, w, http.StatusOK)
+		if total := w.Header().Get(headerTotal); total != "5" {
+			t.Errorf("%s = %q em %s, esperado 5", headerTotal, total, caminho)
+		}
+		var produtos []models.Produto
+		lerJSON(t, w, &produtos)
+		for _, p := range produtos {
+			nomes = append(nomes, p.Nome)
+		}
+		caminho = ""
+		if cursor := w.Header().Get(headerProximoCursor); cursor != "" {
+			cursores = append(cursores, cursor)
+			caminho = "/api/produtos?limit=2&sort=value&order=desc&cursor=" + cursor
+		}
+		if len(cursores) > 5 {
+			t.Fatal("X-Next-Cursor não termina")
+		}
+	}
+
+	if esperado := []string{gerarCursor(2), gerarCursor(4)}; fmt.Sprint(cursores) != fmt.Sprint(esperado) {
+		t.Errorf("cursores = %v, esperado %v", cursores, esperado)
+	}
+	if esperado := "[Produto 5 Produto 4 Produto 3 Produto 2 Produto 1]"; fmt.Sprint(nomes) != esperado {
+		t.Errorf("produtos = %v, esperado %s", nomes, esperado)
+	}
+
+	// Página exata no fim e cursor além do total não trazem próximo cursor.
+	for _, cursor := range []string{gerarCursor(3), gerarCursor(10)} {
+		w := requisitar(t, ListarProdutos, http.MethodGet, "/api/produtos", "/api/produtos?limit=2&cursor="+cursor, nil, "")
+		esperarStatus(t, w, http.StatusOK)
+		if proximo := w.Header().Get(headerProximoCursor); proximo != "" {
+			t.Errorf("cursor %s: %s = %q, esperado vazio", cursor, headerProximoCursor, proximo)
+		}
+		if total := w.Header().Get(headerTotal); total != "5" {
+			t.Errorf("cursor %s: %s = %q, esperado 5", cursor, headerTotal, total)
+		}
+	}
+}
@@ -1,18 +1,29 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strings"
+	"time"
 
 	"bytebros.ti/models"
+	"bytebros.ti/pagamento"
+	"bytebros.ti/repository"
 	"github.com/gin-gonic/gin"
 )
 
-func CriarPedido(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
+func paraCentavos(valor float64) int64 {
+	return int64(math.Round(valor * 100))
+}
 
+func deCentavos(centavos int64) float64 {
+	return float64(centavos) / 100
+}
+
+func CriarPedido(c *gin.Context) {
 	clienteEmail, exists := c.Get("email")
 	if !exists || clienteEmail == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Email do usuário não encontrado no token"})
@@ -25,49 +36,277 @@ func CriarPedido(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
 		return
 	}
+	registrarPedido(c, clienteEmailStr, req)
+}
 
-	tx, err := db.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao iniciar transação do pedido"})
+// registrarPedido confere o pedido contra o catálogo, grava e baixa o
+// estoque, respondendo a requisição. Também é usado pela montagem de PC.
+func registrarPedido(c *gin.Context, clienteEmailStr string, req models.CriarPedidoRequest) {
+	formaPagamento := models.NormalizarFormaPagamento(req.FormaPagamento)
+	if formaPagamento == models.FormaPagamentoPix && configPix == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Pagamento via Pix indisponível"})
+		return
+	}
+	parcelas := 1
+	if formaPagamento == models.FormaPagamentoCredito {
+		if configCartao == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Pagamento com cartão indisponível"})
+			return
+		}
+		if req.CartaoToken == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Informe cartao_token para pagar com cartão"})
+			return
+		}
+		if req.Parcelas > 0 {
+			parcelas = req.Parcelas
+		}
+	} else if req.CartaoToken != "" || req.Parcelas > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "cartao_token e parcelas só valem com forma_pagamento credito"})
+		return
+	}
+	var documentoPagador string
+	if formaPagamento == models.FormaPagamentoBoleto {
+		if configBoleto == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Pagamento com boleto indisponível"})
+			return
+		}
+		var err error
+		if documentoPagador, err = pagamento.NormalizarDocumento(req.DocumentoPagador); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Informe em documento_pagador um CPF ou CNPJ válido para pagar com boleto"})
+			return
+		}
+	} else if req.DocumentoPagador != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "documento_pagador só vale com forma_pagamento boleto"})
 		return
 	}
-	defer tx.Rollback()
 
-	var pedidoID int
-	err = tx.QueryRow(`
-		INSERT INTO pedidos (cliente_email, status, endereco_entrega, tipo_frete, valor_frete, valor_total, forma_pagamento, prazo_entrega)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id`,
-		clienteEmailStr, "Processando", req.EnderecoEntrega, req.TipoFrete, req.ValorFrete, req.ValorTotal, req.FormaPagamento, req.PrazoEntrega).
-		Scan(&pedidoID)
+	var pedido models.Pedido
+	var cobranca *models.Pagamento
+	agora := time.Now()
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		ids := make([]int, 0, len(req.Itens))
+		for _, itemReq := range req.Itens {
+			ids = append(ids, itemReq.ProdutoID)
+		}
+		produtos, err := tx.Produtos.Bloquear(ids)
+		if err != nil {
+			return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos do pedido", "detalhes": err.Error()})
+		}
+		variantes, err := variantesDoPedido(tx, produtos, req.Itens)
+		if err != nil {
+			return err
+		}
+		cotacao, err := cotacaoDoPedido(tx, req)
+		if err != nil {
+			return err
+		}
+		valorFrete := cotacao.Valor
+
+		// Preço e nome vêm sempre do catálogo; os valores enviados pelo cliente
+		// servem apenas para conferência.
+		itens := make([]models.PedidoItem, 0, len(req.Itens))
+		divergencias := make([]models.DivergenciaPedido, 0)
+		var subtotal int64
+
+		for i, itemReq := range req.Itens {
+			produto, ok := produtos[itemReq.ProdutoID]
+			if !ok {
+				return abortar(http.StatusBadRequest, gin.H{"erro": "Produto não encontrado", "linha": i, "produto_id": itemReq.ProdutoID})
+			}
+			item := models.PedidoItem{
+				ProdutoID:     produto.ID,
+				NomeProduto:   produto.Nome,
+				Quantidade:    itemReq.Quantidade,
+				ValorUnitario: produto.PrecoVigente(agora),
+			}
+			if doProduto := variantes[produto.ID]; doProduto != nil {
+				if itemReq.VarianteID == nil {
+					return abortar(http.StatusBadRequest, gin.H{"erro": "Escolha uma variante do produto", "linha": i, "produto_id": produto.ID})
+				}
+				v, ok := doProduto[*itemReq.VarianteID]
+				if !ok {
+					return abortar(http.StatusBadRequest, gin.H{"erro": "Variante não encontrada para o produto", "linha": i, "produto_id": produto.ID, "variante_id": *itemReq.VarianteID})
+				}
+				item.VarianteID = &v.ID
+				item.SKU = v.SKU
+				item.NomeProduto = nomeComVariante(produto.Nome, v)
+				item.ValorUnitario = v.PrecoPara(produto, agora)
+			} else if itemReq.VarianteID != nil {
+				return abortar(http.StatusBadRequest, gin.H{"erro": "Produto não possui variantes", "linha": i, "produto_id": produto.ID})
+			}
+
+			if paraCentavos(itemReq.ValorUnitario) != paraCentavos(item.ValorUnitario) {
+				divergencias = append(divergencias, models.DivergenciaPedido{
+					Linha:     i,
+					ProdutoID: item.ProdutoID,
+					Campo:     "valor_unitario",
+					Informado: itemReq.ValorUnitario,
+					Esperado:  item.ValorUnitario,
+				})
+			}
+
+			subtotal += paraCentavos(item.ValorUnitario) * int64(item.Quantidade)
+			itens = append(itens, item)
+		}
 
+		if faltas := verificarEstoque(produtos, todasVariantes(variantes), itens); len(faltas) > 0 {
+			return abortar(http.StatusConflict, gin.H{
+				"erro":  "Estoque insuficiente para um ou mais itens",
+				"itens": faltas,
+			})
+		}
+
+		total := subtotal + paraCentavos(valorFrete)
+
+		if paraCentavos(req.ValorFrete) != paraCentavos(valorFrete) {
+			divergencias = append(divergencias, models.DivergenciaPedido{
+				Linha:     -1,
+				Campo:     "valor_frete",
+				Informado: req.ValorFrete,
+				Esperado:  valorFrete,
+			})
+		}
+		if paraCentavos(req.ValorTotal) != total {
+			divergencias = append(divergencias, models.DivergenciaPedido{
+				Linha:     -1,
+				Campo:     "valor_total",
+				Informado: req.ValorTotal,
+				Esperado:  deCentavos(total),
+			})
+		}
+
+		if len(divergencias) > 0 {
+			return abortar(http.StatusUnprocessableEntity, gin.H{
+				"erro":         "Os valores do pedido não conferem com o catálogo",
+				"divergencias": divergencias,
+			})
+		}
+		if req.PrazoEntrega != "" && req.PrazoEntrega != cotacao.PrazoEntrega {
+			return abortar(http.StatusUnprocessableEntity, gin.H{
+				"erro":      "O prazo de entrega não confere com a cotação de frete",
+				"informado": req.PrazoEntrega,
+				"esperado":  cotacao.PrazoEntrega,
+			})
+		}
+
+		pedido = models.Pedido{
+			ClienteEmail:    clienteEmailStr,
+			Status:          models.StatusPedidoProcessando,
+			EnderecoEntrega: req.EnderecoEntrega,
+			TipoFrete:       cotacao.Servico,
+			ValorFrete:      valorFrete,
+			ValorTotal:      deCentavos(total),
+			FormaPagamento:  formaPagamento,
+			PrazoEntrega:    cotacao.PrazoEntrega,
+			Itens:           itens,
+		}
+		if err := tx.Pedidos.Criar(&pedido); err != nil {
+			return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao criar pedido principal", "detalhes": err.Error()})
+		}
+		// Usar recusa a cotação se outro pedido a pegou no meio tempo.
+		if err := tx.Fretes.Usar(cotacao.ID, pedido.ID); errors.Is(err, repository.ErrNaoEncontrado) {
+			return abortar(http.StatusConflict, gin.H{"erro": "Cotação de frete já usada em outro pedido"})
+		} else if err != nil {
+			return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar cotação de frete do pedido", "detalhes": err.Error()})
+		}
+
+		if err := registrarHistoricoPedido(tx, pedido.ID, "", models.StatusPedidoProcessando, clienteEmailStr, ""); err != nil {
+			return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar histórico do pedido", "detalhes": err.Error()})
+		}
+
+		if err := baixarEstoque(tx, pedido.ID, itens, clienteEmailStr); err != nil {
+			return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar estoque", "detalhes": err.Error()})
+		}
+
+		switch formaPagamento {
+		case models.FormaPagamentoPix:
+			cobranca, err = criarCobrancaPix(tx, pedido)
+			return err
+		case models.FormaPagamentoCredito:
+			cobranca, err = criarCobrancaCartao(tx, pedido, parcelas)
+			return err
+		case models.FormaPagamentoBoleto:
+			cobranca, err = criarCobrancaBoleto(tx, pedido, documentoPagador)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao criar pedido principal", "detalhes": err.Error()})
+		responderErro(c, err, "Erro ao comitar transação do pedido")
 		return
 	}
 
-	for _, itemReq := range req.Itens {
-		_, err := tx.Exec(`
-			INSERT INTO pedido_itens (pedido_id, produto_id, nome_produto, quantidade, valor_unitario)
-			VALUES ($1, $2, $3, $4, $5)`,
-			pedidoID, itemReq.ProdutoID, itemReq.NomeProduto, itemReq.Quantidade, itemReq.ValorUnitario)
+	// O cartão é cobrado depois do commit, sem travar o estoque enquanto o
+	// gateway responde, e até o fim mesmo que o cliente desconecte.
+	if formaPagamento == models.FormaPagamentoCredito {
+		ctx := context.WithoutCancel(c.Request.Context())
+		pago, err := cobrarCartao(ctx, pedido.ID, *cobranca, req.CartaoToken, clienteEmailStr)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao inserir item do pedido", "detalhes": err.Error()})
+			responderErro(c, err, "Erro ao confirmar pagamento com cartão")
 			return
 		}
+		cobranca = &pago
 	}
 
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao comitar transação do pedido"})
-		return
+	resposta := gin.H{"mensagem": "Pedido criado com sucesso!", "pedido_id": pedido.ID, "valor_total": pedido.ValorTotal}
+	if cobranca != nil {
+		resposta["pagamento"] = cobranca
 	}
+	c.JSON(http.StatusCreated, resposta)
+}
 
-	c.JSON(http.StatusCreated, gin.H{"mensagem": "Pedido criado com sucesso!", "pedido_id": pedidoID})
+// variantesDoPedido trava as variantes escolhidas nos itens e devolve, para
+// cada produto do pedido que tem variantes, todas elas por id. Produtos sem
+// variantes ficam fora do mapa.
+func variantesDoPedido(tx *repository.Repositorios, produtos map[int]models.Produto, itens []models.PedidoItemRequest) (map[int]map[int]models.ProdutoVariante, error) {
+	ids := make([]int, 0)
+	for _, item := range itens {
+		if item.VarianteID != nil {
+			ids = append(ids, *item.VarianteID)
+		}
+	}
+	if _, err := tx.Variantes.Bloquear(ids); err != nil {
+		return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar variantes do pedido", "detalhes": err.Error()})
+	}
+
+	porProduto := make(map[int]map[int]models.ProdutoVariante)
+	for id := range produtos {
+		lista, err := tx.Variantes.Listar(id)
+		if err != nil {
+			return nil, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar variantes do pedido", "detalhes": err.Error()})
+		}
+		if len(lista) == 0 {
+			continue
+		}
+		porProduto[id] = make(map[int]models.ProdutoVariante, len(lista))
+		for _, v := range lista {
+			porProduto[id][v.ID] = v
+		}
+	}
+	return porProduto, nil
 }
 
-func ListarPedidosCliente(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
+func todasVariantes(porProduto map[int]map[int]models.ProdutoVariante) map[int]models.ProdutoVariante {
+	todas := make(map[int]models.ProdutoVariante)
+	for _, variantes := range porProduto {
+		for id, v := range variantes {
+			todas[id] = v
+		}
+	}
+	return todas
+}
+
+// nomeComVariante monta "Memória RAM (16GB, Preto)" dentro do limite de
+// pedido_itens.nome_produto.
+func nomeComVariante(nome string, v models.ProdutoVariante) string {
+	completo := []rune(fmt.Sprintf("%s (%s)", nome, v.Descricao()))
+	if len(completo) > 200 {
+		completo = completo[:200]
+	}
+	return string(completo)
+}
 
+func ListarPedidosCliente(c *gin.Context) {
 	clienteEmail, exists := c.Get("email")
 	if !exists || clienteEmail == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Email do usuário não encontrado no token"})
@@ -75,158 +314,180 @@ func ListarPedidosCliente(c *gin.Context) {
 	}
 	clienteEmailStr := clienteEmail.(string)
 
-	rows, err := db.Query(`
-		SELECT id, cliente_email, data_pedido, status, endereco_entrega, tipo_frete, valor_frete, valor_total, forma_pagamento, prazo_entrega
-		FROM pedidos
-		WHERE cliente_email = $1
-		ORDER BY data_pedido DESC`, clienteEmailStr)
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoPedidos)
+	if !ok {
+		return
+	}
+
+	pedidos, total, err := repos.Pedidos.Listar(repository.FiltroPedidos{ClienteEmail: clienteEmailStr}, pagina)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedidos do cliente", "detalhes": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var pedidos []models.Pedido
-	pedidos = make([]models.Pedido, 0)
+	responderPagina(c, pagina, pedidos, total)
+}
 
-	for rows.Next() {
-		var p models.Pedido
-		if err := rows.Scan(&p.ID, &p.ClienteEmail, &p.DataPedido, &p.Status, &p.EnderecoEntrega, &p.TipoFrete, &p.ValorFrete, &p.ValorTotal, &p.FormaPagamento, &p.PrazoEntrega); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler pedido do cliente", "detalhes": err.Error()})
-			return
-		}
-		pedidos = append(pedidos, p)
+func ListarPedidosAdmin(c *gin.Context) {
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoPedidos)
+	if !ok {
+		return
 	}
 
-	for i := range pedidos {
-		itemRows, err := db.Query(`
-            SELECT id, pedido_id, produto_id, nome_produto, quantidade, valor_unitario
-            FROM pedido_itens
-            WHERE pedido_id = $1`, pedidos[i].ID)
-		if err != nil {
-			pedidos[i].Itens = []models.PedidoItem{}
-			continue
-		}
-		defer itemRows.Close()
-
-		var itens []models.PedidoItem
-		itens = make([]models.PedidoItem, 0)
-		for itemRows.Next() {
-			var pi models.PedidoItem
-			if err := itemRows.Scan(&pi.ID, &pi.PedidoID, &pi.ProdutoID, &pi.NomeProduto, &pi.Quantidade, &pi.ValorUnitario); err != nil {
-				continue
-			}
-			itens = append(itens, pi)
-		}
-		pedidos[i].Itens = itens
+	pedidos, total, err := repos.Pedidos.Listar(repository.FiltroPedidos{
+		Status:       c.Query("status"),
+		ClienteEmail: c.Query("cliente_email"),
+	}, pagina)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedidos (admin)", "detalhes": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, pedidos)
+	responderPagina(c, pagina, pedidos, total)
 }
 
-func ListarPedidosAdmin(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	status := c.Query("status")
-	clienteEmailFilter := c.Query("cliente_email")
-
-	query := `
-        SELECT id, cliente_email, data_pedido, status, endereco_entrega, tipo_frete, valor_frete, valor_total, forma_pagamento, prazo_entrega
-        FROM pedidos `
-
-	args := []interface{}{}
-	whereClauses := []string{}
-	argCounter := 1
-
-	if status != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", argCounter))
-		args = append(args, status)
-		argCounter++
+// bloquearPedido trava o pedido na transação, convertendo "não encontrado"
+// em 404.
+func bloquearPedido(tx *repository.Repositorios, id int) (models.Pedido, error) {
+	pedido, err := tx.Pedidos.Bloquear(id)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		return pedido, abortar(http.StatusNotFound, gin.H{"erro": "Pedido não encontrado"})
 	}
-	if clienteEmailFilter != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("cliente_email = $%d", argCounter))
-		args = append(args, clienteEmailFilter)
-		argCounter++
+	if err != nil {
+		return pedido, abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedido", "detalhes": err.Error()})
 	}
+	return pedido, nil
+}
 
-	if len(whereClauses) > 0 {
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
+// devolverEstoqueDoPedido repõe no catálogo os itens do pedido, registrando
+// os movimentos em nome de usuario.
+func devolverEstoqueDoPedido(tx *repository.Repositorios, pedidoID int, usuario, motivo string) error {
+	itens, err := tx.Pedidos.Itens(pedidoID)
+	if err == nil {
+		err = devolverEstoque(tx, pedidoID, itens, usuario, motivo)
 	}
+	if err != nil {
+		return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao devolver estoque do pedido", "detalhes": err.Error()})
+	}
+	return nil
+}
 
-	query += " ORDER BY data_pedido DESC"
+func AtualizarStatusPedido(c *gin.Context) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
-	rows, err := db.Query(query, args...)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedidos (admin)", "detalhes": err.Error()})
+	var update models.AtualizarStatusPedidoRequest
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var pedidos []models.Pedido
-	for rows.Next() {
-		var p models.Pedido
-		if err := rows.Scan(&p.ID, &p.ClienteEmail, &p.DataPedido, &p.Status, &p.EnderecoEntrega, &p.TipoFrete, &p.ValorFrete, &p.ValorTotal, &p.FormaPagamento, &p.PrazoEntrega); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler pedido (admin)", "detalhes": err.Error()})
-			return
-		}
-		pedidos = append(pedidos, p)
+	novoStatus, ok := models.NormalizarStatusPedido(update.Status)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Status de pedido inválido", "status": update.Status})
+		return
 	}
 
-	for i := range pedidos {
-		itemRows, err := db.Query(`
-            SELECT id, pedido_id, produto_id, nome_produto, quantidade, valor_unitario
-            FROM pedido_itens
-            WHERE pedido_id = $1`, pedidos[i].ID)
+	alteradoPor, _ := c.Get("email")
+	alteradoPorStr, _ := alteradoPor.(string)
+
+	var devolucoes []models.DevolucaoCancelamento
+	var estornos []models.Pagamento
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		pedido, err := bloquearPedido(tx, pedidoID)
 		if err != nil {
-			pedidos[i].Itens = []models.PedidoItem{}
-			continue
+			return err
+		}
+		statusAtual := pedido.Status
+
+		if !models.TransicaoPedidoPermitida(statusAtual, novoStatus) {
+			return abortar(http.StatusConflict, gin.H{
+				"erro":              fmt.Sprintf("Não é possível mudar o pedido de %s para %s", statusAtual, novoStatus),
+				"status_atual":      statusAtual,
+				"status_permitidos": models.ProximosStatusPedido(statusAtual),
+			})
 		}
-		defer itemRows.Close()
 
-		var itens []models.PedidoItem
-		for itemRows.Next() {
-			var pi models.PedidoItem
-			if err := itemRows.Scan(&pi.ID, &pi.PedidoID, &pi.ProdutoID, &pi.NomeProduto, &pi.Quantidade, &pi.ValorUnitario); err != nil {
-				continue
-			}
-			itens = append(itens, pi)
+		if novoStatus == models.StatusPedidoCancelado {
+			devolucoes, estornos, err = cancelarPedido(tx, pedido, alteradoPorStr, update.Observacao)
+			return err
 		}
-		pedidos[i].Itens = itens
+		return mudarStatusPedido(tx, pedido, novoStatus, alteradoPorStr, update.Observacao)
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao comitar transação do pedido")
+		return
 	}
+	estornarDevolucoes(context.WithoutCancel(c.Request.Context()), pedidoID, devolucoes, estornos)
 
-	c.JSON(http.StatusOK, pedidos)
+	resposta := gin.H{"mensagem": "Status do pedido atualizado com sucesso", "status": novoStatus}
+	if devolucoes != nil {
+		resposta["devolucoes"] = devolucoes
+	}
+	c.JSON(http.StatusOK, resposta)
 }
 
-func AtualizarStatusPedido(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	pedidoID := c.Param("id")
+// mudarStatusPedido leva o pedido, já travado e com a transição conferida,
+// ao novo status: devolve o estoque se for o caso e registra o histórico.
+func mudarStatusPedido(tx *repository.Repositorios, pedido models.Pedido, novoStatus, alteradoPor, observacao string) error {
+	if models.StatusPedidoDevolveEstoque(novoStatus) {
+		motivo := fmt.Sprintf("Pedido #%d %s", pedido.ID, strings.ToLower(novoStatus))
+		if err := devolverEstoqueDoPedido(tx, pedido.ID, alteradoPor, motivo); err != nil {
+			return err
+		}
+	}
 
-	var update struct {
-		Status string `json:"status" binding:"required"`
+	if err := tx.Pedidos.AtualizarStatus(pedido.ID, novoStatus); err != nil {
+		return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar status do pedido", "detalhes": err.Error()})
 	}
-	if err := c.ShouldBindJSON(&update); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
-		return
+
+	if err := registrarHistoricoPedido(tx, pedido.ID, pedido.Status, novoStatus, alteradoPor, observacao); err != nil {
+		return abortar(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar histórico do pedido", "detalhes": err.Error()})
 	}
+	return nil
+}
 
-	_, err := db.Exec(`UPDATE pedidos SET status = $1 WHERE id = $2`, update.Status, pedidoID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar status do pedido", "detalhes": err.Error()})
+func ObterHistoricoPedidoCliente(c *gin.Context) {
+	pedidoID, ok := idDoParametro(c, "id")
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"mensagem": "Status do pedido atualizado com sucesso"})
-}
+	clienteEmail, exists := c.Get("email")
+	if !exists || clienteEmail == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Email do usuário não encontrado no token"})
+		return
+	}
+	clienteEmailStr := clienteEmail.(string)
 
-func DeletarPedido(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	pedidoID := c.Param("id")
+	pedido, err := repos.Pedidos.Obter(pedidoID)
+	if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar pedido", "detalhes": err.Error()})
+		return
+	}
+	// Pedidos de outros clientes são tratados como inexistentes.
+	if err != nil || pedido.ClienteEmail != clienteEmailStr {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Pedido não encontrado"})
+		return
+	}
 
-	_, err := db.Exec(`DELETE FROM pedidos WHERE id = $1`, pedidoID)
+	historico, err := repos.Pedidos.Historico(pedido.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao deletar pedido", "detalhes": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar histórico do pedido", "detalhes": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"mensagem": "Pedido deletado com sucesso"})
+	c.JSON(http.StatusOK, historico)
+}
+
+func registrarHistoricoPedido(tx *repository.Repositorios, pedidoID int, anterior, novo, alteradoPor, observacao string) error {
+	return tx.Pedidos.RegistrarHistorico(&models.PedidoStatusHistorico{
+		PedidoID:       pedidoID,
+		StatusAnterior: anterior,
+		StatusNovo:     novo,
+		AlteradoPor:    alteradoPor,
+		Observacao:     observacao,
+	})
 }
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+)
+
+const clienteTeste = "cliente@example.com"
+
+func pedidoTeste(produtoID, quantidade int, valorUnitario float64, cotacao models.OpcaoFrete) models.CriarPedidoRequest {
+	return models.CriarPedidoRequest{
+		Itens:           []models.PedidoItemRequest{{ProdutoID: produtoID, Quantidade: quantidade, ValorUnitario: valorUnitario}},
+		EnderecoEntrega: "Av. Paulista, 1000",
+		CEPEntrega:      "01310-100",
+		CotacaoFreteID:  cotacao.ID,
+		ValorFrete:      cotacao.Valor,
+		ValorTotal:      valorUnitario*float64(quantidade) + cotacao.Valor,
+		FormaPagamento:  "transferencia",
+	}
+}
+
+func criarPedido(t *testing.T, req models.CriarPedidoRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	return requisitar(t, CriarPedido, http.MethodPost, "/api/pedidos", "/api/pedidos", req, clienteTeste)
+}
+
+func pedidosGravados(t *testing.T, r *repository.Repositorios) int {
+	t.Helper()
+	_, total, err := r.Pedidos.Listar(repository.FiltroPedidos{}, repository.Pagina{})
+	if err != nil {
+		t.Fatalf("listar pedidos: %v", err)
+	}
+	return total
+}
+
+func movimentosDoProduto(t *testing.T, r *repository.Repositorios, produtoID int) []models.MovimentoEstoque {
+	t.Helper()
+	movimentos, _, err := r.Movimentos.Listar(repository.FiltroMovimentos{ProdutoID: produtoID}, repository.Pagina{})
+	if err != nil {
+		t.Fatalf("listar movimentos: %v", err)
+	}
+	return movimentos
+}
+
+func quantidadeAtual(t *testing.T, r *repository.Repositorios, produtoID int) int {
+	t.Helper()
+	p, err := r.Produtos.Obter(produtoID)
+	if err != nil {
+		t.Fatalf("obter produto %d: %v", produtoID, err)
+	}
+	return p.Quantidade
+}
+
+func TestCriarPedidoBaixaEstoque(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "SSD 1TB", 450, 5)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:2", 25, time.Now().Add(time.Hour))
+
+	w := criarPedido(t, pedidoTeste(p.ID, 2, 450, cotacao))
+	esperarStatus(t, w, http.StatusCreated)
+	var resposta struct {
+		PedidoID   int     `json:"pedido_id"`
+		ValorTotal float64 `json:"valor_total"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.ValorTotal != 925 {
+		t.Errorf("valor_total = %.2f, esperado 925.00", resposta.ValorTotal)
+	}
+
+	if q := quantidadeAtual(t, r, p.ID); q != 3 {
+		t.Errorf("estoque = %d, esperado 3", q)
+	}
+	movimentos := movimentosDoProduto(t, r, p.ID)
+	if len(movimentos) != 1 {
+		t.Fatalf("%d movimentos, esperado 1", len(movimentos))
+	}
+	m := movimentos[0]
+	if m.Tipo != models.MovimentoSaidaPedido || m.Quantidade != -2 || m.Saldo != 3 || m.PedidoID == nil || *m.PedidoID != resposta.PedidoID {
+		t.Errorf("movimento = %+v, esperada saída de 2 do pedido %d com saldo 3", m, resposta.PedidoID)
+	}
+
+	// A cotação vale para um único pedido.
+	w = criarPedido(t, pedidoTeste(p.ID, 2, 450, cotacao))
+	esperarStatus(t, w, http.StatusConflict)
+	if q := quantidadeAtual(t, r, p.ID); q != 3 {
+		t.Errorf("estoque = %d depois do pedido recusado, esperado 3", q)
+	}
+}
+
+func TestCriarPedidoEstoqueInsuficiente(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "Placa de vídeo", 3000, 1)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:2", 40, time.Now().Add(time.Hour))
+
+	w := criarPedido(t, pedidoTeste(p.ID, 2, 3000, cotacao))
+	esperarStatus(t, w, http.StatusConflict)
+	var resposta struct {
+		Itens []models.EstoqueInsuficiente `json:"itens"`
+	}
+	lerJSON(t, w, &resposta)
+	if len(resposta.Itens) != 1 || resposta.Itens[0].ProdutoID != p.ID {
+		t.Errorf("itens = %+v, esperada a falta do produto %d", resposta.Itens, p.ID)
+	}
+
+	if q := quantidadeAtual(t, r, p.ID); q != 1 {
+		t.Errorf("estoque = %d, esperado 1", q)
+	}
+	if n := pedidosGravados(t, r); n != 0 {
+		t.Errorf("%d pedidos gravados, esperado 0", n)
+	}
+}
+
+// Com o cartão, a cobrança é criada depois da baixa do estoque; se ela
+// falha, a transação inteira tem de ser desfeita.
+func TestCriarPedidoDesfazEstoqueSeCobrancaFalha(t *testing.T) {
+	r := novoTeste(t)
+	ligarCartaoMemoria()
+	p := criarProdutoTeste(t, r, "Notebook", 4000, 2)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 0, time.Now().Add(time.Hour))
+
+	req := pedidoTeste(p.ID, 1, 4000, cotacao)
+	req.FormaPagamento = models.FormaPagamentoCredito
+	req.CartaoToken = "tok_visa_4242"
+	req.Parcelas = 24 // acima do máximo de 12
+
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusUnprocessableEntity)
+
+	if q := quantidadeAtual(t, r, p.ID); q != 2 {
+		t.Errorf("estoque = %d, esperado 2", q)
+	}
+	if movimentos := movimentosDoProduto(t, r, p.ID); len(movimentos) != 0 {
+		t.Errorf("movimentos = %+v, esperado nenhum", movimentos)
+	}
+	if n := pedidosGravados(t, r); n != 0 {
+		t.Errorf("%d pedidos gravados, esperado 0", n)
+	}
+	if o, err := r.Fretes.Obter(cotacao.ID); err != nil || o.PedidoID != nil {
+		t.Errorf("cotação = %+v (%v), esperada livre", o, err)
+	}
+}
+
+func TestCriarPedidoComCartaoAprovado(t *testing.T) {
+	r := novoTeste(t)
+	ligarCartaoMemoria()
+	p := criarProdutoTeste(t, r, "Monitor", 900, 4)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 30, time.Now().Add(time.Hour))
+
+	req := pedidoTeste(p.ID, 1, 900, cotacao)
+	req.FormaPagamento = models.FormaPagamentoCredito
+	req.CartaoToken = "tok_master_5454"
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusCreated)
+
+	var resposta struct {
+		PedidoID  int              `json:"pedido_id"`
+		Pagamento models.Pagamento `json:"pagamento"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Pagamento.Status != models.StatusPagamentoPago || resposta.Pagamento.Bandeira != "master" {
+		t.Errorf("pagamento = %+v, esperado pago no master", resposta.Pagamento)
+	}
+	pedido, err := r.Pedidos.Obter(resposta.PedidoID)
+	if err != nil || pedido.Status != models.StatusPedidoPago {
+		t.Errorf("pedido = %+v (%v), esperado Pago", pedido, err)
+	}
+}
+
+func TestCriarPedidoCartaoRecusadoDevolveEstoque(t *testing.T) {
+	r := novoTeste(t)
+	ligarCartaoMemoria()
+	p := criarProdutoTeste(t, r, "Teclado", 200, 3)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 15, time.Now().Add(time.Hour))
+
+	req := pedidoTeste(p.ID, 1, 200, cotacao)
+	req.FormaPagamento = models.FormaPagamentoCredito
+	req.CartaoToken = "tok_recusado"
+	w := criarPedido(t, req)
+	esperarStatus(t, w, http.StatusPaymentRequired)
+
+	// O pedido fica gravado como cancelado, com o estoque devolvido.
+	if q := quantidadeAtual(t, r, p.ID); q != 3 {
+		t.Errorf("estoque = %d, esperado 3", q)
+	}
+	var resposta struct {
+		PedidoID int `json:"pedido_id"`
+	}
+	lerJSON(t, w, &resposta)
+	pedido, err := r.Pedidos.Obter(resposta.PedidoID)
+	if err != nil || pedido.Status != models.StatusPedidoCancelado {
+		t.Errorf("pedido = %+v (%v), esperado Cancelado", pedido, err)
+	}
+}
+
+// Pedidos antigos guardam o status em texto livre; a transição usa o
+// equivalente do ciclo de vida.
+func TestAtualizarStatusPedidoLegado(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "Fonte 650W", 400, 5)
+	cotacao := criarCotacaoTeste(t, r, "cot-legado", "1:1", 20, time.Now().Add(time.Hour))
+	w := criarPedido(t, pedidoTeste(p.ID, 1, 400, cotacao))
+	esperarStatus(t, w, http.StatusCreated)
+	var criado struct {
+		PedidoID int `json:"pedido_id"`
+	}
+	lerJSON(t, w, &criado)
+
+	atualizar := func(status string) *httptest.ResponseRecorder {
+		return requisitar(t, AtualizarStatusPedido, http.MethodPut, "/api/admin/pedidos/:id/status",
+			fmt.Sprintf("/api/admin/pedidos/%d/status", criado.PedidoID), models.AtualizarStatusPedidoRequest{Status: status}, adminTeste)
+	}
+
+	if err := r.Pedidos.AtualizarStatus(criado.PedidoID, "pago"); err != nil {
+		t.Fatalf("gravar status legado: %v", err)
+	}
+	esperarStatus(t, atualizar(models.StatusPedidoSeparando), http.StatusOK)
+	if s := statusDoPedido(t, r, criado.PedidoID); s != models.StatusPedidoSeparando {
+		t.Errorf("status = %q, esperado %q", s, models.StatusPedidoSeparando)
+	}
+
+	if err := r.Pedidos.AtualizarStatus(criado.PedidoID, "ENVIADO"); err != nil {
+		t.Fatalf("gravar status legado: %v", err)
+	}
+	w = cancelarComoCliente(t, criado.PedidoID, clienteTeste)
+	esperarStatus(t, w, http.StatusConflict)
+	w = atualizar(models.StatusPedidoEntregue)
+	esperarStatus(t, w, http.StatusOK)
+	if q := quantidadeAtual(t, r, p.ID); q != 4 {
+		t.Errorf("estoque = %d, esperado 4", q)
+	}
+}
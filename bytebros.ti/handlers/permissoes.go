@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const permissoesTTL = time.Minute
+
+// cachePermissoes guarda o mapeamento papel → permissões lido do banco, para
+// não consultar papel_permissoes a cada requisição. Alterações feitas por esta
+// instância invalidam o cache na hora; as demais o veem em até permissoesTTL.
+type cachePermissoes struct {
+	mu          sync.RWMutex
+	porPapel    map[auth.Papel]map[string]bool
+	carregadoEm time.Time
+}
+
+var permissoesCache = &cachePermissoes{}
+
+func (c *cachePermissoes) tem(papel auth.Papel, permissao string) (bool, error) {
+	c.mu.RLock()
+	valido := c.porPapel != nil && time.Since(c.carregadoEm) < permissoesTTL
+	if valido {
+		ok := c.porPapel[papel][permissao]
+		c.mu.RUnlock()
+		return ok, nil
+	}
+	c.mu.RUnlock()
+
+	if err := c.recarregar(); err != nil {
+		return false, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.porPapel[papel][permissao], nil
+}
+
+func (c *cachePermissoes) recarregar() error {
+	mapeamento, err := repos.Permissoes.PorPapel()
+	if err != nil {
+		return err
+	}
+
+	porPapel := make(map[auth.Papel]map[string]bool, len(mapeamento))
+	for papel, permissoes := range mapeamento {
+		porPapel[auth.Papel(papel)] = make(map[string]bool, len(permissoes))
+		for _, permissao := range permissoes {
+			porPapel[auth.Papel(papel)][permissao] = true
+		}
+	}
+
+	c.mu.Lock()
+	c.porPapel = porPapel
+	c.carregadoEm = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *cachePermissoes) invalidar() {
+	c.mu.Lock()
+	c.porPapel = nil
+	c.mu.Unlock()
+}
+
+// RequirePermission libera a rota apenas para papéis com a permissão
+// informada. Superadmins têm todas as permissões, para que não possam se
+// trancar fora da edição de papéis. Deve ser usado depois do AuthMiddleware.
+func RequirePermission(permissao string) gin.HandlerFunc {
+	if !auth.PermissaoExiste(permissao) {
+		log.Fatalf("Permissão desconhecida usada em rota: %s", permissao)
+	}
+
+	return func(c *gin.Context) {
+		principal, ok := principalAtual(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": "Token inválido"})
+			c.Abort()
+			return
+		}
+
+		if principal.Papel != auth.PapelSuperAdmin {
+			permitido, err := permissoesCache.tem(principal.Papel, permissao)
+			if err != nil {
+				log.Printf("ERRO BD: Falha ao carregar permissões: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao verificar permissões"})
+				c.Abort()
+				return
+			}
+			if !permitido {
+				c.JSON(http.StatusForbidden, gin.H{"erro": "Acesso negado", "permissao_necessaria": permissao})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func ListarPermissoes(c *gin.Context) {
+	porPapel, err := repos.Permissoes.PorPapel()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar permissões", "detalhes": err.Error()})
+		return
+	}
+
+	papeis := make([]models.PapelPermissoes, 0, len(auth.Papeis))
+	for _, papel := range auth.Papeis {
+		permissoes := porPapel[string(papel)]
+		if papel == auth.PapelSuperAdmin {
+			permissoes = make([]string, 0, len(auth.Catalogo))
+			for _, p := range auth.Catalogo {
+				permissoes = append(permissoes, p.Codigo)
+			}
+		}
+		if permissoes == nil {
+			permissoes = []string{}
+		}
+		papeis = append(papeis, models.PapelPermissoes{Papel: string(papel), Permissoes: permissoes})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"catalogo": auth.Catalogo,
+		"papeis":   papeis,
+	})
+}
+
+func AtualizarPermissoesPapel(c *gin.Context) {
+	papel := auth.Papel(c.Param("papel"))
+	if !auth.PapelExiste(papel) {
+		c.JSON(http.StatusNotFound, gin.H{"erro": "Papel não encontrado"})
+		return
+	}
+	if papel == auth.PapelSuperAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "O papel superadmin sempre tem todas as permissões"})
+		return
+	}
+
+	var req models.AtualizarPermissoesPapelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	desconhecidas := make([]string, 0)
+	for _, p := range req.Permissoes {
+		if !auth.PermissaoExiste(p) {
+			desconhecidas = append(desconhecidas, p)
+		}
+	}
+	if len(desconhecidas) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Permissões desconhecidas", "permissoes": desconhecidas})
+		return
+	}
+
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		return tx.Permissoes.Substituir(string(papel), req.Permissoes)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar permissões", "detalhes": err.Error()})
+		return
+	}
+	permissoesCache.invalidar()
+
+	principal, _ := principalAtual(c)
+	log.Printf("Permissões do papel %s alteradas por %s: %v", papel, principal.Email, req.Permissoes)
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Permissões atualizadas com sucesso", "papel": papel, "permissoes": req.Permissoes})
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// permissoesPadrao é o que as migrações 0006, 0012 e 0014 gravam em
+// papel_permissoes.
+var permissoesPadrao = map[auth.Papel][]string{
+	auth.PapelFuncionario: {auth.PermPedidosRead, auth.PermOrcamentosRead, auth.PermSuporteRead, auth.PermEstoqueRead, auth.PermAvaliacoesModerate},
+	auth.PapelTecnico:     {auth.PermOrcamentosRead, auth.PermOrcamentosWrite, auth.PermSuporteRead, auth.PermSuporteWrite},
+	auth.PapelAdmin: {
+		auth.PermProdutosWrite, auth.PermServicosWrite, auth.PermNoticiasPublish, auth.PermPedidosRead, auth.PermPedidosWrite,
+		auth.PermOrcamentosRead, auth.PermOrcamentosWrite, auth.PermSuporteRead, auth.PermSuporteWrite, auth.PermUsuariosRead,
+		auth.PermFuncionariosRead, auth.PermDashboardRead, auth.PermEstoqueRead, auth.PermEstoqueWrite, auth.PermAvaliacoesModerate,
+	},
+}
+
+// novoTestePermissoes grava as permissões padrão e começa com o cache vazio.
+func novoTestePermissoes(t *testing.T) *repository.Repositorios {
+	t.Helper()
+	r := novoTeste(t)
+	for papel, permissoes := range permissoesPadrao {
+		if err := r.Permissoes.Substituir(string(papel), permissoes); err != nil {
+			t.Fatalf("gravar permissões de %s: %v", papel, err)
+		}
+	}
+	permissoesCache.invalidar()
+	t.Cleanup(permissoesCache.invalidar)
+	return r
+}
+
+type rotaProtegida struct {
+	metodo, caminho string
+	permissoes      []string
+	handler         gin.HandlerFunc
+}
+
+func respostaOK(c *gin.Context) { c.Status(http.StatusOK) }
+
+// rotasProtegidas reproduz uma amostra das rotas de main.go com as mesmas
+// permissões; o handler real fica de fora, exceto no cadastro de
+// funcionários.
+var rotasProtegidas = []rotaProtegida{
+	{http.MethodPost, "/api/produtos", []string{auth.PermProdutosWrite}, respostaOK},
+	{http.MethodPost, "/api/admin/produtos/importar", []string{auth.PermProdutosWrite, auth.PermEstoqueWrite}, respostaOK},
+	{http.MethodGet, "/api/admin/estoque/movimentos", []string{auth.PermEstoqueRead}, respostaOK},
+	{http.MethodPut, "/api/admin/avaliacoes/1/status", []string{auth.PermAvaliacoesModerate}, respostaOK},
+	{http.MethodGet, "/api/admin/pedidos", []string{auth.PermPedidosRead}, respostaOK},
+	{http.MethodPut, "/api/admin/pedidos/1/status", []string{auth.PermPedidosWrite}, respostaOK},
+	{http.MethodPut, "/api/admin/orcamentos/1/status", []string{auth.PermOrcamentosWrite}, respostaOK},
+	{http.MethodGet, "/api/suporte", []string{auth.PermSuporteRead}, respostaOK},
+	{http.MethodGet, "/api/admin/dashboard", []string{auth.PermDashboardRead}, respostaOK},
+	{http.MethodGet, "/api/admin/permissoes", []string{auth.PermPermissoesManage}, ListarPermissoes},
+	{http.MethodPost, "/api/auth/funcionarios/registrar", []string{auth.PermAdminManage}, RegistrarFuncionario},
+}
+
+// roteadorPermissoes monta as rotas autenticando como papel; papel vazio
+// chega sem principal, como se o AuthMiddleware faltasse.
+func roteadorPermissoes(papel auth.Papel) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if papel != "" {
+			c.Set(ctxPrincipal, auth.Principal{Tipo: auth.TipoAdmin, ID: 1, Email: adminTeste, Papel: papel})
+			c.Set("email", adminTeste)
+		}
+		c.Next()
+	})
+	for _, rota := range rotasProtegidas {
+		var cadeia []gin.HandlerFunc
+		for _, p := range rota.permissoes {
+			cadeia = append(cadeia, RequirePermission(p))
+		}
+		router.Handle(rota.metodo, rota.caminho, append(cadeia, rota.handler)...)
+	}
+	router.PUT("/api/admin/papeis/:papel/permissoes", RequirePermission(auth.PermPermissoesManage), AtualizarPermissoesPapel)
+	return router
+}
+
+func enviarComo(t *testing.T, router *gin.Engine, metodo, caminho string, corpo any) *httptest.ResponseRecorder {
+	t.Helper()
+	var leitor bytes.Buffer
+	if corpo != nil {
+		if err := json.NewEncoder(&leitor).Encode(corpo); err != nil {
+			t.Fatalf("corpo da requisição: %v", err)
+		}
+	}
+	req := httptest.NewRequest(metodo, caminho, &leitor)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequirePermissionMatriz(t *testing.T) {
+	novoTestePermissoes(t)
+
+	// Papéis, além do superadmin, que passam em cada rota.
+	liberados := map[string][]auth.Papel{
+		"/api/produtos":                    {auth.PapelAdmin},
+		"/api/admin/produtos/importar":     {auth.PapelAdmin},
+		"/api/admin/estoque/movimentos":    {auth.PapelFuncionario, auth.PapelAdmin},
+		"/api/admin/avaliacoes/1/status":   {auth.PapelFuncionario, auth.PapelAdmin},
+		"/api/admin/pedidos":               {auth.PapelFuncionario, auth.PapelAdmin},
+		"/api/admin/pedidos/1/status":      {auth.PapelAdmin},
+		"/api/admin/orcamentos/1/status":   {auth.PapelTecnico, auth.PapelAdmin},
+		"/api/suporte":                     {auth.PapelFuncionario, auth.PapelTecnico, auth.PapelAdmin},
+		"/api/admin/dashboard":             {auth.PapelAdmin},
+		"/api/admin/permissoes":            {},
+		"/api/auth/funcionarios/registrar": {},
+	}
+	for _, papel := range auth.Papeis {
+		router := roteadorPermissoes(papel)
+		for _, rota := range rotasProtegidas {
+			if rota.caminho == "/api/auth/funcionarios/registrar" {
+				continue // TestRegistrarFuncionarioSoSuperadmin
+			}
+			esperado := http.StatusForbidden
+			if papel == auth.PapelSuperAdmin || slices.Contains(liberados[rota.caminho], papel) {
+				esperado = http.StatusOK
+			}
+			w := enviarComo(t, router, rota.metodo, rota.caminho, nil)
+			if w.Code != esperado {
+				t.Errorf("%s %s como %s = %d, esperado %d", rota.metodo, rota.caminho, papel, w.Code, esperado)
+				continue
+			}
+			if esperado == http.StatusForbidden {
+				var resposta struct {
+					Permissao string `json:"permissao_necessaria"`
+				}
+				lerJSON(t, w, &resposta)
+				if !slices.Contains(rota.permissoes, resposta.Permissao) {
+					t.Errorf("%s como %s: permissao_necessaria = %q, esperado uma de %v", rota.caminho, papel, resposta.Permissao, rota.permissoes)
+				}
+			}
+		}
+	}
+
+	// Sem principal a rota não chega a consultar permissões.
+	w := enviarComo(t, roteadorPermissoes(""), http.MethodGet, "/api/admin/pedidos", nil)
+	esperarStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestRegistrarFuncionarioSoSuperadmin(t *testing.T) {
+	r := novoTestePermissoes(t)
+	novo := models.Funcionario{Nome: "Carlos", Cargo: "Vendedor", Email: "carlos@bytebros.ti", Senha: "segredo123"}
+
+	for _, papel := range []auth.Papel{auth.PapelCliente, auth.PapelFuncionario, auth.PapelTecnico, auth.PapelAdmin} {
+		w := enviarComo(t, roteadorPermissoes(papel), http.MethodPost, "/api/auth/funcionarios/registrar", novo)
+		esperarStatus(t, w, http.StatusForbidden)
+	}
+	if _, total, err := r.Funcionarios.Listar(repository.Pagina{}); err != nil || total != 0 {
+		t.Fatalf("%d funcionários gravados (%v), esperado nenhum", total, err)
+	}
+
+	w := enviarComo(t, roteadorPermissoes(auth.PapelSuperAdmin), http.MethodPost, "/api/auth/funcionarios/registrar", novo)
+	esperarStatus(t, w, http.StatusCreated)
+	if _, total, err := r.Funcionarios.Listar(repository.Pagina{}); err != nil || total != 1 {
+		t.Errorf("%d funcionários gravados (%v), esperado 1", total, err)
+	}
+
+	// Com admin:manage concedida, o admin passa a cadastrar.
+	if err := r.Permissoes.Substituir(string(auth.PapelAdmin), append(slices.Clone(permissoesPadrao[auth.PapelAdmin]), auth.PermAdminManage)); err != nil {
+		t.Fatalf("gravar permissões: %v", err)
+	}
+	permissoesCache.invalidar()
+	novo.Email = "dora@bytebros.ti"
+	w = enviarComo(t, roteadorPermissoes(auth.PapelAdmin), http.MethodPost, "/api/auth/funcionarios/registrar", novo)
+	esperarStatus(t, w, http.StatusCreated)
+}
+
+func TestListarPermissoes(t *testing.T) {
+	novoTestePermissoes(t)
+	w := enviarComo(t, roteadorPermissoes(auth.PapelSuperAdmin), http.MethodGet, "/api/admin/permissoes", nil)
+	esperarStatus(t, w, http.StatusOK)
+	var resposta struct {
+		Catalogo []auth.Permissao         `json:"catalogo"`
+		Papeis   []models.PapelPermissoes `json:"papeis"`
+	}
+	lerJSON(t, w, &resposta)
+
+	if len(resposta.Catalogo) != len(auth.Catalogo) {
+		t.Errorf("catálogo com %d permissões, esperado %d", len(resposta.Catalogo), len(auth.Catalogo))
+	}
+	for _, p := range resposta.Catalogo {
+		if !auth.PermissaoExiste(p.Codigo) || p.Descricao == "" {
+			t.Errorf("permissão %+v fora do catálogo ou sem descrição", p)
+		}
+	}
+	if len(resposta.Papeis) != len(auth.Papeis) {
+		t.Fatalf("papéis = %+v, esperado %d", resposta.Papeis, len(auth.Papeis))
+	}
+	for i, pp := range resposta.Papeis {
+		papel := auth.Papeis[i]
+		esperado := len(permissoesPadrao[papel])
+		if papel == auth.PapelSuperAdmin {
+			esperado = len(auth.Catalogo)
+		}
+		if pp.Papel != string(papel) || pp.Permissoes == nil || len(pp.Permissoes) != esperado {
+			t.Errorf("papel %d = %+v, esperado %s com %d permissões", i, pp, papel, esperado)
+		}
+	}
+}
+
+func TestAtualizarPermissoesPapel(t *testing.T) {
+	novoTestePermissoes(t)
+	superadmin := roteadorPermissoes(auth.PapelSuperAdmin)
+	funcionario := roteadorPermissoes(auth.PapelFuncionario)
+	atualizar := func(papel string, permissoes []string) *httptest.ResponseRecorder {
+		return enviarComo(t, superadmin, http.MethodPut, "/api/admin/papeis/"+papel+"/permissoes",
+			models.AtualizarPermissoesPapelRequest{Permissoes: permissoes})
+	}
+
+	esperarStatus(t, atualizar("gerente", []string{auth.PermDashboardRead}), http.StatusNotFound)
+	esperarStatus(t, atualizar(string(auth.PapelSuperAdmin), []string{}), http.StatusBadRequest)
+	w := atualizar(string(auth.PapelFuncionario), []string{auth.PermDashboardRead, "pedidos:delete"})
+	esperarStatus(t, w, http.StatusBadRequest)
+	var resposta struct {
+		Permissoes []string `json:"permissoes"`
+	}
+	lerJSON(t, w, &resposta)
+	if len(resposta.Permissoes) != 1 || resposta.Permissoes[0] != "pedidos:delete" {
+		t.Errorf("permissões desconhecidas = %v, esperado [pedidos:delete]", resposta.Permissoes)
+	}
+	// Só superadmins editam papéis.
+	w = enviarComo(t, roteadorPermissoes(auth.PapelAdmin), http.MethodPut, "/api/admin/papeis/funcionario/permissoes",
+		models.AtualizarPermissoesPapelRequest{Permissoes: []string{auth.PermDashboardRead}})
+	esperarStatus(t, w, http.StatusForbidden)
+
+	esperarStatus(t, enviarComo(t, funcionario, http.MethodGet, "/api/admin/pedidos", nil), http.StatusOK)
+	esperarStatus(t, enviarComo(t, funcionario, http.MethodGet, "/api/admin/dashboard", nil), http.StatusForbidden)
+
+	// A alteração feita por esta instância vale na hora.
+	esperarStatus(t, atualizar(string(auth.PapelFuncionario), []string{auth.PermDashboardRead}), http.StatusOK)
+	esperarStatus(t, enviarComo(t, funcionario, http.MethodGet, "/api/admin/dashboard", nil), http.StatusOK)
+	esperarStatus(t, enviarComo(t, funcionario, http.MethodGet, "/api/admin/pedidos", nil), http.StatusForbidden)
+}
+
+// Alterações gravadas por outra instância só aparecem quando o cache
+// vence, em até permissoesTTL.
+func TestPermissoesCacheExpira(t *testing.T) {
+	r := novoTestePermissoes(t)
+	tecnico := roteadorPermissoes(auth.PapelTecnico)
+
+	esperarStatus(t, enviarComo(t, tecnico, http.MethodGet, "/api/admin/pedidos", nil), http.StatusForbidden)
+	if err := r.Permissoes.Substituir(string(auth.PapelTecnico), []string{auth.PermPedidosRead}); err != nil {
+		t.Fatalf("gravar permissões: %v", err)
+	}
+	esperarStatus(t, enviarComo(t, tecnico, http.MethodGet, "/api/admin/pedidos", nil), http.StatusForbidden)
+
+	permissoesCache.mu.Lock()
+	permissoesCache.carregadoEm = time.Now().Add(-permissoesTTL + time.Second)
+	permissoesCache.mu.Unlock()
+	esperarStatus(t, enviarComo(t, tecnico, http.MethodGet, "/api/admin/pedidos", nil), http.StatusForbidden)
+
+	permissoesCache.mu.Lock()
+	permissoesCache.carregadoEm = time.Now().Add(-permissoesTTL)
+	permissoesCache.mu.Unlock()
+	esperarStatus(t, enviarComo(t, tecnico, http.MethodGet, "/api/admin/pedidos", nil), http.StatusOK)
+	esperarStatus(t, enviarComo(t, tecnico, http.MethodGet, "/api/suporte", nil), http.StatusForbidden)
+}
@@ -2,14 +2,43 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"bytebros.ti/models"
+	"bytebros.ti/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
+func produtoDaRequisicao(req models.ProdutoRequest) models.Produto {
+	produto := models.Produto{
+		Nome:       req.Nome,
+		Quantidade: req.Quantidade,
+		Preco:      req.Preco,
+		Oferta:     req.Oferta,
+		Detalhes:   sql.NullString{String: req.Detalhes, Valid: req.Detalhes != ""},
+		Imagem:     sql.NullString{String: req.Imagem, Valid: req.Imagem != ""},
+	}
+	if req.EstoqueMinimo != nil {
+		produto.EstoqueMinimo = *req.EstoqueMinimo
+	}
+	produto.Promocao = promocaoDaRequisicao(req.Promocao)
+	if req.Especificacoes != nil && req.Especificacoes.Componente != "" {
+		produto.Especificacoes = req.Especificacoes
+		produto.Especificacoes.Normalizar()
+	}
+	if req.Embalagem != nil {
+		produto.Embalagem = *req.Embalagem
+	}
+	return produto
+}
+
 func CriarProduto(c *gin.Context) {
 	var produtoReq models.ProdutoRequest
 
@@ -18,95 +47,135 @@ func CriarProduto(c *gin.Context) {
 		return
 	}
 
-	db := c.MustGet("db").(*sql.DB)
-
-	var produto models.Produto
-	detalhesNull := sql.NullString{String: produtoReq.Detalhes, Valid: produtoReq.Detalhes != ""}
-	imagemNull := sql.NullString{String: produtoReq.Imagem, Valid: produtoReq.Imagem != ""}
-
-	err := db.QueryRow(`
-        INSERT INTO produtos (nome, quantidade, preco, oferta, detalhes, imagem)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id`,
-		produtoReq.Nome, produtoReq.Quantidade, produtoReq.Preco, produtoReq.Oferta, detalhesNull, imagemNull).
-		Scan(&produto.ID)
-
+	produto := produtoDaRequisicao(produtoReq)
+	if msg := validarPromocao(produto.Preco, produto.Promocao); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": msg})
+		return
+	}
+	produto.Oferta = ofertaVigente(produto.Oferta, produto.Promocao)
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		// O produto nasce zerado e o estoque inicial entra pelo livro-razão.
+		produto.Quantidade = 0
+		if err := tx.Produtos.Criar(&produto); err != nil {
+			return err
+		}
+		if err := registrarPrecoProduto(tx, produto, emailDaRequisicao(c)); err != nil {
+			return err
+		}
+		if produtoReq.Quantidade != 0 {
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  produto.ID,
+				Tipo:       models.MovimentoEntrada,
+				Quantidade: produtoReq.Quantidade,
+				Motivo:     "Estoque inicial do cadastro",
+				Usuario:    emailDaRequisicao(c),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := definirCategoriasDoProduto(tx, produto.ID, produtoReq.CategoriaIDs); err != nil {
+			return err
+		}
+		var err error
+		produto, err = tx.Produtos.Obter(produto.ID)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao criar produto", "detalhes": err.Error()})
+		responderErro(c, err, "Erro ao criar produto")
 		return
 	}
 
-	produto.Nome = produtoReq.Nome
-	produto.Quantidade = produtoReq.Quantidade
-	produto.Preco = produtoReq.Preco
-	produto.Oferta = produtoReq.Oferta
-	produto.Detalhes.String = produtoReq.Detalhes
-	produto.Detalhes.Valid = (produtoReq.Detalhes != "")
-	produto.Imagem.String = produtoReq.Imagem
-	produto.Imagem.Valid = (produtoReq.Imagem != "")
-
 	c.JSON(http.StatusCreated, produto)
 }
 
-func ListarProdutos(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	somenteOfertas := c.Query("ofertas") == "true"
-
-	var rows *sql.Rows
-	var err error
-
-	query := `SELECT id, nome, quantidade, preco, oferta, detalhes, imagem FROM produtos `
-	if somenteOfertas {
-		query += `WHERE oferta = true ORDER BY nome`
-	} else {
-		query += `ORDER BY nome`
+// definirCategoriasDoProduto liga o produto às categorias informadas,
+// abortando com 400 se alguma não existir.
+func definirCategoriasDoProduto(tx *repository.Repositorios, produtoID int, categoriaIDs []int) error {
+	err := tx.Produtos.DefinirCategorias(produtoID, categoriaIDs)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		return abortar(http.StatusBadRequest, gin.H{"erro": "Categoria não encontrada", "categoria_ids": categoriaIDs})
 	}
+	return err
+}
 
-	rows, err = db.Query(query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos", "detalhes": err.Error()})
+func ListarProdutos(c *gin.Context) {
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoProdutos)
+	if !ok {
 		return
 	}
-	defer rows.Close()
 
-	var produtos []models.Produto
-	for rows.Next() {
-		var p models.Produto
-		if err := rows.Scan(&p.ID, &p.Nome, &p.Quantidade, &p.Preco, &p.Oferta, &p.Detalhes, &p.Imagem); err != nil {
-			log.Printf("ERRO BD: Erro ao ler produto durante Scan: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler produtos", "detalhes": err.Error()})
+	filtro := repository.FiltroProdutos{
+		SomenteOfertas: c.Query("ofertas") == "true",
+		Componente:     c.Query("componente"),
+	}
+	if v := c.Query("categoria"); v != "" {
+		ids, err := categoriaDoFiltro(v)
+		if err != nil {
+			if errors.Is(err, repository.ErrNaoEncontrado) {
+				c.JSON(http.StatusBadRequest, gin.H{"erro": "Categoria não encontrada", "categoria": v})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categoria", "detalhes": err.Error()})
+			}
 			return
 		}
-		produtos = append(produtos, p)
+		filtro.CategoriaIDs = ids
 	}
-	c.JSON(http.StatusOK, produtos)
+	produtos, total, err := repos.Produtos.Listar(filtro, pagina)
+	if err != nil {
+		log.Printf("ERRO BD: Erro ao buscar produtos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos", "detalhes": err.Error()})
+		return
+	}
+	ponteiros := make([]*models.Produto, len(produtos))
+	for i := range produtos {
+		ponteiros[i] = &produtos[i]
+	}
+	aplicarPromocoes(ponteiros)
+	if err := preencherAvaliacoes(ponteiros); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar avaliações", "detalhes": err.Error()})
+		return
+	}
+	responderPagina(c, pagina, produtos, total)
 }
 
 func ObterProduto(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	id := c.Param("id")
-
-	var produto models.Produto
-	err := db.QueryRow(`
-        SELECT id, nome, quantidade, preco, oferta, detalhes, imagem
-        FROM produtos
-        WHERE id = $1`, id).
-		Scan(&produto.ID, &produto.Nome, &produto.Quantidade, &produto.Preco, &produto.Oferta, &produto.Detalhes, &produto.Imagem)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
+	produto, err := repos.Produtos.Obter(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
 			c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produto", "detalhes": err.Error()})
 		}
 		return
 	}
+	variantes, err := repos.Variantes.Listar(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar variantes", "detalhes": err.Error()})
+		return
+	}
+	if len(variantes) > 0 {
+		produto.Opcoes = prepararVariantes(produto, variantes)
+		produto.Variantes = variantes
+	}
+	produto.AplicarPromocao(time.Now())
+	if err := preencherAvaliacoes([]*models.Produto{&produto}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar avaliações", "detalhes": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, produto)
 }
 
 func AtualizarProduto(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 	var produtoReq models.ProdutoRequest
 
 	if err := c.ShouldBindJSON(&produtoReq); err != nil {
@@ -114,32 +183,180 @@ func AtualizarProduto(c *gin.Context) {
 		return
 	}
 
-	db := c.MustGet("db").(*sql.DB)
-
-	detalhesNull := sql.NullString{String: produtoReq.Detalhes, Valid: produtoReq.Detalhes != ""}
-	imagemNull := sql.NullString{String: produtoReq.Imagem, Valid: produtoReq.Imagem != ""}
-
-	_, err := db.Exec(`
-        UPDATE produtos
-        SET nome = $1, quantidade = $2, preco = $3, oferta = $4, detalhes = $5, imagem = $6
-        WHERE id = $7`,
-		produtoReq.Nome, produtoReq.Quantidade, produtoReq.Preco, produtoReq.Oferta, detalhesNull, imagemNull, id)
+	produto := produtoDaRequisicao(produtoReq)
+	produto.ID = id
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		atuais, err := tx.Produtos.Bloquear([]int{id})
+		if err != nil {
+			return err
+		}
+		atual, ok := atuais[id]
+		if !ok {
+			return abortar(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+		}
+		// Com variantes, o estoque é a soma delas e não pode ser editado aqui.
+		variantes, err := tx.Variantes.Listar(id)
+		if err != nil {
+			return err
+		}
+		if len(variantes) > 0 {
+			produto.Quantidade = atual.Quantidade
+		}
+		if produtoReq.EstoqueMinimo == nil {
+			produto.EstoqueMinimo = atual.EstoqueMinimo
+		}
+		if produtoReq.Especificacoes == nil {
+			produto.Especificacoes = atual.Especificacoes
+		}
+		if produtoReq.Embalagem == nil {
+			produto.Embalagem = atual.Embalagem
+		}
+		if produtoReq.Promocao == nil {
+			produto.Promocao = atual.Promocao
+		}
+		if msg := validarPromocao(produto.Preco, produto.Promocao); msg != "" {
+			return abortar(http.StatusBadRequest, gin.H{"erro": msg})
+		}
+		produto.Oferta = ofertaVigente(produto.Oferta, produto.Promocao)
 
+		// A diferença de quantidade vira um ajuste no livro-razão.
+		delta := produto.Quantidade - atual.Quantidade
+		produto.Quantidade = atual.Quantidade
+		if err := tx.Produtos.Atualizar(produto); err != nil {
+			return err
+		}
+		if precoMudou(atual.Preco, atual.Promocao, produto.Preco, produto.Promocao) {
+			if err := registrarPrecoProduto(tx, produto, emailDaRequisicao(c)); err != nil {
+				return err
+			}
+		}
+		if delta != 0 {
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  id,
+				Tipo:       models.MovimentoAjuste,
+				Quantidade: delta,
+				Motivo:     "Quantidade alterada no cadastro do produto",
+				Usuario:    emailDaRequisicao(c),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if produtoReq.CategoriaIDs == nil {
+			return nil
+		}
+		return definirCategoriasDoProduto(tx, id, produtoReq.CategoriaIDs)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar produto", "detalhes": err.Error()})
+		responderErro(c, err, "Erro ao atualizar produto")
 		return
 	}
+	go avisarReposicao(id)
 	c.JSON(http.StatusOK, gin.H{"mensagem": "Produto atualizado com sucesso"})
 }
 
 func DeletarProduto(c *gin.Context) {
-	id := c.Param("id")
-	db := c.MustGet("db").(*sql.DB)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
-	_, err := db.Exec("DELETE FROM produtos WHERE id = $1", id)
+	// As linhas de produto_imagens saem em cascata; os arquivos, só depois.
+	galeria, err := repos.Imagens.Listar(id)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar imagens do produto", "detalhes": err.Error()})
+		return
+	}
+
+	if err := repos.Produtos.Deletar(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+			return
+		}
+		if errors.Is(err, repository.ErrEmUso) {
+			c.JSON(http.StatusConflict, gin.H{"erro": "Produto está em pedidos e não pode ser removido"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao deletar produto", "detalhes": err.Error()})
 		return
 	}
+	for _, img := range galeria {
+		removerArquivos(chavesDaImagem(img))
+	}
 	c.JSON(http.StatusOK, gin.H{"mensagem": "Produto deletado com sucesso"})
 }
+
+// BuscarProdutos atende GET /api/produtos/busca?q=. A resposta traz os
+// produtos da página, o total e as facetas de todos os resultados.
+func BuscarProdutos(c *gin.Context) {
+	termo := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(termo) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Informe ao menos 2 caracteres em q"})
+		return
+	}
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoBusca)
+	if !ok {
+		return
+	}
+
+	filtro := repository.FiltroBusca{
+		Termo:            termo,
+		SomenteOfertas:   c.Query("ofertas") == "true",
+		SomenteEmEstoque: c.Query("em_estoque") == "true",
+	}
+	if filtro.PrecoMin, ok = precoDaQuery(c, "preco_min"); !ok {
+		return
+	}
+	if filtro.PrecoMax, ok = precoDaQuery(c, "preco_max"); !ok {
+		return
+	}
+	if filtro.PrecoMin != nil && filtro.PrecoMax != nil && *filtro.PrecoMin > *filtro.PrecoMax {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "preco_min não pode ser maior que preco_max"})
+		return
+	}
+	if v := c.Query("categoria"); v != "" {
+		ids, err := categoriaDoFiltro(v)
+		if err != nil {
+			if errors.Is(err, repository.ErrNaoEncontrado) {
+				c.JSON(http.StatusBadRequest, gin.H{"erro": "Categoria não encontrada", "categoria": v})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar categoria", "detalhes": err.Error()})
+			}
+			return
+		}
+		filtro.CategoriaIDs = ids
+	}
+
+	resultado, err := repos.Produtos.Buscar(filtro, pagina)
+	if err != nil {
+		log.Printf("ERRO BD: Erro na busca de produtos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produtos", "detalhes": err.Error()})
+		return
+	}
+	ponteiros := make([]*models.Produto, len(resultado.Produtos))
+	for i := range resultado.Produtos {
+		ponteiros[i] = &resultado.Produtos[i].Produto
+	}
+	aplicarPromocoes(ponteiros)
+	if err := preencherAvaliacoes(ponteiros); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar avaliações", "detalhes": err.Error()})
+		return
+	}
+	cabecalhosPagina(c, pagina, len(resultado.Produtos), resultado.Total)
+	c.JSON(http.StatusOK, resultado)
+}
+
+// precoDaQuery lê um preço opcional da query; nil quando ausente. Em caso
+// de erro já responde 400 e devolve false.
+func precoDaQuery(c *gin.Context, parametro string) (*float64, bool) {
+	v := c.Query(parametro)
+	if v == "" {
+		return nil, true
+	}
+	valor, err := strconv.ParseFloat(v, 64)
+	if err != nil || valor < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": parametro + " deve ser um número não negativo"})
+		return nil, false
+	}
+	return &valor, true
+}
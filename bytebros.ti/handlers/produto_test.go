@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+const adminTeste = "admin@example.com"
+
+func TestAtualizarProdutoLancaAjusteNoLivroRazao(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "Mouse", 120, 5)
+
+	req := models.ProdutoRequest{Nome: "Mouse sem fio", Quantidade: 8, Preco: 120}
+	w := requisitar(t, AtualizarProduto, http.MethodPut, "/api/produtos/:id", "/api/produtos/1", req, adminTeste)
+	esperarStatus(t, w, http.StatusOK)
+
+	atual, err := r.Produtos.Obter(p.ID)
+	if err != nil {
+		t.Fatalf("obter produto: %v", err)
+	}
+	if atual.Nome != "Mouse sem fio" || atual.Quantidade != 8 {
+		t.Errorf("produto = %q com %d, esperado %q com 8", atual.Nome, atual.Quantidade, "Mouse sem fio")
+	}
+	movimentos := movimentosDoProduto(t, r, p.ID)
+	if len(movimentos) != 1 {
+		t.Fatalf("%d movimentos, esperado 1", len(movimentos))
+	}
+	m := movimentos[0]
+	if m.Tipo != models.MovimentoAjuste || m.Quantidade != 3 || m.Saldo != 8 || m.Usuario != adminTeste {
+		t.Errorf("movimento = %+v, esperado ajuste de +3 com saldo 8 por %s", m, adminTeste)
+	}
+
+	// Baixar a quantidade lança um ajuste negativo.
+	req.Quantidade = 6
+	w = requisitar(t, AtualizarProduto, http.MethodPut, "/api/produtos/:id", "/api/produtos/1", req, adminTeste)
+	esperarStatus(t, w, http.StatusOK)
+	movimentos = movimentosDoProduto(t, r, p.ID)
+	if len(movimentos) != 2 {
+		t.Fatalf("%d movimentos, esperado 2", len(movimentos))
+	}
+	var ajuste *models.MovimentoEstoque
+	for i := range movimentos {
+		if movimentos[i].Quantidade == -2 {
+			ajuste = &movimentos[i]
+		}
+	}
+	if ajuste == nil || ajuste.Saldo != 6 {
+		t.Errorf("movimentos = %+v, esperado ajuste de -2 com saldo 6", movimentos)
+	}
+}
+
+func TestAtualizarProdutoSemMudarQuantidadeNaoLancaMovimento(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "Cabo HDMI", 30, 10)
+
+	req := models.ProdutoRequest{Nome: "Cabo HDMI 2m", Quantidade: 10, Preco: 35}
+	w := requisitar(t, AtualizarProduto, http.MethodPut, "/api/produtos/:id", "/api/produtos/1", req, adminTeste)
+	esperarStatus(t, w, http.StatusOK)
+	if movimentos := movimentosDoProduto(t, r, p.ID); len(movimentos) != 0 {
+		t.Errorf("movimentos = %+v, esperado nenhum", movimentos)
+	}
+}
+
+func TestAtualizarProdutoInexistente(t *testing.T) {
+	novoTeste(t)
+	req := models.ProdutoRequest{Nome: "Fantasma", Quantidade: 1, Preco: 10}
+	w := requisitar(t, AtualizarProduto, http.MethodPut, "/api/produtos/:id", "/api/produtos/99", req, adminTeste)
+	esperarStatus(t, w, http.StatusNotFound)
+}
+
+func TestObterProdutoInexistente(t *testing.T) {
+	novoTeste(t)
+	w := requisitar(t, ObterProduto, http.MethodGet, "/api/produtos/:id", "/api/produtos/99", nil, "")
+	esperarStatus(t, w, http.StatusNotFound)
+}
+
+func TestDeletarProdutoInexistente(t *testing.T) {
+	novoTeste(t)
+	w := requisitar(t, DeletarProduto, http.MethodDelete, "/api/produtos/:id", "/api/produtos/99", nil, adminTeste)
+	esperarStatus(t, w, http.StatusNotFound)
+}
+
+func TestDeletarProdutoEmPedido(t *testing.T) {
+	r := novoTeste(t)
+	p := criarProdutoTeste(t, r, "Fonte 650W", 500, 3)
+	cotacao := criarCotacaoTeste(t, r, "cot-1", "1:1", 20, time.Now().Add(time.Hour))
+	esperarStatus(t, criarPedido(t, pedidoTeste(p.ID, 1, 500, cotacao)), http.StatusCreated)
+
+	w := requisitar(t, DeletarProduto, http.MethodDelete, "/api/produtos/:id", "/api/produtos/1", nil, adminTeste)
+	esperarStatus(t, w, http.StatusConflict)
+	if _, err := r.Produtos.Obter(p.ID); err != nil {
+		t.Errorf("produto removido apesar do pedido: %v", err)
+	}
+}
+
+func TestIDInvalido(t *testing.T) {
+	novoTeste(t)
+	w := requisitar(t, ObterProduto, http.MethodGet, "/api/produtos/:id", "/api/produtos/abc", nil, "")
+	esperarStatus(t, w, http.StatusBadRequest)
+}
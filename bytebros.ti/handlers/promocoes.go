@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const intervaloPromocoesPadrao = time.Minute
+
+// IniciarAgendadorPromocoes liga e desliga, a cada PROMOCOES_INTERVALO
+// (padrão 1m), a oferta dos produtos e serviços conforme a vigência das
+// promoções, até ctx ser cancelado.
+func IniciarAgendadorPromocoes(ctx context.Context) {
+	iniciarTarefaPeriodica(ctx, "PROMOCOES_INTERVALO", intervaloPromocoesPadrao, passadaPromocoes)
+}
+
+// passadaPromocoes é uma rodada do agendador.
+func passadaPromocoes(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	ativadas, encerradas, err := repos.Precos.SincronizarOfertas()
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao sincronizar ofertas: %v", err)
+		return
+	}
+	if ativadas > 0 || encerradas > 0 {
+		log.Printf("Agendador de promoções: %d oferta(s) ativada(s), %d encerrada(s)", ativadas, encerradas)
+	}
+}
+
+// aplicarPromocoes preenche o "de/por" dos produtos em promoção.
+func aplicarPromocoes(produtos []*models.Produto) {
+	agora := time.Now()
+	for _, p := range produtos {
+		p.AplicarPromocao(agora)
+	}
+}
+
+// promocaoDaRequisicao devolve a promoção enviada, ou nil para {} (remover).
+func promocaoDaRequisicao(p *models.Promocao) *models.Promocao {
+	if p == nil || p.Preco == 0 {
+		return nil
+	}
+	return p
+}
+
+// validarPromocao devolve a mensagem de erro, ou "" se a promoção (ou a
+// falta dela) for válida para o preço.
+func validarPromocao(preco float64, p *models.Promocao) string {
+	if p == nil {
+		return ""
+	}
+	if paraCentavos(p.Preco) >= paraCentavos(preco) {
+		return "O preço promocional deve ser menor que o preço normal"
+	}
+	if p.Inicio != nil && p.Fim != nil && !p.Fim.After(*p.Inicio) {
+		return "O fim da promoção deve ser depois do início"
+	}
+	return ""
+}
+
+// ofertaVigente é a oferta conforme a promoção; sem promoção, vale a
+// marcação manual.
+func ofertaVigente(oferta bool, p *models.Promocao) bool {
+	if p == nil {
+		return oferta
+	}
+	return p.Ativa(time.Now())
+}
+
+// precoMudou informa se o preço ou a promoção mudaram e o histórico de
+// preços precisa de uma nova linha.
+func precoMudou(precoAntes float64, antes *models.Promocao, precoDepois float64, depois *models.Promocao) bool {
+	if paraCentavos(precoAntes) != paraCentavos(precoDepois) || (antes == nil) != (depois == nil) {
+		return true
+	}
+	if antes == nil {
+		return false
+	}
+	return paraCentavos(antes.Preco) != paraCentavos(depois.Preco) ||
+		!mesmaData(antes.Inicio, depois.Inicio) || !mesmaData(antes.Fim, depois.Fim)
+}
+
+func mesmaData(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func registrarPrecoProduto(tx *repository.Repositorios, p models.Produto, usuario string) error {
+	return tx.Precos.Registrar(&models.HistoricoPreco{ProdutoID: &p.ID, Preco: p.Preco, Promocao: p.Promocao, AlteradoPor: usuario})
+}
+
+func registrarPrecoServico(tx *repository.Repositorios, s models.Servico, usuario string) error {
+	return tx.Precos.Registrar(&models.HistoricoPreco{ServicoID: &s.ID, Preco: s.Preco, Promocao: s.Promocao, AlteradoPor: usuario})
+}
+
+// ListarHistoricoPrecosProduto lista as alterações de preço e promoção do
+// produto, da mais recente para a mais antiga.
+func ListarHistoricoPrecosProduto(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := repos.Produtos.Obter(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produto", "detalhes": err.Error()})
+		}
+		return
+	}
+	listarHistoricoPrecos(c, repository.FiltroPrecos{ProdutoID: id})
+}
+
+// ListarHistoricoPrecosServico é o equivalente para serviços.
+func ListarHistoricoPrecosServico(c *gin.Context) {
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := repos.Servicos.Obter(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Serviço não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar serviço", "detalhes": err.Error()})
+		}
+		return
+	}
+	listarHistoricoPrecos(c, repository.FiltroPrecos{ServicoID: id})
+}
+
+func listarHistoricoPrecos(c *gin.Context, filtro repository.FiltroPrecos) {
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoPrecos)
+	if !ok {
+		return
+	}
+	historico, total, err := repos.Precos.Listar(filtro, pagina)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar histórico de preços", "detalhes": err.Error()})
+		return
+	}
+	responderPagina(c, pagina, historico, total)
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+)
+
+func TestValidarPromocao(t *testing.T) {
+	inicio := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	fim := inicio.Add(72 * time.Hour)
+	casos := []struct {
+		nome string
+		p    *models.Promocao
+		erro string
+	}{
+		{"sem promoção", nil, ""},
+		{"menor que o preço", &models.Promocao{Preco: 99.99}, ""},
+		{"igual ao preço", &models.Promocao{Preco: 100}, "O preço promocional deve ser menor que o preço normal"},
+		{"igual em centavos", &models.Promocao{Preco: 100.001}, "O preço promocional deve ser menor que o preço normal"},
+		{"maior que o preço", &models.Promocao{Preco: 120}, "O preço promocional deve ser menor que o preço normal"},
+		{"janela válida", &models.Promocao{Preco: 80, Inicio: &inicio, Fim: &fim}, ""},
+		{"fim no início", &models.Promocao{Preco: 80, Inicio: &inicio, Fim: &inicio}, "O fim da promoção deve ser depois do início"},
+		{"fim antes do início", &models.Promocao{Preco: 80, Inicio: &fim, Fim: &inicio}, "O fim da promoção deve ser depois do início"},
+		{"só fim", &models.Promocao{Preco: 80, Fim: &inicio}, ""},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if erro := validarPromocao(100, caso.p); erro != caso.erro {
+				t.Errorf("validarPromocao = %q, esperado %q", erro, caso.erro)
+			}
+		})
+	}
+}
+
+func TestPrecoMudou(t *testing.T) {
+	inicio := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	mesmoInicio := inicio.In(time.FixedZone("BRT", -3*3600))
+	fim := inicio.Add(72 * time.Hour)
+	promo := &models.Promocao{Preco: 80, Inicio: &inicio, Fim: &fim}
+
+	casos := []struct {
+		nome        string
+		precoAntes  float64
+		antes       *models.Promocao
+		precoDepois float64
+		depois      *models.Promocao
+		mudou       bool
+	}{
+		{"nada mudou", 100, nil, 100, nil, false},
+		{"diferença abaixo do centavo", 100, nil, 100.001, nil, false},
+		{"preço", 100, nil, 110, nil, true},
+		{"promoção criada", 100, nil, 100, promo, true},
+		{"promoção removida", 100, promo, 100, nil, true},
+		{"mesma promoção", 100, promo, 100, &models.Promocao{Preco: 80, Inicio: &inicio, Fim: &fim}, false},
+		{"mesmo instante em outro fuso", 100, promo, 100, &models.Promocao{Preco: 80, Inicio: &mesmoInicio, Fim: &fim}, false},
+		{"preço promocional", 100, promo, 100, &models.Promocao{Preco: 75, Inicio: &inicio, Fim: &fim}, true},
+		{"início", 100, promo, 100, &models.Promocao{Preco: 80, Fim: &fim}, true},
+		{"fim", 100, promo, 100, &models.Promocao{Preco: 80, Inicio: &inicio, Fim: &inicio}, true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if mudou := precoMudou(caso.precoAntes, caso.antes, caso.precoDepois, caso.depois); mudou != caso.mudou {
+				t.Errorf("precoMudou = %v, esperado %v", mudou, caso.mudou)
+			}
+		})
+	}
+}
+
+func historicoPrecos(t *testing.T, produtoID int) []models.HistoricoPreco {
+	t.Helper()
+	w := requisitar(t, ListarHistoricoPrecosProduto, http.MethodGet, "/api/admin/produtos/:id/precos",
+		fmt.Sprintf("/api/admin/produtos/%d/precos?sort=id&order=asc", produtoID), nil, adminTeste)
+	esperarStatus(t, w, http.StatusOK)
+	var historico []models.HistoricoPreco
+	lerJSON(t, w, &historico)
+	return historico
+}
+
+func TestHistoricoPrecosProduto(t *testing.T) {
+	r := novoTeste(t)
+	inicio := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	fim := inicio.Add(48 * time.Hour)
+
+	req := models.ProdutoRequest{Nome: "Water cooler", Quantidade: 2, Preco: 500, Promocao: &models.Promocao{Preco: 450, Inicio: &inicio, Fim: &fim}}
+	w := requisitar(t, CriarProduto, http.MethodPost, "/api/produtos", "/api/produtos", req, adminTeste)
+	esperarStatus(t, w, http.StatusCreated)
+	produtos, _, err := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{})
+	if err != nil || len(produtos) != 1 {
+		t.Fatalf("produtos = %+v (%v), esperado 1", produtos, err)
+	}
+	p := produtos[0]
+	if !p.Oferta {
+		t.Error("produto criado dentro da janela da promoção sem oferta")
+	}
+	caminho := fmt.Sprintf("/api/produtos/%d", p.ID)
+	atualizar := func(req models.ProdutoRequest) {
+		t.Helper()
+		w := requisitar(t, AtualizarProduto, http.MethodPut, "/api/produtos/:id", caminho, req, adminTeste)
+		esperarStatus(t, w, http.StatusOK)
+	}
+
+	// Sem promoção na edição, a atual continua e o histórico não muda.
+	atualizar(models.ProdutoRequest{Nome: "Water cooler 240mm", Quantidade: 2, Preco: 500})
+	// Promoção mais barata: nova linha.
+	atualizar(models.ProdutoRequest{Nome: "Water cooler 240mm", Quantidade: 2, Preco: 500, Promocao: &models.Promocao{Preco: 420, Inicio: &inicio, Fim: &fim}})
+	// {} remove a promoção: nova linha sem promoção.
+	atualizar(models.ProdutoRequest{Nome: "Water cooler 240mm", Quantidade: 2, Preco: 500, Promocao: &models.Promocao{}})
+	// Preço inválido para a promoção não grava nada.
+	w = requisitar(t, AtualizarProduto, http.MethodPut, "/api/produtos/:id", caminho,
+		models.ProdutoRequest{Nome: "Water cooler 240mm", Quantidade: 2, Preco: 500, Promocao: &models.Promocao{Preco: 500}}, adminTeste)
+	esperarStatus(t, w, http.StatusBadRequest)
+
+	historico := historicoPrecos(t, p.ID)
+	esperado := []struct {
+		preco    float64
+		promocao float64
+	}{{500, 450}, {500, 420}, {500, 0}}
+	if len(historico) != len(esperado) {
+		t.Fatalf("histórico = %+v, esperado %d linhas", historico, len(esperado))
+	}
+	for i, h := range historico {
+		var promocao float64
+		if h.Promocao != nil {
+			promocao = h.Promocao.Preco
+		}
+		if h.Preco != esperado[i].preco || promocao != esperado[i].promocao || h.AlteradoPor != adminTeste {
+			t.Errorf("linha %d = %+v, esperado preço %.2f e promoção %.2f por %s", i, h, esperado[i].preco, esperado[i].promocao, adminTeste)
+		}
+	}
+	if h := historico[0]; h.Promocao == nil || !mesmaData(h.Promocao.Inicio, &inicio) || !mesmaData(h.Promocao.Fim, &fim) {
+		t.Errorf("janela gravada = %+v, esperado %s a %s", h.Promocao, inicio, fim)
+	}
+	if atual, err := r.Produtos.Obter(p.ID); err != nil || atual.Promocao != nil || atual.Oferta {
+		t.Errorf("produto = %+v (%v), esperado sem promoção e fora de oferta", atual, err)
+	}
+}
+
+func TestPassadaPromocoes(t *testing.T) {
+	r := novoTeste(t)
+	agora := time.Now()
+	comecou, acabou := agora.Add(-time.Hour), agora.Add(-time.Minute)
+	vaiComecar := agora.Add(time.Hour)
+
+	produtos := []struct {
+		nome     string
+		promocao *models.Promocao
+		oferta   bool
+		depois   bool
+	}{
+		{"começou", &models.Promocao{Preco: 10, Inicio: &comecou}, false, true},
+		{"acabou", &models.Promocao{Preco: 10, Inicio: &comecou, Fim: &acabou}, true, false},
+		{"vai começar", &models.Promocao{Preco: 10, Inicio: &vaiComecar}, false, false},
+		{"em vigor", &models.Promocao{Preco: 10}, true, true},
+		// Sem promoção, a oferta é manual e o agendador não mexe.
+		{"manual", nil, true, true},
+	}
+	ids := make([]int, len(produtos))
+	for i, caso := range produtos {
+		p := models.Produto{Nome: caso.nome, Preco: 20, Oferta: caso.oferta, Promocao: caso.promocao}
+		if err := r.Produtos.Criar(&p); err != nil {
+			t.Fatalf("criar produto: %v", err)
+		}
+		ids[i] = p.ID
+	}
+	s := models.Servico{Nome: "Limpeza", Preco: 150, Promocao: &models.Promocao{Preco: 120, Inicio: &comecou}}
+	if err := r.Servicos.Criar(&s); err != nil {
+		t.Fatalf("criar serviço: %v", err)
+	}
+
+	passadaPromocoes(context.Background())
+	for i, caso := range produtos {
+		p, err := r.Produtos.Obter(ids[i])
+		if err != nil {
+			t.Fatalf("obter produto: %v", err)
+		}
+		if p.Oferta != caso.depois {
+			t.Errorf("%s: oferta = %v, esperado %v", caso.nome, p.Oferta, caso.depois)
+		}
+	}
+	if servico, err := r.Servicos.Obter(s.ID); err != nil || !servico.Oferta {
+		t.Errorf("serviço = %+v (%v), esperado em oferta", servico, err)
+	}
+
+	// A segunda passada não encontra nada a mudar.
+	if ativadas, encerradas, err := r.Precos.SincronizarOfertas(); err != nil || ativadas != 0 || encerradas != 0 {
+		t.Errorf("nova sincronização = %d ativadas e %d encerradas (%v), esperado nenhuma", ativadas, encerradas, err)
+	}
+}
+
+// O pedido é cobrado pelo preço vigente no momento da compra.
+func TestCriarPedidoPrecoPromocional(t *testing.T) {
+	agora := time.Now()
+	passado, futuro := agora.Add(-time.Hour), agora.Add(time.Hour)
+	casos := []struct {
+		nome     string
+		promocao *models.Promocao
+		cobrado  float64
+	}{
+		{"dentro da janela", &models.Promocao{Preco: 249.9, Inicio: &passado, Fim: &futuro}, 249.9},
+		{"antes do início", &models.Promocao{Preco: 249.9, Inicio: &futuro}, 300},
+		{"depois do fim", &models.Promocao{Preco: 249.9, Fim: &passado}, 300},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			r := novoTeste(t)
+			p := models.Produto{Nome: "Placa de vídeo", Preco: 300, Quantidade: 3, Promocao: caso.promocao}
+			if err := r.Produtos.Criar(&p); err != nil {
+				t.Fatalf("criar produto: %v", err)
+			}
+			cotacao := criarCotacaoTeste(t, r, "cot-promo", fmt.Sprintf("%d:1", p.ID), 20, time.Now().Add(time.Hour))
+
+			// Quem envia o outro preço recebe a divergência com o vigente.
+			outro := 300.0
+			if caso.cobrado == 300 {
+				outro = 249.9
+			}
+			w := criarPedido(t, pedidoTeste(p.ID, 1, outro, cotacao))
+			esperarStatus(t, w, http.StatusUnprocessableEntity)
+			var divergencia struct {
+				Divergencias []models.DivergenciaPedido `json:"divergencias"`
+			}
+			lerJSON(t, w, &divergencia)
+			if len(divergencia.Divergencias) == 0 || divergencia.Divergencias[0].Campo != "valor_unitario" || divergencia.Divergencias[0].Esperado != caso.cobrado {
+				t.Errorf("divergências = %+v, esperado valor_unitario %.2f", divergencia.Divergencias, caso.cobrado)
+			}
+
+			w = criarPedido(t, pedidoTeste(p.ID, 1, caso.cobrado, cotacao))
+			esperarStatus(t, w, http.StatusCreated)
+			var criado struct {
+				PedidoID   int     `json:"pedido_id"`
+				ValorTotal float64 `json:"valor_total"`
+			}
+			lerJSON(t, w, &criado)
+			if criado.ValorTotal != caso.cobrado+20 {
+				t.Errorf("valor_total = %.2f, esperado %.2f", criado.ValorTotal, caso.cobrado+20)
+			}
+			itens, err := r.Pedidos.Itens(criado.PedidoID)
+			if err != nil || len(itens) != 1 || itens[0].ValorUnitario != caso.cobrado {
+				t.Errorf("itens = %+v (%v), esperado 1 a %.2f", itens, err, caso.cobrado)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/mailer"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const resetSenhaTTL = time.Hour
+
+var mailSender mailer.Sender
+
+func InitializeMailer() {
+	sender, err := mailer.NovoDoAmbiente()
+	if err != nil {
+		log.Fatalf("Erro ao configurar envio de emails: %v", err)
+	}
+	mailSender = sender
+}
+
+// urlFrontend é a base dos links enviados por email.
+func urlFrontend() string {
+	if url := os.Getenv("FRONTEND_URL"); url != "" {
+		return url
+	}
+	return "https://bytebros.netlify.app"
+}
+
+func EsqueciSenha(c *gin.Context) {
+	var req models.EsqueciSenhaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	// A resposta é a mesma exista ou não a conta, para não revelar quais
+	// emails estão cadastrados.
+	resposta := gin.H{"mensagem": "Se o email estiver cadastrado, você receberá as instruções para redefinir a senha."}
+
+	usuario, err := repos.Usuarios.ObterPorEmail(req.Email)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		c.JSON(http.StatusOK, resposta)
+		return
+	}
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao buscar usuário para redefinição de senha: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao solicitar redefinição de senha"})
+		return
+	}
+
+	token, err := tokenAleatorio(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao solicitar redefinição de senha"})
+		return
+	}
+
+	// Só o link mais recente vale; Criar invalida os anteriores.
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		return tx.Redefinicoes.Criar(&models.RedefinicaoSenha{
+			UsuarioID: usuario.ID,
+			TokenHash: hashToken(token),
+			ExpiraEm:  time.Now().Add(resetSenhaTTL),
+		})
+	})
+	if err != nil {
+		log.Printf("ERRO BD: Falha ao gravar token de redefinição: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao solicitar redefinição de senha"})
+		return
+	}
+
+	err = mailSender.Enviar(mailer.Mensagem{
+		Para:    req.Email,
+		Assunto: "Byte Bros.TI - Redefinição de senha",
+		Corpo: fmt.Sprintf(`Olá, %s!
+
+Recebemos um pedido para redefinir a senha da sua conta.
+Para criar uma nova senha, acesse o link abaixo (válido por %d minutos):
+
+%s/redefinir-senha.html?token=%s
+
+Se você não fez este pedido, ignore este email. Sua senha atual continua válida.
+
+Equipe Byte Bros.TI`, usuario.Nome, int(resetSenhaTTL.Minutes()), urlFrontend(), token),
+	})
+	// Falha no envio só vai para o log: um erro aqui, e não para emails
+	// desconhecidos, revelaria quais contas existem.
+	if err != nil {
+		log.Printf("ERRO: Falha ao enviar email de redefinição para %s: %v", req.Email, err)
+	}
+
+	c.JSON(http.StatusOK, resposta)
+}
+
+func RedefinirSenha(c *gin.Context) {
+	var req models.RedefinirSenhaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	if req.NovaSenha != req.ConfirmarSenha {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "A nova senha e a confirmação não coincidem."})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NovaSenha), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao criptografar senha"})
+		return
+	}
+
+	var usuarioID int
+	err = repos.Transacao(func(tx *repository.Repositorios) error {
+		redefinicao, err := tx.Redefinicoes.Bloquear(hashToken(req.Token))
+		if err != nil && !errors.Is(err, repository.ErrNaoEncontrado) {
+			log.Printf("ERRO BD: Falha ao buscar token de redefinição: %v", err)
+			return err
+		}
+		if err != nil || redefinicao.UsadoEm != nil || time.Now().After(redefinicao.ExpiraEm) {
+			return abortar(http.StatusBadRequest, gin.H{"erro": "Link de redefinição inválido ou expirado"})
+		}
+		usuarioID = redefinicao.UsuarioID
+
+		if err := tx.Usuarios.AtualizarSenha(usuarioID, string(hashedPassword)); err != nil {
+			log.Printf("ERRO BD: Falha ao atualizar senha do usuário %d: %v", usuarioID, err)
+			return err
+		}
+		return tx.Redefinicoes.MarcarUsada(redefinicao.ID)
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao redefinir senha")
+		return
+	}
+
+	// Sessões abertas com a senha antiga são encerradas.
+	if err := revogarSessoes(auth.TipoUsuario, usuarioID); err != nil {
+		log.Printf("ERRO: Falha ao revogar sessões do usuário %d: %v", usuarioID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Senha redefinida com sucesso! Faça login com a nova senha."})
+}
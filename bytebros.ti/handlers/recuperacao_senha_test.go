@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"bytebros.ti/mailer"
+	"bytebros.ti/models"
+)
+
+// senderFalho recusa toda mensagem, como um SMTP fora do ar.
+type senderFalho struct{}
+
+func (senderFalho) Enviar(mailer.Mensagem) error {
+	return errors.New("servidor SMTP indisponível")
+}
+
+func esqueciSenha(t *testing.T, email string) string {
+	t.Helper()
+	w := requisitar(t, EsqueciSenha, http.MethodPost, "/auth/esqueci-senha", "/auth/esqueci-senha",
+		models.EsqueciSenhaRequest{Email: email}, "")
+	esperarStatus(t, w, http.StatusOK)
+	return w.Body.String()
+}
+
+func TestEsqueciSenhaNaoRevelaContas(t *testing.T) {
+	r := novoTeste(t)
+	anterior := mailSender
+	t.Cleanup(func() { mailSender = anterior })
+	if err := r.Usuarios.Criar(&models.Usuario{Nome: "Ana", Email: clienteTeste, SenhaHash: "x"}); err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+
+	arquivo := &mailer.ArquivoSender{}
+	mailSender = arquivo
+	desconhecido := esqueciSenha(t, "ninguem@example.com")
+	if enviado := esqueciSenha(t, clienteTeste); enviado != desconhecido {
+		t.Errorf("resposta para conta existente = %s, esperado %s", enviado, desconhecido)
+	}
+	enviadas := arquivo.Enviadas()
+	if len(enviadas) != 1 || enviadas[0].Para != clienteTeste || !strings.Contains(enviadas[0].Corpo, "/redefinir-senha.html?token=") {
+		t.Errorf("mensagens = %+v, esperado o link de redefinição para %s", enviadas, clienteTeste)
+	}
+
+	// Com o envio falhando, a resposta continua a mesma.
+	mailSender = senderFalho{}
+	if falhou := esqueciSenha(t, clienteTeste); falhou != desconhecido {
+		t.Errorf("resposta com falha no envio = %s, esperado %s", falhou, desconhecido)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// repos dá acesso aos dados. main injeta a implementação PostgreSQL; testes
+// podem usar repository.NovoMemoria().
+var repos *repository.Repositorios
+
+func InitializeRepositorios(r *repository.Repositorios) {
+	repos = r
+}
+
+// idDoParametro lê o parâmetro de rota numérico. Em caso de erro já
+// responde 400 e devolve false.
+func idDoParametro(c *gin.Context, nome string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(nome))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID inválido"})
+		return 0, false
+	}
+	return id, true
+}
+
+// emailDaRequisicao devolve o email do usuário autenticado, ou "" em rotas
+// sem AuthMiddleware.
+func emailDaRequisicao(c *gin.Context) string {
+	email, _ := c.Get("email")
+	emailStr, _ := email.(string)
+	return emailStr
+}
+
+// respostaErro interrompe uma transação levando a resposta que o handler
+// deve dar; a transação é desfeita normalmente.
+type respostaErro struct {
+	status int
+	corpo  gin.H
+}
+
+func (e *respostaErro) Error() string {
+	return fmt.Sprint(e.corpo["erro"])
+}
+
+func abortar(status int, corpo gin.H) error {
+	return &respostaErro{status: status, corpo: corpo}
+}
+
+// responderErro envia a resposta carregada por abortar ou, para qualquer
+// outro erro, um 500 com a mensagem informada.
+func responderErro(c *gin.Context, err error, mensagem string) {
+	var resposta *respostaErro
+	if errors.As(err, &resposta) {
+		c.JSON(resposta.status, resposta.corpo)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem, "detalhes": err.Error()})
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
+	"time"
 
 	"bytebros.ti/models"
+	"bytebros.ti/repository"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,74 +19,61 @@ func CriarServico(c *gin.Context) {
 		return
 	}
 
-	db := c.MustGet("db").(*sql.DB)
-
-	var servico models.Servico
-	err := db.QueryRow(`
-        INSERT INTO servicos (nome, preco, oferta, detalhes)
-        VALUES ($1, $2, $3, $4)
-        RETURNING id`,
-		servicoReq.Nome, servicoReq.Preco, servicoReq.Oferta, servicoReq.Detalhes).
-		Scan(&servico.ID)
-
+	servico := models.Servico{
+		Nome:     servicoReq.Nome,
+		Preco:    servicoReq.Preco,
+		Oferta:   servicoReq.Oferta,
+		Detalhes: servicoReq.Detalhes,
+		Promocao: promocaoDaRequisicao(servicoReq.Promocao),
+	}
+	if msg := validarPromocao(servico.Preco, servico.Promocao); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": msg})
+		return
+	}
+	servico.Oferta = ofertaVigente(servico.Oferta, servico.Promocao)
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		if err := tx.Servicos.Criar(&servico); err != nil {
+			return err
+		}
+		return registrarPrecoServico(tx, servico, emailDaRequisicao(c))
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao criar serviço"})
 		return
 	}
 
-	servico.Nome = servicoReq.Nome
-	servico.Preco = servicoReq.Preco
-	servico.Oferta = servicoReq.Oferta
-	servico.Detalhes = servicoReq.Detalhes
-
 	c.JSON(http.StatusCreated, servico)
 }
 
 func ListarServicos(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	somenteOfertas := c.Query("ofertas") == "true"
-
-	var query string
-	if somenteOfertas {
-		query = `SELECT id, nome, preco, oferta, detalhes FROM servicos WHERE oferta = true ORDER BY nome`
-	} else {
-		query = `SELECT id, nome, preco, oferta, detalhes FROM servicos ORDER BY nome`
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoServicos)
+	if !ok {
+		return
 	}
 
-	rows, err := db.Query(query)
+	filtro := repository.FiltroServicos{SomenteOfertas: c.Query("ofertas") == "true"}
+	servicos, total, err := repos.Servicos.Listar(filtro, pagina)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar serviços"})
 		return
 	}
-	defer rows.Close()
-
-	var servicos []models.Servico
-	for rows.Next() {
-		var s models.Servico
-		if err := rows.Scan(&s.ID, &s.Nome, &s.Preco, &s.Oferta, &s.Detalhes); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler serviços"})
-			return
-		}
-		servicos = append(servicos, s)
+	agora := time.Now()
+	for i := range servicos {
+		servicos[i].AplicarPromocao(agora)
 	}
 
-	c.JSON(http.StatusOK, servicos)
+	responderPagina(c, pagina, servicos, total)
 }
 
 func ObterServico(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	id := c.Param("id")
-
-	var servico models.Servico
-	err := db.QueryRow(`
-        SELECT id, nome, preco, oferta, detalhes
-        FROM servicos
-        WHERE id = $1`, id).
-		Scan(&servico.ID, &servico.Nome, &servico.Preco, &servico.Oferta, &servico.Detalhes)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
+	servico, err := repos.Servicos.Obter(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
 			c.JSON(http.StatusNotFound, gin.H{"erro": "Serviço não encontrado"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar serviço"})
@@ -92,11 +81,15 @@ func ObterServico(c *gin.Context) {
 		return
 	}
 
+	servico.AplicarPromocao(time.Now())
 	c.JSON(http.StatusOK, servico)
 }
 
 func AtualizarServico(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 	var servicoReq models.ServicoRequest
 
 	if err := c.ShouldBindJSON(&servicoReq); err != nil {
@@ -104,16 +97,39 @@ func AtualizarServico(c *gin.Context) {
 		return
 	}
 
-	db := c.MustGet("db").(*sql.DB)
-
-	_, err := db.Exec(`
-        UPDATE servicos
-        SET nome = $1, preco = $2, oferta = $3, detalhes = $4
-        WHERE id = $5`,
-		servicoReq.Nome, servicoReq.Preco, servicoReq.Oferta, servicoReq.Detalhes, id)
-
+	servico := models.Servico{
+		ID:       id,
+		Nome:     servicoReq.Nome,
+		Preco:    servicoReq.Preco,
+		Oferta:   servicoReq.Oferta,
+		Detalhes: servicoReq.Detalhes,
+		Promocao: promocaoDaRequisicao(servicoReq.Promocao),
+	}
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		atual, err := tx.Servicos.Obter(id)
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			return abortar(http.StatusNotFound, gin.H{"erro": "Serviço não encontrado"})
+		}
+		if err != nil {
+			return err
+		}
+		if servicoReq.Promocao == nil {
+			servico.Promocao = atual.Promocao
+		}
+		if msg := validarPromocao(servico.Preco, servico.Promocao); msg != "" {
+			return abortar(http.StatusBadRequest, gin.H{"erro": msg})
+		}
+		servico.Oferta = ofertaVigente(servico.Oferta, servico.Promocao)
+		if err := tx.Servicos.Atualizar(servico); err != nil {
+			return err
+		}
+		if !precoMudou(atual.Preco, atual.Promocao, servico.Preco, servico.Promocao) {
+			return nil
+		}
+		return registrarPrecoServico(tx, servico, emailDaRequisicao(c))
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar serviço"})
+		responderErro(c, err, "Erro ao atualizar serviço")
 		return
 	}
 
@@ -121,11 +137,16 @@ func AtualizarServico(c *gin.Context) {
 }
 
 func DeletarServico(c *gin.Context) {
-	id := c.Param("id")
-	db := c.MustGet("db").(*sql.DB)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
-	_, err := db.Exec("DELETE FROM servicos WHERE id = $1", id)
-	if err != nil {
+	if err := repos.Servicos.Deletar(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Serviço não encontrado"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao deletar serviço"})
 		return
 	}
@@ -1,21 +1,18 @@
 package handlers
 
 import (
-	"database/sql"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 
 	"bytebros.ti/models"
+	"bytebros.ti/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
 func CriarMensagemSuporte(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
 	var suporteReq models.SuporteRequest
 
 	if err := c.ShouldBindJSON(&suporteReq); err != nil {
@@ -33,90 +30,42 @@ func CriarMensagemSuporte(c *gin.Context) {
 		suporteReq.TipoInteracao = "suporte"
 	}
 
-	var suporte models.Suporte
-	err := db.QueryRow(`
-        INSERT INTO suporte (nome, email, mensagem, status, tipo_interacao, cliente_email)
-        VALUES ($1, $2, $3, 'aberto', $4, $5)
-        RETURNING id, criado_em`,
-		suporteReq.Nome, suporteReq.Email, suporteReq.Mensagem, suporteReq.TipoInteracao, sql.NullString{String: clienteEmailStr, Valid: clienteEmailStr != ""}).
-		Scan(&suporte.ID, &suporte.CriadoEm)
-
-	if err != nil {
+	suporte := models.Suporte{
+		Nome:          suporteReq.Nome,
+		Email:         suporteReq.Email,
+		Mensagem:      suporteReq.Mensagem,
+		Status:        "aberto",
+		TipoInteracao: suporteReq.TipoInteracao,
+		ClienteEmail:  clienteEmailStr,
+	}
+	if err := repos.Suporte.Criar(&suporte); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao registrar mensagem de suporte", "detalhes": err.Error()})
 		return
 	}
 
-	suporte.Nome = suporteReq.Nome
-	suporte.Email = suporteReq.Email
-	suporte.Mensagem = suporteReq.Mensagem
-	suporte.Status = "aberto"
-	suporte.TipoInteracao = suporteReq.TipoInteracao
-	suporte.ClienteEmail = clienteEmailStr
-
 	c.JSON(http.StatusCreated, suporte)
 }
 
 func ListarMensagensSuporte(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
-	status := c.Query("status")
-	tipoInteracao := c.Query("tipo_interacao")
-	clienteEmailFilter := c.Query("cliente_email")
-
-	var query string
-	var args []interface{}
-	argCounter := 1
-
-	query = `SELECT id, nome, email, mensagem, status, tipo_interacao, cliente_email, criado_em FROM suporte `
-	whereClauses := []string{}
-
-	if status != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", argCounter))
-		args = append(args, status)
-		argCounter++
-	}
-	if tipoInteracao != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("tipo_interacao = $%d", argCounter))
-		args = append(args, tipoInteracao)
-		argCounter++
-	}
-	if clienteEmailFilter != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("cliente_email = $%d", argCounter))
-		args = append(args, clienteEmailFilter)
-		argCounter++
-	}
-
-	if len(whereClauses) > 0 {
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
+	pagina, ok := paginaDaRequisicao(c, repository.OrdenacaoSuporte)
+	if !ok {
+		return
 	}
 
-	query += " ORDER BY criado_em DESC"
-
-	rows, err := db.Query(query, args...)
+	mensagens, total, err := repos.Suporte.Listar(repository.FiltroSuporte{
+		Status:        c.Query("status"),
+		TipoInteracao: c.Query("tipo_interacao"),
+		ClienteEmail:  c.Query("cliente_email"),
+	}, pagina)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar mensagens de suporte", "detalhes": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var mensagens []models.Suporte
-	for rows.Next() {
-		var s models.Suporte
-		var clienteEmailSQL sql.NullString
-		if err := rows.Scan(&s.ID, &s.Nome, &s.Email, &s.Mensagem, &s.Status, &s.TipoInteracao, &clienteEmailSQL, &s.CriadoEm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler mensagens de suporte", "detalhes": err.Error()})
-			return
-		}
-		s.ClienteEmail = clienteEmailSQL.String
-		mensagens = append(mensagens, s)
-	}
-
-	c.JSON(http.StatusOK, mensagens)
+	responderPagina(c, pagina, mensagens, total)
 }
 
 func ListarInteracoesCliente(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-
 	clienteEmail, exists := c.Get("email")
 	if !exists || clienteEmail == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Email do usuário não encontrado no token"})
@@ -127,49 +76,22 @@ func ListarInteracoesCliente(c *gin.Context) {
 	var interacoes []interface{}
 	interacoes = make([]interface{}, 0)
 
-	suporteQuery := `
-        SELECT id, nome, email, mensagem, status, tipo_interacao, cliente_email, criado_em
-        FROM suporte
-        WHERE cliente_email = $1
-        ORDER BY criado_em DESC`
-
-	suporteRows, err := db.Query(suporteQuery, clienteEmailStr)
+	// As interações juntam duas fontes e por isso não são paginadas.
+	mensagens, _, err := repos.Suporte.Listar(repository.FiltroSuporte{ClienteEmail: clienteEmailStr}, repository.Pagina{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar interações de suporte/contato", "detalhes": err.Error()})
 		return
 	}
-	defer suporteRows.Close()
-
-	for suporteRows.Next() {
-		var s models.Suporte
-		var clienteEmailSQL sql.NullString
-		if err := suporteRows.Scan(&s.ID, &s.Nome, &s.Email, &s.Mensagem, &s.Status, &s.TipoInteracao, &clienteEmailSQL, &s.CriadoEm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler interações de suporte/contato", "detalhes": err.Error()})
-			return
-		}
-		s.ClienteEmail = clienteEmailSQL.String
+	for _, s := range mensagens {
 		interacoes = append(interacoes, s)
 	}
 
-	orcamentoQuery := `
-        SELECT id, nome_cliente, email_cliente, telefone, descricao, servico_nome, status, criado_em, atualizado_em
-        FROM orcamentos
-        WHERE email_cliente = $1
-        ORDER BY criado_em DESC`
-
-	orcamentoRows, err := db.Query(orcamentoQuery, clienteEmailStr)
+	orcamentos, _, err := repos.Orcamentos.Listar(repository.FiltroOrcamentos{Email: clienteEmailStr}, repository.Pagina{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar orçamentos do cliente", "detalhes": err.Error()})
 		return
 	}
-	defer orcamentoRows.Close()
-
-	for orcamentoRows.Next() {
-		var o models.Orcamento
-		if err := orcamentoRows.Scan(&o.ID, &o.NomeCliente, &o.EmailCliente, &o.Telefone, &o.Descricao, &o.ServicoNome, &o.Status, &o.CriadoEm, &o.AtualizadoEm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao ler dados do orçamento do cliente", "detalhes": err.Error()})
-			return
-		}
+	for _, o := range orcamentos {
 		interacoes = append(interacoes, map[string]interface{}{
 			"id":             o.ID,
 			"nome":           o.NomeCliente,
@@ -188,7 +110,10 @@ func ListarInteracoesCliente(c *gin.Context) {
 }
 
 func AtualizarStatusSuporte(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 	var update models.SuporteUpdate
 
 	if err := c.ShouldBindJSON(&update); err != nil {
@@ -196,15 +121,11 @@ func AtualizarStatusSuporte(c *gin.Context) {
 		return
 	}
 
-	db := c.MustGet("db").(*sql.DB)
-
-	_, err := db.Exec(`
-        UPDATE suporte
-        SET status = $1
-        WHERE id = $2`,
-		update.Status, id)
-
-	if err != nil {
+	if err := repos.Suporte.AtualizarStatus(id, update.Status); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Mensagem de suporte não encontrada"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao atualizar status do suporte", "detalhes": err.Error()})
 		return
 	}
@@ -213,37 +134,36 @@ func AtualizarStatusSuporte(c *gin.Context) {
 }
 
 func ObterMensagemSuporte(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	id := c.Param("id")
-
-	var suporte models.Suporte
-	var clienteEmailSQL sql.NullString
-	err := db.QueryRow(`
-        SELECT id, nome, email, mensagem, status, tipo_interacao, cliente_email, criado_em
-        FROM suporte
-        WHERE id = $1`, id).
-		Scan(&suporte.ID, &suporte.Nome, &suporte.Email, &suporte.Mensagem, &suporte.Status, &suporte.TipoInteracao, &clienteEmailSQL, &suporte.CriadoEm)
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
+	suporte, err := repos.Suporte.Obter(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
 			c.JSON(http.StatusNotFound, gin.H{"erro": "Mensagem de suporte não encontrada"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar mensagem de suporte", "detalhes": err.Error()})
 		}
 		return
 	}
-	suporte.ClienteEmail = clienteEmailSQL.String
 
 	c.JSON(http.StatusOK, suporte)
 }
 
 func DeletarSuporte(c *gin.Context) {
-	db := c.MustGet("db").(*sql.DB)
-	id := c.Param("id")
+	id, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
 
-	_, err := db.Exec("DELETE FROM suporte WHERE id = $1", id)
-	if err != nil {
-		log.Printf("ERRO BD: Falha ao deletar mensagem de suporte ID %s: %v", id, err)
+	if err := repos.Suporte.Deletar(id); err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Mensagem de suporte não encontrada"})
+			return
+		}
+		log.Printf("ERRO BD: Falha ao deletar mensagem de suporte ID %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao deletar mensagem de suporte", "detalhes": err.Error()})
 		return
 	}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// iniciarTarefaPeriodica roda passada agora e depois a cada intervalo lido
+// de variavelAmbiente (uma duração como "5m"; padrao se vazia), até ctx ser
+// cancelado. Um valor inválido encerra o servidor na inicialização.
+func iniciarTarefaPeriodica(ctx context.Context, variavelAmbiente string, padrao time.Duration, passada func(context.Context)) {
+	intervalo := padrao
+	if v := os.Getenv(variavelAmbiente); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("%s inválido: %q", variavelAmbiente, v)
+		}
+		intervalo = d
+	}
+
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+		for {
+			passada(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var errRefreshTokenInvalido = errors.New("refresh token inválido ou expirado")
+
+func jwtSecret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// gerarAccessToken emite o JWT de curta duração. Cada token recebe um jti
+// próprio para poder ser revogado individualmente.
+func gerarAccessToken(p auth.Principal) (string, error) {
+	jti, err := tokenAleatorio(16)
+	if err != nil {
+		return "", err
+	}
+	return auth.Assinar(auth.NovasClaims(p, jti, accessTokenTTL), jwtSecret())
+}
+
+// emitirTokens gera um access token e um refresh token novo, gravando apenas
+// o hash do refresh token no banco.
+func emitirTokens(p auth.Principal) (string, string, error) {
+	accessToken, err := gerarAccessToken(p)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := tokenAleatorio(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = repos.Tokens.SalvarRefresh(&models.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		Tipo:      p.Tipo,
+		SujeitoID: p.ID,
+		ExpiraEm:  time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// carregarPrincipal relê o titular no repositório correspondente, para que
+// um refresh reflita email e papel atuais.
+func carregarPrincipal(tipo string, id int) (auth.Principal, error) {
+	p := auth.Principal{Tipo: tipo, ID: id}
+	switch tipo {
+	case auth.TipoUsuario:
+		u, err := repos.Usuarios.Obter(id)
+		p.Email, p.Papel = u.Email, auth.PapelCliente
+		return p, err
+	case auth.TipoFuncionario:
+		f, err := repos.Funcionarios.Obter(id)
+		p.Email, p.Papel = f.Email, auth.PapelDoCargo(f.Cargo)
+		return p, err
+	case auth.TipoAdmin:
+		a, err := repos.Admins.Obter(id)
+		p.Email, p.Papel = a.Email, auth.PapelDoAdmin(a.IsAdmin)
+		return p, err
+	}
+	return p, repository.ErrNaoEncontrado
+}
+
+// rotacionarRefreshToken troca um refresh token válido por um par novo. Se um
+// token já revogado for reapresentado, todos os tokens do titular são
+// revogados, pois isso indica que ele vazou.
+func rotacionarRefreshToken(refreshToken string) (string, string, error) {
+	var token models.RefreshToken
+	var reutilizado bool
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		var err error
+		token, err = tx.Tokens.BloquearRefresh(hashToken(refreshToken))
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			return errRefreshTokenInvalido
+		}
+		if err != nil {
+			return err
+		}
+
+		if token.RevogadoEm != nil {
+			// A revogação precisa ser gravada, então a transação termina sem erro.
+			reutilizado = true
+			return tx.Tokens.RevogarSessoes(token.Tipo, token.SujeitoID)
+		}
+		if time.Now().After(token.ExpiraEm) {
+			return errRefreshTokenInvalido
+		}
+		return tx.Tokens.RevogarRefresh(token.ID)
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if reutilizado {
+		log.Printf("AVISO: Refresh token revogado reutilizado (%s %d). Todas as sessões foram revogadas.", token.Tipo, token.SujeitoID)
+		return "", "", errRefreshTokenInvalido
+	}
+
+	p, err := carregarPrincipal(token.Tipo, token.SujeitoID)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		return "", "", errRefreshTokenInvalido
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return emitirTokens(p)
+}
+
+// revogarAccessToken coloca o jti na lista de revogação até o token expirar.
+func revogarAccessToken(claims *auth.Claims) error {
+	if err := repos.Tokens.LimparJTIsExpirados(); err != nil {
+		log.Printf("AVISO: Falha ao limpar tokens revogados expirados: %v", err)
+	}
+	return repos.Tokens.RevogarJTI(claims.ID, claims.ExpiresAt.Time)
+}
+
+// revogarSessoes revoga todos os refresh tokens ativos do titular.
+func revogarSessoes(tipo string, id int) error {
+	return repos.Tokens.RevogarSessoes(tipo, id)
+}
+
+// tokenRevogado consulta a lista de revogação pelo jti.
+func tokenRevogado(jti string) (bool, error) {
+	return repos.Tokens.JTIRevogado(jti)
+}
+
+func tokenAleatorio(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	accessToken, refreshToken, err := rotacionarRefreshToken(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, errRefreshTokenInvalido) {
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": "Refresh token inválido ou expirado"})
+			return
+		}
+		log.Printf("ERRO BD: Falha ao renovar token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao renovar token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+func Logout(c *gin.Context) {
+	var req models.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	claims, ok := claimsAtuais(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Usuário não autenticado"})
+		return
+	}
+	p := claims.Principal()
+
+	if err := revogarAccessToken(claims); err != nil {
+		log.Printf("ERRO BD: Falha ao revogar access token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao encerrar sessão"})
+		return
+	}
+
+	if req.Todos {
+		if err := revogarSessoes(p.Tipo, p.ID); err != nil {
+			log.Printf("ERRO BD: Falha ao revogar sessões de %s: %v", p.Sujeito(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao encerrar sessões"})
+			return
+		}
+	} else if req.RefreshToken != "" {
+		if err := repos.Tokens.RevogarRefreshDoTitular(hashToken(req.RefreshToken), p.Tipo, p.ID); err != nil {
+			log.Printf("ERRO BD: Falha ao revogar refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao encerrar sessão"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Sessão encerrada com sucesso"})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bytebros.ti/auth"
+	"bytebros.ti/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roteadorAuth monta as rotas de sessão como em main.go, mais uma rota
+// protegida qualquer.
+func roteadorAuth() *gin.Engine {
+	router := gin.New()
+	router.POST("/auth/refresh", RefreshToken)
+	router.POST("/auth/logout", AuthMiddleware(), Logout)
+	router.GET("/api/meus-pedidos", AuthMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"email": emailDaRequisicao(c)})
+	})
+	return router
+}
+
+func enviarAuth(t *testing.T, router *gin.Engine, metodo, caminho, accessToken string, corpo any) *httptest.ResponseRecorder {
+	t.Helper()
+	var leitor bytes.Buffer
+	if corpo != nil {
+		if err := json.NewEncoder(&leitor).Encode(corpo); err != nil {
+			t.Fatalf("corpo da requisição: %v", err)
+		}
+	}
+	req := httptest.NewRequest(metodo, caminho, &leitor)
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// sessaoTeste cria o cliente e abre uma sessão para ele, como o login.
+func sessaoTeste(t *testing.T) (auth.Principal, models.TokenResponse) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "segredo-de-teste")
+	u := models.Usuario{Nome: "Ana Souza", Email: clienteTeste, SenhaHash: "x"}
+	if err := repos.Usuarios.Criar(&u); err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+	p := auth.Principal{Tipo: auth.TipoUsuario, ID: u.ID, Email: u.Email, Papel: auth.PapelCliente}
+	return p, novaSessao(t, p)
+}
+
+func novaSessao(t *testing.T, p auth.Principal) models.TokenResponse {
+	t.Helper()
+	access, refresh, err := emitirTokens(p)
+	if err != nil {
+		t.Fatalf("emitir tokens: %v", err)
+	}
+	return models.TokenResponse{Token: access, RefreshToken: refresh}
+}
+
+func renovar(t *testing.T, router *gin.Engine, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	return enviarAuth(t, router, http.MethodPost, "/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: refreshToken})
+}
+
+func TestRefreshTokenRotaciona(t *testing.T) {
+	novoTeste(t)
+	router := roteadorAuth()
+	_, sessao := sessaoTeste(t)
+
+	w := renovar(t, router, sessao.RefreshToken)
+	esperarStatus(t, w, http.StatusOK)
+	var nova models.TokenResponse
+	lerJSON(t, w, &nova)
+	if nova.Token == "" || nova.RefreshToken == "" || nova.RefreshToken == sessao.RefreshToken {
+		t.Fatalf("tokens = %+v, esperado um par novo", nova)
+	}
+	w = enviarAuth(t, router, http.MethodGet, "/api/meus-pedidos", nova.Token, nil)
+	esperarStatus(t, w, http.StatusOK)
+	esperarStatus(t, renovar(t, router, nova.RefreshToken), http.StatusOK)
+}
+
+// Reapresentar um refresh token já trocado indica vazamento: todas as
+// sessões do titular caem, inclusive as abertas em outros aparelhos.
+func TestRefreshTokenReutilizadoRevogaSessoes(t *testing.T) {
+	novoTeste(t)
+	router := roteadorAuth()
+	p, sessao := sessaoTeste(t)
+	outroAparelho := novaSessao(t, p)
+
+	w := renovar(t, router, sessao.RefreshToken)
+	esperarStatus(t, w, http.StatusOK)
+	var legitima models.TokenResponse
+	lerJSON(t, w, &legitima)
+
+	esperarStatus(t, renovar(t, router, sessao.RefreshToken), http.StatusUnauthorized)
+	esperarStatus(t, renovar(t, router, legitima.RefreshToken), http.StatusUnauthorized)
+	esperarStatus(t, renovar(t, router, outroAparelho.RefreshToken), http.StatusUnauthorized)
+
+	// Outro titular não é afetado.
+	outro := models.Usuario{Nome: "Bia", Email: "bia@example.com", SenhaHash: "x"}
+	if err := repos.Usuarios.Criar(&outro); err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+	sessaoOutro := novaSessao(t, auth.Principal{Tipo: auth.TipoUsuario, ID: outro.ID, Email: outro.Email, Papel: auth.PapelCliente})
+	esperarStatus(t, renovar(t, router, sessaoOutro.RefreshToken), http.StatusOK)
+}
+
+func TestRefreshTokenInvalido(t *testing.T) {
+	novoTeste(t)
+	router := roteadorAuth()
+	p, _ := sessaoTeste(t)
+
+	vencido := "refresh-vencido"
+	err := repos.Tokens.SalvarRefresh(&models.RefreshToken{
+		TokenHash: hashToken(vencido),
+		Tipo:      p.Tipo,
+		SujeitoID: p.ID,
+		ExpiraEm:  time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("salvar refresh: %v", err)
+	}
+	esperarStatus(t, renovar(t, router, vencido), http.StatusUnauthorized)
+	esperarStatus(t, renovar(t, router, "nunca-emitido"), http.StatusUnauthorized)
+	esperarStatus(t, enviarAuth(t, router, http.MethodPost, "/auth/refresh", "", gin.H{}), http.StatusBadRequest)
+}
+
+func TestLogoutRevogaAccessToken(t *testing.T) {
+	novoTeste(t)
+	router := roteadorAuth()
+	p, sessao := sessaoTeste(t)
+	outroAparelho := novaSessao(t, p)
+
+	esperarStatus(t, enviarAuth(t, router, http.MethodGet, "/api/meus-pedidos", sessao.Token, nil), http.StatusOK)
+	w := enviarAuth(t, router, http.MethodPost, "/auth/logout", sessao.Token, models.LogoutRequest{RefreshToken: sessao.RefreshToken})
+	esperarStatus(t, w, http.StatusOK)
+
+	// O jti do token fica na lista de revogação até ele expirar.
+	w = enviarAuth(t, router, http.MethodGet, "/api/meus-pedidos", sessao.Token, nil)
+	esperarStatus(t, w, http.StatusUnauthorized)
+	var resposta struct {
+		Erro string `json:"erro"`
+	}
+	lerJSON(t, w, &resposta)
+	if resposta.Erro != "Token revogado" {
+		t.Errorf("erro = %q, esperado %q", resposta.Erro, "Token revogado")
+	}
+	esperarStatus(t, enviarAuth(t, router, http.MethodPost, "/auth/logout", sessao.Token, nil), http.StatusUnauthorized)
+
+	// A sessão do outro aparelho continua.
+	esperarStatus(t, enviarAuth(t, router, http.MethodGet, "/api/meus-pedidos", outroAparelho.Token, nil), http.StatusOK)
+	w = renovar(t, router, outroAparelho.RefreshToken)
+	esperarStatus(t, w, http.StatusOK)
+	lerJSON(t, w, &outroAparelho)
+
+	// O refresh token da sessão encerrada está revogado; reapresentá-lo
+	// conta como reutilização e derruba as demais.
+	esperarStatus(t, renovar(t, router, sessao.RefreshToken), http.StatusUnauthorized)
+	esperarStatus(t, renovar(t, router, outroAparelho.RefreshToken), http.StatusUnauthorized)
+}
+
+func TestLogoutTodasAsSessoes(t *testing.T) {
+	novoTeste(t)
+	router := roteadorAuth()
+	p, sessao := sessaoTeste(t)
+	outroAparelho := novaSessao(t, p)
+
+	w := enviarAuth(t, router, http.MethodPost, "/auth/logout", sessao.Token, models.LogoutRequest{Todos: true})
+	esperarStatus(t, w, http.StatusOK)
+	esperarStatus(t, renovar(t, router, sessao.RefreshToken), http.StatusUnauthorized)
+	esperarStatus(t, renovar(t, router, outroAparelho.RefreshToken), http.StatusUnauthorized)
+}
+
+func TestAuthMiddlewareRecusaToken(t *testing.T) {
+	novoTeste(t)
+	router := roteadorAuth()
+	p, sessao := sessaoTeste(t)
+
+	// Assinado com outro segredo.
+	claims := auth.NovasClaims(p, "jti-falso", accessTokenTTL)
+	forjado, err := auth.Assinar(claims, []byte("outro-segredo"))
+	if err != nil {
+		t.Fatalf("assinar: %v", err)
+	}
+	// Expirado.
+	expirado, err := auth.Assinar(auth.NovasClaims(p, "jti-expirado", -time.Minute), jwtSecret())
+	if err != nil {
+		t.Fatalf("assinar: %v", err)
+	}
+
+	casos := map[string]string{
+		"sem token":     "",
+		"outro segredo": forjado,
+		"expirado":      expirado,
+		"refresh token": sessao.RefreshToken,
+	}
+	for nome, token := range casos {
+		t.Run(nome, func(t *testing.T) {
+			esperarStatus(t, enviarAuth(t, router, http.MethodGet, "/api/meus-pedidos", token, nil), http.StatusUnauthorized)
+		})
+	}
+}
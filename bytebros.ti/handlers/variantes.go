@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prepararVariantes preenche o preço efetivo das variantes e devolve as
+// opções do produto: para cada atributo, os valores distintos em ordem.
+func prepararVariantes(produto models.Produto, variantes []models.ProdutoVariante) map[string][]string {
+	vistos := make(map[string]map[string]bool)
+	agora := time.Now()
+	for i := range variantes {
+		variantes[i].PrecoEfetivo = variantes[i].PrecoPara(produto, agora)
+		for nome, valor := range variantes[i].Atributos {
+			if vistos[nome] == nil {
+				vistos[nome] = make(map[string]bool)
+			}
+			vistos[nome][valor] = true
+		}
+	}
+
+	opcoes := make(map[string][]string, len(vistos))
+	for nome, valores := range vistos {
+		for valor := range valores {
+			opcoes[nome] = append(opcoes[nome], valor)
+		}
+		sort.Strings(opcoes[nome])
+	}
+	return opcoes
+}
+
+// sincronizarEstoqueProduto mantém produtos.quantidade igual à soma das
+// variantes e devolve o produto. A diferença, que só aparece quando o
+// produto ganha a primeira variante, é lançada como ajuste. Deve rodar na
+// mesma transação da alteração da variante.
+func sincronizarEstoqueProduto(tx *repository.Repositorios, produtoID int, usuario string) (models.Produto, error) {
+	produtos, err := tx.Produtos.Bloquear([]int{produtoID})
+	if err != nil {
+		return models.Produto{}, err
+	}
+	variantes, err := tx.Variantes.Listar(produtoID)
+	if err != nil {
+		return models.Produto{}, err
+	}
+	produto := produtos[produtoID]
+	soma := 0
+	for _, v := range variantes {
+		soma += v.Quantidade
+	}
+	if soma != produto.Quantidade {
+		err := movimentarEstoque(tx, &models.MovimentoEstoque{
+			ProdutoID:  produtoID,
+			Tipo:       models.MovimentoAjuste,
+			Quantidade: soma - produto.Quantidade,
+			Motivo:     "Estoque do produto passa a ser a soma das variantes",
+			Usuario:    usuario,
+		})
+		if err != nil {
+			return produto, err
+		}
+	}
+	produto.Quantidade = soma
+	return produto, nil
+}
+
+// varianteDaRequisicao valida o corpo de criação/edição. Em caso de erro já
+// responde 400 e devolve false.
+func varianteDaRequisicao(c *gin.Context, produtoID int) (models.ProdutoVariante, bool) {
+	var req models.ProdutoVarianteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return models.ProdutoVariante{}, false
+	}
+
+	v := models.ProdutoVariante{
+		ProdutoID:  produtoID,
+		SKU:        strings.ToUpper(strings.TrimSpace(req.SKU)),
+		Atributos:  make(map[string]string, len(req.Atributos)),
+		Preco:      req.Preco,
+		Quantidade: req.Quantidade,
+	}
+	if v.SKU == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "SKU não pode ser vazio"})
+		return v, false
+	}
+	for nome, valor := range req.Atributos {
+		nome, valor = strings.ToLower(strings.TrimSpace(nome)), strings.TrimSpace(valor)
+		if nome == "" || valor == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Atributos precisam de nome e valor"})
+			return v, false
+		}
+		v.Atributos[nome] = valor
+	}
+	return v, true
+}
+
+func ListarVariantes(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+
+	produto, err := repos.Produtos.Obter(produtoID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar produto", "detalhes": err.Error()})
+		}
+		return
+	}
+	variantes, err := repos.Variantes.Listar(produtoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao buscar variantes", "detalhes": err.Error()})
+		return
+	}
+	prepararVariantes(produto, variantes)
+	c.JSON(http.StatusOK, variantes)
+}
+
+func CriarVariante(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	variante, ok := varianteDaRequisicao(c, produtoID)
+	if !ok {
+		return
+	}
+	usuario := emailDaRequisicao(c)
+
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		// A variante nasce zerada e o estoque dela entra pelo livro-razão.
+		quantidade := variante.Quantidade
+		variante.Quantidade = 0
+		if err := tx.Variantes.Criar(&variante); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNaoEncontrado):
+				return abortar(http.StatusNotFound, gin.H{"erro": "Produto não encontrado"})
+			case errors.Is(err, repository.ErrDuplicado):
+				return abortar(http.StatusConflict, gin.H{"erro": "Já existe uma variante com este SKU", "sku": variante.SKU})
+			}
+			return err
+		}
+		if quantidade != 0 {
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  produtoID,
+				VarianteID: &variante.ID,
+				Tipo:       models.MovimentoEntrada,
+				Quantidade: quantidade,
+				Motivo:     "Estoque inicial da variante " + variante.SKU,
+				Usuario:    usuario,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		variante.Quantidade = quantidade
+
+		produto, err := sincronizarEstoqueProduto(tx, produtoID, usuario)
+		variante.PrecoEfetivo = variante.PrecoPara(produto, time.Now())
+		return err
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao criar variante")
+		return
+	}
+	go avisarReposicao(produtoID)
+	c.JSON(http.StatusCreated, variante)
+}
+
+// obterVariante lê a variante na transação, convertendo "não encontrada"
+// em 404.
+func obterVariante(tx *repository.Repositorios, produtoID, id int) (models.ProdutoVariante, error) {
+	v, err := tx.Variantes.Obter(produtoID, id)
+	if errors.Is(err, repository.ErrNaoEncontrado) {
+		return v, abortar(http.StatusNotFound, gin.H{"erro": "Variante não encontrada"})
+	}
+	return v, err
+}
+
+func AtualizarVariante(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	varianteID, ok := idDoParametro(c, "varianteId")
+	if !ok {
+		return
+	}
+	variante, ok := varianteDaRequisicao(c, produtoID)
+	if !ok {
+		return
+	}
+	variante.ID = varianteID
+	usuario := emailDaRequisicao(c)
+
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		atual, err := obterVariante(tx, produtoID, varianteID)
+		if err != nil {
+			return err
+		}
+		// A diferença de quantidade vira um ajuste no livro-razão.
+		quantidade := variante.Quantidade
+		variante.Quantidade = atual.Quantidade
+		if err := tx.Variantes.Atualizar(variante); err != nil {
+			if errors.Is(err, repository.ErrDuplicado) {
+				return abortar(http.StatusConflict, gin.H{"erro": "Já existe uma variante com este SKU", "sku": variante.SKU})
+			}
+			return err
+		}
+		if delta := quantidade - atual.Quantidade; delta != 0 {
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  produtoID,
+				VarianteID: &variante.ID,
+				Tipo:       models.MovimentoAjuste,
+				Quantidade: delta,
+				Motivo:     "Quantidade alterada no cadastro da variante",
+				Usuario:    usuario,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		variante.Quantidade = quantidade
+
+		produto, err := sincronizarEstoqueProduto(tx, produtoID, usuario)
+		variante.PrecoEfetivo = variante.PrecoPara(produto, time.Now())
+		return err
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao atualizar variante")
+		return
+	}
+	go avisarReposicao(produtoID)
+	c.JSON(http.StatusOK, variante)
+}
+
+func DeletarVariante(c *gin.Context) {
+	produtoID, ok := idDoParametro(c, "id")
+	if !ok {
+		return
+	}
+	varianteID, ok := idDoParametro(c, "varianteId")
+	if !ok {
+		return
+	}
+	usuario := emailDaRequisicao(c)
+
+	err := repos.Transacao(func(tx *repository.Repositorios) error {
+		atual, err := obterVariante(tx, produtoID, varianteID)
+		if err != nil {
+			return err
+		}
+		// O estoque da variante sai do produto antes dela; o movimento fica
+		// no livro-razão sem a variante.
+		if atual.Quantidade != 0 {
+			err := movimentarEstoque(tx, &models.MovimentoEstoque{
+				ProdutoID:  produtoID,
+				VarianteID: &atual.ID,
+				Tipo:       models.MovimentoAjuste,
+				Quantidade: -atual.Quantidade,
+				Motivo:     "Variante " + atual.SKU + " removida",
+				Usuario:    usuario,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Variantes.Deletar(produtoID, varianteID); err != nil {
+			if errors.Is(err, repository.ErrEmUso) {
+				return abortar(http.StatusConflict, gin.H{"erro": "Variante está em pedidos e não pode ser removida; zere o estoque dela"})
+			}
+			return err
+		}
+		_, err = sincronizarEstoqueProduto(tx, produtoID, usuario)
+		return err
+	})
+	if err != nil {
+		responderErro(c, err, "Erro ao deletar variante")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Variante deletada com sucesso"})
+}
@@ -0,0 +1,156 @@
+// Package imagens valida as imagens enviadas e gera as versões reduzidas
+// usadas pela loja, só com a biblioteca padrão.
+package imagens
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registra o decodificador para image.Decode
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Tamanho é uma versão reduzida: o maior lado fica com no máximo Lado pixels.
+type Tamanho struct {
+	Nome string
+	Lado int
+}
+
+// Tamanhos gerados para cada imagem, além da original.
+var Tamanhos = []Tamanho{
+	{Nome: "miniatura", Lado: 150},
+	{Nome: "media", Lado: 600},
+	{Nome: "grande", Lado: 1200},
+}
+
+// Original é o nome da versão enviada, guardada sem alterações.
+const Original = "original"
+
+// MaxPixels barra imagens pequenas em bytes mas enormes quando
+// descompactadas.
+const MaxPixels = 40_000_000
+
+var (
+	ErrFormato    = errors.New("formato de imagem não suportado")
+	ErrDimensoes  = errors.New("imagem grande demais")
+	ErrCorrompida = errors.New("imagem corrompida")
+)
+
+// tiposAceitos liga o Content-Type detectado pelo conteúdo à extensão.
+var tiposAceitos = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Versao é um arquivo pronto para ser gravado.
+type Versao struct {
+	Nome        string
+	Conteudo    []byte
+	ContentType string
+	Extensao    string
+	Largura     int
+	Altura      int
+}
+
+// Processar confere o tipo pelo conteúdo (não pelo nome nem pelo cabeçalho
+// do upload) e devolve a original seguida das versões de Tamanhos. As
+// reduções saem em JPEG, ou em PNG quando a original pode ter transparência.
+func Processar(conteudo []byte) ([]Versao, error) {
+	contentType := http.DetectContentType(conteudo)
+	extensao, ok := tiposAceitos[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFormato, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(conteudo))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrompida, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrDimensoes, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(conteudo))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrompida, err)
+	}
+
+	versoes := []Versao{{
+		Nome:        Original,
+		Conteudo:    conteudo,
+		ContentType: contentType,
+		Extensao:    extensao,
+		Largura:     config.Width,
+		Altura:      config.Height,
+	}}
+
+	// Converte uma vez para RGBA; as reduções leem direto de Pix.
+	origem := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	draw.Draw(origem, origem.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	for _, t := range Tamanhos {
+		largura, altura := dimensoes(config.Width, config.Height, t.Lado)
+		reduzida := reduzir(origem, largura, altura)
+
+		var buf bytes.Buffer
+		v := Versao{Nome: t.Nome, Largura: largura, Altura: altura}
+		if contentType == "image/jpeg" {
+			err = jpeg.Encode(&buf, reduzida, &jpeg.Options{Quality: 85})
+			v.ContentType, v.Extensao = "image/jpeg", "jpg"
+		} else {
+			err = png.Encode(&buf, reduzida)
+			v.ContentType, v.Extensao = "image/png", "png"
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao gerar versão %s: %w", t.Nome, err)
+		}
+		v.Conteudo = buf.Bytes()
+		versoes = append(versoes, v)
+	}
+	return versoes, nil
+}
+
+// dimensoes mantém a proporção com o maior lado em no máximo lado pixels,
+// sem ampliar imagens menores.
+func dimensoes(largura, altura, lado int) (int, int) {
+	if largura <= lado && altura <= lado {
+		return largura, altura
+	}
+	if largura >= altura {
+		return lado, max(1, altura*lado/largura)
+	}
+	return max(1, largura*lado/altura), lado
+}
+
+// reduzir faz a média de cada bloco de pixels da origem (filtro de caixa),
+// o que evita o serrilhado de pegar só o pixel mais próximo.
+func reduzir(origem *image.RGBA, largura, altura int) *image.RGBA {
+	destino := image.NewRGBA(image.Rect(0, 0, largura, altura))
+	ol, oa := origem.Bounds().Dx(), origem.Bounds().Dy()
+	for y := 0; y < altura; y++ {
+		y0, y1 := y*oa/altura, max((y+1)*oa/altura, y*oa/altura+1)
+		for x := 0; x < largura; x++ {
+			x0, x1 := x*ol/largura, max((x+1)*ol/largura, x*ol/largura+1)
+			var soma [4]int
+			for sy := y0; sy < y1; sy++ {
+				linha := origem.Pix[sy*origem.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := linha[sx*4 : sx*4+4]
+					soma[0] += int(p[0])
+					soma[1] += int(p[1])
+					soma[2] += int(p[2])
+					soma[3] += int(p[3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			d := destino.Pix[y*destino.Stride+x*4:]
+			d[0], d[1], d[2], d[3] = uint8(soma[0]/n), uint8(soma[1]/n), uint8(soma[2]/n), uint8(soma[3]/n)
+		}
+	}
+	return destino
+}
@@ -0,0 +1,152 @@
+package imagens
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func imagemTeste(largura, altura int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, largura, altura))
+	for y := 0; y < altura; y++ {
+		for x := 0; x < largura; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func pngTeste(t *testing.T, largura, altura int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, imagemTeste(largura, altura)); err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func jpegTeste(t *testing.T, largura, altura int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, imagemTeste(largura, altura), nil); err != nil {
+		t.Fatalf("jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gifTeste(t *testing.T, largura, altura int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, imagemTeste(largura, altura), nil); err != nil {
+		t.Fatalf("gif: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessarTamanhos(t *testing.T) {
+	casos := []struct {
+		nome     string
+		conteudo []byte
+		tipo     string
+		// largura e altura da original, miniatura, media e grande.
+		dimensoes [4][2]int
+	}{
+		{"paisagem", jpegTeste(t, 1500, 1000), "image/jpeg", [4][2]int{{1500, 1000}, {150, 100}, {600, 400}, {1200, 800}}},
+		{"retrato", pngTeste(t, 400, 1000), "image/png", [4][2]int{{400, 1000}, {60, 150}, {240, 600}, {400, 1000}}},
+		// Imagens menores que o tamanho não são ampliadas.
+		{"pequena", gifTeste(t, 100, 50), "image/gif", [4][2]int{{100, 50}, {100, 50}, {100, 50}, {100, 50}}},
+		// O lado menor nunca chega a zero.
+		{"faixa", pngTeste(t, 3000, 1), "image/png", [4][2]int{{3000, 1}, {150, 1}, {600, 1}, {1200, 1}}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			versoes, err := Processar(caso.conteudo)
+			if err != nil {
+				t.Fatalf("processar: %v", err)
+			}
+			nomes := []string{Original, "miniatura", "media", "grande"}
+			if len(versoes) != len(nomes) {
+				t.Fatalf("%d versões, esperado %d", len(versoes), len(nomes))
+			}
+			if !bytes.Equal(versoes[0].Conteudo, caso.conteudo) || versoes[0].ContentType != caso.tipo {
+				t.Errorf("original %s alterada ou com o tipo errado", versoes[0].ContentType)
+			}
+			// As reduções da JPEG saem em JPEG; as de PNG e GIF, em PNG.
+			tipoReduzida := "image/png"
+			if caso.tipo == "image/jpeg" {
+				tipoReduzida = "image/jpeg"
+			}
+			for i, v := range versoes {
+				largura, altura := caso.dimensoes[i][0], caso.dimensoes[i][1]
+				if v.Nome != nomes[i] || v.Largura != largura || v.Altura != altura {
+					t.Errorf("versão %d = %s %dx%d, esperado %s %dx%d", i, v.Nome, v.Largura, v.Altura, nomes[i], largura, altura)
+				}
+				if i == 0 {
+					continue
+				}
+				if v.ContentType != tipoReduzida {
+					t.Errorf("%s em %s, esperado %s", v.Nome, v.ContentType, tipoReduzida)
+				}
+				// O arquivo gerado tem as dimensões declaradas.
+				config, _, err := image.DecodeConfig(bytes.NewReader(v.Conteudo))
+				if err != nil || config.Width != largura || config.Height != altura {
+					t.Errorf("%s decodifica em %dx%d (%v), esperado %dx%d", v.Nome, config.Width, config.Height, err, largura, altura)
+				}
+			}
+		})
+	}
+}
+
+func TestReduzirFazAMedia(t *testing.T) {
+	origem := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	origem.Set(0, 0, color.RGBA{255, 255, 255, 255})
+	origem.Set(1, 1, color.RGBA{255, 255, 255, 255})
+	origem.Set(1, 0, color.RGBA{0, 0, 0, 255})
+	origem.Set(0, 1, color.RGBA{0, 0, 0, 255})
+
+	if c := reduzir(origem, 1, 1).RGBAAt(0, 0); c != (color.RGBA{127, 127, 127, 255}) {
+		t.Errorf("média de 2x2 xadrez = %v, esperado cinza 127", c)
+	}
+}
+
+// pngComDimensoes troca largura e altura no IHDR de um PNG válido,
+// recalculando o CRC do chunk.
+func pngComDimensoes(t *testing.T, largura, altura uint32) []byte {
+	t.Helper()
+	conteudo := pngTeste(t, 1, 1)
+	// Assinatura (8), tamanho (4), "IHDR" (4) e então os dados.
+	ihdr := conteudo[16:29]
+	binary.BigEndian.PutUint32(ihdr[0:4], largura)
+	binary.BigEndian.PutUint32(ihdr[4:8], altura)
+	binary.BigEndian.PutUint32(conteudo[29:33], crc32.ChecksumIEEE(conteudo[12:29]))
+	return conteudo
+}
+
+func TestProcessarRecusa(t *testing.T) {
+	foto := jpegTeste(t, 10, 10)
+	casos := []struct {
+		nome     string
+		conteudo []byte
+		erro     error
+	}{
+		{"texto", []byte("isto não é uma imagem"), ErrFormato},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ErrFormato},
+		{"webp", append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 32)...), ErrFormato},
+		{"jpeg truncado", foto[:len(foto)/2], ErrCorrompida},
+		{"só a assinatura png", []byte("\x89PNG\r\n\x1a\n"), ErrCorrompida},
+		// 8000x6000 são 48 milhões de pixels num arquivo de poucos bytes.
+		{"resolução grande demais", pngComDimensoes(t, 8000, 6000), ErrDimensoes},
+	}
+	for _, caso := range casos {
+		if _, err := Processar(caso.conteudo); !errors.Is(err, caso.erro) {
+			t.Errorf("%s: %v, esperado %v", caso.nome, err, caso.erro)
+		}
+	}
+}
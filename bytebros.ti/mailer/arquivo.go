@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArquivoSender grava cada mensagem como um arquivo .eml em Dir, para uso em
+// desenvolvimento local e testes. Com Dir vazio, a mensagem vai só para o log.
+// As mensagens enviadas também ficam disponíveis em Enviadas.
+type ArquivoSender struct {
+	Dir       string
+	Remetente string
+
+	mu       sync.Mutex
+	enviadas []Mensagem
+}
+
+func (s *ArquivoSender) Enviar(msg Mensagem) error {
+	s.mu.Lock()
+	s.enviadas = append(s.enviadas, msg)
+	s.mu.Unlock()
+
+	if s.Dir == "" {
+		log.Printf("EMAIL para %s | %s\n%s", msg.Para, msg.Assunto, msg.Corpo)
+		return nil
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de emails: %w", err)
+	}
+
+	nome := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), nomeSeguro(msg.Para))
+	caminho := filepath.Join(s.Dir, nome)
+	if err := os.WriteFile(caminho, montarMensagem(s.Remetente, msg), 0o644); err != nil {
+		return fmt.Errorf("erro ao gravar email em %s: %w", caminho, err)
+	}
+	log.Printf("Email para %s gravado em %s", msg.Para, caminho)
+	return nil
+}
+
+// Enviadas devolve uma cópia das mensagens enviadas até agora.
+func (s *ArquivoSender) Enviadas() []Mensagem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mensagem(nil), s.enviadas...)
+}
+
+func nomeSeguro(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,72 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArquivoSenderGravaEml(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "emails")
+	s := &ArquivoSender{Dir: dir, Remetente: "loja@bytebros.ti"}
+	msg := Mensagem{Para: "ana+loja@example.com", Assunto: "Redefinição de senha", Corpo: "Olá!\nAcesse o link."}
+	if err := s.Enviar(msg); err != nil {
+		t.Fatalf("Enviar: %v", err)
+	}
+
+	arquivos, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(arquivos) != 1 {
+		t.Fatalf("arquivos = %v (%v), esperado um .eml", arquivos, err)
+	}
+	if nome := filepath.Base(arquivos[0]); !strings.HasSuffix(nome, "_ana_loja_example.com.eml") {
+		t.Errorf("nome = %q, esperado terminar com o destinatário sem caracteres especiais", nome)
+	}
+	dados, err := os.ReadFile(arquivos[0])
+	if err != nil {
+		t.Fatalf("ler %s: %v", arquivos[0], err)
+	}
+	m, corpo := lerMensagem(t, dados)
+	if m.Header.Get("To") != msg.Para || m.Header.Get("From") != s.Remetente {
+		t.Errorf("cabeçalhos = %v", m.Header)
+	}
+	if corpo != "Olá!\r\nAcesse o link." {
+		t.Errorf("corpo = %q", corpo)
+	}
+
+	if enviadas := s.Enviadas(); len(enviadas) != 1 || enviadas[0] != msg {
+		t.Errorf("Enviadas = %+v, esperado só %+v", enviadas, msg)
+	}
+}
+
+func TestArquivoSenderSoLog(t *testing.T) {
+	s := &ArquivoSender{}
+	for _, para := range []string{"ana@example.com", "bia@example.com"} {
+		if err := s.Enviar(Mensagem{Para: para, Assunto: "Aviso", Corpo: "x"}); err != nil {
+			t.Fatalf("Enviar: %v", err)
+		}
+	}
+	enviadas := s.Enviadas()
+	if len(enviadas) != 2 || enviadas[1].Para != "bia@example.com" {
+		t.Errorf("Enviadas = %+v, esperado as duas mensagens em ordem", enviadas)
+	}
+	// A cópia devolvida não altera as mensagens guardadas.
+	enviadas[0].Para = "outro@example.com"
+	if s.Enviadas()[0].Para != "ana@example.com" {
+		t.Error("Enviadas devolveu o slice interno")
+	}
+}
+
+func TestNomeSeguro(t *testing.T) {
+	casos := map[string]string{
+		"ana@example.com":     "ana_example.com",
+		"joão+1@example.com":  "jo_o_1_example.com",
+		"../../etc/passwd":    ".._.._etc_passwd",
+		"Bia.Souza-2@loja.ti": "Bia.Souza-2_loja.ti",
+	}
+	for entrada, esperado := range casos {
+		if s := nomeSeguro(entrada); s != esperado {
+			t.Errorf("nomeSeguro(%q) = %q, esperado %q", entrada, s, esperado)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Mensagem é um email de texto simples.
+type Mensagem struct {
+	Para    string
+	Assunto string
+	Corpo   string
+}
+
+// Sender envia mensagens de email. A implementação é escolhida na
+// inicialização: SMTP em produção, arquivo/log em desenvolvimento e testes.
+type Sender interface {
+	Enviar(msg Mensagem) error
+}
+
+// NovoDoAmbiente monta o Sender a partir das variáveis de ambiente.
+//
+// MAIL_DRIVER=smtp usa SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASS e MAIL_FROM.
+// MAIL_DRIVER=arquivo grava cada mensagem em MAIL_DIR.
+// Qualquer outro valor (ou vazio) apenas registra as mensagens no log.
+func NovoDoAmbiente() (Sender, error) {
+	remetente := os.Getenv("MAIL_FROM")
+	if remetente == "" {
+		remetente = "nao-responda@bytebros.ti"
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		porta := 587
+		if p := os.Getenv("SMTP_PORT"); p != "" {
+			var err error
+			porta, err = strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("SMTP_PORT inválida: %w", err)
+			}
+		}
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST não configurado")
+		}
+		return &SMTPSender{
+			Host:      host,
+			Porta:     porta,
+			Usuario:   os.Getenv("SMTP_USER"),
+			Senha:     os.Getenv("SMTP_PASS"),
+			Remetente: remetente,
+		}, nil
+	case "arquivo":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "emails"
+		}
+		return &ArquivoSender{Dir: dir, Remetente: remetente}, nil
+	default:
+		log.Println("MAIL_DRIVER não definido. Emails serão apenas registrados no log.")
+		return &ArquivoSender{Remetente: remetente}, nil
+	}
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import "testing"
+
+func TestNovoDoAmbiente(t *testing.T) {
+	casos := []struct {
+		nome string
+		env  map[string]string
+		erro bool
+		conf func(Sender) bool
+	}{
+		{"log", map[string]string{}, false, func(s Sender) bool {
+			a, ok := s.(*ArquivoSender)
+			return ok && a.Dir == "" && a.Remetente == "nao-responda@bytebros.ti"
+		}},
+		{"arquivo", map[string]string{"MAIL_DRIVER": "arquivo", "MAIL_FROM": "loja@bytebros.ti"}, false, func(s Sender) bool {
+			a, ok := s.(*ArquivoSender)
+			return ok && a.Dir == "emails" && a.Remetente == "loja@bytebros.ti"
+		}},
+		{"smtp", map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": "smtp.example.com", "SMTP_USER": "u", "SMTP_PASS": "p"}, false, func(s Sender) bool {
+			m, ok := s.(*SMTPSender)
+			return ok && m.Host == "smtp.example.com" && m.Porta == 587 && m.Usuario == "u" && m.Senha == "p"
+		}},
+		{"smtp com porta", map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": "smtp.example.com", "SMTP_PORT": "2525"}, false, func(s Sender) bool {
+			m, ok := s.(*SMTPSender)
+			return ok && m.Porta == 2525
+		}},
+		{"smtp sem host", map[string]string{"MAIL_DRIVER": "smtp"}, true, nil},
+		{"smtp porta inválida", map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": "smtp.example.com", "SMTP_PORT": "x"}, true, nil},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			for _, v := range []string{"MAIL_DRIVER", "MAIL_FROM", "MAIL_DIR", "SMTP_HOST", "SMTP_PORT", "SMTP_USER", "SMTP_PASS"} {
+				t.Setenv(v, caso.env[v])
+			}
+			s, err := NovoDoAmbiente()
+			if caso.erro {
+				if err == nil {
+					t.Errorf("esperado erro, veio %#v", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NovoDoAmbiente: %v", err)
+			}
+			if !caso.conf(s) {
+				t.Errorf("sender = %#v", s)
+			}
+		})
+	}
+}
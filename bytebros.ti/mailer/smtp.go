@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPSender envia mensagens por um servidor SMTP com autenticação PLAIN.
+type SMTPSender struct {
+	Host      string
+	Porta     int
+	Usuario   string
+	Senha     string
+	Remetente string
+}
+
+func (s *SMTPSender) Enviar(msg Mensagem) error {
+	var auth smtp.Auth
+	if s.Usuario != "" {
+		auth = smtp.PlainAuth("", s.Usuario, s.Senha, s.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Porta)
+	if err := smtp.SendMail(addr, auth, s.Remetente, []string{msg.Para}, montarMensagem(s.Remetente, msg)); err != nil {
+		return fmt.Errorf("erro ao enviar email para %s: %w", msg.Para, err)
+	}
+	return nil
+}
+
+// montarMensagem gera a mensagem no formato RFC 5322 com corpo UTF-8. O
+// assunto, que pode ter acentos, vai codificado como RFC 2047.
+func montarMensagem(remetente string, msg Mensagem) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + remetente + "\r\n")
+	b.WriteString("To: " + msg.Para + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Assunto) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Corpo, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,154 @@
+package mailer
+
+import (
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func lerMensagem(t *testing.T, dados []byte) (*mail.Message, string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(string(dados)))
+	if err != nil {
+		t.Fatalf("mensagem inválida: %v\n%s", err, dados)
+	}
+	corpo, err := io.ReadAll(m.Body)
+	if err != nil {
+		t.Fatalf("ler corpo: %v", err)
+	}
+	return m, string(corpo)
+}
+
+func TestMontarMensagem(t *testing.T) {
+	casos := []struct {
+		nome, assunto, cabecalho string
+	}{
+		{"ascii", "Pedido #12 enviado", "Pedido #12 enviado"},
+		{"acentos", "Byte Bros.TI - Redefinição de senha", "=?utf-8?q?Byte_Bros.TI_-_Redefini=C3=A7=C3=A3o_de_senha?="},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			dados := montarMensagem("loja@bytebros.ti", Mensagem{Para: "ana@example.com", Assunto: caso.assunto, Corpo: "Olá, Ana!\n\nSeu código é 123."})
+			if strings.Count(string(dados), "\n") != strings.Count(string(dados), "\r\n") {
+				t.Errorf("linhas sem CRLF:\n%q", dados)
+			}
+
+			m, corpo := lerMensagem(t, dados)
+			cabecalhos := map[string]string{
+				"From":                      "loja@bytebros.ti",
+				"To":                        "ana@example.com",
+				"Subject":                   caso.cabecalho,
+				"Mime-Version":              "1.0",
+				"Content-Type":              "text/plain; charset=UTF-8",
+				"Content-Transfer-Encoding": "8bit",
+			}
+			for nome, esperado := range cabecalhos {
+				if v := m.Header.Get(nome); v != esperado {
+					t.Errorf("%s = %q, esperado %q", nome, v, esperado)
+				}
+			}
+			if _, err := m.Header.Date(); err != nil {
+				t.Errorf("Date inválido: %v", err)
+			}
+			var dec mime.WordDecoder
+			if assunto, err := dec.DecodeHeader(m.Header.Get("Subject")); err != nil || assunto != caso.assunto {
+				t.Errorf("assunto decodificado = %q (%v), esperado %q", assunto, err, caso.assunto)
+			}
+			if esperado := "Olá, Ana!\r\n\r\nSeu código é 123."; corpo != esperado {
+				t.Errorf("corpo = %q, esperado %q", corpo, esperado)
+			}
+		})
+	}
+}
+
+// servidorSMTP atende uma única sessão SMTP sem autenticação e devolve
+// pelo canal o remetente, os destinatários e o conteúdo recebidos.
+func servidorSMTP(t *testing.T) (*net.TCPAddr, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("escutar: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	recebido := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var sessao []string
+		tp.PrintfLine("220 teste ESMTP")
+		for {
+			linha, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			comando := strings.ToUpper(strings.SplitN(linha, " ", 2)[0])
+			switch comando {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 teste")
+			case "MAIL", "RCPT":
+				sessao = append(sessao, linha)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 Envie")
+				dados, err := io.ReadAll(tp.DotReader())
+				if err != nil {
+					return
+				}
+				sessao = append(sessao, string(dados))
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Tchau")
+				recebido <- sessao
+				return
+			default:
+				tp.PrintfLine("502 Não implementado")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr), recebido
+}
+
+func TestSMTPSenderEnviar(t *testing.T) {
+	addr, recebido := servidorSMTP(t)
+	s := &SMTPSender{Host: addr.IP.String(), Porta: addr.Port, Remetente: "loja@bytebros.ti"}
+	msg := Mensagem{Para: "ana@example.com", Assunto: "Orçamento aprovado", Corpo: "Linha 1\n.linha com ponto"}
+	if err := s.Enviar(msg); err != nil {
+		t.Fatalf("Enviar: %v", err)
+	}
+
+	sessao := <-recebido
+	if len(sessao) != 3 || sessao[0] != "MAIL FROM:<loja@bytebros.ti>" || !strings.HasPrefix(sessao[1], "RCPT TO:<ana@example.com>") {
+		t.Fatalf("sessão = %q, esperado MAIL FROM, RCPT TO e os dados", sessao)
+	}
+	m, corpo := lerMensagem(t, []byte(sessao[2]))
+	if v := m.Header.Get("Subject"); v != mime.QEncoding.Encode("utf-8", msg.Assunto) {
+		t.Errorf("Subject = %q", v)
+	}
+	if esperado := "Linha 1\n.linha com ponto\n"; corpo != esperado {
+		t.Errorf("corpo = %q, esperado %q", corpo, esperado)
+	}
+}
+
+func TestSMTPSenderServidorFora(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("escutar: %v", err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close()
+
+	s := &SMTPSender{Host: addr.IP.String(), Porta: addr.Port, Remetente: "loja@bytebros.ti"}
+	err = s.Enviar(Mensagem{Para: "ana@example.com", Assunto: "Teste", Corpo: "x"})
+	if err == nil || !strings.Contains(err.Error(), "ana@example.com") {
+		t.Errorf("erro = %v, esperado falha citando o destinatário", err)
+	}
+}
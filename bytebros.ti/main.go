@@ -9,8 +9,10 @@ import (
 	"syscall"
 	"time"
 
+	"bytebros.ti/auth"
 	"bytebros.ti/database"
 	"bytebros.ti/handlers"
+	"bytebros.ti/repository"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -27,11 +29,31 @@ func main() {
 	database.InitDB()
 	defer database.CloseDB()
 
-	if err := database.CreateTables(); err != nil {
-		log.Fatalf("Erro ao criar tabelas: %v", err)
+	// "bytebros migrate|rollback|status" gerencia o esquema e sai sem subir o servidor.
+	if len(os.Args) > 1 {
+		codigo := executarComando(os.Args[1:])
+		database.CloseDB()
+		os.Exit(codigo)
 	}
 
+	if err := database.Migrar(); err != nil {
+		log.Fatalf("Erro ao aplicar migrações: %v", err)
+	}
+
+	handlers.InitializeRepositorios(repository.NovoPostgres(database.DB))
 	handlers.InitializeGeminiClient()
+	handlers.InitializeMailer()
+	handlers.InitializeStorage()
+	handlers.InitializeFrete()
+	handlers.InitializePagamentos()
+
+	// O monitor de estoque e o agendador de promoções param junto com o
+	// servidor.
+	monitorCtx, pararMonitor := context.WithCancel(context.Background())
+	defer pararMonitor()
+	handlers.IniciarMonitorEstoque(monitorCtx)
+	handlers.IniciarAgendadorPromocoes(monitorCtx)
+	handlers.IniciarExpiracaoPagamentos(monitorCtx)
 	log.SetOutput(os.Stderr)
 
 	router := gin.Default()
@@ -42,28 +64,56 @@ func main() {
 	config.AllowOrigins = []string{"https://bytebros.netlify.app"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.ExposeHeaders = []string{"Content-Length", "X-Total-Count", "X-Next-Cursor"}
 	config.AllowCredentials = true
 	router.Use(cors.New(config))
 
-	router.Use(func(c *gin.Context) {
-		c.Set("db", database.DB)
-		c.Next()
-	})
-
 	// --- ROTAS DA APLICAÇÃO ---
+	perm := handlers.RequirePermission
+
 	router.GET("/api/noticias", handlers.ListarNoticias)
 	router.GET("/api/noticias/:id", handlers.ObterNoticia)
 
 	produtoRoutes := router.Group("/api/produtos")
 	{
-		produtoRoutes.POST("", handlers.CriarProduto)
+		produtoRoutes.POST("", handlers.AuthMiddleware(), perm(auth.PermProdutosWrite), handlers.CriarProduto)
 		produtoRoutes.GET("", handlers.ListarProdutos)
+		produtoRoutes.GET("/busca", handlers.BuscarProdutos)
 		produtoRoutes.GET("/:id", handlers.ObterProduto)
-		produtoRoutes.PUT("/:id", handlers.AtualizarProduto)
-		produtoRoutes.DELETE("/:id", handlers.DeletarProduto)
+		produtoRoutes.GET("/:id/imagens", handlers.ListarImagensProduto)
+		produtoRoutes.GET("/:id/variantes", handlers.ListarVariantes)
+		produtoRoutes.POST("/:id/avise-me", handlers.AuthMiddleware(), handlers.AviseMe)
+		produtoRoutes.GET("/:id/avaliacoes", handlers.ListarAvaliacoesProduto)
+		produtoRoutes.POST("/:id/avaliacoes", handlers.AuthMiddleware(), handlers.CriarAvaliacao)
+		produtoRoutes.PUT("/:id", handlers.AuthMiddleware(), perm(auth.PermProdutosWrite), handlers.AtualizarProduto)
+		produtoRoutes.DELETE("/:id", handlers.AuthMiddleware(), perm(auth.PermProdutosWrite), handlers.DeletarProduto)
+	}
+
+	categoriaRoutes := router.Group("/api/categorias")
+	{
+		categoriaRoutes.GET("", handlers.ListarCategorias)
+		categoriaRoutes.POST("", handlers.AuthMiddleware(), perm(auth.PermProdutosWrite), handlers.CriarCategoria)
+		categoriaRoutes.PUT("/:id", handlers.AuthMiddleware(), perm(auth.PermProdutosWrite), handlers.AtualizarCategoria)
+		categoriaRoutes.DELETE("/:id", handlers.AuthMiddleware(), perm(auth.PermProdutosWrite), handlers.DeletarCategoria)
 	}
 
+	montagemRoutes := router.Group("/api/montagem")
+	{
+		montagemRoutes.POST("/validar", handlers.ValidarMontagem)
+		montagemRoutes.POST("/pedido", handlers.AuthMiddleware(), handlers.CriarPedidoMontagem)
+	}
+
+	router.POST("/api/frete/cotacao", handlers.CotarFrete)
+
+	pagamentoRoutes := router.Group("/api/pagamentos")
+	{
+		pagamentoRoutes.GET("/pix/:txid/qrcode.png", handlers.QRCodePix)
+		pagamentoRoutes.POST("/pix/:txid/simular", handlers.SimularPagamentoPix)
+		pagamentoRoutes.GET("/parcelas", handlers.ListarParcelas)
+	}
+	// Chamado pelo PSP; a autenticação é a assinatura HMAC do corpo.
+	router.POST("/api/webhooks/pix", handlers.WebhookPix)
+
 	orcamentoRoutes := router.Group("/api/orcamentos")
 	{
 		orcamentoRoutes.POST("", handlers.CriarOrcamento)
@@ -72,9 +122,13 @@ func main() {
 	authRoutes := router.Group("/api/auth")
 	{
 		authRoutes.POST("/registrar", handlers.RegistrarUsuario)
-		authRoutes.POST("/login", handlers.LoginUsuario)
-		authRoutes.POST("/funcionarios/registrar", handlers.RegistrarFuncionario)
-		authRoutes.POST("/funcionarios/login", handlers.LoginFuncionario)
+		authRoutes.POST("/login", handlers.Login)
+		authRoutes.POST("/funcionarios/registrar", handlers.AuthMiddleware(), perm(auth.PermAdminManage), handlers.RegistrarFuncionario)
+		authRoutes.POST("/funcionarios/login", handlers.Login)
+		authRoutes.POST("/refresh", handlers.RefreshToken)
+		authRoutes.POST("/esqueci-senha", handlers.EsqueciSenha)
+		authRoutes.POST("/redefinir-senha", handlers.RedefinirSenha)
+		authRoutes.POST("/logout", handlers.AuthMiddleware(), handlers.Logout)
 	}
 
 	protected := router.Group("/api")
@@ -83,27 +137,55 @@ func main() {
 		protected.GET("/perfil", handlers.ObterPerfil)
 		protected.POST("/pedidos", handlers.CriarPedido)
 		protected.GET("/meus-pedidos", handlers.ListarPedidosCliente)
+		protected.GET("/meus-pedidos/:id/historico", handlers.ObterHistoricoPedidoCliente)
+		protected.POST("/meus-pedidos/:id/cancelar", handlers.CancelarPedidoCliente)
+		protected.GET("/meus-pedidos/:id/pagamentos", handlers.ListarPagamentosPedidoCliente)
+		protected.GET("/meus-pedidos/:id/pagamentos/:pagamentoId/boleto.pdf", handlers.BoletoPDFCliente)
 		protected.GET("/minhas-interacoes", handlers.ListarInteracoesCliente)
 		protected.POST("/chatbot/suporte", handlers.ChatbotSupportRequest)
 		protected.PUT("/usuarios/email", handlers.AtualizarEmailUsuario)
 		protected.PUT("/usuarios/telefone", handlers.AtualizarTelefoneUsuario)
 
 		adminRoutes := protected.Group("/admin")
-		adminRoutes.Use(handlers.AdminMiddleware())
 		{
-			adminRoutes.DELETE("/administradores/:id", handlers.DeletarAdministrador)
-			adminRoutes.GET("/funcionarios", handlers.ListarFuncionarios)
-			adminRoutes.GET("/usuarios", handlers.ListarUsuarios)
-			adminRoutes.GET("/pedidos", handlers.ListarPedidosAdmin)
-			adminRoutes.PUT("/pedidos/:id/status", handlers.AtualizarStatusPedido)
-			adminRoutes.DELETE("/pedidos/:id", handlers.DeletarPedido)
-			adminRoutes.POST("/noticias", handlers.CriarNoticia)
-			adminRoutes.PUT("/noticias/:id", handlers.AtualizarNoticia)
-			adminRoutes.DELETE("/noticias/:id", handlers.DeletarNoticia)
-			adminRoutes.GET("/orcamentos", handlers.ListarOrcamentos)
-			adminRoutes.GET("/orcamentos/:id", handlers.ObterOrcamento)
-			adminRoutes.PUT("/orcamentos/:id/status", handlers.AtualizarStatusOrcamento)
-			adminRoutes.DELETE("/orcamentos/:id", handlers.DeletarOrcamento)
+			adminRoutes.POST("/administradores", perm(auth.PermAdminManage), handlers.CriarAdministrador)
+			adminRoutes.DELETE("/administradores/:id", perm(auth.PermAdminManage), handlers.DeletarAdministrador)
+			adminRoutes.GET("/dashboard", perm(auth.PermDashboardRead), handlers.AdminDashboard)
+			adminRoutes.GET("/permissoes", perm(auth.PermPermissoesManage), handlers.ListarPermissoes)
+			adminRoutes.PUT("/papeis/:papel/permissoes", perm(auth.PermPermissoesManage), handlers.AtualizarPermissoesPapel)
+			adminRoutes.POST("/produtos/importar", perm(auth.PermProdutosWrite), perm(auth.PermEstoqueWrite), handlers.ImportarProdutos)
+			adminRoutes.GET("/produtos/exportar", perm(auth.PermEstoqueRead), handlers.ExportarProdutos)
+			adminRoutes.GET("/produtos/:id/precos", perm(auth.PermProdutosWrite), handlers.ListarHistoricoPrecosProduto)
+			adminRoutes.GET("/servicos/:id/precos", perm(auth.PermServicosWrite), handlers.ListarHistoricoPrecosServico)
+			adminRoutes.POST("/produtos/:id/imagens", perm(auth.PermProdutosWrite), handlers.EnviarImagensProduto)
+			adminRoutes.PUT("/produtos/:id/imagens/ordem", perm(auth.PermProdutosWrite), handlers.ReordenarImagensProduto)
+			adminRoutes.DELETE("/produtos/:id/imagens/:imagemId", perm(auth.PermProdutosWrite), handlers.DeletarImagemProduto)
+			adminRoutes.POST("/produtos/:id/variantes", perm(auth.PermProdutosWrite), handlers.CriarVariante)
+			adminRoutes.PUT("/produtos/:id/variantes/:varianteId", perm(auth.PermProdutosWrite), handlers.AtualizarVariante)
+			adminRoutes.DELETE("/produtos/:id/variantes/:varianteId", perm(auth.PermProdutosWrite), handlers.DeletarVariante)
+			adminRoutes.POST("/estoque/movimentos", perm(auth.PermEstoqueWrite), handlers.LancarMovimentoEstoque)
+			adminRoutes.GET("/estoque/movimentos", perm(auth.PermEstoqueRead), handlers.ListarMovimentosEstoque)
+			adminRoutes.GET("/estoque/relatorio", perm(auth.PermEstoqueRead), handlers.RelatorioEstoque)
+			adminRoutes.GET("/estoque/conferencia", perm(auth.PermEstoqueRead), handlers.ConferirEstoque)
+			adminRoutes.GET("/notificacoes", perm(auth.PermDashboardRead), handlers.ListarNotificacoes)
+			adminRoutes.GET("/avaliacoes", perm(auth.PermAvaliacoesModerate), handlers.ListarAvaliacoesAdmin)
+			adminRoutes.PUT("/avaliacoes/:id/status", perm(auth.PermAvaliacoesModerate), handlers.ModerarAvaliacao)
+			adminRoutes.PUT("/notificacoes/:id/lida", perm(auth.PermDashboardRead), handlers.MarcarNotificacaoLida)
+			adminRoutes.GET("/funcionarios", perm(auth.PermFuncionariosRead), handlers.ListarFuncionarios)
+			adminRoutes.GET("/usuarios", perm(auth.PermUsuariosRead), handlers.ListarUsuarios)
+			adminRoutes.GET("/pedidos", perm(auth.PermPedidosRead), handlers.ListarPedidosAdmin)
+			adminRoutes.GET("/pedidos/:id/pagamentos", perm(auth.PermPedidosRead), handlers.ListarPagamentosPedidoAdmin)
+			adminRoutes.POST("/pedidos/:id/pagamentos/:pagamentoId/estorno", perm(auth.PermPedidosWrite), handlers.EstornarPagamento)
+			adminRoutes.GET("/pedidos/:id/pagamentos/:pagamentoId/boleto.pdf", perm(auth.PermPedidosRead), handlers.BoletoPDFAdmin)
+			adminRoutes.POST("/pagamentos/boletos/retorno", perm(auth.PermPedidosWrite), handlers.ProcessarRetornoBoleto)
+			adminRoutes.PUT("/pedidos/:id/status", perm(auth.PermPedidosWrite), handlers.AtualizarStatusPedido)
+			adminRoutes.POST("/noticias", perm(auth.PermNoticiasPublish), handlers.CriarNoticia)
+			adminRoutes.PUT("/noticias/:id", perm(auth.PermNoticiasPublish), handlers.AtualizarNoticia)
+			adminRoutes.DELETE("/noticias/:id", perm(auth.PermNoticiasPublish), handlers.DeletarNoticia)
+			adminRoutes.GET("/orcamentos", perm(auth.PermOrcamentosRead), handlers.ListarOrcamentos)
+			adminRoutes.GET("/orcamentos/:id", perm(auth.PermOrcamentosRead), handlers.ObterOrcamento)
+			adminRoutes.PUT("/orcamentos/:id/status", perm(auth.PermOrcamentosWrite), handlers.AtualizarStatusOrcamento)
+			adminRoutes.DELETE("/orcamentos/:id", perm(auth.PermOrcamentosWrite), handlers.DeletarOrcamento)
 		}
 	}
 
@@ -112,7 +194,7 @@ func main() {
 		servicosRoutes.GET("/", handlers.ListarServicos)
 		servicosRoutes.GET("/:id", handlers.ObterServico)
 		adminServicos := servicosRoutes.Group("/")
-		adminServicos.Use(handlers.AuthMiddleware(), handlers.AdminMiddleware())
+		adminServicos.Use(handlers.AuthMiddleware(), perm(auth.PermServicosWrite))
 		{
 			adminServicos.POST("/", handlers.CriarServico)
 			adminServicos.PUT("/:id", handlers.AtualizarServico)
@@ -123,24 +205,22 @@ func main() {
 	suporteRoutes := router.Group("/api/suporte")
 	{
 		suporteRoutes.POST("", handlers.CriarMensagemSuporte)
-		adminSuporte := suporteRoutes.Group("")
-		adminSuporte.Use(handlers.AuthMiddleware(), handlers.AdminMiddleware())
+		equipeSuporte := suporteRoutes.Group("")
+		equipeSuporte.Use(handlers.AuthMiddleware())
 		{
-			adminSuporte.GET("", handlers.ListarMensagensSuporte)
-			adminSuporte.GET("/:id", handlers.ObterMensagemSuporte)
-			adminSuporte.PUT("/:id/status", handlers.AtualizarStatusSuporte)
-			adminSuporte.DELETE("/:id", handlers.DeletarSuporte)
+			equipeSuporte.GET("", perm(auth.PermSuporteRead), handlers.ListarMensagensSuporte)
+			equipeSuporte.GET("/:id", perm(auth.PermSuporteRead), handlers.ObterMensagemSuporte)
+			equipeSuporte.PUT("/:id/status", perm(auth.PermSuporteWrite), handlers.AtualizarStatusSuporte)
+			equipeSuporte.DELETE("/:id", perm(auth.PermSuporteWrite), handlers.DeletarSuporte)
 		}
 	}
 
-	adminRoutes := router.Group("/api/admin")
-	adminRoutes.Use(handlers.AuthMiddleware(), handlers.AdminMiddleware())
-	{
-		adminRoutes.POST("/administradores", handlers.CriarAdministrador)
-		adminRoutes.GET("/dashboard", handlers.AdminDashboard)
+	// Com o storage local, a própria API serve as imagens enviadas.
+	if local, ok := handlers.StorageLocal(); ok {
+		router.Static(local.Prefixo, local.Dir)
 	}
 
-	router.POST("/api/admin/login", handlers.LoginAdmin)
+	router.POST("/api/admin/login", handlers.Login)
 	router.POST("/api/chatbot", handlers.ChatbotHandler)
 
 	// Handler para rotas não encontradas
@@ -166,6 +246,7 @@ func main() {
 
 	<-quit
 	log.Println("Desligando servidor...")
+	pararMonitor()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -10,17 +10,6 @@ type Administrador struct {
 	IsAdmin    bool      `json:"is_admin"`
 	CriadoEm   time.Time `json:"criado_em"`
 	Atualizado time.Time `json:"atualizado_em"`
-}
-
-type AdminLogin struct {
-	Email string `json:"email" binding:"required,email"`
-	Senha string `json:"senha" binding:"required,min=8"`
-}
 
-type AdminResponse struct {
-	ID      int    `json:"id"`
-	Nome    string `json:"nome"`
-	Email   string `json:"email"`
-	IsAdmin bool   `json:"is_admin"`
-	Token   string `json:"token"`
+	SenhaHash string `json:"-"`
 }
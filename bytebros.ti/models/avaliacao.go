@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Status de moderação de uma avaliação. Só as aprovadas aparecem na loja e
+// entram na média do produto.
+const (
+	AvaliacaoPendente = "pendente"
+	AvaliacaoAprovada = "aprovada"
+	AvaliacaoOculta   = "oculta"
+)
+
+// Avaliacao é a nota de 1 a 5, com comentário, de um cliente que recebeu o
+// produto. Cada cliente avalia um produto uma única vez.
+type Avaliacao struct {
+	ID        int `json:"id"`
+	ProdutoID int `json:"produto_id"`
+	// ClienteEmail só é exposto na moderação.
+	ClienteEmail string `json:"cliente_email,omitempty"`
+	// Autor é o primeiro nome do cliente, exibido na loja.
+	Autor       string     `json:"autor"`
+	Nota        int        `json:"nota"`
+	Comentario  string     `json:"comentario"`
+	Status      string     `json:"status"`
+	CriadoEm    time.Time  `json:"criado_em"`
+	ModeradoEm  *time.Time `json:"moderado_em,omitempty"`
+	ModeradoPor string     `json:"moderado_por,omitempty"`
+}
+
+type AvaliacaoRequest struct {
+	Nota       int    `json:"nota" binding:"required,min=1,max=5"`
+	Comentario string `json:"comentario" binding:"required,max=2000"`
+}
+
+type ModerarAvaliacaoRequest struct {
+	Status string `json:"status" binding:"required,oneof=aprovada oculta"`
+}
+
+// ResumoAvaliacoes é a média e o total das avaliações aprovadas.
+type ResumoAvaliacoes struct {
+	Media float64 `json:"media"`
+	Total int     `json:"total"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// AvisoEstoque é o pedido de um cliente para ser avisado por email quando
+// o produto voltar ao estoque. NotificadoEm fica nil até o envio.
+type AvisoEstoque struct {
+	ID           int        `json:"id"`
+	ProdutoID    int        `json:"produto_id"`
+	Email        string     `json:"email"`
+	CriadoEm     time.Time  `json:"criado_em"`
+	NotificadoEm *time.Time `json:"notificado_em"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// ProdutoBusca é um produto encontrado por GET /api/produtos/busca, com a
+// relevância usada na ordenação padrão.
+type ProdutoBusca struct {
+	Produto
+	Relevancia float64 `json:"relevancia"`
+}
+
+// FacetaPreco conta os resultados de uma faixa de preço. Max nil indica a
+// última faixa, sem teto; o intervalo é [Min, Max).
+type FacetaPreco struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Total int      `json:"total"`
+}
+
+// FacetaCategoria conta os resultados ligados diretamente à categoria.
+type FacetaCategoria struct {
+	CategoriaID int    `json:"categoria_id"`
+	Nome        string `json:"nome"`
+	Slug        string `json:"slug"`
+	Total       int    `json:"total"`
+}
+
+type FacetasBusca struct {
+	Precos     []FacetaPreco     `json:"precos"`
+	Categorias []FacetaCategoria `json:"categorias"`
+	EmEstoque  int               `json:"em_estoque"`
+	SemEstoque int               `json:"sem_estoque"`
+}
+
+type ResultadoBusca struct {
+	Produtos []ProdutoBusca `json:"produtos"`
+	Total    int            `json:"total"`
+	Facetas  FacetasBusca   `json:"facetas"`
+}
@@ -0,0 +1,22 @@
+package models
+
+type Categoria struct {
+	ID    int    `json:"id"`
+	Nome  string `json:"nome"`
+	Slug  string `json:"slug"`
+	PaiID *int   `json:"pai_id"`
+}
+
+type CategoriaRequest struct {
+	Nome  string `json:"nome" binding:"required,min=2"`
+	Slug  string `json:"slug"`
+	PaiID *int   `json:"pai_id"`
+}
+
+// CategoriaArvore é um nó de GET /api/categorias. TotalProdutos conta os
+// produtos da categoria e das subcategorias, sem repetir.
+type CategoriaArvore struct {
+	Categoria
+	TotalProdutos int               `json:"total_produtos"`
+	Subcategorias []CategoriaArvore `json:"subcategorias"`
+}
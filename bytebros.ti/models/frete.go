@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ItemFreteRequest é um item do carrinho a cotar.
+type ItemFreteRequest struct {
+	ProdutoID  int `json:"produto_id" binding:"required,min=1"`
+	Quantidade int `json:"quantidade" binding:"required,min=1"`
+}
+
+type CotacaoFreteRequest struct {
+	CEP   string             `json:"cep" binding:"required"`
+	Itens []ItemFreteRequest `json:"itens" binding:"required,min=1,max=100,dive"`
+}
+
+// OpcaoFrete é uma opção de entrega cotada. O pedido informa o ID em
+// cotacao_frete_id; a opção vale até ExpiraEm e para um único pedido.
+type OpcaoFrete struct {
+	ID             string  `json:"id"`
+	CEPDestino     string  `json:"cep"`
+	Transportadora string  `json:"transportadora"`
+	Servico        string  `json:"servico"`
+	Nome           string  `json:"nome"`
+	Valor          float64 `json:"valor"`
+	// PrazoDias é contado em dias úteis; PrazoEntrega é a data prevista,
+	// no formato dd/mm/aaaa.
+	PrazoDias    int    `json:"prazo_dias"`
+	PrazoEntrega string `json:"prazo_entrega"`
+	// Itens identifica o carrinho cotado, como "3:1,12:2" (produto:quantidade
+	// em ordem de produto).
+	Itens    string    `json:"-"`
+	PedidoID *int      `json:"-"`
+	CriadoEm time.Time `json:"-"`
+	ExpiraEm time.Time `json:"expira_em"`
+}
+
+// CotacaoFrete é a resposta de POST /api/frete/cotacao, com as opções da
+// mais barata para a mais cara.
+type CotacaoFrete struct {
+	CEP          string       `json:"cep"`
+	PesoCobradoG int          `json:"peso_cobrado_g"`
+	Opcoes       []OpcaoFrete `json:"opcoes"`
+}
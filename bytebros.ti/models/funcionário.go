@@ -1,11 +1,16 @@
 package models
 
+import "time"
+
 type Funcionario struct {
 	ID    int    `json:"id"`
 	Nome  string `json:"nome" binding:"required,min=3"`
 	Cargo string `json:"cargo" binding:"required"`
 	Email string `json:"email" binding:"required,email"`
 	Senha string `json:"senha" binding:"required,min=6"`
+
+	CriadoEm  time.Time `json:"criado_em"`
+	SenhaHash string    `json:"-"`
 }
 
 type FuncionarioRequest struct {
@@ -15,15 +20,11 @@ type FuncionarioRequest struct {
 	Senha string `json:"senha"`
 }
 
-type FuncionarioLogin struct {
-	Email string `json:"email" binding:"required,email"`
-	Senha string `json:"senha" binding:"required,min=6"`
-}
-
 type FuncionarioResponse struct {
-	ID    int    `json:"id"`
-	Nome  string `json:"nome"`
-	Cargo string `json:"cargo"`
-	Email string `json:"email"`
-	Token string `json:"token,omitempty"`
+	ID           int    `json:"id"`
+	Nome         string `json:"nome"`
+	Cargo        string `json:"cargo"`
+	Email        string `json:"email"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
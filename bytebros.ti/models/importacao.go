@@ -0,0 +1,21 @@
+package models
+
+// ErroImportacao é um problema numa linha da planilha importada. Linha
+// conta como no editor de planilhas: o cabeçalho é a linha 1.
+type ErroImportacao struct {
+	Linha  int    `json:"linha"`
+	Coluna string `json:"coluna,omitempty"`
+	Erro   string `json:"erro"`
+}
+
+// ResultadoImportacao resume uma importação do catálogo. Com erros nada é
+// gravado; na simulação as contagens mostram o que seria feito.
+type ResultadoImportacao struct {
+	Simulacao            bool             `json:"simulacao"`
+	Linhas               int              `json:"linhas"`
+	ProdutosCriados      int              `json:"produtos_criados"`
+	ProdutosAtualizados  int              `json:"produtos_atualizados"`
+	VariantesCriadas     int              `json:"variantes_criadas"`
+	VariantesAtualizadas int              `json:"variantes_atualizadas"`
+	Erros                []ErroImportacao `json:"erros"`
+}
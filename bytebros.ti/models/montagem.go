@@ -0,0 +1,83 @@
+package models
+
+import "strings"
+
+// Componentes de PC reconhecidos pela verificação de montagem.
+const (
+	ComponenteCPU           = "cpu"
+	ComponentePlacaMae      = "placa_mae"
+	ComponenteMemoria       = "memoria"
+	ComponenteFonte         = "fonte"
+	ComponenteGabinete      = "gabinete"
+	ComponenteGPU           = "gpu"
+	ComponenteArmazenamento = "armazenamento"
+	ComponenteCooler        = "cooler"
+)
+
+// Especificacoes são os atributos técnicos de um componente de PC. Cada
+// componente usa só os campos que fazem sentido para ele:
+//
+//   - cpu: socket, chipsets (placas suportadas sem atualizar a BIOS), tdp_w
+//   - placa_mae: socket, chipset, tipo_memoria, slots_memoria, formato
+//   - memoria: tipo_memoria, modulos (pentes no kit)
+//   - cooler: sockets, tdp_w (calor que consegue dissipar)
+//   - gabinete: formatos_suportados
+//   - fonte: potencia_w
+//   - gpu: tdp_w
+type Especificacoes struct {
+	// Componente vazio, na edição do produto, remove as especificações.
+	Componente         string   `json:"componente" binding:"omitempty,oneof=cpu placa_mae memoria fonte gabinete gpu armazenamento cooler"`
+	Socket             string   `json:"socket,omitempty" binding:"max=30"`
+	Sockets            []string `json:"sockets,omitempty" binding:"max=30,dive,max=30"`
+	Chipset            string   `json:"chipset,omitempty" binding:"max=30"`
+	Chipsets           []string `json:"chipsets,omitempty" binding:"max=50,dive,max=30"`
+	TipoMemoria        string   `json:"tipo_memoria,omitempty" binding:"max=10"`
+	SlotsMemoria       int      `json:"slots_memoria,omitempty" binding:"min=0,max=16"`
+	Modulos            int      `json:"modulos,omitempty" binding:"min=0,max=16"`
+	TDP                int      `json:"tdp_w,omitempty" binding:"min=0,max=2000"`
+	Formato            string   `json:"formato,omitempty" binding:"max=30"`
+	FormatosSuportados []string `json:"formatos_suportados,omitempty" binding:"max=10,dive,max=30"`
+	Potencia           int      `json:"potencia_w,omitempty" binding:"min=0,max=5000"`
+}
+
+// Normalizar grava sockets, chipsets e tipo de memória em maiúsculas, como
+// "AM5" e "DDR5", para que a comparação não dependa de como foram digitados.
+func (e *Especificacoes) Normalizar() {
+	e.Socket = strings.ToUpper(strings.TrimSpace(e.Socket))
+	e.Chipset = strings.ToUpper(strings.TrimSpace(e.Chipset))
+	e.TipoMemoria = strings.ToUpper(strings.TrimSpace(e.TipoMemoria))
+	e.Formato = strings.TrimSpace(e.Formato)
+	for i := range e.Sockets {
+		e.Sockets[i] = strings.ToUpper(strings.TrimSpace(e.Sockets[i]))
+	}
+	for i := range e.Chipsets {
+		e.Chipsets[i] = strings.ToUpper(strings.TrimSpace(e.Chipsets[i]))
+	}
+	for i := range e.FormatosSuportados {
+		e.FormatosSuportados[i] = strings.TrimSpace(e.FormatosSuportados[i])
+	}
+}
+
+// MontagemRequest lista os produtos da montagem; um id repetido conta como
+// mais uma unidade (dois kits de memória, por exemplo).
+type MontagemRequest struct {
+	ProdutoIDs []int `json:"produto_ids" binding:"required,min=1,max=30,dive,min=1"`
+}
+
+// ProblemaMontagem é uma incompatibilidade (erro) ou um alerta (aviso),
+// com os produtos envolvidos.
+type ProblemaMontagem struct {
+	Codigo     string `json:"codigo"`
+	Mensagem   string `json:"mensagem"`
+	ProdutoIDs []int  `json:"produto_ids"`
+}
+
+// ResultadoMontagem é a resposta da verificação. Compativel é falso se
+// houver qualquer erro; avisos não impedem o pedido.
+type ResultadoMontagem struct {
+	Compativel           bool               `json:"compativel"`
+	Erros                []ProblemaMontagem `json:"erros"`
+	Avisos               []ProblemaMontagem `json:"avisos"`
+	ConsumoEstimadoW     int                `json:"consumo_estimado_w"`
+	PotenciaRecomendadaW int                `json:"potencia_recomendada_w"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Tipos de movimento de estoque.
+const (
+	MovimentoEntrada     = "entrada"
+	MovimentoSaidaPedido = "saida_pedido"
+	MovimentoAjuste      = "ajuste"
+	MovimentoDevolucao   = "devolucao"
+	MovimentoPerda       = "perda"
+)
+
+// MovimentoEstoque é uma linha do livro-razão do estoque. Quantidade tem
+// sinal (negativa nas saídas) e a soma dos movimentos de um produto é o
+// estoque dele. Movimentos não são alterados nem apagados.
+type MovimentoEstoque struct {
+	ID         int    `json:"id"`
+	ProdutoID  int    `json:"produto_id"`
+	VarianteID *int   `json:"variante_id"`
+	Tipo       string `json:"tipo"`
+	Quantidade int    `json:"quantidade"`
+	// Saldo é o estoque do produto logo depois do movimento e SaldoVariante,
+	// o da variante, quando houver.
+	Saldo         int       `json:"saldo"`
+	SaldoVariante *int      `json:"saldo_variante,omitempty"`
+	Motivo        string    `json:"motivo"`
+	PedidoID      *int      `json:"pedido_id,omitempty"`
+	Usuario       string    `json:"usuario"`
+	CriadoEm      time.Time `json:"criado_em"`
+}
+
+// MovimentoEstoqueRequest lança um movimento manual. Em entrada e perda a
+// quantidade é sempre positiva e o tipo define o sinal; no ajuste ela é o
+// quanto somar ao estoque (negativa para reduzir).
+type MovimentoEstoqueRequest struct {
+	ProdutoID  int    `json:"produto_id" binding:"required"`
+	Tipo       string `json:"tipo" binding:"required,oneof=entrada ajuste perda"`
+	Quantidade int    `json:"quantidade" binding:"required"`
+	VarianteID *int   `json:"variante_id"`
+	Motivo     string `json:"motivo" binding:"required,max=255"`
+}
+
+// ResumoEstoque consolida os movimentos de um produto em um período:
+// saldo antes do período, soma por tipo dentro dele e saldo ao final.
+type ResumoEstoque struct {
+	ProdutoID    int            `json:"produto_id"`
+	NomeProduto  string         `json:"nome_produto"`
+	SaldoInicial int            `json:"saldo_inicial"`
+	Movimentos   map[string]int `json:"movimentos"`
+	SaldoFinal   int            `json:"saldo_final"`
+}
+
+// DivergenciaEstoque aponta um produto ou variante cuja quantidade não
+// bate com a soma dos movimentos.
+type DivergenciaEstoque struct {
+	ProdutoID   int    `json:"produto_id"`
+	VarianteID  *int   `json:"variante_id,omitempty"`
+	NomeProduto string `json:"nome_produto"`
+	SKU         string `json:"sku,omitempty"`
+	Quantidade  int    `json:"quantidade"`
+	SaldoRazao  int    `json:"saldo_movimentos"`
+}
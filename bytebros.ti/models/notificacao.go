@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// NotificacaoEstoqueBaixo é aberta quando um produto chega ao estoque
+// mínimo e resolvida quando ele volta a ficar acima.
+const NotificacaoEstoqueBaixo = "estoque_baixo"
+
+// NotificacaoAdmin é um aviso para a equipe no painel administrativo.
+type NotificacaoAdmin struct {
+	ID          int        `json:"id"`
+	Tipo        string     `json:"tipo"`
+	ProdutoID   *int       `json:"produto_id,omitempty"`
+	Titulo      string     `json:"titulo"`
+	Mensagem    string     `json:"mensagem"`
+	CriadoEm    time.Time  `json:"criado_em"`
+	LidaEm      *time.Time `json:"lida_em"`
+	LidaPor     string     `json:"lida_por,omitempty"`
+	ResolvidaEm *time.Time `json:"resolvida_em"`
+}
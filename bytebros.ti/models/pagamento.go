@@ -0,0 +1,137 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Formas de pagamento com cobrança gerada pela loja. As demais continuam
+// sendo combinadas à parte.
+const (
+	FormaPagamentoPix     = "pix"
+	FormaPagamentoCredito = "credito"
+	FormaPagamentoBoleto  = "boleto"
+)
+
+// NormalizarFormaPagamento devolve a forma em minúsculas e sem espaços nas
+// pontas, como ela é gravada no pedido.
+func NormalizarFormaPagamento(forma string) string {
+	return strings.ToLower(strings.TrimSpace(forma))
+}
+
+// Ciclo de vida de uma cobrança.
+const (
+	StatusPagamentoPendente = "pendente"
+	StatusPagamentoPago     = "pago"
+	StatusPagamentoExpirado = "expirado"
+)
+
+// Status só do cartão: a autorização reserva o valor no limite, a captura
+// o cobra (status "pago"). "estornado" é o estorno total; em
+// "estorno_pendente" um estorno foi pedido e o gateway ainda não respondeu,
+// ou a resposta ainda não foi gravada.
+const (
+	StatusPagamentoAutorizado      = "autorizado"
+	StatusPagamentoRecusado        = "recusado"
+	StatusPagamentoCancelado       = "cancelado"
+	StatusPagamentoEstornado       = "estornado"
+	StatusPagamentoEstornoPendente = "estorno_pendente"
+)
+
+// Pagamento é uma cobrança de um pedido. Um pedido pode acumular várias,
+// por exemplo um Pix expirado seguido de outro.
+type Pagamento struct {
+	ID       int     `json:"id"`
+	PedidoID int     `json:"pedido_id"`
+	Metodo   string  `json:"metodo"`
+	Status   string  `json:"status"`
+	Valor    float64 `json:"valor"`
+	// TxID, CopiaECola e QRCodeURL só existem no Pix; QRCodeURL não é
+	// gravada, é montada a partir do txid.
+	TxID       string `json:"txid,omitempty"`
+	CopiaECola string `json:"copia_e_cola,omitempty"`
+	QRCodeURL  string `json:"qr_code_url,omitempty"`
+	// EndToEndID é o identificador do Pix recebido, informado pelo PSP.
+	EndToEndID   string     `json:"end_to_end_id,omitempty"`
+	ExpiraEm     *time.Time `json:"expira_em,omitempty"`
+	PagoEm       *time.Time `json:"pago_em,omitempty"`
+	CriadoEm     time.Time  `json:"criado_em"`
+	AtualizadoEm time.Time  `json:"atualizado_em"`
+
+	// Campos do cartão. Valor inclui os juros do parcelamento, quando há.
+	Gateway        string  `json:"gateway,omitempty"`
+	GatewayID      string  `json:"gateway_id,omitempty"`
+	Parcelas       int     `json:"parcelas,omitempty"`
+	ValorParcela   float64 `json:"valor_parcela,omitempty"`
+	Bandeira       string  `json:"bandeira,omitempty"`
+	FinalCartao    string  `json:"final_cartao,omitempty"`
+	ValorEstornado float64 `json:"valor_estornado,omitempty"`
+	// EstornoPendente é o valor do estorno em andamento. A referência é a
+	// chave de idempotência no gateway; ela, o motivo e quem pediu ficam
+	// gravados para que o estorno seja retomado igual depois de uma falha.
+	EstornoPendente   float64 `json:"estorno_pendente,omitempty"`
+	EstornoReferencia string  `json:"-"`
+	EstornoMotivo     string  `json:"-"`
+	EstornoUsuario    string  `json:"-"`
+	// Transacoes só é preenchida na listagem da equipe.
+	Transacoes []TransacaoPagamento `json:"transacoes,omitempty"`
+
+	// Campos do boleto. NossoNumero tem 11 dígitos, sem a carteira e o
+	// dígito verificador impressos; BoletoURL, como QRCodeURL, não é gravada.
+	NossoNumero      string     `json:"nosso_numero,omitempty"`
+	LinhaDigitavel   string     `json:"linha_digitavel,omitempty"`
+	CodigoBarras     string     `json:"codigo_barras,omitempty"`
+	Vencimento       *time.Time `json:"vencimento,omitempty"`
+	DocumentoPagador string     `json:"documento_pagador,omitempty"`
+	BoletoURL        string     `json:"boleto_url,omitempty"`
+}
+
+// Operações feitas no gateway de cartão.
+const (
+	TransacaoAutorizacao  = "autorizacao"
+	TransacaoCaptura      = "captura"
+	TransacaoEstorno      = "estorno"
+	TransacaoCancelamento = "cancelamento"
+)
+
+// TransacaoPagamento registra uma chamada ao gateway, aprovada ou não.
+type TransacaoPagamento struct {
+	ID          int       `json:"id"`
+	PagamentoID int       `json:"pagamento_id"`
+	Tipo        string    `json:"tipo"`
+	Valor       float64   `json:"valor"`
+	Sucesso     bool      `json:"sucesso"`
+	GatewayID   string    `json:"gateway_id,omitempty"`
+	Mensagem    string    `json:"mensagem,omitempty"`
+	Usuario     string    `json:"usuario"`
+	CriadoEm    time.Time `json:"criado_em"`
+}
+
+// OpcaoParcelamento é uma linha da tabela de parcelas do cartão.
+type OpcaoParcelamento struct {
+	Parcelas     int     `json:"parcelas"`
+	ValorParcela float64 `json:"valor_parcela"`
+	Total        float64 `json:"total"`
+	// TaxaMensal é zero nas parcelas sem juros.
+	TaxaMensal float64 `json:"taxa_mensal"`
+	SemJuros   bool    `json:"sem_juros"`
+}
+
+// ResultadoRetornoBoleto é o que aconteceu com um título liquidado no
+// arquivo de retorno do banco.
+type ResultadoRetornoBoleto struct {
+	Linha       int     `json:"linha"`
+	NossoNumero string  `json:"nosso_numero"`
+	ValorPago   float64 `json:"valor_pago"`
+	PedidoID    *int    `json:"pedido_id,omitempty"`
+	// Resultado é "liquidado", "ja_pago", "nao_encontrado" ou
+	// "valor_divergente".
+	Resultado string `json:"resultado"`
+}
+
+// EstornoRequest é o corpo do estorno pela equipe. Sem Valor, estorna o
+// que ainda não foi devolvido.
+type EstornoRequest struct {
+	Valor  *float64 `json:"valor" binding:"omitempty,gt=0"`
+	Motivo string   `json:"motivo" binding:"required,max=255"`
+}
@@ -1,6 +1,67 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// Ciclo de vida de um pedido de loja.
+const (
+	StatusPedidoProcessando = "Processando"
+	StatusPedidoPago        = "Pago"
+	StatusPedidoSeparando   = "Separando"
+	StatusPedidoEnviado     = "Enviado"
+	StatusPedidoEntregue    = "Entregue"
+	StatusPedidoCancelado   = "Cancelado"
+	StatusPedidoDevolvido   = "Devolvido"
+)
+
+// transicoesPedido lista, para cada status, os próximos status permitidos.
+var transicoesPedido = map[string][]string{
+	StatusPedidoProcessando: {StatusPedidoPago, StatusPedidoCancelado},
+	StatusPedidoPago:        {StatusPedidoSeparando, StatusPedidoCancelado},
+	StatusPedidoSeparando:   {StatusPedidoEnviado, StatusPedidoCancelado},
+	StatusPedidoEnviado:     {StatusPedidoEntregue, StatusPedidoDevolvido},
+	StatusPedidoEntregue:    {StatusPedidoDevolvido},
+	StatusPedidoCancelado:   {},
+	StatusPedidoDevolvido:   {},
+}
+
+// NormalizarStatusPedido devolve a grafia oficial do status, ignorando
+// maiúsculas/minúsculas, e se ele faz parte do ciclo de vida.
+func NormalizarStatusPedido(status string) (string, bool) {
+	for s := range transicoesPedido {
+		if strings.EqualFold(s, strings.TrimSpace(status)) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// ProximosStatusPedido devolve os status para os quais o pedido pode ir. O
+// status atual passa por NormalizarStatusPedido, como o gravado em outra
+// grafia ("pago", "ENVIADO").
+func ProximosStatusPedido(status string) []string {
+	atual, _ := NormalizarStatusPedido(status)
+	return transicoesPedido[atual]
+}
+
+// TransicaoPedidoPermitida informa se o pedido pode passar de um status a
+// outro; de é normalizado como em ProximosStatusPedido.
+func TransicaoPedidoPermitida(de, para string) bool {
+	for _, s := range ProximosStatusPedido(de) {
+		if s == para {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusPedidoDevolveEstoque informa se, ao entrar neste status, os itens
+// do pedido voltam para o estoque.
+func StatusPedidoDevolveEstoque(status string) bool {
+	return status == StatusPedidoCancelado || status == StatusPedidoDevolvido
+}
 
 type Pedido struct {
 	ID              int          `json:"id"`
@@ -21,24 +82,99 @@ type PedidoItem struct {
 	ID            int     `json:"id"`
 	PedidoID      int     `json:"pedido_id"`
 	ProdutoID     int     `json:"produto_id"`
+	VarianteID    *int    `json:"variante_id"`
+	SKU           string  `json:"sku,omitempty"`
 	NomeProduto   string  `json:"nome_produto"`
 	Quantidade    int     `json:"quantidade"`
 	ValorUnitario float64 `json:"valor_unitario"`
 }
 
 type CriarPedidoRequest struct {
-	Itens           []PedidoItemRequest `json:"itens" binding:"required"`
+	Itens           []PedidoItemRequest `json:"itens" binding:"required,min=1,dive"`
 	EnderecoEntrega string              `json:"endereco_entrega" binding:"required"`
-	TipoFrete       string              `json:"tipo_frete" binding:"required"`
-	ValorFrete      float64             `json:"valor_frete" binding:"required,min=0"`
-	ValorTotal      float64             `json:"valor_total" binding:"required,min=0"`
-	FormaPagamento  string              `json:"forma_pagamento" binding:"required"`
-	PrazoEntrega    string              `json:"prazo_entrega"`
+	// CEPEntrega é o CEP do endereço de entrega, que precisa ser o mesmo da
+	// cotação de frete.
+	CEPEntrega string `json:"cep_entrega" binding:"required"`
+	// CotacaoFreteID é o id de uma opção de POST /api/frete/cotacao; o tipo,
+	// o valor e o prazo do frete vêm dela. ValorFrete e PrazoEntrega servem
+	// só para conferência.
+	CotacaoFreteID string  `json:"cotacao_frete_id" binding:"required"`
+	ValorFrete     float64 `json:"valor_frete" binding:"min=0"`
+	ValorTotal     float64 `json:"valor_total" binding:"min=0"`
+	FormaPagamento string  `json:"forma_pagamento" binding:"required"`
+	PrazoEntrega   string  `json:"prazo_entrega"`
+
+	// Só no cartão ("credito"): o token gerado pelo SDK do gateway no
+	// navegador, que nunca envia o número do cartão à loja, e o número de
+	// parcelas (padrão 1).
+	CartaoToken string `json:"cartao_token" binding:"max=255"`
+	Parcelas    int    `json:"parcelas" binding:"omitempty,min=1,max=24"`
+
+	// DocumentoPagador é o CPF ou CNPJ impresso no boleto, obrigatório com
+	// forma_pagamento "boleto".
+	DocumentoPagador string `json:"documento_pagador" binding:"max=20"`
 }
 
 type PedidoItemRequest struct {
-	ProdutoID     int     `json:"produto_id" binding:"required"`
-	NomeProduto   string  `json:"nome_produto" binding:"required"`
+	ProdutoID int `json:"produto_id" binding:"required"`
+	// VarianteID é obrigatório para produtos com variantes.
+	VarianteID    *int    `json:"variante_id"`
+	NomeProduto   string  `json:"nome_produto"`
 	Quantidade    int     `json:"quantidade" binding:"required,min=1"`
-	ValorUnitario float64 `json:"valor_unitario" binding:"required,min=0"`
+	ValorUnitario float64 `json:"valor_unitario" binding:"min=0"`
+}
+
+// DivergenciaPedido descreve uma linha do pedido cujo valor informado pelo
+// cliente não bate com o calculado pelo servidor a partir do catálogo.
+type DivergenciaPedido struct {
+	Linha     int     `json:"linha"`
+	ProdutoID int     `json:"produto_id,omitempty"`
+	Campo     string  `json:"campo"`
+	Informado float64 `json:"informado"`
+	Esperado  float64 `json:"esperado"`
+}
+
+// EstoqueInsuficiente indica um produto (ou variante) cujo estoque não cobre
+// a quantidade pedida.
+type EstoqueInsuficiente struct {
+	ProdutoID   int    `json:"produto_id"`
+	VarianteID  *int   `json:"variante_id,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	NomeProduto string `json:"nome_produto"`
+	Solicitado  int    `json:"solicitado"`
+	Disponivel  int    `json:"disponivel"`
+}
+
+type AtualizarStatusPedidoRequest struct {
+	Status     string `json:"status" binding:"required"`
+	Observacao string `json:"observacao"`
+}
+
+// CancelarPedidoRequest é o corpo do cancelamento pelo cliente; o motivo
+// vai para o histórico do pedido.
+type CancelarPedidoRequest struct {
+	Motivo string `json:"motivo" binding:"required,max=500"`
+}
+
+// DevolucaoCancelamento é o que aconteceu com uma cobrança paga quando o
+// pedido foi cancelado.
+type DevolucaoCancelamento struct {
+	PagamentoID int     `json:"pagamento_id"`
+	Metodo      string  `json:"metodo"`
+	Valor       float64 `json:"valor"`
+	// Resultado é "estornado", "estorno_recusado" (a equipe estorna depois),
+	// "estorno_pendente" (o gateway não respondeu; o estorno é retomado) ou
+	// "manual" (Pix e boleto, devolvidos pela equipe fora da loja).
+	Resultado string `json:"resultado"`
+	Motivo    string `json:"motivo,omitempty"`
+}
+
+type PedidoStatusHistorico struct {
+	ID             int       `json:"id"`
+	PedidoID       int       `json:"pedido_id"`
+	StatusAnterior string    `json:"status_anterior,omitempty"`
+	StatusNovo     string    `json:"status_novo"`
+	AlteradoPor    string    `json:"alterado_por"`
+	Observacao     string    `json:"observacao,omitempty"`
+	AlteradoEm     time.Time `json:"alterado_em"`
 }
@@ -0,0 +1,10 @@
+package models
+
+type PapelPermissoes struct {
+	Papel      string   `json:"papel"`
+	Permissoes []string `json:"permissoes"`
+}
+
+type AtualizarPermissoesPapelRequest struct {
+	Permissoes []string `json:"permissoes" binding:"required"`
+}
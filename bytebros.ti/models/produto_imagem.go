@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ProdutoImagem é uma das imagens de um produto, exibidas em ordem
+// crescente de Ordem. URLs traz uma entrada por versão: "original" e os
+// tamanhos reduzidos ("miniatura", "media", "grande").
+type ProdutoImagem struct {
+	ID          int               `json:"id"`
+	ProdutoID   int               `json:"produto_id"`
+	Ordem       int               `json:"ordem"`
+	URLs        map[string]string `json:"urls"`
+	ContentType string            `json:"content_type"`
+	Largura     int               `json:"largura"`
+	Altura      int               `json:"altura"`
+	CriadoEm    time.Time         `json:"criado_em"`
+
+	// Chaves guarda onde cada versão está no storage, para removê-las.
+	Chaves map[string]string `json:"-"`
+}
+
+// ReordenarImagensRequest traz todos os ids de imagens do produto na nova
+// ordem.
+type ReordenarImagensRequest struct {
+	IDs []int `json:"ids" binding:"required,min=1"`
+}
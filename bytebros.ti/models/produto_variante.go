@@ -0,0 +1,53 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProdutoVariante é uma versão vendável de um produto (por exemplo 16 GB
+// preto). Quando um produto tem variantes, o estoque é controlado por
+// variante e produtos.quantidade passa a ser a soma delas.
+type ProdutoVariante struct {
+	ID        int               `json:"id"`
+	ProdutoID int               `json:"produto_id"`
+	SKU       string            `json:"sku"`
+	Atributos map[string]string `json:"atributos"`
+	// Preco substitui o preço do produto; nil usa o do produto.
+	Preco      *float64 `json:"preco"`
+	Quantidade int      `json:"quantidade"`
+	// PrecoEfetivo é o preço cobrado, já considerando o do produto.
+	PrecoEfetivo float64 `json:"preco_efetivo"`
+}
+
+// PrecoPara devolve o preço da variante ou, sem sobrescrita, o preço
+// vigente do produto, com a promoção dele.
+func (v ProdutoVariante) PrecoPara(p Produto, agora time.Time) float64 {
+	if v.Preco != nil {
+		return *v.Preco
+	}
+	return p.PrecoVigente(agora)
+}
+
+// Descricao junta os atributos em ordem alfabética de nome, como
+// "16GB, Preto", para compor o nome do item no pedido.
+func (v ProdutoVariante) Descricao() string {
+	nomes := make([]string, 0, len(v.Atributos))
+	for nome := range v.Atributos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	valores := make([]string, 0, len(nomes))
+	for _, nome := range nomes {
+		valores = append(valores, v.Atributos[nome])
+	}
+	return strings.Join(valores, ", ")
+}
+
+type ProdutoVarianteRequest struct {
+	SKU        string            `json:"sku" binding:"required,max=64"`
+	Atributos  map[string]string `json:"atributos" binding:"required,min=1"`
+	Preco      *float64          `json:"preco" binding:"omitempty,min=0.01"`
+	Quantidade int               `json:"quantidade" binding:"min=0"`
+}
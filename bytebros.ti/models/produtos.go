@@ -1,6 +1,9 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 type Produto struct {
 	ID         int            `json:"id"`
@@ -10,6 +13,48 @@ type Produto struct {
 	Oferta     bool           `json:"oferta"`
 	Detalhes   sql.NullString `json:"details"`
 	Imagem     sql.NullString `json:"image"`
+	// Promocao, quando existe, controla Oferta: o agendador de promoções
+	// liga e desliga a oferta conforme a vigência.
+	Promocao *Promocao `json:"promocao,omitempty"`
+	// PrecoDe e PrecoPor só aparecem com a promoção em vigor.
+	PrecoDe  *float64 `json:"preco_de,omitempty"`
+	PrecoPor *float64 `json:"preco_por,omitempty"`
+	// EstoqueMinimo é o ponto de reposição: com quantidade igual ou abaixo
+	// dele, o monitor de estoque abre um alerta para a equipe.
+	EstoqueMinimo int `json:"estoque_minimo"`
+	// Especificacoes só existe em componentes de PC.
+	Especificacoes *Especificacoes `json:"especificacoes,omitempty"`
+	// Embalagem é usada na cotação de frete.
+	Embalagem Embalagem `json:"embalagem"`
+
+	CategoriaIDs []int `json:"categoria_ids"`
+
+	// Avaliacoes resume as avaliações aprovadas, nas listagens e no detalhe.
+	Avaliacoes ResumoAvaliacoes `json:"avaliacoes"`
+
+	// Variantes e Opcoes só são preenchidas em GET /api/produtos/{id}.
+	// Opcoes lista, por atributo, os valores existentes nas variantes.
+	Variantes []ProdutoVariante   `json:"variantes,omitempty"`
+	Opcoes    map[string][]string `json:"opcoes,omitempty"`
+}
+
+// PrecoVigente é o preço cobrado no instante, já com a promoção.
+func (p Produto) PrecoVigente(agora time.Time) float64 {
+	return precoVigente(p.Preco, p.Promocao, agora)
+}
+
+// AplicarPromocao preenche PrecoDe e PrecoPor se a promoção estiver em vigor.
+func (p *Produto) AplicarPromocao(agora time.Time) {
+	p.PrecoDe, p.PrecoPor = dePor(p.Preco, p.Promocao, agora)
+}
+
+// Embalagem é o peso e as medidas do produto já embalado. Campos zerados
+// não foram informados e a cotação de frete usa a embalagem padrão.
+type Embalagem struct {
+	PesoG         int `json:"peso_g" binding:"min=0,max=100000"`
+	AlturaCm      int `json:"altura_cm" binding:"min=0,max=300"`
+	LarguraCm     int `json:"largura_cm" binding:"min=0,max=300"`
+	ComprimentoCm int `json:"comprimento_cm" binding:"min=0,max=300"`
 }
 
 type ProdutoRequest struct {
@@ -19,4 +64,15 @@ type ProdutoRequest struct {
 	Oferta     bool    `json:"oferta"`
 	Detalhes   string  `json:"details"`
 	Imagem     string  `json:"image"`
+	// Promocao ausente mantém a atual na edição; {} remove. Com promoção,
+	// Oferta é ignorado.
+	Promocao *Promocao `json:"promocao"`
+	// EstoqueMinimo ausente vale 0 no cadastro e mantém o atual na edição.
+	EstoqueMinimo *int `json:"estoque_minimo" binding:"omitempty,min=0"`
+	// Especificacoes ausente mantém as atuais na edição; {} remove.
+	Especificacoes *Especificacoes `json:"especificacoes"`
+	// Embalagem ausente mantém a atual na edição.
+	Embalagem *Embalagem `json:"embalagem"`
+	// CategoriaIDs ausente mantém as categorias atuais na edição; [] remove todas.
+	CategoriaIDs []int `json:"categoria_ids"`
 }
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Promocao é o preço promocional de um produto ou serviço. Sem Inicio a
+// promoção vale desde já; sem Fim, até ser removida.
+type Promocao struct {
+	// Preco zerado, na edição, remove a promoção.
+	Preco  float64    `json:"preco" binding:"omitempty,min=0.01"`
+	Inicio *time.Time `json:"inicio"`
+	Fim    *time.Time `json:"fim"`
+}
+
+// Ativa informa se a promoção está em vigor no instante.
+func (p *Promocao) Ativa(agora time.Time) bool {
+	if p == nil || p.Preco <= 0 {
+		return false
+	}
+	if p.Inicio != nil && agora.Before(*p.Inicio) {
+		return false
+	}
+	return p.Fim == nil || agora.Before(*p.Fim)
+}
+
+// precoVigente devolve o preço promocional, se a promoção estiver em vigor,
+// ou o preço normal.
+func precoVigente(preco float64, p *Promocao, agora time.Time) float64 {
+	if p.Ativa(agora) {
+		return p.Preco
+	}
+	return preco
+}
+
+// dePor devolve o "de/por" exibido na loja, ou nil fora de promoção.
+func dePor(preco float64, p *Promocao, agora time.Time) (*float64, *float64) {
+	if !p.Ativa(agora) {
+		return nil, nil
+	}
+	de, por := preco, p.Preco
+	return &de, &por
+}
+
+// HistoricoPreco registra o preço e a promoção de um produto ou serviço a
+// cada alteração. Só um entre ProdutoID e ServicoID é preenchido.
+type HistoricoPreco struct {
+	ID          int       `json:"id"`
+	ProdutoID   *int      `json:"produto_id,omitempty"`
+	ServicoID   *int      `json:"servico_id,omitempty"`
+	Preco       float64   `json:"preco"`
+	Promocao    *Promocao `json:"promocao"`
+	AlteradoPor string    `json:"alterado_por"`
+	CriadoEm    time.Time `json:"criado_em"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromocaoAtiva(t *testing.T) {
+	inicio := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	fim := time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	janela := &Promocao{Preco: 90, Inicio: &inicio, Fim: &fim}
+
+	casos := []struct {
+		nome  string
+		p     *Promocao
+		agora time.Time
+		ativa bool
+	}{
+		{"sem promoção", nil, inicio, false},
+		{"preço zerado", &Promocao{Preco: 0}, inicio, false},
+		{"sem datas", &Promocao{Preco: 90}, inicio, true},
+		{"antes do início", janela, inicio.Add(-time.Nanosecond), false},
+		{"no início", janela, inicio, true},
+		{"dentro da janela", janela, inicio.Add(48 * time.Hour), true},
+		{"um instante antes do fim", janela, fim.Add(-time.Nanosecond), true},
+		{"no fim", janela, fim, false},
+		{"depois do fim", janela, fim.Add(time.Hour), false},
+		{"só início, depois dele", &Promocao{Preco: 90, Inicio: &inicio}, fim.AddDate(1, 0, 0), true},
+		{"só fim, antes dele", &Promocao{Preco: 90, Fim: &fim}, inicio.AddDate(-1, 0, 0), true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if ativa := caso.p.Ativa(caso.agora); ativa != caso.ativa {
+				t.Errorf("Ativa(%s) = %v, esperado %v", caso.agora, ativa, caso.ativa)
+			}
+		})
+	}
+}
+
+func TestProdutoPrecoVigente(t *testing.T) {
+	inicio := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	fim := time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	p := Produto{Preco: 100, Promocao: &Promocao{Preco: 79.9, Inicio: &inicio, Fim: &fim}}
+
+	casos := []struct {
+		agora time.Time
+		preco float64
+		dePor bool
+	}{
+		{inicio.Add(-time.Minute), 100, false},
+		{inicio, 79.9, true},
+		{fim.Add(-time.Minute), 79.9, true},
+		{fim, 100, false},
+	}
+	for _, caso := range casos {
+		if preco := p.PrecoVigente(caso.agora); preco != caso.preco {
+			t.Errorf("PrecoVigente(%s) = %.2f, esperado %.2f", caso.agora, preco, caso.preco)
+		}
+		p.AplicarPromocao(caso.agora)
+		switch {
+		case !caso.dePor && (p.PrecoDe != nil || p.PrecoPor != nil):
+			t.Errorf("em %s: de/por = %v/%v, esperado vazio", caso.agora, p.PrecoDe, p.PrecoPor)
+		case caso.dePor && (p.PrecoDe == nil || p.PrecoPor == nil || *p.PrecoDe != 100 || *p.PrecoPor != 79.9):
+			t.Errorf("em %s: de/por = %v/%v, esperado 100/79.9", caso.agora, p.PrecoDe, p.PrecoPor)
+		}
+	}
+}
@@ -1,11 +1,23 @@
 package models
 
+import "time"
+
 type Servico struct {
 	ID       int     `json:"id"`
 	Nome     string  `json:"nome" binding:"required,min=3"`
 	Preco    float64 `json:"preco" binding:"required,min=0.01"`
 	Oferta   bool    `json:"oferta"`
 	Detalhes string  `json:"detalhes" binding:"required,min=10"`
+	// Promocao, quando existe, controla Oferta, como em Produto.
+	Promocao *Promocao `json:"promocao,omitempty"`
+	// PrecoDe e PrecoPor só aparecem com a promoção em vigor.
+	PrecoDe  *float64 `json:"preco_de,omitempty"`
+	PrecoPor *float64 `json:"preco_por,omitempty"`
+}
+
+// AplicarPromocao preenche PrecoDe e PrecoPor se a promoção estiver em vigor.
+func (s *Servico) AplicarPromocao(agora time.Time) {
+	s.PrecoDe, s.PrecoPor = dePor(s.Preco, s.Promocao, agora)
 }
 
 type ServicoRequest struct {
@@ -13,4 +25,6 @@ type ServicoRequest struct {
 	Preco    float64 `json:"preco"`
 	Oferta   bool    `json:"oferta"`
 	Detalhes string  `json:"detalhes"`
+	// Promocao ausente mantém a atual na edição; {} remove.
+	Promocao *Promocao `json:"promocao"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	Todos        bool   `json:"todos"`
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken é o registro de um refresh token emitido. Só o hash do token
+// é guardado.
+type RefreshToken struct {
+	ID         int
+	TokenHash  string
+	Tipo       string
+	SujeitoID  int
+	ExpiraEm   time.Time
+	RevogadoEm *time.Time
+}
+
+// RedefinicaoSenha é um pedido de redefinição de senha enviado por email.
+type RedefinicaoSenha struct {
+	ID        int
+	UsuarioID int
+	TokenHash string
+	ExpiraEm  time.Time
+	UsadoEm   *time.Time
+}
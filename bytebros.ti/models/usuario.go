@@ -1,11 +1,16 @@
 package models
 
+import "time"
+
 type Usuario struct {
 	ID       int    `json:"id"`
 	Nome     string `json:"nome_completo" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Senha    string `json:"senha" binding:"required,min=6"`
 	Telefone string `json:"telefone"`
+
+	CriadoEm  time.Time `json:"criado_em"`
+	SenhaHash string    `json:"-"`
 }
 
 type LoginRequest struct {
@@ -14,11 +19,25 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	ID       int    `json:"id"`
-	Nome     string `json:"nome"`
-	Email    string `json:"email"`
-	Token    string `json:"token,omitempty"`
-	Telefone string `json:"telefone,omitempty"`
+	ID           int    `json:"id"`
+	Nome         string `json:"nome"`
+	Email        string `json:"email"`
+	Tipo         string `json:"tipo"`
+	Papel        string `json:"papel"`
+	Cargo        string `json:"cargo,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Telefone     string `json:"telefone,omitempty"`
+}
+
+type EsqueciSenhaRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type RedefinirSenhaRequest struct {
+	Token          string `json:"token" binding:"required"`
+	NovaSenha      string `json:"nova_senha" binding:"required,min=6"`
+	ConfirmarSenha string `json:"confirmar_senha" binding:"required"`
 }
 
 type AtualizarEmailRequest struct {
@@ -0,0 +1,282 @@
+// Package montagem verifica a compatibilidade entre os componentes de uma
+// montagem de PC a partir das especificações cadastradas nos produtos.
+package montagem
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"bytebros.ti/models"
+)
+
+// Consumo estimado, em watts, dos componentes que não informam TDP.
+const (
+	consumoPlacaMae      = 50
+	consumoModulo        = 5
+	consumoArmazenamento = 10
+	consumoCooler        = 5
+	consumoGabinete      = 10
+)
+
+// folgaFonte é a margem sobre o consumo estimado usada para recomendar a
+// potência da fonte; o resultado é arredondado para cima em passos de 50 W.
+const (
+	folgaFonte    = 1.3
+	passoPotencia = 50
+)
+
+// unicos são os componentes que só podem aparecer uma vez na montagem.
+var unicos = []string{
+	models.ComponenteCPU,
+	models.ComponentePlacaMae,
+	models.ComponenteFonte,
+	models.ComponenteGabinete,
+}
+
+// essenciais são os componentes sem os quais o PC não liga.
+var essenciais = []string{
+	models.ComponenteCPU,
+	models.ComponentePlacaMae,
+	models.ComponenteMemoria,
+	models.ComponenteFonte,
+}
+
+var nomes = map[string]string{
+	models.ComponenteCPU:           "processador",
+	models.ComponentePlacaMae:      "placa-mãe",
+	models.ComponenteMemoria:       "memória",
+	models.ComponenteFonte:         "fonte",
+	models.ComponenteGabinete:      "gabinete",
+	models.ComponenteGPU:           "placa de vídeo",
+	models.ComponenteArmazenamento: "armazenamento",
+	models.ComponenteCooler:        "cooler",
+}
+
+type verificacao struct {
+	resultado models.ResultadoMontagem
+	porTipo   map[string][]models.Produto
+}
+
+func (v *verificacao) erro(codigo, mensagem string, produtos ...models.Produto) {
+	v.resultado.Erros = append(v.resultado.Erros, problema(codigo, mensagem, produtos))
+}
+
+func (v *verificacao) aviso(codigo, mensagem string, produtos ...models.Produto) {
+	v.resultado.Avisos = append(v.resultado.Avisos, problema(codigo, mensagem, produtos))
+}
+
+// unico devolve o componente do tipo quando há exatamente um na montagem.
+func (v *verificacao) unico(tipo string) (models.Produto, bool) {
+	lista := v.porTipo[tipo]
+	if len(lista) != 1 {
+		return models.Produto{}, false
+	}
+	return lista[0], true
+}
+
+func problema(codigo, mensagem string, produtos []models.Produto) models.ProblemaMontagem {
+	ids := make([]int, 0, len(produtos))
+	vistos := make(map[int]bool)
+	for _, p := range produtos {
+		if !vistos[p.ID] {
+			vistos[p.ID] = true
+			ids = append(ids, p.ID)
+		}
+	}
+	return models.ProblemaMontagem{Codigo: codigo, Mensagem: mensagem, ProdutoIDs: ids}
+}
+
+// Validar confere os produtos da montagem, um por unidade: um produto
+// repetido conta como duas peças. Produtos sem especificações só geram aviso.
+func Validar(produtos []models.Produto) models.ResultadoMontagem {
+	v := &verificacao{
+		resultado: models.ResultadoMontagem{
+			Erros:  []models.ProblemaMontagem{},
+			Avisos: []models.ProblemaMontagem{},
+		},
+		porTipo: make(map[string][]models.Produto),
+	}
+
+	var semEspecificacoes []models.Produto
+	for _, p := range produtos {
+		if p.Especificacoes == nil {
+			semEspecificacoes = append(semEspecificacoes, p)
+			continue
+		}
+		v.porTipo[p.Especificacoes.Componente] = append(v.porTipo[p.Especificacoes.Componente], p)
+	}
+	if len(semEspecificacoes) > 0 {
+		v.aviso("sem_especificacoes", "Produtos sem especificações técnicas não foram verificados", semEspecificacoes...)
+	}
+
+	for _, tipo := range unicos {
+		if lista := v.porTipo[tipo]; len(lista) > 1 {
+			v.erro("componente_repetido", fmt.Sprintf("A montagem só pode ter um(a) %s", nomes[tipo]), lista...)
+		}
+	}
+	for _, tipo := range essenciais {
+		if len(v.porTipo[tipo]) == 0 {
+			v.aviso("componente_ausente", fmt.Sprintf("A montagem não tem %s", nomes[tipo]))
+		}
+	}
+
+	v.verificarProcessador()
+	v.verificarMemoria()
+	v.verificarCooler()
+	v.verificarGabinete()
+	v.verificarFonte()
+
+	v.resultado.Compativel = len(v.resultado.Erros) == 0
+	return v.resultado
+}
+
+func (v *verificacao) verificarProcessador() {
+	cpu, okCPU := v.unico(models.ComponenteCPU)
+	placa, okPlaca := v.unico(models.ComponentePlacaMae)
+	if !okCPU || !okPlaca {
+		return
+	}
+	ce, pe := cpu.Especificacoes, placa.Especificacoes
+	if ce.Socket != "" && pe.Socket != "" && !strings.EqualFold(ce.Socket, pe.Socket) {
+		v.erro("socket_incompativel",
+			fmt.Sprintf("O processador usa o socket %s e a placa-mãe, o %s", ce.Socket, pe.Socket), cpu, placa)
+		return
+	}
+	if len(ce.Chipsets) > 0 && pe.Chipset != "" && !contem(ce.Chipsets, pe.Chipset) {
+		v.aviso("chipset_nao_listado",
+			fmt.Sprintf("O chipset %s não está entre os suportados pelo processador; pode ser preciso atualizar a BIOS", pe.Chipset), cpu, placa)
+	}
+}
+
+func (v *verificacao) verificarMemoria() {
+	memorias := v.porTipo[models.ComponenteMemoria]
+	placa, ok := v.unico(models.ComponentePlacaMae)
+	if !ok || len(memorias) == 0 {
+		return
+	}
+	pe := placa.Especificacoes
+
+	var incompativeis []models.Produto
+	modulos := 0
+	for _, m := range memorias {
+		if m.Especificacoes.TipoMemoria != "" && pe.TipoMemoria != "" && !strings.EqualFold(m.Especificacoes.TipoMemoria, pe.TipoMemoria) {
+			incompativeis = append(incompativeis, m)
+		}
+		modulos += modulosDe(m.Especificacoes)
+	}
+	if len(incompativeis) > 0 {
+		v.erro("memoria_incompativel",
+			fmt.Sprintf("A placa-mãe só aceita memória %s", pe.TipoMemoria), append(incompativeis, placa)...)
+	}
+	if pe.SlotsMemoria > 0 && modulos > pe.SlotsMemoria {
+		v.erro("slots_memoria_insuficientes",
+			fmt.Sprintf("A montagem tem %d módulos de memória e a placa-mãe, %d slots", modulos, pe.SlotsMemoria), append(memorias, placa)...)
+	}
+}
+
+func (v *verificacao) verificarCooler() {
+	cpu, ok := v.unico(models.ComponenteCPU)
+	if !ok {
+		return
+	}
+	ce := cpu.Especificacoes
+	for _, cooler := range v.porTipo[models.ComponenteCooler] {
+		e := cooler.Especificacoes
+		if ce.Socket != "" && len(e.Sockets) > 0 && !contem(e.Sockets, ce.Socket) {
+			v.erro("cooler_incompativel",
+				fmt.Sprintf("O cooler não é compatível com o socket %s", ce.Socket), cooler, cpu)
+			continue
+		}
+		if e.TDP > 0 && ce.TDP > 0 && e.TDP < ce.TDP {
+			v.aviso("cooler_subdimensionado",
+				fmt.Sprintf("O cooler dissipa %d W e o processador tem TDP de %d W", e.TDP, ce.TDP), cooler, cpu)
+		}
+	}
+}
+
+func (v *verificacao) verificarGabinete() {
+	gabinete, okGabinete := v.unico(models.ComponenteGabinete)
+	placa, okPlaca := v.unico(models.ComponentePlacaMae)
+	if !okGabinete || !okPlaca {
+		return
+	}
+	formato := placa.Especificacoes.Formato
+	suportados := gabinete.Especificacoes.FormatosSuportados
+	if formato == "" || len(suportados) == 0 {
+		return
+	}
+	for _, f := range suportados {
+		if strings.EqualFold(f, formato) {
+			return
+		}
+	}
+	v.erro("formato_incompativel",
+		fmt.Sprintf("O gabinete não comporta placas-mãe %s", formato), gabinete, placa)
+}
+
+func (v *verificacao) verificarFonte() {
+	consumo := 0
+	for tipo, lista := range v.porTipo {
+		for _, p := range lista {
+			consumo += consumoDe(tipo, p.Especificacoes)
+		}
+	}
+	recomendada := 0
+	if consumo > 0 {
+		recomendada = int(math.Ceil(float64(consumo)*folgaFonte/passoPotencia)) * passoPotencia
+	}
+	v.resultado.ConsumoEstimadoW = consumo
+	v.resultado.PotenciaRecomendadaW = recomendada
+
+	fonte, ok := v.unico(models.ComponenteFonte)
+	if !ok || fonte.Especificacoes.Potencia == 0 {
+		return
+	}
+	potencia := fonte.Especificacoes.Potencia
+	switch {
+	case potencia < consumo:
+		v.erro("fonte_insuficiente",
+			fmt.Sprintf("A fonte tem %d W e o consumo estimado é de %d W", potencia, consumo), fonte)
+	case potencia < recomendada:
+		v.aviso("fonte_abaixo_recomendado",
+			fmt.Sprintf("A fonte tem %d W; o recomendado para esta montagem é %d W", potencia, recomendada), fonte)
+	}
+}
+
+func consumoDe(tipo string, e *models.Especificacoes) int {
+	switch tipo {
+	case models.ComponenteCPU, models.ComponenteGPU:
+		return e.TDP
+	case models.ComponentePlacaMae:
+		return consumoPlacaMae
+	case models.ComponenteMemoria:
+		return consumoModulo * modulosDe(e)
+	case models.ComponenteArmazenamento:
+		return consumoArmazenamento
+	case models.ComponenteCooler:
+		return consumoCooler
+	case models.ComponenteGabinete:
+		return consumoGabinete
+	}
+	return 0
+}
+
+// modulosDe considera um pente quando o kit não informa a quantidade.
+func modulosDe(e *models.Especificacoes) int {
+	if e.Modulos > 0 {
+		return e.Modulos
+	}
+	return 1
+}
+
+// contem ignora maiúsculas e minúsculas, como a comparação de formatos:
+// especificações gravadas antes de Normalizar podem ter "am5" ou "ddr5".
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if strings.EqualFold(item, valor) {
+			return true
+		}
+	}
+	return false
+}
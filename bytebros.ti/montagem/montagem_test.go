@@ -0,0 +1,176 @@
+package montagem
+
+import (
+	"slices"
+	"testing"
+
+	"bytebros.ti/models"
+)
+
+// montagemBase é uma montagem compatível: consumo de 390 W (processador
+// 105, placa-mãe 50, dois pentes 10, placa de vídeo 200, armazenamento 10,
+// cooler 5 e gabinete 10), fonte recomendada de 550 W e fonte de 650 W.
+func montagemBase() []models.Produto {
+	return []models.Produto{
+		{ID: 1, Especificacoes: &models.Especificacoes{Componente: models.ComponenteCPU, Socket: "AM5", Chipsets: []string{"B650", "X670"}, TDP: 105}},
+		{ID: 2, Especificacoes: &models.Especificacoes{Componente: models.ComponentePlacaMae, Socket: "AM5", Chipset: "B650", TipoMemoria: "DDR5", SlotsMemoria: 4, Formato: "ATX"}},
+		{ID: 3, Especificacoes: &models.Especificacoes{Componente: models.ComponenteMemoria, TipoMemoria: "DDR5", Modulos: 2}},
+		{ID: 4, Especificacoes: &models.Especificacoes{Componente: models.ComponenteFonte, Potencia: 650}},
+		{ID: 5, Especificacoes: &models.Especificacoes{Componente: models.ComponenteGabinete, FormatosSuportados: []string{"ATX", "Micro-ATX"}}},
+		{ID: 6, Especificacoes: &models.Especificacoes{Componente: models.ComponenteGPU, TDP: 200}},
+		{ID: 7, Especificacoes: &models.Especificacoes{Componente: models.ComponenteArmazenamento}},
+		{ID: 8, Especificacoes: &models.Especificacoes{Componente: models.ComponenteCooler, Sockets: []string{"AM4", "AM5"}, TDP: 150}},
+	}
+}
+
+// especificacoes devolve, para alterar, as especificações do produto id.
+func especificacoes(produtos []models.Produto, id int) *models.Especificacoes {
+	for _, p := range produtos {
+		if p.ID == id {
+			return p.Especificacoes
+		}
+	}
+	return nil
+}
+
+func semProduto(produtos []models.Produto, id int) []models.Produto {
+	return slices.DeleteFunc(produtos, func(p models.Produto) bool { return p.ID == id })
+}
+
+func codigos(problemas []models.ProblemaMontagem) []string {
+	lista := make([]string, 0, len(problemas))
+	for _, p := range problemas {
+		lista = append(lista, p.Codigo)
+	}
+	return lista
+}
+
+func TestValidar(t *testing.T) {
+	casos := []struct {
+		nome   string
+		ajuste func(ps []models.Produto) []models.Produto
+		erros  []string
+		avisos []string
+		// ids esperados no primeiro problema, quando informados.
+		ids []int
+	}{
+		{"compatível", func(ps []models.Produto) []models.Produto { return ps }, nil, nil, nil},
+		{"socket em minúsculas", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 2).Socket = "am5"
+			especificacoes(ps, 8).Sockets = []string{"am5"}
+			especificacoes(ps, 3).TipoMemoria = "ddr5"
+			especificacoes(ps, 2).Chipset = "b650"
+			return ps
+		}, nil, nil, nil},
+		{"socket incompatível", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 2).Socket = "LGA1700"
+			return ps
+		}, []string{"socket_incompativel"}, nil, []int{1, 2}},
+		{"chipset não listado", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 2).Chipset = "A620"
+			return ps
+		}, nil, []string{"chipset_nao_listado"}, []int{1, 2}},
+		{"memória incompatível", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 3).TipoMemoria = "DDR4"
+			return ps
+		}, []string{"memoria_incompativel"}, nil, []int{3, 2}},
+		{"slots preenchidos", func(ps []models.Produto) []models.Produto {
+			// Dois kits de 2 ocupam os 4 slots.
+			return append(ps, ps[2])
+		}, nil, nil, nil},
+		{"slots insuficientes", func(ps []models.Produto) []models.Produto {
+			// Três kits de 2 pedem 6 slots; o produto repetido aparece uma vez.
+			return append(ps, ps[2], ps[2])
+		}, []string{"slots_memoria_insuficientes"}, nil, []int{3, 2}},
+		{"cooler de outro socket", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 8).Sockets = []string{"LGA1700"}
+			return ps
+		}, []string{"cooler_incompativel"}, nil, []int{8, 1}},
+		{"cooler subdimensionado", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 8).TDP = 65
+			return ps
+		}, nil, []string{"cooler_subdimensionado"}, []int{8, 1}},
+		{"formato incompatível", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 5).FormatosSuportados = []string{"Mini-ITX"}
+			return ps
+		}, []string{"formato_incompativel"}, nil, []int{5, 2}},
+		{"formato em minúsculas", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 2).Formato = "micro-atx"
+			return ps
+		}, nil, nil, nil},
+		{"fonte insuficiente", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 4).Potencia = 389
+			return ps
+		}, []string{"fonte_insuficiente"}, nil, []int{4}},
+		{"fonte igual ao consumo", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 4).Potencia = 390
+			return ps
+		}, nil, []string{"fonte_abaixo_recomendado"}, []int{4}},
+		{"fonte igual à recomendada", func(ps []models.Produto) []models.Produto {
+			especificacoes(ps, 4).Potencia = 550
+			return ps
+		}, nil, nil, nil},
+		{"processador repetido", func(ps []models.Produto) []models.Produto {
+			// Com dois processadores, socket e cooler não são verificados.
+			outro := models.Produto{ID: 9, Especificacoes: &models.Especificacoes{Componente: models.ComponenteCPU, Socket: "LGA1700", TDP: 65}}
+			return append(ps, outro)
+		}, []string{"componente_repetido"}, nil, []int{1, 9}},
+		{"sem memória", func(ps []models.Produto) []models.Produto {
+			return semProduto(ps, 3)
+		}, nil, []string{"componente_ausente"}, nil},
+		{"sem especificações", func(ps []models.Produto) []models.Produto {
+			return append(ps, models.Produto{ID: 10})
+		}, nil, []string{"sem_especificacoes"}, []int{10}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			resultado := Validar(caso.ajuste(montagemBase()))
+			erros, avisos := codigos(resultado.Erros), codigos(resultado.Avisos)
+			if !slices.Equal(erros, caso.erros) {
+				t.Errorf("erros = %v, esperado %v", erros, caso.erros)
+			}
+			if !slices.Equal(avisos, caso.avisos) {
+				t.Errorf("avisos = %v, esperado %v", avisos, caso.avisos)
+			}
+			if resultado.Compativel != (len(caso.erros) == 0) {
+				t.Errorf("compativel = %v com erros %v", resultado.Compativel, erros)
+			}
+			if caso.ids != nil {
+				primeiro := append(resultado.Erros, resultado.Avisos...)[0]
+				if !slices.Equal(primeiro.ProdutoIDs, caso.ids) {
+					t.Errorf("produtos de %s = %v, esperado %v", primeiro.Codigo, primeiro.ProdutoIDs, caso.ids)
+				}
+			}
+		})
+	}
+}
+
+func TestValidarConsumo(t *testing.T) {
+	casos := []struct {
+		nome        string
+		produtos    []models.Produto
+		consumo     int
+		recomendada int
+	}{
+		{"vazia", nil, 0, 0},
+		{"base", montagemBase(), 390, 550},
+		// Só a estimativa da placa-mãe: 50 W com folga dão 65, arredondados a 100.
+		{"só placa-mãe", montagemBase()[1:2], 50, 100},
+		// 500 W com folga dão 650 exatos, sem subir mais um passo.
+		{"múltiplo exato", []models.Produto{
+			{ID: 1, Especificacoes: &models.Especificacoes{Componente: models.ComponenteCPU, TDP: 125}},
+			{ID: 2, Especificacoes: &models.Especificacoes{Componente: models.ComponentePlacaMae}},
+			{ID: 3, Especificacoes: &models.Especificacoes{Componente: models.ComponenteMemoria}},
+			{ID: 6, Especificacoes: &models.Especificacoes{Componente: models.ComponenteGPU, TDP: 320}},
+		}, 500, 650},
+		// Produtos sem especificações não entram na conta.
+		{"sem especificações", []models.Produto{{ID: 1}}, 0, 0},
+	}
+	for _, caso := range casos {
+		resultado := Validar(caso.produtos)
+		if resultado.ConsumoEstimadoW != caso.consumo || resultado.PotenciaRecomendadaW != caso.recomendada {
+			t.Errorf("%s: consumo %d W e recomendada %d W, esperado %d e %d", caso.nome,
+				resultado.ConsumoEstimadoW, resultado.PotenciaRecomendadaW, caso.consumo, caso.recomendada)
+		}
+	}
+}
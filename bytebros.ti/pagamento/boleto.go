@@ -0,0 +1,300 @@
+package pagamento
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDocumentoInvalido = errors.New("CPF ou CNPJ inválido")
+	ErrVencimento        = errors.New("vencimento fora da faixa do fator de vencimento")
+	ErrValorBoleto       = errors.New("valor do boleto fora da faixa aceita")
+)
+
+// ConfigBoleto é a conta de cobrança do beneficiário. O campo livre do
+// código de barras segue o layout do Bradesco (237): agência, carteira,
+// nosso número, conta e um zero.
+type ConfigBoleto struct {
+	Banco    string
+	Agencia  string // 4 dígitos
+	Conta    string // 7 dígitos, sem o dígito verificador
+	Carteira string // 2 dígitos
+
+	Beneficiario string
+	// Documento é o CNPJ do beneficiário, só com os dígitos.
+	Documento string
+	Endereco  string
+
+	// DiasVencimento conta a partir da emissão. Depois do vencimento, o
+	// banco ainda leva DiasBaixa para avisar no arquivo de retorno, e só
+	// então a cobrança expira.
+	DiasVencimento int
+	DiasBaixa      int
+	Instrucoes     string
+}
+
+// Boleto são os dados de cobrança de um boleto emitido.
+type Boleto struct {
+	// NossoNumero tem 11 dígitos, sem o dígito verificador.
+	NossoNumero    string
+	Vencimento     time.Time
+	Centavos       int64
+	CodigoBarras   string
+	LinhaDigitavel string
+}
+
+// BoletoDoAmbiente lê a conta de cobrança. Sem BOLETO_AGENCIA o boleto
+// fica desligado e a função devolve nil.
+//
+// BOLETO_CONTA, BOLETO_BENEFICIARIO e BOLETO_CNPJ são obrigatórios;
+// BOLETO_CARTEIRA tem padrão "09", BOLETO_DIAS_VENCIMENTO padrão 3 e
+// BOLETO_DIAS_BAIXA padrão 5. BOLETO_ENDERECO e BOLETO_INSTRUCOES vão
+// impressos no boleto.
+func BoletoDoAmbiente() (*ConfigBoleto, error) {
+	agencia := strings.TrimSpace(os.Getenv("BOLETO_AGENCIA"))
+	if agencia == "" {
+		return nil, nil
+	}
+	c := &ConfigBoleto{
+		Banco:          "237",
+		Agencia:        agencia,
+		Conta:          strings.TrimSpace(os.Getenv("BOLETO_CONTA")),
+		Carteira:       strings.TrimSpace(os.Getenv("BOLETO_CARTEIRA")),
+		Beneficiario:   strings.TrimSpace(os.Getenv("BOLETO_BENEFICIARIO")),
+		Endereco:       strings.TrimSpace(os.Getenv("BOLETO_ENDERECO")),
+		Instrucoes:     strings.TrimSpace(os.Getenv("BOLETO_INSTRUCOES")),
+		DiasVencimento: 3,
+		DiasBaixa:      5,
+	}
+	if c.Carteira == "" {
+		c.Carteira = "09"
+	}
+	if c.Instrucoes == "" {
+		c.Instrucoes = "Não receber após o vencimento."
+	}
+	if !soDigitos(c.Agencia, 4) || !soDigitos(c.Conta, 7) || !soDigitos(c.Carteira, 2) {
+		return nil, errors.New("BOLETO_AGENCIA, BOLETO_CONTA e BOLETO_CARTEIRA precisam de 4, 7 e 2 dígitos")
+	}
+	if c.Beneficiario == "" {
+		return nil, errors.New("BOLETO_BENEFICIARIO é obrigatório com BOLETO_AGENCIA")
+	}
+	cnpj, err := NormalizarDocumento(os.Getenv("BOLETO_CNPJ"))
+	if err != nil || len(cnpj) != 14 {
+		return nil, fmt.Errorf("BOLETO_CNPJ inválido: %q", os.Getenv("BOLETO_CNPJ"))
+	}
+	c.Documento = cnpj
+
+	dias := []struct {
+		nome    string
+		destino *int
+	}{
+		{"BOLETO_DIAS_VENCIMENTO", &c.DiasVencimento},
+		{"BOLETO_DIAS_BAIXA", &c.DiasBaixa},
+	}
+	for _, v := range dias {
+		if texto := os.Getenv(v.nome); texto != "" {
+			n, err := strconv.Atoi(texto)
+			if err != nil || n < 0 || n > 60 {
+				return nil, fmt.Errorf("%s inválido: %q", v.nome, texto)
+			}
+			*v.destino = n
+		}
+	}
+	return c, nil
+}
+
+// Vencimento é a data de vencimento de um boleto emitido agora, passada
+// para segunda-feira se cair no fim de semana.
+func (c *ConfigBoleto) Vencimento(emissao time.Time) time.Time {
+	v := time.Date(emissao.Year(), emissao.Month(), emissao.Day(), 0, 0, 0, 0, emissao.Location()).AddDate(0, 0, c.DiasVencimento)
+	for v.Weekday() == time.Saturday || v.Weekday() == time.Sunday {
+		v = v.AddDate(0, 0, 1)
+	}
+	return v
+}
+
+// Expiracao é quando a cobrança deixa de esperar o retorno do banco: o
+// fim do dia do vencimento mais DiasBaixa.
+func (c *ConfigBoleto) Expiracao(vencimento time.Time) time.Time {
+	return vencimento.AddDate(0, 0, 1+c.DiasBaixa)
+}
+
+// Gerar monta o boleto com o nosso número da sequência informada.
+func (c *ConfigBoleto) Gerar(sequencia int64, vencimento time.Time, centavos int64) (Boleto, error) {
+	b := Boleto{NossoNumero: fmt.Sprintf("%011d", sequencia), Vencimento: vencimento, Centavos: centavos}
+	if sequencia <= 0 || len(b.NossoNumero) != 11 {
+		return Boleto{}, fmt.Errorf("nosso número fora da faixa: %d", sequencia)
+	}
+	if centavos <= 0 || centavos > 99_999_999_99 {
+		return Boleto{}, ErrValorBoleto
+	}
+	fator, err := FatorVencimento(vencimento)
+	if err != nil {
+		return Boleto{}, err
+	}
+
+	campoLivre := c.Agencia + c.Carteira + b.NossoNumero + c.Conta + "0"
+	// O dígito geral (posição 5) é calculado sobre os outros 43.
+	semDV := c.Banco + "9" + fmt.Sprintf("%04d%010d", fator, centavos) + campoLivre
+	b.CodigoBarras = semDV[:4] + dvCodigoBarras(semDV) + semDV[4:]
+	b.LinhaDigitavel = LinhaDigitavel(b.CodigoBarras)
+	return b, nil
+}
+
+// NossoNumeroImpresso é o nosso número como vai no boleto e no extrato:
+// carteira, número e o dígito verificador em módulo 11 na base 7, que
+// pode ser "P".
+func (c *ConfigBoleto) NossoNumeroImpresso(nossoNumero string) string {
+	return c.Carteira + "/" + nossoNumero + "-" + dvNossoNumero(c.Carteira+nossoNumero)
+}
+
+// CodigoBeneficiario é a agência e a conta no formato "1234 / 0012345".
+func (c *ConfigBoleto) CodigoBeneficiario() string {
+	return c.Agencia + " / " + c.Conta
+}
+
+// BancoComDV é o código do banco com o dígito, como vai no boleto.
+func (c *ConfigBoleto) BancoComDV() string {
+	return "237-2"
+}
+
+// FatorVencimento são os dias desde 07/10/1997, de 1000 a 9999. Em
+// 22/02/2025 o fator chegou a 10000 e recomeçou em 1000, como a FEBRABAN
+// definiu.
+func FatorVencimento(vencimento time.Time) (int, error) {
+	base := time.Date(1997, 10, 7, 0, 0, 0, 0, time.UTC)
+	dia := time.Date(vencimento.Year(), vencimento.Month(), vencimento.Day(), 0, 0, 0, 0, time.UTC)
+	dias := int(dia.Sub(base).Hours() / 24)
+	if dias < 1000 {
+		return 0, ErrVencimento
+	}
+	if dias > 9999 {
+		dias = (dias-1000)%9000 + 1000
+	}
+	return dias, nil
+}
+
+// LinhaDigitavel converte os 44 dígitos do código de barras nos cinco
+// campos digitados no app do banco, cada um dos três primeiros com o seu
+// dígito em módulo 10.
+func LinhaDigitavel(codigoBarras string) string {
+	campo1 := codigoBarras[0:4] + codigoBarras[19:24]
+	campo2 := codigoBarras[24:34]
+	campo3 := codigoBarras[34:44]
+	campo1 += modulo10(campo1)
+	campo2 += modulo10(campo2)
+	campo3 += modulo10(campo3)
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s",
+		campo1[:5], campo1[5:], campo2[:5], campo2[5:], campo3[:5], campo3[5:], codigoBarras[4:5], codigoBarras[5:19])
+}
+
+// modulo10 multiplica os dígitos por 2 e 1 alternados, da direita para a
+// esquerda, somando os algarismos de cada produto.
+func modulo10(digitos string) string {
+	soma, peso := 0, 2
+	for i := len(digitos) - 1; i >= 0; i-- {
+		p := int(digitos[i]-'0') * peso
+		soma += p/10 + p%10
+		peso = 3 - peso
+	}
+	return strconv.Itoa((10 - soma%10) % 10)
+}
+
+// modulo11 multiplica os dígitos pelos pesos de 2 até pesoMax, repetidos
+// da direita para a esquerda, e passa o resto da soma por 11 a dv.
+func modulo11(digitos string, pesoMax int, dv func(resto int) string) string {
+	soma, peso := 0, 2
+	for i := len(digitos) - 1; i >= 0; i-- {
+		soma += int(digitos[i]-'0') * peso
+		if peso++; peso > pesoMax {
+			peso = 2
+		}
+	}
+	return dv(soma % 11)
+}
+
+func dvCodigoBarras(digitos string) string {
+	return modulo11(digitos, 9, func(resto int) string {
+		if dv := 11 - resto; dv >= 2 && dv <= 9 {
+			return strconv.Itoa(dv)
+		}
+		return "1"
+	})
+}
+
+func dvNossoNumero(digitos string) string {
+	return modulo11(digitos, 7, func(resto int) string {
+		switch resto {
+		case 0:
+			return "0"
+		case 1:
+			return "P"
+		}
+		return strconv.Itoa(11 - resto)
+	})
+}
+
+// NormalizarDocumento devolve só os dígitos de um CPF ou CNPJ válido.
+func NormalizarDocumento(documento string) (string, error) {
+	var b strings.Builder
+	for _, r := range documento {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == '/' || r == ' ':
+		default:
+			return "", ErrDocumentoInvalido
+		}
+	}
+	d := b.String()
+	if (len(d) != 11 && len(d) != 14) || strings.Count(d, d[:1]) == len(d) {
+		return "", ErrDocumentoInvalido
+	}
+
+	// Os dois últimos dígitos são verificadores em módulo 11: no CPF com
+	// pesos crescentes sem limite, no CNPJ com pesos de 2 a 9.
+	pesoMax := 9
+	if len(d) == 11 {
+		pesoMax = 11
+	}
+	dv := func(resto int) string {
+		if resto < 2 {
+			return "0"
+		}
+		return strconv.Itoa(11 - resto)
+	}
+	base := d[:len(d)-2]
+	primeiro := modulo11(base, pesoMax, dv)
+	segundo := modulo11(base+primeiro, pesoMax, dv)
+	if d[len(d)-2:] != primeiro+segundo {
+		return "", ErrDocumentoInvalido
+	}
+	return d, nil
+}
+
+// FormatarDocumento põe a pontuação de CPF ou CNPJ.
+func FormatarDocumento(d string) string {
+	switch len(d) {
+	case 11:
+		return d[0:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:11]
+	case 14:
+		return d[0:2] + "." + d[2:5] + "." + d[5:8] + "/" + d[8:12] + "-" + d[12:14]
+	}
+	return d
+}
+
+func soDigitos(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
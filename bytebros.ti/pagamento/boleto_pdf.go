@@ -0,0 +1,174 @@
+package pagamento
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"bytebros.ti/pdf"
+)
+
+// Pagador é quem paga o boleto, como sai impresso.
+type Pagador struct {
+	Nome      string
+	Documento string
+	Endereco  string
+}
+
+const formatoDataBoleto = "02/01/2006"
+
+// PDF desenha o boleto numa página A4: o recibo do pagador em cima e a
+// ficha de compensação, com o código de barras, embaixo. numeroDocumento
+// identifica o pedido.
+func (c *ConfigBoleto) PDF(b Boleto, numeroDocumento string, emissao time.Time, pagador Pagador) ([]byte, error) {
+	doc := pdf.Novo()
+	p := doc.NovaPagina()
+	valor := formatarReais(b.Centavos)
+	nossoNumero := c.NossoNumeroImpresso(b.NossoNumero)
+	vencimento := b.Vencimento.Format(formatoDataBoleto)
+	beneficiario := c.Beneficiario + " - CNPJ " + FormatarDocumento(c.Documento)
+	nomePagador := pagador.Nome
+	if pagador.Documento != "" {
+		nomePagador += " - " + FormatarDocumento(pagador.Documento)
+	}
+
+	// Recibo do pagador.
+	cabecalhoBoleto(p, c, 15, "Recibo do Pagador")
+	campoBoleto(p, 10, 17, 110, "Beneficiário", c.Beneficiario)
+	campoBoleto(p, 120, 17, 40, "CPF/CNPJ", FormatarDocumento(c.Documento))
+	campoBoleto(p, 160, 17, 40, "Vencimento", vencimento)
+	campoBoleto(p, 10, 25, 110, "Pagador", nomePagador)
+	campoBoleto(p, 120, 25, 40, "Nosso número", nossoNumero)
+	campoBoleto(p, 160, 25, 40, "Valor do documento", valor)
+	campoBoleto(p, 10, 33, 60, "Agência / Código do beneficiário", c.CodigoBeneficiario())
+	campoBoleto(p, 70, 33, 50, "Nº do documento", numeroDocumento)
+	campoBoleto(p, 120, 33, 40, "Data do documento", emissao.Format(formatoDataBoleto))
+	campoBoleto(p, 160, 33, 40, "Linha digitável", "")
+	p.Texto(161, 39.5, 6, false, b.LinhaDigitavel)
+	p.Texto(150, 46, 6, false, "Autenticação mecânica")
+
+	for x := 10.0; x < 200; x += 3 {
+		p.Linha(x, 56, x+1.5, 56, 0.2)
+	}
+	p.Texto(170, 55, 5, false, "Corte na linha pontilhada")
+
+	// Ficha de compensação.
+	cabecalhoBoleto(p, c, 73, b.LinhaDigitavel)
+	campoBoleto(p, 10, 75, 140, "Local de pagamento", "Pagável em qualquer banco até o vencimento")
+	campoBoleto(p, 150, 75, 50, "Vencimento", vencimento)
+	campoBoleto(p, 10, 83, 140, "Beneficiário", beneficiario)
+	campoBoleto(p, 150, 83, 50, "Agência / Código do beneficiário", c.CodigoBeneficiario())
+	campoBoleto(p, 10, 91, 30, "Data do documento", emissao.Format(formatoDataBoleto))
+	campoBoleto(p, 40, 91, 35, "Nº do documento", numeroDocumento)
+	campoBoleto(p, 75, 91, 20, "Espécie doc.", "DM")
+	campoBoleto(p, 95, 91, 20, "Aceite", "N")
+	campoBoleto(p, 115, 91, 35, "Data do processamento", emissao.Format(formatoDataBoleto))
+	campoBoleto(p, 150, 91, 50, "Nosso número", nossoNumero)
+	campoBoleto(p, 10, 99, 30, "Uso do banco", "")
+	campoBoleto(p, 40, 99, 20, "Carteira", c.Carteira)
+	campoBoleto(p, 60, 99, 20, "Espécie", "R$")
+	campoBoleto(p, 80, 99, 35, "Quantidade", "")
+	campoBoleto(p, 115, 99, 35, "Valor", "")
+	campoBoleto(p, 150, 99, 50, "(=) Valor do documento", valor)
+
+	p.Linha(10, 107, 10, 139, 0.2)
+	p.Texto(11, 109.5, 6, false, "Instruções (texto de responsabilidade do beneficiário)")
+	for i, linha := range strings.Split(c.Instrucoes, "\n") {
+		if i == 4 {
+			break
+		}
+		p.Texto(11, 114+float64(i)*4.5, 8, false, strings.TrimSpace(linha))
+	}
+	for i, rotulo := range []string{"(-) Desconto / Abatimento", "(+) Mora / Multa", "(+) Outros acréscimos", "(=) Valor cobrado"} {
+		campoBoleto(p, 150, 107+float64(i)*8, 50, rotulo, "")
+	}
+
+	p.Linha(10, 139, 10, 155, 0.2)
+	p.Texto(11, 141.5, 6, false, "Pagador")
+	p.Texto(11, 146, 8, false, nomePagador)
+	p.Texto(11, 150.5, 8, false, pagador.Endereco)
+	p.Linha(10, 155, 200, 155, 0.2)
+	p.Texto(150, 158, 6, false, "Autenticação mecânica - Ficha de Compensação")
+
+	codigoDeBarras(p, 10, 160, 13, b.CodigoBarras)
+
+	var buf bytes.Buffer
+	if err := doc.Escrever(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cabecalhoBoleto escreve o banco e, à direita, o texto da linha (a linha
+// digitável ou o nome da via), com um traço grosso embaixo em y.
+func cabecalhoBoleto(p *pdf.Pagina, c *ConfigBoleto, y float64, texto string) {
+	p.Texto(10, y-1, 13, true, "Bradesco")
+	p.Linha(40, y-6, 40, y, 0.4)
+	p.Texto(42, y-1, 13, true, c.BancoComDV())
+	p.Linha(62, y-6, 62, y, 0.4)
+	p.Texto(65, y-1, 10, true, texto)
+	p.Linha(10, y, 200, y, 0.5)
+}
+
+// campoBoleto desenha uma célula de 8 mm de altura com o rótulo pequeno em
+// cima e o valor embaixo.
+func campoBoleto(p *pdf.Pagina, x, y, largura float64, rotulo, valor string) {
+	p.Linha(x, y, x, y+8, 0.2)
+	p.Linha(x, y+8, x+largura, y+8, 0.2)
+	p.Texto(x+1, y+2.5, 6, false, rotulo)
+	p.Texto(x+1, y+6.5, 9, false, valor)
+}
+
+// Barras do intercalado 2 de 5: cada dígito tem 5 elementos, 2 largos.
+var padroes2de5 = [10]string{"nnwwn", "wnnnw", "nwnnw", "wwnnn", "nnwnw", "wnwnn", "nwwnn", "nnnww", "wnnwn", "nwnwn"}
+
+// codigoDeBarras desenha os 44 dígitos no padrão intercalado 2 de 5 da
+// FEBRABAN: os dígitos vão em pares, o primeiro nas barras e o segundo
+// nos espaços, com as barras largas três vezes as estreitas e 103 mm no
+// total.
+func codigoDeBarras(p *pdf.Pagina, x, y, altura float64, digitos string) {
+	var elementos strings.Builder
+	elementos.WriteString("nnnn")
+	for i := 0; i+1 < len(digitos); i += 2 {
+		barras, espacos := padroes2de5[digitos[i]-'0'], padroes2de5[digitos[i+1]-'0']
+		for j := 0; j < 5; j++ {
+			elementos.WriteByte(barras[j])
+			elementos.WriteByte(espacos[j])
+		}
+	}
+	elementos.WriteString("wnn")
+
+	modulos := 0
+	for _, e := range elementos.String() {
+		modulos += larguraModulos(byte(e))
+	}
+	estreita := 103.0 / float64(modulos)
+	for i, e := range []byte(elementos.String()) {
+		largura := float64(larguraModulos(e)) * estreita
+		if i%2 == 0 {
+			p.Retangulo(x, y, largura, altura)
+		}
+		x += largura
+	}
+}
+
+func larguraModulos(elemento byte) int {
+	if elemento == 'w' {
+		return 3
+	}
+	return 1
+}
+
+// formatarReais escreve centavos como "1.234,56".
+func formatarReais(centavos int64) string {
+	inteiro := fmt.Sprintf("%d", centavos/100)
+	var b strings.Builder
+	for i, r := range inteiro {
+		if i > 0 && (len(inteiro)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return fmt.Sprintf("%s,%02d", b.String(), centavos%100)
+}
@@ -0,0 +1,93 @@
+package pagamento
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Boleto Bradesco publicado como exemplo do padrão FEBRABAN: agência 3381,
+// carteira 26, nosso número 00078271395, conta 0000633, vencimento em
+// 11/06/2018 (fator 7552) e R$ 3.700,00.
+const (
+	exemploBradescoBarras = "23799755200003700003381260007827139500006330"
+	exemploBradescoLinha  = "23793.38128 60007.827136 95000.063305 9 75520000370000"
+)
+
+func TestGerarExemploBradesco(t *testing.T) {
+	c := &ConfigBoleto{Banco: "237", Agencia: "3381", Conta: "0000633", Carteira: "26"}
+	b, err := c.Gerar(78271395, time.Date(2018, 6, 11, 0, 0, 0, 0, time.UTC), 370000)
+	if err != nil {
+		t.Fatalf("gerar: %v", err)
+	}
+	if b.CodigoBarras != exemploBradescoBarras {
+		t.Errorf("código de barras = %s, esperado %s", b.CodigoBarras, exemploBradescoBarras)
+	}
+	if b.LinhaDigitavel != exemploBradescoLinha {
+		t.Errorf("linha digitável = %s, esperado %s", b.LinhaDigitavel, exemploBradescoLinha)
+	}
+}
+
+func TestDigitosDoExemploBradesco(t *testing.T) {
+	// Dígito geral (posição 5) em módulo 11 sobre as outras 43 posições.
+	semDV := exemploBradescoBarras[:4] + exemploBradescoBarras[5:]
+	if dv := dvCodigoBarras(semDV); dv != "9" {
+		t.Errorf("dígito geral = %s, esperado 9", dv)
+	}
+	// Os três primeiros campos da linha, cada um com o seu módulo 10.
+	for _, campo := range []string{"2379338128", "60007827136", "95000063305"} {
+		if dv := modulo10(campo[:len(campo)-1]); dv != campo[len(campo)-1:] {
+			t.Errorf("módulo 10 de %s = %s, esperado %s", campo[:len(campo)-1], dv, campo[len(campo)-1:])
+		}
+	}
+}
+
+func TestDvCodigoBarrasRestosEspeciais(t *testing.T) {
+	// 11 - resto fora de 2 a 9 vira 1; aqui com os restos 0 e 10.
+	for _, digitos := range []string{"0000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000005"} {
+		if dv := dvCodigoBarras(digitos); dv != "1" {
+			t.Errorf("dígito de %s = %s, esperado 1", digitos, dv)
+		}
+	}
+}
+
+func TestFatorVencimento(t *testing.T) {
+	casos := []struct {
+		data  time.Time
+		fator int
+	}{
+		{time.Date(2000, 7, 3, 0, 0, 0, 0, time.UTC), 1000},
+		{time.Date(2018, 6, 11, 0, 0, 0, 0, time.UTC), 7552},
+		// Último dia do primeiro ciclo e a virada definida pela FEBRABAN.
+		{time.Date(2025, 2, 21, 0, 0, 0, 0, time.UTC), 9999},
+		{time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC), 1000},
+		{time.Date(2025, 2, 23, 0, 0, 0, 0, time.UTC), 1001},
+		{time.Date(2026, 10, 18, 23, 59, 0, 0, time.FixedZone("BRT", -3*3600)), 1603},
+	}
+	for _, caso := range casos {
+		fator, err := FatorVencimento(caso.data)
+		if err != nil || fator != caso.fator {
+			t.Errorf("fator de %s = %d, %v; esperado %d", caso.data.Format("02/01/2006"), fator, err, caso.fator)
+		}
+	}
+	if _, err := FatorVencimento(time.Date(2000, 7, 2, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrVencimento) {
+		t.Errorf("fator de 02/07/2000: %v, esperado ErrVencimento", err)
+	}
+}
+
+func TestGerarDepoisDaVirada(t *testing.T) {
+	c := &ConfigBoleto{Banco: "237", Agencia: "3381", Conta: "0000633", Carteira: "09"}
+	b, err := c.Gerar(1, time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC), 15000)
+	if err != nil {
+		t.Fatalf("gerar: %v", err)
+	}
+	if fator := b.CodigoBarras[5:9]; fator != "1000" {
+		t.Errorf("fator no código de barras = %s, esperado 1000", fator)
+	}
+	if valor := b.CodigoBarras[9:19]; valor != "0000015000" {
+		t.Errorf("valor no código de barras = %s, esperado 0000015000", valor)
+	}
+	if _, err := c.Gerar(1, time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC), 0); !errors.Is(err, ErrValorBoleto) {
+		t.Errorf("boleto sem valor: %v, esperado ErrValorBoleto", err)
+	}
+}
@@ -0,0 +1,179 @@
+package pagamento
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts de arquivo de retorno aceitos.
+const (
+	CNAB240 = "cnab240"
+	CNAB400 = "cnab400"
+)
+
+var ErrLayoutCNAB = errors.New("arquivo de retorno fora dos layouts CNAB 240 e CNAB 400")
+
+// Códigos de ocorrência que liquidam o título: liquidação normal, em
+// cartório (só no CNAB 400) e após a baixa.
+var (
+	liquidacao240 = map[string]bool{"06": true, "17": true}
+	liquidacao400 = map[string]bool{"06": true, "15": true, "17": true}
+)
+
+// RetornoCNAB é o conteúdo útil de um arquivo de retorno de cobrança.
+type RetornoCNAB struct {
+	Layout    string
+	Banco     string
+	Registros []RegistroRetorno
+}
+
+// RegistroRetorno é um título do arquivo. Só os liquidados trazem valor
+// pago e data de pagamento.
+type RegistroRetorno struct {
+	Linha       int
+	NossoNumero string
+	Ocorrencia  string
+	Liquidado   bool
+	ValorPago   int64
+	PagoEm      time.Time
+}
+
+// LerRetornoCNAB lê um retorno no layout CNAB 240 da FEBRABAN ou no CNAB
+// 400 do Bradesco, deduzido pelo registro de header.
+func LerRetornoCNAB(r io.Reader) (RetornoCNAB, error) {
+	var linhas []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024), 1024)
+	for scanner.Scan() {
+		linhas = append(linhas, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return RetornoCNAB{}, fmt.Errorf("%w: %v", ErrLayoutCNAB, err)
+	}
+	for len(linhas) > 0 && strings.TrimSpace(linhas[len(linhas)-1]) == "" {
+		linhas = linhas[:len(linhas)-1]
+	}
+	if len(linhas) == 0 {
+		return RetornoCNAB{}, ErrLayoutCNAB
+	}
+
+	header := linhas[0]
+	switch {
+	case len(header) <= 240 && len(header) >= 143 && header[7] == '0' && header[142] == '2':
+		return lerCNAB240(linhas)
+	case len(header) <= 400 && strings.HasPrefix(header, "02RETORNO"):
+		return lerCNAB400(linhas)
+	}
+	return RetornoCNAB{}, ErrLayoutCNAB
+}
+
+func lerCNAB240(linhas []string) (RetornoCNAB, error) {
+	retorno := RetornoCNAB{Layout: CNAB240, Banco: linhas[0][0:3], Registros: []RegistroRetorno{}}
+	// O segmento T traz o título e o U, logo em seguida, os valores pagos.
+	var atual *RegistroRetorno
+	for i, linha := range linhas {
+		if len(linha) > 240 {
+			return retorno, fmt.Errorf("linha %d: mais de 240 posições", i+1)
+		}
+		linha = preencher(linha, 240)
+		if linha[7] != '3' {
+			continue
+		}
+		switch linha[13] {
+		case 'T':
+			ocorrencia := linha[15:17]
+			retorno.Registros = append(retorno.Registros, RegistroRetorno{
+				Linha:       i + 1,
+				NossoNumero: nossoNumeroDoRetorno(linha[37:57]),
+				Ocorrencia:  ocorrencia,
+				Liquidado:   liquidacao240[ocorrencia],
+			})
+			atual = &retorno.Registros[len(retorno.Registros)-1]
+		case 'U':
+			if atual == nil {
+				return retorno, fmt.Errorf("linha %d: segmento U sem segmento T", i+1)
+			}
+			if atual.Liquidado {
+				var err error
+				if atual.ValorPago, err = valorCNAB(linha[77:92]); err != nil {
+					return retorno, fmt.Errorf("linha %d: valor pago: %w", i+1, err)
+				}
+				atual.PagoEm = dataCNAB(linha[137:145], linha[145:153])
+			}
+			atual = nil
+		}
+	}
+	return retorno, nil
+}
+
+func lerCNAB400(linhas []string) (RetornoCNAB, error) {
+	retorno := RetornoCNAB{Layout: CNAB400, Banco: preencher(linhas[0], 400)[76:79], Registros: []RegistroRetorno{}}
+	for i, linha := range linhas {
+		if len(linha) > 400 {
+			return retorno, fmt.Errorf("linha %d: mais de 400 posições", i+1)
+		}
+		linha = preencher(linha, 400)
+		if linha[0] != '1' {
+			continue
+		}
+		ocorrencia := linha[108:110]
+		registro := RegistroRetorno{
+			Linha:       i + 1,
+			NossoNumero: nossoNumeroDoRetorno(linha[70:82]),
+			Ocorrencia:  ocorrencia,
+			Liquidado:   liquidacao400[ocorrencia],
+		}
+		if registro.Liquidado {
+			var err error
+			if registro.ValorPago, err = valorCNAB(linha[253:266]); err != nil {
+				return retorno, fmt.Errorf("linha %d: valor pago: %w", i+1, err)
+			}
+			registro.PagoEm = dataCNAB(linha[110:116], linha[295:301])
+		}
+		retorno.Registros = append(retorno.Registros, registro)
+	}
+	return retorno, nil
+}
+
+// nossoNumeroDoRetorno tira do campo os 11 dígitos do nosso número, que
+// nos dois layouts vêm logo antes do dígito verificador, ignorando a
+// carteira ou os zeros à esquerda.
+func nossoNumeroDoRetorno(campo string) string {
+	campo = strings.TrimSpace(campo)
+	if len(campo) < 12 {
+		return campo
+	}
+	return campo[len(campo)-12 : len(campo)-1]
+}
+
+// valorCNAB lê um valor em centavos, sem vírgula.
+func valorCNAB(campo string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(campo), 10, 64)
+}
+
+// dataCNAB lê a data de ocorrência (DDMMAA ou DDMMAAAA) ou, se ela vier
+// zerada, a data do crédito. Sem nenhuma das duas devolve o instante zero.
+func dataCNAB(campos ...string) time.Time {
+	for _, campo := range campos {
+		formato := "020106"
+		if len(campo) == 8 {
+			formato = "02012006"
+		}
+		if t, err := time.ParseInLocation(formato, campo, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func preencher(linha string, tamanho int) string {
+	if len(linha) < tamanho {
+		return linha + strings.Repeat(" ", tamanho-len(linha))
+	}
+	return linha
+}
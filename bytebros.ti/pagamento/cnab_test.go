@@ -0,0 +1,90 @@
+package pagamento
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Os arquivos em testdata têm três títulos da carteira 09: o 1 liquidado
+// pelo valor, o 2 liquidado a menor e o 3 só com a entrada confirmada.
+func lerRetorno(t *testing.T, arquivo string) RetornoCNAB {
+	t.Helper()
+	f, err := os.Open("testdata/" + arquivo)
+	if err != nil {
+		t.Fatalf("abrir %s: %v", arquivo, err)
+	}
+	defer f.Close()
+	retorno, err := LerRetornoCNAB(f)
+	if err != nil {
+		t.Fatalf("ler %s: %v", arquivo, err)
+	}
+	return retorno
+}
+
+func conferirRegistros(t *testing.T, retorno RetornoCNAB, esperados []RegistroRetorno) {
+	t.Helper()
+	if len(retorno.Registros) != len(esperados) {
+		t.Fatalf("%d registros, esperado %d: %+v", len(retorno.Registros), len(esperados), retorno.Registros)
+	}
+	for i, e := range esperados {
+		r := retorno.Registros[i]
+		if r.Linha != e.Linha || r.NossoNumero != e.NossoNumero || r.Ocorrencia != e.Ocorrencia ||
+			r.Liquidado != e.Liquidado || r.ValorPago != e.ValorPago || !r.PagoEm.Equal(e.PagoEm) {
+			t.Errorf("registro %d = %+v, esperado %+v", i, r, e)
+		}
+	}
+}
+
+func TestLerRetornoCNAB240(t *testing.T) {
+	retorno := lerRetorno(t, "retorno_240.ret")
+	if retorno.Layout != CNAB240 || retorno.Banco != "237" {
+		t.Fatalf("layout %s do banco %s, esperado %s do 237", retorno.Layout, retorno.Banco, CNAB240)
+	}
+	pagoEm := time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)
+	conferirRegistros(t, retorno, []RegistroRetorno{
+		{Linha: 3, NossoNumero: "00000000001", Ocorrencia: "06", Liquidado: true, ValorPago: 15000, PagoEm: pagoEm},
+		{Linha: 5, NossoNumero: "00000000002", Ocorrencia: "06", Liquidado: true, ValorPago: 12000, PagoEm: pagoEm},
+		{Linha: 7, NossoNumero: "00000000003", Ocorrencia: "02"},
+	})
+}
+
+func TestLerRetornoCNAB400(t *testing.T) {
+	retorno := lerRetorno(t, "retorno_400.ret")
+	if retorno.Layout != CNAB400 || retorno.Banco != "237" {
+		t.Fatalf("layout %s do banco %s, esperado %s do 237", retorno.Layout, retorno.Banco, CNAB400)
+	}
+	pagoEm := time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)
+	conferirRegistros(t, retorno, []RegistroRetorno{
+		{Linha: 2, NossoNumero: "00000000001", Ocorrencia: "06", Liquidado: true, ValorPago: 15000, PagoEm: pagoEm},
+		{Linha: 3, NossoNumero: "00000000002", Ocorrencia: "06", Liquidado: true, ValorPago: 12000, PagoEm: pagoEm},
+		{Linha: 4, NossoNumero: "00000000003", Ocorrencia: "02"},
+	})
+}
+
+func TestLerRetornoCNABDeOutroBanco(t *testing.T) {
+	// A conferência do banco fica com quem chama; o leitor só informa.
+	retorno := lerRetorno(t, "retorno_400_itau.ret")
+	if retorno.Banco != "341" {
+		t.Errorf("banco = %s, esperado 341", retorno.Banco)
+	}
+}
+
+func TestLerRetornoCNABInvalido(t *testing.T) {
+	casos := map[string]string{
+		"vazio":        "\r\n\r\n",
+		"remessa 400":  "01REMESSA01COBRANCA",
+		"linha grande": "02RETORNO01COBRANCA\r\n1" + strings.Repeat("0", 400),
+	}
+	for nome, conteudo := range casos {
+		_, err := LerRetornoCNAB(strings.NewReader(conteudo))
+		if err == nil {
+			t.Errorf("%s: aceito, esperado erro", nome)
+		}
+		if nome != "linha grande" && !errors.Is(err, ErrLayoutCNAB) {
+			t.Errorf("%s: %v, esperado ErrLayoutCNAB", nome, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package pagamento
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Gateway é um processador de cartões. Os valores vão em centavos, e os
+// cartões chegam como tokens gerados no navegador pelo SDK do gateway.
+//
+// Uma operação recusada (cartão sem limite, estorno acima do capturado)
+// não é erro: volta com Aprovada falsa e o motivo em Mensagem. O erro fica
+// para falhas de comunicação, em que o resultado é desconhecido.
+type Gateway interface {
+	Nome() string
+	// Autorizar reserva o valor no limite do cartão, sem cobrar.
+	Autorizar(ctx context.Context, a Autorizacao) (Resposta, error)
+	// Capturar cobra o valor, até o autorizado, de uma autorização.
+	Capturar(ctx context.Context, id string, centavos int64) (Resposta, error)
+	// Estornar devolve parte ou todo o valor capturado. Referencia é a chave
+	// de idempotência: repetir a chamada com ela não estorna duas vezes.
+	Estornar(ctx context.Context, id, referencia string, centavos int64) (Resposta, error)
+	// Cancelar libera uma autorização ainda não capturada.
+	Cancelar(ctx context.Context, id string) (Resposta, error)
+}
+
+type Autorizacao struct {
+	// Referencia identifica a cobrança na loja; o gateway a usa como chave
+	// de idempotência, então repetir a chamada não autoriza duas vezes.
+	Referencia string
+	Token      string
+	Centavos   int64
+	Parcelas   int
+}
+
+type Resposta struct {
+	// ID é o da autorização no gateway, o mesmo em todas as operações dela.
+	ID       string
+	Aprovada bool
+	Mensagem string
+	// Bandeira e FinalCartao vêm na autorização.
+	Bandeira    string
+	FinalCartao string
+}
+
+// ConfigCartao reúne o gateway e as regras de parcelamento.
+type ConfigCartao struct {
+	Gateway      Gateway
+	Parcelamento Parcelamento
+}
+
+// CartaoDoAmbiente lê a configuração do cartão. Sem CARTAO_GATEWAY o
+// cartão fica desligado e a função devolve nil.
+//
+// CARTAO_GATEWAY=processador usa a API de CARTAO_URL com a chave
+// CARTAO_CHAVE; CARTAO_GATEWAY=memoria aprova tudo localmente, para
+// desenvolvimento. O parcelamento vem de CARTAO_PARCELAS_MAX (padrão 12),
+// CARTAO_PARCELAS_SEM_JUROS (padrão 3), CARTAO_JUROS_MES (em %, padrão
+// 1.99) e CARTAO_PARCELA_MINIMA (em reais, padrão 10.00).
+func CartaoDoAmbiente() (*ConfigCartao, error) {
+	c := &ConfigCartao{Parcelamento: ParcelamentoPadrao}
+	switch os.Getenv("CARTAO_GATEWAY") {
+	case "":
+		return nil, nil
+	case "memoria":
+		c.Gateway = NovoGatewayMemoria()
+	case "processador":
+		p := &Processador{URL: strings.TrimRight(os.Getenv("CARTAO_URL"), "/"), Chave: os.Getenv("CARTAO_CHAVE")}
+		if p.URL == "" || p.Chave == "" {
+			return nil, fmt.Errorf("CARTAO_URL e CARTAO_CHAVE são obrigatórios com CARTAO_GATEWAY=processador")
+		}
+		c.Gateway = p
+	default:
+		return nil, fmt.Errorf("CARTAO_GATEWAY inválido: %s", os.Getenv("CARTAO_GATEWAY"))
+	}
+
+	inteiros := []struct {
+		nome    string
+		destino *int
+	}{
+		{"CARTAO_PARCELAS_MAX", &c.Parcelamento.Maximo},
+		{"CARTAO_PARCELAS_SEM_JUROS", &c.Parcelamento.SemJuros},
+	}
+	for _, v := range inteiros {
+		if texto := os.Getenv(v.nome); texto != "" {
+			n, err := strconv.Atoi(texto)
+			if err != nil || n < 1 || n > MaxParcelas {
+				return nil, fmt.Errorf("%s inválido: %q", v.nome, texto)
+			}
+			*v.destino = n
+		}
+	}
+	if v := os.Getenv("CARTAO_JUROS_MES"); v != "" {
+		taxa, err := strconv.ParseFloat(v, 64)
+		if err != nil || taxa < 0 || taxa > 20 {
+			return nil, fmt.Errorf("CARTAO_JUROS_MES inválido: %q", v)
+		}
+		c.Parcelamento.TaxaMensal = taxa / 100
+	}
+	if v := os.Getenv("CARTAO_PARCELA_MINIMA"); v != "" {
+		minima, err := strconv.ParseFloat(v, 64)
+		if err != nil || minima < 0 {
+			return nil, fmt.Errorf("CARTAO_PARCELA_MINIMA inválido: %q", v)
+		}
+		c.Parcelamento.ParcelaMinima = int64(minima*100 + 0.5)
+	}
+	return c, nil
+}
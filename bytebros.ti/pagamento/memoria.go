@@ -0,0 +1,119 @@
+package pagamento
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// GatewayMemoria é um gateway de cartão em memória, para desenvolvimento e
+// testes. Aprova qualquer token, menos os que começam com "tok_recusado";
+// tokens "tok_<bandeira>_<final>", como "tok_visa_4242", definem a bandeira
+// e o final do cartão.
+type GatewayMemoria struct {
+	mu         sync.Mutex
+	sequencia  int
+	autorizado map[string]*autorizacaoMemoria
+	// porReferencia dá a idempotência de Autorizar, e estornos a de Estornar.
+	porReferencia map[string]string
+	estornos      map[string]Resposta
+}
+
+type autorizacaoMemoria struct {
+	autorizado int64
+	capturado  int64
+	estornado  int64
+	cancelada  bool
+	resposta   Resposta
+}
+
+func NovoGatewayMemoria() *GatewayMemoria {
+	return &GatewayMemoria{autorizado: map[string]*autorizacaoMemoria{}, porReferencia: map[string]string{}, estornos: map[string]Resposta{}}
+}
+
+func (g *GatewayMemoria) Nome() string { return "memoria" }
+
+func (g *GatewayMemoria) Autorizar(ctx context.Context, a Autorizacao) (Resposta, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if id, ok := g.porReferencia[a.Referencia]; ok && a.Referencia != "" {
+		return g.autorizado[id].resposta, nil
+	}
+	if strings.HasPrefix(a.Token, "tok_recusado") {
+		return Resposta{Mensagem: "Cartão recusado pelo emissor"}, nil
+	}
+	if a.Token == "" || a.Centavos <= 0 || a.Parcelas < 1 {
+		return Resposta{Mensagem: "Dados da autorização inválidos"}, nil
+	}
+
+	g.sequencia++
+	resposta := Resposta{ID: fmt.Sprintf("mem_%06d", g.sequencia), Aprovada: true, Bandeira: "visa", FinalCartao: "4242"}
+	if partes := strings.Split(a.Token, "_"); len(partes) == 3 && partes[0] == "tok" {
+		resposta.Bandeira, resposta.FinalCartao = partes[1], partes[2]
+	}
+	g.autorizado[resposta.ID] = &autorizacaoMemoria{autorizado: a.Centavos, resposta: resposta}
+	if a.Referencia != "" {
+		g.porReferencia[a.Referencia] = resposta.ID
+	}
+	return resposta, nil
+}
+
+func (g *GatewayMemoria) Capturar(ctx context.Context, id string, centavos int64) (Resposta, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t, ok := g.autorizado[id]
+	switch {
+	case !ok:
+		return Resposta{ID: id, Mensagem: "Autorização não encontrada"}, nil
+	case t.cancelada:
+		return Resposta{ID: id, Mensagem: "Autorização cancelada"}, nil
+	case t.capturado > 0:
+		return Resposta{ID: id, Mensagem: "Autorização já capturada"}, nil
+	case centavos <= 0 || centavos > t.autorizado:
+		return Resposta{ID: id, Mensagem: "Valor acima do autorizado"}, nil
+	}
+	t.capturado = centavos
+	return Resposta{ID: id, Aprovada: true}, nil
+}
+
+func (g *GatewayMemoria) Estornar(ctx context.Context, id, referencia string, centavos int64) (Resposta, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if resposta, ok := g.estornos[referencia]; ok && referencia != "" {
+		return resposta, nil
+	}
+	t, ok := g.autorizado[id]
+	var resposta Resposta
+	switch {
+	case !ok:
+		resposta = Resposta{ID: id, Mensagem: "Autorização não encontrada"}
+	case centavos <= 0 || t.estornado+centavos > t.capturado:
+		resposta = Resposta{ID: id, Mensagem: "Valor acima do capturado"}
+	default:
+		t.estornado += centavos
+		resposta = Resposta{ID: id, Aprovada: true}
+	}
+	if referencia != "" {
+		g.estornos[referencia] = resposta
+	}
+	return resposta, nil
+}
+
+func (g *GatewayMemoria) Cancelar(ctx context.Context, id string) (Resposta, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t, ok := g.autorizado[id]
+	switch {
+	case !ok:
+		return Resposta{ID: id, Mensagem: "Autorização não encontrada"}, nil
+	case t.capturado > 0:
+		return Resposta{ID: id, Mensagem: "Autorização já capturada; use o estorno"}, nil
+	}
+	t.cancelada = true
+	return Resposta{ID: id, Aprovada: true}, nil
+}
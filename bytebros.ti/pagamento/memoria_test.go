@@ -0,0 +1,118 @@
+package pagamento
+
+import (
+	"context"
+	"testing"
+)
+
+func autorizarTeste(t *testing.T, g *GatewayMemoria, referencia, token string, centavos int64) Resposta {
+	t.Helper()
+	resposta, err := g.Autorizar(context.Background(), Autorizacao{Referencia: referencia, Token: token, Centavos: centavos, Parcelas: 1})
+	if err != nil {
+		t.Fatalf("autorizar: %v", err)
+	}
+	return resposta
+}
+
+func esperarResposta(t *testing.T, operacao string, resposta Resposta, err error, aprovada bool, mensagem string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", operacao, err)
+	}
+	if resposta.Aprovada != aprovada || resposta.Mensagem != mensagem {
+		t.Errorf("%s = %+v, esperado aprovada=%v %q", operacao, resposta, aprovada, mensagem)
+	}
+}
+
+func TestGatewayMemoriaAutorizarECapturar(t *testing.T) {
+	g := NovoGatewayMemoria()
+	ctx := context.Background()
+
+	a := autorizarTeste(t, g, "pagamento-1", "tok_master_5454", 15000)
+	if !a.Aprovada || a.ID != "mem_000001" || a.Bandeira != "master" || a.FinalCartao != "5454" {
+		t.Fatalf("autorização = %+v, esperado mem_000001 no master final 5454", a)
+	}
+	// A mesma referência devolve a mesma autorização, sem cobrar de novo.
+	if repetida := autorizarTeste(t, g, "pagamento-1", "tok_master_5454", 15000); repetida != a {
+		t.Errorf("autorização repetida = %+v, esperado %+v", repetida, a)
+	}
+	if outra := autorizarTeste(t, g, "pagamento-2", "tok_qualquer", 100); outra.ID != "mem_000002" || outra.Bandeira != "visa" || outra.FinalCartao != "4242" {
+		t.Errorf("autorização sem bandeira no token = %+v, esperado mem_000002 no visa final 4242", outra)
+	}
+
+	r, err := g.Capturar(ctx, a.ID, 15001)
+	esperarResposta(t, "captura acima do autorizado", r, err, false, "Valor acima do autorizado")
+	r, err = g.Capturar(ctx, "mem_999999", 100)
+	esperarResposta(t, "captura sem autorização", r, err, false, "Autorização não encontrada")
+	r, err = g.Capturar(ctx, a.ID, 15000)
+	esperarResposta(t, "captura", r, err, true, "")
+	r, err = g.Capturar(ctx, a.ID, 15000)
+	esperarResposta(t, "segunda captura", r, err, false, "Autorização já capturada")
+	r, err = g.Cancelar(ctx, a.ID)
+	esperarResposta(t, "cancelamento depois da captura", r, err, false, "Autorização já capturada; use o estorno")
+}
+
+func TestGatewayMemoriaCancelar(t *testing.T) {
+	g := NovoGatewayMemoria()
+	ctx := context.Background()
+	a := autorizarTeste(t, g, "pagamento-1", "tok_visa_4242", 15000)
+
+	r, err := g.Cancelar(ctx, a.ID)
+	esperarResposta(t, "cancelamento", r, err, true, "")
+	r, err = g.Capturar(ctx, a.ID, 15000)
+	esperarResposta(t, "captura depois do cancelamento", r, err, false, "Autorização cancelada")
+	r, err = g.Cancelar(ctx, "mem_999999")
+	esperarResposta(t, "cancelamento sem autorização", r, err, false, "Autorização não encontrada")
+}
+
+func TestGatewayMemoriaRecusa(t *testing.T) {
+	g := NovoGatewayMemoria()
+	r := autorizarTeste(t, g, "pagamento-1", "tok_recusado_0002", 15000)
+	esperarResposta(t, "cartão recusado", r, nil, false, "Cartão recusado pelo emissor")
+	if r.ID != "" {
+		t.Errorf("recusa com ID %q, esperado sem autorização", r.ID)
+	}
+
+	casos := map[string]Autorizacao{
+		"sem token":      {Referencia: "pagamento-2", Centavos: 15000, Parcelas: 1},
+		"sem valor":      {Referencia: "pagamento-3", Token: "tok_visa_4242", Parcelas: 1},
+		"sem parcelas":   {Referencia: "pagamento-4", Token: "tok_visa_4242", Centavos: 15000},
+		"valor negativo": {Referencia: "pagamento-5", Token: "tok_visa_4242", Centavos: -1, Parcelas: 1},
+	}
+	for nome, a := range casos {
+		r, err := g.Autorizar(context.Background(), a)
+		esperarResposta(t, nome, r, err, false, "Dados da autorização inválidos")
+	}
+
+	// A recusa não fica guardada: a mesma referência pode tentar outro cartão.
+	if r := autorizarTeste(t, g, "pagamento-1", "tok_visa_4242", 15000); !r.Aprovada {
+		t.Errorf("nova tentativa = %+v, esperado aprovada", r)
+	}
+}
+
+func TestGatewayMemoriaEstornar(t *testing.T) {
+	g := NovoGatewayMemoria()
+	ctx := context.Background()
+	a := autorizarTeste(t, g, "pagamento-1", "tok_visa_4242", 15000)
+
+	r, err := g.Estornar(ctx, a.ID, "estorno-1", 1000)
+	esperarResposta(t, "estorno antes da captura", r, err, false, "Valor acima do capturado")
+	if _, err := g.Capturar(ctx, a.ID, 15000); err != nil {
+		t.Fatalf("capturar: %v", err)
+	}
+	// A referência já respondida devolve a mesma resposta, mesmo recusada.
+	r, err = g.Estornar(ctx, a.ID, "estorno-1", 1000)
+	esperarResposta(t, "estorno com referência recusada", r, err, false, "Valor acima do capturado")
+
+	r, err = g.Estornar(ctx, a.ID, "estorno-2", 10000)
+	esperarResposta(t, "estorno parcial", r, err, true, "")
+	r, err = g.Estornar(ctx, a.ID, "estorno-2", 10000)
+	esperarResposta(t, "estorno repetido", r, err, true, "")
+	// O repetido não estornou de novo: o saldo continua R$ 50,00.
+	r, err = g.Estornar(ctx, a.ID, "estorno-3", 5001)
+	esperarResposta(t, "estorno acima do saldo", r, err, false, "Valor acima do capturado")
+	r, err = g.Estornar(ctx, a.ID, "estorno-4", 5000)
+	esperarResposta(t, "estorno do saldo", r, err, true, "")
+	r, err = g.Estornar(ctx, "mem_999999", "estorno-5", 100)
+	esperarResposta(t, "estorno sem autorização", r, err, false, "Autorização não encontrada")
+}
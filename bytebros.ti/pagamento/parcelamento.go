@@ -0,0 +1,67 @@
+package pagamento
+
+import (
+	"errors"
+	"math"
+)
+
+// MaxParcelas é o limite aceito pelos gateways.
+const MaxParcelas = 24
+
+var ErrParcelas = errors.New("número de parcelas indisponível para o valor")
+
+// Parcelamento são as regras das parcelas no cartão. As primeiras SemJuros
+// parcelas não têm juros; acima delas, as parcelas são fixas pela tabela
+// Price com TaxaMensal (0.0199 para 1,99% ao mês).
+type Parcelamento struct {
+	Maximo     int
+	SemJuros   int
+	TaxaMensal float64
+	// ParcelaMinima, em centavos, vale a partir de 2 parcelas.
+	ParcelaMinima int64
+}
+
+var ParcelamentoPadrao = Parcelamento{Maximo: 12, SemJuros: 3, TaxaMensal: 0.0199, ParcelaMinima: 1000}
+
+// Parcela é o resultado do parcelamento, em centavos. Sem juros, Total é o
+// próprio valor e ValorParcela é arredondado para cima; a diferença de
+// centavos sai na primeira parcela.
+type Parcela struct {
+	Parcelas     int
+	ValorParcela int64
+	Total        int64
+	TaxaMensal   float64
+}
+
+// Calcular parcela centavos em n vezes.
+func (p Parcelamento) Calcular(centavos int64, n int) (Parcela, error) {
+	if n < 1 || n > p.Maximo || n > MaxParcelas || centavos <= 0 {
+		return Parcela{}, ErrParcelas
+	}
+	parcela := Parcela{Parcelas: n, Total: centavos}
+	if n <= p.SemJuros || p.TaxaMensal == 0 {
+		parcela.ValorParcela = (centavos + int64(n) - 1) / int64(n)
+	} else {
+		i := p.TaxaMensal
+		fator := i / (1 - math.Pow(1+i, -float64(n)))
+		// O desconto evita que erros de ponto flutuante subam um centavo.
+		parcela.ValorParcela = int64(math.Ceil(float64(centavos)*fator - 1e-6))
+		parcela.Total = parcela.ValorParcela * int64(n)
+		parcela.TaxaMensal = i
+	}
+	if n > 1 && parcela.ValorParcela < p.ParcelaMinima {
+		return Parcela{}, ErrParcelas
+	}
+	return parcela, nil
+}
+
+// Opcoes lista as parcelas possíveis para o valor, de 1 até o máximo.
+func (p Parcelamento) Opcoes(centavos int64) []Parcela {
+	opcoes := make([]Parcela, 0, p.Maximo)
+	for n := 1; n <= p.Maximo; n++ {
+		if parcela, err := p.Calcular(centavos, n); err == nil {
+			opcoes = append(opcoes, parcela)
+		}
+	}
+	return opcoes
+}
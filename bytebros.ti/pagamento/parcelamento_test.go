@@ -0,0 +1,70 @@
+package pagamento
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCalcularParcelamentoPadrao(t *testing.T) {
+	casos := []struct {
+		centavos int64
+		n        int
+		esperado Parcela
+	}{
+		{100000, 1, Parcela{Parcelas: 1, ValorParcela: 100000, Total: 100000}},
+		// Sem juros, o centavo que sobra fica na primeira parcela.
+		{100000, 3, Parcela{Parcelas: 3, ValorParcela: 33334, Total: 100000}},
+		{10001, 3, Parcela{Parcelas: 3, ValorParcela: 3334, Total: 10001}},
+		// Com juros, tabela Price a 1,99% ao mês.
+		{100000, 4, Parcela{Parcelas: 4, ValorParcela: 26257, Total: 105028, TaxaMensal: 0.0199}},
+		{100000, 10, Parcela{Parcelas: 10, ValorParcela: 11127, Total: 111270, TaxaMensal: 0.0199}},
+		{100000, 12, Parcela{Parcelas: 12, ValorParcela: 9451, Total: 113412, TaxaMensal: 0.0199}},
+		{12000, 12, Parcela{Parcelas: 12, ValorParcela: 1135, Total: 13620, TaxaMensal: 0.0199}},
+		{11999, 12, Parcela{Parcelas: 12, ValorParcela: 1134, Total: 13608, TaxaMensal: 0.0199}},
+	}
+	for _, caso := range casos {
+		parcela, err := ParcelamentoPadrao.Calcular(caso.centavos, caso.n)
+		if err != nil || parcela != caso.esperado {
+			t.Errorf("%d em %dx = %+v, %v; esperado %+v", caso.centavos, caso.n, parcela, err, caso.esperado)
+		}
+	}
+}
+
+func TestCalcularParcelamentoIndisponivel(t *testing.T) {
+	casos := []struct {
+		nome     string
+		centavos int64
+		n        int
+	}{
+		{"zero parcelas", 100000, 0},
+		{"acima do máximo", 100000, 13},
+		{"sem valor", 0, 1},
+		{"parcela abaixo da mínima", 5000, 6},
+	}
+	for _, caso := range casos {
+		if _, err := ParcelamentoPadrao.Calcular(caso.centavos, caso.n); !errors.Is(err, ErrParcelas) {
+			t.Errorf("%s: %v, esperado ErrParcelas", caso.nome, err)
+		}
+	}
+
+	// O limite dos gateways vale mesmo com um máximo configurado acima dele.
+	p := Parcelamento{Maximo: 36, SemJuros: 36}
+	if _, err := p.Calcular(1000000, MaxParcelas+1); !errors.Is(err, ErrParcelas) {
+		t.Errorf("%d parcelas: %v, esperado ErrParcelas", MaxParcelas+1, err)
+	}
+}
+
+func TestOpcoesParcelamento(t *testing.T) {
+	if opcoes := ParcelamentoPadrao.Opcoes(100000); len(opcoes) != 12 {
+		t.Errorf("%d opções para R$ 1.000,00, esperado 12", len(opcoes))
+	}
+	// R$ 50,00 vai até 5x de 10,61; em 6x a parcela fica abaixo de R$ 10,00.
+	opcoes := ParcelamentoPadrao.Opcoes(5000)
+	if len(opcoes) != 5 || opcoes[4].ValorParcela != 1061 {
+		t.Errorf("opções para R$ 50,00 = %+v, esperado de 1x a 5x de 1061", opcoes)
+	}
+	// A parcela única não tem mínimo.
+	if opcoes := ParcelamentoPadrao.Opcoes(500); len(opcoes) != 1 || opcoes[0].Total != 500 {
+		t.Errorf("opções para R$ 5,00 = %+v, esperado só 1x", opcoes)
+	}
+}
@@ -0,0 +1,172 @@
+// Package pagamento gera as cobranças dos pedidos, confere as confirmações
+// enviadas pelos provedores de pagamento (PSPs) e fala com os gateways de
+// cartão.
+package pagamento
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BRCode é um Pix estático com valor e txid, no padrão EMV MPM do Banco
+// Central ("copia e cola").
+type BRCode struct {
+	Chave string
+	// Nome e Cidade do recebedor são cortados em 25 e 15 caracteres, sem
+	// acentos.
+	Nome   string
+	Cidade string
+	Valor  float64
+	TxID   string
+}
+
+// Payload monta o texto do BR Code, terminado pelo CRC16 (campo 63).
+func (b BRCode) Payload() string {
+	var s strings.Builder
+	s.WriteString(campo("00", "01"))
+	s.WriteString(campo("26", campo("00", "br.gov.bcb.pix")+campo("01", b.Chave)))
+	s.WriteString(campo("52", "0000"))
+	s.WriteString(campo("53", "986"))
+	s.WriteString(campo("54", fmt.Sprintf("%.2f", b.Valor)))
+	s.WriteString(campo("58", "BR"))
+	s.WriteString(campo("59", ascii(b.Nome, 25)))
+	s.WriteString(campo("60", ascii(b.Cidade, 15)))
+	s.WriteString(campo("62", campo("05", b.TxID)))
+	s.WriteString("6304")
+	return s.String() + fmt.Sprintf("%04X", crc16(s.String()))
+}
+
+func campo(id, valor string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(valor), valor)
+}
+
+var semAcento = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// ascii deixa o texto em maiúsculas, sem acentos e com até n caracteres,
+// como os apps dos bancos esperam.
+func ascii(texto string, n int) string {
+	texto = semAcento.Replace(strings.ToLower(texto))
+	var b strings.Builder
+	for _, r := range strings.ToUpper(texto) {
+		if r < unicode.MaxASCII && unicode.IsPrint(r) && b.Len() < n {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// crc16 é o CRC-16/CCITT-FALSE (polinômio 0x1021, início 0xFFFF).
+func crc16(s string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+const alfanumericos = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// NovoTxID gera o txid da cobrança: "BB", o pedido com 10 dígitos e 13
+// caracteres aleatórios, nos 25 alfanuméricos que o BR Code estático aceita.
+func NovoTxID(pedidoID int) (string, error) {
+	sufixo := make([]byte, 13)
+	limite := big.NewInt(int64(len(alfanumericos)))
+	for i := range sufixo {
+		n, err := rand.Int(rand.Reader, limite)
+		if err != nil {
+			return "", err
+		}
+		sufixo[i] = alfanumericos[n.Int64()]
+	}
+	return fmt.Sprintf("BB%010d%s", pedidoID, sufixo), nil
+}
+
+// ConfigPix reúne o recebedor e o PSP das cobranças Pix.
+type ConfigPix struct {
+	Chave  string
+	Nome   string
+	Cidade string
+	// Segredo assina os webhooks do PSP.
+	Segredo string
+	// Validade é o prazo para pagar; depois dele a cobrança expira.
+	Validade time.Duration
+	// Falso, quando existe, simula o PSP em desenvolvimento.
+	Falso *PSPFalso
+}
+
+// Cobranca monta o BR Code de uma cobrança.
+func (c *ConfigPix) Cobranca(txid string, valor float64) BRCode {
+	return BRCode{Chave: c.Chave, Nome: c.Nome, Cidade: c.Cidade, Valor: valor, TxID: txid}
+}
+
+// PixDoAmbiente lê a configuração do Pix. Sem PIX_CHAVE o Pix fica
+// desligado e a função devolve nil.
+//
+// PIX_NOME e PIX_CIDADE identificam o recebedor (padrão "BYTE BROS TI" e
+// "SAO PAULO"); PIX_WEBHOOK_SEGREDO, obrigatório, assina os webhooks;
+// PIX_VALIDADE é o prazo de pagamento (padrão 30m). PIX_PSP=falso liga o
+// PSP simulado, que envia os webhooks para PIX_WEBHOOK_URL.
+func PixDoAmbiente() (*ConfigPix, error) {
+	chave := strings.TrimSpace(os.Getenv("PIX_CHAVE"))
+	if chave == "" {
+		return nil, nil
+	}
+	if len(chave) > 77 {
+		return nil, fmt.Errorf("PIX_CHAVE inválida: mais de 77 caracteres")
+	}
+	c := &ConfigPix{
+		Chave:    chave,
+		Nome:     os.Getenv("PIX_NOME"),
+		Cidade:   os.Getenv("PIX_CIDADE"),
+		Segredo:  os.Getenv("PIX_WEBHOOK_SEGREDO"),
+		Validade: 30 * time.Minute,
+	}
+	if c.Nome == "" {
+		c.Nome = "BYTE BROS TI"
+	}
+	if c.Cidade == "" {
+		c.Cidade = "SAO PAULO"
+	}
+	if c.Segredo == "" {
+		return nil, errors.New("PIX_WEBHOOK_SEGREDO é obrigatório com PIX_CHAVE")
+	}
+	if v := os.Getenv("PIX_VALIDADE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("PIX_VALIDADE inválido: %q", v)
+		}
+		c.Validade = d
+	}
+	switch os.Getenv("PIX_PSP") {
+	case "":
+	case "falso":
+		url := os.Getenv("PIX_WEBHOOK_URL")
+		if url == "" {
+			url = "http://localhost:" + os.Getenv("PORT") + "/api/webhooks/pix"
+		}
+		c.Falso = &PSPFalso{URLWebhook: url, Segredo: c.Segredo}
+	default:
+		return nil, fmt.Errorf("PIX_PSP inválido: %s", os.Getenv("PIX_PSP"))
+	}
+	return c, nil
+}
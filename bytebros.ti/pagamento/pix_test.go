@@ -0,0 +1,66 @@
+package pagamento
+
+import (
+	"strings"
+	"testing"
+)
+
+// Exemplo do Manual de Padrões para Iniciação do Pix, do Banco Central: BR
+// Code estático sem valor, cujo CRC publicado é 1D3D.
+const exemploBancoCentral = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestCRC16ExemploBancoCentral(t *testing.T) {
+	semCRC := strings.TrimSuffix(exemploBancoCentral, "1D3D")
+	if crc := crc16(semCRC); crc != 0x1D3D {
+		t.Errorf("crc16 do exemplo do Banco Central = %04X, esperado 1D3D", crc)
+	}
+	// Valor de conferência do CRC-16/CCITT-FALSE.
+	if crc := crc16("123456789"); crc != 0x29B1 {
+		t.Errorf("crc16(\"123456789\") = %04X, esperado 29B1", crc)
+	}
+}
+
+func TestPayloadBRCode(t *testing.T) {
+	b := BRCode{
+		Chave:  "123e4567-e12b-12d1-a456-426655440000",
+		Nome:   "Byte Bros Informática Ltda",
+		Cidade: "São Paulo",
+		Valor:  1234.5,
+		TxID:   "BB0000000042abcDEF1234567",
+	}
+	// Mesmos campos do exemplo do Banco Central, mais o valor (54); nome e
+	// cidade sem acentos e o nome cortado em 25 caracteres.
+	esperado := "000201" +
+		"26580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-426655440000" +
+		"52040000" +
+		"5303986" +
+		"54071234.50" +
+		"5802BR" +
+		"5925BYTE BROS INFORMATICA LTD" +
+		"6009SAO PAULO" +
+		"62290525BB0000000042abcDEF1234567" +
+		"6304854F"
+	if payload := b.Payload(); payload != esperado {
+		t.Errorf("payload =\n%s\nesperado\n%s", payload, esperado)
+	}
+}
+
+func TestNovoTxID(t *testing.T) {
+	a, err := NovoTxID(42)
+	if err != nil {
+		t.Fatalf("txid: %v", err)
+	}
+	b, err := NovoTxID(42)
+	if err != nil {
+		t.Fatalf("txid: %v", err)
+	}
+	if len(a) != 25 || !strings.HasPrefix(a, "BB0000000042") {
+		t.Errorf("txid = %q, esperado 25 caracteres começando por BB0000000042", a)
+	}
+	if strings.Trim(a, alfanumericos) != "" {
+		t.Errorf("txid %q tem caracteres fora de %s", a, alfanumericos)
+	}
+	if a == b {
+		t.Errorf("dois txids iguais: %q", a)
+	}
+}
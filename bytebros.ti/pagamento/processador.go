@@ -0,0 +1,102 @@
+package pagamento
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Processador fala com um processador de cartões pela API REST genérica
+// que a maioria deles segue:
+//
+//	POST /v1/autorizacoes                    {"token", "valor", "parcelas", "referencia"}
+//	POST /v1/autorizacoes/{id}/captura       {"valor"}
+//	POST /v1/autorizacoes/{id}/estorno       {"valor"}
+//	POST /v1/autorizacoes/{id}/cancelamento
+//
+// Valores em centavos. A resposta traz "id", "status" ("aprovada" ou
+// "recusada") e "mensagem", e a autorização também "bandeira" e
+// "final_cartao". Recusas podem vir como 402 ou 422.
+type Processador struct {
+	URL   string
+	Chave string
+
+	// Cliente permite trocar o http.Client; nil usa um com timeout de 30s.
+	Cliente *http.Client
+}
+
+func (p *Processador) Nome() string { return "processador" }
+
+type respostaProcessador struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	Mensagem    string `json:"mensagem"`
+	Bandeira    string `json:"bandeira"`
+	FinalCartao string `json:"final_cartao"`
+}
+
+func (p *Processador) Autorizar(ctx context.Context, a Autorizacao) (Resposta, error) {
+	corpo := map[string]any{"token": a.Token, "valor": a.Centavos, "parcelas": a.Parcelas, "referencia": a.Referencia}
+	return p.enviar(ctx, "/v1/autorizacoes", a.Referencia, corpo)
+}
+
+func (p *Processador) Capturar(ctx context.Context, id string, centavos int64) (Resposta, error) {
+	return p.enviar(ctx, "/v1/autorizacoes/"+url.PathEscape(id)+"/captura", "", map[string]any{"valor": centavos})
+}
+
+func (p *Processador) Estornar(ctx context.Context, id, referencia string, centavos int64) (Resposta, error) {
+	return p.enviar(ctx, "/v1/autorizacoes/"+url.PathEscape(id)+"/estorno", referencia, map[string]any{"valor": centavos})
+}
+
+func (p *Processador) Cancelar(ctx context.Context, id string) (Resposta, error) {
+	return p.enviar(ctx, "/v1/autorizacoes/"+url.PathEscape(id)+"/cancelamento", "", map[string]any{})
+}
+
+func (p *Processador) enviar(ctx context.Context, caminho, idempotencia string, corpo any) (Resposta, error) {
+	dados, err := json.Marshal(corpo)
+	if err != nil {
+		return Resposta{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL+caminho, bytes.NewReader(dados))
+	if err != nil {
+		return Resposta{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Chave)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if idempotencia != "" {
+		req.Header.Set("Idempotency-Key", idempotencia)
+	}
+
+	cliente := p.Cliente
+	if cliente == nil {
+		cliente = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := cliente.Do(req)
+	if err != nil {
+		return Resposta{}, err
+	}
+	defer resp.Body.Close()
+
+	recusa := resp.StatusCode == http.StatusPaymentRequired || resp.StatusCode == http.StatusUnprocessableEntity
+	if resp.StatusCode/100 != 2 && !recusa {
+		detalhe, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Resposta{}, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(detalhe)))
+	}
+	var r respostaProcessador
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&r); err != nil {
+		return Resposta{}, fmt.Errorf("resposta inválida: %w", err)
+	}
+	resposta := Resposta{ID: r.ID, Mensagem: r.Mensagem, Bandeira: r.Bandeira, FinalCartao: r.FinalCartao}
+	resposta.Aprovada = !recusa && r.Status == "aprovada"
+	if !resposta.Aprovada && resposta.Mensagem == "" {
+		resposta.Mensagem = "Operação recusada pelo processador"
+	}
+	return resposta, nil
+}
@@ -0,0 +1,119 @@
+package pagamento
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cabeçalhos do webhook. A assinatura é o HMAC-SHA256, em hexadecimal, de
+// "<timestamp>.<corpo>", com o timestamp em segundos Unix.
+const (
+	CabecalhoAssinatura = "X-Pix-Signature"
+	CabecalhoTimestamp  = "X-Pix-Timestamp"
+)
+
+// toleranciaWebhook limita a idade de um webhook, contra reenvio de
+// notificações antigas capturadas.
+const toleranciaWebhook = 5 * time.Minute
+
+var ErrAssinatura = errors.New("assinatura do webhook inválida")
+
+// NotificacaoPix é o corpo do webhook, no formato da API Pix do Banco
+// Central: um ou mais Pix recebidos.
+type NotificacaoPix struct {
+	Pix []PixRecebido `json:"pix"`
+}
+
+type PixRecebido struct {
+	EndToEndID string `json:"endToEndId"`
+	TxID       string `json:"txid"`
+	// Valor vem como texto com ponto decimal, por exemplo "123.45".
+	Valor   string    `json:"valor"`
+	Horario time.Time `json:"horario"`
+}
+
+// Assinar calcula a assinatura do corpo enviado no instante timestamp.
+func Assinar(segredo string, timestamp int64, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(corpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerificarAssinatura confere a assinatura e a idade do webhook.
+func VerificarAssinatura(segredo, timestamp, assinatura string, corpo []byte, agora time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrAssinatura
+	}
+	idade := agora.Sub(time.Unix(ts, 0))
+	if idade > toleranciaWebhook || idade < -toleranciaWebhook {
+		return ErrAssinatura
+	}
+	esperada := Assinar(segredo, ts, corpo)
+	if !hmac.Equal([]byte(esperada), []byte(strings.ToLower(assinatura))) {
+		return ErrAssinatura
+	}
+	return nil
+}
+
+// PSPFalso faz o papel do PSP em desenvolvimento e nos testes: "paga" uma
+// cobrança enviando ao webhook a mesma notificação assinada que o PSP
+// enviaria.
+type PSPFalso struct {
+	URLWebhook string
+	Segredo    string
+
+	// Cliente permite trocar o http.Client; nil usa um com timeout de 10s.
+	Cliente *http.Client
+}
+
+// Pagar notifica o recebimento de valor para o txid e devolve o endToEndId
+// inventado para o Pix.
+func (p *PSPFalso) Pagar(ctx context.Context, txid string, valor float64) (string, error) {
+	agora := time.Now()
+	e2e := fmt.Sprintf("E00000000%s%011d", agora.UTC().Format("200601021504"), agora.UnixNano()%1e11)
+	corpo, err := json.Marshal(NotificacaoPix{Pix: []PixRecebido{{
+		EndToEndID: e2e,
+		TxID:       txid,
+		Valor:      strconv.FormatFloat(valor, 'f', 2, 64),
+		Horario:    agora.UTC().Truncate(time.Second),
+	}}})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URLWebhook, bytes.NewReader(corpo))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(CabecalhoTimestamp, strconv.FormatInt(agora.Unix(), 10))
+	req.Header.Set(CabecalhoAssinatura, Assinar(p.Segredo, agora.Unix(), corpo))
+
+	cliente := p.Cliente
+	if cliente == nil {
+		cliente = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := cliente.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detalhe, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(detalhe)))
+	}
+	return e2e, nil
+}
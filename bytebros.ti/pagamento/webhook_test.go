@@ -0,0 +1,44 @@
+package pagamento
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerificarAssinatura(t *testing.T) {
+	agora := time.Unix(1_760_000_000, 0)
+	corpo := []byte(`{"pix":[{"endToEndId":"E1","txid":"BB1","valor":"10.00"}]}`)
+	ts := strconv.FormatInt(agora.Unix(), 10)
+	assinatura := Assinar("segredo", agora.Unix(), corpo)
+
+	casos := []struct {
+		nome       string
+		segredo    string
+		timestamp  string
+		assinatura string
+		corpo      []byte
+		agora      time.Time
+		valida     bool
+	}{
+		{"válida", "segredo", ts, assinatura, corpo, agora, true},
+		{"hexadecimal em maiúsculas", "segredo", ts, strings.ToUpper(assinatura), corpo, agora, true},
+		{"segredo errado", "outro", ts, assinatura, corpo, agora, false},
+		{"corpo alterado", "segredo", ts, assinatura, []byte(`{"pix":[]}`), agora, false},
+		{"timestamp trocado", "segredo", strconv.FormatInt(agora.Unix()+1, 10), assinatura, corpo, agora.Add(time.Second), false},
+		{"timestamp inválido", "segredo", "ontem", assinatura, corpo, agora, false},
+		{"reenvio antigo", "segredo", ts, assinatura, corpo, agora.Add(toleranciaWebhook + time.Second), false},
+		{"do futuro", "segredo", ts, assinatura, corpo, agora.Add(-toleranciaWebhook - time.Second), false},
+	}
+	for _, caso := range casos {
+		err := VerificarAssinatura(caso.segredo, caso.timestamp, caso.assinatura, caso.corpo, caso.agora)
+		if caso.valida && err != nil {
+			t.Errorf("%s: %v", caso.nome, err)
+		}
+		if !caso.valida && !errors.Is(err, ErrAssinatura) {
+			t.Errorf("%s: %v, esperado ErrAssinatura", caso.nome, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package pdf escreve documentos PDF simples (texto nas fontes padrão,
+// linhas e retângulos preenchidos) só com a biblioteca padrão.
+//
+// As medidas são em milímetros, com a origem no canto superior esquerdo da
+// página e y crescendo para baixo, como num layout impresso.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Tamanho A4, em milímetros.
+const (
+	LarguraA4 = 210.0
+	AlturaA4  = 297.0
+)
+
+const pontosPorMM = 72 / 25.4
+
+type Documento struct {
+	paginas []*Pagina
+}
+
+func Novo() *Documento {
+	return &Documento{}
+}
+
+// Pagina acumula os comandos de desenho de uma página A4.
+type Pagina struct {
+	conteudo bytes.Buffer
+}
+
+// NovaPagina acrescenta uma página em branco ao documento.
+func (d *Documento) NovaPagina() *Pagina {
+	p := &Pagina{}
+	d.paginas = append(d.paginas, p)
+	return p
+}
+
+// Texto escreve em Helvetica, com y na linha de base do texto. Caracteres
+// fora do Latin-1 saem como "?".
+func (p *Pagina) Texto(x, y, tamanho float64, negrito bool, texto string) {
+	fonte := "F1"
+	if negrito {
+		fonte = "F2"
+	}
+	fmt.Fprintf(&p.conteudo, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		fonte, numero(tamanho), numero(x*pontosPorMM), numero((AlturaA4-y)*pontosPorMM), latin1(texto))
+}
+
+// Retangulo preenche de preto o retângulo com canto superior esquerdo em
+// (x, y).
+func (p *Pagina) Retangulo(x, y, largura, altura float64) {
+	fmt.Fprintf(&p.conteudo, "%s %s %s %s re f\n",
+		numero(x*pontosPorMM), numero((AlturaA4-y-altura)*pontosPorMM), numero(largura*pontosPorMM), numero(altura*pontosPorMM))
+}
+
+// Linha traça um segmento com a espessura em milímetros.
+func (p *Pagina) Linha(x1, y1, x2, y2, espessura float64) {
+	fmt.Fprintf(&p.conteudo, "%s w %s %s m %s %s l S\n", numero(espessura*pontosPorMM),
+		numero(x1*pontosPorMM), numero((AlturaA4-y1)*pontosPorMM), numero(x2*pontosPorMM), numero((AlturaA4-y2)*pontosPorMM))
+}
+
+// Escrever grava o documento em w.
+func (d *Documento) Escrever(w io.Writer) error {
+	var b bytes.Buffer
+	var offsets []int
+	objeto := func(corpo string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), corpo)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1: catálogo, 2: árvore de páginas, 3 e 4: fontes; depois cada página
+	// e o seu conteúdo.
+	kids := make([]string, len(d.paginas))
+	for i := range d.paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objeto("<< /Type /Catalog /Pages 2 0 R >>")
+	objeto(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.paginas)))
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range d.paginas {
+		objeto(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			numero(LarguraA4*pontosPorMM), numero(AlturaA4*pontosPorMM), 6+2*i))
+		objeto(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.conteudo.Len(), p.conteudo.String()))
+	}
+
+	inicioXref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, inicioXref)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func numero(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// latin1 converte o texto para a WinAnsiEncoding das fontes padrão e
+// escapa os caracteres especiais das strings PDF.
+func latin1(texto string) string {
+	var b strings.Builder
+	for _, r := range texto {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
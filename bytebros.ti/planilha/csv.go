@@ -0,0 +1,49 @@
+package planilha
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// bom abre o CSV exportado para que o Excel reconheça o UTF-8.
+var bom = []byte("\ufeff")
+
+type escritorCSV struct{ w *csv.Writer }
+
+func novoEscritorCSV(w io.Writer) (*escritorCSV, error) {
+	if _, err := w.Write(bom); err != nil {
+		return nil, err
+	}
+	return &escritorCSV{w: csv.NewWriter(w)}, nil
+}
+
+func (e *escritorCSV) Escrever(linha []string) error {
+	return e.w.Write(linha)
+}
+
+func (e *escritorCSV) Fechar() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// lerCSV aceita vírgula ou ponto e vírgula como separador (o Excel em
+// português salva com ";"), decidindo pela primeira linha.
+func lerCSV(r io.Reader) ([][]string, error) {
+	br := bufio.NewReader(r)
+	if inicio, _ := br.Peek(len(bom)); bytes.Equal(inicio, bom) {
+		br.Discard(len(bom))
+	}
+	cabecalho, _ := br.Peek(4096)
+	if i := bytes.IndexAny(cabecalho, "\r\n"); i >= 0 {
+		cabecalho = cabecalho[:i]
+	}
+
+	leitor := csv.NewReader(br)
+	if bytes.Count(cabecalho, []byte(";")) > bytes.Count(cabecalho, []byte(",")) {
+		leitor.Comma = ';'
+	}
+	leitor.FieldsPerRecord = -1
+	return leitor.ReadAll()
+}
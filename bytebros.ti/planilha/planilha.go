@@ -0,0 +1,69 @@
+// Package planilha lê e escreve planilhas simples (uma aba, só texto) em CSV
+// e XLSX, só com a biblioteca padrão.
+package planilha
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Formatos aceitos na leitura e na escrita.
+var Formatos = []string{CSV, XLSX}
+
+// ErrFormato indica um formato fora de Formatos.
+var ErrFormato = errors.New("formato de planilha não suportado")
+
+// Escritor grava uma linha por chamada; Fechar conclui o arquivo e precisa
+// ser chamado mesmo sem linhas.
+type Escritor interface {
+	Escrever(linha []string) error
+	Fechar() error
+}
+
+// NovoEscritor cria um escritor do formato em w.
+func NovoEscritor(formato string, w io.Writer) (Escritor, error) {
+	switch formato {
+	case CSV:
+		return novoEscritorCSV(w)
+	case XLSX:
+		return novoEscritorXLSX(w)
+	}
+	return nil, ErrFormato
+}
+
+// Ler devolve todas as linhas da planilha. No XLSX vale a primeira aba;
+// células vazias no fim da linha podem ser omitidas.
+func Ler(formato string, r io.ReaderAt, tamanho int64) ([][]string, error) {
+	switch formato {
+	case CSV:
+		return lerCSV(io.NewSectionReader(r, 0, tamanho))
+	case XLSX:
+		return lerXLSX(r, tamanho)
+	}
+	return nil, ErrFormato
+}
+
+// FormatoDoArquivo deduz o formato pela extensão do nome do arquivo.
+func FormatoDoArquivo(nome string) (string, error) {
+	nome = strings.ToLower(nome)
+	for _, formato := range Formatos {
+		if strings.HasSuffix(nome, "."+formato) {
+			return formato, nil
+		}
+	}
+	return "", ErrFormato
+}
+
+// ContentType devolve o tipo MIME do formato, para o download.
+func ContentType(formato string) string {
+	if formato == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
@@ -0,0 +1,176 @@
+package planilha
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// linhasTeste cobre o que costuma quebrar numa planilha: acentos,
+// separadores e aspas dentro do texto, quebras de linha, caracteres do
+// XML, espaços nas pontas, zeros à esquerda e células vazias no meio.
+var linhasTeste = [][]string{
+	{"nome", "sku", "preco", "quantidade", "detalhes"},
+	{"Memória DDR5 16GB", "MEM-16", "1299.90", "10", `Kit "dual channel", 2x8GB; 6000 MHz`},
+	{"Cabo <SATA> & força", "", "0.5", "0", "linha 1\nlinha 2"},
+	{"  espaços  ", "00123", "1e5", "-3", ""},
+	{"SSD", "", "", "7", "só o último"},
+}
+
+func escrever(t *testing.T, formato string, linhas [][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	e, err := NovoEscritor(formato, &buf)
+	if err != nil {
+		t.Fatalf("escritor %s: %v", formato, err)
+	}
+	for _, l := range linhas {
+		if err := e.Escrever(l); err != nil {
+			t.Fatalf("escrever %s: %v", formato, err)
+		}
+	}
+	if err := e.Fechar(); err != nil {
+		t.Fatalf("fechar %s: %v", formato, err)
+	}
+	return buf.Bytes()
+}
+
+func ler(t *testing.T, formato string, conteudo []byte) [][]string {
+	t.Helper()
+	linhas, err := Ler(formato, bytes.NewReader(conteudo), int64(len(conteudo)))
+	if err != nil {
+		t.Fatalf("ler %s: %v", formato, err)
+	}
+	return linhas
+}
+
+// semVaziasNoFim tira as células vazias do fim de cada linha, que o XLSX
+// não grava.
+func semVaziasNoFim(linhas [][]string) [][]string {
+	saida := make([][]string, len(linhas))
+	for i, l := range linhas {
+		n := len(l)
+		for n > 0 && l[n-1] == "" {
+			n--
+		}
+		saida[i] = l[:n]
+	}
+	return saida
+}
+
+func conferirLinhas(t *testing.T, obtidas, esperadas [][]string) {
+	t.Helper()
+	obtidas, esperadas = semVaziasNoFim(obtidas), semVaziasNoFim(esperadas)
+	if len(obtidas) != len(esperadas) {
+		t.Fatalf("%d linhas, esperado %d: %q", len(obtidas), len(esperadas), obtidas)
+	}
+	for i := range esperadas {
+		if !slices.Equal(obtidas[i], esperadas[i]) {
+			t.Errorf("linha %d = %q, esperado %q", i+1, obtidas[i], esperadas[i])
+		}
+	}
+}
+
+func TestIdaEVolta(t *testing.T) {
+	for _, formato := range Formatos {
+		t.Run(formato, func(t *testing.T) {
+			conferirLinhas(t, ler(t, formato, escrever(t, formato, linhasTeste)), linhasTeste)
+		})
+	}
+}
+
+func TestIdaEVoltaXLSXColunasAlemDeZ(t *testing.T) {
+	linha := make([]string, 30)
+	for i := range linha {
+		linha[i] = nomeColuna(i)
+	}
+	// Uma linha vazia no meio não some nem desloca as seguintes.
+	linhas := [][]string{linha, {}, {"depois da vazia"}}
+	conferirLinhas(t, ler(t, XLSX, escrever(t, XLSX, linhas)), linhas)
+}
+
+func TestNomeEIndiceColuna(t *testing.T) {
+	casos := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA", 16383: "XFD"}
+	for indice, nome := range casos {
+		if n := nomeColuna(indice); n != nome {
+			t.Errorf("nomeColuna(%d) = %s, esperado %s", indice, n, nome)
+		}
+		if i, err := indiceColuna(nome + "12"); err != nil || i != indice {
+			t.Errorf("indiceColuna(%s12) = %d, %v; esperado %d", nome, i, err, indice)
+		}
+	}
+	for _, ref := range []string{"12", "", "XFE1"} {
+		if _, err := indiceColuna(ref); err == nil {
+			t.Errorf("indiceColuna(%q) aceito, esperado erro", ref)
+		}
+	}
+}
+
+func TestLerCSVComPontoEVirgula(t *testing.T) {
+	// Como o Excel em português salva: BOM, ";" e vírgula decimal.
+	conteudo := "\ufeffnome;preco\r\nTeclado, ABNT2;199,90\r\n"
+	conferirLinhas(t, ler(t, CSV, []byte(conteudo)), [][]string{{"nome", "preco"}, {"Teclado, ABNT2", "199,90"}})
+}
+
+// TestLerXLSXDoExcel lê um pacote no formato que o Excel grava: textos em
+// sharedStrings.xml, um deles formatado em partes, aba com outro nome e
+// linhas puladas.
+func TestLerXLSXDoExcel(t *testing.T) {
+	partes := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Produtos" sheetId="1" r:id="rId3"/><sheet name="Outra" sheetId="2" r:id="rId4"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId4" Target="worksheets/sheet1.xml"/><Relationship Id="rId3" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>nome</t></si><si><t>preco</t></si><si><r><t>Placa </t></r><r><rPr><b/></rPr><t>mãe</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="A1"><v>9</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3"><v>899.9</v></c></row></sheetData></worksheet>`,
+	}
+	conferirLinhas(t, ler(t, XLSX, escreverZip(t, partes)), [][]string{{"nome", "preco"}, nil, {"Placa mãe", "", "899.9"}})
+}
+
+func TestLerXLSXInvalido(t *testing.T) {
+	casos := map[string][]byte{
+		"não é zip":  []byte("nome,preco\n"),
+		"zip vazio":  escreverZip(t, nil),
+		"texto fora": escreverZip(t, map[string]string{"xl/workbook.xml": xlsxWorkbook, "xl/_rels/workbook.xml.rels": xlsxWorkbookRels, "xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>5</v></c></row></sheetData></worksheet>`}),
+	}
+	for nome, conteudo := range casos {
+		if _, err := Ler(XLSX, bytes.NewReader(conteudo), int64(len(conteudo))); err == nil {
+			t.Errorf("%s: aceito, esperado erro", nome)
+		}
+	}
+}
+
+func escreverZip(t *testing.T, partes map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	for nome, conteudo := range partes {
+		f, err := z.Create(nome)
+		if err != nil {
+			t.Fatalf("zip: %v", err)
+		}
+		f.Write([]byte(conteudo))
+	}
+	z.Close()
+	return buf.Bytes()
+}
+
+func TestFormatoDoArquivo(t *testing.T) {
+	casos := map[string]string{"produtos.csv": CSV, "Catálogo.XLSX": XLSX, "planilha.xls": "", "csv": ""}
+	for nome, esperado := range casos {
+		formato, err := FormatoDoArquivo(nome)
+		if formato != esperado || (esperado == "") != errors.Is(err, ErrFormato) {
+			t.Errorf("FormatoDoArquivo(%q) = %q, %v; esperado %q", nome, formato, err, esperado)
+		}
+	}
+	if _, err := NovoEscritor("ods", &strings.Builder{}); !errors.Is(err, ErrFormato) {
+		t.Errorf("escritor ods: %v, esperado ErrFormato", err)
+	}
+}
@@ -0,0 +1,279 @@
+package planilha
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limites de leitura, contra arquivos compactados que explodem ao abrir.
+const (
+	maxParteXLSX  = 50 << 20
+	maxColunaXLSX = 16384
+	// maxLinhasVaziasXLSX limita o preenchimento de linhas puladas.
+	maxLinhasVaziasXLSX = 10000
+)
+
+// Partes fixas do pacote gerado: uma pasta de trabalho com a aba "Planilha1".
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Planilha1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxAbreAba  = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxFechaAba = `</sheetData></worksheet>`
+)
+
+// numero reconhece o texto que pode ir como célula numérica sem mudar ao
+// ser lido de volta (sem zeros à esquerda nem notação científica).
+var numero = regexp.MustCompile(`^-?(0|[1-9][0-9]{0,14})(\.[0-9]{1,6})?$`)
+
+type escritorXLSX struct {
+	zip   *zip.Writer
+	aba   io.Writer
+	linha int
+}
+
+// novoEscritorXLSX grava as partes fixas e deixa a aba aberta, de modo que
+// as linhas vão direto para w à medida que são escritas.
+func novoEscritorXLSX(w io.Writer) (*escritorXLSX, error) {
+	z := zip.NewWriter(w)
+	partes := []struct{ nome, conteudo string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range partes {
+		f, err := z.Create(p.nome)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.conteudo); err != nil {
+			return nil, err
+		}
+	}
+	aba, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(aba, xlsxAbreAba); err != nil {
+		return nil, err
+	}
+	return &escritorXLSX{zip: z, aba: aba}, nil
+}
+
+func (e *escritorXLSX) Escrever(linha []string) error {
+	e.linha++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, e.linha)
+	for i, valor := range linha {
+		if valor == "" {
+			continue
+		}
+		ref := nomeColuna(i) + strconv.Itoa(e.linha)
+		if numero.MatchString(valor) {
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, valor)
+			continue
+		}
+		fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		if err := xml.EscapeText(&b, []byte(valor)); err != nil {
+			return err
+		}
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+	_, err := io.WriteString(e.aba, b.String())
+	return err
+}
+
+func (e *escritorXLSX) Fechar() error {
+	if _, err := io.WriteString(e.aba, xlsxFechaAba); err != nil {
+		return err
+	}
+	return e.zip.Close()
+}
+
+// nomeColuna converte o índice (0 = A) no nome da coluna: A..Z, AA, AB...
+func nomeColuna(i int) string {
+	nome := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		nome = string(rune('A'+(i-1)%26)) + nome
+	}
+	return nome
+}
+
+// indiceColuna faz o caminho inverso a partir de uma referência como "AB12".
+func indiceColuna(ref string) (int, error) {
+	i := 0
+	n := 0
+	for ; n < len(ref) && ref[n] >= 'A' && ref[n] <= 'Z'; n++ {
+		i = i*26 + int(ref[n]-'A'+1)
+	}
+	if n == 0 || i > maxColunaXLSX {
+		return 0, fmt.Errorf("referência de célula inválida: %q", ref)
+	}
+	return i - 1, nil
+}
+
+type xlsxRelacoes struct {
+	Relacoes []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxPastaTrabalho struct {
+	Abas []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxTexto é um texto simples (<t>) ou formatado (vários <r><t>).
+type xlsxTexto struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxTexto) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxAba struct {
+	Linhas []struct {
+		Numero  int `xml:"r,attr"`
+		Celulas []struct {
+			Ref   string    `xml:"r,attr"`
+			Tipo  string    `xml:"t,attr"`
+			Valor string    `xml:"v"`
+			Texto xlsxTexto `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func lerXLSX(r io.ReaderAt, tamanho int64) ([][]string, error) {
+	z, err := zip.NewReader(r, tamanho)
+	if err != nil {
+		return nil, fmt.Errorf("arquivo XLSX inválido: %w", err)
+	}
+	partes := make(map[string]*zip.File, len(z.File))
+	for _, f := range z.File {
+		partes[f.Name] = f
+	}
+	lerParte := func(nome string, destino any) error {
+		f, ok := partes[nome]
+		if !ok {
+			return fmt.Errorf("arquivo XLSX sem a parte %s", nome)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(io.LimitReader(rc, maxParteXLSX)).Decode(destino); err != nil {
+			return fmt.Errorf("parte %s do XLSX inválida: %w", nome, err)
+		}
+		return nil
+	}
+
+	// A primeira aba é a primeira de workbook.xml, resolvida pelas relações.
+	var pasta xlsxPastaTrabalho
+	if err := lerParte("xl/workbook.xml", &pasta); err != nil {
+		return nil, err
+	}
+	if len(pasta.Abas) == 0 {
+		return nil, fmt.Errorf("arquivo XLSX sem abas")
+	}
+	var relacoes xlsxRelacoes
+	if err := lerParte("xl/_rels/workbook.xml.rels", &relacoes); err != nil {
+		return nil, err
+	}
+	nomeAba := ""
+	for _, rel := range relacoes.Relacoes {
+		if rel.ID == pasta.Abas[0].RID {
+			nomeAba = rel.Target
+		}
+	}
+	if nomeAba == "" {
+		return nil, fmt.Errorf("arquivo XLSX sem a primeira aba")
+	}
+	if strings.HasPrefix(nomeAba, "/") {
+		nomeAba = strings.TrimPrefix(nomeAba, "/")
+	} else {
+		nomeAba = path.Join("xl", nomeAba)
+	}
+
+	var compartilhados []string
+	if _, ok := partes["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Itens []xlsxTexto `xml:"si"`
+		}
+		if err := lerParte("xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		compartilhados = make([]string, len(sst.Itens))
+		for i, item := range sst.Itens {
+			compartilhados[i] = item.String()
+		}
+	}
+
+	var aba xlsxAba
+	if err := lerParte(nomeAba, &aba); err != nil {
+		return nil, err
+	}
+	linhas := make([][]string, 0, len(aba.Linhas))
+	for _, l := range aba.Linhas {
+		// Linhas vazias não aparecem no arquivo; mantém a numeração.
+		for l.Numero > len(linhas)+1 && l.Numero <= len(aba.Linhas)+maxLinhasVaziasXLSX {
+			linhas = append(linhas, nil)
+		}
+		linha := make([]string, 0, len(l.Celulas))
+		for _, c := range l.Celulas {
+			coluna := len(linha)
+			if c.Ref != "" {
+				if coluna, err = indiceColuna(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			valor := c.Valor
+			switch c.Tipo {
+			case "s":
+				i, err := strconv.Atoi(c.Valor)
+				if err != nil || i < 0 || i >= len(compartilhados) {
+					return nil, fmt.Errorf("célula %s aponta para um texto inexistente", c.Ref)
+				}
+				valor = compartilhados[i]
+			case "inlineStr":
+				valor = c.Texto.String()
+			}
+			for len(linha) < coluna {
+				linha = append(linha, "")
+			}
+			if coluna < len(linha) {
+				linha[coluna] = valor
+			} else {
+				linha = append(linha, valor)
+			}
+		}
+		linhas = append(linhas, linha)
+	}
+	return linhas, nil
+}
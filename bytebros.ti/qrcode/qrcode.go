@@ -0,0 +1,495 @@
+// Package qrcode gera QR Codes (ISO/IEC 18004) em modo byte, só com a
+// biblioteca padrão. É o suficiente para os códigos Pix "copia e cola".
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Nivel é o nível de correção de erros.
+type Nivel int
+
+const (
+	NivelL Nivel = iota // ~7%
+	NivelM              // ~15%
+	NivelQ              // ~25%
+	NivelH              // ~30%
+)
+
+// bitsFormato são os dois bits do nível na informação de formato.
+var bitsFormato = [4]int{1, 0, 3, 2}
+
+// Tabelas 9 e 7 da norma: palavras de correção por bloco e número de
+// blocos, por nível e versão (o índice 0 não é usado).
+var eccPorBloco = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var blocosECC = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// ErrGrandeDemais indica um conteúdo que não cabe na versão 40.
+var ErrGrandeDemais = errors.New("conteúdo grande demais para um QR Code")
+
+// Codigo é a matriz de módulos; true é escuro.
+type Codigo struct {
+	Versao  int
+	Tamanho int
+	modulos [][]bool
+	funcao  [][]bool
+}
+
+// Escuro informa se o módulo da coluna x e linha y é escuro.
+func (c *Codigo) Escuro(x, y int) bool {
+	return c.modulos[y][x]
+}
+
+// Gerar codifica o conteúdo na menor versão que o comporta.
+func Gerar(conteudo []byte, nivel Nivel) (*Codigo, error) {
+	versao := 0
+	for v := 1; v <= 40; v++ {
+		if 4+bitsContagem(v)+8*len(conteudo) <= palavrasDados(v, nivel)*8 {
+			versao = v
+			break
+		}
+	}
+	if versao == 0 {
+		return nil, ErrGrandeDemais
+	}
+
+	// Modo byte (0100), contagem e dados; depois terminador e preenchimento.
+	var bits bufferBits
+	bits.escrever(0x4, 4)
+	bits.escrever(len(conteudo), bitsContagem(versao))
+	for _, b := range conteudo {
+		bits.escrever(int(b), 8)
+	}
+	capacidade := palavrasDados(versao, nivel) * 8
+	bits.escrever(0, min(4, capacidade-len(bits)))
+	bits.escrever(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacidade; pad ^= 0xEC ^ 0x11 {
+		bits.escrever(pad, 8)
+	}
+	dados := make([]byte, len(bits)/8)
+	for i, b := range bits {
+		if b {
+			dados[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	c := novoCodigo(versao)
+	c.desenharPadroes(nivel)
+	c.desenharPalavras(intercalarECC(dados, versao, nivel))
+
+	melhor, menor := 0, -1
+	for mascara := 0; mascara < 8; mascara++ {
+		c.aplicarMascara(mascara)
+		c.desenharFormato(nivel, mascara)
+		if p := c.penalidade(); menor < 0 || p < menor {
+			melhor, menor = mascara, p
+		}
+		c.aplicarMascara(mascara)
+	}
+	c.aplicarMascara(melhor)
+	c.desenharFormato(nivel, melhor)
+	return c, nil
+}
+
+// PNG desenha o código com escala pixels por módulo e a margem de 4
+// módulos exigida pela norma.
+func (c *Codigo) PNG(escala int) ([]byte, error) {
+	if escala < 1 {
+		escala = 1
+	}
+	const margem = 4
+	lado := (c.Tamanho + 2*margem) * escala
+	img := image.NewGray(image.Rect(0, 0, lado, lado))
+	for y := 0; y < lado; y++ {
+		for x := 0; x < lado; x++ {
+			mx, my := x/escala-margem, y/escala-margem
+			cor := color.Gray{Y: 0xFF}
+			if mx >= 0 && my >= 0 && mx < c.Tamanho && my < c.Tamanho && c.modulos[my][mx] {
+				cor = color.Gray{Y: 0}
+			}
+			img.SetGray(x, y, cor)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type bufferBits []bool
+
+func (b *bufferBits) escrever(valor, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (valor>>i)&1 == 1)
+	}
+}
+
+func bitsContagem(versao int) int {
+	if versao <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// modulosDados conta os módulos livres para dados e correção na versão.
+func modulosDados(versao int) int {
+	n := (16*versao+128)*versao + 64
+	if versao >= 2 {
+		alinhamentos := versao/7 + 2
+		n -= (25*alinhamentos-10)*alinhamentos - 55
+		if versao >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func palavrasDados(versao int, nivel Nivel) int {
+	return modulosDados(versao)/8 - eccPorBloco[nivel][versao]*blocosECC[nivel][versao]
+}
+
+// intercalarECC divide os dados em blocos, calcula a correção Reed-Solomon
+// de cada um e intercala as palavras como a norma manda.
+func intercalarECC(dados []byte, versao int, nivel Nivel) []byte {
+	numBlocos := blocosECC[nivel][versao]
+	eccBloco := eccPorBloco[nivel][versao]
+	totalPalavras := modulosDados(versao) / 8
+	curtos := numBlocos - totalPalavras%numBlocos
+	tamCurto := totalPalavras / numBlocos
+
+	divisor := divisorRS(eccBloco)
+	blocos := make([][]byte, numBlocos)
+	k := 0
+	for i := range blocos {
+		n := tamCurto - eccBloco
+		if i >= curtos {
+			n++
+		}
+		dat := dados[k : k+n]
+		k += n
+		bloco := append([]byte{}, dat...)
+		if i < curtos {
+			bloco = append(bloco, 0) // posição vazia, pulada na intercalação
+		}
+		blocos[i] = append(bloco, restoRS(dat, divisor)...)
+	}
+
+	resultado := make([]byte, 0, totalPalavras)
+	for i := 0; i < len(blocos[0]); i++ {
+		for j, bloco := range blocos {
+			if i != tamCurto-eccBloco || j >= curtos {
+				resultado = append(resultado, bloco[i])
+			}
+		}
+	}
+	return resultado
+}
+
+func divisorRS(grau int) []byte {
+	resultado := make([]byte, grau)
+	resultado[grau-1] = 1
+	raiz := byte(1)
+	for i := 0; i < grau; i++ {
+		for j := range resultado {
+			resultado[j] = multiplicarGF(resultado[j], raiz)
+			if j+1 < len(resultado) {
+				resultado[j] ^= resultado[j+1]
+			}
+		}
+		raiz = multiplicarGF(raiz, 0x02)
+	}
+	return resultado
+}
+
+func restoRS(dados, divisor []byte) []byte {
+	resultado := make([]byte, len(divisor))
+	for _, b := range dados {
+		fator := b ^ resultado[0]
+		copy(resultado, resultado[1:])
+		resultado[len(resultado)-1] = 0
+		for i := range resultado {
+			resultado[i] ^= multiplicarGF(divisor[i], fator)
+		}
+	}
+	return resultado
+}
+
+// multiplicarGF multiplica em GF(2^8) com o polinômio 0x11D.
+func multiplicarGF(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func novoCodigo(versao int) *Codigo {
+	tamanho := versao*4 + 17
+	c := &Codigo{Versao: versao, Tamanho: tamanho, modulos: make([][]bool, tamanho), funcao: make([][]bool, tamanho)}
+	for i := range c.modulos {
+		c.modulos[i] = make([]bool, tamanho)
+		c.funcao[i] = make([]bool, tamanho)
+	}
+	return c
+}
+
+func (c *Codigo) fixar(x, y int, escuro bool) {
+	c.modulos[y][x] = escuro
+	c.funcao[y][x] = true
+}
+
+// desenharPadroes desenha localizadores, temporização, alinhamentos e
+// versão, e reserva a área de formato.
+func (c *Codigo) desenharPadroes(nivel Nivel) {
+	for i := 0; i < c.Tamanho; i++ {
+		c.fixar(6, i, i%2 == 0)
+		c.fixar(i, 6, i%2 == 0)
+	}
+	c.desenharLocalizador(3, 3)
+	c.desenharLocalizador(c.Tamanho-4, 3)
+	c.desenharLocalizador(3, c.Tamanho-4)
+
+	posicoes := c.posicoesAlinhamento()
+	n := len(posicoes)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == 0 && j == 0 || i == 0 && j == n-1 || i == n-1 && j == 0 {
+				continue // sobrepostos aos localizadores
+			}
+			c.desenharAlinhamento(posicoes[i], posicoes[j])
+		}
+	}
+
+	c.desenharFormato(nivel, 0)
+	c.desenharVersao()
+}
+
+func (c *Codigo) desenharLocalizador(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.Tamanho || yy >= c.Tamanho {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.fixar(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Codigo) desenharAlinhamento(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.fixar(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func (c *Codigo) posicoesAlinhamento() []int {
+	if c.Versao == 1 {
+		return nil
+	}
+	n := c.Versao/7 + 2
+	passo := (c.Versao*8 + n*3 + 5) / (n*4 - 4) * 2
+	posicoes := make([]int, n)
+	posicoes[0] = 6
+	for i, pos := n-1, c.Tamanho-7; i >= 1; i, pos = i-1, pos-passo {
+		posicoes[i] = pos
+	}
+	return posicoes
+}
+
+func (c *Codigo) desenharFormato(nivel Nivel, mascara int) {
+	dados := bitsFormato[nivel]<<3 | mascara
+	resto := dados
+	for i := 0; i < 10; i++ {
+		resto = (resto << 1) ^ ((resto >> 9) * 0x537)
+	}
+	bits := (dados<<10 | resto) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.fixar(8, i, bit(i))
+	}
+	c.fixar(8, 7, bit(6))
+	c.fixar(8, 8, bit(7))
+	c.fixar(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.fixar(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.fixar(c.Tamanho-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.fixar(8, c.Tamanho-15+i, bit(i))
+	}
+	c.fixar(8, c.Tamanho-8, true)
+}
+
+func (c *Codigo) desenharVersao() {
+	if c.Versao < 7 {
+		return
+	}
+	resto := c.Versao
+	for i := 0; i < 12; i++ {
+		resto = (resto << 1) ^ ((resto >> 11) * 0x1F25)
+	}
+	bits := c.Versao<<12 | resto
+	for i := 0; i < 18; i++ {
+		escuro := (bits>>i)&1 == 1
+		a, b := c.Tamanho-11+i%3, i/3
+		c.fixar(a, b, escuro)
+		c.fixar(b, a, escuro)
+	}
+}
+
+// desenharPalavras preenche os módulos livres em zigue-zague, de duas em
+// duas colunas a partir do canto inferior direito.
+func (c *Codigo) desenharPalavras(palavras []byte) {
+	i := 0
+	for direita := c.Tamanho - 1; direita >= 1; direita -= 2 {
+		if direita == 6 {
+			direita = 5
+		}
+		for vert := 0; vert < c.Tamanho; vert++ {
+			for j := 0; j < 2; j++ {
+				x := direita - j
+				y := vert
+				if (direita+1)&2 == 0 {
+					y = c.Tamanho - 1 - vert
+				}
+				if !c.funcao[y][x] && i < len(palavras)*8 {
+					c.modulos[y][x] = (palavras[i>>3]>>(7-i&7))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (c *Codigo) aplicarMascara(mascara int) {
+	for y := 0; y < c.Tamanho; y++ {
+		for x := 0; x < c.Tamanho; x++ {
+			var inverter bool
+			switch mascara {
+			case 0:
+				inverter = (x+y)%2 == 0
+			case 1:
+				inverter = y%2 == 0
+			case 2:
+				inverter = x%3 == 0
+			case 3:
+				inverter = (x+y)%3 == 0
+			case 4:
+				inverter = (x/3+y/2)%2 == 0
+			case 5:
+				inverter = x*y%2+x*y%3 == 0
+			case 6:
+				inverter = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				inverter = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if inverter && !c.funcao[y][x] {
+				c.modulos[y][x] = !c.modulos[y][x]
+			}
+		}
+	}
+}
+
+// penalidade soma as quatro regras da norma usadas na escolha da máscara.
+func (c *Codigo) penalidade() int {
+	n := c.Tamanho
+	p := 0
+	linha := func(i, j int, horizontal bool) bool {
+		if horizontal {
+			return c.modulos[i][j]
+		}
+		return c.modulos[j][i]
+	}
+	for _, horizontal := range []bool{true, false} {
+		for i := 0; i < n; i++ {
+			// Regra 1: cinco ou mais módulos seguidos da mesma cor.
+			seguidos := 1
+			for j := 1; j < n; j++ {
+				if linha(i, j, horizontal) == linha(i, j-1, horizontal) {
+					seguidos++
+					continue
+				}
+				if seguidos >= 5 {
+					p += seguidos - 2
+				}
+				seguidos = 1
+			}
+			if seguidos >= 5 {
+				p += seguidos - 2
+			}
+			// Regra 3: 1:1:3:1:1 com quatro módulos claros de um dos lados.
+			for j := 0; j+7 <= n; j++ {
+				padrao := true
+				for k, escuro := range []bool{true, false, true, true, true, false, true} {
+					if linha(i, j+k, horizontal) != escuro {
+						padrao = false
+						break
+					}
+				}
+				if padrao && (claros(i, j-4, j, horizontal, linha, n) || claros(i, j+7, j+11, horizontal, linha, n)) {
+					p += 40
+				}
+			}
+		}
+	}
+	// Regra 2: blocos 2x2 da mesma cor.
+	escuros := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.modulos[y][x] {
+				escuros++
+			}
+			if x+1 < n && y+1 < n {
+				m := c.modulos[y][x]
+				if m == c.modulos[y][x+1] && m == c.modulos[y+1][x] && m == c.modulos[y+1][x+1] {
+					p += 3
+				}
+			}
+		}
+	}
+	// Regra 4: proporção de módulos escuros longe de 50%.
+	desvio := abs(escuros*20-n*n*10) / (n * n)
+	return p + desvio*10
+}
+
+// claros informa se os módulos de de até ate (exclusive) na linha são
+// claros; fora da matriz conta como claro.
+func claros(i, de, ate int, horizontal bool, linha func(i, j int, horizontal bool) bool, n int) bool {
+	for j := de; j < ate; j++ {
+		if j >= 0 && j < n && linha(i, j, horizontal) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
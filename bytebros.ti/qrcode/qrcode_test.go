@@ -0,0 +1,249 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+// Exemplo "HELLO WORLD" 1-M do tutorial de QR Codes da Thonky: as palavras
+// de dados e as 10 de correção publicadas.
+func TestRestoRSExemploHelloWorld(t *testing.T) {
+	dados := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	esperado := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if resto := restoRS(dados, divisorRS(10)); !bytes.Equal(resto, esperado) {
+		t.Errorf("correção = %v, esperado %v", resto, esperado)
+	}
+}
+
+// lerFormato lê os 15 bits de formato da cópia junto ao localizador
+// superior esquerdo.
+func lerFormato(c *Codigo) int {
+	bits := 0
+	ler := func(i, x, y int) {
+		if c.Escuro(x, y) {
+			bits |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		ler(i, 8, i)
+	}
+	ler(6, 8, 7)
+	ler(7, 8, 8)
+	ler(8, 7, 8)
+	for i := 9; i < 15; i++ {
+		ler(i, 14-i, 8)
+	}
+	return bits
+}
+
+// lerFormatoCopia lê a segunda cópia, dividida entre os outros dois
+// localizadores.
+func lerFormatoCopia(c *Codigo) int {
+	bits := 0
+	for i := 0; i < 15; i++ {
+		x, y := c.Tamanho-1-i, 8
+		if i >= 8 {
+			x, y = 8, c.Tamanho-15+i
+		}
+		if c.Escuro(x, y) {
+			bits |= 1 << i
+		}
+	}
+	return bits
+}
+
+// Tabela C.1 da norma, máscara 0 de cada nível.
+func TestFormatoTabelaDaNorma(t *testing.T) {
+	casos := []struct {
+		nivel Nivel
+		bits  int
+	}{
+		{NivelL, 0b111011111000100},
+		{NivelM, 0b101010000010010},
+		{NivelQ, 0b011010101011111},
+		{NivelH, 0b001011010001001},
+	}
+	for _, caso := range casos {
+		c := novoCodigo(1)
+		c.desenharFormato(caso.nivel, 0)
+		if bits := lerFormato(c); bits != caso.bits {
+			t.Errorf("formato do nível %d = %015b, esperado %015b", caso.nivel, bits, caso.bits)
+		}
+		if bits := lerFormatoCopia(c); bits != caso.bits {
+			t.Errorf("cópia do formato do nível %d = %015b, esperado %015b", caso.nivel, bits, caso.bits)
+		}
+	}
+}
+
+// Tabela D.1 da norma: informação de versão da versão 7.
+func TestVersaoTabelaDaNorma(t *testing.T) {
+	c := novoCodigo(7)
+	c.desenharVersao()
+	bits := 0
+	for i := 0; i < 18; i++ {
+		if c.Escuro(c.Tamanho-11+i%3, i/3) {
+			bits |= 1 << i
+		}
+		if c.Escuro(i/3, c.Tamanho-11+i%3) != c.Escuro(c.Tamanho-11+i%3, i/3) {
+			t.Fatalf("as duas cópias da versão diferem no bit %d", i)
+		}
+	}
+	if bits != 0b000111110010010100 {
+		t.Errorf("versão 7 = %018b, esperado 000111110010010100", bits)
+	}
+}
+
+// payloadPix é o BR Code de pagamento/pix_test.go: 183 bytes, versão 10-M,
+// com blocos de dois tamanhos e informação de versão.
+const payloadPix = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-42665544000052040000530398654071234.505802BR5925BYTE BROS INFORMATICA LTD6009SAO PAULO62290525BB0000000042abcDEF12345676304854F"
+
+// TestGerarDecodifica lê de volta a matriz gerada: localizadores, formato,
+// palavras em zigue-zague, blocos Reed-Solomon e o conteúdo em modo byte.
+func TestGerarDecodifica(t *testing.T) {
+	c, err := Gerar([]byte(payloadPix), NivelM)
+	if err != nil {
+		t.Fatalf("gerar: %v", err)
+	}
+	if c.Versao != 10 || c.Tamanho != 57 {
+		t.Fatalf("versão %d com %d módulos, esperado 10 com 57", c.Versao, c.Tamanho)
+	}
+
+	for _, canto := range [][2]int{{0, 0}, {c.Tamanho - 7, 0}, {0, c.Tamanho - 7}} {
+		for d := 0; d < 7; d++ {
+			borda := c.Escuro(canto[0]+d, canto[1]) && c.Escuro(canto[0], canto[1]+d)
+			if !borda || !c.Escuro(canto[0]+3, canto[1]+3) || c.Escuro(canto[0]+1, canto[1]+1) {
+				t.Fatalf("localizador em %v incompleto", canto)
+			}
+		}
+	}
+	for i := 8; i < c.Tamanho-8; i++ {
+		if c.Escuro(i, 6) != (i%2 == 0) || c.Escuro(6, i) != (i%2 == 0) {
+			t.Fatalf("temporização errada no módulo %d", i)
+		}
+	}
+
+	formato := lerFormato(c)
+	if formato != lerFormatoCopia(c) {
+		t.Fatalf("cópias do formato diferem: %015b e %015b", formato, lerFormatoCopia(c))
+	}
+	dadosFormato := (formato ^ 0x5412) >> 10
+	if dadosFormato>>3 != bitsFormato[NivelM] {
+		t.Fatalf("formato %015b não é do nível M", formato)
+	}
+	mascara := dadosFormato & 7
+
+	// Desfaz a máscara e lê os módulos de dados na ordem de desenharPalavras.
+	c.aplicarMascara(mascara)
+	total := modulosDados(c.Versao) / 8
+	palavras := make([]byte, total)
+	i := 0
+	for direita := c.Tamanho - 1; direita >= 1; direita -= 2 {
+		if direita == 6 {
+			direita = 5
+		}
+		for vert := 0; vert < c.Tamanho; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := direita-j, vert
+				if (direita+1)&2 == 0 {
+					y = c.Tamanho - 1 - vert
+				}
+				if !c.funcao[y][x] && i < total*8 {
+					if c.Escuro(x, y) {
+						palavras[i>>3] |= 1 << (7 - i&7)
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	// Separa os blocos: primeiro as palavras de dados intercaladas, depois
+	// as de correção.
+	numBlocos, ecc := blocosECC[NivelM][c.Versao], eccPorBloco[NivelM][c.Versao]
+	curtos := numBlocos - total%numBlocos
+	dados := make([][]byte, numBlocos)
+	correcao := make([][]byte, numBlocos)
+	k := 0
+	for i := 0; i <= total/numBlocos-ecc; i++ {
+		for b := range dados {
+			if i < total/numBlocos-ecc || b >= curtos {
+				dados[b] = append(dados[b], palavras[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < ecc; i++ {
+		for b := range correcao {
+			correcao[b] = append(correcao[b], palavras[k])
+			k++
+		}
+	}
+	var conteudo []byte
+	for b := range dados {
+		if resto := restoRS(dados[b], divisorRS(ecc)); !bytes.Equal(resto, correcao[b]) {
+			t.Fatalf("correção do bloco %d não confere", b)
+		}
+		conteudo = append(conteudo, dados[b]...)
+	}
+
+	lerBits := func(pos, n int) int {
+		v := 0
+		for j := pos; j < pos+n; j++ {
+			v = v<<1 | int(conteudo[j>>3]>>(7-j&7)&1)
+		}
+		return v
+	}
+	if modo := lerBits(0, 4); modo != 0x4 {
+		t.Fatalf("modo %04b, esperado 0100 (byte)", modo)
+	}
+	n := lerBits(4, bitsContagem(c.Versao))
+	if n != len(payloadPix) {
+		t.Fatalf("contagem %d, esperado %d", n, len(payloadPix))
+	}
+	lido := make([]byte, n)
+	for j := range lido {
+		lido[j] = byte(lerBits(4+bitsContagem(c.Versao)+8*j, 8))
+	}
+	if string(lido) != payloadPix {
+		t.Errorf("conteúdo lido = %q, esperado %q", lido, payloadPix)
+	}
+}
+
+func TestGerarGrandeDemais(t *testing.T) {
+	if _, err := Gerar(make([]byte, 2954), NivelL); err != ErrGrandeDemais {
+		t.Errorf("2954 bytes no nível L: %v, esperado ErrGrandeDemais", err)
+	}
+	if c, err := Gerar(make([]byte, 2953), NivelL); err != nil || c.Versao != 40 {
+		t.Errorf("2953 bytes no nível L: versão %v, %v; esperado versão 40", c, err)
+	}
+}
+
+func TestPNGComMargem(t *testing.T) {
+	c, err := Gerar([]byte(payloadPix), NivelM)
+	if err != nil {
+		t.Fatalf("gerar: %v", err)
+	}
+	dados, err := c.PNG(3)
+	if err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(dados))
+	if err != nil {
+		t.Fatalf("decodificar png: %v", err)
+	}
+	lado := (c.Tamanho + 8) * 3
+	if b := img.Bounds(); b.Dx() != lado || b.Dy() != lado {
+		t.Fatalf("imagem %dx%d, esperado %dx%d", b.Dx(), b.Dy(), lado, lado)
+	}
+	escuro := func(x, y int) bool {
+		r, _, _, _ := img.At(x, y).RGBA()
+		return r == 0
+	}
+	if escuro(0, 0) || escuro(4*3-1, 4*3-1) {
+		t.Error("margem de 4 módulos deveria ser clara")
+	}
+	if !escuro(4*3, 4*3) || !escuro(4*3+2, 4*3+2) {
+		t.Error("canto do localizador deveria ser escuro")
+	}
+}
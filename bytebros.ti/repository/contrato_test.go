@@ -0,0 +1,305 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"bytebros.ti/database"
+	"bytebros.ti/models"
+	"bytebros.ti/repository"
+)
+
+// errDesfazer encerra a transação de cada caso para que nada fique gravado,
+// inclusive no banco de TEST_DATABASE_URL.
+var errDesfazer = errors.New("desfazer")
+
+// implementacoes devolve as implementações a comparar: a em memória sempre e
+// a do PostgreSQL quando TEST_DATABASE_URL aponta para um banco de teste.
+func implementacoes(t *testing.T) map[string]*repository.Repositorios {
+	t.Helper()
+	impls := map[string]*repository.Repositorios{"memoria": repository.NovoMemoria()}
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Log("TEST_DATABASE_URL não definida; comparando só a implementação em memória")
+		return impls
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("abrir banco de teste: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	database.DB = db
+	if err := database.Migrar(); err != nil {
+		t.Fatalf("migrar banco de teste: %v", err)
+	}
+	impls["postgres"] = repository.NovoPostgres(db)
+	return impls
+}
+
+// contrato roda caso contra cada implementação dentro de uma transação que é
+// sempre desfeita. No PostgreSQL um erro de restrição aborta a transação, por
+// isso cada caso provoca no máximo um, no fim.
+func contrato(t *testing.T, caso func(t *testing.T, r *repository.Repositorios)) {
+	for nome, r := range implementacoes(t) {
+		t.Run(nome, func(t *testing.T) {
+			err := r.Transacao(func(tx *repository.Repositorios) error {
+				caso(t, tx)
+				return errDesfazer
+			})
+			if !errors.Is(err, errDesfazer) {
+				t.Fatalf("transação: %v", err)
+			}
+		})
+	}
+}
+
+func criarProduto(t *testing.T, r *repository.Repositorios, nome string, quantidade int) models.Produto {
+	t.Helper()
+	p := models.Produto{Nome: nome, Quantidade: quantidade, Preco: 100}
+	if err := r.Produtos.Criar(&p); err != nil {
+		t.Fatalf("criar produto %q: %v", nome, err)
+	}
+	return p
+}
+
+func TestContratoProdutoObterEAjustarEstoque(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		p := criarProduto(t, r, "Teclado", 5)
+		if p.ID == 0 {
+			t.Fatal("Criar não preencheu o id")
+		}
+		if err := r.Produtos.AjustarEstoque(p.ID, -2); err != nil {
+			t.Fatalf("ajustar estoque: %v", err)
+		}
+		atual, err := r.Produtos.Obter(p.ID)
+		if err != nil {
+			t.Fatalf("obter produto: %v", err)
+		}
+		if atual.Nome != "Teclado" || atual.Quantidade != 3 {
+			t.Errorf("produto = %q com %d, esperado %q com 3", atual.Nome, atual.Quantidade, "Teclado")
+		}
+		if err := r.Produtos.AjustarEstoque(p.ID+1000, 1); !errors.Is(err, repository.ErrNaoEncontrado) {
+			t.Errorf("ajustar estoque de produto inexistente: %v, esperado ErrNaoEncontrado", err)
+		}
+		if _, err := r.Produtos.Obter(p.ID + 1000); !errors.Is(err, repository.ErrNaoEncontrado) {
+			t.Errorf("obter produto inexistente: %v, esperado ErrNaoEncontrado", err)
+		}
+	})
+}
+
+func TestContratoProdutoBloquearIgnoraInexistentes(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		a := criarProduto(t, r, "Monitor", 2)
+		b := criarProduto(t, r, "Webcam", 4)
+		bloqueados, err := r.Produtos.Bloquear([]int{b.ID, a.ID, b.ID + 1000, a.ID})
+		if err != nil {
+			t.Fatalf("bloquear: %v", err)
+		}
+		if len(bloqueados) != 2 || bloqueados[a.ID].Quantidade != 2 || bloqueados[b.ID].Quantidade != 4 {
+			t.Errorf("bloqueados = %+v, esperado só os produtos %d e %d", bloqueados, a.ID, b.ID)
+		}
+	})
+}
+
+func TestContratoProdutoListarContaOTotal(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		_, antes, err := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{Limite: 1})
+		if err != nil {
+			t.Fatalf("listar: %v", err)
+		}
+		for _, nome := range []string{"SSD", "HD", "Memória"} {
+			criarProduto(t, r, nome, 1)
+		}
+		pagina, total, err := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{Limite: 2})
+		if err != nil {
+			t.Fatalf("listar: %v", err)
+		}
+		if total != antes+3 || len(pagina) != 2 {
+			t.Errorf("listar devolveu %d de %d, esperado 2 de %d", len(pagina), total, antes+3)
+		}
+	})
+}
+
+func TestContratoProdutoDeletarInexistente(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		p := criarProduto(t, r, "Mousepad", 1)
+		if err := r.Produtos.Deletar(p.ID); err != nil {
+			t.Fatalf("deletar: %v", err)
+		}
+		if err := r.Produtos.Deletar(p.ID); !errors.Is(err, repository.ErrNaoEncontrado) {
+			t.Errorf("deletar de novo: %v, esperado ErrNaoEncontrado", err)
+		}
+	})
+}
+
+func TestContratoProdutoEmPedidoNaoPodeSerDeletado(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		p := criarProduto(t, r, "Headset", 3)
+		pedido := models.Pedido{
+			ClienteEmail:    "cliente@example.com",
+			Status:          models.StatusPedidoProcessando,
+			EnderecoEntrega: "Rua A, 1",
+			TipoFrete:       "PAC",
+			ValorTotal:      100,
+			FormaPagamento:  "transferencia",
+			Itens:           []models.PedidoItem{{ProdutoID: p.ID, NomeProduto: p.Nome, Quantidade: 1, ValorUnitario: 100}},
+		}
+		if err := r.Pedidos.Criar(&pedido); err != nil {
+			t.Fatalf("criar pedido: %v", err)
+		}
+		itens, err := r.Pedidos.Itens(pedido.ID)
+		if err != nil {
+			t.Fatalf("itens: %v", err)
+		}
+		if len(itens) != 1 || itens[0].ProdutoID != p.ID || itens[0].PedidoID != pedido.ID {
+			t.Fatalf("itens = %+v, esperado um item do produto %d", itens, p.ID)
+		}
+		if err := r.Produtos.Deletar(p.ID); !errors.Is(err, repository.ErrEmUso) {
+			t.Errorf("deletar produto em pedido: %v, esperado ErrEmUso", err)
+		}
+	})
+}
+
+func TestContratoCategoriaSlugDuplicado(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		c := models.Categoria{Nome: "Periféricos", Slug: "perifericos-contrato"}
+		if err := r.Categorias.Criar(&c); err != nil {
+			t.Fatalf("criar categoria: %v", err)
+		}
+		obtida, err := r.Categorias.ObterPorSlug(c.Slug)
+		if err != nil || obtida.ID != c.ID {
+			t.Fatalf("obter por slug = %+v, %v; esperado id %d", obtida, err, c.ID)
+		}
+		outra := models.Categoria{Nome: "Periféricos 2", Slug: c.Slug}
+		if err := r.Categorias.Criar(&outra); !errors.Is(err, repository.ErrDuplicado) {
+			t.Errorf("criar slug repetido: %v, esperado ErrDuplicado", err)
+		}
+	})
+}
+
+// Os preços caem nos limites de LimitesFaixasPreco para conferir que as
+// duas implementações usam o intervalo [Min, Max). O termo é inventado para
+// não casar com produtos que já existam no banco de teste.
+func TestContratoBuscaFacetasEFaixasDePreco(t *testing.T) {
+	contrato(t, func(t *testing.T, r *repository.Repositorios) {
+		c := models.Categoria{Nome: "Quixabas", Slug: "quixabas-contrato"}
+		if err := r.Categorias.Criar(&c); err != nil {
+			t.Fatalf("criar categoria: %v", err)
+		}
+		produtos := []struct {
+			preco     float64
+			estoque   int
+			oferta    bool
+			categoria bool
+		}{
+			{99.99, 1, false, true},
+			{100, 0, true, true},
+			{500, 2, false, false},
+			{999.99, 3, true, false},
+			{3000, 0, false, true},
+			{4500, 1, false, false},
+		}
+		ids := make(map[float64]int)
+		for _, dados := range produtos {
+			p := models.Produto{Nome: fmt.Sprintf("Xylofone Quasar %.2f", dados.preco), Quantidade: dados.estoque, Preco: dados.preco, Oferta: dados.oferta}
+			if err := r.Produtos.Criar(&p); err != nil {
+				t.Fatalf("criar produto: %v", err)
+			}
+			ids[dados.preco] = p.ID
+			if dados.categoria {
+				if err := r.Produtos.DefinirCategorias(p.ID, []int{c.ID}); err != nil {
+					t.Fatalf("definir categorias: %v", err)
+				}
+			}
+		}
+		// Fora da busca: não entra em nenhuma faceta.
+		criarProduto(t, r, "Mousepad", 1)
+
+		min, max := 100.0, 1000.0
+		casos := []struct {
+			nome      string
+			filtro    repository.FiltroBusca
+			precos    []float64
+			faixas    []int
+			categoria int
+			estoque   [2]int
+		}{
+			{"todos", repository.FiltroBusca{}, []float64{99.99, 100, 500, 999.99, 3000, 4500}, []int{1, 1, 2, 0, 2}, 3, [2]int{4, 2}},
+			{"faixa de preço inclusiva", repository.FiltroBusca{PrecoMin: &min, PrecoMax: &max}, []float64{100, 500, 999.99}, []int{0, 1, 2, 0, 0}, 1, [2]int{2, 1}},
+			{"em estoque", repository.FiltroBusca{SomenteEmEstoque: true}, []float64{99.99, 500, 999.99, 4500}, []int{1, 0, 2, 0, 1}, 1, [2]int{4, 0}},
+			{"ofertas", repository.FiltroBusca{SomenteOfertas: true}, []float64{100, 999.99}, []int{0, 1, 1, 0, 0}, 1, [2]int{1, 1}},
+			{"categoria", repository.FiltroBusca{CategoriaIDs: []int{c.ID}}, []float64{99.99, 100, 3000}, []int{1, 1, 0, 0, 1}, 3, [2]int{1, 2}},
+		}
+		for _, caso := range casos {
+			caso.filtro.Termo = "xylofone quasar"
+			// A página tem um item, mas total e facetas contam todos.
+			resultado, err := r.Produtos.Buscar(caso.filtro, repository.Pagina{Limite: 1, Ordenar: "value"})
+			if err != nil {
+				t.Fatalf("%s: buscar: %v", caso.nome, err)
+			}
+			if resultado.Total != len(caso.precos) || len(resultado.Produtos) != 1 || resultado.Produtos[0].ID != ids[caso.precos[0]] {
+				t.Errorf("%s: %d de %d resultados, primeiro %+v; esperado 1 de %d começando pelo de %.2f",
+					caso.nome, len(resultado.Produtos), resultado.Total, resultado.Produtos, len(caso.precos), caso.precos[0])
+			}
+
+			faixas := resultado.Facetas.Precos
+			if len(faixas) != len(repository.LimitesFaixasPreco)+1 {
+				t.Fatalf("%s: %d faixas, esperado %d", caso.nome, len(faixas), len(repository.LimitesFaixasPreco)+1)
+			}
+			for i, faixa := range faixas {
+				if faixa.Total != caso.faixas[i] {
+					t.Errorf("%s: faixa %d (%.0f a %v) com %d, esperado %d", caso.nome, i, faixa.Min, faixa.Max, faixa.Total, caso.faixas[i])
+				}
+			}
+			if faixas[0].Min != 0 || *faixas[0].Max != 100 || faixas[1].Min != 100 || faixas[4].Min != 3000 || faixas[4].Max != nil {
+				t.Errorf("%s: limites das faixas = %+v", caso.nome, faixas)
+			}
+
+			categorias := resultado.Facetas.Categorias
+			if len(categorias) != 1 || categorias[0].CategoriaID != c.ID || categorias[0].Slug != c.Slug || categorias[0].Total != caso.categoria {
+				t.Errorf("%s: categorias = %+v, esperado %s com %d", caso.nome, categorias, c.Slug, caso.categoria)
+			}
+			if e := resultado.Facetas; e.EmEstoque != caso.estoque[0] || e.SemEstoque != caso.estoque[1] {
+				t.Errorf("%s: %d em estoque e %d sem, esperado %d e %d", caso.nome, e.EmEstoque, e.SemEstoque, caso.estoque[0], caso.estoque[1])
+			}
+		}
+
+		// Sem resultados, as faixas continuam todas lá, zeradas.
+		vazio, err := r.Produtos.Buscar(repository.FiltroBusca{Termo: "xylofone quasar", PrecoMin: &max, PrecoMax: &min}, repository.Pagina{})
+		if err != nil {
+			t.Fatalf("buscar: %v", err)
+		}
+		if vazio.Total != 0 || len(vazio.Produtos) != 0 || len(vazio.Facetas.Precos) != len(repository.LimitesFaixasPreco)+1 || len(vazio.Facetas.Categorias) != 0 {
+			t.Errorf("busca vazia = %+v", vazio)
+		}
+	})
+}
+
+func TestTransacaoDesfazEmErro(t *testing.T) {
+	// Só na memória: no PostgreSQL o caso deixaria um produto gravado se a
+	// implementação estivesse errada.
+	r := repository.NovoMemoria()
+	p := criarProduto(t, r, "Gabinete", 5)
+
+	err := r.Transacao(func(tx *repository.Repositorios) error {
+		if err := tx.Produtos.AjustarEstoque(p.ID, -5); err != nil {
+			return err
+		}
+		criarProduto(t, tx, "Fonte", 1)
+		return errDesfazer
+	})
+	if !errors.Is(err, errDesfazer) {
+		t.Fatalf("transação: %v", err)
+	}
+	atual, err := r.Produtos.Obter(p.ID)
+	if err != nil || atual.Quantidade != 5 {
+		t.Errorf("produto = %+v, %v; esperado quantidade 5", atual, err)
+	}
+	if _, total, _ := r.Produtos.Listar(repository.FiltroProdutos{}, repository.Pagina{}); total != 1 {
+		t.Errorf("%d produtos depois do rollback, esperado 1", total)
+	}
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+// armazem guarda os dados da implementação em memória. Um único mutex
+// serializa tudo; Transacao segura o mutex durante toda a função e restaura
+// uma cópia dos dados se ela falhar.
+type armazem struct {
+	mu    sync.Mutex
+	dados *dadosMemoria
+}
+
+type dadosMemoria struct {
+	ultimoID   map[string]int
+	produtos   map[int]models.Produto
+	categorias map[int]models.Categoria
+	// produtoCategorias guarda as categorias de cada produto, como a
+	// tabela produto_categorias.
+	produtoCategorias map[int][]int
+	imagens           map[int]models.ProdutoImagem
+	variantes         map[int]models.ProdutoVariante
+	movimentos        map[int]models.MovimentoEstoque
+	notificacoes      map[int]models.NotificacaoAdmin
+	avisos            map[int]models.AvisoEstoque
+	avaliacoes        map[int]models.Avaliacao
+	precos            map[int]models.HistoricoPreco
+	servicos          map[int]models.Servico
+	noticias          map[int]models.Noticia
+	orcamentos        map[int]models.Orcamento
+	suporte           map[int]models.Suporte
+	pedidos           map[int]models.Pedido
+	pedidoItens       map[int]models.PedidoItem
+	cotacoesFrete     map[string]models.OpcaoFrete
+	pagamentos        map[int]models.Pagamento
+	transacoes        map[int]models.TransacaoPagamento
+	historico         map[int]models.PedidoStatusHistorico
+	usuarios          map[int]models.Usuario
+	funcionarios      map[int]models.Funcionario
+	admins            map[int]models.Administrador
+	refresh           map[int]models.RefreshToken
+	jtis              map[string]time.Time
+	redefinicoes      map[int]models.RedefinicaoSenha
+	permissoes        map[string][]string
+}
+
+func novosDadosMemoria() *dadosMemoria {
+	return &dadosMemoria{
+		ultimoID:          map[string]int{},
+		produtos:          map[int]models.Produto{},
+		categorias:        map[int]models.Categoria{},
+		produtoCategorias: map[int][]int{},
+		imagens:           map[int]models.ProdutoImagem{},
+		variantes:         map[int]models.ProdutoVariante{},
+		movimentos:        map[int]models.MovimentoEstoque{},
+		notificacoes:      map[int]models.NotificacaoAdmin{},
+		avisos:            map[int]models.AvisoEstoque{},
+		avaliacoes:        map[int]models.Avaliacao{},
+		precos:            map[int]models.HistoricoPreco{},
+		servicos:          map[int]models.Servico{},
+		noticias:          map[int]models.Noticia{},
+		orcamentos:        map[int]models.Orcamento{},
+		suporte:           map[int]models.Suporte{},
+		pedidos:           map[int]models.Pedido{},
+		pedidoItens:       map[int]models.PedidoItem{},
+		cotacoesFrete:     map[string]models.OpcaoFrete{},
+		pagamentos:        map[int]models.Pagamento{},
+		transacoes:        map[int]models.TransacaoPagamento{},
+		historico:         map[int]models.PedidoStatusHistorico{},
+		usuarios:          map[int]models.Usuario{},
+		funcionarios:      map[int]models.Funcionario{},
+		admins:            map[int]models.Administrador{},
+		refresh:           map[int]models.RefreshToken{},
+		jtis:              map[string]time.Time{},
+		redefinicoes:      map[int]models.RedefinicaoSenha{},
+		permissoes:        map[string][]string{},
+	}
+}
+
+func copiarMapa[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func (d *dadosMemoria) clonar() *dadosMemoria {
+	c := &dadosMemoria{
+		ultimoID:          copiarMapa(d.ultimoID),
+		produtos:          copiarMapa(d.produtos),
+		categorias:        copiarMapa(d.categorias),
+		produtoCategorias: copiarMapa(d.produtoCategorias),
+		imagens:           copiarMapa(d.imagens),
+		variantes:         copiarMapa(d.variantes),
+		movimentos:        copiarMapa(d.movimentos),
+		notificacoes:      copiarMapa(d.notificacoes),
+		avisos:            copiarMapa(d.avisos),
+		avaliacoes:        copiarMapa(d.avaliacoes),
+		precos:            copiarMapa(d.precos),
+		servicos:          copiarMapa(d.servicos),
+		noticias:          copiarMapa(d.noticias),
+		orcamentos:        copiarMapa(d.orcamentos),
+		suporte:           copiarMapa(d.suporte),
+		pedidos:           copiarMapa(d.pedidos),
+		pedidoItens:       copiarMapa(d.pedidoItens),
+		cotacoesFrete:     copiarMapa(d.cotacoesFrete),
+		pagamentos:        copiarMapa(d.pagamentos),
+		transacoes:        copiarMapa(d.transacoes),
+		historico:         copiarMapa(d.historico),
+		usuarios:          copiarMapa(d.usuarios),
+		funcionarios:      copiarMapa(d.funcionarios),
+		admins:            copiarMapa(d.admins),
+		refresh:           copiarMapa(d.refresh),
+		jtis:              copiarMapa(d.jtis),
+		redefinicoes:      copiarMapa(d.redefinicoes),
+		permissoes:        make(map[string][]string, len(d.permissoes)),
+	}
+	for papel, perms := range d.permissoes {
+		c.permissoes[papel] = append([]string(nil), perms...)
+	}
+	return c
+}
+
+func (d *dadosMemoria) proximoID(tabela string) int {
+	d.ultimoID[tabela]++
+	return d.ultimoID[tabela]
+}
+
+// NovoMemoria cria repositórios vazios que guardam tudo em memória. Não há
+// permissões cadastradas; use Permissoes.Substituir para configurá-las.
+func NovoMemoria() *Repositorios {
+	a := &armazem{dados: novosDadosMemoria()}
+	r := repositoriosMemoria(a, false)
+	r.transacao = func(fn func(tx *Repositorios) error) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		copia := a.dados.clonar()
+		repos := repositoriosMemoria(a, true)
+		repos.transacao = func(fn func(*Repositorios) error) error {
+			return fn(repos)
+		}
+		if err := fn(repos); err != nil {
+			a.dados = copia
+			return err
+		}
+		return nil
+	}
+	return r
+}
+
+func repositoriosMemoria(a *armazem, emTx bool) *Repositorios {
+	m := memoria{a: a, emTx: emTx}
+	return &Repositorios{
+		Produtos:     &produtosMemoria{m},
+		Categorias:   &categoriasMemoria{m},
+		Imagens:      &imagensMemoria{m},
+		Variantes:    &variantesMemoria{m},
+		Movimentos:   &movimentosMemoria{m},
+		Notificacoes: &notificacoesMemoria{m},
+		Avisos:       &avisosMemoria{m},
+		Avaliacoes:   &avaliacoesMemoria{m},
+		Precos:       &precosMemoria{m},
+		Servicos:     &servicosMemoria{m},
+		Noticias:     &noticiasMemoria{m},
+		Orcamentos:   &orcamentosMemoria{m},
+		Suporte:      &suporteMemoria{m},
+		Pedidos:      &pedidosMemoria{m},
+		Fretes:       &fretesMemoria{m},
+		Pagamentos:   &pagamentosMemoria{m},
+		Usuarios:     &usuariosMemoria{m},
+		Funcionarios: &funcionariosMemoria{m},
+		Admins:       &adminsMemoria{m},
+		Tokens:       &tokensMemoria{m},
+		Redefinicoes: &redefinicoesMemoria{m},
+		Permissoes:   &permissoesMemoria{m},
+	}
+}
+
+// memoria é embutida em cada repositório em memória.
+type memoria struct {
+	a    *armazem
+	emTx bool
+}
+
+// abrir trava o armazém (a não ser que já esteja numa transação) e devolve
+// os dados junto com a função que destrava.
+func (m memoria) abrir() (*dadosMemoria, func()) {
+	if m.emTx {
+		return m.a.dados, func() {}
+	}
+	m.a.mu.Lock()
+	return m.a.dados, m.a.mu.Unlock
+}
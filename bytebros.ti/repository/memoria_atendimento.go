@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type orcamentosMemoria struct{ memoria }
+
+func (r *orcamentosMemoria) Criar(o *models.Orcamento) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	o.ID = d.proximoID("orcamentos")
+	d.orcamentos[o.ID] = *o
+	return nil
+}
+
+var comparadoresOrcamento = map[string]func(a, b models.Orcamento) int{
+	"id":           func(a, b models.Orcamento) int { return cmp.Compare(a.ID, b.ID) },
+	"nome_cliente": func(a, b models.Orcamento) int { return strings.Compare(a.NomeCliente, b.NomeCliente) },
+	"status":       func(a, b models.Orcamento) int { return strings.Compare(a.Status, b.Status) },
+	"criado_em":    func(a, b models.Orcamento) int { return a.CriadoEm.Compare(b.CriadoEm) },
+}
+
+func (r *orcamentosMemoria) Listar(filtro FiltroOrcamentos, pagina Pagina) ([]models.Orcamento, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	orcamentos := make([]models.Orcamento, 0)
+	for _, o := range d.orcamentos {
+		if filtro.Status != "" && o.Status != filtro.Status {
+			continue
+		}
+		if filtro.Email != "" && o.EmailCliente != filtro.Email {
+			continue
+		}
+		orcamentos = append(orcamentos, o)
+	}
+	orcamentos, total := paginar(orcamentos, pagina, OrdenacaoOrcamentos, comparadoresOrcamento,
+		func(o models.Orcamento) int { return o.ID }, func(o models.Orcamento) time.Time { return o.CriadoEm })
+	return orcamentos, total, nil
+}
+
+func (r *orcamentosMemoria) Obter(id int) (models.Orcamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	o, ok := d.orcamentos[id]
+	if !ok {
+		return o, ErrNaoEncontrado
+	}
+	return o, nil
+}
+
+func (r *orcamentosMemoria) AtualizarStatus(id int, status string) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	o, ok := d.orcamentos[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	o.Status = status
+	o.AtualizadoEm = time.Now()
+	d.orcamentos[id] = o
+	return nil
+}
+
+func (r *orcamentosMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.orcamentos[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	delete(d.orcamentos, id)
+	return nil
+}
+
+type suporteMemoria struct{ memoria }
+
+func (r *suporteMemoria) Criar(s *models.Suporte) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	s.ID = d.proximoID("suporte")
+	s.CriadoEm = time.Now()
+	d.suporte[s.ID] = *s
+	return nil
+}
+
+var comparadoresSuporte = map[string]func(a, b models.Suporte) int{
+	"id":        func(a, b models.Suporte) int { return cmp.Compare(a.ID, b.ID) },
+	"nome":      func(a, b models.Suporte) int { return strings.Compare(a.Nome, b.Nome) },
+	"status":    func(a, b models.Suporte) int { return strings.Compare(a.Status, b.Status) },
+	"criado_em": func(a, b models.Suporte) int { return a.CriadoEm.Compare(b.CriadoEm) },
+}
+
+func (r *suporteMemoria) Listar(filtro FiltroSuporte, pagina Pagina) ([]models.Suporte, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	mensagens := make([]models.Suporte, 0)
+	for _, s := range d.suporte {
+		if filtro.Status != "" && s.Status != filtro.Status {
+			continue
+		}
+		if filtro.TipoInteracao != "" && s.TipoInteracao != filtro.TipoInteracao {
+			continue
+		}
+		if filtro.ClienteEmail != "" && s.ClienteEmail != filtro.ClienteEmail {
+			continue
+		}
+		mensagens = append(mensagens, s)
+	}
+	mensagens, total := paginar(mensagens, pagina, OrdenacaoSuporte, comparadoresSuporte,
+		func(s models.Suporte) int { return s.ID }, func(s models.Suporte) time.Time { return s.CriadoEm })
+	return mensagens, total, nil
+}
+
+func (r *suporteMemoria) Obter(id int) (models.Suporte, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	s, ok := d.suporte[id]
+	if !ok {
+		return s, ErrNaoEncontrado
+	}
+	return s, nil
+}
+
+func (r *suporteMemoria) AtualizarStatus(id int, status string) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	s, ok := d.suporte[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	s.Status = status
+	d.suporte[id] = s
+	return nil
+}
+
+func (r *suporteMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.suporte[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	delete(d.suporte, id)
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"cmp"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type avaliacoesMemoria struct{ memoria }
+
+func (r *avaliacoesMemoria) Criar(a *models.Avaliacao) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.produtos[a.ProdutoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	for _, existente := range d.avaliacoes {
+		if existente.ProdutoID == a.ProdutoID && existente.ClienteEmail == a.ClienteEmail {
+			return ErrDuplicado
+		}
+	}
+	a.ID = d.proximoID("avaliacoes")
+	a.Status = models.AvaliacaoPendente
+	a.CriadoEm = time.Now()
+	d.avaliacoes[a.ID] = *a
+	return nil
+}
+
+var comparadoresAvaliacao = map[string]func(a, b models.Avaliacao) int{
+	"id":        func(a, b models.Avaliacao) int { return cmp.Compare(a.ID, b.ID) },
+	"criado_em": func(a, b models.Avaliacao) int { return a.CriadoEm.Compare(b.CriadoEm) },
+	"nota":      func(a, b models.Avaliacao) int { return cmp.Compare(a.Nota, b.Nota) },
+}
+
+func (r *avaliacoesMemoria) Listar(filtro FiltroAvaliacoes, pagina Pagina) ([]models.Avaliacao, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	avaliacoes := make([]models.Avaliacao, 0)
+	for _, a := range d.avaliacoes {
+		if (filtro.ProdutoID != 0 && a.ProdutoID != filtro.ProdutoID) || (filtro.Status != "" && a.Status != filtro.Status) {
+			continue
+		}
+		avaliacoes = append(avaliacoes, a)
+	}
+	avaliacoes, total := paginar(avaliacoes, pagina, OrdenacaoAvaliacoes, comparadoresAvaliacao,
+		func(a models.Avaliacao) int { return a.ID }, func(a models.Avaliacao) time.Time { return a.CriadoEm })
+	return avaliacoes, total, nil
+}
+
+func (r *avaliacoesMemoria) Moderar(id int, status, usuario string) (models.Avaliacao, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	a, ok := d.avaliacoes[id]
+	if !ok {
+		return a, ErrNaoEncontrado
+	}
+	agora := time.Now()
+	a.Status = status
+	a.ModeradoEm = &agora
+	a.ModeradoPor = usuario
+	d.avaliacoes[id] = a
+	return a, nil
+}
+
+func (r *avaliacoesMemoria) Resumos(produtoIDs []int) (map[int]models.ResumoAvaliacoes, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	buscados := make(map[int]bool, len(produtoIDs))
+	for _, id := range produtoIDs {
+		buscados[id] = true
+	}
+	somas := make(map[int]int)
+	resumos := make(map[int]models.ResumoAvaliacoes)
+	for _, a := range d.avaliacoes {
+		if !buscados[a.ProdutoID] || a.Status != models.AvaliacaoAprovada {
+			continue
+		}
+		somas[a.ProdutoID] += a.Nota
+		resumo := resumos[a.ProdutoID]
+		resumo.Total++
+		resumos[a.ProdutoID] = resumo
+	}
+	for id, resumo := range resumos {
+		resumo.Media = mediaNotas(somas[id], resumo.Total)
+		resumos[id] = resumo
+	}
+	return resumos, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type avisosMemoria struct{ memoria }
+
+func (r *avisosMemoria) Criar(a *models.AvisoEstoque) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.produtos[a.ProdutoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	for _, existente := range d.avisos {
+		if existente.ProdutoID == a.ProdutoID && existente.Email == a.Email && existente.NotificadoEm == nil {
+			return ErrDuplicado
+		}
+	}
+	a.ID = d.proximoID("avisos_estoque")
+	a.CriadoEm = time.Now()
+	a.NotificadoEm = nil
+	d.avisos[a.ID] = *a
+	return nil
+}
+
+func (r *avisosMemoria) ProdutosRepostos() ([]int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	vistos := make(map[int]bool)
+	ids := make([]int, 0)
+	for _, a := range d.avisos {
+		if a.NotificadoEm == nil && d.produtos[a.ProdutoID].Quantidade > 0 && !vistos[a.ProdutoID] {
+			vistos[a.ProdutoID] = true
+			ids = append(ids, a.ProdutoID)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (r *avisosMemoria) Reivindicar(produtoID int) ([]models.AvisoEstoque, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	avisos := make([]models.AvisoEstoque, 0)
+	if d.produtos[produtoID].Quantidade <= 0 {
+		return avisos, nil
+	}
+	agora := time.Now()
+	for id, a := range d.avisos {
+		if a.ProdutoID == produtoID && a.NotificadoEm == nil {
+			a.NotificadoEm = &agora
+			d.avisos[id] = a
+			avisos = append(avisos, a)
+		}
+	}
+	sort.Slice(avisos, func(i, j int) bool { return avisos[i].ID < avisos[j].ID })
+	return avisos, nil
+}
+
+func (r *avisosMemoria) Liberar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	a, ok := d.avisos[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	a.NotificadoEm = nil
+	d.avisos[id] = a
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"bytebros.ti/models"
+)
+
+var semAcento = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// palavrasBusca separa o texto em palavras minúsculas e sem acento.
+func palavrasBusca(texto string) []string {
+	return strings.FieldsFunc(semAcento.Replace(strings.ToLower(texto)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// distanciaEdicao é a distância de Damerau-Levenshtein (com troca de letras
+// vizinhas valendo um erro) entre a e b.
+func distanciaEdicao(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			custo := 1
+			if ra[i-1] == rb[j-1] {
+				custo = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+custo)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// pontuarTermo dá a nota de uma palavra da busca contra as palavras de um
+// campo: 1 para prefixo (aproxima o radical do PostgreSQL), 0.5 para até
+// um erro de digitação (dois em palavras longas) e 0 se não casar.
+func pontuarTermo(termo string, palavras []string) float64 {
+	tolerancia := 1
+	if len(termo) >= 8 {
+		tolerancia = 2
+	}
+	nota := 0.0
+	for _, p := range palavras {
+		switch {
+		case strings.HasPrefix(p, termo) || (len(p) >= 4 && strings.HasPrefix(termo, p)):
+			return 1
+		case len(termo) >= 4 && distanciaEdicao(termo, p) <= tolerancia:
+			nota = 0.5
+		}
+	}
+	return nota
+}
+
+// relevanciaMemoria imita a busca do PostgreSQL sem dicionário: todas as
+// palavras da busca (exceto as curtas, como "de") precisam aparecer no nome
+// ou nos detalhes, e o nome pesa o dobro.
+func relevanciaMemoria(termo string, p models.Produto) (float64, bool) {
+	nome := palavrasBusca(p.Nome)
+	detalhes := palavrasBusca(p.Detalhes.String)
+
+	termos := slices.DeleteFunc(palavrasBusca(termo), func(t string) bool { return len(t) < 3 })
+	if len(termos) == 0 {
+		return 0, false
+	}
+	total := 0.0
+	for _, t := range termos {
+		nota := max(pontuarTermo(t, nome), pontuarTermo(t, detalhes)/2)
+		if nota == 0 {
+			return 0, false
+		}
+		total += nota
+	}
+	return total / float64(len(termos)), true
+}
+
+var comparadoresBusca = map[string]func(a, b models.ProdutoBusca) int{
+	"relevancia": func(a, b models.ProdutoBusca) int { return cmp.Compare(a.Relevancia, b.Relevancia) },
+	"id":         func(a, b models.ProdutoBusca) int { return comparadoresProduto["id"](a.Produto, b.Produto) },
+	"name":       func(a, b models.ProdutoBusca) int { return comparadoresProduto["name"](a.Produto, b.Produto) },
+	"value":      func(a, b models.ProdutoBusca) int { return comparadoresProduto["value"](a.Produto, b.Produto) },
+	"quantity":   func(a, b models.ProdutoBusca) int { return comparadoresProduto["quantity"](a.Produto, b.Produto) },
+}
+
+func (r *produtosMemoria) Buscar(filtro FiltroBusca, pagina Pagina) (models.ResultadoBusca, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	encontrados := make([]models.ProdutoBusca, 0)
+	for _, p := range d.produtos {
+		switch {
+		case filtro.SomenteOfertas && !p.Oferta,
+			filtro.SomenteEmEstoque && p.Quantidade <= 0,
+			filtro.PrecoMin != nil && p.Preco < *filtro.PrecoMin,
+			filtro.PrecoMax != nil && p.Preco > *filtro.PrecoMax:
+			continue
+		}
+		p.CategoriaIDs = d.categoriasDoProduto(p.ID)
+		if len(filtro.CategoriaIDs) > 0 && !slices.ContainsFunc(p.CategoriaIDs, func(id int) bool {
+			return slices.Contains(filtro.CategoriaIDs, id)
+		}) {
+			continue
+		}
+		relevancia, ok := relevanciaMemoria(filtro.Termo, p)
+		if !ok {
+			continue
+		}
+		encontrados = append(encontrados, models.ProdutoBusca{Produto: p, Relevancia: relevancia})
+	}
+
+	facetas := models.FacetasBusca{Precos: facetasPreco(), Categorias: make([]models.FacetaCategoria, 0)}
+	porCategoria := make(map[int]int)
+	for _, p := range encontrados {
+		// Mesma regra do width_bucket: quantos limites são <= preço.
+		faixa := sort.Search(len(LimitesFaixasPreco), func(i int) bool { return LimitesFaixasPreco[i] > p.Preco })
+		facetas.Precos[faixa].Total++
+		if p.Quantidade > 0 {
+			facetas.EmEstoque++
+		} else {
+			facetas.SemEstoque++
+		}
+		for _, id := range p.CategoriaIDs {
+			porCategoria[id]++
+		}
+	}
+	for id, total := range porCategoria {
+		c := d.categorias[id]
+		facetas.Categorias = append(facetas.Categorias, models.FacetaCategoria{CategoriaID: id, Nome: c.Nome, Slug: c.Slug, Total: total})
+	}
+	slices.SortFunc(facetas.Categorias, func(a, b models.FacetaCategoria) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), strings.Compare(a.Nome, b.Nome))
+	})
+
+	produtos, total := paginar(encontrados, pagina, OrdenacaoBusca, comparadoresBusca,
+		func(p models.ProdutoBusca) int { return p.ID }, nil)
+	return models.ResultadoBusca{Produtos: produtos, Total: total, Facetas: facetas}, nil
+}
@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type produtosMemoria struct{ memoria }
+
+func (r *produtosMemoria) Criar(p *models.Produto) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p.ID = d.proximoID("produtos")
+	d.produtos[p.ID] = *p
+	return nil
+}
+
+var comparadoresProduto = map[string]func(a, b models.Produto) int{
+	"id":       func(a, b models.Produto) int { return cmp.Compare(a.ID, b.ID) },
+	"name":     func(a, b models.Produto) int { return strings.Compare(a.Nome, b.Nome) },
+	"value":    func(a, b models.Produto) int { return cmp.Compare(a.Preco, b.Preco) },
+	"quantity": func(a, b models.Produto) int { return cmp.Compare(a.Quantidade, b.Quantidade) },
+}
+
+func (r *produtosMemoria) Listar(filtro FiltroProdutos, pagina Pagina) ([]models.Produto, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	produtos := make([]models.Produto, 0, len(d.produtos))
+	for _, p := range d.produtos {
+		if filtro.SomenteOfertas && !p.Oferta {
+			continue
+		}
+		if filtro.Componente != "" && (p.Especificacoes == nil || p.Especificacoes.Componente != filtro.Componente) {
+			continue
+		}
+		p.CategoriaIDs = d.categoriasDoProduto(p.ID)
+		if len(filtro.CategoriaIDs) > 0 && !slices.ContainsFunc(p.CategoriaIDs, func(id int) bool {
+			return slices.Contains(filtro.CategoriaIDs, id)
+		}) {
+			continue
+		}
+		produtos = append(produtos, p)
+	}
+	produtos, total := paginar(produtos, pagina, OrdenacaoProdutos, comparadoresProduto,
+		func(p models.Produto) int { return p.ID }, nil)
+	return produtos, total, nil
+}
+
+func (r *produtosMemoria) Obter(id int) (models.Produto, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.produtos[id]
+	if !ok {
+		return p, ErrNaoEncontrado
+	}
+	p.CategoriaIDs = d.categoriasDoProduto(id)
+	return p, nil
+}
+
+func (r *produtosMemoria) Percorrer(visitar func(p models.Produto, variantes []models.ProdutoVariante) error) error {
+	// A cópia é feita com o lock e visitar roda sem ele, como a leitura
+	// linha a linha do Postgres, que não trava as tabelas.
+	d, fechar := r.abrir()
+	produtos := make([]models.Produto, 0, len(d.produtos))
+	variantes := make(map[int][]models.ProdutoVariante)
+	for _, p := range d.produtos {
+		p.CategoriaIDs = d.categoriasDoProduto(p.ID)
+		produtos = append(produtos, p)
+	}
+	for _, v := range d.variantes {
+		variantes[v.ProdutoID] = append(variantes[v.ProdutoID], v)
+	}
+	fechar()
+
+	slices.SortFunc(produtos, func(a, b models.Produto) int { return cmp.Compare(a.ID, b.ID) })
+	for _, p := range produtos {
+		doProduto := variantes[p.ID]
+		slices.SortFunc(doProduto, func(a, b models.ProdutoVariante) int { return cmp.Compare(a.ID, b.ID) })
+		if err := visitar(p, doProduto); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// categoriasDoProduto devolve uma cópia, nunca nil, para o JSON sair [].
+func (d *dadosMemoria) categoriasDoProduto(id int) []int {
+	return append(make([]int, 0, len(d.produtoCategorias[id])), d.produtoCategorias[id]...)
+}
+
+func (r *produtosMemoria) Atualizar(p models.Produto) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.produtos[p.ID]; !ok {
+		return ErrNaoEncontrado
+	}
+	p.CategoriaIDs = nil
+	d.produtos[p.ID] = p
+	return nil
+}
+
+func (r *produtosMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.produtos[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	// Como o ON DELETE RESTRICT de pedido_itens.
+	for _, item := range d.pedidoItens {
+		if item.ProdutoID == id {
+			return ErrEmUso
+		}
+	}
+	delete(d.produtos, id)
+	delete(d.produtoCategorias, id)
+	// Como o ON DELETE CASCADE de produto_imagens, produto_variantes,
+	// movimentos_estoque, notificacoes_admin, avisos_estoque, avaliacoes e
+	// historico_precos.
+	for imagemID, img := range d.imagens {
+		if img.ProdutoID == id {
+			delete(d.imagens, imagemID)
+		}
+	}
+	for varianteID, v := range d.variantes {
+		if v.ProdutoID == id {
+			delete(d.variantes, varianteID)
+		}
+	}
+	for movimentoID, m := range d.movimentos {
+		if m.ProdutoID == id {
+			delete(d.movimentos, movimentoID)
+		}
+	}
+	for notificacaoID, n := range d.notificacoes {
+		if n.ProdutoID != nil && *n.ProdutoID == id {
+			delete(d.notificacoes, notificacaoID)
+		}
+	}
+	for avisoID, a := range d.avisos {
+		if a.ProdutoID == id {
+			delete(d.avisos, avisoID)
+		}
+	}
+	for avaliacaoID, a := range d.avaliacoes {
+		if a.ProdutoID == id {
+			delete(d.avaliacoes, avaliacaoID)
+		}
+	}
+	for precoID, h := range d.precos {
+		if h.ProdutoID != nil && *h.ProdutoID == id {
+			delete(d.precos, precoID)
+		}
+	}
+	return nil
+}
+
+func (r *produtosMemoria) Bloquear(ids []int) (map[int]models.Produto, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	produtos := make(map[int]models.Produto, len(ids))
+	for _, id := range ids {
+		if p, ok := d.produtos[id]; ok {
+			produtos[id] = p
+		}
+	}
+	return produtos, nil
+}
+
+func (r *produtosMemoria) AjustarEstoque(id int, delta int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.produtos[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	p.Quantidade += delta
+	d.produtos[id] = p
+	return nil
+}
+
+func (r *produtosMemoria) DefinirCategorias(produtoID int, categoriaIDs []int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.produtos[produtoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	ids := idsUnicos(categoriaIDs)
+	for _, id := range ids {
+		// Como a chave estrangeira de produto_categorias.
+		if _, ok := d.categorias[id]; !ok {
+			return ErrNaoEncontrado
+		}
+	}
+	// Sempre uma fatia nova: o clone da transação compartilha as antigas.
+	d.produtoCategorias[produtoID] = ids
+	return nil
+}
+
+type categoriasMemoria struct{ memoria }
+
+func (r *categoriasMemoria) Criar(c *models.Categoria) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if d.slugEmUso(c.Slug, 0) {
+		return ErrDuplicado
+	}
+	c.ID = d.proximoID("categorias")
+	d.categorias[c.ID] = *c
+	return nil
+}
+
+func (d *dadosMemoria) slugEmUso(slug string, exceto int) bool {
+	for _, c := range d.categorias {
+		if c.Slug == slug && c.ID != exceto {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *categoriasMemoria) Listar() ([]models.Categoria, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	categorias := make([]models.Categoria, 0, len(d.categorias))
+	for _, c := range d.categorias {
+		categorias = append(categorias, c)
+	}
+	slices.SortFunc(categorias, func(a, b models.Categoria) int {
+		return cmp.Or(strings.Compare(a.Nome, b.Nome), cmp.Compare(a.ID, b.ID))
+	})
+	return categorias, nil
+}
+
+func (r *categoriasMemoria) Obter(id int) (models.Categoria, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	c, ok := d.categorias[id]
+	if !ok {
+		return c, ErrNaoEncontrado
+	}
+	return c, nil
+}
+
+func (r *categoriasMemoria) ObterPorSlug(slug string) (models.Categoria, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, c := range d.categorias {
+		if c.Slug == slug {
+			return c, nil
+		}
+	}
+	return models.Categoria{}, ErrNaoEncontrado
+}
+
+func (r *categoriasMemoria) Atualizar(c models.Categoria) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.categorias[c.ID]; !ok {
+		return ErrNaoEncontrado
+	}
+	if d.slugEmUso(c.Slug, c.ID) {
+		return ErrDuplicado
+	}
+	d.categorias[c.ID] = c
+	return nil
+}
+
+func (r *categoriasMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.categorias[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	// Como o ON DELETE RESTRICT de categorias.pai_id.
+	for _, c := range d.categorias {
+		if c.PaiID != nil && *c.PaiID == id {
+			return ErrEmUso
+		}
+	}
+	delete(d.categorias, id)
+	for produtoID, ids := range d.produtoCategorias {
+		if slices.Contains(ids, id) {
+			d.produtoCategorias[produtoID] = slices.DeleteFunc(slices.Clone(ids), func(c int) bool { return c == id })
+		}
+	}
+	return nil
+}
+
+func (r *categoriasMemoria) Produtos() (map[int][]int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	produtos := make(map[int][]int)
+	for produtoID, ids := range d.produtoCategorias {
+		for _, id := range ids {
+			produtos[id] = append(produtos[id], produtoID)
+		}
+	}
+	return produtos, nil
+}
+
+type servicosMemoria struct{ memoria }
+
+func (r *servicosMemoria) Criar(s *models.Servico) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	s.ID = d.proximoID("servicos")
+	d.servicos[s.ID] = *s
+	return nil
+}
+
+var comparadoresServico = map[string]func(a, b models.Servico) int{
+	"id":    func(a, b models.Servico) int { return cmp.Compare(a.ID, b.ID) },
+	"nome":  func(a, b models.Servico) int { return strings.Compare(a.Nome, b.Nome) },
+	"preco": func(a, b models.Servico) int { return cmp.Compare(a.Preco, b.Preco) },
+}
+
+func (r *servicosMemoria) Listar(filtro FiltroServicos, pagina Pagina) ([]models.Servico, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	servicos := make([]models.Servico, 0, len(d.servicos))
+	for _, s := range d.servicos {
+		if filtro.SomenteOfertas && !s.Oferta {
+			continue
+		}
+		servicos = append(servicos, s)
+	}
+	servicos, total := paginar(servicos, pagina, OrdenacaoServicos, comparadoresServico,
+		func(s models.Servico) int { return s.ID }, nil)
+	return servicos, total, nil
+}
+
+func (r *servicosMemoria) Obter(id int) (models.Servico, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	s, ok := d.servicos[id]
+	if !ok {
+		return s, ErrNaoEncontrado
+	}
+	return s, nil
+}
+
+func (r *servicosMemoria) Atualizar(s models.Servico) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.servicos[s.ID]; !ok {
+		return ErrNaoEncontrado
+	}
+	d.servicos[s.ID] = s
+	return nil
+}
+
+func (r *servicosMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.servicos[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	delete(d.servicos, id)
+	// Como o ON DELETE CASCADE de historico_precos.
+	for precoID, h := range d.precos {
+		if h.ServicoID != nil && *h.ServicoID == id {
+			delete(d.precos, precoID)
+		}
+	}
+	return nil
+}
+
+type noticiasMemoria struct{ memoria }
+
+func (r *noticiasMemoria) Criar(n *models.Noticia) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	n.ID = uint(d.proximoID("noticias"))
+	d.noticias[int(n.ID)] = *n
+	return nil
+}
+
+var comparadoresNoticia = map[string]func(a, b models.Noticia) int{
+	"id":     func(a, b models.Noticia) int { return cmp.Compare(a.ID, b.ID) },
+	"titulo": func(a, b models.Noticia) int { return strings.Compare(a.Titulo, b.Titulo) },
+	"data":   func(a, b models.Noticia) int { return a.Data.Compare(b.Data) },
+}
+
+func (r *noticiasMemoria) Listar(pagina Pagina) ([]models.Noticia, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	noticias := make([]models.Noticia, 0, len(d.noticias))
+	for _, n := range d.noticias {
+		noticias = append(noticias, n)
+	}
+	noticias, total := paginar(noticias, pagina, OrdenacaoNoticias, comparadoresNoticia,
+		func(n models.Noticia) int { return int(n.ID) }, func(n models.Noticia) time.Time { return n.Data })
+	return noticias, total, nil
+}
+
+func (r *noticiasMemoria) Obter(id int) (models.Noticia, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	n, ok := d.noticias[id]
+	if !ok {
+		return n, ErrNaoEncontrado
+	}
+	return n, nil
+}
+
+func (r *noticiasMemoria) Atualizar(n models.Noticia) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	atual, ok := d.noticias[int(n.ID)]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	// A data de publicação não muda na edição.
+	n.Data = atual.Data
+	d.noticias[int(n.ID)] = n
+	return nil
+}
+
+func (r *noticiasMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.noticias[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	delete(d.noticias, id)
+	return nil
+}
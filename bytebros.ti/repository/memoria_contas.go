@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type usuariosMemoria struct{ memoria }
+
+func (d *dadosMemoria) usuarioPorEmail(email string) (models.Usuario, bool) {
+	for _, u := range d.usuarios {
+		if u.Email == email {
+			return u, true
+		}
+	}
+	return models.Usuario{}, false
+}
+
+func (r *usuariosMemoria) Criar(u *models.Usuario) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, existe := d.usuarioPorEmail(u.Email); existe {
+		return ErrDuplicado
+	}
+	u.ID = d.proximoID("usuarios")
+	u.CriadoEm = time.Now()
+	d.usuarios[u.ID] = *u
+	return nil
+}
+
+func (r *usuariosMemoria) Obter(id int) (models.Usuario, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	u, ok := d.usuarios[id]
+	if !ok {
+		return u, ErrNaoEncontrado
+	}
+	return u, nil
+}
+
+func (r *usuariosMemoria) ObterPorEmail(email string) (models.Usuario, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	u, ok := d.usuarioPorEmail(email)
+	if !ok {
+		return u, ErrNaoEncontrado
+	}
+	return u, nil
+}
+
+func (r *usuariosMemoria) EmailEmUso(email string, excetoID int) (bool, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	u, ok := d.usuarioPorEmail(email)
+	return ok && u.ID != excetoID, nil
+}
+
+func (r *usuariosMemoria) alterar(id int, fn func(u *models.Usuario) error) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	u, ok := d.usuarios[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	if err := fn(&u); err != nil {
+		return err
+	}
+	d.usuarios[id] = u
+	return nil
+}
+
+func (r *usuariosMemoria) AtualizarEmail(id int, email string) error {
+	return r.alterar(id, func(u *models.Usuario) error {
+		if outro, existe := r.a.dados.usuarioPorEmail(email); existe && outro.ID != id {
+			return ErrDuplicado
+		}
+		u.Email = email
+		return nil
+	})
+}
+
+func (r *usuariosMemoria) AtualizarTelefone(id int, telefone string) error {
+	return r.alterar(id, func(u *models.Usuario) error {
+		u.Telefone = telefone
+		return nil
+	})
+}
+
+func (r *usuariosMemoria) AtualizarSenha(id int, senhaHash string) error {
+	return r.alterar(id, func(u *models.Usuario) error {
+		u.SenhaHash = senhaHash
+		return nil
+	})
+}
+
+var comparadoresUsuario = map[string]func(a, b models.Usuario) int{
+	"id":            func(a, b models.Usuario) int { return cmp.Compare(a.ID, b.ID) },
+	"nome_completo": func(a, b models.Usuario) int { return strings.Compare(a.Nome, b.Nome) },
+	"email":         func(a, b models.Usuario) int { return strings.Compare(a.Email, b.Email) },
+	"criado_em":     func(a, b models.Usuario) int { return a.CriadoEm.Compare(b.CriadoEm) },
+}
+
+func (r *usuariosMemoria) Listar(busca string, pagina Pagina) ([]models.Usuario, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	busca = strings.ToLower(busca)
+	usuarios := make([]models.Usuario, 0)
+	for _, u := range d.usuarios {
+		if busca != "" &&
+			!strings.Contains(strings.ToLower(u.Email), busca) &&
+			!strings.Contains(strings.ToLower(u.Telefone), busca) {
+			continue
+		}
+		u.SenhaHash = ""
+		usuarios = append(usuarios, u)
+	}
+	usuarios, total := paginar(usuarios, pagina, OrdenacaoUsuarios, comparadoresUsuario,
+		func(u models.Usuario) int { return u.ID }, func(u models.Usuario) time.Time { return u.CriadoEm })
+	return usuarios, total, nil
+}
+
+type funcionariosMemoria struct{ memoria }
+
+func (r *funcionariosMemoria) Criar(f *models.Funcionario) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, outro := range d.funcionarios {
+		if outro.Email == f.Email {
+			return ErrDuplicado
+		}
+	}
+	f.ID = d.proximoID("funcionarios")
+	f.CriadoEm = time.Now()
+	d.funcionarios[f.ID] = *f
+	return nil
+}
+
+func (r *funcionariosMemoria) Obter(id int) (models.Funcionario, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	f, ok := d.funcionarios[id]
+	if !ok {
+		return f, ErrNaoEncontrado
+	}
+	return f, nil
+}
+
+func (r *funcionariosMemoria) ObterPorEmail(email string) (models.Funcionario, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, f := range d.funcionarios {
+		if f.Email == email {
+			return f, nil
+		}
+	}
+	return models.Funcionario{}, ErrNaoEncontrado
+}
+
+var comparadoresFuncionario = map[string]func(a, b models.Funcionario) int{
+	"id":        func(a, b models.Funcionario) int { return cmp.Compare(a.ID, b.ID) },
+	"nome":      func(a, b models.Funcionario) int { return strings.Compare(a.Nome, b.Nome) },
+	"cargo":     func(a, b models.Funcionario) int { return strings.Compare(a.Cargo, b.Cargo) },
+	"email":     func(a, b models.Funcionario) int { return strings.Compare(a.Email, b.Email) },
+	"criado_em": func(a, b models.Funcionario) int { return a.CriadoEm.Compare(b.CriadoEm) },
+}
+
+func (r *funcionariosMemoria) Listar(pagina Pagina) ([]models.Funcionario, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	funcionarios := make([]models.Funcionario, 0, len(d.funcionarios))
+	for _, f := range d.funcionarios {
+		f.SenhaHash = ""
+		funcionarios = append(funcionarios, f)
+	}
+	funcionarios, total := paginar(funcionarios, pagina, OrdenacaoFuncionarios, comparadoresFuncionario,
+		func(f models.Funcionario) int { return f.ID }, func(f models.Funcionario) time.Time { return f.CriadoEm })
+	return funcionarios, total, nil
+}
+
+type adminsMemoria struct{ memoria }
+
+func (r *adminsMemoria) Criar(a *models.Administrador) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, outro := range d.admins {
+		if outro.Email == a.Email {
+			return ErrDuplicado
+		}
+	}
+	a.ID = d.proximoID("admin")
+	a.CriadoEm = time.Now()
+	a.Atualizado = a.CriadoEm
+	d.admins[a.ID] = *a
+	return nil
+}
+
+func (r *adminsMemoria) Obter(id int) (models.Administrador, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	a, ok := d.admins[id]
+	if !ok {
+		return a, ErrNaoEncontrado
+	}
+	return a, nil
+}
+
+func (r *adminsMemoria) ObterPorEmail(email string) (models.Administrador, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, a := range d.admins {
+		if a.Email == email {
+			return a, nil
+		}
+	}
+	return models.Administrador{}, ErrNaoEncontrado
+}
+
+func (r *adminsMemoria) Deletar(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.admins[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	delete(d.admins, id)
+	return nil
+}
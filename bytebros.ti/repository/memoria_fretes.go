@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type fretesMemoria struct{ memoria }
+
+func (r *fretesMemoria) Criar(o *models.OpcaoFrete) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.cotacoesFrete[o.ID]; ok {
+		return ErrDuplicado
+	}
+	o.CriadoEm = time.Now()
+	d.cotacoesFrete[o.ID] = *o
+	return nil
+}
+
+func (r *fretesMemoria) Obter(id string) (models.OpcaoFrete, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	o, ok := d.cotacoesFrete[id]
+	if !ok {
+		return models.OpcaoFrete{}, ErrNaoEncontrado
+	}
+	return o, nil
+}
+
+func (r *fretesMemoria) Usar(id string, pedidoID int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	o, ok := d.cotacoesFrete[id]
+	if !ok || o.PedidoID != nil {
+		return ErrNaoEncontrado
+	}
+	o.PedidoID = &pedidoID
+	d.cotacoesFrete[id] = o
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type imagensMemoria struct{ memoria }
+
+func (r *imagensMemoria) Criar(img *models.ProdutoImagem) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	// Como a chave estrangeira de produto_imagens.
+	if _, ok := d.produtos[img.ProdutoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	img.Ordem = 0
+	for _, outra := range d.imagens {
+		if outra.ProdutoID == img.ProdutoID && outra.Ordem >= img.Ordem {
+			img.Ordem = outra.Ordem + 1
+		}
+	}
+	img.ID = d.proximoID("produto_imagens")
+	img.CriadoEm = time.Now()
+	d.imagens[img.ID] = *img
+	return nil
+}
+
+func (d *dadosMemoria) imagensDoProduto(produtoID int) []models.ProdutoImagem {
+	imagens := make([]models.ProdutoImagem, 0)
+	for _, img := range d.imagens {
+		if img.ProdutoID == produtoID {
+			imagens = append(imagens, img)
+		}
+	}
+	slices.SortFunc(imagens, func(a, b models.ProdutoImagem) int {
+		return cmp.Or(cmp.Compare(a.Ordem, b.Ordem), cmp.Compare(a.ID, b.ID))
+	})
+	return imagens
+}
+
+func (r *imagensMemoria) Listar(produtoID int) ([]models.ProdutoImagem, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	return d.imagensDoProduto(produtoID), nil
+}
+
+func (r *imagensMemoria) Obter(produtoID, id int) (models.ProdutoImagem, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	img, ok := d.imagens[id]
+	if !ok || img.ProdutoID != produtoID {
+		return models.ProdutoImagem{}, ErrNaoEncontrado
+	}
+	return img, nil
+}
+
+func (r *imagensMemoria) Deletar(produtoID, id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	img, ok := d.imagens[id]
+	if !ok || img.ProdutoID != produtoID {
+		return ErrNaoEncontrado
+	}
+	delete(d.imagens, id)
+	return nil
+}
+
+func (r *imagensMemoria) Reordenar(produtoID int, ids []int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, id := range ids {
+		if img, ok := d.imagens[id]; !ok || img.ProdutoID != produtoID {
+			return ErrNaoEncontrado
+		}
+	}
+	for ordem, id := range ids {
+		img := d.imagens[id]
+		img.Ordem = ordem
+		d.imagens[id] = img
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"cmp"
+	"sort"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type movimentosMemoria struct{ memoria }
+
+func (r *movimentosMemoria) Registrar(m *models.MovimentoEstoque) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.produtos[m.ProdutoID]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	m.Saldo = p.Quantidade
+	m.SaldoVariante = nil
+	if m.VarianteID != nil {
+		v, ok := d.variantes[*m.VarianteID]
+		if !ok {
+			return ErrNaoEncontrado
+		}
+		m.SaldoVariante = &v.Quantidade
+	}
+	m.ID = d.proximoID("movimentos_estoque")
+	m.CriadoEm = time.Now()
+	d.movimentos[m.ID] = *m
+	return nil
+}
+
+var comparadoresMovimento = map[string]func(a, b models.MovimentoEstoque) int{
+	"id":         func(a, b models.MovimentoEstoque) int { return cmp.Compare(a.ID, b.ID) },
+	"criado_em":  func(a, b models.MovimentoEstoque) int { return a.CriadoEm.Compare(b.CriadoEm) },
+	"quantidade": func(a, b models.MovimentoEstoque) int { return cmp.Compare(a.Quantidade, b.Quantidade) },
+}
+
+func (r *movimentosMemoria) Listar(filtro FiltroMovimentos, pagina Pagina) ([]models.MovimentoEstoque, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	movimentos := make([]models.MovimentoEstoque, 0)
+	for _, m := range d.movimentos {
+		switch {
+		case filtro.ProdutoID != 0 && m.ProdutoID != filtro.ProdutoID,
+			filtro.VarianteID != 0 && (m.VarianteID == nil || *m.VarianteID != filtro.VarianteID),
+			filtro.Tipo != "" && m.Tipo != filtro.Tipo:
+			continue
+		}
+		movimentos = append(movimentos, m)
+	}
+	movimentos, total := paginar(movimentos, pagina, OrdenacaoMovimentos, comparadoresMovimento,
+		func(m models.MovimentoEstoque) int { return m.ID }, func(m models.MovimentoEstoque) time.Time { return m.CriadoEm })
+	return movimentos, total, nil
+}
+
+func (r *movimentosMemoria) Resumo(produtoID int, desde, ate *time.Time) ([]models.ResumoEstoque, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	porProduto := make(map[int]*models.ResumoEstoque)
+	for _, m := range d.movimentos {
+		if (produtoID != 0 && m.ProdutoID != produtoID) || (ate != nil && m.CriadoEm.After(*ate)) {
+			continue
+		}
+		resumo, ok := porProduto[m.ProdutoID]
+		if !ok {
+			resumo = &models.ResumoEstoque{ProdutoID: m.ProdutoID, NomeProduto: d.produtos[m.ProdutoID].Nome, Movimentos: map[string]int{}}
+			porProduto[m.ProdutoID] = resumo
+		}
+		if desde != nil && m.CriadoEm.Before(*desde) {
+			resumo.SaldoInicial += m.Quantidade
+		} else {
+			resumo.Movimentos[m.Tipo] += m.Quantidade
+		}
+		resumo.SaldoFinal += m.Quantidade
+	}
+
+	resumos := make([]models.ResumoEstoque, 0, len(porProduto))
+	for _, resumo := range porProduto {
+		// Tipos que se anularam no período ficam fora, como no PostgreSQL.
+		for tipo, total := range resumo.Movimentos {
+			if total == 0 {
+				delete(resumo.Movimentos, tipo)
+			}
+		}
+		resumos = append(resumos, *resumo)
+	}
+	sort.Slice(resumos, func(i, j int) bool { return resumos[i].ProdutoID < resumos[j].ProdutoID })
+	return resumos, nil
+}
+
+func (r *movimentosMemoria) Divergencias() ([]models.DivergenciaEstoque, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	porProduto := make(map[int]int)
+	porVariante := make(map[int]int)
+	for _, m := range d.movimentos {
+		porProduto[m.ProdutoID] += m.Quantidade
+		if m.VarianteID != nil {
+			porVariante[*m.VarianteID] += m.Quantidade
+		}
+	}
+
+	divergencias := make([]models.DivergenciaEstoque, 0)
+	for _, p := range d.produtos {
+		if p.Quantidade != porProduto[p.ID] {
+			divergencias = append(divergencias, models.DivergenciaEstoque{
+				ProdutoID: p.ID, NomeProduto: p.Nome, Quantidade: p.Quantidade, SaldoRazao: porProduto[p.ID],
+			})
+		}
+	}
+	for _, v := range d.variantes {
+		if v.Quantidade != porVariante[v.ID] {
+			divergencias = append(divergencias, models.DivergenciaEstoque{
+				ProdutoID: v.ProdutoID, VarianteID: &v.ID, NomeProduto: d.produtos[v.ProdutoID].Nome, SKU: v.SKU,
+				Quantidade: v.Quantidade, SaldoRazao: porVariante[v.ID],
+			})
+		}
+	}
+	// Mesma ordem do PostgreSQL: por produto, com a linha do produto antes
+	// das variantes.
+	sort.Slice(divergencias, func(i, j int) bool {
+		a, b := divergencias[i], divergencias[j]
+		if a.ProdutoID != b.ProdutoID {
+			return a.ProdutoID < b.ProdutoID
+		}
+		if a.VarianteID == nil || b.VarianteID == nil {
+			return a.VarianteID == nil && b.VarianteID != nil
+		}
+		return *a.VarianteID < *b.VarianteID
+	})
+	return divergencias, nil
+}
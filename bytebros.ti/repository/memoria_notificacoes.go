@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type notificacoesMemoria struct{ memoria }
+
+// alertaAberto informa se o produto já tem um alerta do tipo sem resolver,
+// como o índice único parcial de notificacoes_admin.
+func (d *dadosMemoria) alertaAberto(tipo string, produtoID int) bool {
+	for _, n := range d.notificacoes {
+		if n.Tipo == tipo && n.ProdutoID != nil && *n.ProdutoID == produtoID && n.ResolvidaEm == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *notificacoesMemoria) AbrirAlertasEstoque() ([]models.NotificacaoAdmin, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	ids := make([]int, 0)
+	for id, p := range d.produtos {
+		if p.Quantidade <= p.EstoqueMinimo && !d.alertaAberto(models.NotificacaoEstoqueBaixo, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	criadas := make([]models.NotificacaoAdmin, 0, len(ids))
+	for _, id := range ids {
+		p := d.produtos[id]
+		n := models.NotificacaoAdmin{
+			ID:        d.proximoID("notificacoes_admin"),
+			Tipo:      models.NotificacaoEstoqueBaixo,
+			ProdutoID: &p.ID,
+			Titulo:    "Estoque baixo: " + p.Nome,
+			Mensagem:  fmt.Sprintf("%s está com %d unidade(s) em estoque; o mínimo configurado é %d.", p.Nome, p.Quantidade, p.EstoqueMinimo),
+			CriadoEm:  time.Now(),
+		}
+		d.notificacoes[n.ID] = n
+		criadas = append(criadas, n)
+	}
+	return criadas, nil
+}
+
+func (r *notificacoesMemoria) ResolverAlertasEstoque() (int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	agora := time.Now()
+	resolvidas := 0
+	for id, n := range d.notificacoes {
+		if n.Tipo != models.NotificacaoEstoqueBaixo || n.ProdutoID == nil || n.ResolvidaEm != nil {
+			continue
+		}
+		if p := d.produtos[*n.ProdutoID]; p.Quantidade > p.EstoqueMinimo {
+			n.ResolvidaEm = &agora
+			d.notificacoes[id] = n
+			resolvidas++
+		}
+	}
+	return resolvidas, nil
+}
+
+var comparadoresNotificacao = map[string]func(a, b models.NotificacaoAdmin) int{
+	"id":        func(a, b models.NotificacaoAdmin) int { return cmp.Compare(a.ID, b.ID) },
+	"criado_em": func(a, b models.NotificacaoAdmin) int { return a.CriadoEm.Compare(b.CriadoEm) },
+}
+
+func (r *notificacoesMemoria) Listar(filtro FiltroNotificacoes, pagina Pagina) ([]models.NotificacaoAdmin, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	notificacoes := make([]models.NotificacaoAdmin, 0)
+	for _, n := range d.notificacoes {
+		if (filtro.Tipo != "" && n.Tipo != filtro.Tipo) || (filtro.SomenteNaoLidas && n.LidaEm != nil) {
+			continue
+		}
+		notificacoes = append(notificacoes, n)
+	}
+	notificacoes, total := paginar(notificacoes, pagina, OrdenacaoNotificacoes, comparadoresNotificacao,
+		func(n models.NotificacaoAdmin) int { return n.ID }, func(n models.NotificacaoAdmin) time.Time { return n.CriadoEm })
+	return notificacoes, total, nil
+}
+
+func (r *notificacoesMemoria) MarcarLida(id int, usuario string) (models.NotificacaoAdmin, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	n, ok := d.notificacoes[id]
+	if !ok {
+		return n, ErrNaoEncontrado
+	}
+	// Ler de novo não muda quem leu primeiro.
+	if n.LidaEm == nil {
+		agora := time.Now()
+		n.LidaEm = &agora
+		n.LidaPor = usuario
+		d.notificacoes[id] = n
+	}
+	return n, nil
+}
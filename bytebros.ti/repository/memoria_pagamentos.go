@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type pagamentosMemoria struct{ memoria }
+
+func (r *pagamentosMemoria) Criar(p *models.Pagamento) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.pedidos[p.PedidoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	for _, outro := range d.pagamentos {
+		if p.TxID != "" && outro.TxID == p.TxID || p.NossoNumero != "" && outro.NossoNumero == p.NossoNumero {
+			return ErrDuplicado
+		}
+	}
+	p.ID = d.proximoID("pagamentos")
+	p.CriadoEm = time.Now()
+	p.AtualizadoEm = p.CriadoEm
+	d.pagamentos[p.ID] = *p
+	return nil
+}
+
+func (r *pagamentosMemoria) Bloquear(id int) (models.Pagamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.pagamentos[id]
+	if !ok {
+		return p, ErrNaoEncontrado
+	}
+	return p, nil
+}
+
+func (r *pagamentosMemoria) ObterPorTxID(txid string) (models.Pagamento, error) {
+	return r.BloquearPorTxID(txid)
+}
+
+func (r *pagamentosMemoria) BloquearPorTxID(txid string) (models.Pagamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, p := range d.pagamentos {
+		if txid != "" && p.TxID == txid {
+			return p, nil
+		}
+	}
+	return models.Pagamento{}, ErrNaoEncontrado
+}
+
+func (r *pagamentosMemoria) BloquearPorNossoNumero(nossoNumero string) (models.Pagamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, p := range d.pagamentos {
+		if nossoNumero != "" && p.NossoNumero == nossoNumero {
+			return p, nil
+		}
+	}
+	return models.Pagamento{}, ErrNaoEncontrado
+}
+
+func (r *pagamentosMemoria) ProximoNossoNumero() (int64, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	return int64(d.proximoID("nosso_numero_seq")), nil
+}
+
+func (r *pagamentosMemoria) DoPedido(pedidoID int) ([]models.Pagamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	pagamentos := make([]models.Pagamento, 0)
+	for _, p := range d.pagamentos {
+		if p.PedidoID == pedidoID {
+			pagamentos = append(pagamentos, p)
+		}
+	}
+	sort.Slice(pagamentos, func(i, j int) bool { return pagamentos[i].ID < pagamentos[j].ID })
+	return pagamentos, nil
+}
+
+func (r *pagamentosMemoria) Atualizar(p *models.Pagamento) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	atual, ok := d.pagamentos[p.ID]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	for _, outro := range d.pagamentos {
+		if p.EndToEndID != "" && outro.ID != p.ID && outro.EndToEndID == p.EndToEndID {
+			return ErrDuplicado
+		}
+	}
+	atual.Status = p.Status
+	atual.EndToEndID = p.EndToEndID
+	atual.PagoEm = p.PagoEm
+	atual.GatewayID = p.GatewayID
+	atual.Bandeira = p.Bandeira
+	atual.FinalCartao = p.FinalCartao
+	atual.ValorEstornado = p.ValorEstornado
+	atual.EstornoPendente = p.EstornoPendente
+	atual.EstornoReferencia = p.EstornoReferencia
+	atual.EstornoMotivo = p.EstornoMotivo
+	atual.EstornoUsuario = p.EstornoUsuario
+	atual.AtualizadoEm = time.Now()
+	d.pagamentos[p.ID] = atual
+	*p = atual
+	return nil
+}
+
+func (r *pagamentosMemoria) Vencidas(agora time.Time) ([]int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	ids := make([]int, 0)
+	for _, p := range d.pagamentos {
+		if p.Status == models.StatusPagamentoPendente && p.ExpiraEm != nil && p.ExpiraEm.Before(agora) {
+			ids = append(ids, p.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (r *pagamentosMemoria) EstornosPendentes(antes time.Time) ([]models.Pagamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	pagamentos := make([]models.Pagamento, 0)
+	for _, p := range d.pagamentos {
+		if p.Status == models.StatusPagamentoEstornoPendente && p.AtualizadoEm.Before(antes) {
+			pagamentos = append(pagamentos, p)
+		}
+	}
+	sort.Slice(pagamentos, func(i, j int) bool { return pagamentos[i].ID < pagamentos[j].ID })
+	return pagamentos, nil
+}
+
+func (r *pagamentosMemoria) RegistrarTransacao(t *models.TransacaoPagamento) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.pagamentos[t.PagamentoID]; !ok {
+		return ErrEmUso
+	}
+	t.ID = d.proximoID("transacoes_pagamento")
+	t.CriadoEm = time.Now()
+	d.transacoes[t.ID] = *t
+	return nil
+}
+
+func (r *pagamentosMemoria) Transacoes(pagamentoID int) ([]models.TransacaoPagamento, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	transacoes := make([]models.TransacaoPagamento, 0)
+	for _, t := range d.transacoes {
+		if t.PagamentoID == pagamentoID {
+			transacoes = append(transacoes, t)
+		}
+	}
+	sort.Slice(transacoes, func(i, j int) bool { return transacoes[i].ID < transacoes[j].ID })
+	return transacoes, nil
+}
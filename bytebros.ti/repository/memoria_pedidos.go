@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type pedidosMemoria struct{ memoria }
+
+func (r *pedidosMemoria) Criar(p *models.Pedido) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p.ID = d.proximoID("pedidos")
+	p.DataPedido = time.Now()
+	p.CriadoEm = p.DataPedido
+	for i := range p.Itens {
+		p.Itens[i].ID = d.proximoID("pedido_itens")
+		p.Itens[i].PedidoID = p.ID
+		d.pedidoItens[p.Itens[i].ID] = p.Itens[i]
+	}
+
+	semItens := *p
+	semItens.Itens = nil
+	d.pedidos[p.ID] = semItens
+	return nil
+}
+
+func (d *dadosMemoria) itensDoPedido(pedidoID int) []models.PedidoItem {
+	itens := make([]models.PedidoItem, 0)
+	for _, item := range d.pedidoItens {
+		if item.PedidoID == pedidoID {
+			itens = append(itens, item)
+		}
+	}
+	sort.Slice(itens, func(i, j int) bool { return itens[i].ID < itens[j].ID })
+	return itens
+}
+
+var comparadoresPedido = map[string]func(a, b models.Pedido) int{
+	"id":          func(a, b models.Pedido) int { return cmp.Compare(a.ID, b.ID) },
+	"data_pedido": func(a, b models.Pedido) int { return a.DataPedido.Compare(b.DataPedido) },
+	"status":      func(a, b models.Pedido) int { return strings.Compare(a.Status, b.Status) },
+	"valor_total": func(a, b models.Pedido) int { return cmp.Compare(a.ValorTotal, b.ValorTotal) },
+}
+
+func (r *pedidosMemoria) Listar(filtro FiltroPedidos, pagina Pagina) ([]models.Pedido, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	pedidos := make([]models.Pedido, 0)
+	for _, p := range d.pedidos {
+		if filtro.Status != "" && p.Status != filtro.Status {
+			continue
+		}
+		if filtro.ClienteEmail != "" && p.ClienteEmail != filtro.ClienteEmail {
+			continue
+		}
+		p.Itens = d.itensDoPedido(p.ID)
+		pedidos = append(pedidos, p)
+	}
+	pedidos, total := paginar(pedidos, pagina, OrdenacaoPedidos, comparadoresPedido,
+		func(p models.Pedido) int { return p.ID }, func(p models.Pedido) time.Time { return p.DataPedido })
+	return pedidos, total, nil
+}
+
+func (r *pedidosMemoria) Obter(id int) (models.Pedido, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.pedidos[id]
+	if !ok {
+		return p, ErrNaoEncontrado
+	}
+	p.Itens = d.itensDoPedido(id)
+	return p, nil
+}
+
+func (r *pedidosMemoria) Bloquear(id int) (models.Pedido, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.pedidos[id]
+	if !ok {
+		return p, ErrNaoEncontrado
+	}
+	return p, nil
+}
+
+func (r *pedidosMemoria) Itens(pedidoID int) ([]models.PedidoItem, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	return d.itensDoPedido(pedidoID), nil
+}
+
+func (r *pedidosMemoria) AtualizarStatus(id int, status string) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	p, ok := d.pedidos[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	p.Status = status
+	d.pedidos[id] = p
+	return nil
+}
+
+func (r *pedidosMemoria) ClienteRecebeu(email string, produtoID int) (bool, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, item := range d.pedidoItens {
+		p := d.pedidos[item.PedidoID]
+		if item.ProdutoID == produtoID && p.ClienteEmail == email && p.Status == models.StatusPedidoEntregue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *pedidosMemoria) RegistrarHistorico(h *models.PedidoStatusHistorico) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.pedidos[h.PedidoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	h.ID = d.proximoID("pedido_status_historico")
+	h.AlteradoEm = time.Now()
+	d.historico[h.ID] = *h
+	return nil
+}
+
+func (r *pedidosMemoria) Historico(pedidoID int) ([]models.PedidoStatusHistorico, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	historico := make([]models.PedidoStatusHistorico, 0)
+	for _, h := range d.historico {
+		if h.PedidoID == pedidoID {
+			historico = append(historico, h)
+		}
+	}
+	sort.Slice(historico, func(i, j int) bool { return historico[i].ID < historico[j].ID })
+	return historico, nil
+}
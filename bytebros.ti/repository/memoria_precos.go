@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"cmp"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type precosMemoria struct{ memoria }
+
+func (r *precosMemoria) Registrar(h *models.HistoricoPreco) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	h.ID = d.proximoID("historico_precos")
+	h.CriadoEm = time.Now()
+	d.precos[h.ID] = *h
+	return nil
+}
+
+var comparadoresPreco = map[string]func(a, b models.HistoricoPreco) int{
+	"id":        func(a, b models.HistoricoPreco) int { return cmp.Compare(a.ID, b.ID) },
+	"criado_em": func(a, b models.HistoricoPreco) int { return a.CriadoEm.Compare(b.CriadoEm) },
+}
+
+func (r *precosMemoria) Listar(filtro FiltroPrecos, pagina Pagina) ([]models.HistoricoPreco, int, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	historico := make([]models.HistoricoPreco, 0)
+	for _, h := range d.precos {
+		if filtro.ProdutoID != 0 && (h.ProdutoID == nil || *h.ProdutoID != filtro.ProdutoID) {
+			continue
+		}
+		if filtro.ServicoID != 0 && (h.ServicoID == nil || *h.ServicoID != filtro.ServicoID) {
+			continue
+		}
+		historico = append(historico, h)
+	}
+	historico, total := paginar(historico, pagina, OrdenacaoPrecos, comparadoresPreco,
+		func(h models.HistoricoPreco) int { return h.ID }, func(h models.HistoricoPreco) time.Time { return h.CriadoEm })
+	return historico, total, nil
+}
+
+func (r *precosMemoria) SincronizarOfertas() (ativadas, encerradas int, err error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	agora := time.Now()
+	for id, p := range d.produtos {
+		if p.Promocao == nil || p.Oferta == p.Promocao.Ativa(agora) {
+			continue
+		}
+		p.Oferta = !p.Oferta
+		d.produtos[id] = p
+		if p.Oferta {
+			ativadas++
+		} else {
+			encerradas++
+		}
+	}
+	for id, s := range d.servicos {
+		if s.Promocao == nil || s.Oferta == s.Promocao.Ativa(agora) {
+			continue
+		}
+		s.Oferta = !s.Oferta
+		d.servicos[id] = s
+		if s.Oferta {
+			ativadas++
+		} else {
+			encerradas++
+		}
+	}
+	return ativadas, encerradas, nil
+}
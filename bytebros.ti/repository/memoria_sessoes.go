@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type tokensMemoria struct{ memoria }
+
+func (r *tokensMemoria) SalvarRefresh(t *models.RefreshToken) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, outro := range d.refresh {
+		if outro.TokenHash == t.TokenHash {
+			return ErrDuplicado
+		}
+	}
+	t.ID = d.proximoID("refresh_tokens")
+	d.refresh[t.ID] = *t
+	return nil
+}
+
+func (r *tokensMemoria) BloquearRefresh(tokenHash string) (models.RefreshToken, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, t := range d.refresh {
+		if t.TokenHash == tokenHash {
+			return t, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNaoEncontrado
+}
+
+// revogarSe revoga os refresh tokens ativos que satisfazem o filtro.
+func (r *tokensMemoria) revogarSe(filtro func(t models.RefreshToken) bool) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	agora := time.Now()
+	for id, t := range d.refresh {
+		if t.RevogadoEm == nil && filtro(t) {
+			t.RevogadoEm = &agora
+			d.refresh[id] = t
+		}
+	}
+}
+
+func (r *tokensMemoria) RevogarRefresh(id int) error {
+	r.revogarSe(func(t models.RefreshToken) bool { return t.ID == id })
+	return nil
+}
+
+func (r *tokensMemoria) RevogarRefreshDoTitular(tokenHash, tipo string, sujeitoID int) error {
+	r.revogarSe(func(t models.RefreshToken) bool {
+		return t.TokenHash == tokenHash && t.Tipo == tipo && t.SujeitoID == sujeitoID
+	})
+	return nil
+}
+
+func (r *tokensMemoria) RevogarSessoes(tipo string, sujeitoID int) error {
+	r.revogarSe(func(t models.RefreshToken) bool { return t.Tipo == tipo && t.SujeitoID == sujeitoID })
+	return nil
+}
+
+func (r *tokensMemoria) RevogarJTI(jti string, expiraEm time.Time) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, existe := d.jtis[jti]; !existe {
+		d.jtis[jti] = expiraEm
+	}
+	return nil
+}
+
+func (r *tokensMemoria) JTIRevogado(jti string) (bool, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	_, revogado := d.jtis[jti]
+	return revogado, nil
+}
+
+func (r *tokensMemoria) LimparJTIsExpirados() error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	agora := time.Now()
+	for jti, expiraEm := range d.jtis {
+		if expiraEm.Before(agora) {
+			delete(d.jtis, jti)
+		}
+	}
+	return nil
+}
+
+type redefinicoesMemoria struct{ memoria }
+
+func (r *redefinicoesMemoria) Criar(rs *models.RedefinicaoSenha) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	agora := time.Now()
+	for id, outra := range d.redefinicoes {
+		if outra.UsuarioID == rs.UsuarioID && outra.UsadoEm == nil {
+			outra.UsadoEm = &agora
+			d.redefinicoes[id] = outra
+		}
+	}
+	rs.ID = d.proximoID("redefinicoes_senha")
+	d.redefinicoes[rs.ID] = *rs
+	return nil
+}
+
+func (r *redefinicoesMemoria) Bloquear(tokenHash string) (models.RedefinicaoSenha, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	for _, rs := range d.redefinicoes {
+		if rs.TokenHash == tokenHash {
+			return rs, nil
+		}
+	}
+	return models.RedefinicaoSenha{}, ErrNaoEncontrado
+}
+
+func (r *redefinicoesMemoria) MarcarUsada(id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	rs, ok := d.redefinicoes[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	agora := time.Now()
+	rs.UsadoEm = &agora
+	d.redefinicoes[id] = rs
+	return nil
+}
+
+type permissoesMemoria struct{ memoria }
+
+func (r *permissoesMemoria) PorPapel() (map[string][]string, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	porPapel := make(map[string][]string, len(d.permissoes))
+	for papel, perms := range d.permissoes {
+		porPapel[papel] = append([]string(nil), perms...)
+	}
+	return porPapel, nil
+}
+
+func (r *permissoesMemoria) Substituir(papel string, permissoes []string) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	unicas := make([]string, 0, len(permissoes))
+	vistas := make(map[string]bool, len(permissoes))
+	for _, p := range permissoes {
+		if !vistas[p] {
+			vistas[p] = true
+			unicas = append(unicas, p)
+		}
+	}
+	sort.Strings(unicas)
+	if len(unicas) == 0 {
+		delete(d.permissoes, papel)
+	} else {
+		d.permissoes[papel] = unicas
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"sort"
+
+	"bytebros.ti/models"
+)
+
+type variantesMemoria struct{ memoria }
+
+// skuEmUso imita o UNIQUE de produto_variantes.sku.
+func (d *dadosMemoria) skuEmUso(sku string, exceto int) bool {
+	for _, v := range d.variantes {
+		if v.SKU == sku && v.ID != exceto {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *variantesMemoria) Criar(v *models.ProdutoVariante) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	if _, ok := d.produtos[v.ProdutoID]; !ok {
+		return ErrNaoEncontrado
+	}
+	if d.skuEmUso(v.SKU, 0) {
+		return ErrDuplicado
+	}
+	v.ID = d.proximoID("produto_variantes")
+	d.variantes[v.ID] = *v
+	return nil
+}
+
+func (r *variantesMemoria) Listar(produtoID int) ([]models.ProdutoVariante, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	variantes := make([]models.ProdutoVariante, 0)
+	for _, v := range d.variantes {
+		if v.ProdutoID == produtoID {
+			variantes = append(variantes, v)
+		}
+	}
+	sort.Slice(variantes, func(i, j int) bool { return variantes[i].ID < variantes[j].ID })
+	return variantes, nil
+}
+
+func (r *variantesMemoria) Todas() ([]models.ProdutoVariante, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	variantes := make([]models.ProdutoVariante, 0, len(d.variantes))
+	for _, v := range d.variantes {
+		variantes = append(variantes, v)
+	}
+	sort.Slice(variantes, func(i, j int) bool {
+		if variantes[i].ProdutoID != variantes[j].ProdutoID {
+			return variantes[i].ProdutoID < variantes[j].ProdutoID
+		}
+		return variantes[i].ID < variantes[j].ID
+	})
+	return variantes, nil
+}
+
+func (r *variantesMemoria) Obter(produtoID, id int) (models.ProdutoVariante, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	v, ok := d.variantes[id]
+	if !ok || v.ProdutoID != produtoID {
+		return models.ProdutoVariante{}, ErrNaoEncontrado
+	}
+	return v, nil
+}
+
+func (r *variantesMemoria) Atualizar(v models.ProdutoVariante) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	atual, ok := d.variantes[v.ID]
+	if !ok || atual.ProdutoID != v.ProdutoID {
+		return ErrNaoEncontrado
+	}
+	if d.skuEmUso(v.SKU, v.ID) {
+		return ErrDuplicado
+	}
+	d.variantes[v.ID] = v
+	return nil
+}
+
+func (r *variantesMemoria) Deletar(produtoID, id int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	v, ok := d.variantes[id]
+	if !ok || v.ProdutoID != produtoID {
+		return ErrNaoEncontrado
+	}
+	// Como o ON DELETE RESTRICT de pedido_itens.variante_id.
+	for _, item := range d.pedidoItens {
+		if item.VarianteID != nil && *item.VarianteID == id {
+			return ErrEmUso
+		}
+	}
+	delete(d.variantes, id)
+	// Como o ON DELETE SET NULL de movimentos_estoque.variante_id.
+	for movimentoID, m := range d.movimentos {
+		if m.VarianteID != nil && *m.VarianteID == id {
+			m.VarianteID = nil
+			d.movimentos[movimentoID] = m
+		}
+	}
+	return nil
+}
+
+func (r *variantesMemoria) Bloquear(ids []int) (map[int]models.ProdutoVariante, error) {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	variantes := make(map[int]models.ProdutoVariante, len(ids))
+	for _, id := range ids {
+		if v, ok := d.variantes[id]; ok {
+			variantes[id] = v
+		}
+	}
+	return variantes, nil
+}
+
+func (r *variantesMemoria) AjustarEstoque(id int, delta int) error {
+	d, fechar := r.abrir()
+	defer fechar()
+
+	v, ok := d.variantes[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	v.Quantidade += delta
+	d.variantes[id] = v
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Pagina pede uma fatia ordenada de uma listagem. O valor zero devolve tudo,
+// na ordem padrão do recurso.
+type Pagina struct {
+	// Limite é o máximo de itens devolvidos; 0 não limita.
+	Limite int
+	// Deslocamento é quantos itens pular antes do primeiro devolvido.
+	Deslocamento int
+	// Ordenar é um dos Campos da Ordenacao do recurso; vazio usa o padrão.
+	Ordenar string
+	Desc    bool
+	// Desde e Ate filtram pela data do recurso, inclusive. Só valem para
+	// recursos com Ordenacao.PorData.
+	Desde *time.Time
+	Ate   *time.Time
+}
+
+// Ordenacao descreve os campos pelos quais um recurso pode ser ordenado.
+// Os nomes são os mesmos do JSON devolvido pela API.
+type Ordenacao struct {
+	Campos     []string
+	Padrao     string
+	PadraoDesc bool
+	// PorData indica que o recurso tem data e aceita Desde/Ate.
+	PorData bool
+}
+
+func (o Ordenacao) Aceita(campo string) bool {
+	return slices.Contains(o.Campos, campo)
+}
+
+var (
+	OrdenacaoProdutos     = Ordenacao{Campos: []string{"id", "name", "value", "quantity"}, Padrao: "name"}
+	OrdenacaoBusca        = Ordenacao{Campos: []string{"relevancia", "id", "name", "value", "quantity"}, Padrao: "relevancia", PadraoDesc: true}
+	OrdenacaoServicos     = Ordenacao{Campos: []string{"id", "nome", "preco"}, Padrao: "nome"}
+	OrdenacaoNoticias     = Ordenacao{Campos: []string{"id", "titulo", "data"}, Padrao: "data", PadraoDesc: true, PorData: true}
+	OrdenacaoOrcamentos   = Ordenacao{Campos: []string{"id", "nome_cliente", "status", "criado_em"}, Padrao: "criado_em", PadraoDesc: true, PorData: true}
+	OrdenacaoSuporte      = Ordenacao{Campos: []string{"id", "nome", "status", "criado_em"}, Padrao: "criado_em", PadraoDesc: true, PorData: true}
+	OrdenacaoPedidos      = Ordenacao{Campos: []string{"id", "data_pedido", "status", "valor_total"}, Padrao: "data_pedido", PadraoDesc: true, PorData: true}
+	OrdenacaoUsuarios     = Ordenacao{Campos: []string{"id", "nome_completo", "email", "criado_em"}, Padrao: "nome_completo", PorData: true}
+	OrdenacaoFuncionarios = Ordenacao{Campos: []string{"id", "nome", "cargo", "email", "criado_em"}, Padrao: "nome", PorData: true}
+	OrdenacaoMovimentos   = Ordenacao{Campos: []string{"id", "criado_em", "quantidade"}, Padrao: "criado_em", PadraoDesc: true, PorData: true}
+	OrdenacaoNotificacoes = Ordenacao{Campos: []string{"id", "criado_em"}, Padrao: "criado_em", PadraoDesc: true, PorData: true}
+	OrdenacaoAvaliacoes   = Ordenacao{Campos: []string{"id", "criado_em", "nota"}, Padrao: "criado_em", PadraoDesc: true, PorData: true}
+	OrdenacaoPrecos       = Ordenacao{Campos: []string{"id", "criado_em"}, Padrao: "criado_em", PadraoDesc: true, PorData: true}
+)
+
+// ordem resolve o campo e o sentido da ordenação, caindo no padrão do
+// recurso quando Ordenar está vazio ou não é aceito.
+func (p Pagina) ordem(o Ordenacao) (string, bool) {
+	if p.Ordenar == "" || !o.Aceita(p.Ordenar) {
+		return o.Padrao, o.PadraoDesc
+	}
+	return p.Ordenar, p.Desc
+}
+
+// paginar aplica em memória o que o PostgreSQL faz com WHERE, ORDER BY,
+// LIMIT e OFFSET. comparar traz uma função por campo de o; data é nil para
+// recursos sem data. Devolve a fatia pedida e o total antes do limite.
+func paginar[T any](itens []T, p Pagina, o Ordenacao, comparar map[string]func(a, b T) int, id func(T) int, data func(T) time.Time) ([]T, int) {
+	if data != nil && (p.Desde != nil || p.Ate != nil) {
+		itens = slices.DeleteFunc(itens, func(item T) bool {
+			d := data(item)
+			return (p.Desde != nil && d.Before(*p.Desde)) || (p.Ate != nil && d.After(*p.Ate))
+		})
+	}
+
+	campo, desc := p.ordem(o)
+	porCampo := comparar[campo]
+	slices.SortStableFunc(itens, func(a, b T) int {
+		c := porCampo(a, b)
+		if c == 0 {
+			// Empates saem por id, como no ORDER BY do PostgreSQL.
+			c = cmp.Compare(id(a), id(b))
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+
+	total := len(itens)
+	inicio := min(p.Deslocamento, total)
+	fim := total
+	if p.Limite > 0 {
+		fim = min(inicio+p.Limite, total)
+	}
+	return itens[inicio:fim], total
+}
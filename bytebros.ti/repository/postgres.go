@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// executor é o que *sql.DB e *sql.Tx têm em comum, para que os mesmos
+// repositórios rodem dentro ou fora de uma transação.
+type executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NovoPostgres cria os repositórios sobre a conexão informada.
+func NovoPostgres(db *sql.DB) *Repositorios {
+	r := repositoriosPostgres(db)
+	r.transacao = func(fn func(tx *Repositorios) error) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		repos := repositoriosPostgres(tx)
+		repos.transacao = func(fn func(*Repositorios) error) error {
+			// Transações aninhadas reaproveitam a transação externa.
+			return fn(repos)
+		}
+		if err := fn(repos); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	return r
+}
+
+func repositoriosPostgres(db executor) *Repositorios {
+	return &Repositorios{
+		Produtos:     &produtosPostgres{db},
+		Categorias:   &categoriasPostgres{db},
+		Imagens:      &imagensPostgres{db},
+		Variantes:    &variantesPostgres{db},
+		Movimentos:   &movimentosPostgres{db},
+		Notificacoes: &notificacoesPostgres{db},
+		Avisos:       &avisosPostgres{db},
+		Avaliacoes:   &avaliacoesPostgres{db},
+		Precos:       &precosPostgres{db},
+		Servicos:     &servicosPostgres{db},
+		Noticias:     &noticiasPostgres{db},
+		Orcamentos:   &orcamentosPostgres{db},
+		Suporte:      &suportePostgres{db},
+		Pedidos:      &pedidosPostgres{db},
+		Fretes:       &fretesPostgres{db},
+		Pagamentos:   &pagamentosPostgres{db},
+		Usuarios:     &usuariosPostgres{db},
+		Funcionarios: &funcionariosPostgres{db},
+		Admins:       &adminsPostgres{db},
+		Tokens:       &tokensPostgres{db},
+		Redefinicoes: &redefinicoesPostgres{db},
+		Permissoes:   &permissoesPostgres{db},
+	}
+}
+
+// filtroSQL monta a cláusula WHERE a partir dos filtros não vazios.
+type filtroSQL struct {
+	clausulas []string
+	args      []any
+}
+
+func (f *filtroSQL) igual(coluna, valor string) {
+	if valor == "" {
+		return
+	}
+	f.args = append(f.args, valor)
+	f.clausulas = append(f.clausulas, fmt.Sprintf("%s = $%d", coluna, len(f.args)))
+}
+
+// condicao acrescenta uma cláusula com um único parâmetro, escrito como %d
+// no formato (por exemplo "id = ANY($%d)").
+func (f *filtroSQL) condicao(formato string, valor any) {
+	f.args = append(f.args, valor)
+	f.clausulas = append(f.clausulas, fmt.Sprintf(formato, len(f.args)))
+}
+
+// periodo restringe a coluna de data ao intervalo pedido na página.
+func (f *filtroSQL) periodo(coluna string, p Pagina) {
+	if p.Desde != nil {
+		f.args = append(f.args, *p.Desde)
+		f.clausulas = append(f.clausulas, fmt.Sprintf("%s >= $%d", coluna, len(f.args)))
+	}
+	if p.Ate != nil {
+		f.args = append(f.args, *p.Ate)
+		f.clausulas = append(f.clausulas, fmt.Sprintf("%s <= $%d", coluna, len(f.args)))
+	}
+}
+
+func (f *filtroSQL) where() string {
+	if len(f.clausulas) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.clausulas, " AND ")
+}
+
+// contar devolve quantas linhas da tabela passam pelo filtro, para o total
+// das listagens paginadas.
+func (f *filtroSQL) contar(db executor, tabela string) (int, error) {
+	var total int
+	err := db.QueryRow(`SELECT COUNT(*) FROM `+tabela+f.where(), f.args...).Scan(&total)
+	return total, err
+}
+
+// ordemSQL monta ORDER BY, LIMIT e OFFSET. colunas traduz os campos da
+// Ordenacao para colunas da tabela; o id desempata.
+func ordemSQL(p Pagina, o Ordenacao, colunas map[string]string) string {
+	campo, desc := p.ordem(o)
+	sentido := " ASC"
+	if desc {
+		sentido = " DESC"
+	}
+	clausula := " ORDER BY " + colunas[campo] + sentido + ", id" + sentido
+	if p.Limite > 0 {
+		clausula += fmt.Sprintf(" LIMIT %d", p.Limite)
+	}
+	if p.Deslocamento > 0 {
+		clausula += fmt.Sprintf(" OFFSET %d", p.Deslocamento)
+	}
+	return clausula
+}
+
+// verificarAfetadas converte "nenhuma linha afetada" em ErrNaoEncontrado.
+func verificarAfetadas(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNaoEncontrado
+	}
+	return nil
+}
+
+func naoEncontrado(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNaoEncontrado
+	}
+	return err
+}
+
+func textoNulo(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// inteiroNulo converte uma coluna INTEGER anulável em *int.
+func inteiroNulo(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// dataNula converte uma coluna TIMESTAMP anulável em *time.Time.
+func dataNula(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// emUso converte violações de chave estrangeira em ErrEmUso.
+func emUso(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrEmUso
+	}
+	return err
+}
+
+// duplicado converte violações de UNIQUE em ErrDuplicado.
+func duplicado(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicado
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+
+	"bytebros.ti/models"
+)
+
+type orcamentosPostgres struct{ db executor }
+
+const colunasOrcamento = `id, nome_cliente, email_cliente, telefone, descricao, servico_nome, status, criado_em, atualizado_em`
+
+func scanOrcamento(s interface{ Scan(...any) error }, o *models.Orcamento) error {
+	var servicoNome sql.NullString
+	err := s.Scan(&o.ID, &o.NomeCliente, &o.EmailCliente, &o.Telefone, &o.Descricao, &servicoNome, &o.Status, &o.CriadoEm, &o.AtualizadoEm)
+	o.ServicoNome = servicoNome.String
+	return err
+}
+
+func (r *orcamentosPostgres) Criar(o *models.Orcamento) error {
+	return r.db.QueryRow(`
+		INSERT INTO orcamentos (nome_cliente, email_cliente, telefone, descricao, servico_nome, status, criado_em, atualizado_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		o.NomeCliente, o.EmailCliente, o.Telefone, o.Descricao, o.ServicoNome, o.Status, o.CriadoEm, o.AtualizadoEm).
+		Scan(&o.ID)
+}
+
+var colunasOrdenacaoOrcamento = map[string]string{"id": "id", "nome_cliente": "nome_cliente", "status": "status", "criado_em": "criado_em"}
+
+func (r *orcamentosPostgres) Listar(filtro FiltroOrcamentos, pagina Pagina) ([]models.Orcamento, int, error) {
+	var f filtroSQL
+	f.igual("status", filtro.Status)
+	f.igual("email_cliente", filtro.Email)
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "orcamentos")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+colunasOrcamento+` FROM orcamentos`+f.where()+ordemSQL(pagina, OrdenacaoOrcamentos, colunasOrdenacaoOrcamento), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	orcamentos := make([]models.Orcamento, 0)
+	for rows.Next() {
+		var o models.Orcamento
+		if err := scanOrcamento(rows, &o); err != nil {
+			return nil, 0, err
+		}
+		orcamentos = append(orcamentos, o)
+	}
+	return orcamentos, total, rows.Err()
+}
+
+func (r *orcamentosPostgres) Obter(id int) (models.Orcamento, error) {
+	var o models.Orcamento
+	err := scanOrcamento(r.db.QueryRow(`SELECT `+colunasOrcamento+` FROM orcamentos WHERE id = $1`, id), &o)
+	return o, naoEncontrado(err)
+}
+
+func (r *orcamentosPostgres) AtualizarStatus(id int, status string) error {
+	return verificarAfetadas(r.db.Exec(`
+		UPDATE orcamentos
+		SET status = $1, atualizado_em = NOW()
+		WHERE id = $2`, status, id))
+}
+
+func (r *orcamentosPostgres) Deletar(id int) error {
+	return verificarAfetadas(r.db.Exec(`DELETE FROM orcamentos WHERE id = $1`, id))
+}
+
+type suportePostgres struct{ db executor }
+
+const colunasSuporte = `id, nome, email, mensagem, status, tipo_interacao, cliente_email, criado_em`
+
+func scanSuporte(s interface{ Scan(...any) error }, m *models.Suporte) error {
+	var clienteEmail sql.NullString
+	err := s.Scan(&m.ID, &m.Nome, &m.Email, &m.Mensagem, &m.Status, &m.TipoInteracao, &clienteEmail, &m.CriadoEm)
+	m.ClienteEmail = clienteEmail.String
+	return err
+}
+
+func (r *suportePostgres) Criar(s *models.Suporte) error {
+	return r.db.QueryRow(`
+		INSERT INTO suporte (nome, email, mensagem, status, tipo_interacao, cliente_email)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, criado_em`,
+		s.Nome, s.Email, s.Mensagem, s.Status, s.TipoInteracao, textoNulo(s.ClienteEmail)).
+		Scan(&s.ID, &s.CriadoEm)
+}
+
+var colunasOrdenacaoSuporte = map[string]string{"id": "id", "nome": "nome", "status": "status", "criado_em": "criado_em"}
+
+func (r *suportePostgres) Listar(filtro FiltroSuporte, pagina Pagina) ([]models.Suporte, int, error) {
+	var f filtroSQL
+	f.igual("status", filtro.Status)
+	f.igual("tipo_interacao", filtro.TipoInteracao)
+	f.igual("cliente_email", filtro.ClienteEmail)
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "suporte")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+colunasSuporte+` FROM suporte`+f.where()+ordemSQL(pagina, OrdenacaoSuporte, colunasOrdenacaoSuporte), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	mensagens := make([]models.Suporte, 0)
+	for rows.Next() {
+		var s models.Suporte
+		if err := scanSuporte(rows, &s); err != nil {
+			return nil, 0, err
+		}
+		mensagens = append(mensagens, s)
+	}
+	return mensagens, total, rows.Err()
+}
+
+func (r *suportePostgres) Obter(id int) (models.Suporte, error) {
+	var s models.Suporte
+	err := scanSuporte(r.db.QueryRow(`SELECT `+colunasSuporte+` FROM suporte WHERE id = $1`, id), &s)
+	return s, naoEncontrado(err)
+}
+
+func (r *suportePostgres) AtualizarStatus(id int, status string) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE suporte SET status = $1 WHERE id = $2`, status, id))
+}
+
+func (r *suportePostgres) Deletar(id int) error {
+	return verificarAfetadas(r.db.Exec(`DELETE FROM suporte WHERE id = $1`, id))
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+
+	"bytebros.ti/models"
+	"github.com/lib/pq"
+)
+
+type avaliacoesPostgres struct{ db executor }
+
+const colunasAvaliacao = `id, produto_id, cliente_email, autor, nota, comentario, status, criado_em, moderado_em, moderado_por`
+
+func scanAvaliacao(s interface{ Scan(...any) error }, a *models.Avaliacao) error {
+	var moderadoEm sql.NullTime
+	var moderadoPor sql.NullString
+	if err := s.Scan(&a.ID, &a.ProdutoID, &a.ClienteEmail, &a.Autor, &a.Nota, &a.Comentario, &a.Status, &a.CriadoEm, &moderadoEm, &moderadoPor); err != nil {
+		return err
+	}
+	a.ModeradoEm = dataNula(moderadoEm)
+	a.ModeradoPor = moderadoPor.String
+	return nil
+}
+
+// mediaNotas arredonda a média para uma casa decimal, como é exibida.
+func mediaNotas(soma, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(soma)/float64(total)*10) / 10
+}
+
+func (r *avaliacoesPostgres) Criar(a *models.Avaliacao) error {
+	err := r.db.QueryRow(`
+		INSERT INTO avaliacoes (produto_id, cliente_email, autor, nota, comentario)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, criado_em`,
+		a.ProdutoID, a.ClienteEmail, a.Autor, a.Nota, a.Comentario).
+		Scan(&a.ID, &a.Status, &a.CriadoEm)
+	if errors.Is(emUso(err), ErrEmUso) {
+		return ErrNaoEncontrado
+	}
+	return duplicado(err)
+}
+
+var colunasOrdenacaoAvaliacao = map[string]string{"id": "id", "criado_em": "criado_em", "nota": "nota"}
+
+func (r *avaliacoesPostgres) Listar(filtro FiltroAvaliacoes, pagina Pagina) ([]models.Avaliacao, int, error) {
+	var f filtroSQL
+	if filtro.ProdutoID != 0 {
+		f.condicao("produto_id = $%d", filtro.ProdutoID)
+	}
+	f.igual("status", filtro.Status)
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "avaliacoes")
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(`SELECT `+colunasAvaliacao+` FROM avaliacoes`+f.where()+
+		ordemSQL(pagina, OrdenacaoAvaliacoes, colunasOrdenacaoAvaliacao), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	avaliacoes := make([]models.Avaliacao, 0)
+	for rows.Next() {
+		var a models.Avaliacao
+		if err := scanAvaliacao(rows, &a); err != nil {
+			return nil, 0, err
+		}
+		avaliacoes = append(avaliacoes, a)
+	}
+	return avaliacoes, total, rows.Err()
+}
+
+func (r *avaliacoesPostgres) Moderar(id int, status, usuario string) (models.Avaliacao, error) {
+	var a models.Avaliacao
+	err := scanAvaliacao(r.db.QueryRow(`
+		UPDATE avaliacoes
+		SET status = $2, moderado_em = CURRENT_TIMESTAMP, moderado_por = $3
+		WHERE id = $1
+		RETURNING `+colunasAvaliacao, id, status, usuario), &a)
+	return a, naoEncontrado(err)
+}
+
+func (r *avaliacoesPostgres) Resumos(produtoIDs []int) (map[int]models.ResumoAvaliacoes, error) {
+	resumos := make(map[int]models.ResumoAvaliacoes)
+	if len(produtoIDs) == 0 {
+		return resumos, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT produto_id, SUM(nota), COUNT(*)
+		FROM avaliacoes
+		WHERE produto_id = ANY($1) AND status = $2
+		GROUP BY produto_id`,
+		pq.Array(produtoIDs), models.AvaliacaoAprovada)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, soma, total int
+		if err := rows.Scan(&id, &soma, &total); err != nil {
+			return nil, err
+		}
+		resumos[id] = models.ResumoAvaliacoes{Media: mediaNotas(soma, total), Total: total}
+	}
+	return resumos, rows.Err()
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"bytebros.ti/models"
+)
+
+type avisosPostgres struct{ db executor }
+
+func (r *avisosPostgres) Criar(a *models.AvisoEstoque) error {
+	err := r.db.QueryRow(`
+		INSERT INTO avisos_estoque (produto_id, email)
+		VALUES ($1, $2)
+		RETURNING id, criado_em`,
+		a.ProdutoID, a.Email).
+		Scan(&a.ID, &a.CriadoEm)
+	if errors.Is(emUso(err), ErrEmUso) {
+		return ErrNaoEncontrado
+	}
+	return duplicado(err)
+}
+
+func (r *avisosPostgres) ProdutosRepostos() ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT a.produto_id
+		FROM avisos_estoque a
+		JOIN produtos p ON p.id = a.produto_id
+		WHERE a.notificado_em IS NULL AND p.quantidade > 0
+		ORDER BY a.produto_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *avisosPostgres) Reivindicar(produtoID int) ([]models.AvisoEstoque, error) {
+	// O UPDATE trava as linhas: uma segunda chamada concorrente espera e já
+	// não encontra os avisos pendentes.
+	rows, err := r.db.Query(`
+		UPDATE avisos_estoque a
+		SET notificado_em = CURRENT_TIMESTAMP
+		FROM produtos p
+		WHERE p.id = a.produto_id AND a.produto_id = $1 AND a.notificado_em IS NULL AND p.quantidade > 0
+		RETURNING a.id, a.produto_id, a.email, a.criado_em, a.notificado_em`, produtoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	avisos := make([]models.AvisoEstoque, 0)
+	for rows.Next() {
+		var a models.AvisoEstoque
+		var notificadoEm sql.NullTime
+		if err := rows.Scan(&a.ID, &a.ProdutoID, &a.Email, &a.CriadoEm, &notificadoEm); err != nil {
+			return nil, err
+		}
+		a.NotificadoEm = dataNula(notificadoEm)
+		avisos = append(avisos, a)
+	}
+	return avisos, rows.Err()
+}
+
+func (r *avisosPostgres) Liberar(id int) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE avisos_estoque SET notificado_em = NULL WHERE id = $1`, id))
+}
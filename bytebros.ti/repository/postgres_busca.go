@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+
+	"bytebros.ti/models"
+	"github.com/lib/pq"
+)
+
+// consultaBusca prepara o termo ($1) uma única vez: q para o tsvector e t,
+// sem acentos, para a semelhança por trigramas.
+const consultaBusca = `WITH consulta AS (
+	SELECT websearch_to_tsquery('portuguese', sem_acento($1)) AS q, sem_acento(lower($1)) AS t
+) `
+
+// relevanciaBusca soma o rank textual (normalizado para 0..1) à semelhança
+// do termo com alguma palavra do nome, que cobre erros de digitação.
+const relevanciaBusca = `ts_rank_cd(busca, consulta.q, 32) + word_similarity(consulta.t, sem_acento(lower(nome)))`
+
+var colunasOrdenacaoBusca = map[string]string{"relevancia": "relevancia", "id": "id", "name": "nome", "value": "preco", "quantity": "quantidade"}
+
+func (r *produtosPostgres) Buscar(filtro FiltroBusca, pagina Pagina) (models.ResultadoBusca, error) {
+	f := filtroSQL{args: []any{filtro.Termo}}
+	f.clausulas = append(f.clausulas, "(busca @@ consulta.q OR consulta.t <% sem_acento(lower(nome)))")
+	if filtro.SomenteOfertas {
+		f.clausulas = append(f.clausulas, "oferta = true")
+	}
+	if filtro.SomenteEmEstoque {
+		f.clausulas = append(f.clausulas, "quantidade > 0")
+	}
+	if filtro.PrecoMin != nil {
+		f.condicao("preco >= $%d", *filtro.PrecoMin)
+	}
+	if filtro.PrecoMax != nil {
+		f.condicao("preco <= $%d", *filtro.PrecoMax)
+	}
+	if len(filtro.CategoriaIDs) > 0 {
+		f.condicao("id IN (SELECT produto_id FROM produto_categorias WHERE categoria_id = ANY($%d))", pq.Array(filtro.CategoriaIDs))
+	}
+	de := ` FROM produtos CROSS JOIN consulta` + f.where()
+
+	resultado := models.ResultadoBusca{Produtos: make([]models.ProdutoBusca, 0)}
+	if err := r.db.QueryRow(consultaBusca+`SELECT COUNT(*)`+de, f.args...).Scan(&resultado.Total); err != nil {
+		return resultado, err
+	}
+
+	rows, err := r.db.Query(consultaBusca+`SELECT `+colunasProduto+`, `+relevanciaBusca+` AS relevancia`+de+
+		ordemSQL(pagina, OrdenacaoBusca, colunasOrdenacaoBusca), f.args...)
+	if err != nil {
+		return resultado, err
+	}
+	defer rows.Close()
+
+	var produtos []models.Produto
+	var relevancias []float64
+	for rows.Next() {
+		var p models.Produto
+		var relevancia float64
+		if err := scanProduto(rows, &p, &relevancia); err != nil {
+			return resultado, err
+		}
+		produtos = append(produtos, p)
+		relevancias = append(relevancias, relevancia)
+	}
+	if err := rows.Err(); err != nil {
+		return resultado, err
+	}
+	if err := r.carregarCategorias(produtos); err != nil {
+		return resultado, err
+	}
+	for i, p := range produtos {
+		resultado.Produtos = append(resultado.Produtos, models.ProdutoBusca{Produto: p, Relevancia: relevancias[i]})
+	}
+
+	resultado.Facetas, err = r.facetas(de, f.args)
+	return resultado, err
+}
+
+// facetas conta os resultados por faixa de preço, categoria e estoque. de é
+// o FROM ... WHERE da busca, com os mesmos args.
+func (r *produtosPostgres) facetas(de string, args []any) (models.FacetasBusca, error) {
+	facetas := models.FacetasBusca{Precos: facetasPreco(), Categorias: make([]models.FacetaCategoria, 0)}
+
+	// width_bucket devolve quantos limites são <= preco, que é o índice da
+	// faixa em facetasPreco.
+	argsFaixas := append(slices.Clone(args), pq.Array(LimitesFaixasPreco))
+	faixas := fmt.Sprintf(`SELECT width_bucket(preco::float8, $%d::float8[]) AS faixa, COUNT(*)`, len(argsFaixas))
+	rows, err := r.db.Query(consultaBusca+faixas+de+` GROUP BY faixa`, argsFaixas...)
+	if err != nil {
+		return facetas, err
+	}
+	for rows.Next() {
+		var faixa, total int
+		if err := rows.Scan(&faixa, &total); err != nil {
+			rows.Close()
+			return facetas, err
+		}
+		facetas.Precos[faixa].Total = total
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return facetas, err
+	}
+
+	rows, err = r.db.Query(consultaBusca+`
+		SELECT c.id, c.nome, c.slug, COUNT(*)
+		FROM produto_categorias pc
+		JOIN categorias c ON c.id = pc.categoria_id
+		WHERE pc.produto_id IN (SELECT id`+de+`)
+		GROUP BY c.id, c.nome, c.slug
+		ORDER BY COUNT(*) DESC, c.nome`, args...)
+	if err != nil {
+		return facetas, err
+	}
+	for rows.Next() {
+		var fc models.FacetaCategoria
+		if err := rows.Scan(&fc.CategoriaID, &fc.Nome, &fc.Slug, &fc.Total); err != nil {
+			rows.Close()
+			return facetas, err
+		}
+		facetas.Categorias = append(facetas.Categorias, fc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return facetas, err
+	}
+
+	err = r.db.QueryRow(consultaBusca+`SELECT COUNT(*) FILTER (WHERE quantidade > 0), COUNT(*) FILTER (WHERE quantidade <= 0)`+de, args...).
+		Scan(&facetas.EmEstoque, &facetas.SemEstoque)
+	return facetas, err
+}
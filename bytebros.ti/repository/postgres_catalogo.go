@@ -0,0 +1,485 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"bytebros.ti/models"
+	"github.com/lib/pq"
+)
+
+type produtosPostgres struct{ db executor }
+
+const colunasProduto = `id, nome, quantidade, preco, oferta, detalhes, imagem, estoque_minimo, especificacoes, preco_promocional, promocao_inicio, promocao_fim,
+	peso_g, altura_cm, largura_cm, comprimento_cm`
+
+// scanProduto lê as colunasProduto e, em seguida, as colunas extras da
+// consulta.
+func scanProduto(s interface{ Scan(...any) error }, p *models.Produto, extras ...any) error {
+	var especificacoes []byte
+	var precoPromocional sql.NullFloat64
+	var inicio, fim sql.NullTime
+	destinos := append([]any{&p.ID, &p.Nome, &p.Quantidade, &p.Preco, &p.Oferta, &p.Detalhes, &p.Imagem, &p.EstoqueMinimo, &especificacoes,
+		&precoPromocional, &inicio, &fim,
+		&p.Embalagem.PesoG, &p.Embalagem.AlturaCm, &p.Embalagem.LarguraCm, &p.Embalagem.ComprimentoCm}, extras...)
+	if err := s.Scan(destinos...); err != nil {
+		return err
+	}
+	p.Promocao = promocaoNula(precoPromocional, inicio, fim)
+	p.Especificacoes = nil
+	if especificacoes == nil {
+		return nil
+	}
+	p.Especificacoes = &models.Especificacoes{}
+	return json.Unmarshal(especificacoes, p.Especificacoes)
+}
+
+// especificacoesJSON converte as especificações para a coluna JSONB; nil
+// vira NULL.
+func especificacoesJSON(e *models.Especificacoes) (any, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+func (r *produtosPostgres) Criar(p *models.Produto) error {
+	especificacoes, err := especificacoesJSON(p.Especificacoes)
+	if err != nil {
+		return err
+	}
+	precoPromocional, inicio, fim := colunasPromocao(p.Promocao)
+	return r.db.QueryRow(`
+		INSERT INTO produtos (nome, quantidade, preco, oferta, detalhes, imagem, estoque_minimo, especificacoes,
+			preco_promocional, promocao_inicio, promocao_fim, peso_g, altura_cm, largura_cm, comprimento_cm)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id`,
+		p.Nome, p.Quantidade, p.Preco, p.Oferta, p.Detalhes, p.Imagem, p.EstoqueMinimo, especificacoes,
+		precoPromocional, inicio, fim, p.Embalagem.PesoG, p.Embalagem.AlturaCm, p.Embalagem.LarguraCm, p.Embalagem.ComprimentoCm).
+		Scan(&p.ID)
+}
+
+var colunasOrdenacaoProduto = map[string]string{"id": "id", "name": "nome", "value": "preco", "quantity": "quantidade"}
+
+func (r *produtosPostgres) Listar(filtro FiltroProdutos, pagina Pagina) ([]models.Produto, int, error) {
+	var f filtroSQL
+	if filtro.SomenteOfertas {
+		f.clausulas = append(f.clausulas, "oferta = true")
+	}
+	if len(filtro.CategoriaIDs) > 0 {
+		f.condicao("id IN (SELECT produto_id FROM produto_categorias WHERE categoria_id = ANY($%d))", pq.Array(filtro.CategoriaIDs))
+	}
+	f.igual("especificacoes->>'componente'", filtro.Componente)
+
+	total, err := f.contar(r.db, "produtos")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+colunasProduto+` FROM produtos`+f.where()+ordemSQL(pagina, OrdenacaoProdutos, colunasOrdenacaoProduto), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	produtos := make([]models.Produto, 0)
+	for rows.Next() {
+		var p models.Produto
+		if err := scanProduto(rows, &p); err != nil {
+			return nil, 0, err
+		}
+		produtos = append(produtos, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := r.carregarCategorias(produtos); err != nil {
+		return nil, 0, err
+	}
+	return produtos, total, nil
+}
+
+func (r *produtosPostgres) Percorrer(visitar func(p models.Produto, variantes []models.ProdutoVariante) error) error {
+	rows, err := r.db.Query(`
+		SELECT ` + colunasProduto + `,
+			COALESCE((SELECT json_agg(categoria_id ORDER BY categoria_id) FROM produto_categorias WHERE produto_id = produtos.id), '[]'),
+			COALESCE((SELECT json_agg(json_build_object('id', id, 'produto_id', produto_id, 'sku', sku, 'atributos', atributos,
+				'preco', preco, 'quantidade', quantidade) ORDER BY id) FROM produto_variantes WHERE produto_id = produtos.id), '[]')
+		FROM produtos
+		ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Produto
+		var categorias, variantesJSON []byte
+		if err := scanProduto(rows, &p, &categorias, &variantesJSON); err != nil {
+			return err
+		}
+		var variantes []models.ProdutoVariante
+		if err := json.Unmarshal(categorias, &p.CategoriaIDs); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(variantesJSON, &variantes); err != nil {
+			return err
+		}
+		if err := visitar(p, variantes); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *produtosPostgres) Obter(id int) (models.Produto, error) {
+	var p models.Produto
+	err := scanProduto(r.db.QueryRow(`SELECT `+colunasProduto+` FROM produtos WHERE id = $1`, id), &p)
+	if err != nil {
+		return p, naoEncontrado(err)
+	}
+	produtos := []models.Produto{p}
+	err = r.carregarCategorias(produtos)
+	return produtos[0], err
+}
+
+// carregarCategorias preenche CategoriaIDs de todos os produtos com uma
+// única consulta.
+func (r *produtosPostgres) carregarCategorias(produtos []models.Produto) error {
+	ids := make([]int, len(produtos))
+	for i := range produtos {
+		ids[i] = produtos[i].ID
+		produtos[i].CategoriaIDs = []int{}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT produto_id, categoria_id
+		FROM produto_categorias
+		WHERE produto_id = ANY($1)
+		ORDER BY categoria_id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	categorias := make(map[int][]int)
+	for rows.Next() {
+		var produtoID, categoriaID int
+		if err := rows.Scan(&produtoID, &categoriaID); err != nil {
+			return err
+		}
+		categorias[produtoID] = append(categorias[produtoID], categoriaID)
+	}
+	for i := range produtos {
+		if c, ok := categorias[produtos[i].ID]; ok {
+			produtos[i].CategoriaIDs = c
+		}
+	}
+	return rows.Err()
+}
+
+func (r *produtosPostgres) Atualizar(p models.Produto) error {
+	especificacoes, err := especificacoesJSON(p.Especificacoes)
+	if err != nil {
+		return err
+	}
+	precoPromocional, inicio, fim := colunasPromocao(p.Promocao)
+	return verificarAfetadas(r.db.Exec(`
+		UPDATE produtos
+		SET nome = $1, quantidade = $2, preco = $3, oferta = $4, detalhes = $5, imagem = $6, estoque_minimo = $7, especificacoes = $8,
+			preco_promocional = $9, promocao_inicio = $10, promocao_fim = $11,
+			peso_g = $12, altura_cm = $13, largura_cm = $14, comprimento_cm = $15
+		WHERE id = $16`,
+		p.Nome, p.Quantidade, p.Preco, p.Oferta, p.Detalhes, p.Imagem, p.EstoqueMinimo, especificacoes,
+		precoPromocional, inicio, fim, p.Embalagem.PesoG, p.Embalagem.AlturaCm, p.Embalagem.LarguraCm, p.Embalagem.ComprimentoCm, p.ID))
+}
+
+func (r *produtosPostgres) Deletar(id int) error {
+	// Produtos em pedidos são barrados pelo ON DELETE RESTRICT de pedido_itens.
+	return emUso(verificarAfetadas(r.db.Exec(`DELETE FROM produtos WHERE id = $1`, id)))
+}
+
+func (r *produtosPostgres) Bloquear(ids []int) (map[int]models.Produto, error) {
+	unicos := idsUnicos(ids)
+	rows, err := r.db.Query(`
+		SELECT `+colunasProduto+`
+		FROM produtos
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE`, pq.Array(unicos))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	produtos := make(map[int]models.Produto, len(unicos))
+	for rows.Next() {
+		var p models.Produto
+		if err := scanProduto(rows, &p); err != nil {
+			return nil, err
+		}
+		produtos[p.ID] = p
+	}
+	return produtos, rows.Err()
+}
+
+func (r *produtosPostgres) AjustarEstoque(id int, delta int) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE produtos SET quantidade = quantidade + $1 WHERE id = $2`, delta, id))
+}
+
+func (r *produtosPostgres) DefinirCategorias(produtoID int, categoriaIDs []int) error {
+	if _, err := r.db.Exec(`DELETE FROM produto_categorias WHERE produto_id = $1`, produtoID); err != nil {
+		return err
+	}
+	if len(categoriaIDs) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO produto_categorias (produto_id, categoria_id)
+		SELECT $1, unnest($2::int[])`,
+		produtoID, pq.Array(idsUnicos(categoriaIDs)))
+	if errors.Is(emUso(err), ErrEmUso) {
+		// Chave estrangeira violada: produto ou categoria inexistente.
+		return ErrNaoEncontrado
+	}
+	return err
+}
+
+type categoriasPostgres struct{ db executor }
+
+const colunasCategoria = `id, nome, slug, pai_id`
+
+func scanCategoria(s interface{ Scan(...any) error }, c *models.Categoria) error {
+	var pai sql.NullInt64
+	if err := s.Scan(&c.ID, &c.Nome, &c.Slug, &pai); err != nil {
+		return err
+	}
+	c.PaiID = inteiroNulo(pai)
+	return nil
+}
+
+func (r *categoriasPostgres) Criar(c *models.Categoria) error {
+	err := r.db.QueryRow(`
+		INSERT INTO categorias (nome, slug, pai_id)
+		VALUES ($1, $2, $3)
+		RETURNING id`,
+		c.Nome, c.Slug, c.PaiID).
+		Scan(&c.ID)
+	return duplicado(err)
+}
+
+func (r *categoriasPostgres) Listar() ([]models.Categoria, error) {
+	rows, err := r.db.Query(`SELECT ` + colunasCategoria + ` FROM categorias ORDER BY nome, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categorias := make([]models.Categoria, 0)
+	for rows.Next() {
+		var c models.Categoria
+		if err := scanCategoria(rows, &c); err != nil {
+			return nil, err
+		}
+		categorias = append(categorias, c)
+	}
+	return categorias, rows.Err()
+}
+
+func (r *categoriasPostgres) Obter(id int) (models.Categoria, error) {
+	var c models.Categoria
+	err := scanCategoria(r.db.QueryRow(`SELECT `+colunasCategoria+` FROM categorias WHERE id = $1`, id), &c)
+	return c, naoEncontrado(err)
+}
+
+func (r *categoriasPostgres) ObterPorSlug(slug string) (models.Categoria, error) {
+	var c models.Categoria
+	err := scanCategoria(r.db.QueryRow(`SELECT `+colunasCategoria+` FROM categorias WHERE slug = $1`, slug), &c)
+	return c, naoEncontrado(err)
+}
+
+func (r *categoriasPostgres) Atualizar(c models.Categoria) error {
+	return duplicado(verificarAfetadas(r.db.Exec(`
+		UPDATE categorias
+		SET nome = $1, slug = $2, pai_id = $3
+		WHERE id = $4`,
+		c.Nome, c.Slug, c.PaiID, c.ID)))
+}
+
+func (r *categoriasPostgres) Deletar(id int) error {
+	// Subcategorias são barradas pelo ON DELETE RESTRICT de pai_id; as
+	// ligações com produtos saem em cascata.
+	return emUso(verificarAfetadas(r.db.Exec(`DELETE FROM categorias WHERE id = $1`, id)))
+}
+
+func (r *categoriasPostgres) Produtos() (map[int][]int, error) {
+	rows, err := r.db.Query(`SELECT categoria_id, produto_id FROM produto_categorias ORDER BY produto_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	produtos := make(map[int][]int)
+	for rows.Next() {
+		var categoriaID, produtoID int
+		if err := rows.Scan(&categoriaID, &produtoID); err != nil {
+			return nil, err
+		}
+		produtos[categoriaID] = append(produtos[categoriaID], produtoID)
+	}
+	return produtos, rows.Err()
+}
+
+// idsUnicos remove repetidos e ordena, para travar linhas sempre na mesma
+// ordem e evitar deadlock entre pedidos concorrentes.
+func idsUnicos(ids []int) []int {
+	unicos := make([]int, 0, len(ids))
+	vistos := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if !vistos[id] {
+			vistos[id] = true
+			unicos = append(unicos, id)
+		}
+	}
+	sort.Ints(unicos)
+	return unicos
+}
+
+type servicosPostgres struct{ db executor }
+
+const colunasServico = `id, nome, preco, oferta, detalhes, preco_promocional, promocao_inicio, promocao_fim`
+
+func scanServico(sc interface{ Scan(...any) error }, s *models.Servico) error {
+	var precoPromocional sql.NullFloat64
+	var inicio, fim sql.NullTime
+	if err := sc.Scan(&s.ID, &s.Nome, &s.Preco, &s.Oferta, &s.Detalhes, &precoPromocional, &inicio, &fim); err != nil {
+		return err
+	}
+	s.Promocao = promocaoNula(precoPromocional, inicio, fim)
+	return nil
+}
+
+func (r *servicosPostgres) Criar(s *models.Servico) error {
+	precoPromocional, inicio, fim := colunasPromocao(s.Promocao)
+	return r.db.QueryRow(`
+		INSERT INTO servicos (nome, preco, oferta, detalhes, preco_promocional, promocao_inicio, promocao_fim)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		s.Nome, s.Preco, s.Oferta, s.Detalhes, precoPromocional, inicio, fim).
+		Scan(&s.ID)
+}
+
+var colunasOrdenacaoServico = map[string]string{"id": "id", "nome": "nome", "preco": "preco"}
+
+func (r *servicosPostgres) Listar(filtro FiltroServicos, pagina Pagina) ([]models.Servico, int, error) {
+	var f filtroSQL
+	if filtro.SomenteOfertas {
+		f.clausulas = append(f.clausulas, "oferta = true")
+	}
+
+	total, err := f.contar(r.db, "servicos")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+colunasServico+` FROM servicos`+f.where()+ordemSQL(pagina, OrdenacaoServicos, colunasOrdenacaoServico), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	servicos := make([]models.Servico, 0)
+	for rows.Next() {
+		var s models.Servico
+		if err := scanServico(rows, &s); err != nil {
+			return nil, 0, err
+		}
+		servicos = append(servicos, s)
+	}
+	return servicos, total, rows.Err()
+}
+
+func (r *servicosPostgres) Obter(id int) (models.Servico, error) {
+	var s models.Servico
+	err := scanServico(r.db.QueryRow(`SELECT `+colunasServico+` FROM servicos WHERE id = $1`, id), &s)
+	return s, naoEncontrado(err)
+}
+
+func (r *servicosPostgres) Atualizar(s models.Servico) error {
+	precoPromocional, inicio, fim := colunasPromocao(s.Promocao)
+	return verificarAfetadas(r.db.Exec(`
+		UPDATE servicos
+		SET nome = $1, preco = $2, oferta = $3, detalhes = $4, preco_promocional = $5, promocao_inicio = $6, promocao_fim = $7
+		WHERE id = $8`,
+		s.Nome, s.Preco, s.Oferta, s.Detalhes, precoPromocional, inicio, fim, s.ID))
+}
+
+func (r *servicosPostgres) Deletar(id int) error {
+	return verificarAfetadas(r.db.Exec(`DELETE FROM servicos WHERE id = $1`, id))
+}
+
+type noticiasPostgres struct{ db executor }
+
+func (r *noticiasPostgres) Criar(n *models.Noticia) error {
+	return r.db.QueryRow(`
+		INSERT INTO noticias (titulo, subtitulo, conteudo, autor, data)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		n.Titulo, n.Subtitulo, n.Conteudo, n.Autor, n.Data).
+		Scan(&n.ID)
+}
+
+var colunasOrdenacaoNoticia = map[string]string{"id": "id", "titulo": "titulo", "data": "data"}
+
+func (r *noticiasPostgres) Listar(pagina Pagina) ([]models.Noticia, int, error) {
+	var f filtroSQL
+	f.periodo("data", pagina)
+
+	total, err := f.contar(r.db, "noticias")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT id, titulo, subtitulo, conteudo, autor, data FROM noticias`+f.where()+ordemSQL(pagina, OrdenacaoNoticias, colunasOrdenacaoNoticia), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	noticias := make([]models.Noticia, 0)
+	for rows.Next() {
+		var n models.Noticia
+		if err := rows.Scan(&n.ID, &n.Titulo, &n.Subtitulo, &n.Conteudo, &n.Autor, &n.Data); err != nil {
+			return nil, 0, err
+		}
+		noticias = append(noticias, n)
+	}
+	return noticias, total, rows.Err()
+}
+
+func (r *noticiasPostgres) Obter(id int) (models.Noticia, error) {
+	var n models.Noticia
+	err := r.db.QueryRow(`
+		SELECT id, titulo, subtitulo, conteudo, autor, data
+		FROM noticias
+		WHERE id = $1`, id).
+		Scan(&n.ID, &n.Titulo, &n.Subtitulo, &n.Conteudo, &n.Autor, &n.Data)
+	return n, naoEncontrado(err)
+}
+
+func (r *noticiasPostgres) Atualizar(n models.Noticia) error {
+	return verificarAfetadas(r.db.Exec(`
+		UPDATE noticias
+		SET titulo = $1, subtitulo = $2, conteudo = $3, autor = $4
+		WHERE id = $5`,
+		n.Titulo, n.Subtitulo, n.Conteudo, n.Autor, n.ID))
+}
+
+func (r *noticiasPostgres) Deletar(id int) error {
+	return verificarAfetadas(r.db.Exec(`DELETE FROM noticias WHERE id = $1`, id))
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"bytebros.ti/models"
+)
+
+type usuariosPostgres struct{ db executor }
+
+func scanUsuario(s interface{ Scan(...any) error }, u *models.Usuario) error {
+	var telefone sql.NullString
+	err := s.Scan(&u.ID, &u.Nome, &u.Email, &u.SenhaHash, &telefone)
+	u.Telefone = telefone.String
+	return err
+}
+
+func (r *usuariosPostgres) Criar(u *models.Usuario) error {
+	return duplicado(r.db.QueryRow(`
+		INSERT INTO usuarios (nome_completo, email, senha_hash, telefone)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		u.Nome, u.Email, u.SenhaHash, u.Telefone).
+		Scan(&u.ID))
+}
+
+func (r *usuariosPostgres) Obter(id int) (models.Usuario, error) {
+	var u models.Usuario
+	err := scanUsuario(r.db.QueryRow(`SELECT id, nome_completo, email, senha_hash, telefone FROM usuarios WHERE id = $1`, id), &u)
+	return u, naoEncontrado(err)
+}
+
+func (r *usuariosPostgres) ObterPorEmail(email string) (models.Usuario, error) {
+	var u models.Usuario
+	err := scanUsuario(r.db.QueryRow(`SELECT id, nome_completo, email, senha_hash, telefone FROM usuarios WHERE email = $1`, email), &u)
+	return u, naoEncontrado(err)
+}
+
+func (r *usuariosPostgres) EmailEmUso(email string, excetoID int) (bool, error) {
+	var emUso bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM usuarios WHERE email = $1 AND id != $2)`, email, excetoID).Scan(&emUso)
+	return emUso, err
+}
+
+func (r *usuariosPostgres) AtualizarEmail(id int, email string) error {
+	return duplicado(verificarAfetadas(r.db.Exec(`UPDATE usuarios SET email = $1, atualizado_em = NOW() WHERE id = $2`, email, id)))
+}
+
+func (r *usuariosPostgres) AtualizarTelefone(id int, telefone string) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE usuarios SET telefone = $1, atualizado_em = NOW() WHERE id = $2`, telefone, id))
+}
+
+func (r *usuariosPostgres) AtualizarSenha(id int, senhaHash string) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE usuarios SET senha_hash = $1, atualizado_em = NOW() WHERE id = $2`, senhaHash, id))
+}
+
+var colunasOrdenacaoUsuario = map[string]string{"id": "id", "nome_completo": "nome_completo", "email": "email", "criado_em": "criado_em"}
+
+func (r *usuariosPostgres) Listar(busca string, pagina Pagina) ([]models.Usuario, int, error) {
+	var f filtroSQL
+	if busca != "" {
+		f.args = append(f.args, "%"+strings.ToLower(busca)+"%")
+		f.clausulas = append(f.clausulas, "(LOWER(email) LIKE $1 OR LOWER(telefone) LIKE $1)")
+	}
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "usuarios")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT id, nome_completo, email, telefone, criado_em FROM usuarios`+f.where()+ordemSQL(pagina, OrdenacaoUsuarios, colunasOrdenacaoUsuario), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	usuarios := make([]models.Usuario, 0)
+	for rows.Next() {
+		var u models.Usuario
+		var telefone sql.NullString
+		var criadoEm sql.NullTime
+		if err := rows.Scan(&u.ID, &u.Nome, &u.Email, &telefone, &criadoEm); err != nil {
+			return nil, 0, err
+		}
+		u.Telefone = telefone.String
+		u.CriadoEm = criadoEm.Time
+		usuarios = append(usuarios, u)
+	}
+	return usuarios, total, rows.Err()
+}
+
+type funcionariosPostgres struct{ db executor }
+
+func (r *funcionariosPostgres) Criar(f *models.Funcionario) error {
+	return duplicado(r.db.QueryRow(`
+		INSERT INTO funcionarios (nome, cargo, email, senha_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		f.Nome, f.Cargo, f.Email, f.SenhaHash).
+		Scan(&f.ID))
+}
+
+func (r *funcionariosPostgres) Obter(id int) (models.Funcionario, error) {
+	var f models.Funcionario
+	err := r.db.QueryRow(`SELECT id, nome, cargo, email, senha_hash FROM funcionarios WHERE id = $1`, id).
+		Scan(&f.ID, &f.Nome, &f.Cargo, &f.Email, &f.SenhaHash)
+	return f, naoEncontrado(err)
+}
+
+func (r *funcionariosPostgres) ObterPorEmail(email string) (models.Funcionario, error) {
+	var f models.Funcionario
+	err := r.db.QueryRow(`SELECT id, nome, cargo, email, senha_hash FROM funcionarios WHERE email = $1`, email).
+		Scan(&f.ID, &f.Nome, &f.Cargo, &f.Email, &f.SenhaHash)
+	return f, naoEncontrado(err)
+}
+
+var colunasOrdenacaoFuncionario = map[string]string{"id": "id", "nome": "nome", "cargo": "cargo", "email": "email", "criado_em": "criado_em"}
+
+func (r *funcionariosPostgres) Listar(pagina Pagina) ([]models.Funcionario, int, error) {
+	var f filtroSQL
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "funcionarios")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT id, nome, cargo, email, criado_em FROM funcionarios`+f.where()+ordemSQL(pagina, OrdenacaoFuncionarios, colunasOrdenacaoFuncionario), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	funcionarios := make([]models.Funcionario, 0)
+	for rows.Next() {
+		var fn models.Funcionario
+		var criadoEm sql.NullTime
+		if err := rows.Scan(&fn.ID, &fn.Nome, &fn.Cargo, &fn.Email, &criadoEm); err != nil {
+			return nil, 0, err
+		}
+		fn.CriadoEm = criadoEm.Time
+		funcionarios = append(funcionarios, fn)
+	}
+	return funcionarios, total, rows.Err()
+}
+
+type adminsPostgres struct{ db executor }
+
+const colunasAdmin = `id, nome, email, senha_hash, is_admin, criado_em, atualizado_em`
+
+func scanAdmin(s interface{ Scan(...any) error }, a *models.Administrador) error {
+	return s.Scan(&a.ID, &a.Nome, &a.Email, &a.SenhaHash, &a.IsAdmin, &a.CriadoEm, &a.Atualizado)
+}
+
+func (r *adminsPostgres) Criar(a *models.Administrador) error {
+	return duplicado(r.db.QueryRow(`
+		INSERT INTO admin (nome, email, senha_hash, is_admin)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, criado_em, atualizado_em`,
+		a.Nome, a.Email, a.SenhaHash, a.IsAdmin).
+		Scan(&a.ID, &a.CriadoEm, &a.Atualizado))
+}
+
+func (r *adminsPostgres) Obter(id int) (models.Administrador, error) {
+	var a models.Administrador
+	err := scanAdmin(r.db.QueryRow(`SELECT `+colunasAdmin+` FROM admin WHERE id = $1`, id), &a)
+	return a, naoEncontrado(err)
+}
+
+func (r *adminsPostgres) ObterPorEmail(email string) (models.Administrador, error) {
+	var a models.Administrador
+	err := scanAdmin(r.db.QueryRow(`SELECT `+colunasAdmin+` FROM admin WHERE email = $1`, email), &a)
+	return a, naoEncontrado(err)
+}
+
+func (r *adminsPostgres) Deletar(id int) error {
+	return verificarAfetadas(r.db.Exec(`DELETE FROM admin WHERE id = $1`, id))
+}
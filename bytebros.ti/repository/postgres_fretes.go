@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"database/sql"
+
+	"bytebros.ti/models"
+)
+
+type fretesPostgres struct{ db executor }
+
+func (r *fretesPostgres) Criar(o *models.OpcaoFrete) error {
+	return duplicado(r.db.QueryRow(`
+		INSERT INTO cotacoes_frete (id, cep_destino, transportadora, servico, nome, valor, prazo_dias, prazo_entrega, itens, expira_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING criado_em`,
+		o.ID, o.CEPDestino, o.Transportadora, o.Servico, o.Nome, o.Valor, o.PrazoDias, o.PrazoEntrega, o.Itens, o.ExpiraEm).
+		Scan(&o.CriadoEm))
+}
+
+func (r *fretesPostgres) Obter(id string) (models.OpcaoFrete, error) {
+	var o models.OpcaoFrete
+	var pedidoID sql.NullInt64
+	err := r.db.QueryRow(`
+		SELECT id, cep_destino, transportadora, servico, nome, valor, prazo_dias, prazo_entrega, itens, pedido_id, criado_em, expira_em
+		FROM cotacoes_frete
+		WHERE id = $1`, id).
+		Scan(&o.ID, &o.CEPDestino, &o.Transportadora, &o.Servico, &o.Nome, &o.Valor, &o.PrazoDias, &o.PrazoEntrega, &o.Itens,
+			&pedidoID, &o.CriadoEm, &o.ExpiraEm)
+	o.PedidoID = inteiroNulo(pedidoID)
+	return o, naoEncontrado(err)
+}
+
+func (r *fretesPostgres) Usar(id string, pedidoID int) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE cotacoes_frete SET pedido_id = $1 WHERE id = $2 AND pedido_id IS NULL`, pedidoID, id))
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+
+	"bytebros.ti/models"
+	"github.com/lib/pq"
+)
+
+type imagensPostgres struct{ db executor }
+
+const colunasImagem = `id, produto_id, ordem, urls, chaves, content_type, largura, altura, criado_em`
+
+func scanImagem(s interface{ Scan(...any) error }, img *models.ProdutoImagem) error {
+	var urls, chaves []byte
+	if err := s.Scan(&img.ID, &img.ProdutoID, &img.Ordem, &urls, &chaves, &img.ContentType, &img.Largura, &img.Altura, &img.CriadoEm); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(urls, &img.URLs); err != nil {
+		return err
+	}
+	return json.Unmarshal(chaves, &img.Chaves)
+}
+
+func (r *imagensPostgres) Criar(img *models.ProdutoImagem) error {
+	urls, err := json.Marshal(img.URLs)
+	if err != nil {
+		return err
+	}
+	chaves, err := json.Marshal(img.Chaves)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRow(`
+		INSERT INTO produto_imagens (produto_id, ordem, urls, chaves, content_type, largura, altura)
+		VALUES ($1, (SELECT COALESCE(MAX(ordem) + 1, 0) FROM produto_imagens WHERE produto_id = $1), $2, $3, $4, $5, $6)
+		RETURNING id, ordem, criado_em`,
+		img.ProdutoID, urls, chaves, img.ContentType, img.Largura, img.Altura).
+		Scan(&img.ID, &img.Ordem, &img.CriadoEm)
+	if errors.Is(emUso(err), ErrEmUso) {
+		return ErrNaoEncontrado
+	}
+	return err
+}
+
+func (r *imagensPostgres) Listar(produtoID int) ([]models.ProdutoImagem, error) {
+	rows, err := r.db.Query(`SELECT `+colunasImagem+` FROM produto_imagens WHERE produto_id = $1 ORDER BY ordem, id`, produtoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imagens := make([]models.ProdutoImagem, 0)
+	for rows.Next() {
+		var img models.ProdutoImagem
+		if err := scanImagem(rows, &img); err != nil {
+			return nil, err
+		}
+		imagens = append(imagens, img)
+	}
+	return imagens, rows.Err()
+}
+
+func (r *imagensPostgres) Obter(produtoID, id int) (models.ProdutoImagem, error) {
+	var img models.ProdutoImagem
+	err := scanImagem(r.db.QueryRow(`SELECT `+colunasImagem+` FROM produto_imagens WHERE id = $1 AND produto_id = $2`, id, produtoID), &img)
+	return img, naoEncontrado(err)
+}
+
+func (r *imagensPostgres) Deletar(produtoID, id int) error {
+	return verificarAfetadas(r.db.Exec(`DELETE FROM produto_imagens WHERE id = $1 AND produto_id = $2`, id, produtoID))
+}
+
+func (r *imagensPostgres) Reordenar(produtoID int, ids []int) error {
+	// WITH ORDINALITY numera os ids na ordem do array, a partir de 1.
+	res, err := r.db.Exec(`
+		UPDATE produto_imagens i
+		SET ordem = nova.posicao - 1
+		FROM unnest($2::int[]) WITH ORDINALITY AS nova(id, posicao)
+		WHERE i.id = nova.id AND i.produto_id = $1`,
+		produtoID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(idsUnicos(ids)) {
+		return ErrNaoEncontrado
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type movimentosPostgres struct{ db executor }
+
+const colunasMovimento = `id, produto_id, variante_id, tipo, quantidade, saldo, saldo_variante, motivo, pedido_id, usuario, criado_em`
+
+func scanMovimento(s interface{ Scan(...any) error }, m *models.MovimentoEstoque) error {
+	var varianteID, saldoVariante, pedidoID sql.NullInt64
+	if err := s.Scan(&m.ID, &m.ProdutoID, &varianteID, &m.Tipo, &m.Quantidade, &m.Saldo, &saldoVariante, &m.Motivo, &pedidoID, &m.Usuario, &m.CriadoEm); err != nil {
+		return err
+	}
+	m.VarianteID = inteiroNulo(varianteID)
+	m.SaldoVariante = inteiroNulo(saldoVariante)
+	m.PedidoID = inteiroNulo(pedidoID)
+	return nil
+}
+
+func (r *movimentosPostgres) Registrar(m *models.MovimentoEstoque) error {
+	// Os saldos são lidos das próprias linhas já ajustadas na transação.
+	var saldoVariante sql.NullInt64
+	err := r.db.QueryRow(`
+		INSERT INTO movimentos_estoque (produto_id, variante_id, tipo, quantidade, saldo, saldo_variante, motivo, pedido_id, usuario)
+		SELECT p.id, $2, $3, $4, p.quantidade, (SELECT v.quantidade FROM produto_variantes v WHERE v.id = $2), $5, $6, $7
+		FROM produtos p
+		WHERE p.id = $1
+		RETURNING id, saldo, saldo_variante, criado_em`,
+		m.ProdutoID, m.VarianteID, m.Tipo, m.Quantidade, m.Motivo, m.PedidoID, m.Usuario).
+		Scan(&m.ID, &m.Saldo, &saldoVariante, &m.CriadoEm)
+	m.SaldoVariante = inteiroNulo(saldoVariante)
+	return naoEncontrado(err)
+}
+
+var colunasOrdenacaoMovimento = map[string]string{"id": "id", "criado_em": "criado_em", "quantidade": "quantidade"}
+
+func (r *movimentosPostgres) Listar(filtro FiltroMovimentos, pagina Pagina) ([]models.MovimentoEstoque, int, error) {
+	var f filtroSQL
+	if filtro.ProdutoID != 0 {
+		f.condicao("produto_id = $%d", filtro.ProdutoID)
+	}
+	if filtro.VarianteID != 0 {
+		f.condicao("variante_id = $%d", filtro.VarianteID)
+	}
+	f.igual("tipo", filtro.Tipo)
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "movimentos_estoque")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+colunasMovimento+` FROM movimentos_estoque`+f.where()+ordemSQL(pagina, OrdenacaoMovimentos, colunasOrdenacaoMovimento), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	movimentos := make([]models.MovimentoEstoque, 0)
+	for rows.Next() {
+		var m models.MovimentoEstoque
+		if err := scanMovimento(rows, &m); err != nil {
+			return nil, 0, err
+		}
+		movimentos = append(movimentos, m)
+	}
+	return movimentos, total, rows.Err()
+}
+
+func (r *movimentosPostgres) Resumo(produtoID int, desde, ate *time.Time) ([]models.ResumoEstoque, error) {
+	var f filtroSQL
+	if produtoID != 0 {
+		f.condicao("m.produto_id = $%d", produtoID)
+	}
+	if ate != nil {
+		f.condicao("m.criado_em <= $%d", *ate)
+	}
+	// Sem desde, tudo conta como período e o saldo inicial é zero.
+	anterior := "0"
+	if desde != nil {
+		f.args = append(f.args, *desde)
+		anterior = fmt.Sprintf("COALESCE(SUM(m.quantidade) FILTER (WHERE m.criado_em < $%d), 0)", len(f.args))
+	}
+	rows, err := r.db.Query(`
+		SELECT m.produto_id, p.nome, m.tipo, `+anterior+`, SUM(m.quantidade)
+		FROM movimentos_estoque m
+		JOIN produtos p ON p.id = m.produto_id`+f.where()+`
+		GROUP BY m.produto_id, p.nome, m.tipo
+		ORDER BY m.produto_id, m.tipo`, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resumos := make([]models.ResumoEstoque, 0)
+	for rows.Next() {
+		var id, antes, total int
+		var nome, tipo string
+		if err := rows.Scan(&id, &nome, &tipo, &antes, &total); err != nil {
+			return nil, err
+		}
+		if len(resumos) == 0 || resumos[len(resumos)-1].ProdutoID != id {
+			resumos = append(resumos, models.ResumoEstoque{ProdutoID: id, NomeProduto: nome, Movimentos: map[string]int{}})
+		}
+		resumo := &resumos[len(resumos)-1]
+		resumo.SaldoInicial += antes
+		if total != antes {
+			resumo.Movimentos[tipo] += total - antes
+		}
+		resumo.SaldoFinal += total
+	}
+	return resumos, rows.Err()
+}
+
+func (r *movimentosPostgres) Divergencias() ([]models.DivergenciaEstoque, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, NULL::int, p.nome, NULL::varchar, p.quantidade, COALESCE(SUM(m.quantidade), 0)::int
+		FROM produtos p
+		LEFT JOIN movimentos_estoque m ON m.produto_id = p.id
+		GROUP BY p.id
+		HAVING p.quantidade <> COALESCE(SUM(m.quantidade), 0)
+		UNION ALL
+		SELECT v.produto_id, v.id, p.nome, v.sku, v.quantidade, COALESCE(SUM(m.quantidade), 0)::int
+		FROM produto_variantes v
+		JOIN produtos p ON p.id = v.produto_id
+		LEFT JOIN movimentos_estoque m ON m.variante_id = v.id
+		GROUP BY v.id, p.nome
+		HAVING v.quantidade <> COALESCE(SUM(m.quantidade), 0)
+		ORDER BY 1, 2 NULLS FIRST`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	divergencias := make([]models.DivergenciaEstoque, 0)
+	for rows.Next() {
+		var d models.DivergenciaEstoque
+		var varianteID sql.NullInt64
+		var sku sql.NullString
+		if err := rows.Scan(&d.ProdutoID, &varianteID, &d.NomeProduto, &sku, &d.Quantidade, &d.SaldoRazao); err != nil {
+			return nil, err
+		}
+		d.VarianteID = inteiroNulo(varianteID)
+		d.SKU = sku.String
+		divergencias = append(divergencias, d)
+	}
+	return divergencias, rows.Err()
+}
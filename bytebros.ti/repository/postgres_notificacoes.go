@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+
+	"bytebros.ti/models"
+)
+
+type notificacoesPostgres struct{ db executor }
+
+const colunasNotificacao = `id, tipo, produto_id, titulo, mensagem, criado_em, lida_em, lida_por, resolvida_em`
+
+func scanNotificacao(s interface{ Scan(...any) error }, n *models.NotificacaoAdmin) error {
+	var produtoID sql.NullInt64
+	var lidaEm, resolvidaEm sql.NullTime
+	var lidaPor sql.NullString
+	if err := s.Scan(&n.ID, &n.Tipo, &produtoID, &n.Titulo, &n.Mensagem, &n.CriadoEm, &lidaEm, &lidaPor, &resolvidaEm); err != nil {
+		return err
+	}
+	n.ProdutoID = inteiroNulo(produtoID)
+	n.LidaEm = dataNula(lidaEm)
+	n.LidaPor = lidaPor.String
+	n.ResolvidaEm = dataNula(resolvidaEm)
+	return nil
+}
+
+func (r *notificacoesPostgres) listarDe(query string, args ...any) ([]models.NotificacaoAdmin, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notificacoes := make([]models.NotificacaoAdmin, 0)
+	for rows.Next() {
+		var n models.NotificacaoAdmin
+		if err := scanNotificacao(rows, &n); err != nil {
+			return nil, err
+		}
+		notificacoes = append(notificacoes, n)
+	}
+	return notificacoes, rows.Err()
+}
+
+func (r *notificacoesPostgres) AbrirAlertasEstoque() ([]models.NotificacaoAdmin, error) {
+	// O índice único parcial descarta alertas abertos em paralelo por outra
+	// instância.
+	return r.listarDe(`
+		INSERT INTO notificacoes_admin (tipo, produto_id, titulo, mensagem)
+		SELECT $1, p.id, 'Estoque baixo: ' || p.nome,
+			format('%s está com %s unidade(s) em estoque; o mínimo configurado é %s.', p.nome, p.quantidade, p.estoque_minimo)
+		FROM produtos p
+		WHERE p.quantidade <= p.estoque_minimo
+			AND NOT EXISTS (
+				SELECT 1 FROM notificacoes_admin n
+				WHERE n.tipo = $1 AND n.produto_id = p.id AND n.resolvida_em IS NULL
+			)
+		ORDER BY p.id
+		ON CONFLICT DO NOTHING
+		RETURNING `+colunasNotificacao, models.NotificacaoEstoqueBaixo)
+}
+
+func (r *notificacoesPostgres) ResolverAlertasEstoque() (int, error) {
+	res, err := r.db.Exec(`
+		UPDATE notificacoes_admin n
+		SET resolvida_em = CURRENT_TIMESTAMP
+		FROM produtos p
+		WHERE p.id = n.produto_id AND n.tipo = $1 AND n.resolvida_em IS NULL AND p.quantidade > p.estoque_minimo`,
+		models.NotificacaoEstoqueBaixo)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+var colunasOrdenacaoNotificacao = map[string]string{"id": "id", "criado_em": "criado_em"}
+
+func (r *notificacoesPostgres) Listar(filtro FiltroNotificacoes, pagina Pagina) ([]models.NotificacaoAdmin, int, error) {
+	var f filtroSQL
+	f.igual("tipo", filtro.Tipo)
+	if filtro.SomenteNaoLidas {
+		f.clausulas = append(f.clausulas, "lida_em IS NULL")
+	}
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "notificacoes_admin")
+	if err != nil {
+		return nil, 0, err
+	}
+	notificacoes, err := r.listarDe(`SELECT `+colunasNotificacao+` FROM notificacoes_admin`+f.where()+
+		ordemSQL(pagina, OrdenacaoNotificacoes, colunasOrdenacaoNotificacao), f.args...)
+	return notificacoes, total, err
+}
+
+func (r *notificacoesPostgres) MarcarLida(id int, usuario string) (models.NotificacaoAdmin, error) {
+	// Ler de novo não muda quem leu primeiro.
+	var n models.NotificacaoAdmin
+	err := scanNotificacao(r.db.QueryRow(`
+		UPDATE notificacoes_admin
+		SET lida_em = COALESCE(lida_em, CURRENT_TIMESTAMP), lida_por = COALESCE(lida_por, $2)
+		WHERE id = $1
+		RETURNING `+colunasNotificacao, id, usuario), &n)
+	return n, naoEncontrado(err)
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type pagamentosPostgres struct{ db executor }
+
+const colunasPagamento = `id, pedido_id, metodo, status, valor, txid, copia_e_cola, end_to_end_id, expira_em, pago_em, criado_em, atualizado_em,
+	gateway, gateway_id, parcelas, valor_parcela, bandeira, final_cartao, valor_estornado,
+	nosso_numero, linha_digitavel, codigo_barras, vencimento, documento_pagador,
+	estorno_pendente, estorno_referencia, estorno_motivo, estorno_usuario`
+
+func scanPagamento(s interface{ Scan(...any) error }, p *models.Pagamento) error {
+	var txid, copiaECola, e2e, gateway, gatewayID, bandeira, finalCartao sql.NullString
+	var nossoNumero, linhaDigitavel, codigoBarras, documentoPagador sql.NullString
+	var estornoReferencia, estornoMotivo, estornoUsuario sql.NullString
+	var expiraEm, pagoEm, vencimento sql.NullTime
+	var parcelas sql.NullInt64
+	var valorParcela sql.NullFloat64
+	err := s.Scan(&p.ID, &p.PedidoID, &p.Metodo, &p.Status, &p.Valor, &txid, &copiaECola, &e2e, &expiraEm, &pagoEm, &p.CriadoEm, &p.AtualizadoEm,
+		&gateway, &gatewayID, &parcelas, &valorParcela, &bandeira, &finalCartao, &p.ValorEstornado,
+		&nossoNumero, &linhaDigitavel, &codigoBarras, &vencimento, &documentoPagador,
+		&p.EstornoPendente, &estornoReferencia, &estornoMotivo, &estornoUsuario)
+	p.TxID, p.CopiaECola, p.EndToEndID = txid.String, copiaECola.String, e2e.String
+	p.ExpiraEm, p.PagoEm = dataNula(expiraEm), dataNula(pagoEm)
+	p.Gateway, p.GatewayID, p.Bandeira, p.FinalCartao = gateway.String, gatewayID.String, bandeira.String, finalCartao.String
+	p.Parcelas, p.ValorParcela = int(parcelas.Int64), valorParcela.Float64
+	p.NossoNumero, p.LinhaDigitavel, p.CodigoBarras = nossoNumero.String, linhaDigitavel.String, codigoBarras.String
+	p.Vencimento, p.DocumentoPagador = dataNula(vencimento), documentoPagador.String
+	p.EstornoReferencia, p.EstornoMotivo, p.EstornoUsuario = estornoReferencia.String, estornoMotivo.String, estornoUsuario.String
+	return err
+}
+
+func (r *pagamentosPostgres) Criar(p *models.Pagamento) error {
+	err := r.db.QueryRow(`
+		INSERT INTO pagamentos (pedido_id, metodo, status, valor, txid, copia_e_cola, expira_em, gateway, parcelas, valor_parcela,
+			nosso_numero, linha_digitavel, codigo_barras, vencimento, documento_pagador)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, criado_em, atualizado_em`,
+		p.PedidoID, p.Metodo, p.Status, p.Valor, textoNulo(p.TxID), textoNulo(p.CopiaECola), p.ExpiraEm,
+		textoNulo(p.Gateway), sql.NullInt64{Int64: int64(p.Parcelas), Valid: p.Parcelas > 0},
+		sql.NullFloat64{Float64: p.ValorParcela, Valid: p.Parcelas > 0},
+		textoNulo(p.NossoNumero), textoNulo(p.LinhaDigitavel), textoNulo(p.CodigoBarras), p.Vencimento, textoNulo(p.DocumentoPagador)).
+		Scan(&p.ID, &p.CriadoEm, &p.AtualizadoEm)
+	return duplicado(emUso(err))
+}
+
+func (r *pagamentosPostgres) Bloquear(id int) (models.Pagamento, error) {
+	var p models.Pagamento
+	err := scanPagamento(r.db.QueryRow(`SELECT `+colunasPagamento+` FROM pagamentos WHERE id = $1 FOR UPDATE`, id), &p)
+	return p, naoEncontrado(err)
+}
+
+func (r *pagamentosPostgres) ObterPorTxID(txid string) (models.Pagamento, error) {
+	var p models.Pagamento
+	err := scanPagamento(r.db.QueryRow(`SELECT `+colunasPagamento+` FROM pagamentos WHERE txid = $1`, txid), &p)
+	return p, naoEncontrado(err)
+}
+
+func (r *pagamentosPostgres) BloquearPorTxID(txid string) (models.Pagamento, error) {
+	var p models.Pagamento
+	err := scanPagamento(r.db.QueryRow(`SELECT `+colunasPagamento+` FROM pagamentos WHERE txid = $1 FOR UPDATE`, txid), &p)
+	return p, naoEncontrado(err)
+}
+
+func (r *pagamentosPostgres) BloquearPorNossoNumero(nossoNumero string) (models.Pagamento, error) {
+	var p models.Pagamento
+	err := scanPagamento(r.db.QueryRow(`SELECT `+colunasPagamento+` FROM pagamentos WHERE nosso_numero = $1 FOR UPDATE`, nossoNumero), &p)
+	return p, naoEncontrado(err)
+}
+
+func (r *pagamentosPostgres) ProximoNossoNumero() (int64, error) {
+	var n int64
+	err := r.db.QueryRow(`SELECT nextval('nosso_numero_seq')`).Scan(&n)
+	return n, err
+}
+
+func (r *pagamentosPostgres) DoPedido(pedidoID int) ([]models.Pagamento, error) {
+	rows, err := r.db.Query(`SELECT `+colunasPagamento+` FROM pagamentos WHERE pedido_id = $1 ORDER BY id`, pedidoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pagamentos := make([]models.Pagamento, 0)
+	for rows.Next() {
+		var p models.Pagamento
+		if err := scanPagamento(rows, &p); err != nil {
+			return nil, err
+		}
+		pagamentos = append(pagamentos, p)
+	}
+	return pagamentos, rows.Err()
+}
+
+func (r *pagamentosPostgres) Atualizar(p *models.Pagamento) error {
+	err := r.db.QueryRow(`
+		UPDATE pagamentos
+		SET status = $1, end_to_end_id = $2, pago_em = $3, gateway_id = $4, bandeira = $5, final_cartao = $6,
+			valor_estornado = $7, estorno_pendente = $8, estorno_referencia = $9, estorno_motivo = $10, estorno_usuario = $11,
+			atualizado_em = NOW()
+		WHERE id = $12
+		RETURNING atualizado_em`,
+		p.Status, textoNulo(p.EndToEndID), p.PagoEm, textoNulo(p.GatewayID), textoNulo(p.Bandeira), textoNulo(p.FinalCartao),
+		p.ValorEstornado, p.EstornoPendente, textoNulo(p.EstornoReferencia), textoNulo(p.EstornoMotivo), textoNulo(p.EstornoUsuario), p.ID).
+		Scan(&p.AtualizadoEm)
+	return duplicado(naoEncontrado(err))
+}
+
+func (r *pagamentosPostgres) Vencidas(agora time.Time) ([]int, error) {
+	rows, err := r.db.Query(`SELECT id FROM pagamentos WHERE status = $1 AND expira_em < $2 ORDER BY id`, models.StatusPagamentoPendente, agora)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *pagamentosPostgres) EstornosPendentes(antes time.Time) ([]models.Pagamento, error) {
+	rows, err := r.db.Query(`SELECT `+colunasPagamento+` FROM pagamentos WHERE status = $1 AND atualizado_em < $2 ORDER BY id`,
+		models.StatusPagamentoEstornoPendente, antes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pagamentos := make([]models.Pagamento, 0)
+	for rows.Next() {
+		var p models.Pagamento
+		if err := scanPagamento(rows, &p); err != nil {
+			return nil, err
+		}
+		pagamentos = append(pagamentos, p)
+	}
+	return pagamentos, rows.Err()
+}
+
+func (r *pagamentosPostgres) RegistrarTransacao(t *models.TransacaoPagamento) error {
+	err := r.db.QueryRow(`
+		INSERT INTO transacoes_pagamento (pagamento_id, tipo, valor, sucesso, gateway_id, mensagem, usuario)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, criado_em`,
+		t.PagamentoID, t.Tipo, t.Valor, t.Sucesso, textoNulo(t.GatewayID), textoNulo(t.Mensagem), t.Usuario).
+		Scan(&t.ID, &t.CriadoEm)
+	return emUso(err)
+}
+
+func (r *pagamentosPostgres) Transacoes(pagamentoID int) ([]models.TransacaoPagamento, error) {
+	rows, err := r.db.Query(`
+		SELECT id, pagamento_id, tipo, valor, sucesso, gateway_id, mensagem, usuario, criado_em
+		FROM transacoes_pagamento WHERE pagamento_id = $1 ORDER BY id`, pagamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transacoes := make([]models.TransacaoPagamento, 0)
+	for rows.Next() {
+		var t models.TransacaoPagamento
+		var gatewayID, mensagem sql.NullString
+		if err := rows.Scan(&t.ID, &t.PagamentoID, &t.Tipo, &t.Valor, &t.Sucesso, &gatewayID, &mensagem, &t.Usuario, &t.CriadoEm); err != nil {
+			return nil, err
+		}
+		t.GatewayID, t.Mensagem = gatewayID.String, mensagem.String
+		transacoes = append(transacoes, t)
+	}
+	return transacoes, rows.Err()
+}
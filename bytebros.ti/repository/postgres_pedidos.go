@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+
+	"bytebros.ti/models"
+	"github.com/lib/pq"
+)
+
+type pedidosPostgres struct{ db executor }
+
+const colunasPedido = `id, cliente_email, data_pedido, status, endereco_entrega, tipo_frete, valor_frete, valor_total, forma_pagamento, prazo_entrega`
+
+func scanPedido(s interface{ Scan(...any) error }, p *models.Pedido) error {
+	var prazo sql.NullString
+	err := s.Scan(&p.ID, &p.ClienteEmail, &p.DataPedido, &p.Status, &p.EnderecoEntrega, &p.TipoFrete, &p.ValorFrete, &p.ValorTotal, &p.FormaPagamento, &prazo)
+	p.PrazoEntrega = prazo.String
+	return err
+}
+
+func (r *pedidosPostgres) Criar(p *models.Pedido) error {
+	err := r.db.QueryRow(`
+		INSERT INTO pedidos (cliente_email, status, endereco_entrega, tipo_frete, valor_frete, valor_total, forma_pagamento, prazo_entrega)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, data_pedido`,
+		p.ClienteEmail, p.Status, p.EnderecoEntrega, p.TipoFrete, p.ValorFrete, p.ValorTotal, p.FormaPagamento, p.PrazoEntrega).
+		Scan(&p.ID, &p.DataPedido)
+	if err != nil {
+		return err
+	}
+
+	for i := range p.Itens {
+		item := &p.Itens[i]
+		item.PedidoID = p.ID
+		err := r.db.QueryRow(`
+			INSERT INTO pedido_itens (pedido_id, produto_id, variante_id, sku, nome_produto, quantidade, valor_unitario)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id`,
+			item.PedidoID, item.ProdutoID, item.VarianteID, textoNulo(item.SKU), item.NomeProduto, item.Quantidade, item.ValorUnitario).
+			Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var colunasOrdenacaoPedido = map[string]string{"id": "id", "data_pedido": "data_pedido", "status": "status", "valor_total": "valor_total"}
+
+func (r *pedidosPostgres) Listar(filtro FiltroPedidos, pagina Pagina) ([]models.Pedido, int, error) {
+	var f filtroSQL
+	f.igual("status", filtro.Status)
+	f.igual("cliente_email", filtro.ClienteEmail)
+	f.periodo("data_pedido", pagina)
+
+	total, err := f.contar(r.db, "pedidos")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+colunasPedido+` FROM pedidos`+f.where()+ordemSQL(pagina, OrdenacaoPedidos, colunasOrdenacaoPedido), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	pedidos := make([]models.Pedido, 0)
+	ids := make([]int, 0)
+	for rows.Next() {
+		var p models.Pedido
+		if err := scanPedido(rows, &p); err != nil {
+			return nil, 0, err
+		}
+		p.Itens = []models.PedidoItem{}
+		pedidos = append(pedidos, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(ids) == 0 {
+		return pedidos, total, nil
+	}
+
+	itens, err := r.itensDe(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range pedidos {
+		if doPedido, ok := itens[pedidos[i].ID]; ok {
+			pedidos[i].Itens = doPedido
+		}
+	}
+	return pedidos, total, nil
+}
+
+// itensDe busca os itens de vários pedidos numa única consulta.
+func (r *pedidosPostgres) itensDe(pedidoIDs []int) (map[int][]models.PedidoItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, pedido_id, produto_id, variante_id, sku, nome_produto, quantidade, valor_unitario
+		FROM pedido_itens
+		WHERE pedido_id = ANY($1)
+		ORDER BY id`, pq.Array(pedidoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := make(map[int][]models.PedidoItem)
+	for rows.Next() {
+		var pi models.PedidoItem
+		var varianteID sql.NullInt64
+		var sku sql.NullString
+		if err := rows.Scan(&pi.ID, &pi.PedidoID, &pi.ProdutoID, &varianteID, &sku, &pi.NomeProduto, &pi.Quantidade, &pi.ValorUnitario); err != nil {
+			return nil, err
+		}
+		pi.VarianteID = inteiroNulo(varianteID)
+		pi.SKU = sku.String
+		itens[pi.PedidoID] = append(itens[pi.PedidoID], pi)
+	}
+	return itens, rows.Err()
+}
+
+func (r *pedidosPostgres) Obter(id int) (models.Pedido, error) {
+	var p models.Pedido
+	if err := scanPedido(r.db.QueryRow(`SELECT `+colunasPedido+` FROM pedidos WHERE id = $1`, id), &p); err != nil {
+		return p, naoEncontrado(err)
+	}
+	itens, err := r.Itens(id)
+	p.Itens = itens
+	return p, err
+}
+
+func (r *pedidosPostgres) Bloquear(id int) (models.Pedido, error) {
+	var p models.Pedido
+	err := scanPedido(r.db.QueryRow(`SELECT `+colunasPedido+` FROM pedidos WHERE id = $1 FOR UPDATE`, id), &p)
+	return p, naoEncontrado(err)
+}
+
+func (r *pedidosPostgres) Itens(pedidoID int) ([]models.PedidoItem, error) {
+	itens, err := r.itensDe([]int{pedidoID})
+	if err != nil {
+		return nil, err
+	}
+	if itens[pedidoID] == nil {
+		return []models.PedidoItem{}, nil
+	}
+	return itens[pedidoID], nil
+}
+
+func (r *pedidosPostgres) AtualizarStatus(id int, status string) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE pedidos SET status = $1 WHERE id = $2`, status, id))
+}
+
+func (r *pedidosPostgres) ClienteRecebeu(email string, produtoID int) (bool, error) {
+	var recebeu bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM pedidos p
+			JOIN pedido_itens i ON i.pedido_id = p.id
+			WHERE p.cliente_email = $1 AND i.produto_id = $2 AND p.status = $3
+		)`, email, produtoID, models.StatusPedidoEntregue).
+		Scan(&recebeu)
+	return recebeu, err
+}
+
+func (r *pedidosPostgres) RegistrarHistorico(h *models.PedidoStatusHistorico) error {
+	return r.db.QueryRow(`
+		INSERT INTO pedido_status_historico (pedido_id, status_anterior, status_novo, alterado_por, observacao)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, alterado_em`,
+		h.PedidoID, textoNulo(h.StatusAnterior), h.StatusNovo, h.AlteradoPor, textoNulo(h.Observacao)).
+		Scan(&h.ID, &h.AlteradoEm)
+}
+
+func (r *pedidosPostgres) Historico(pedidoID int) ([]models.PedidoStatusHistorico, error) {
+	rows, err := r.db.Query(`
+		SELECT id, pedido_id, status_anterior, status_novo, alterado_por, observacao, alterado_em
+		FROM pedido_status_historico
+		WHERE pedido_id = $1
+		ORDER BY alterado_em, id`, pedidoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	historico := make([]models.PedidoStatusHistorico, 0)
+	for rows.Next() {
+		var h models.PedidoStatusHistorico
+		var anterior, observacao sql.NullString
+		if err := rows.Scan(&h.ID, &h.PedidoID, &anterior, &h.StatusNovo, &h.AlteradoPor, &observacao, &h.AlteradoEm); err != nil {
+			return nil, err
+		}
+		h.StatusAnterior = anterior.String
+		h.Observacao = observacao.String
+		historico = append(historico, h)
+	}
+	return historico, rows.Err()
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+
+	"bytebros.ti/models"
+)
+
+type precosPostgres struct{ db executor }
+
+// promocaoNula monta a promoção a partir das colunas preco_promocional,
+// promocao_inicio e promocao_fim; sem preço não há promoção.
+func promocaoNula(preco sql.NullFloat64, inicio, fim sql.NullTime) *models.Promocao {
+	if !preco.Valid {
+		return nil
+	}
+	return &models.Promocao{Preco: preco.Float64, Inicio: dataNula(inicio), Fim: dataNula(fim)}
+}
+
+// colunasPromocao devolve os valores das colunas de promoção; sem promoção,
+// as três ficam NULL.
+func colunasPromocao(p *models.Promocao) (preco, inicio, fim any) {
+	if p == nil {
+		return nil, nil, nil
+	}
+	preco = p.Preco
+	if p.Inicio != nil {
+		inicio = *p.Inicio
+	}
+	if p.Fim != nil {
+		fim = *p.Fim
+	}
+	return preco, inicio, fim
+}
+
+// promocaoVigenteSQL é verdadeira quando a promoção da linha está em vigor.
+const promocaoVigenteSQL = `(COALESCE(promocao_inicio <= CURRENT_TIMESTAMP, true) AND COALESCE(promocao_fim > CURRENT_TIMESTAMP, true))`
+
+func (r *precosPostgres) Registrar(h *models.HistoricoPreco) error {
+	preco, inicio, fim := colunasPromocao(h.Promocao)
+	return r.db.QueryRow(`
+		INSERT INTO historico_precos (produto_id, servico_id, preco, preco_promocional, promocao_inicio, promocao_fim, alterado_por)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, criado_em`,
+		h.ProdutoID, h.ServicoID, h.Preco, preco, inicio, fim, h.AlteradoPor).
+		Scan(&h.ID, &h.CriadoEm)
+}
+
+var colunasOrdenacaoPreco = map[string]string{"id": "id", "criado_em": "criado_em"}
+
+func (r *precosPostgres) Listar(filtro FiltroPrecos, pagina Pagina) ([]models.HistoricoPreco, int, error) {
+	var f filtroSQL
+	if filtro.ProdutoID != 0 {
+		f.condicao("produto_id = $%d", filtro.ProdutoID)
+	}
+	if filtro.ServicoID != 0 {
+		f.condicao("servico_id = $%d", filtro.ServicoID)
+	}
+	f.periodo("criado_em", pagina)
+
+	total, err := f.contar(r.db, "historico_precos")
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(`
+		SELECT id, produto_id, servico_id, preco, preco_promocional, promocao_inicio, promocao_fim, alterado_por, criado_em
+		FROM historico_precos`+f.where()+ordemSQL(pagina, OrdenacaoPrecos, colunasOrdenacaoPreco), f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	historico := make([]models.HistoricoPreco, 0)
+	for rows.Next() {
+		var h models.HistoricoPreco
+		var produtoID, servicoID sql.NullInt64
+		var preco sql.NullFloat64
+		var inicio, fim sql.NullTime
+		if err := rows.Scan(&h.ID, &produtoID, &servicoID, &h.Preco, &preco, &inicio, &fim, &h.AlteradoPor, &h.CriadoEm); err != nil {
+			return nil, 0, err
+		}
+		h.ProdutoID = inteiroNulo(produtoID)
+		h.ServicoID = inteiroNulo(servicoID)
+		h.Promocao = promocaoNula(preco, inicio, fim)
+		historico = append(historico, h)
+	}
+	return historico, total, rows.Err()
+}
+
+func (r *precosPostgres) SincronizarOfertas() (ativadas, encerradas int, err error) {
+	for _, tabela := range []string{"produtos", "servicos"} {
+		var a, e int
+		err := r.db.QueryRow(`
+			WITH alterados AS (
+				UPDATE `+tabela+`
+				SET oferta = NOT oferta
+				WHERE preco_promocional IS NOT NULL AND oferta <> `+promocaoVigenteSQL+`
+				RETURNING oferta
+			)
+			SELECT COUNT(*) FILTER (WHERE oferta), COUNT(*) FILTER (WHERE NOT oferta) FROM alterados`).
+			Scan(&a, &e)
+		if err != nil {
+			return 0, 0, err
+		}
+		ativadas += a
+		encerradas += e
+	}
+	return ativadas, encerradas, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+type tokensPostgres struct{ db executor }
+
+func (r *tokensPostgres) SalvarRefresh(t *models.RefreshToken) error {
+	return r.db.QueryRow(`
+		INSERT INTO refresh_tokens (token_hash, tipo, sujeito_id, expira_em)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		t.TokenHash, t.Tipo, t.SujeitoID, t.ExpiraEm).
+		Scan(&t.ID)
+}
+
+func (r *tokensPostgres) BloquearRefresh(tokenHash string) (models.RefreshToken, error) {
+	t := models.RefreshToken{TokenHash: tokenHash}
+	var revogadoEm sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, tipo, sujeito_id, expira_em, revogado_em
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE`, tokenHash).
+		Scan(&t.ID, &t.Tipo, &t.SujeitoID, &t.ExpiraEm, &revogadoEm)
+	if revogadoEm.Valid {
+		t.RevogadoEm = &revogadoEm.Time
+	}
+	return t, naoEncontrado(err)
+}
+
+func (r *tokensPostgres) RevogarRefresh(id int) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revogado_em = NOW() WHERE id = $1 AND revogado_em IS NULL`, id)
+	return err
+}
+
+func (r *tokensPostgres) RevogarRefreshDoTitular(tokenHash, tipo string, sujeitoID int) error {
+	_, err := r.db.Exec(`
+		UPDATE refresh_tokens SET revogado_em = NOW()
+		WHERE token_hash = $1 AND tipo = $2 AND sujeito_id = $3 AND revogado_em IS NULL`,
+		tokenHash, tipo, sujeitoID)
+	return err
+}
+
+func (r *tokensPostgres) RevogarSessoes(tipo string, sujeitoID int) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revogado_em = NOW() WHERE tipo = $1 AND sujeito_id = $2 AND revogado_em IS NULL`, tipo, sujeitoID)
+	return err
+}
+
+func (r *tokensPostgres) RevogarJTI(jti string, expiraEm time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO tokens_revogados (jti, expira_em)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`, jti, expiraEm)
+	return err
+}
+
+func (r *tokensPostgres) JTIRevogado(jti string) (bool, error) {
+	var revogado bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM tokens_revogados WHERE jti = $1)`, jti).Scan(&revogado)
+	return revogado, err
+}
+
+func (r *tokensPostgres) LimparJTIsExpirados() error {
+	_, err := r.db.Exec(`DELETE FROM tokens_revogados WHERE expira_em < NOW()`)
+	return err
+}
+
+type redefinicoesPostgres struct{ db executor }
+
+func (r *redefinicoesPostgres) Criar(rs *models.RedefinicaoSenha) error {
+	if _, err := r.db.Exec(`UPDATE redefinicoes_senha SET usado_em = NOW() WHERE usuario_id = $1 AND usado_em IS NULL`, rs.UsuarioID); err != nil {
+		return err
+	}
+	return r.db.QueryRow(`
+		INSERT INTO redefinicoes_senha (usuario_id, token_hash, expira_em)
+		VALUES ($1, $2, $3)
+		RETURNING id`,
+		rs.UsuarioID, rs.TokenHash, rs.ExpiraEm).
+		Scan(&rs.ID)
+}
+
+func (r *redefinicoesPostgres) Bloquear(tokenHash string) (models.RedefinicaoSenha, error) {
+	rs := models.RedefinicaoSenha{TokenHash: tokenHash}
+	var usadoEm sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, usuario_id, expira_em, usado_em
+		FROM redefinicoes_senha
+		WHERE token_hash = $1
+		FOR UPDATE`, tokenHash).
+		Scan(&rs.ID, &rs.UsuarioID, &rs.ExpiraEm, &usadoEm)
+	if usadoEm.Valid {
+		rs.UsadoEm = &usadoEm.Time
+	}
+	return rs, naoEncontrado(err)
+}
+
+func (r *redefinicoesPostgres) MarcarUsada(id int) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE redefinicoes_senha SET usado_em = NOW() WHERE id = $1`, id))
+}
+
+type permissoesPostgres struct{ db executor }
+
+func (r *permissoesPostgres) PorPapel() (map[string][]string, error) {
+	rows, err := r.db.Query(`SELECT papel, permissao FROM papel_permissoes ORDER BY papel, permissao`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	porPapel := make(map[string][]string)
+	for rows.Next() {
+		var papel, permissao string
+		if err := rows.Scan(&papel, &permissao); err != nil {
+			return nil, err
+		}
+		porPapel[papel] = append(porPapel[papel], permissao)
+	}
+	return porPapel, rows.Err()
+}
+
+func (r *permissoesPostgres) Substituir(papel string, permissoes []string) error {
+	if _, err := r.db.Exec(`DELETE FROM papel_permissoes WHERE papel = $1`, papel); err != nil {
+		return err
+	}
+	for _, p := range permissoes {
+		_, err := r.db.Exec(`
+			INSERT INTO papel_permissoes (papel, permissao)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, papel, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
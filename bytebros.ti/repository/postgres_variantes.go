@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"bytebros.ti/models"
+	"github.com/lib/pq"
+)
+
+type variantesPostgres struct{ db executor }
+
+const colunasVariante = `id, produto_id, sku, atributos, preco, quantidade`
+
+func scanVariante(s interface{ Scan(...any) error }, v *models.ProdutoVariante) error {
+	var atributos []byte
+	var preco sql.NullFloat64
+	if err := s.Scan(&v.ID, &v.ProdutoID, &v.SKU, &atributos, &preco, &v.Quantidade); err != nil {
+		return err
+	}
+	v.Preco = nil
+	if preco.Valid {
+		v.Preco = &preco.Float64
+	}
+	return json.Unmarshal(atributos, &v.Atributos)
+}
+
+func (r *variantesPostgres) Criar(v *models.ProdutoVariante) error {
+	atributos, err := json.Marshal(v.Atributos)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRow(`
+		INSERT INTO produto_variantes (produto_id, sku, atributos, preco, quantidade)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		v.ProdutoID, v.SKU, atributos, v.Preco, v.Quantidade).
+		Scan(&v.ID)
+	if errors.Is(emUso(err), ErrEmUso) {
+		return ErrNaoEncontrado
+	}
+	return duplicado(err)
+}
+
+func (r *variantesPostgres) Listar(produtoID int) ([]models.ProdutoVariante, error) {
+	return r.listarDe(`SELECT `+colunasVariante+` FROM produto_variantes WHERE produto_id = $1 ORDER BY id`, produtoID)
+}
+
+func (r *variantesPostgres) Todas() ([]models.ProdutoVariante, error) {
+	return r.listarDe(`SELECT ` + colunasVariante + ` FROM produto_variantes ORDER BY produto_id, id`)
+}
+
+func (r *variantesPostgres) listarDe(query string, args ...any) ([]models.ProdutoVariante, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variantes := make([]models.ProdutoVariante, 0)
+	for rows.Next() {
+		var v models.ProdutoVariante
+		if err := scanVariante(rows, &v); err != nil {
+			return nil, err
+		}
+		variantes = append(variantes, v)
+	}
+	return variantes, rows.Err()
+}
+
+func (r *variantesPostgres) Obter(produtoID, id int) (models.ProdutoVariante, error) {
+	var v models.ProdutoVariante
+	err := scanVariante(r.db.QueryRow(`SELECT `+colunasVariante+` FROM produto_variantes WHERE id = $1 AND produto_id = $2`, id, produtoID), &v)
+	return v, naoEncontrado(err)
+}
+
+func (r *variantesPostgres) Atualizar(v models.ProdutoVariante) error {
+	atributos, err := json.Marshal(v.Atributos)
+	if err != nil {
+		return err
+	}
+	return duplicado(verificarAfetadas(r.db.Exec(`
+		UPDATE produto_variantes
+		SET sku = $1, atributos = $2, preco = $3, quantidade = $4
+		WHERE id = $5 AND produto_id = $6`,
+		v.SKU, atributos, v.Preco, v.Quantidade, v.ID, v.ProdutoID)))
+}
+
+func (r *variantesPostgres) Deletar(produtoID, id int) error {
+	// Variantes em pedidos são barradas pelo ON DELETE RESTRICT de pedido_itens.
+	return emUso(verificarAfetadas(r.db.Exec(`DELETE FROM produto_variantes WHERE id = $1 AND produto_id = $2`, id, produtoID)))
+}
+
+func (r *variantesPostgres) Bloquear(ids []int) (map[int]models.ProdutoVariante, error) {
+	unicos := idsUnicos(ids)
+	rows, err := r.db.Query(`
+		SELECT `+colunasVariante+`
+		FROM produto_variantes
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE`, pq.Array(unicos))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variantes := make(map[int]models.ProdutoVariante, len(unicos))
+	for rows.Next() {
+		var v models.ProdutoVariante
+		if err := scanVariante(rows, &v); err != nil {
+			return nil, err
+		}
+		variantes[v.ID] = v
+	}
+	return variantes, rows.Err()
+}
+
+func (r *variantesPostgres) AjustarEstoque(id int, delta int) error {
+	return verificarAfetadas(r.db.Exec(`UPDATE produto_variantes SET quantidade = quantidade + $1 WHERE id = $2`, delta, id))
+}
@@ -0,0 +1,405 @@
+// Package repository isola o acesso a dados dos handlers. Cada agregado tem
+// uma interface própria, com uma implementação sobre PostgreSQL e outra em
+// memória, usada para testar handlers sem banco.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"bytebros.ti/models"
+)
+
+var (
+	// ErrNaoEncontrado é devolvido quando o registro pedido não existe.
+	ErrNaoEncontrado = errors.New("registro não encontrado")
+	// ErrDuplicado é devolvido quando um valor único (como o email) já existe.
+	ErrDuplicado = errors.New("registro duplicado")
+	// ErrEmUso é devolvido ao excluir um registro do qual outros dependem.
+	ErrEmUso = errors.New("registro em uso")
+)
+
+// Repositorios agrupa os repositórios de todos os agregados.
+type Repositorios struct {
+	Produtos     ProdutoRepo
+	Categorias   CategoriaRepo
+	Imagens      ImagemRepo
+	Variantes    VarianteRepo
+	Movimentos   MovimentoRepo
+	Notificacoes NotificacaoRepo
+	Avisos       AvisoEstoqueRepo
+	Avaliacoes   AvaliacaoRepo
+	Precos       PrecoRepo
+	Servicos     ServicoRepo
+	Noticias     NoticiaRepo
+	Orcamentos   OrcamentoRepo
+	Suporte      SuporteRepo
+	Pedidos      PedidoRepo
+	Fretes       CotacaoFreteRepo
+	Pagamentos   PagamentoRepo
+	Usuarios     UsuarioRepo
+	Funcionarios FuncionarioRepo
+	Admins       AdminRepo
+	Tokens       TokenRepo
+	Redefinicoes RedefinicaoSenhaRepo
+	Permissoes   PermissaoRepo
+
+	transacao func(fn func(tx *Repositorios) error) error
+}
+
+// Transacao executa fn com repositórios que compartilham uma única
+// transação. Se fn devolver erro, nada do que ela fez é gravado.
+func (r *Repositorios) Transacao(fn func(tx *Repositorios) error) error {
+	return r.transacao(fn)
+}
+
+type FiltroProdutos struct {
+	SomenteOfertas bool
+	// CategoriaIDs limita aos produtos ligados a qualquer uma das categorias.
+	CategoriaIDs []int
+	// Componente limita aos componentes de PC do tipo (cpu, placa_mae...).
+	Componente string
+}
+
+type FiltroAvaliacoes struct {
+	ProdutoID int
+	Status    string
+}
+
+// FiltroPrecos escolhe o histórico de um produto ou de um serviço.
+type FiltroPrecos struct {
+	ProdutoID int
+	ServicoID int
+}
+
+type FiltroServicos struct {
+	SomenteOfertas bool
+}
+
+type FiltroOrcamentos struct {
+	Status string
+	Email  string
+}
+
+type FiltroSuporte struct {
+	Status        string
+	TipoInteracao string
+	ClienteEmail  string
+}
+
+type FiltroPedidos struct {
+	Status       string
+	ClienteEmail string
+}
+
+type FiltroMovimentos struct {
+	ProdutoID  int
+	VarianteID int
+	Tipo       string
+}
+
+type ProdutoRepo interface {
+	Criar(p *models.Produto) error
+	// Listar devolve a página pedida e o total de produtos do filtro.
+	Listar(filtro FiltroProdutos, pagina Pagina) ([]models.Produto, int, error)
+	Obter(id int) (models.Produto, error)
+	Atualizar(p models.Produto) error
+	Deletar(id int) error
+	// Bloquear carrega os produtos travando as linhas até o fim da
+	// transação, sempre em ordem de id. Ids inexistentes ficam de fora.
+	Bloquear(ids []int) (map[int]models.Produto, error)
+	// AjustarEstoque soma delta (negativo para baixar) à quantidade.
+	AjustarEstoque(id int, delta int) error
+	// DefinirCategorias troca as categorias do produto pelas informadas.
+	DefinirCategorias(produtoID int, categoriaIDs []int) error
+	// Percorrer chama visitar com cada produto, em ordem de id, junto das
+	// suas categorias e variantes, sem carregar o catálogo inteiro. Um erro
+	// de visitar interrompe a leitura e é devolvido.
+	Percorrer(visitar func(p models.Produto, variantes []models.ProdutoVariante) error) error
+	// Buscar faz a busca textual, com facetas calculadas sobre todos os
+	// resultados (não só a página).
+	Buscar(filtro FiltroBusca, pagina Pagina) (models.ResultadoBusca, error)
+}
+
+// FiltroBusca é o termo e os filtros de GET /api/produtos/busca.
+type FiltroBusca struct {
+	Termo string
+	// CategoriaIDs já vem expandido com as subcategorias.
+	CategoriaIDs     []int
+	PrecoMin         *float64
+	PrecoMax         *float64
+	SomenteEmEstoque bool
+	SomenteOfertas   bool
+}
+
+// LimitesFaixasPreco separam as faixas da faceta de preço: até 100, de 100
+// a 500 e assim por diante, e acima de 3000.
+var LimitesFaixasPreco = []float64{100, 500, 1000, 3000}
+
+// facetasPreco devolve as faixas de LimitesFaixasPreco com total zero.
+func facetasPreco() []models.FacetaPreco {
+	faixas := make([]models.FacetaPreco, len(LimitesFaixasPreco)+1)
+	for i, limite := range LimitesFaixasPreco {
+		faixas[i].Max = &limite
+		faixas[i+1].Min = limite
+	}
+	return faixas
+}
+
+type VarianteRepo interface {
+	// Criar devolve ErrDuplicado se o SKU já existir e ErrNaoEncontrado se
+	// o produto não existir.
+	Criar(v *models.ProdutoVariante) error
+	// Listar devolve as variantes do produto em ordem de id.
+	Listar(produtoID int) ([]models.ProdutoVariante, error)
+	// Todas devolve as variantes de todos os produtos, em ordem de produto
+	// e de id.
+	Todas() ([]models.ProdutoVariante, error)
+	Obter(produtoID, id int) (models.ProdutoVariante, error)
+	// Atualizar devolve ErrDuplicado se o novo SKU já existir.
+	Atualizar(v models.ProdutoVariante) error
+	// Deletar devolve ErrEmUso se a variante estiver em pedidos.
+	Deletar(produtoID, id int) error
+	// Bloquear carrega as variantes travando as linhas até o fim da
+	// transação. Ids inexistentes ficam fora do mapa.
+	Bloquear(ids []int) (map[int]models.ProdutoVariante, error)
+	// AjustarEstoque soma delta (negativo para baixar) à quantidade.
+	AjustarEstoque(id int, delta int) error
+}
+
+// MovimentoRepo é o livro-razão do estoque: movimentos são só inseridos.
+type MovimentoRepo interface {
+	// Registrar grava o movimento preenchendo Saldo (e SaldoVariante) com o
+	// estoque atual; deve ser chamada depois do AjustarEstoque
+	// correspondente, na mesma transação.
+	Registrar(m *models.MovimentoEstoque) error
+	Listar(filtro FiltroMovimentos, pagina Pagina) ([]models.MovimentoEstoque, int, error)
+	// Resumo consolida por produto os movimentos até ate, separando o que
+	// veio antes de desde. Datas nil não limitam.
+	Resumo(produtoID int, desde, ate *time.Time) ([]models.ResumoEstoque, error)
+	// Divergencias lista produtos e variantes cuja quantidade difere da
+	// soma dos movimentos.
+	Divergencias() ([]models.DivergenciaEstoque, error)
+}
+
+type FiltroNotificacoes struct {
+	Tipo            string
+	SomenteNaoLidas bool
+}
+
+type NotificacaoRepo interface {
+	// AbrirAlertasEstoque abre um alerta estoque_baixo para cada produto com
+	// quantidade igual ou abaixo do mínimo que ainda não tenha um aberto, e
+	// devolve os alertas criados.
+	AbrirAlertasEstoque() ([]models.NotificacaoAdmin, error)
+	// ResolverAlertasEstoque fecha os alertas abertos de produtos que
+	// voltaram a ficar acima do mínimo e devolve quantos fechou.
+	ResolverAlertasEstoque() (int, error)
+	Listar(filtro FiltroNotificacoes, pagina Pagina) ([]models.NotificacaoAdmin, int, error)
+	MarcarLida(id int, usuario string) (models.NotificacaoAdmin, error)
+}
+
+type AvisoEstoqueRepo interface {
+	// Criar devolve ErrNaoEncontrado se o produto não existir e ErrDuplicado
+	// se o email já aguarda aviso do produto.
+	Criar(a *models.AvisoEstoque) error
+	// ProdutosRepostos lista os produtos com estoque que têm avisos
+	// pendentes.
+	ProdutosRepostos() ([]int, error)
+	// Reivindicar marca como enviados e devolve os avisos pendentes do
+	// produto, se ele tiver estoque. Cabe a quem chama enviar os emails.
+	Reivindicar(produtoID int) ([]models.AvisoEstoque, error)
+	// Liberar devolve o aviso para a fila quando o envio falha.
+	Liberar(id int) error
+}
+
+type AvaliacaoRepo interface {
+	// Criar devolve ErrDuplicado se o cliente já avaliou o produto e
+	// ErrNaoEncontrado se o produto não existir.
+	Criar(a *models.Avaliacao) error
+	Listar(filtro FiltroAvaliacoes, pagina Pagina) ([]models.Avaliacao, int, error)
+	// Moderar troca o status e registra quem moderou.
+	Moderar(id int, status, usuario string) (models.Avaliacao, error)
+	// Resumos devolve média e total das avaliações aprovadas de cada
+	// produto; produtos sem avaliações ficam fora do mapa.
+	Resumos(produtoIDs []int) (map[int]models.ResumoAvaliacoes, error)
+}
+
+type PrecoRepo interface {
+	// Registrar grava uma linha no histórico de preços.
+	Registrar(h *models.HistoricoPreco) error
+	Listar(filtro FiltroPrecos, pagina Pagina) ([]models.HistoricoPreco, int, error)
+	// SincronizarOfertas liga a oferta dos produtos e serviços cuja
+	// promoção entrou em vigor e desliga a dos que saíram dela. Itens sem
+	// promoção não são tocados.
+	SincronizarOfertas() (ativadas, encerradas int, err error)
+}
+
+type CotacaoFreteRepo interface {
+	Criar(o *models.OpcaoFrete) error
+	Obter(id string) (models.OpcaoFrete, error)
+	// Usar liga a cotação ao pedido. Devolve ErrNaoEncontrado se ela não
+	// existir ou já tiver sido usada.
+	Usar(id string, pedidoID int) error
+}
+
+type PagamentoRepo interface {
+	Criar(p *models.Pagamento) error
+	// Bloquear lê a cobrança travando a linha até o fim da transação.
+	Bloquear(id int) (models.Pagamento, error)
+	ObterPorTxID(txid string) (models.Pagamento, error)
+	// BloquearPorTxID é o Bloquear do webhook Pix.
+	BloquearPorTxID(txid string) (models.Pagamento, error)
+	// BloquearPorNossoNumero é o Bloquear do retorno de boletos.
+	BloquearPorNossoNumero(nossoNumero string) (models.Pagamento, error)
+	// ProximoNossoNumero reserva o próximo número da sequência de boletos.
+	ProximoNossoNumero() (int64, error)
+	// DoPedido lista as cobranças do pedido, da mais antiga para a mais
+	// recente.
+	DoPedido(pedidoID int) ([]models.Pagamento, error)
+	// Atualizar grava status, end_to_end_id, pago_em, o que o gateway de
+	// cartão devolve (gateway_id, bandeira, final_cartao, valor_estornado)
+	// e o estorno pendente.
+	Atualizar(p *models.Pagamento) error
+	// RegistrarTransacao guarda uma chamada ao gateway de cartão.
+	RegistrarTransacao(t *models.TransacaoPagamento) error
+	// Transacoes lista as chamadas ao gateway da cobrança, em ordem.
+	Transacoes(pagamentoID int) ([]models.TransacaoPagamento, error)
+	// Vencidas devolve os ids das cobranças pendentes que expiraram antes
+	// de agora.
+	Vencidas(agora time.Time) ([]int, error)
+	// EstornosPendentes devolve as cobranças em "estorno_pendente" sem
+	// mudança desde antes.
+	EstornosPendentes(antes time.Time) ([]models.Pagamento, error)
+}
+
+type ImagemRepo interface {
+	// Criar põe a imagem depois das que o produto já tem.
+	Criar(img *models.ProdutoImagem) error
+	// Listar devolve as imagens do produto em ordem.
+	Listar(produtoID int) ([]models.ProdutoImagem, error)
+	Obter(produtoID, id int) (models.ProdutoImagem, error)
+	Deletar(produtoID, id int) error
+	// Reordenar grava a posição de cada id conforme a ordem recebida.
+	Reordenar(produtoID int, ids []int) error
+}
+
+type CategoriaRepo interface {
+	// Criar devolve ErrDuplicado se o slug já existir.
+	Criar(c *models.Categoria) error
+	// Listar devolve todas as categorias, em ordem de nome.
+	Listar() ([]models.Categoria, error)
+	Obter(id int) (models.Categoria, error)
+	ObterPorSlug(slug string) (models.Categoria, error)
+	Atualizar(c models.Categoria) error
+	// Deletar devolve ErrEmUso se a categoria tiver subcategorias.
+	Deletar(id int) error
+	// Produtos devolve, para cada categoria, os ids dos produtos ligados
+	// diretamente a ela.
+	Produtos() (map[int][]int, error)
+}
+
+type ServicoRepo interface {
+	Criar(s *models.Servico) error
+	Listar(filtro FiltroServicos, pagina Pagina) ([]models.Servico, int, error)
+	Obter(id int) (models.Servico, error)
+	Atualizar(s models.Servico) error
+	Deletar(id int) error
+}
+
+type NoticiaRepo interface {
+	Criar(n *models.Noticia) error
+	Listar(pagina Pagina) ([]models.Noticia, int, error)
+	Obter(id int) (models.Noticia, error)
+	Atualizar(n models.Noticia) error
+	Deletar(id int) error
+}
+
+type OrcamentoRepo interface {
+	Criar(o *models.Orcamento) error
+	Listar(filtro FiltroOrcamentos, pagina Pagina) ([]models.Orcamento, int, error)
+	Obter(id int) (models.Orcamento, error)
+	AtualizarStatus(id int, status string) error
+	Deletar(id int) error
+}
+
+type SuporteRepo interface {
+	Criar(s *models.Suporte) error
+	Listar(filtro FiltroSuporte, pagina Pagina) ([]models.Suporte, int, error)
+	Obter(id int) (models.Suporte, error)
+	AtualizarStatus(id int, status string) error
+	Deletar(id int) error
+}
+
+type PedidoRepo interface {
+	// Criar grava o pedido e os seus itens, preenchendo os ids.
+	Criar(p *models.Pedido) error
+	// Listar devolve a página pedida, com os itens de cada pedido.
+	Listar(filtro FiltroPedidos, pagina Pagina) ([]models.Pedido, int, error)
+	// Obter devolve o pedido com os itens.
+	Obter(id int) (models.Pedido, error)
+	// Bloquear lê o pedido (sem itens) travando a linha até o fim da transação.
+	Bloquear(id int) (models.Pedido, error)
+	Itens(pedidoID int) ([]models.PedidoItem, error)
+	AtualizarStatus(id int, status string) error
+	// ClienteRecebeu informa se o cliente tem um pedido entregue com o produto.
+	ClienteRecebeu(email string, produtoID int) (bool, error)
+	RegistrarHistorico(h *models.PedidoStatusHistorico) error
+	Historico(pedidoID int) ([]models.PedidoStatusHistorico, error)
+}
+
+type UsuarioRepo interface {
+	// Criar grava o usuário usando SenhaHash.
+	Criar(u *models.Usuario) error
+	Obter(id int) (models.Usuario, error)
+	ObterPorEmail(email string) (models.Usuario, error)
+	// EmailEmUso informa se outro usuário, diferente de excetoID, usa o email.
+	EmailEmUso(email string, excetoID int) (bool, error)
+	AtualizarEmail(id int, email string) error
+	AtualizarTelefone(id int, telefone string) error
+	AtualizarSenha(id int, senhaHash string) error
+	// Listar filtra por trecho do email ou telefone, sem diferenciar maiúsculas.
+	Listar(busca string, pagina Pagina) ([]models.Usuario, int, error)
+}
+
+type FuncionarioRepo interface {
+	Criar(f *models.Funcionario) error
+	Obter(id int) (models.Funcionario, error)
+	ObterPorEmail(email string) (models.Funcionario, error)
+	Listar(pagina Pagina) ([]models.Funcionario, int, error)
+}
+
+type AdminRepo interface {
+	Criar(a *models.Administrador) error
+	Obter(id int) (models.Administrador, error)
+	ObterPorEmail(email string) (models.Administrador, error)
+	Deletar(id int) error
+}
+
+type TokenRepo interface {
+	SalvarRefresh(t *models.RefreshToken) error
+	// BloquearRefresh busca o refresh token pelo hash travando a linha.
+	BloquearRefresh(tokenHash string) (models.RefreshToken, error)
+	RevogarRefresh(id int) error
+	// RevogarRefreshDoTitular revoga o token informado se ele pertencer ao titular.
+	RevogarRefreshDoTitular(tokenHash, tipo string, sujeitoID int) error
+	// RevogarSessoes revoga todos os refresh tokens ativos do titular.
+	RevogarSessoes(tipo string, sujeitoID int) error
+	RevogarJTI(jti string, expiraEm time.Time) error
+	JTIRevogado(jti string) (bool, error)
+	LimparJTIsExpirados() error
+}
+
+type RedefinicaoSenhaRepo interface {
+	// Criar invalida os pedidos anteriores do usuário e grava o novo.
+	Criar(r *models.RedefinicaoSenha) error
+	Bloquear(tokenHash string) (models.RedefinicaoSenha, error)
+	MarcarUsada(id int) error
+}
+
+type PermissaoRepo interface {
+	// PorPapel devolve as permissões de cada papel, em ordem alfabética.
+	PorPapel() (map[string][]string, error)
+	// Substituir troca todas as permissões do papel pelas informadas.
+	Substituir(papel string, permissoes []string) error
+}
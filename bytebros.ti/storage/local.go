@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage grava os arquivos em Dir. A API serve esse diretório em
+// Prefixo (por exemplo "/uploads"), que também compõe as URLs devolvidas.
+type LocalStorage struct {
+	Dir     string
+	Prefixo string
+}
+
+func (s *LocalStorage) Salvar(_ context.Context, chave string, conteudo []byte, _ string) (string, error) {
+	if !chaveValida(chave) {
+		return "", fmt.Errorf("chave de arquivo inválida: %q", chave)
+	}
+	caminho := filepath.Join(s.Dir, filepath.FromSlash(chave))
+	if err := os.MkdirAll(filepath.Dir(caminho), 0o755); err != nil {
+		return "", fmt.Errorf("erro ao criar diretório de uploads: %w", err)
+	}
+	if err := os.WriteFile(caminho, conteudo, 0o644); err != nil {
+		return "", fmt.Errorf("erro ao gravar %s: %w", caminho, err)
+	}
+	return s.Prefixo + "/" + chave, nil
+}
+
+func (s *LocalStorage) Remover(_ context.Context, chave string) error {
+	if !chaveValida(chave) {
+		return fmt.Errorf("chave de arquivo inválida: %q", chave)
+	}
+	err := os.Remove(filepath.Join(s.Dir, filepath.FromSlash(chave)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
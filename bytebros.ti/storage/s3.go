@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage grava num bucket compatível com S3 (AWS, MinIO, R2...) usando
+// a API REST com assinatura AWS Signature Version 4.
+type S3Storage struct {
+	// Endpoint é a URL base do serviço, como "https://s3.sa-east-1.amazonaws.com"
+	// ou "http://localhost:9000" para um MinIO local.
+	Endpoint  string
+	Regiao    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// URLPublica, se definida, substitui o endpoint nas URLs devolvidas
+	// (por exemplo o domínio de uma CDN na frente do bucket).
+	URLPublica string
+	// EstiloCaminho usa endpoint/bucket/chave em vez de bucket.endpoint/chave;
+	// é o que MinIO e stubs locais costumam exigir.
+	EstiloCaminho bool
+
+	// Cliente permite trocar o http.Client; nil usa um com timeout de 30s.
+	Cliente *http.Client
+}
+
+func (s *S3Storage) Salvar(ctx context.Context, chave string, conteudo []byte, contentType string) (string, error) {
+	if !chaveValida(chave) {
+		return "", fmt.Errorf("chave de arquivo inválida: %q", chave)
+	}
+	endereco, err := s.urlObjeto(chave)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endereco, bytes.NewReader(conteudo))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	if err := s.enviar(req, conteudo, http.StatusOK); err != nil {
+		return "", fmt.Errorf("erro ao enviar %s para o S3: %w", chave, err)
+	}
+
+	if s.URLPublica != "" {
+		return s.URLPublica + "/" + escaparChave(chave), nil
+	}
+	return endereco, nil
+}
+
+func (s *S3Storage) Remover(ctx context.Context, chave string) error {
+	if !chaveValida(chave) {
+		return fmt.Errorf("chave de arquivo inválida: %q", chave)
+	}
+	endereco, err := s.urlObjeto(chave)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endereco, nil)
+	if err != nil {
+		return err
+	}
+	// O S3 responde 204 mesmo se o objeto não existir.
+	if err := s.enviar(req, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound); err != nil {
+		return fmt.Errorf("erro ao remover %s do S3: %w", chave, err)
+	}
+	return nil
+}
+
+// urlObjeto monta a URL do objeto no estilo de caminho ou de host virtual.
+func (s *S3Storage) urlObjeto(chave string) (string, error) {
+	base, err := url.Parse(s.Endpoint)
+	if err != nil || base.Host == "" {
+		return "", fmt.Errorf("S3_ENDPOINT inválido: %q", s.Endpoint)
+	}
+	if s.EstiloCaminho {
+		return base.Scheme + "://" + base.Host + "/" + s.Bucket + "/" + escaparChave(chave), nil
+	}
+	return base.Scheme + "://" + s.Bucket + "." + base.Host + "/" + escaparChave(chave), nil
+}
+
+func (s *S3Storage) enviar(req *http.Request, corpo []byte, aceitos ...int) error {
+	s.assinar(req, corpo, time.Now().UTC())
+
+	cliente := s.Cliente
+	if cliente == nil {
+		cliente = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for _, status := range aceitos {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	detalhe, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(detalhe)))
+}
+
+// assinar aplica a AWS Signature Version 4 sobre todos os cabeçalhos da
+// requisição mais host, x-amz-date e x-amz-content-sha256.
+func (s *S3Storage) assinar(req *http.Request, corpo []byte, agora time.Time) {
+	dataHora := agora.Format("20060102T150405Z")
+	data := agora.Format("20060102")
+	hashCorpo := sha256Hex(corpo)
+	req.Header.Set("X-Amz-Date", dataHora)
+	req.Header.Set("X-Amz-Content-Sha256", hashCorpo)
+
+	cabecalhos := map[string]string{"host": req.URL.Host}
+	for nome, valores := range req.Header {
+		cabecalhos[strings.ToLower(nome)] = strings.TrimSpace(strings.Join(valores, ","))
+	}
+	nomes := make([]string, 0, len(cabecalhos))
+	for nome := range cabecalhos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	var canonicos strings.Builder
+	for _, nome := range nomes {
+		canonicos.WriteString(nome + ":" + cabecalhos[nome] + "\n")
+	}
+	assinados := strings.Join(nomes, ";")
+
+	requisicaoCanonica := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicos.String(),
+		assinados,
+		hashCorpo,
+	}, "\n")
+
+	escopo := data + "/" + s.Regiao + "/s3/aws4_request"
+	textoAssinado := "AWS4-HMAC-SHA256\n" + dataHora + "\n" + escopo + "\n" + sha256Hex([]byte(requisicaoCanonica))
+
+	chave := hmacSHA256([]byte("AWS4"+s.SecretKey), data)
+	chave = hmacSHA256(chave, s.Regiao)
+	chave = hmacSHA256(chave, "s3")
+	chave = hmacSHA256(chave, "aws4_request")
+	assinatura := hex.EncodeToString(hmacSHA256(chave, textoAssinado))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+escopo+
+		", SignedHeaders="+assinados+", Signature="+assinatura)
+}
+
+// escaparChave codifica a chave como o S3 espera na assinatura: tudo fora
+// de A-Z, a-z, 0-9, "-", "_", ".", "~" e "/" vira %XX.
+func escaparChave(chave string) string {
+	var b strings.Builder
+	for _, c := range []byte(chave) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	soma := sha256.Sum256(b)
+	return hex.EncodeToString(soma[:])
+}
+
+func hmacSHA256(chave []byte, texto string) []byte {
+	h := hmac.New(sha256.New, chave)
+	h.Write([]byte(texto))
+	return h.Sum(nil)
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Storage guarda arquivos públicos, como as imagens de produtos. A
+// implementação é escolhida na inicialização: disco local em
+// desenvolvimento, um bucket compatível com S3 em produção.
+type Storage interface {
+	// Salvar grava o conteúdo sob a chave (por exemplo
+	// "produtos/12/ab34/media.jpg") e devolve a URL pública do arquivo.
+	Salvar(ctx context.Context, chave string, conteudo []byte, contentType string) (string, error)
+	// Remover apaga o arquivo; chaves inexistentes não são erro.
+	Remover(ctx context.Context, chave string) error
+}
+
+// NovoDoAmbiente monta o Storage a partir das variáveis de ambiente.
+//
+// STORAGE_DRIVER=s3 usa S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY,
+// S3_SECRET_KEY, S3_PUBLIC_URL e S3_PATH_STYLE.
+// Qualquer outro valor (ou vazio) grava em STORAGE_DIR, servido pela própria
+// API em STORAGE_URL_PREFIX.
+func NovoDoAmbiente() (Storage, error) {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		s := &S3Storage{
+			Endpoint:      strings.TrimSuffix(os.Getenv("S3_ENDPOINT"), "/"),
+			Regiao:        os.Getenv("S3_REGION"),
+			Bucket:        os.Getenv("S3_BUCKET"),
+			AccessKey:     os.Getenv("S3_ACCESS_KEY"),
+			SecretKey:     os.Getenv("S3_SECRET_KEY"),
+			URLPublica:    strings.TrimSuffix(os.Getenv("S3_PUBLIC_URL"), "/"),
+			EstiloCaminho: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		if s.Regiao == "" {
+			s.Regiao = "us-east-1"
+		}
+		if s.Endpoint == "" {
+			s.Endpoint = "https://s3." + s.Regiao + ".amazonaws.com"
+		}
+		if s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+			return nil, fmt.Errorf("S3_BUCKET, S3_ACCESS_KEY e S3_SECRET_KEY são obrigatórios com STORAGE_DRIVER=s3")
+		}
+		return s, nil
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		prefixo := os.Getenv("STORAGE_URL_PREFIX")
+		if prefixo == "" {
+			prefixo = "/uploads"
+		}
+		return &LocalStorage{Dir: dir, Prefixo: "/" + strings.Trim(prefixo, "/")}, nil
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER inválido: %s", os.Getenv("STORAGE_DRIVER"))
+	}
+}
+
+// chaveValida recusa chaves vazias, absolutas ou com "..", que poderiam
+// escapar do diretório ou do bucket.
+func chaveValida(chave string) bool {
+	if chave == "" || strings.HasPrefix(chave, "/") || strings.Contains(chave, "\\") {
+		return false
+	}
+	for _, parte := range strings.Split(chave, "/") {
+		if parte == "" || parte == "." || parte == ".." {
+			return false
+		}
+	}
+	return true
+}